@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"auth/internal/config"
+	"auth/internal/repository/pg"
+	"auth/internal/services/importer"
+	"auth/pkg/logger"
+	"auth/pkg/storage/postgres"
+)
+
+func main() {
+	var (
+		filePath string
+		format   string
+		dryRun   bool
+	)
+	flag.StringVar(&filePath, "file", "", "path to CSV or JSONL file with users")
+	flag.StringVar(&format, "format", "", "input format: csv or jsonl (default: from file extension)")
+	flag.BoolVar(&dryRun, "dry-run", false, "validate the file without writing to the database")
+
+	cfg := config.MustLoad()
+
+	if filePath == "" {
+		panic("file is required")
+	}
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), ".")
+	}
+
+	log := logger.SetupLogger(cfg.Env)
+
+	db, err := postgres.New(cfg.Postgres)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	imp := importer.New(log, pg.NewUserRepository(db))
+
+	report, err := imp.Import(context.Background(), f, format, dryRun)
+	for _, rowErr := range report.Errors {
+		fmt.Fprintln(os.Stderr, rowErr.Error())
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	prefix := ""
+	if dryRun {
+		prefix = "dry run: "
+	}
+	fmt.Printf("%screated %d, skipped %d, failed %d\n", prefix, report.Created, report.Skipped, len(report.Errors))
+
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+}
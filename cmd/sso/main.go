@@ -20,12 +20,15 @@ func main() {
 	go func() {
 		application.GRPCServer.MustRun()
 	}()
+	go func() {
+		application.HTTPServer.MustRun()
+	}()
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
 
 	<-stop
 
-	application.GRPCServer.Stop() // Assuming GRPCServer has Stop() method for graceful shutdown
+	application.Stop()
 	log.Info("Gracefully stopped")
 }
@@ -0,0 +1,870 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: sso/apps.proto
+
+package ssov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type App struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name         string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	RedirectUris []string               `protobuf:"bytes,3,rep,name=redirect_uris,json=redirectUris,proto3" json:"redirect_uris,omitempty"`
+	GrantTypes   []string               `protobuf:"bytes,4,rep,name=grant_types,json=grantTypes,proto3" json:"grant_types,omitempty"`
+	// token_claims are the profile attributes put in the app's access tokens, such as "name"
+	// or "locale".
+	TokenClaims           []string             `protobuf:"bytes,5,rep,name=token_claims,json=tokenClaims,proto3" json:"token_claims,omitempty"`
+	AccessTtl             *durationpb.Duration `protobuf:"bytes,6,opt,name=access_ttl,json=accessTtl,proto3" json:"access_ttl,omitempty"`
+	RefreshTtl            *durationpb.Duration `protobuf:"bytes,7,opt,name=refresh_ttl,json=refreshTtl,proto3" json:"refresh_ttl,omitempty"`
+	Enabled               bool                 `protobuf:"varint,8,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	RefreshIdleTimeout    *durationpb.Duration `protobuf:"bytes,9,opt,name=refresh_idle_timeout,json=refreshIdleTimeout,proto3" json:"refresh_idle_timeout,omitempty"`
+	MaxSessions           int32                `protobuf:"varint,10,opt,name=max_sessions,json=maxSessions,proto3" json:"max_sessions,omitempty"`
+	InviteOnly            bool                 `protobuf:"varint,11,opt,name=invite_only,json=inviteOnly,proto3" json:"invite_only,omitempty"`
+	BackchannelLogoutUri  string               `protobuf:"bytes,12,opt,name=backchannel_logout_uri,json=backchannelLogoutUri,proto3" json:"backchannel_logout_uri,omitempty"`
+	FrontchannelLogoutUri string               `protobuf:"bytes,13,opt,name=frontchannel_logout_uri,json=frontchannelLogoutUri,proto3" json:"frontchannel_logout_uri,omitempty"`
+	// id_token_claims are the profile attributes put in the ID tokens of SSO.ExchangeCode.
+	IdTokenClaims []string `protobuf:"bytes,14,rep,name=id_token_claims,json=idTokenClaims,proto3" json:"id_token_claims,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *App) Reset() {
+	*x = App{}
+	mi := &file_sso_apps_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *App) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*App) ProtoMessage() {}
+
+func (x *App) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use App.ProtoReflect.Descriptor instead.
+func (*App) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *App) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *App) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *App) GetRedirectUris() []string {
+	if x != nil {
+		return x.RedirectUris
+	}
+	return nil
+}
+
+func (x *App) GetGrantTypes() []string {
+	if x != nil {
+		return x.GrantTypes
+	}
+	return nil
+}
+
+func (x *App) GetTokenClaims() []string {
+	if x != nil {
+		return x.TokenClaims
+	}
+	return nil
+}
+
+func (x *App) GetAccessTtl() *durationpb.Duration {
+	if x != nil {
+		return x.AccessTtl
+	}
+	return nil
+}
+
+func (x *App) GetRefreshTtl() *durationpb.Duration {
+	if x != nil {
+		return x.RefreshTtl
+	}
+	return nil
+}
+
+func (x *App) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *App) GetRefreshIdleTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.RefreshIdleTimeout
+	}
+	return nil
+}
+
+func (x *App) GetMaxSessions() int32 {
+	if x != nil {
+		return x.MaxSessions
+	}
+	return 0
+}
+
+func (x *App) GetInviteOnly() bool {
+	if x != nil {
+		return x.InviteOnly
+	}
+	return false
+}
+
+func (x *App) GetBackchannelLogoutUri() string {
+	if x != nil {
+		return x.BackchannelLogoutUri
+	}
+	return ""
+}
+
+func (x *App) GetFrontchannelLogoutUri() string {
+	if x != nil {
+		return x.FrontchannelLogoutUri
+	}
+	return ""
+}
+
+func (x *App) GetIdTokenClaims() []string {
+	if x != nil {
+		return x.IdTokenClaims
+	}
+	return nil
+}
+
+type CreateAppRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Name         string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	RedirectUris []string               `protobuf:"bytes,2,rep,name=redirect_uris,json=redirectUris,proto3" json:"redirect_uris,omitempty"`
+	GrantTypes   []string               `protobuf:"bytes,3,rep,name=grant_types,json=grantTypes,proto3" json:"grant_types,omitempty"`
+	TokenClaims  []string               `protobuf:"bytes,4,rep,name=token_claims,json=tokenClaims,proto3" json:"token_claims,omitempty"`
+	AccessTtl    *durationpb.Duration   `protobuf:"bytes,5,opt,name=access_ttl,json=accessTtl,proto3" json:"access_ttl,omitempty"`
+	RefreshTtl   *durationpb.Duration   `protobuf:"bytes,6,opt,name=refresh_ttl,json=refreshTtl,proto3" json:"refresh_ttl,omitempty"`
+	// enabled defaults to true.
+	Enabled               *bool                `protobuf:"varint,7,opt,name=enabled,proto3,oneof" json:"enabled,omitempty"`
+	RefreshIdleTimeout    *durationpb.Duration `protobuf:"bytes,8,opt,name=refresh_idle_timeout,json=refreshIdleTimeout,proto3" json:"refresh_idle_timeout,omitempty"`
+	MaxSessions           int32                `protobuf:"varint,9,opt,name=max_sessions,json=maxSessions,proto3" json:"max_sessions,omitempty"`
+	InviteOnly            bool                 `protobuf:"varint,10,opt,name=invite_only,json=inviteOnly,proto3" json:"invite_only,omitempty"`
+	BackchannelLogoutUri  string               `protobuf:"bytes,11,opt,name=backchannel_logout_uri,json=backchannelLogoutUri,proto3" json:"backchannel_logout_uri,omitempty"`
+	FrontchannelLogoutUri string               `protobuf:"bytes,12,opt,name=frontchannel_logout_uri,json=frontchannelLogoutUri,proto3" json:"frontchannel_logout_uri,omitempty"`
+	IdTokenClaims         []string             `protobuf:"bytes,13,rep,name=id_token_claims,json=idTokenClaims,proto3" json:"id_token_claims,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *CreateAppRequest) Reset() {
+	*x = CreateAppRequest{}
+	mi := &file_sso_apps_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAppRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAppRequest) ProtoMessage() {}
+
+func (x *CreateAppRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAppRequest.ProtoReflect.Descriptor instead.
+func (*CreateAppRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateAppRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateAppRequest) GetRedirectUris() []string {
+	if x != nil {
+		return x.RedirectUris
+	}
+	return nil
+}
+
+func (x *CreateAppRequest) GetGrantTypes() []string {
+	if x != nil {
+		return x.GrantTypes
+	}
+	return nil
+}
+
+func (x *CreateAppRequest) GetTokenClaims() []string {
+	if x != nil {
+		return x.TokenClaims
+	}
+	return nil
+}
+
+func (x *CreateAppRequest) GetAccessTtl() *durationpb.Duration {
+	if x != nil {
+		return x.AccessTtl
+	}
+	return nil
+}
+
+func (x *CreateAppRequest) GetRefreshTtl() *durationpb.Duration {
+	if x != nil {
+		return x.RefreshTtl
+	}
+	return nil
+}
+
+func (x *CreateAppRequest) GetEnabled() bool {
+	if x != nil && x.Enabled != nil {
+		return *x.Enabled
+	}
+	return false
+}
+
+func (x *CreateAppRequest) GetRefreshIdleTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.RefreshIdleTimeout
+	}
+	return nil
+}
+
+func (x *CreateAppRequest) GetMaxSessions() int32 {
+	if x != nil {
+		return x.MaxSessions
+	}
+	return 0
+}
+
+func (x *CreateAppRequest) GetInviteOnly() bool {
+	if x != nil {
+		return x.InviteOnly
+	}
+	return false
+}
+
+func (x *CreateAppRequest) GetBackchannelLogoutUri() string {
+	if x != nil {
+		return x.BackchannelLogoutUri
+	}
+	return ""
+}
+
+func (x *CreateAppRequest) GetFrontchannelLogoutUri() string {
+	if x != nil {
+		return x.FrontchannelLogoutUri
+	}
+	return ""
+}
+
+func (x *CreateAppRequest) GetIdTokenClaims() []string {
+	if x != nil {
+		return x.IdTokenClaims
+	}
+	return nil
+}
+
+type CreateAppResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	App           *App                   `protobuf:"bytes,1,opt,name=app,proto3" json:"app,omitempty"`
+	AccessSecret  string                 `protobuf:"bytes,2,opt,name=access_secret,json=accessSecret,proto3" json:"access_secret,omitempty"`
+	RefreshSecret string                 `protobuf:"bytes,3,opt,name=refresh_secret,json=refreshSecret,proto3" json:"refresh_secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAppResponse) Reset() {
+	*x = CreateAppResponse{}
+	mi := &file_sso_apps_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAppResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAppResponse) ProtoMessage() {}
+
+func (x *CreateAppResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAppResponse.ProtoReflect.Descriptor instead.
+func (*CreateAppResponse) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateAppResponse) GetApp() *App {
+	if x != nil {
+		return x.App
+	}
+	return nil
+}
+
+func (x *CreateAppResponse) GetAccessSecret() string {
+	if x != nil {
+		return x.AccessSecret
+	}
+	return ""
+}
+
+func (x *CreateAppResponse) GetRefreshSecret() string {
+	if x != nil {
+		return x.RefreshSecret
+	}
+	return ""
+}
+
+type GetAppRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int32                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAppRequest) Reset() {
+	*x = GetAppRequest{}
+	mi := &file_sso_apps_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAppRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAppRequest) ProtoMessage() {}
+
+func (x *GetAppRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAppRequest.ProtoReflect.Descriptor instead.
+func (*GetAppRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetAppRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+type ListAppsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAppsRequest) Reset() {
+	*x = ListAppsRequest{}
+	mi := &file_sso_apps_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAppsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAppsRequest) ProtoMessage() {}
+
+func (x *ListAppsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAppsRequest.ProtoReflect.Descriptor instead.
+func (*ListAppsRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{4}
+}
+
+type ListAppsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Apps          []*App                 `protobuf:"bytes,1,rep,name=apps,proto3" json:"apps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAppsResponse) Reset() {
+	*x = ListAppsResponse{}
+	mi := &file_sso_apps_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAppsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAppsResponse) ProtoMessage() {}
+
+func (x *ListAppsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAppsResponse.ProtoReflect.Descriptor instead.
+func (*ListAppsResponse) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListAppsResponse) GetApps() []*App {
+	if x != nil {
+		return x.Apps
+	}
+	return nil
+}
+
+type UpdateAppRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int32                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	App           *App                   `protobuf:"bytes,2,opt,name=app,proto3" json:"app,omitempty"`
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,3,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateAppRequest) Reset() {
+	*x = UpdateAppRequest{}
+	mi := &file_sso_apps_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAppRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAppRequest) ProtoMessage() {}
+
+func (x *UpdateAppRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAppRequest.ProtoReflect.Descriptor instead.
+func (*UpdateAppRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdateAppRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *UpdateAppRequest) GetApp() *App {
+	if x != nil {
+		return x.App
+	}
+	return nil
+}
+
+func (x *UpdateAppRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type DeleteAppRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int32                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAppRequest) Reset() {
+	*x = DeleteAppRequest{}
+	mi := &file_sso_apps_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAppRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAppRequest) ProtoMessage() {}
+
+func (x *DeleteAppRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAppRequest.ProtoReflect.Descriptor instead.
+func (*DeleteAppRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteAppRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+type RotateAppSecretRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	AppId int32                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	// kind is "access" or "refresh".
+	Kind          string               `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	GracePeriod   *durationpb.Duration `protobuf:"bytes,3,opt,name=grace_period,json=gracePeriod,proto3" json:"grace_period,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateAppSecretRequest) Reset() {
+	*x = RotateAppSecretRequest{}
+	mi := &file_sso_apps_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateAppSecretRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateAppSecretRequest) ProtoMessage() {}
+
+func (x *RotateAppSecretRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateAppSecretRequest.ProtoReflect.Descriptor instead.
+func (*RotateAppSecretRequest) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RotateAppSecretRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *RotateAppSecretRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *RotateAppSecretRequest) GetGracePeriod() *durationpb.Duration {
+	if x != nil {
+		return x.GracePeriod
+	}
+	return nil
+}
+
+type RotateAppSecretResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Secret        string                 `protobuf:"bytes,1,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateAppSecretResponse) Reset() {
+	*x = RotateAppSecretResponse{}
+	mi := &file_sso_apps_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateAppSecretResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateAppSecretResponse) ProtoMessage() {}
+
+func (x *RotateAppSecretResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_apps_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateAppSecretResponse.ProtoReflect.Descriptor instead.
+func (*RotateAppSecretResponse) Descriptor() ([]byte, []int) {
+	return file_sso_apps_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RotateAppSecretResponse) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+var File_sso_apps_proto protoreflect.FileDescriptor
+
+const file_sso_apps_proto_rawDesc = "" +
+	"\n" +
+	"\x0esso/apps.proto\x12\x04auth\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a google/protobuf/field_mask.proto\"\xc9\x04\n" +
+	"\x03App\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12#\n" +
+	"\rredirect_uris\x18\x03 \x03(\tR\fredirectUris\x12\x1f\n" +
+	"\vgrant_types\x18\x04 \x03(\tR\n" +
+	"grantTypes\x12!\n" +
+	"\ftoken_claims\x18\x05 \x03(\tR\vtokenClaims\x128\n" +
+	"\n" +
+	"access_ttl\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\taccessTtl\x12:\n" +
+	"\vrefresh_ttl\x18\a \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"refreshTtl\x12\x18\n" +
+	"\aenabled\x18\b \x01(\bR\aenabled\x12K\n" +
+	"\x14refresh_idle_timeout\x18\t \x01(\v2\x19.google.protobuf.DurationR\x12refreshIdleTimeout\x12!\n" +
+	"\fmax_sessions\x18\n" +
+	" \x01(\x05R\vmaxSessions\x12\x1f\n" +
+	"\vinvite_only\x18\v \x01(\bR\n" +
+	"inviteOnly\x124\n" +
+	"\x16backchannel_logout_uri\x18\f \x01(\tR\x14backchannelLogoutUri\x126\n" +
+	"\x17frontchannel_logout_uri\x18\r \x01(\tR\x15frontchannelLogoutUri\x12&\n" +
+	"\x0fid_token_claims\x18\x0e \x03(\tR\ridTokenClaims\"\xd7\x04\n" +
+	"\x10CreateAppRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
+	"\rredirect_uris\x18\x02 \x03(\tR\fredirectUris\x12\x1f\n" +
+	"\vgrant_types\x18\x03 \x03(\tR\n" +
+	"grantTypes\x12!\n" +
+	"\ftoken_claims\x18\x04 \x03(\tR\vtokenClaims\x128\n" +
+	"\n" +
+	"access_ttl\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\taccessTtl\x12:\n" +
+	"\vrefresh_ttl\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"refreshTtl\x12\x1d\n" +
+	"\aenabled\x18\a \x01(\bH\x00R\aenabled\x88\x01\x01\x12K\n" +
+	"\x14refresh_idle_timeout\x18\b \x01(\v2\x19.google.protobuf.DurationR\x12refreshIdleTimeout\x12!\n" +
+	"\fmax_sessions\x18\t \x01(\x05R\vmaxSessions\x12\x1f\n" +
+	"\vinvite_only\x18\n" +
+	" \x01(\bR\n" +
+	"inviteOnly\x124\n" +
+	"\x16backchannel_logout_uri\x18\v \x01(\tR\x14backchannelLogoutUri\x126\n" +
+	"\x17frontchannel_logout_uri\x18\f \x01(\tR\x15frontchannelLogoutUri\x12&\n" +
+	"\x0fid_token_claims\x18\r \x03(\tR\ridTokenClaimsB\n" +
+	"\n" +
+	"\b_enabled\"|\n" +
+	"\x11CreateAppResponse\x12\x1b\n" +
+	"\x03app\x18\x01 \x01(\v2\t.auth.AppR\x03app\x12#\n" +
+	"\raccess_secret\x18\x02 \x01(\tR\faccessSecret\x12%\n" +
+	"\x0erefresh_secret\x18\x03 \x01(\tR\rrefreshSecret\"&\n" +
+	"\rGetAppRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x05R\x05appId\"\x11\n" +
+	"\x0fListAppsRequest\"1\n" +
+	"\x10ListAppsResponse\x12\x1d\n" +
+	"\x04apps\x18\x01 \x03(\v2\t.auth.AppR\x04apps\"\x83\x01\n" +
+	"\x10UpdateAppRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x05R\x05appId\x12\x1b\n" +
+	"\x03app\x18\x02 \x01(\v2\t.auth.AppR\x03app\x12;\n" +
+	"\vupdate_mask\x18\x03 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\")\n" +
+	"\x10DeleteAppRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x05R\x05appId\"\x81\x01\n" +
+	"\x16RotateAppSecretRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x05R\x05appId\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12<\n" +
+	"\fgrace_period\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\vgracePeriod\"1\n" +
+	"\x17RotateAppSecretResponse\x12\x16\n" +
+	"\x06secret\x18\x01 \x01(\tR\x06secret2\xe6\x02\n" +
+	"\x04Apps\x12<\n" +
+	"\tCreateApp\x12\x16.auth.CreateAppRequest\x1a\x17.auth.CreateAppResponse\x12(\n" +
+	"\x06GetApp\x12\x13.auth.GetAppRequest\x1a\t.auth.App\x129\n" +
+	"\bListApps\x12\x15.auth.ListAppsRequest\x1a\x16.auth.ListAppsResponse\x12.\n" +
+	"\tUpdateApp\x12\x16.auth.UpdateAppRequest\x1a\t.auth.App\x12;\n" +
+	"\tDeleteApp\x12\x16.auth.DeleteAppRequest\x1a\x16.google.protobuf.Empty\x12N\n" +
+	"\x0fRotateAppSecret\x12\x1c.auth.RotateAppSecretRequest\x1a\x1d.auth.RotateAppSecretResponseB\x17Z\x15auth/gen/go/sso;ssov1b\x06proto3"
+
+var (
+	file_sso_apps_proto_rawDescOnce sync.Once
+	file_sso_apps_proto_rawDescData []byte
+)
+
+func file_sso_apps_proto_rawDescGZIP() []byte {
+	file_sso_apps_proto_rawDescOnce.Do(func() {
+		file_sso_apps_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sso_apps_proto_rawDesc), len(file_sso_apps_proto_rawDesc)))
+	})
+	return file_sso_apps_proto_rawDescData
+}
+
+var file_sso_apps_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_sso_apps_proto_goTypes = []any{
+	(*App)(nil),                     // 0: auth.App
+	(*CreateAppRequest)(nil),        // 1: auth.CreateAppRequest
+	(*CreateAppResponse)(nil),       // 2: auth.CreateAppResponse
+	(*GetAppRequest)(nil),           // 3: auth.GetAppRequest
+	(*ListAppsRequest)(nil),         // 4: auth.ListAppsRequest
+	(*ListAppsResponse)(nil),        // 5: auth.ListAppsResponse
+	(*UpdateAppRequest)(nil),        // 6: auth.UpdateAppRequest
+	(*DeleteAppRequest)(nil),        // 7: auth.DeleteAppRequest
+	(*RotateAppSecretRequest)(nil),  // 8: auth.RotateAppSecretRequest
+	(*RotateAppSecretResponse)(nil), // 9: auth.RotateAppSecretResponse
+	(*durationpb.Duration)(nil),     // 10: google.protobuf.Duration
+	(*fieldmaskpb.FieldMask)(nil),   // 11: google.protobuf.FieldMask
+	(*emptypb.Empty)(nil),           // 12: google.protobuf.Empty
+}
+var file_sso_apps_proto_depIdxs = []int32{
+	10, // 0: auth.App.access_ttl:type_name -> google.protobuf.Duration
+	10, // 1: auth.App.refresh_ttl:type_name -> google.protobuf.Duration
+	10, // 2: auth.App.refresh_idle_timeout:type_name -> google.protobuf.Duration
+	10, // 3: auth.CreateAppRequest.access_ttl:type_name -> google.protobuf.Duration
+	10, // 4: auth.CreateAppRequest.refresh_ttl:type_name -> google.protobuf.Duration
+	10, // 5: auth.CreateAppRequest.refresh_idle_timeout:type_name -> google.protobuf.Duration
+	0,  // 6: auth.CreateAppResponse.app:type_name -> auth.App
+	0,  // 7: auth.ListAppsResponse.apps:type_name -> auth.App
+	0,  // 8: auth.UpdateAppRequest.app:type_name -> auth.App
+	11, // 9: auth.UpdateAppRequest.update_mask:type_name -> google.protobuf.FieldMask
+	10, // 10: auth.RotateAppSecretRequest.grace_period:type_name -> google.protobuf.Duration
+	1,  // 11: auth.Apps.CreateApp:input_type -> auth.CreateAppRequest
+	3,  // 12: auth.Apps.GetApp:input_type -> auth.GetAppRequest
+	4,  // 13: auth.Apps.ListApps:input_type -> auth.ListAppsRequest
+	6,  // 14: auth.Apps.UpdateApp:input_type -> auth.UpdateAppRequest
+	7,  // 15: auth.Apps.DeleteApp:input_type -> auth.DeleteAppRequest
+	8,  // 16: auth.Apps.RotateAppSecret:input_type -> auth.RotateAppSecretRequest
+	2,  // 17: auth.Apps.CreateApp:output_type -> auth.CreateAppResponse
+	0,  // 18: auth.Apps.GetApp:output_type -> auth.App
+	5,  // 19: auth.Apps.ListApps:output_type -> auth.ListAppsResponse
+	0,  // 20: auth.Apps.UpdateApp:output_type -> auth.App
+	12, // 21: auth.Apps.DeleteApp:output_type -> google.protobuf.Empty
+	9,  // 22: auth.Apps.RotateAppSecret:output_type -> auth.RotateAppSecretResponse
+	17, // [17:23] is the sub-list for method output_type
+	11, // [11:17] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_sso_apps_proto_init() }
+func file_sso_apps_proto_init() {
+	if File_sso_apps_proto != nil {
+		return
+	}
+	file_sso_apps_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sso_apps_proto_rawDesc), len(file_sso_apps_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sso_apps_proto_goTypes,
+		DependencyIndexes: file_sso_apps_proto_depIdxs,
+		MessageInfos:      file_sso_apps_proto_msgTypes,
+	}.Build()
+	File_sso_apps_proto = out.File
+	file_sso_apps_proto_goTypes = nil
+	file_sso_apps_proto_depIdxs = nil
+}
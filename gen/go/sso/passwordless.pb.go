@@ -0,0 +1,275 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: sso/passwordless.proto
+
+package ssov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StartPasswordlessRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Email string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	AppId int32                  `protobuf:"varint,2,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	OrgId int64                  `protobuf:"varint,3,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	// method is "link" or "code".
+	Method        string `protobuf:"bytes,4,opt,name=method,proto3" json:"method,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartPasswordlessRequest) Reset() {
+	*x = StartPasswordlessRequest{}
+	mi := &file_sso_passwordless_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartPasswordlessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartPasswordlessRequest) ProtoMessage() {}
+
+func (x *StartPasswordlessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_passwordless_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartPasswordlessRequest.ProtoReflect.Descriptor instead.
+func (*StartPasswordlessRequest) Descriptor() ([]byte, []int) {
+	return file_sso_passwordless_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartPasswordlessRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *StartPasswordlessRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *StartPasswordlessRequest) GetOrgId() int64 {
+	if x != nil {
+		return x.OrgId
+	}
+	return 0
+}
+
+func (x *StartPasswordlessRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+type StartPasswordlessResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChallengeId   string                 `protobuf:"bytes,1,opt,name=challenge_id,json=challengeId,proto3" json:"challenge_id,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartPasswordlessResponse) Reset() {
+	*x = StartPasswordlessResponse{}
+	mi := &file_sso_passwordless_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartPasswordlessResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartPasswordlessResponse) ProtoMessage() {}
+
+func (x *StartPasswordlessResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_passwordless_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartPasswordlessResponse.ProtoReflect.Descriptor instead.
+func (*StartPasswordlessResponse) Descriptor() ([]byte, []int) {
+	return file_sso_passwordless_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StartPasswordlessResponse) GetChallengeId() string {
+	if x != nil {
+		return x.ChallengeId
+	}
+	return ""
+}
+
+func (x *StartPasswordlessResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type CompletePasswordlessRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChallengeId   string                 `protobuf:"bytes,1,opt,name=challenge_id,json=challengeId,proto3" json:"challenge_id,omitempty"`
+	Secret        string                 `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompletePasswordlessRequest) Reset() {
+	*x = CompletePasswordlessRequest{}
+	mi := &file_sso_passwordless_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompletePasswordlessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompletePasswordlessRequest) ProtoMessage() {}
+
+func (x *CompletePasswordlessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_passwordless_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompletePasswordlessRequest.ProtoReflect.Descriptor instead.
+func (*CompletePasswordlessRequest) Descriptor() ([]byte, []int) {
+	return file_sso_passwordless_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CompletePasswordlessRequest) GetChallengeId() string {
+	if x != nil {
+		return x.ChallengeId
+	}
+	return ""
+}
+
+func (x *CompletePasswordlessRequest) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+var File_sso_passwordless_proto protoreflect.FileDescriptor
+
+const file_sso_passwordless_proto_rawDesc = "" +
+	"\n" +
+	"\x16sso/passwordless.proto\x12\x04auth\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x0esso/auth.proto\"v\n" +
+	"\x18StartPasswordlessRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x15\n" +
+	"\x06app_id\x18\x02 \x01(\x05R\x05appId\x12\x15\n" +
+	"\x06org_id\x18\x03 \x01(\x03R\x05orgId\x12\x16\n" +
+	"\x06method\x18\x04 \x01(\tR\x06method\"y\n" +
+	"\x19StartPasswordlessResponse\x12!\n" +
+	"\fchallenge_id\x18\x01 \x01(\tR\vchallengeId\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"X\n" +
+	"\x1bCompletePasswordlessRequest\x12!\n" +
+	"\fchallenge_id\x18\x01 \x01(\tR\vchallengeId\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret2\xb8\x01\n" +
+	"\fPasswordless\x12T\n" +
+	"\x11StartPasswordless\x12\x1e.auth.StartPasswordlessRequest\x1a\x1f.auth.StartPasswordlessResponse\x12R\n" +
+	"\x14CompletePasswordless\x12!.auth.CompletePasswordlessRequest\x1a\x17.auth.TokenPairResponseB\x17Z\x15auth/gen/go/sso;ssov1b\x06proto3"
+
+var (
+	file_sso_passwordless_proto_rawDescOnce sync.Once
+	file_sso_passwordless_proto_rawDescData []byte
+)
+
+func file_sso_passwordless_proto_rawDescGZIP() []byte {
+	file_sso_passwordless_proto_rawDescOnce.Do(func() {
+		file_sso_passwordless_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sso_passwordless_proto_rawDesc), len(file_sso_passwordless_proto_rawDesc)))
+	})
+	return file_sso_passwordless_proto_rawDescData
+}
+
+var file_sso_passwordless_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_sso_passwordless_proto_goTypes = []any{
+	(*StartPasswordlessRequest)(nil),    // 0: auth.StartPasswordlessRequest
+	(*StartPasswordlessResponse)(nil),   // 1: auth.StartPasswordlessResponse
+	(*CompletePasswordlessRequest)(nil), // 2: auth.CompletePasswordlessRequest
+	(*timestamppb.Timestamp)(nil),       // 3: google.protobuf.Timestamp
+	(*TokenPairResponse)(nil),           // 4: auth.TokenPairResponse
+}
+var file_sso_passwordless_proto_depIdxs = []int32{
+	3, // 0: auth.StartPasswordlessResponse.expires_at:type_name -> google.protobuf.Timestamp
+	0, // 1: auth.Passwordless.StartPasswordless:input_type -> auth.StartPasswordlessRequest
+	2, // 2: auth.Passwordless.CompletePasswordless:input_type -> auth.CompletePasswordlessRequest
+	1, // 3: auth.Passwordless.StartPasswordless:output_type -> auth.StartPasswordlessResponse
+	4, // 4: auth.Passwordless.CompletePasswordless:output_type -> auth.TokenPairResponse
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_sso_passwordless_proto_init() }
+func file_sso_passwordless_proto_init() {
+	if File_sso_passwordless_proto != nil {
+		return
+	}
+	file_sso_auth_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sso_passwordless_proto_rawDesc), len(file_sso_passwordless_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sso_passwordless_proto_goTypes,
+		DependencyIndexes: file_sso_passwordless_proto_depIdxs,
+		MessageInfos:      file_sso_passwordless_proto_msgTypes,
+	}.Build()
+	File_sso_passwordless_proto = out.File
+	file_sso_passwordless_proto_goTypes = nil
+	file_sso_passwordless_proto_depIdxs = nil
+}
@@ -0,0 +1,163 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: sso/passwordless.proto
+
+package ssov1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Passwordless_StartPasswordless_FullMethodName    = "/auth.Passwordless/StartPasswordless"
+	Passwordless_CompletePasswordless_FullMethodName = "/auth.Passwordless/CompletePasswordless"
+)
+
+// PasswordlessClient is the client API for Passwordless service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Passwordless signs users in with magic links and one-time codes sent by email.
+type PasswordlessClient interface {
+	StartPasswordless(ctx context.Context, in *StartPasswordlessRequest, opts ...grpc.CallOption) (*StartPasswordlessResponse, error)
+	CompletePasswordless(ctx context.Context, in *CompletePasswordlessRequest, opts ...grpc.CallOption) (*TokenPairResponse, error)
+}
+
+type passwordlessClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPasswordlessClient(cc grpc.ClientConnInterface) PasswordlessClient {
+	return &passwordlessClient{cc}
+}
+
+func (c *passwordlessClient) StartPasswordless(ctx context.Context, in *StartPasswordlessRequest, opts ...grpc.CallOption) (*StartPasswordlessResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartPasswordlessResponse)
+	err := c.cc.Invoke(ctx, Passwordless_StartPasswordless_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *passwordlessClient) CompletePasswordless(ctx context.Context, in *CompletePasswordlessRequest, opts ...grpc.CallOption) (*TokenPairResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TokenPairResponse)
+	err := c.cc.Invoke(ctx, Passwordless_CompletePasswordless_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PasswordlessServer is the server API for Passwordless service.
+// All implementations must embed UnimplementedPasswordlessServer
+// for forward compatibility.
+//
+// Passwordless signs users in with magic links and one-time codes sent by email.
+type PasswordlessServer interface {
+	StartPasswordless(context.Context, *StartPasswordlessRequest) (*StartPasswordlessResponse, error)
+	CompletePasswordless(context.Context, *CompletePasswordlessRequest) (*TokenPairResponse, error)
+	mustEmbedUnimplementedPasswordlessServer()
+}
+
+// UnimplementedPasswordlessServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPasswordlessServer struct{}
+
+func (UnimplementedPasswordlessServer) StartPasswordless(context.Context, *StartPasswordlessRequest) (*StartPasswordlessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartPasswordless not implemented")
+}
+func (UnimplementedPasswordlessServer) CompletePasswordless(context.Context, *CompletePasswordlessRequest) (*TokenPairResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompletePasswordless not implemented")
+}
+func (UnimplementedPasswordlessServer) mustEmbedUnimplementedPasswordlessServer() {}
+func (UnimplementedPasswordlessServer) testEmbeddedByValue()                      {}
+
+// UnsafePasswordlessServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PasswordlessServer will
+// result in compilation errors.
+type UnsafePasswordlessServer interface {
+	mustEmbedUnimplementedPasswordlessServer()
+}
+
+func RegisterPasswordlessServer(s grpc.ServiceRegistrar, srv PasswordlessServer) {
+	// If the following call pancis, it indicates UnimplementedPasswordlessServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Passwordless_ServiceDesc, srv)
+}
+
+func _Passwordless_StartPasswordless_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartPasswordlessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordlessServer).StartPasswordless(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Passwordless_StartPasswordless_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordlessServer).StartPasswordless(ctx, req.(*StartPasswordlessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Passwordless_CompletePasswordless_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompletePasswordlessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordlessServer).CompletePasswordless(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Passwordless_CompletePasswordless_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordlessServer).CompletePasswordless(ctx, req.(*CompletePasswordlessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Passwordless_ServiceDesc is the grpc.ServiceDesc for Passwordless service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Passwordless_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "auth.Passwordless",
+	HandlerType: (*PasswordlessServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StartPasswordless",
+			Handler:    _Passwordless_StartPasswordless_Handler,
+		},
+		{
+			MethodName: "CompletePasswordless",
+			Handler:    _Passwordless_CompletePasswordless_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "sso/passwordless.proto",
+}
@@ -0,0 +1,530 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: sso/phone.proto
+
+package ssov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StartPhoneLoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Phone         string                 `protobuf:"bytes,1,opt,name=phone,proto3" json:"phone,omitempty"`
+	AppId         int32                  `protobuf:"varint,2,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	OrgId         int64                  `protobuf:"varint,3,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartPhoneLoginRequest) Reset() {
+	*x = StartPhoneLoginRequest{}
+	mi := &file_sso_phone_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartPhoneLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartPhoneLoginRequest) ProtoMessage() {}
+
+func (x *StartPhoneLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_phone_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartPhoneLoginRequest.ProtoReflect.Descriptor instead.
+func (*StartPhoneLoginRequest) Descriptor() ([]byte, []int) {
+	return file_sso_phone_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartPhoneLoginRequest) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *StartPhoneLoginRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *StartPhoneLoginRequest) GetOrgId() int64 {
+	if x != nil {
+		return x.OrgId
+	}
+	return 0
+}
+
+type PhoneChallengeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChallengeId   string                 `protobuf:"bytes,1,opt,name=challenge_id,json=challengeId,proto3" json:"challenge_id,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PhoneChallengeResponse) Reset() {
+	*x = PhoneChallengeResponse{}
+	mi := &file_sso_phone_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PhoneChallengeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PhoneChallengeResponse) ProtoMessage() {}
+
+func (x *PhoneChallengeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_phone_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PhoneChallengeResponse.ProtoReflect.Descriptor instead.
+func (*PhoneChallengeResponse) Descriptor() ([]byte, []int) {
+	return file_sso_phone_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PhoneChallengeResponse) GetChallengeId() string {
+	if x != nil {
+		return x.ChallengeId
+	}
+	return ""
+}
+
+func (x *PhoneChallengeResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type CompletePhoneLoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChallengeId   string                 `protobuf:"bytes,1,opt,name=challenge_id,json=challengeId,proto3" json:"challenge_id,omitempty"`
+	Code          string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompletePhoneLoginRequest) Reset() {
+	*x = CompletePhoneLoginRequest{}
+	mi := &file_sso_phone_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompletePhoneLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompletePhoneLoginRequest) ProtoMessage() {}
+
+func (x *CompletePhoneLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_phone_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompletePhoneLoginRequest.ProtoReflect.Descriptor instead.
+func (*CompletePhoneLoginRequest) Descriptor() ([]byte, []int) {
+	return file_sso_phone_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CompletePhoneLoginRequest) GetChallengeId() string {
+	if x != nil {
+		return x.ChallengeId
+	}
+	return ""
+}
+
+func (x *CompletePhoneLoginRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type StartPhoneVerificationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Phone         string                 `protobuf:"bytes,1,opt,name=phone,proto3" json:"phone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartPhoneVerificationRequest) Reset() {
+	*x = StartPhoneVerificationRequest{}
+	mi := &file_sso_phone_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartPhoneVerificationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartPhoneVerificationRequest) ProtoMessage() {}
+
+func (x *StartPhoneVerificationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_phone_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartPhoneVerificationRequest.ProtoReflect.Descriptor instead.
+func (*StartPhoneVerificationRequest) Descriptor() ([]byte, []int) {
+	return file_sso_phone_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StartPhoneVerificationRequest) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+type ConfirmPhoneVerificationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChallengeId   string                 `protobuf:"bytes,1,opt,name=challenge_id,json=challengeId,proto3" json:"challenge_id,omitempty"`
+	Code          string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfirmPhoneVerificationRequest) Reset() {
+	*x = ConfirmPhoneVerificationRequest{}
+	mi := &file_sso_phone_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmPhoneVerificationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmPhoneVerificationRequest) ProtoMessage() {}
+
+func (x *ConfirmPhoneVerificationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_phone_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmPhoneVerificationRequest.ProtoReflect.Descriptor instead.
+func (*ConfirmPhoneVerificationRequest) Descriptor() ([]byte, []int) {
+	return file_sso_phone_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ConfirmPhoneVerificationRequest) GetChallengeId() string {
+	if x != nil {
+		return x.ChallengeId
+	}
+	return ""
+}
+
+func (x *ConfirmPhoneVerificationRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type ConfirmPhoneVerificationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Phone         string                 `protobuf:"bytes,1,opt,name=phone,proto3" json:"phone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfirmPhoneVerificationResponse) Reset() {
+	*x = ConfirmPhoneVerificationResponse{}
+	mi := &file_sso_phone_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmPhoneVerificationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmPhoneVerificationResponse) ProtoMessage() {}
+
+func (x *ConfirmPhoneVerificationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_phone_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmPhoneVerificationResponse.ProtoReflect.Descriptor instead.
+func (*ConfirmPhoneVerificationResponse) Descriptor() ([]byte, []int) {
+	return file_sso_phone_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ConfirmPhoneVerificationResponse) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+type StartPhoneStepUpRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartPhoneStepUpRequest) Reset() {
+	*x = StartPhoneStepUpRequest{}
+	mi := &file_sso_phone_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartPhoneStepUpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartPhoneStepUpRequest) ProtoMessage() {}
+
+func (x *StartPhoneStepUpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_phone_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartPhoneStepUpRequest.ProtoReflect.Descriptor instead.
+func (*StartPhoneStepUpRequest) Descriptor() ([]byte, []int) {
+	return file_sso_phone_proto_rawDescGZIP(), []int{6}
+}
+
+type CompletePhoneStepUpRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RefreshToken  string                 `protobuf:"bytes,1,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	ChallengeId   string                 `protobuf:"bytes,2,opt,name=challenge_id,json=challengeId,proto3" json:"challenge_id,omitempty"`
+	Code          string                 `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompletePhoneStepUpRequest) Reset() {
+	*x = CompletePhoneStepUpRequest{}
+	mi := &file_sso_phone_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompletePhoneStepUpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompletePhoneStepUpRequest) ProtoMessage() {}
+
+func (x *CompletePhoneStepUpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_phone_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompletePhoneStepUpRequest.ProtoReflect.Descriptor instead.
+func (*CompletePhoneStepUpRequest) Descriptor() ([]byte, []int) {
+	return file_sso_phone_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CompletePhoneStepUpRequest) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+func (x *CompletePhoneStepUpRequest) GetChallengeId() string {
+	if x != nil {
+		return x.ChallengeId
+	}
+	return ""
+}
+
+func (x *CompletePhoneStepUpRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+var File_sso_phone_proto protoreflect.FileDescriptor
+
+const file_sso_phone_proto_rawDesc = "" +
+	"\n" +
+	"\x0fsso/phone.proto\x12\x04auth\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x0esso/auth.proto\"\\\n" +
+	"\x16StartPhoneLoginRequest\x12\x14\n" +
+	"\x05phone\x18\x01 \x01(\tR\x05phone\x12\x15\n" +
+	"\x06app_id\x18\x02 \x01(\x05R\x05appId\x12\x15\n" +
+	"\x06org_id\x18\x03 \x01(\x03R\x05orgId\"v\n" +
+	"\x16PhoneChallengeResponse\x12!\n" +
+	"\fchallenge_id\x18\x01 \x01(\tR\vchallengeId\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"R\n" +
+	"\x19CompletePhoneLoginRequest\x12!\n" +
+	"\fchallenge_id\x18\x01 \x01(\tR\vchallengeId\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\"5\n" +
+	"\x1dStartPhoneVerificationRequest\x12\x14\n" +
+	"\x05phone\x18\x01 \x01(\tR\x05phone\"X\n" +
+	"\x1fConfirmPhoneVerificationRequest\x12!\n" +
+	"\fchallenge_id\x18\x01 \x01(\tR\vchallengeId\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\"8\n" +
+	" ConfirmPhoneVerificationResponse\x12\x14\n" +
+	"\x05phone\x18\x01 \x01(\tR\x05phone\"\x19\n" +
+	"\x17StartPhoneStepUpRequest\"x\n" +
+	"\x1aCompletePhoneStepUpRequest\x12#\n" +
+	"\rrefresh_token\x18\x01 \x01(\tR\frefreshToken\x12!\n" +
+	"\fchallenge_id\x18\x02 \x01(\tR\vchallengeId\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code2\x87\x04\n" +
+	"\x05Phone\x12M\n" +
+	"\x0fStartPhoneLogin\x12\x1c.auth.StartPhoneLoginRequest\x1a\x1c.auth.PhoneChallengeResponse\x12N\n" +
+	"\x12CompletePhoneLogin\x12\x1f.auth.CompletePhoneLoginRequest\x1a\x17.auth.TokenPairResponse\x12[\n" +
+	"\x16StartPhoneVerification\x12#.auth.StartPhoneVerificationRequest\x1a\x1c.auth.PhoneChallengeResponse\x12i\n" +
+	"\x18ConfirmPhoneVerification\x12%.auth.ConfirmPhoneVerificationRequest\x1a&.auth.ConfirmPhoneVerificationResponse\x12J\n" +
+	"\vStartStepUp\x12\x1d.auth.StartPhoneStepUpRequest\x1a\x1c.auth.PhoneChallengeResponse\x12K\n" +
+	"\x0eCompleteStepUp\x12 .auth.CompletePhoneStepUpRequest\x1a\x17.auth.TokenPairResponseB\x17Z\x15auth/gen/go/sso;ssov1b\x06proto3"
+
+var (
+	file_sso_phone_proto_rawDescOnce sync.Once
+	file_sso_phone_proto_rawDescData []byte
+)
+
+func file_sso_phone_proto_rawDescGZIP() []byte {
+	file_sso_phone_proto_rawDescOnce.Do(func() {
+		file_sso_phone_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sso_phone_proto_rawDesc), len(file_sso_phone_proto_rawDesc)))
+	})
+	return file_sso_phone_proto_rawDescData
+}
+
+var file_sso_phone_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_sso_phone_proto_goTypes = []any{
+	(*StartPhoneLoginRequest)(nil),           // 0: auth.StartPhoneLoginRequest
+	(*PhoneChallengeResponse)(nil),           // 1: auth.PhoneChallengeResponse
+	(*CompletePhoneLoginRequest)(nil),        // 2: auth.CompletePhoneLoginRequest
+	(*StartPhoneVerificationRequest)(nil),    // 3: auth.StartPhoneVerificationRequest
+	(*ConfirmPhoneVerificationRequest)(nil),  // 4: auth.ConfirmPhoneVerificationRequest
+	(*ConfirmPhoneVerificationResponse)(nil), // 5: auth.ConfirmPhoneVerificationResponse
+	(*StartPhoneStepUpRequest)(nil),          // 6: auth.StartPhoneStepUpRequest
+	(*CompletePhoneStepUpRequest)(nil),       // 7: auth.CompletePhoneStepUpRequest
+	(*timestamppb.Timestamp)(nil),            // 8: google.protobuf.Timestamp
+	(*TokenPairResponse)(nil),                // 9: auth.TokenPairResponse
+}
+var file_sso_phone_proto_depIdxs = []int32{
+	8, // 0: auth.PhoneChallengeResponse.expires_at:type_name -> google.protobuf.Timestamp
+	0, // 1: auth.Phone.StartPhoneLogin:input_type -> auth.StartPhoneLoginRequest
+	2, // 2: auth.Phone.CompletePhoneLogin:input_type -> auth.CompletePhoneLoginRequest
+	3, // 3: auth.Phone.StartPhoneVerification:input_type -> auth.StartPhoneVerificationRequest
+	4, // 4: auth.Phone.ConfirmPhoneVerification:input_type -> auth.ConfirmPhoneVerificationRequest
+	6, // 5: auth.Phone.StartStepUp:input_type -> auth.StartPhoneStepUpRequest
+	7, // 6: auth.Phone.CompleteStepUp:input_type -> auth.CompletePhoneStepUpRequest
+	1, // 7: auth.Phone.StartPhoneLogin:output_type -> auth.PhoneChallengeResponse
+	9, // 8: auth.Phone.CompletePhoneLogin:output_type -> auth.TokenPairResponse
+	1, // 9: auth.Phone.StartPhoneVerification:output_type -> auth.PhoneChallengeResponse
+	5, // 10: auth.Phone.ConfirmPhoneVerification:output_type -> auth.ConfirmPhoneVerificationResponse
+	1, // 11: auth.Phone.StartStepUp:output_type -> auth.PhoneChallengeResponse
+	9, // 12: auth.Phone.CompleteStepUp:output_type -> auth.TokenPairResponse
+	7, // [7:13] is the sub-list for method output_type
+	1, // [1:7] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_sso_phone_proto_init() }
+func file_sso_phone_proto_init() {
+	if File_sso_phone_proto != nil {
+		return
+	}
+	file_sso_auth_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sso_phone_proto_rawDesc), len(file_sso_phone_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sso_phone_proto_goTypes,
+		DependencyIndexes: file_sso_phone_proto_depIdxs,
+		MessageInfos:      file_sso_phone_proto_msgTypes,
+	}.Build()
+	File_sso_phone_proto = out.File
+	file_sso_phone_proto_goTypes = nil
+	file_sso_phone_proto_depIdxs = nil
+}
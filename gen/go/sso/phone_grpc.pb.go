@@ -0,0 +1,315 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: sso/phone.proto
+
+package ssov1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Phone_StartPhoneLogin_FullMethodName          = "/auth.Phone/StartPhoneLogin"
+	Phone_CompletePhoneLogin_FullMethodName       = "/auth.Phone/CompletePhoneLogin"
+	Phone_StartPhoneVerification_FullMethodName   = "/auth.Phone/StartPhoneVerification"
+	Phone_ConfirmPhoneVerification_FullMethodName = "/auth.Phone/ConfirmPhoneVerification"
+	Phone_StartStepUp_FullMethodName              = "/auth.Phone/StartStepUp"
+	Phone_CompleteStepUp_FullMethodName           = "/auth.Phone/CompleteStepUp"
+)
+
+// PhoneClient is the client API for Phone service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Phone signs users in, verifies phone numbers and steps sessions up with codes sent by SMS.
+type PhoneClient interface {
+	StartPhoneLogin(ctx context.Context, in *StartPhoneLoginRequest, opts ...grpc.CallOption) (*PhoneChallengeResponse, error)
+	CompletePhoneLogin(ctx context.Context, in *CompletePhoneLoginRequest, opts ...grpc.CallOption) (*TokenPairResponse, error)
+	StartPhoneVerification(ctx context.Context, in *StartPhoneVerificationRequest, opts ...grpc.CallOption) (*PhoneChallengeResponse, error)
+	ConfirmPhoneVerification(ctx context.Context, in *ConfirmPhoneVerificationRequest, opts ...grpc.CallOption) (*ConfirmPhoneVerificationResponse, error)
+	StartStepUp(ctx context.Context, in *StartPhoneStepUpRequest, opts ...grpc.CallOption) (*PhoneChallengeResponse, error)
+	CompleteStepUp(ctx context.Context, in *CompletePhoneStepUpRequest, opts ...grpc.CallOption) (*TokenPairResponse, error)
+}
+
+type phoneClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPhoneClient(cc grpc.ClientConnInterface) PhoneClient {
+	return &phoneClient{cc}
+}
+
+func (c *phoneClient) StartPhoneLogin(ctx context.Context, in *StartPhoneLoginRequest, opts ...grpc.CallOption) (*PhoneChallengeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PhoneChallengeResponse)
+	err := c.cc.Invoke(ctx, Phone_StartPhoneLogin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *phoneClient) CompletePhoneLogin(ctx context.Context, in *CompletePhoneLoginRequest, opts ...grpc.CallOption) (*TokenPairResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TokenPairResponse)
+	err := c.cc.Invoke(ctx, Phone_CompletePhoneLogin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *phoneClient) StartPhoneVerification(ctx context.Context, in *StartPhoneVerificationRequest, opts ...grpc.CallOption) (*PhoneChallengeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PhoneChallengeResponse)
+	err := c.cc.Invoke(ctx, Phone_StartPhoneVerification_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *phoneClient) ConfirmPhoneVerification(ctx context.Context, in *ConfirmPhoneVerificationRequest, opts ...grpc.CallOption) (*ConfirmPhoneVerificationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConfirmPhoneVerificationResponse)
+	err := c.cc.Invoke(ctx, Phone_ConfirmPhoneVerification_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *phoneClient) StartStepUp(ctx context.Context, in *StartPhoneStepUpRequest, opts ...grpc.CallOption) (*PhoneChallengeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PhoneChallengeResponse)
+	err := c.cc.Invoke(ctx, Phone_StartStepUp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *phoneClient) CompleteStepUp(ctx context.Context, in *CompletePhoneStepUpRequest, opts ...grpc.CallOption) (*TokenPairResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TokenPairResponse)
+	err := c.cc.Invoke(ctx, Phone_CompleteStepUp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PhoneServer is the server API for Phone service.
+// All implementations must embed UnimplementedPhoneServer
+// for forward compatibility.
+//
+// Phone signs users in, verifies phone numbers and steps sessions up with codes sent by SMS.
+type PhoneServer interface {
+	StartPhoneLogin(context.Context, *StartPhoneLoginRequest) (*PhoneChallengeResponse, error)
+	CompletePhoneLogin(context.Context, *CompletePhoneLoginRequest) (*TokenPairResponse, error)
+	StartPhoneVerification(context.Context, *StartPhoneVerificationRequest) (*PhoneChallengeResponse, error)
+	ConfirmPhoneVerification(context.Context, *ConfirmPhoneVerificationRequest) (*ConfirmPhoneVerificationResponse, error)
+	StartStepUp(context.Context, *StartPhoneStepUpRequest) (*PhoneChallengeResponse, error)
+	CompleteStepUp(context.Context, *CompletePhoneStepUpRequest) (*TokenPairResponse, error)
+	mustEmbedUnimplementedPhoneServer()
+}
+
+// UnimplementedPhoneServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPhoneServer struct{}
+
+func (UnimplementedPhoneServer) StartPhoneLogin(context.Context, *StartPhoneLoginRequest) (*PhoneChallengeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartPhoneLogin not implemented")
+}
+func (UnimplementedPhoneServer) CompletePhoneLogin(context.Context, *CompletePhoneLoginRequest) (*TokenPairResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompletePhoneLogin not implemented")
+}
+func (UnimplementedPhoneServer) StartPhoneVerification(context.Context, *StartPhoneVerificationRequest) (*PhoneChallengeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartPhoneVerification not implemented")
+}
+func (UnimplementedPhoneServer) ConfirmPhoneVerification(context.Context, *ConfirmPhoneVerificationRequest) (*ConfirmPhoneVerificationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmPhoneVerification not implemented")
+}
+func (UnimplementedPhoneServer) StartStepUp(context.Context, *StartPhoneStepUpRequest) (*PhoneChallengeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartStepUp not implemented")
+}
+func (UnimplementedPhoneServer) CompleteStepUp(context.Context, *CompletePhoneStepUpRequest) (*TokenPairResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteStepUp not implemented")
+}
+func (UnimplementedPhoneServer) mustEmbedUnimplementedPhoneServer() {}
+func (UnimplementedPhoneServer) testEmbeddedByValue()               {}
+
+// UnsafePhoneServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PhoneServer will
+// result in compilation errors.
+type UnsafePhoneServer interface {
+	mustEmbedUnimplementedPhoneServer()
+}
+
+func RegisterPhoneServer(s grpc.ServiceRegistrar, srv PhoneServer) {
+	// If the following call pancis, it indicates UnimplementedPhoneServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Phone_ServiceDesc, srv)
+}
+
+func _Phone_StartPhoneLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartPhoneLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PhoneServer).StartPhoneLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Phone_StartPhoneLogin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PhoneServer).StartPhoneLogin(ctx, req.(*StartPhoneLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Phone_CompletePhoneLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompletePhoneLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PhoneServer).CompletePhoneLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Phone_CompletePhoneLogin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PhoneServer).CompletePhoneLogin(ctx, req.(*CompletePhoneLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Phone_StartPhoneVerification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartPhoneVerificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PhoneServer).StartPhoneVerification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Phone_StartPhoneVerification_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PhoneServer).StartPhoneVerification(ctx, req.(*StartPhoneVerificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Phone_ConfirmPhoneVerification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmPhoneVerificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PhoneServer).ConfirmPhoneVerification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Phone_ConfirmPhoneVerification_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PhoneServer).ConfirmPhoneVerification(ctx, req.(*ConfirmPhoneVerificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Phone_StartStepUp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartPhoneStepUpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PhoneServer).StartStepUp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Phone_StartStepUp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PhoneServer).StartStepUp(ctx, req.(*StartPhoneStepUpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Phone_CompleteStepUp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompletePhoneStepUpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PhoneServer).CompleteStepUp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Phone_CompleteStepUp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PhoneServer).CompleteStepUp(ctx, req.(*CompletePhoneStepUpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Phone_ServiceDesc is the grpc.ServiceDesc for Phone service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Phone_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "auth.Phone",
+	HandlerType: (*PhoneServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StartPhoneLogin",
+			Handler:    _Phone_StartPhoneLogin_Handler,
+		},
+		{
+			MethodName: "CompletePhoneLogin",
+			Handler:    _Phone_CompletePhoneLogin_Handler,
+		},
+		{
+			MethodName: "StartPhoneVerification",
+			Handler:    _Phone_StartPhoneVerification_Handler,
+		},
+		{
+			MethodName: "ConfirmPhoneVerification",
+			Handler:    _Phone_ConfirmPhoneVerification_Handler,
+		},
+		{
+			MethodName: "StartStepUp",
+			Handler:    _Phone_StartStepUp_Handler,
+		},
+		{
+			MethodName: "CompleteStepUp",
+			Handler:    _Phone_CompleteStepUp_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "sso/phone.proto",
+}
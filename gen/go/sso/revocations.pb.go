@@ -0,0 +1,251 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: sso/revocations.proto
+
+package ssov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Revocation struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Offset           string                 `protobuf:"bytes,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Kind             string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	UserId           int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AppId            int32                  `protobuf:"varint,4,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	ServiceAccountId int64                  `protobuf:"varint,5,opt,name=service_account_id,json=serviceAccountId,proto3" json:"service_account_id,omitempty"`
+	SessionId        string                 `protobuf:"bytes,6,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	TokenId          string                 `protobuf:"bytes,7,opt,name=token_id,json=tokenId,proto3" json:"token_id,omitempty"`
+	KeyId            string                 `protobuf:"bytes,8,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	RevokedAt        *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=revoked_at,json=revokedAt,proto3" json:"revoked_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Revocation) Reset() {
+	*x = Revocation{}
+	mi := &file_sso_revocations_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Revocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Revocation) ProtoMessage() {}
+
+func (x *Revocation) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_revocations_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Revocation.ProtoReflect.Descriptor instead.
+func (*Revocation) Descriptor() ([]byte, []int) {
+	return file_sso_revocations_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Revocation) GetOffset() string {
+	if x != nil {
+		return x.Offset
+	}
+	return ""
+}
+
+func (x *Revocation) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Revocation) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Revocation) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *Revocation) GetServiceAccountId() int64 {
+	if x != nil {
+		return x.ServiceAccountId
+	}
+	return 0
+}
+
+func (x *Revocation) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *Revocation) GetTokenId() string {
+	if x != nil {
+		return x.TokenId
+	}
+	return ""
+}
+
+func (x *Revocation) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *Revocation) GetRevokedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RevokedAt
+	}
+	return nil
+}
+
+type WatchRevocationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        string                 `protobuf:"bytes,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRevocationsRequest) Reset() {
+	*x = WatchRevocationsRequest{}
+	mi := &file_sso_revocations_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRevocationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRevocationsRequest) ProtoMessage() {}
+
+func (x *WatchRevocationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_revocations_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRevocationsRequest.ProtoReflect.Descriptor instead.
+func (*WatchRevocationsRequest) Descriptor() ([]byte, []int) {
+	return file_sso_revocations_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WatchRevocationsRequest) GetOffset() string {
+	if x != nil {
+		return x.Offset
+	}
+	return ""
+}
+
+var File_sso_revocations_proto protoreflect.FileDescriptor
+
+const file_sso_revocations_proto_rawDesc = "" +
+	"\n" +
+	"\x15sso/revocations.proto\x12\x04auth\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa2\x02\n" +
+	"\n" +
+	"Revocation\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\tR\x06offset\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x03R\x06userId\x12\x15\n" +
+	"\x06app_id\x18\x04 \x01(\x05R\x05appId\x12,\n" +
+	"\x12service_account_id\x18\x05 \x01(\x03R\x10serviceAccountId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x06 \x01(\tR\tsessionId\x12\x19\n" +
+	"\btoken_id\x18\a \x01(\tR\atokenId\x12\x15\n" +
+	"\x06key_id\x18\b \x01(\tR\x05keyId\x129\n" +
+	"\n" +
+	"revoked_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\trevokedAt\"1\n" +
+	"\x17WatchRevocationsRequest\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\tR\x06offset2T\n" +
+	"\vRevocations\x12E\n" +
+	"\x10WatchRevocations\x12\x1d.auth.WatchRevocationsRequest\x1a\x10.auth.Revocation0\x01B\x17Z\x15auth/gen/go/sso;ssov1b\x06proto3"
+
+var (
+	file_sso_revocations_proto_rawDescOnce sync.Once
+	file_sso_revocations_proto_rawDescData []byte
+)
+
+func file_sso_revocations_proto_rawDescGZIP() []byte {
+	file_sso_revocations_proto_rawDescOnce.Do(func() {
+		file_sso_revocations_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sso_revocations_proto_rawDesc), len(file_sso_revocations_proto_rawDesc)))
+	})
+	return file_sso_revocations_proto_rawDescData
+}
+
+var file_sso_revocations_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_sso_revocations_proto_goTypes = []any{
+	(*Revocation)(nil),              // 0: auth.Revocation
+	(*WatchRevocationsRequest)(nil), // 1: auth.WatchRevocationsRequest
+	(*timestamppb.Timestamp)(nil),   // 2: google.protobuf.Timestamp
+}
+var file_sso_revocations_proto_depIdxs = []int32{
+	2, // 0: auth.Revocation.revoked_at:type_name -> google.protobuf.Timestamp
+	1, // 1: auth.Revocations.WatchRevocations:input_type -> auth.WatchRevocationsRequest
+	0, // 2: auth.Revocations.WatchRevocations:output_type -> auth.Revocation
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_sso_revocations_proto_init() }
+func file_sso_revocations_proto_init() {
+	if File_sso_revocations_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sso_revocations_proto_rawDesc), len(file_sso_revocations_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sso_revocations_proto_goTypes,
+		DependencyIndexes: file_sso_revocations_proto_depIdxs,
+		MessageInfos:      file_sso_revocations_proto_msgTypes,
+	}.Build()
+	File_sso_revocations_proto = out.File
+	file_sso_revocations_proto_goTypes = nil
+	file_sso_revocations_proto_depIdxs = nil
+}
@@ -0,0 +1,132 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: sso/revocations.proto
+
+package ssov1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Revocations_WatchRevocations_FullMethodName = "/auth.Revocations/WatchRevocations"
+)
+
+// RevocationsClient is the client API for Revocations service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Revocations streams the revocation feed to resource servers that cache token validity.
+type RevocationsClient interface {
+	// WatchRevocations sends the entries after offset, or only new ones if offset is empty,
+	// and keeps sending them as they happen.
+	WatchRevocations(ctx context.Context, in *WatchRevocationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Revocation], error)
+}
+
+type revocationsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRevocationsClient(cc grpc.ClientConnInterface) RevocationsClient {
+	return &revocationsClient{cc}
+}
+
+func (c *revocationsClient) WatchRevocations(ctx context.Context, in *WatchRevocationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Revocation], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Revocations_ServiceDesc.Streams[0], Revocations_WatchRevocations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRevocationsRequest, Revocation]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Revocations_WatchRevocationsClient = grpc.ServerStreamingClient[Revocation]
+
+// RevocationsServer is the server API for Revocations service.
+// All implementations must embed UnimplementedRevocationsServer
+// for forward compatibility.
+//
+// Revocations streams the revocation feed to resource servers that cache token validity.
+type RevocationsServer interface {
+	// WatchRevocations sends the entries after offset, or only new ones if offset is empty,
+	// and keeps sending them as they happen.
+	WatchRevocations(*WatchRevocationsRequest, grpc.ServerStreamingServer[Revocation]) error
+	mustEmbedUnimplementedRevocationsServer()
+}
+
+// UnimplementedRevocationsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRevocationsServer struct{}
+
+func (UnimplementedRevocationsServer) WatchRevocations(*WatchRevocationsRequest, grpc.ServerStreamingServer[Revocation]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRevocations not implemented")
+}
+func (UnimplementedRevocationsServer) mustEmbedUnimplementedRevocationsServer() {}
+func (UnimplementedRevocationsServer) testEmbeddedByValue()                     {}
+
+// UnsafeRevocationsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RevocationsServer will
+// result in compilation errors.
+type UnsafeRevocationsServer interface {
+	mustEmbedUnimplementedRevocationsServer()
+}
+
+func RegisterRevocationsServer(s grpc.ServiceRegistrar, srv RevocationsServer) {
+	// If the following call pancis, it indicates UnimplementedRevocationsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Revocations_ServiceDesc, srv)
+}
+
+func _Revocations_WatchRevocations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRevocationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RevocationsServer).WatchRevocations(m, &grpc.GenericServerStream[WatchRevocationsRequest, Revocation]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Revocations_WatchRevocationsServer = grpc.ServerStreamingServer[Revocation]
+
+// Revocations_ServiceDesc is the grpc.ServiceDesc for Revocations service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Revocations_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "auth.Revocations",
+	HandlerType: (*RevocationsServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRevocations",
+			Handler:       _Revocations_WatchRevocations_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sso/revocations.proto",
+}
@@ -0,0 +1,719 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: sso/saml.proto
+
+package ssov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CompleteSAMLLoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompleteSAMLLoginRequest) Reset() {
+	*x = CompleteSAMLLoginRequest{}
+	mi := &file_sso_saml_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompleteSAMLLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteSAMLLoginRequest) ProtoMessage() {}
+
+func (x *CompleteSAMLLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_saml_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteSAMLLoginRequest.ProtoReflect.Descriptor instead.
+func (*CompleteSAMLLoginRequest) Descriptor() ([]byte, []int) {
+	return file_sso_saml_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CompleteSAMLLoginRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type StartSAMLLoginRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ServiceProviderId int32                  `protobuf:"varint,1,opt,name=service_provider_id,json=serviceProviderId,proto3" json:"service_provider_id,omitempty"`
+	RelayState        string                 `protobuf:"bytes,2,opt,name=relay_state,json=relayState,proto3" json:"relay_state,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *StartSAMLLoginRequest) Reset() {
+	*x = StartSAMLLoginRequest{}
+	mi := &file_sso_saml_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartSAMLLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartSAMLLoginRequest) ProtoMessage() {}
+
+func (x *StartSAMLLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_saml_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartSAMLLoginRequest.ProtoReflect.Descriptor instead.
+func (*StartSAMLLoginRequest) Descriptor() ([]byte, []int) {
+	return file_sso_saml_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StartSAMLLoginRequest) GetServiceProviderId() int32 {
+	if x != nil {
+		return x.ServiceProviderId
+	}
+	return 0
+}
+
+func (x *StartSAMLLoginRequest) GetRelayState() string {
+	if x != nil {
+		return x.RelayState
+	}
+	return ""
+}
+
+// SAMLPost is what the browser posts to the service provider.
+type SAMLPost struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AcsUrl        string                 `protobuf:"bytes,1,opt,name=acs_url,json=acsUrl,proto3" json:"acs_url,omitempty"`
+	SamlResponse  string                 `protobuf:"bytes,2,opt,name=saml_response,json=samlResponse,proto3" json:"saml_response,omitempty"`
+	RelayState    string                 `protobuf:"bytes,3,opt,name=relay_state,json=relayState,proto3" json:"relay_state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SAMLPost) Reset() {
+	*x = SAMLPost{}
+	mi := &file_sso_saml_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SAMLPost) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SAMLPost) ProtoMessage() {}
+
+func (x *SAMLPost) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_saml_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SAMLPost.ProtoReflect.Descriptor instead.
+func (*SAMLPost) Descriptor() ([]byte, []int) {
+	return file_sso_saml_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SAMLPost) GetAcsUrl() string {
+	if x != nil {
+		return x.AcsUrl
+	}
+	return ""
+}
+
+func (x *SAMLPost) GetSamlResponse() string {
+	if x != nil {
+		return x.SamlResponse
+	}
+	return ""
+}
+
+func (x *SAMLPost) GetRelayState() string {
+	if x != nil {
+		return x.RelayState
+	}
+	return ""
+}
+
+type CreateSAMLServiceProviderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int32                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	EntityId      string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	AcsUrl        string                 `protobuf:"bytes,4,opt,name=acs_url,json=acsUrl,proto3" json:"acs_url,omitempty"`
+	SloUrl        string                 `protobuf:"bytes,5,opt,name=slo_url,json=sloUrl,proto3" json:"slo_url,omitempty"`
+	Certificate   string                 `protobuf:"bytes,6,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	NameIdFormat  string                 `protobuf:"bytes,7,opt,name=name_id_format,json=nameIdFormat,proto3" json:"name_id_format,omitempty"`
+	AttributeMap  map[string]string      `protobuf:"bytes,8,rep,name=attribute_map,json=attributeMap,proto3" json:"attribute_map,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSAMLServiceProviderRequest) Reset() {
+	*x = CreateSAMLServiceProviderRequest{}
+	mi := &file_sso_saml_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSAMLServiceProviderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSAMLServiceProviderRequest) ProtoMessage() {}
+
+func (x *CreateSAMLServiceProviderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_saml_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSAMLServiceProviderRequest.ProtoReflect.Descriptor instead.
+func (*CreateSAMLServiceProviderRequest) Descriptor() ([]byte, []int) {
+	return file_sso_saml_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateSAMLServiceProviderRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *CreateSAMLServiceProviderRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *CreateSAMLServiceProviderRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateSAMLServiceProviderRequest) GetAcsUrl() string {
+	if x != nil {
+		return x.AcsUrl
+	}
+	return ""
+}
+
+func (x *CreateSAMLServiceProviderRequest) GetSloUrl() string {
+	if x != nil {
+		return x.SloUrl
+	}
+	return ""
+}
+
+func (x *CreateSAMLServiceProviderRequest) GetCertificate() string {
+	if x != nil {
+		return x.Certificate
+	}
+	return ""
+}
+
+func (x *CreateSAMLServiceProviderRequest) GetNameIdFormat() string {
+	if x != nil {
+		return x.NameIdFormat
+	}
+	return ""
+}
+
+func (x *CreateSAMLServiceProviderRequest) GetAttributeMap() map[string]string {
+	if x != nil {
+		return x.AttributeMap
+	}
+	return nil
+}
+
+type SAMLServiceProvider struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	AppId         int32                  `protobuf:"varint,2,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	EntityId      string                 `protobuf:"bytes,3,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	AcsUrl        string                 `protobuf:"bytes,5,opt,name=acs_url,json=acsUrl,proto3" json:"acs_url,omitempty"`
+	SloUrl        string                 `protobuf:"bytes,6,opt,name=slo_url,json=sloUrl,proto3" json:"slo_url,omitempty"`
+	Certificate   string                 `protobuf:"bytes,7,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	NameIdFormat  string                 `protobuf:"bytes,8,opt,name=name_id_format,json=nameIdFormat,proto3" json:"name_id_format,omitempty"`
+	AttributeMap  map[string]string      `protobuf:"bytes,9,rep,name=attribute_map,json=attributeMap,proto3" json:"attribute_map,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Disabled      bool                   `protobuf:"varint,10,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SAMLServiceProvider) Reset() {
+	*x = SAMLServiceProvider{}
+	mi := &file_sso_saml_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SAMLServiceProvider) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SAMLServiceProvider) ProtoMessage() {}
+
+func (x *SAMLServiceProvider) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_saml_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SAMLServiceProvider.ProtoReflect.Descriptor instead.
+func (*SAMLServiceProvider) Descriptor() ([]byte, []int) {
+	return file_sso_saml_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SAMLServiceProvider) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SAMLServiceProvider) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *SAMLServiceProvider) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *SAMLServiceProvider) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SAMLServiceProvider) GetAcsUrl() string {
+	if x != nil {
+		return x.AcsUrl
+	}
+	return ""
+}
+
+func (x *SAMLServiceProvider) GetSloUrl() string {
+	if x != nil {
+		return x.SloUrl
+	}
+	return ""
+}
+
+func (x *SAMLServiceProvider) GetCertificate() string {
+	if x != nil {
+		return x.Certificate
+	}
+	return ""
+}
+
+func (x *SAMLServiceProvider) GetNameIdFormat() string {
+	if x != nil {
+		return x.NameIdFormat
+	}
+	return ""
+}
+
+func (x *SAMLServiceProvider) GetAttributeMap() map[string]string {
+	if x != nil {
+		return x.AttributeMap
+	}
+	return nil
+}
+
+func (x *SAMLServiceProvider) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+func (x *SAMLServiceProvider) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ListSAMLServiceProvidersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSAMLServiceProvidersRequest) Reset() {
+	*x = ListSAMLServiceProvidersRequest{}
+	mi := &file_sso_saml_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSAMLServiceProvidersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSAMLServiceProvidersRequest) ProtoMessage() {}
+
+func (x *ListSAMLServiceProvidersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_saml_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSAMLServiceProvidersRequest.ProtoReflect.Descriptor instead.
+func (*ListSAMLServiceProvidersRequest) Descriptor() ([]byte, []int) {
+	return file_sso_saml_proto_rawDescGZIP(), []int{5}
+}
+
+type ListSAMLServiceProvidersResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ServiceProviders []*SAMLServiceProvider `protobuf:"bytes,1,rep,name=service_providers,json=serviceProviders,proto3" json:"service_providers,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ListSAMLServiceProvidersResponse) Reset() {
+	*x = ListSAMLServiceProvidersResponse{}
+	mi := &file_sso_saml_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSAMLServiceProvidersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSAMLServiceProvidersResponse) ProtoMessage() {}
+
+func (x *ListSAMLServiceProvidersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_saml_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSAMLServiceProvidersResponse.ProtoReflect.Descriptor instead.
+func (*ListSAMLServiceProvidersResponse) Descriptor() ([]byte, []int) {
+	return file_sso_saml_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListSAMLServiceProvidersResponse) GetServiceProviders() []*SAMLServiceProvider {
+	if x != nil {
+		return x.ServiceProviders
+	}
+	return nil
+}
+
+type SetSAMLServiceProviderDisabledRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Disabled      bool                   `protobuf:"varint,2,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetSAMLServiceProviderDisabledRequest) Reset() {
+	*x = SetSAMLServiceProviderDisabledRequest{}
+	mi := &file_sso_saml_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetSAMLServiceProviderDisabledRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetSAMLServiceProviderDisabledRequest) ProtoMessage() {}
+
+func (x *SetSAMLServiceProviderDisabledRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_saml_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetSAMLServiceProviderDisabledRequest.ProtoReflect.Descriptor instead.
+func (*SetSAMLServiceProviderDisabledRequest) Descriptor() ([]byte, []int) {
+	return file_sso_saml_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SetSAMLServiceProviderDisabledRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SetSAMLServiceProviderDisabledRequest) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+type DeleteSAMLServiceProviderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSAMLServiceProviderRequest) Reset() {
+	*x = DeleteSAMLServiceProviderRequest{}
+	mi := &file_sso_saml_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSAMLServiceProviderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSAMLServiceProviderRequest) ProtoMessage() {}
+
+func (x *DeleteSAMLServiceProviderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_saml_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSAMLServiceProviderRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSAMLServiceProviderRequest) Descriptor() ([]byte, []int) {
+	return file_sso_saml_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteSAMLServiceProviderRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+var File_sso_saml_proto protoreflect.FileDescriptor
+
+const file_sso_saml_proto_rawDesc = "" +
+	"\n" +
+	"\x0esso/saml.proto\x12\x04auth\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"9\n" +
+	"\x18CompleteSAMLLoginRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\"h\n" +
+	"\x15StartSAMLLoginRequest\x12.\n" +
+	"\x13service_provider_id\x18\x01 \x01(\x05R\x11serviceProviderId\x12\x1f\n" +
+	"\vrelay_state\x18\x02 \x01(\tR\n" +
+	"relayState\"i\n" +
+	"\bSAMLPost\x12\x17\n" +
+	"\aacs_url\x18\x01 \x01(\tR\x06acsUrl\x12#\n" +
+	"\rsaml_response\x18\x02 \x01(\tR\fsamlResponse\x12\x1f\n" +
+	"\vrelay_state\x18\x03 \x01(\tR\n" +
+	"relayState\"\x84\x03\n" +
+	" CreateSAMLServiceProviderRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x05R\x05appId\x12\x1b\n" +
+	"\tentity_id\x18\x02 \x01(\tR\bentityId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x17\n" +
+	"\aacs_url\x18\x04 \x01(\tR\x06acsUrl\x12\x17\n" +
+	"\aslo_url\x18\x05 \x01(\tR\x06sloUrl\x12 \n" +
+	"\vcertificate\x18\x06 \x01(\tR\vcertificate\x12$\n" +
+	"\x0ename_id_format\x18\a \x01(\tR\fnameIdFormat\x12]\n" +
+	"\rattribute_map\x18\b \x03(\v28.auth.CreateSAMLServiceProviderRequest.AttributeMapEntryR\fattributeMap\x1a?\n" +
+	"\x11AttributeMapEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xd1\x03\n" +
+	"\x13SAMLServiceProvider\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x15\n" +
+	"\x06app_id\x18\x02 \x01(\x05R\x05appId\x12\x1b\n" +
+	"\tentity_id\x18\x03 \x01(\tR\bentityId\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x17\n" +
+	"\aacs_url\x18\x05 \x01(\tR\x06acsUrl\x12\x17\n" +
+	"\aslo_url\x18\x06 \x01(\tR\x06sloUrl\x12 \n" +
+	"\vcertificate\x18\a \x01(\tR\vcertificate\x12$\n" +
+	"\x0ename_id_format\x18\b \x01(\tR\fnameIdFormat\x12P\n" +
+	"\rattribute_map\x18\t \x03(\v2+.auth.SAMLServiceProvider.AttributeMapEntryR\fattributeMap\x12\x1a\n" +
+	"\bdisabled\x18\n" +
+	" \x01(\bR\bdisabled\x129\n" +
+	"\n" +
+	"created_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x1a?\n" +
+	"\x11AttributeMapEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"!\n" +
+	"\x1fListSAMLServiceProvidersRequest\"j\n" +
+	" ListSAMLServiceProvidersResponse\x12F\n" +
+	"\x11service_providers\x18\x01 \x03(\v2\x19.auth.SAMLServiceProviderR\x10serviceProviders\"S\n" +
+	"%SetSAMLServiceProviderDisabledRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x1a\n" +
+	"\bdisabled\x18\x02 \x01(\bR\bdisabled\"2\n" +
+	" DeleteSAMLServiceProviderRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id2\x99\x04\n" +
+	"\x04SAML\x12C\n" +
+	"\x11CompleteSAMLLogin\x12\x1e.auth.CompleteSAMLLoginRequest\x1a\x0e.auth.SAMLPost\x12=\n" +
+	"\x0eStartSAMLLogin\x12\x1b.auth.StartSAMLLoginRequest\x1a\x0e.auth.SAMLPost\x12^\n" +
+	"\x19CreateSAMLServiceProvider\x12&.auth.CreateSAMLServiceProviderRequest\x1a\x19.auth.SAMLServiceProvider\x12i\n" +
+	"\x18ListSAMLServiceProviders\x12%.auth.ListSAMLServiceProvidersRequest\x1a&.auth.ListSAMLServiceProvidersResponse\x12e\n" +
+	"\x1eSetSAMLServiceProviderDisabled\x12+.auth.SetSAMLServiceProviderDisabledRequest\x1a\x16.google.protobuf.Empty\x12[\n" +
+	"\x19DeleteSAMLServiceProvider\x12&.auth.DeleteSAMLServiceProviderRequest\x1a\x16.google.protobuf.EmptyB\x17Z\x15auth/gen/go/sso;ssov1b\x06proto3"
+
+var (
+	file_sso_saml_proto_rawDescOnce sync.Once
+	file_sso_saml_proto_rawDescData []byte
+)
+
+func file_sso_saml_proto_rawDescGZIP() []byte {
+	file_sso_saml_proto_rawDescOnce.Do(func() {
+		file_sso_saml_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sso_saml_proto_rawDesc), len(file_sso_saml_proto_rawDesc)))
+	})
+	return file_sso_saml_proto_rawDescData
+}
+
+var file_sso_saml_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_sso_saml_proto_goTypes = []any{
+	(*CompleteSAMLLoginRequest)(nil),              // 0: auth.CompleteSAMLLoginRequest
+	(*StartSAMLLoginRequest)(nil),                 // 1: auth.StartSAMLLoginRequest
+	(*SAMLPost)(nil),                              // 2: auth.SAMLPost
+	(*CreateSAMLServiceProviderRequest)(nil),      // 3: auth.CreateSAMLServiceProviderRequest
+	(*SAMLServiceProvider)(nil),                   // 4: auth.SAMLServiceProvider
+	(*ListSAMLServiceProvidersRequest)(nil),       // 5: auth.ListSAMLServiceProvidersRequest
+	(*ListSAMLServiceProvidersResponse)(nil),      // 6: auth.ListSAMLServiceProvidersResponse
+	(*SetSAMLServiceProviderDisabledRequest)(nil), // 7: auth.SetSAMLServiceProviderDisabledRequest
+	(*DeleteSAMLServiceProviderRequest)(nil),      // 8: auth.DeleteSAMLServiceProviderRequest
+	nil,                                           // 9: auth.CreateSAMLServiceProviderRequest.AttributeMapEntry
+	nil,                                           // 10: auth.SAMLServiceProvider.AttributeMapEntry
+	(*timestamppb.Timestamp)(nil),                 // 11: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),                         // 12: google.protobuf.Empty
+}
+var file_sso_saml_proto_depIdxs = []int32{
+	9,  // 0: auth.CreateSAMLServiceProviderRequest.attribute_map:type_name -> auth.CreateSAMLServiceProviderRequest.AttributeMapEntry
+	10, // 1: auth.SAMLServiceProvider.attribute_map:type_name -> auth.SAMLServiceProvider.AttributeMapEntry
+	11, // 2: auth.SAMLServiceProvider.created_at:type_name -> google.protobuf.Timestamp
+	4,  // 3: auth.ListSAMLServiceProvidersResponse.service_providers:type_name -> auth.SAMLServiceProvider
+	0,  // 4: auth.SAML.CompleteSAMLLogin:input_type -> auth.CompleteSAMLLoginRequest
+	1,  // 5: auth.SAML.StartSAMLLogin:input_type -> auth.StartSAMLLoginRequest
+	3,  // 6: auth.SAML.CreateSAMLServiceProvider:input_type -> auth.CreateSAMLServiceProviderRequest
+	5,  // 7: auth.SAML.ListSAMLServiceProviders:input_type -> auth.ListSAMLServiceProvidersRequest
+	7,  // 8: auth.SAML.SetSAMLServiceProviderDisabled:input_type -> auth.SetSAMLServiceProviderDisabledRequest
+	8,  // 9: auth.SAML.DeleteSAMLServiceProvider:input_type -> auth.DeleteSAMLServiceProviderRequest
+	2,  // 10: auth.SAML.CompleteSAMLLogin:output_type -> auth.SAMLPost
+	2,  // 11: auth.SAML.StartSAMLLogin:output_type -> auth.SAMLPost
+	4,  // 12: auth.SAML.CreateSAMLServiceProvider:output_type -> auth.SAMLServiceProvider
+	6,  // 13: auth.SAML.ListSAMLServiceProviders:output_type -> auth.ListSAMLServiceProvidersResponse
+	12, // 14: auth.SAML.SetSAMLServiceProviderDisabled:output_type -> google.protobuf.Empty
+	12, // 15: auth.SAML.DeleteSAMLServiceProvider:output_type -> google.protobuf.Empty
+	10, // [10:16] is the sub-list for method output_type
+	4,  // [4:10] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_sso_saml_proto_init() }
+func file_sso_saml_proto_init() {
+	if File_sso_saml_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sso_saml_proto_rawDesc), len(file_sso_saml_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sso_saml_proto_goTypes,
+		DependencyIndexes: file_sso_saml_proto_depIdxs,
+		MessageInfos:      file_sso_saml_proto_msgTypes,
+	}.Build()
+	File_sso_saml_proto = out.File
+	file_sso_saml_proto_goTypes = nil
+	file_sso_saml_proto_depIdxs = nil
+}
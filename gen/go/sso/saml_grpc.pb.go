@@ -0,0 +1,316 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: sso/saml.proto
+
+package ssov1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SAML_CompleteSAMLLogin_FullMethodName              = "/auth.SAML/CompleteSAMLLogin"
+	SAML_StartSAMLLogin_FullMethodName                 = "/auth.SAML/StartSAMLLogin"
+	SAML_CreateSAMLServiceProvider_FullMethodName      = "/auth.SAML/CreateSAMLServiceProvider"
+	SAML_ListSAMLServiceProviders_FullMethodName       = "/auth.SAML/ListSAMLServiceProviders"
+	SAML_SetSAMLServiceProviderDisabled_FullMethodName = "/auth.SAML/SetSAMLServiceProviderDisabled"
+	SAML_DeleteSAMLServiceProvider_FullMethodName      = "/auth.SAML/DeleteSAMLServiceProvider"
+)
+
+// SAMLClient is the client API for SAML service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SAML signs users in to SAML service providers, acting as their identity provider.
+type SAMLClient interface {
+	CompleteSAMLLogin(ctx context.Context, in *CompleteSAMLLoginRequest, opts ...grpc.CallOption) (*SAMLPost, error)
+	StartSAMLLogin(ctx context.Context, in *StartSAMLLoginRequest, opts ...grpc.CallOption) (*SAMLPost, error)
+	CreateSAMLServiceProvider(ctx context.Context, in *CreateSAMLServiceProviderRequest, opts ...grpc.CallOption) (*SAMLServiceProvider, error)
+	ListSAMLServiceProviders(ctx context.Context, in *ListSAMLServiceProvidersRequest, opts ...grpc.CallOption) (*ListSAMLServiceProvidersResponse, error)
+	SetSAMLServiceProviderDisabled(ctx context.Context, in *SetSAMLServiceProviderDisabledRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteSAMLServiceProvider(ctx context.Context, in *DeleteSAMLServiceProviderRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type sAMLClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSAMLClient(cc grpc.ClientConnInterface) SAMLClient {
+	return &sAMLClient{cc}
+}
+
+func (c *sAMLClient) CompleteSAMLLogin(ctx context.Context, in *CompleteSAMLLoginRequest, opts ...grpc.CallOption) (*SAMLPost, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SAMLPost)
+	err := c.cc.Invoke(ctx, SAML_CompleteSAMLLogin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sAMLClient) StartSAMLLogin(ctx context.Context, in *StartSAMLLoginRequest, opts ...grpc.CallOption) (*SAMLPost, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SAMLPost)
+	err := c.cc.Invoke(ctx, SAML_StartSAMLLogin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sAMLClient) CreateSAMLServiceProvider(ctx context.Context, in *CreateSAMLServiceProviderRequest, opts ...grpc.CallOption) (*SAMLServiceProvider, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SAMLServiceProvider)
+	err := c.cc.Invoke(ctx, SAML_CreateSAMLServiceProvider_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sAMLClient) ListSAMLServiceProviders(ctx context.Context, in *ListSAMLServiceProvidersRequest, opts ...grpc.CallOption) (*ListSAMLServiceProvidersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSAMLServiceProvidersResponse)
+	err := c.cc.Invoke(ctx, SAML_ListSAMLServiceProviders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sAMLClient) SetSAMLServiceProviderDisabled(ctx context.Context, in *SetSAMLServiceProviderDisabledRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, SAML_SetSAMLServiceProviderDisabled_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sAMLClient) DeleteSAMLServiceProvider(ctx context.Context, in *DeleteSAMLServiceProviderRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, SAML_DeleteSAMLServiceProvider_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SAMLServer is the server API for SAML service.
+// All implementations must embed UnimplementedSAMLServer
+// for forward compatibility.
+//
+// SAML signs users in to SAML service providers, acting as their identity provider.
+type SAMLServer interface {
+	CompleteSAMLLogin(context.Context, *CompleteSAMLLoginRequest) (*SAMLPost, error)
+	StartSAMLLogin(context.Context, *StartSAMLLoginRequest) (*SAMLPost, error)
+	CreateSAMLServiceProvider(context.Context, *CreateSAMLServiceProviderRequest) (*SAMLServiceProvider, error)
+	ListSAMLServiceProviders(context.Context, *ListSAMLServiceProvidersRequest) (*ListSAMLServiceProvidersResponse, error)
+	SetSAMLServiceProviderDisabled(context.Context, *SetSAMLServiceProviderDisabledRequest) (*emptypb.Empty, error)
+	DeleteSAMLServiceProvider(context.Context, *DeleteSAMLServiceProviderRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedSAMLServer()
+}
+
+// UnimplementedSAMLServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSAMLServer struct{}
+
+func (UnimplementedSAMLServer) CompleteSAMLLogin(context.Context, *CompleteSAMLLoginRequest) (*SAMLPost, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteSAMLLogin not implemented")
+}
+func (UnimplementedSAMLServer) StartSAMLLogin(context.Context, *StartSAMLLoginRequest) (*SAMLPost, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartSAMLLogin not implemented")
+}
+func (UnimplementedSAMLServer) CreateSAMLServiceProvider(context.Context, *CreateSAMLServiceProviderRequest) (*SAMLServiceProvider, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSAMLServiceProvider not implemented")
+}
+func (UnimplementedSAMLServer) ListSAMLServiceProviders(context.Context, *ListSAMLServiceProvidersRequest) (*ListSAMLServiceProvidersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSAMLServiceProviders not implemented")
+}
+func (UnimplementedSAMLServer) SetSAMLServiceProviderDisabled(context.Context, *SetSAMLServiceProviderDisabledRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSAMLServiceProviderDisabled not implemented")
+}
+func (UnimplementedSAMLServer) DeleteSAMLServiceProvider(context.Context, *DeleteSAMLServiceProviderRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSAMLServiceProvider not implemented")
+}
+func (UnimplementedSAMLServer) mustEmbedUnimplementedSAMLServer() {}
+func (UnimplementedSAMLServer) testEmbeddedByValue()              {}
+
+// UnsafeSAMLServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SAMLServer will
+// result in compilation errors.
+type UnsafeSAMLServer interface {
+	mustEmbedUnimplementedSAMLServer()
+}
+
+func RegisterSAMLServer(s grpc.ServiceRegistrar, srv SAMLServer) {
+	// If the following call pancis, it indicates UnimplementedSAMLServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SAML_ServiceDesc, srv)
+}
+
+func _SAML_CompleteSAMLLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompleteSAMLLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SAMLServer).CompleteSAMLLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SAML_CompleteSAMLLogin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SAMLServer).CompleteSAMLLogin(ctx, req.(*CompleteSAMLLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SAML_StartSAMLLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartSAMLLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SAMLServer).StartSAMLLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SAML_StartSAMLLogin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SAMLServer).StartSAMLLogin(ctx, req.(*StartSAMLLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SAML_CreateSAMLServiceProvider_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSAMLServiceProviderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SAMLServer).CreateSAMLServiceProvider(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SAML_CreateSAMLServiceProvider_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SAMLServer).CreateSAMLServiceProvider(ctx, req.(*CreateSAMLServiceProviderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SAML_ListSAMLServiceProviders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSAMLServiceProvidersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SAMLServer).ListSAMLServiceProviders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SAML_ListSAMLServiceProviders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SAMLServer).ListSAMLServiceProviders(ctx, req.(*ListSAMLServiceProvidersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SAML_SetSAMLServiceProviderDisabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetSAMLServiceProviderDisabledRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SAMLServer).SetSAMLServiceProviderDisabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SAML_SetSAMLServiceProviderDisabled_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SAMLServer).SetSAMLServiceProviderDisabled(ctx, req.(*SetSAMLServiceProviderDisabledRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SAML_DeleteSAMLServiceProvider_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSAMLServiceProviderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SAMLServer).DeleteSAMLServiceProvider(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SAML_DeleteSAMLServiceProvider_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SAMLServer).DeleteSAMLServiceProvider(ctx, req.(*DeleteSAMLServiceProviderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SAML_ServiceDesc is the grpc.ServiceDesc for SAML service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SAML_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "auth.SAML",
+	HandlerType: (*SAMLServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CompleteSAMLLogin",
+			Handler:    _SAML_CompleteSAMLLogin_Handler,
+		},
+		{
+			MethodName: "StartSAMLLogin",
+			Handler:    _SAML_StartSAMLLogin_Handler,
+		},
+		{
+			MethodName: "CreateSAMLServiceProvider",
+			Handler:    _SAML_CreateSAMLServiceProvider_Handler,
+		},
+		{
+			MethodName: "ListSAMLServiceProviders",
+			Handler:    _SAML_ListSAMLServiceProviders_Handler,
+		},
+		{
+			MethodName: "SetSAMLServiceProviderDisabled",
+			Handler:    _SAML_SetSAMLServiceProviderDisabled_Handler,
+		},
+		{
+			MethodName: "DeleteSAMLServiceProvider",
+			Handler:    _SAML_DeleteSAMLServiceProvider_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "sso/saml.proto",
+}
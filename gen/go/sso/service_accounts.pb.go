@@ -0,0 +1,1003 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: sso/service_accounts.proto
+
+package ssov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ServiceAccount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	AppId         int32                  `protobuf:"varint,2,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Disabled      bool                   `protobuf:"varint,5,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	CreatedBy     int64                  `protobuf:"varint,6,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Roles         []string               `protobuf:"bytes,8,rep,name=roles,proto3" json:"roles,omitempty"`
+	Permissions   []string               `protobuf:"bytes,9,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServiceAccount) Reset() {
+	*x = ServiceAccount{}
+	mi := &file_sso_service_accounts_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceAccount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceAccount) ProtoMessage() {}
+
+func (x *ServiceAccount) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceAccount.ProtoReflect.Descriptor instead.
+func (*ServiceAccount) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ServiceAccount) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ServiceAccount) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *ServiceAccount) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ServiceAccount) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ServiceAccount) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+func (x *ServiceAccount) GetCreatedBy() int64 {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return 0
+}
+
+func (x *ServiceAccount) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *ServiceAccount) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *ServiceAccount) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+type ServiceAccountKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Algorithm     string                 `protobuf:"bytes,2,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServiceAccountKey) Reset() {
+	*x = ServiceAccountKey{}
+	mi := &file_sso_service_accounts_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceAccountKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceAccountKey) ProtoMessage() {}
+
+func (x *ServiceAccountKey) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceAccountKey.ProtoReflect.Descriptor instead.
+func (*ServiceAccountKey) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ServiceAccountKey) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ServiceAccountKey) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+func (x *ServiceAccountKey) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *ServiceAccountKey) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type CreateServiceAccountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int32                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateServiceAccountRequest) Reset() {
+	*x = CreateServiceAccountRequest{}
+	mi := &file_sso_service_accounts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateServiceAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateServiceAccountRequest) ProtoMessage() {}
+
+func (x *CreateServiceAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateServiceAccountRequest.ProtoReflect.Descriptor instead.
+func (*CreateServiceAccountRequest) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateServiceAccountRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *CreateServiceAccountRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateServiceAccountRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type GetServiceAccountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServiceAccountRequest) Reset() {
+	*x = GetServiceAccountRequest{}
+	mi := &file_sso_service_accounts_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServiceAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServiceAccountRequest) ProtoMessage() {}
+
+func (x *GetServiceAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServiceAccountRequest.ProtoReflect.Descriptor instead.
+func (*GetServiceAccountRequest) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetServiceAccountRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListServiceAccountsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int32                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServiceAccountsRequest) Reset() {
+	*x = ListServiceAccountsRequest{}
+	mi := &file_sso_service_accounts_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServiceAccountsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServiceAccountsRequest) ProtoMessage() {}
+
+func (x *ListServiceAccountsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServiceAccountsRequest.ProtoReflect.Descriptor instead.
+func (*ListServiceAccountsRequest) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListServiceAccountsRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+type ListServiceAccountsResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ServiceAccounts []*ServiceAccount      `protobuf:"bytes,1,rep,name=service_accounts,json=serviceAccounts,proto3" json:"service_accounts,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ListServiceAccountsResponse) Reset() {
+	*x = ListServiceAccountsResponse{}
+	mi := &file_sso_service_accounts_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServiceAccountsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServiceAccountsResponse) ProtoMessage() {}
+
+func (x *ListServiceAccountsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServiceAccountsResponse.ProtoReflect.Descriptor instead.
+func (*ListServiceAccountsResponse) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListServiceAccountsResponse) GetServiceAccounts() []*ServiceAccount {
+	if x != nil {
+		return x.ServiceAccounts
+	}
+	return nil
+}
+
+type SetServiceAccountDisabledRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Disabled      bool                   `protobuf:"varint,2,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetServiceAccountDisabledRequest) Reset() {
+	*x = SetServiceAccountDisabledRequest{}
+	mi := &file_sso_service_accounts_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetServiceAccountDisabledRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetServiceAccountDisabledRequest) ProtoMessage() {}
+
+func (x *SetServiceAccountDisabledRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetServiceAccountDisabledRequest.ProtoReflect.Descriptor instead.
+func (*SetServiceAccountDisabledRequest) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SetServiceAccountDisabledRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SetServiceAccountDisabledRequest) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+type DeleteServiceAccountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteServiceAccountRequest) Reset() {
+	*x = DeleteServiceAccountRequest{}
+	mi := &file_sso_service_accounts_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteServiceAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteServiceAccountRequest) ProtoMessage() {}
+
+func (x *DeleteServiceAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteServiceAccountRequest.ProtoReflect.Descriptor instead.
+func (*DeleteServiceAccountRequest) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteServiceAccountRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type AddServiceAccountKeyRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ServiceAccountId int64                  `protobuf:"varint,1,opt,name=service_account_id,json=serviceAccountId,proto3" json:"service_account_id,omitempty"`
+	// public_key is PEM encoded.
+	PublicKey     string                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddServiceAccountKeyRequest) Reset() {
+	*x = AddServiceAccountKeyRequest{}
+	mi := &file_sso_service_accounts_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddServiceAccountKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddServiceAccountKeyRequest) ProtoMessage() {}
+
+func (x *AddServiceAccountKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddServiceAccountKeyRequest.ProtoReflect.Descriptor instead.
+func (*AddServiceAccountKeyRequest) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AddServiceAccountKeyRequest) GetServiceAccountId() int64 {
+	if x != nil {
+		return x.ServiceAccountId
+	}
+	return 0
+}
+
+func (x *AddServiceAccountKeyRequest) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *AddServiceAccountKeyRequest) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type ListServiceAccountKeysRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ServiceAccountId int64                  `protobuf:"varint,1,opt,name=service_account_id,json=serviceAccountId,proto3" json:"service_account_id,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ListServiceAccountKeysRequest) Reset() {
+	*x = ListServiceAccountKeysRequest{}
+	mi := &file_sso_service_accounts_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServiceAccountKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServiceAccountKeysRequest) ProtoMessage() {}
+
+func (x *ListServiceAccountKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServiceAccountKeysRequest.ProtoReflect.Descriptor instead.
+func (*ListServiceAccountKeysRequest) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListServiceAccountKeysRequest) GetServiceAccountId() int64 {
+	if x != nil {
+		return x.ServiceAccountId
+	}
+	return 0
+}
+
+type ListServiceAccountKeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []*ServiceAccountKey   `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServiceAccountKeysResponse) Reset() {
+	*x = ListServiceAccountKeysResponse{}
+	mi := &file_sso_service_accounts_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServiceAccountKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServiceAccountKeysResponse) ProtoMessage() {}
+
+func (x *ListServiceAccountKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServiceAccountKeysResponse.ProtoReflect.Descriptor instead.
+func (*ListServiceAccountKeysResponse) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListServiceAccountKeysResponse) GetKeys() []*ServiceAccountKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type RevokeServiceAccountKeyRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ServiceAccountId int64                  `protobuf:"varint,1,opt,name=service_account_id,json=serviceAccountId,proto3" json:"service_account_id,omitempty"`
+	KeyId            string                 `protobuf:"bytes,2,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *RevokeServiceAccountKeyRequest) Reset() {
+	*x = RevokeServiceAccountKeyRequest{}
+	mi := &file_sso_service_accounts_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeServiceAccountKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeServiceAccountKeyRequest) ProtoMessage() {}
+
+func (x *RevokeServiceAccountKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeServiceAccountKeyRequest.ProtoReflect.Descriptor instead.
+func (*RevokeServiceAccountKeyRequest) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RevokeServiceAccountKeyRequest) GetServiceAccountId() int64 {
+	if x != nil {
+		return x.ServiceAccountId
+	}
+	return 0
+}
+
+func (x *RevokeServiceAccountKeyRequest) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+type ServiceAccountRoleRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ServiceAccountId int64                  `protobuf:"varint,1,opt,name=service_account_id,json=serviceAccountId,proto3" json:"service_account_id,omitempty"`
+	RoleId           int64                  `protobuf:"varint,2,opt,name=role_id,json=roleId,proto3" json:"role_id,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ServiceAccountRoleRequest) Reset() {
+	*x = ServiceAccountRoleRequest{}
+	mi := &file_sso_service_accounts_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceAccountRoleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceAccountRoleRequest) ProtoMessage() {}
+
+func (x *ServiceAccountRoleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceAccountRoleRequest.ProtoReflect.Descriptor instead.
+func (*ServiceAccountRoleRequest) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ServiceAccountRoleRequest) GetServiceAccountId() int64 {
+	if x != nil {
+		return x.ServiceAccountId
+	}
+	return 0
+}
+
+func (x *ServiceAccountRoleRequest) GetRoleId() int64 {
+	if x != nil {
+		return x.RoleId
+	}
+	return 0
+}
+
+type ExchangeAssertionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Assertion     string                 `protobuf:"bytes,1,opt,name=assertion,proto3" json:"assertion,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExchangeAssertionRequest) Reset() {
+	*x = ExchangeAssertionRequest{}
+	mi := &file_sso_service_accounts_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExchangeAssertionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExchangeAssertionRequest) ProtoMessage() {}
+
+func (x *ExchangeAssertionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExchangeAssertionRequest.ProtoReflect.Descriptor instead.
+func (*ExchangeAssertionRequest) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ExchangeAssertionRequest) GetAssertion() string {
+	if x != nil {
+		return x.Assertion
+	}
+	return ""
+}
+
+type ExchangeAssertionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccessToken   string                 `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	ExpiresIn     int64                  `protobuf:"varint,2,opt,name=expires_in,json=expiresIn,proto3" json:"expires_in,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExchangeAssertionResponse) Reset() {
+	*x = ExchangeAssertionResponse{}
+	mi := &file_sso_service_accounts_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExchangeAssertionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExchangeAssertionResponse) ProtoMessage() {}
+
+func (x *ExchangeAssertionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_service_accounts_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExchangeAssertionResponse.ProtoReflect.Descriptor instead.
+func (*ExchangeAssertionResponse) Descriptor() ([]byte, []int) {
+	return file_sso_service_accounts_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ExchangeAssertionResponse) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *ExchangeAssertionResponse) GetExpiresIn() int64 {
+	if x != nil {
+		return x.ExpiresIn
+	}
+	return 0
+}
+
+var File_sso_service_accounts_proto protoreflect.FileDescriptor
+
+const file_sso_service_accounts_proto_rawDesc = "" +
+	"\n" +
+	"\x1asso/service_accounts.proto\x12\x04auth\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9b\x02\n" +
+	"\x0eServiceAccount\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x15\n" +
+	"\x06app_id\x18\x02 \x01(\x05R\x05appId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x1a\n" +
+	"\bdisabled\x18\x05 \x01(\bR\bdisabled\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x06 \x01(\x03R\tcreatedBy\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x14\n" +
+	"\x05roles\x18\b \x03(\tR\x05roles\x12 \n" +
+	"\vpermissions\x18\t \x03(\tR\vpermissions\"\xb7\x01\n" +
+	"\x11ServiceAccountKey\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\talgorithm\x18\x02 \x01(\tR\talgorithm\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"j\n" +
+	"\x1bCreateServiceAccountRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x05R\x05appId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\"*\n" +
+	"\x18GetServiceAccountRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"3\n" +
+	"\x1aListServiceAccountsRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x05R\x05appId\"^\n" +
+	"\x1bListServiceAccountsResponse\x12?\n" +
+	"\x10service_accounts\x18\x01 \x03(\v2\x14.auth.ServiceAccountR\x0fserviceAccounts\"N\n" +
+	" SetServiceAccountDisabledRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1a\n" +
+	"\bdisabled\x18\x02 \x01(\bR\bdisabled\"-\n" +
+	"\x1bDeleteServiceAccountRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xa5\x01\n" +
+	"\x1bAddServiceAccountKeyRequest\x12,\n" +
+	"\x12service_account_id\x18\x01 \x01(\x03R\x10serviceAccountId\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\tR\tpublicKey\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"M\n" +
+	"\x1dListServiceAccountKeysRequest\x12,\n" +
+	"\x12service_account_id\x18\x01 \x01(\x03R\x10serviceAccountId\"M\n" +
+	"\x1eListServiceAccountKeysResponse\x12+\n" +
+	"\x04keys\x18\x01 \x03(\v2\x17.auth.ServiceAccountKeyR\x04keys\"e\n" +
+	"\x1eRevokeServiceAccountKeyRequest\x12,\n" +
+	"\x12service_account_id\x18\x01 \x01(\x03R\x10serviceAccountId\x12\x15\n" +
+	"\x06key_id\x18\x02 \x01(\tR\x05keyId\"b\n" +
+	"\x19ServiceAccountRoleRequest\x12,\n" +
+	"\x12service_account_id\x18\x01 \x01(\x03R\x10serviceAccountId\x12\x17\n" +
+	"\arole_id\x18\x02 \x01(\x03R\x06roleId\"8\n" +
+	"\x18ExchangeAssertionRequest\x12\x1c\n" +
+	"\tassertion\x18\x01 \x01(\tR\tassertion\"]\n" +
+	"\x19ExchangeAssertionResponse\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\x12\x1d\n" +
+	"\n" +
+	"expires_in\x18\x02 \x01(\x03R\texpiresIn2\xcb\a\n" +
+	"\x0fServiceAccounts\x12O\n" +
+	"\x14CreateServiceAccount\x12!.auth.CreateServiceAccountRequest\x1a\x14.auth.ServiceAccount\x12I\n" +
+	"\x11GetServiceAccount\x12\x1e.auth.GetServiceAccountRequest\x1a\x14.auth.ServiceAccount\x12Z\n" +
+	"\x13ListServiceAccounts\x12 .auth.ListServiceAccountsRequest\x1a!.auth.ListServiceAccountsResponse\x12[\n" +
+	"\x19SetServiceAccountDisabled\x12&.auth.SetServiceAccountDisabledRequest\x1a\x16.google.protobuf.Empty\x12Q\n" +
+	"\x14DeleteServiceAccount\x12!.auth.DeleteServiceAccountRequest\x1a\x16.google.protobuf.Empty\x12R\n" +
+	"\x14AddServiceAccountKey\x12!.auth.AddServiceAccountKeyRequest\x1a\x17.auth.ServiceAccountKey\x12c\n" +
+	"\x16ListServiceAccountKeys\x12#.auth.ListServiceAccountKeysRequest\x1a$.auth.ListServiceAccountKeysResponse\x12W\n" +
+	"\x17RevokeServiceAccountKey\x12$.auth.RevokeServiceAccountKeyRequest\x1a\x16.google.protobuf.Empty\x12S\n" +
+	"\x18AssignServiceAccountRole\x12\x1f.auth.ServiceAccountRoleRequest\x1a\x16.google.protobuf.Empty\x12S\n" +
+	"\x18RevokeServiceAccountRole\x12\x1f.auth.ServiceAccountRoleRequest\x1a\x16.google.protobuf.Empty\x12T\n" +
+	"\x11ExchangeAssertion\x12\x1e.auth.ExchangeAssertionRequest\x1a\x1f.auth.ExchangeAssertionResponseB\x17Z\x15auth/gen/go/sso;ssov1b\x06proto3"
+
+var (
+	file_sso_service_accounts_proto_rawDescOnce sync.Once
+	file_sso_service_accounts_proto_rawDescData []byte
+)
+
+func file_sso_service_accounts_proto_rawDescGZIP() []byte {
+	file_sso_service_accounts_proto_rawDescOnce.Do(func() {
+		file_sso_service_accounts_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sso_service_accounts_proto_rawDesc), len(file_sso_service_accounts_proto_rawDesc)))
+	})
+	return file_sso_service_accounts_proto_rawDescData
+}
+
+var file_sso_service_accounts_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_sso_service_accounts_proto_goTypes = []any{
+	(*ServiceAccount)(nil),                   // 0: auth.ServiceAccount
+	(*ServiceAccountKey)(nil),                // 1: auth.ServiceAccountKey
+	(*CreateServiceAccountRequest)(nil),      // 2: auth.CreateServiceAccountRequest
+	(*GetServiceAccountRequest)(nil),         // 3: auth.GetServiceAccountRequest
+	(*ListServiceAccountsRequest)(nil),       // 4: auth.ListServiceAccountsRequest
+	(*ListServiceAccountsResponse)(nil),      // 5: auth.ListServiceAccountsResponse
+	(*SetServiceAccountDisabledRequest)(nil), // 6: auth.SetServiceAccountDisabledRequest
+	(*DeleteServiceAccountRequest)(nil),      // 7: auth.DeleteServiceAccountRequest
+	(*AddServiceAccountKeyRequest)(nil),      // 8: auth.AddServiceAccountKeyRequest
+	(*ListServiceAccountKeysRequest)(nil),    // 9: auth.ListServiceAccountKeysRequest
+	(*ListServiceAccountKeysResponse)(nil),   // 10: auth.ListServiceAccountKeysResponse
+	(*RevokeServiceAccountKeyRequest)(nil),   // 11: auth.RevokeServiceAccountKeyRequest
+	(*ServiceAccountRoleRequest)(nil),        // 12: auth.ServiceAccountRoleRequest
+	(*ExchangeAssertionRequest)(nil),         // 13: auth.ExchangeAssertionRequest
+	(*ExchangeAssertionResponse)(nil),        // 14: auth.ExchangeAssertionResponse
+	(*timestamppb.Timestamp)(nil),            // 15: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),                    // 16: google.protobuf.Empty
+}
+var file_sso_service_accounts_proto_depIdxs = []int32{
+	15, // 0: auth.ServiceAccount.created_at:type_name -> google.protobuf.Timestamp
+	15, // 1: auth.ServiceAccountKey.expires_at:type_name -> google.protobuf.Timestamp
+	15, // 2: auth.ServiceAccountKey.created_at:type_name -> google.protobuf.Timestamp
+	0,  // 3: auth.ListServiceAccountsResponse.service_accounts:type_name -> auth.ServiceAccount
+	15, // 4: auth.AddServiceAccountKeyRequest.expires_at:type_name -> google.protobuf.Timestamp
+	1,  // 5: auth.ListServiceAccountKeysResponse.keys:type_name -> auth.ServiceAccountKey
+	2,  // 6: auth.ServiceAccounts.CreateServiceAccount:input_type -> auth.CreateServiceAccountRequest
+	3,  // 7: auth.ServiceAccounts.GetServiceAccount:input_type -> auth.GetServiceAccountRequest
+	4,  // 8: auth.ServiceAccounts.ListServiceAccounts:input_type -> auth.ListServiceAccountsRequest
+	6,  // 9: auth.ServiceAccounts.SetServiceAccountDisabled:input_type -> auth.SetServiceAccountDisabledRequest
+	7,  // 10: auth.ServiceAccounts.DeleteServiceAccount:input_type -> auth.DeleteServiceAccountRequest
+	8,  // 11: auth.ServiceAccounts.AddServiceAccountKey:input_type -> auth.AddServiceAccountKeyRequest
+	9,  // 12: auth.ServiceAccounts.ListServiceAccountKeys:input_type -> auth.ListServiceAccountKeysRequest
+	11, // 13: auth.ServiceAccounts.RevokeServiceAccountKey:input_type -> auth.RevokeServiceAccountKeyRequest
+	12, // 14: auth.ServiceAccounts.AssignServiceAccountRole:input_type -> auth.ServiceAccountRoleRequest
+	12, // 15: auth.ServiceAccounts.RevokeServiceAccountRole:input_type -> auth.ServiceAccountRoleRequest
+	13, // 16: auth.ServiceAccounts.ExchangeAssertion:input_type -> auth.ExchangeAssertionRequest
+	0,  // 17: auth.ServiceAccounts.CreateServiceAccount:output_type -> auth.ServiceAccount
+	0,  // 18: auth.ServiceAccounts.GetServiceAccount:output_type -> auth.ServiceAccount
+	5,  // 19: auth.ServiceAccounts.ListServiceAccounts:output_type -> auth.ListServiceAccountsResponse
+	16, // 20: auth.ServiceAccounts.SetServiceAccountDisabled:output_type -> google.protobuf.Empty
+	16, // 21: auth.ServiceAccounts.DeleteServiceAccount:output_type -> google.protobuf.Empty
+	1,  // 22: auth.ServiceAccounts.AddServiceAccountKey:output_type -> auth.ServiceAccountKey
+	10, // 23: auth.ServiceAccounts.ListServiceAccountKeys:output_type -> auth.ListServiceAccountKeysResponse
+	16, // 24: auth.ServiceAccounts.RevokeServiceAccountKey:output_type -> google.protobuf.Empty
+	16, // 25: auth.ServiceAccounts.AssignServiceAccountRole:output_type -> google.protobuf.Empty
+	16, // 26: auth.ServiceAccounts.RevokeServiceAccountRole:output_type -> google.protobuf.Empty
+	14, // 27: auth.ServiceAccounts.ExchangeAssertion:output_type -> auth.ExchangeAssertionResponse
+	17, // [17:28] is the sub-list for method output_type
+	6,  // [6:17] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_sso_service_accounts_proto_init() }
+func file_sso_service_accounts_proto_init() {
+	if File_sso_service_accounts_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sso_service_accounts_proto_rawDesc), len(file_sso_service_accounts_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sso_service_accounts_proto_goTypes,
+		DependencyIndexes: file_sso_service_accounts_proto_depIdxs,
+		MessageInfos:      file_sso_service_accounts_proto_msgTypes,
+	}.Build()
+	File_sso_service_accounts_proto = out.File
+	file_sso_service_accounts_proto_goTypes = nil
+	file_sso_service_accounts_proto_depIdxs = nil
+}
@@ -0,0 +1,125 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: sso/sso.proto
+
+package ssov1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SSO_ExchangeCode_FullMethodName = "/auth.SSO/ExchangeCode"
+)
+
+// SSOClient is the client API for SSO service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SSO lets apps exchange the codes of the central sign-in for tokens.
+type SSOClient interface {
+	ExchangeCode(ctx context.Context, in *ExchangeSSOCodeRequest, opts ...grpc.CallOption) (*ExchangeSSOCodeResponse, error)
+}
+
+type sSOClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSSOClient(cc grpc.ClientConnInterface) SSOClient {
+	return &sSOClient{cc}
+}
+
+func (c *sSOClient) ExchangeCode(ctx context.Context, in *ExchangeSSOCodeRequest, opts ...grpc.CallOption) (*ExchangeSSOCodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExchangeSSOCodeResponse)
+	err := c.cc.Invoke(ctx, SSO_ExchangeCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SSOServer is the server API for SSO service.
+// All implementations must embed UnimplementedSSOServer
+// for forward compatibility.
+//
+// SSO lets apps exchange the codes of the central sign-in for tokens.
+type SSOServer interface {
+	ExchangeCode(context.Context, *ExchangeSSOCodeRequest) (*ExchangeSSOCodeResponse, error)
+	mustEmbedUnimplementedSSOServer()
+}
+
+// UnimplementedSSOServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSSOServer struct{}
+
+func (UnimplementedSSOServer) ExchangeCode(context.Context, *ExchangeSSOCodeRequest) (*ExchangeSSOCodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExchangeCode not implemented")
+}
+func (UnimplementedSSOServer) mustEmbedUnimplementedSSOServer() {}
+func (UnimplementedSSOServer) testEmbeddedByValue()             {}
+
+// UnsafeSSOServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SSOServer will
+// result in compilation errors.
+type UnsafeSSOServer interface {
+	mustEmbedUnimplementedSSOServer()
+}
+
+func RegisterSSOServer(s grpc.ServiceRegistrar, srv SSOServer) {
+	// If the following call pancis, it indicates UnimplementedSSOServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SSO_ServiceDesc, srv)
+}
+
+func _SSO_ExchangeCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExchangeSSOCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SSOServer).ExchangeCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SSO_ExchangeCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SSOServer).ExchangeCode(ctx, req.(*ExchangeSSOCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SSO_ServiceDesc is the grpc.ServiceDesc for SSO service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SSO_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "auth.SSO",
+	HandlerType: (*SSOServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ExchangeCode",
+			Handler:    _SSO_ExchangeCode_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "sso/sso.proto",
+}
@@ -2,18 +2,64 @@ package app
 
 import (
 	grpcapp "auth/internal/app/grpc"
+	httpapp "auth/internal/app/http"
 	"auth/internal/config"
+	"auth/internal/domain/models"
+	"auth/internal/repository/loginstate"
 	"auth/internal/repository/pg"
 	"auth/internal/repository/refresh"
+	"auth/internal/repository/revocations"
+	"auth/internal/repository/ssosessions"
+	"auth/internal/services/admin"
+	"auth/internal/services/apps"
 	"auth/internal/services/auth"
+	"auth/internal/services/authz"
+	"auth/internal/services/federation"
+	"auth/internal/services/notify"
+	"auth/internal/services/orgs"
+	"auth/internal/services/passwordless"
+	"auth/internal/services/phone"
+	"auth/internal/services/profile"
+	"auth/internal/services/provisioning"
+	"auth/internal/services/rbac"
+	revocationsvc "auth/internal/services/revocations"
+	"auth/internal/services/samlidp"
+	"auth/internal/services/serviceaccounts"
+	"auth/internal/services/sso"
+	"auth/internal/services/tokens"
+	"auth/internal/services/webhooks"
+	"auth/internal/transport/grpc/authn"
+	samlhttp "auth/internal/transport/http/saml"
+	scimhttp "auth/internal/transport/http/scim"
+	ssohttp "auth/internal/transport/http/sso"
+	"auth/pkg/ldap"
+	"auth/pkg/logger"
+	"auth/pkg/mail"
+	"auth/pkg/password"
+	"auth/pkg/saml"
+	"auth/pkg/secretbox"
+	"auth/pkg/sms"
 	"auth/pkg/storage/postgres"
 	"auth/pkg/storage/redis"
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
 	"time"
 )
 
 type App struct {
 	GRPCServer *grpcapp.App
+	HTTPServer *httpapp.App
+	// cancel stops the background jobs.
+	cancel context.CancelFunc
 }
 
 func New(log *slog.Logger, cfg config.Config) *App {
@@ -27,13 +73,355 @@ func New(log *slog.Logger, cfg config.Config) *App {
 		panic(err)
 	}
 
+	box, err := secretbox.NewFromBase64(cfg.SecretsKey)
+	if err != nil {
+		panic(err)
+	}
+
 	userRepo := pg.NewUserRepository(db)
-	appRepo := pg.NewAppRepository(db)
+	appRepo := pg.NewAppRepository(db, box)
 	refreshRepo := refresh.New(rdb)
+	ssoSessionRepo := ssosessions.New(rdb)
+	auditRepo := pg.NewAuditRepository(db)
+	roleRepo := pg.NewRoleRepository(db)
+	relationRepo := pg.NewRelationRepository(db)
+	orgRepo := pg.NewOrgRepository(db)
+	tokenRepo := pg.NewTokenRepository(db)
+	serviceAccountRepo := pg.NewServiceAccountRepository(db)
+	webhookRepo := pg.NewWebhookRepository(db, box)
+	revocationFeed := revocations.New(rdb, cfg.Revocations.FeedLength)
+	federationRepo := pg.NewFederationRepository(db, box)
 
-	authService := auth.New(log, userRepo, appRepo, refreshRepo, time.Duration(time.Minute*15), time.Duration(time.Hour*24*15))
+	if n, err := appRepo.EncryptLegacySecrets(context.Background()); err != nil {
+		log.Error("failed to encrypt legacy app secrets", logger.Err(err))
+	} else if n > 0 {
+		log.Info("encrypted legacy app secrets", slog.Int("count", n))
+	}
 
-	grpcApp := grpcapp.New(log, *authService, cfg.GRPCServerPort)
+	if cfg.Invitations.SigningKey == "" {
+		panic("INVITATION_SIGNING_KEY is not set")
+	}
+
+	if len(cfg.Impersonation.Scopes) == 0 {
+		panic("IMPERSONATION_SCOPES is empty")
+	}
+	for _, scope := range cfg.Impersonation.Scopes {
+		if !slices.Contains(models.TokenScopes, scope) {
+			panic("unknown scope in IMPERSONATION_SCOPES: " + scope)
+		}
+	}
+
+	passwordPolicy, err := password.NewFromConfig(cfg.Password)
+	if err != nil {
+		panic(err)
+	}
+
+	var backends []auth.CredentialBackend
+	if cfg.LDAP.URL != "" {
+		backends = append(backends, directoryBackend(log, cfg.LDAP, userRepo, roleRepo))
+	}
+
+	authService := auth.New(log, userRepo, appRepo, roleRepo, orgRepo, refreshRepo, ssoSessionRepo, auditRepo, passwordPolicy, auth.SessionPolicy{
+		AccessTTL:           cfg.Session.AccessTTL,
+		RefreshTTL:          cfg.Session.RefreshTTL,
+		RefreshIdleTimeout:  cfg.Session.RefreshIdleTimeout,
+		MaxSessions:         cfg.Session.MaxSessions,
+		MaxAuthzClaimsBytes: cfg.Session.MaxAuthzClaimsBytes,
+		Issuer:              cfg.SSO.Issuer,
+	}, auth.InvitationPolicy{
+		SigningKey: cfg.Invitations.SigningKey,
+		InviteOnly: cfg.Invitations.InviteOnly,
+	}, revocationFeed, backends...)
+
+	profileService := profile.New(log, userRepo)
+	adminService := admin.New(log, userRepo, refreshRepo, ssoSessionRepo, auditRepo, authService, notify.NewLogNotifier(log), admin.ImpersonationPolicy{
+		TTL:        cfg.Impersonation.TTL,
+		Scopes:     cfg.Impersonation.Scopes,
+		NotifyUser: cfg.Impersonation.NotifyUser,
+	}, revocationFeed)
+	appService := apps.New(log, appRepo, userRepo, auditRepo, revocationFeed)
+	rbacService := rbac.New(log, roleRepo, userRepo, auditRepo)
+	authzService := authz.New(log, relationRepo, userRepo, auditRepo)
+	orgService := orgs.New(log, orgRepo, userRepo, auditRepo, orgs.InvitationSettings{
+		SigningKey: cfg.Invitations.SigningKey,
+		TTL:        cfg.Invitations.TTL,
+		AcceptURL:  cfg.Invitations.AcceptURL,
+	}, orgs.Policy{
+		// Texted codes are the only second factor.
+		MFAAvailable: cfg.Phone.SecondFactor,
+	})
+
+	tokenService := tokens.New(log, tokenRepo, userRepo, auditRepo, authService, revocationFeed)
+	serviceAccountService := serviceaccounts.New(log, serviceAccountRepo, appRepo, roleRepo, userRepo, auditRepo, serviceaccounts.AssertionPolicy{
+		Audience:            cfg.ServiceAccounts.AssertionAudience,
+		MaxAssertionTTL:     cfg.ServiceAccounts.MaxAssertionTTL,
+		AccessTTL:           cfg.Session.AccessTTL,
+		MaxAuthzClaimsBytes: cfg.Session.MaxAuthzClaimsBytes,
+	}, revocationFeed)
+
+	webhookService := webhooks.New(log, webhookRepo, userRepo, auditRepo)
+
+	if cfg.Revocations.BatchSize <= 0 || cfg.Revocations.PollTimeout <= 0 {
+		panic("REVOCATION_BATCH_SIZE and REVOCATION_POLL_TIMEOUT must be positive")
+	}
+	revocationService := revocationsvc.New(log, revocationFeed, userRepo, revocationsvc.WatchPolicy{
+		BatchSize:   cfg.Revocations.BatchSize,
+		PollTimeout: cfg.Revocations.PollTimeout,
+	})
 
-	return &App{GRPCServer: grpcApp}
+	if cfg.Federation.StateTTL <= 0 || cfg.Federation.HTTPTimeout <= 0 {
+		panic("FEDERATION_STATE_TTL and FEDERATION_HTTP_TIMEOUT must be positive")
+	}
+	federationService := federation.New(log, federationRepo, userRepo, appRepo, loginstate.New(rdb), authService, auditRepo,
+		federation.OIDCConnectors(&http.Client{Timeout: cfg.Federation.HTTPTimeout}), federation.Policy{
+			StateTTL:   cfg.Federation.StateTTL,
+			InviteOnly: cfg.Invitations.InviteOnly,
+		})
+
+	if cfg.Passwordless.TTL <= 0 || cfg.Passwordless.MaxAttempts <= 0 || !strings.Contains(cfg.Passwordless.LinkURL, "{token}") {
+		panic("PASSWORDLESS_TTL and PASSWORDLESS_MAX_ATTEMPTS must be positive and PASSWORDLESS_LINK_URL must contain {token}")
+	}
+	passwordlessService := passwordless.New(log, userRepo, appRepo, loginstate.New(rdb), mailSender(log, cfg.Mail), authService, auditRepo, passwordless.Policy{
+		TTL:         cfg.Passwordless.TTL,
+		MaxAttempts: cfg.Passwordless.MaxAttempts,
+		LinkURL:     cfg.Passwordless.LinkURL,
+		InviteOnly:  cfg.Invitations.InviteOnly,
+	})
+
+	if cfg.Phone.TTL <= 0 || cfg.Phone.MaxAttempts <= 0 || cfg.Phone.NumberLimit <= 0 || cfg.Phone.NumberWindow <= 0 ||
+		cfg.Phone.PrefixLimit <= 0 || cfg.Phone.PrefixWindow <= 0 || cfg.Phone.PrefixDigits <= 0 {
+		panic("PHONE_CODE_TTL, PHONE_MAX_ATTEMPTS and the SMS_NUMBER_* and SMS_PREFIX_* limits must be positive")
+	}
+	// No SMS provider is integrated yet, so codes only go to the log.
+	phoneService := phone.New(log, userRepo, appRepo, loginstate.New(rdb), sms.NewLogSender(log), authService, auditRepo, phone.Policy{
+		TTL:          cfg.Phone.TTL,
+		MaxAttempts:  cfg.Phone.MaxAttempts,
+		InviteOnly:   cfg.Invitations.InviteOnly,
+		NumberLimit:  cfg.Phone.NumberLimit,
+		NumberWindow: cfg.Phone.NumberWindow,
+		PrefixLimit:  cfg.Phone.PrefixLimit,
+		PrefixWindow: cfg.Phone.PrefixWindow,
+		PrefixDigits: cfg.Phone.PrefixDigits,
+	})
+
+	mux := http.NewServeMux()
+
+	var samlService *samlidp.SAMLService
+	if cfg.SAML.KeyFile != "" {
+		samlService = samlidp.New(log, identityProvider(cfg.SAML), pg.NewSAMLRepository(db), userRepo, appRepo, roleRepo,
+			loginstate.New(rdb), authService, auditRepo, samlidp.Policy{
+				LoginURL:   cfg.SAML.LoginURL,
+				RequestTTL: cfg.SAML.RequestTTL,
+			})
+		samlhttp.Register(mux, samlService)
+	}
+
+	loginURL, err := url.Parse(cfg.SSO.LoginURL)
+	if err != nil || loginURL.Host == "" || !strings.Contains(cfg.SSO.LoginURL, "{request}") {
+		panic("SSO_LOGIN_URL must be an absolute URL containing {request}")
+	}
+	if cfg.SSO.SessionTTL <= 0 || cfg.SSO.RequestTTL <= 0 || cfg.SSO.CodeTTL <= 0 || cfg.SSO.CookieName == "" {
+		panic("SSO_SESSION_TTL, SSO_REQUEST_TTL and SSO_CODE_TTL must be positive and SSO_COOKIE_NAME must be set")
+	}
+	if cfg.SSO.Issuer == "" {
+		panic("SSO_ISSUER must be set")
+	}
+	logoutRepo := pg.NewLogoutRepository(db)
+	ssoService := sso.New(log, appRepo, ssoSessionRepo, loginstate.New(rdb), refreshRepo, authService, logoutRepo, auditRepo, sso.Policy{
+		LoginURL:   cfg.SSO.LoginURL,
+		SessionTTL: cfg.SSO.SessionTTL,
+		RequestTTL: cfg.SSO.RequestTTL,
+		CodeTTL:    cfg.SSO.CodeTTL,
+		Issuer:     cfg.SSO.Issuer,
+	})
+	ssohttp.Register(mux, ssoService, ssohttp.Settings{
+		CookieName:   cfg.SSO.CookieName,
+		CookieSecure: cfg.SSO.CookieSecure,
+		LoginOrigin:  loginURL.Scheme + "://" + loginURL.Host,
+	})
+
+	provisioningService := provisioning.New(log, pg.NewSCIMRepository(db), userRepo, passwordPolicy, refreshRepo, ssoSessionRepo, revocationFeed, auditRepo)
+	scimhttp.Register(mux, provisioningService, authn.Scoped(tokenService, models.ScopeAdmin), cfg.SCIM.BaseURL)
+
+	grpcApp := grpcapp.New(log, grpcapp.Services{
+		Auth:            *authService,
+		Profile:         *profileService,
+		Admin:           *adminService,
+		Apps:            *appService,
+		RBAC:            *rbacService,
+		Authz:           *authzService,
+		Orgs:            *orgService,
+		Tokens:          *tokenService,
+		ServiceAccounts: *serviceAccountService,
+		Webhooks:        *webhookService,
+		Revocations:     *revocationService,
+		Federation:      *federationService,
+		Passwordless:    *passwordlessService,
+		Phone:           *phoneService,
+		SSO:             *ssoService,
+		SAML:            samlService,
+	}, cfg.GRPCServerPort)
+	httpApp := httpapp.New(log, mux, cfg.HTTPServerPort)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if cfg.Audit.Retention > 0 {
+		if cfg.Audit.PruneInterval <= 0 {
+			panic("AUDIT_PRUNE_INTERVAL must be positive when AUDIT_RETENTION is set")
+		}
+		go pruneAuditLog(ctx, log, adminService, cfg.Audit)
+	}
+
+	if cfg.Webhooks.DispatchInterval <= 0 || cfg.Webhooks.BatchSize <= 0 || cfg.Webhooks.MaxAttempts <= 0 || cfg.Webhooks.Timeout <= 0 {
+		panic("WEBHOOK_DISPATCH_INTERVAL, WEBHOOK_BATCH_SIZE, WEBHOOK_MAX_ATTEMPTS and WEBHOOK_TIMEOUT must be positive")
+	}
+	dispatcher := webhooks.NewDispatcher(log, webhookRepo, webhooks.DispatchPolicy{
+		Interval:    cfg.Webhooks.DispatchInterval,
+		BatchSize:   cfg.Webhooks.BatchSize,
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		RetryBase:   cfg.Webhooks.RetryBase,
+		RetryMax:    cfg.Webhooks.RetryMax,
+		Timeout:     cfg.Webhooks.Timeout,
+	})
+	go dispatcher.Run(ctx)
+
+	if cfg.SSO.LogoutTokenTTL <= 0 || cfg.SSO.LogoutDispatchInterval <= 0 || cfg.SSO.LogoutBatchSize <= 0 ||
+		cfg.SSO.LogoutMaxAttempts <= 0 || cfg.SSO.LogoutTimeout <= 0 {
+		panic("SSO_LOGOUT_TOKEN_TTL, SSO_LOGOUT_DISPATCH_INTERVAL, SSO_LOGOUT_BATCH_SIZE, SSO_LOGOUT_MAX_ATTEMPTS and SSO_LOGOUT_TIMEOUT must be positive")
+	}
+	logoutDispatcher := sso.NewLogoutDispatcher(log, appRepo, logoutRepo, sso.LogoutPolicy{
+		Issuer:      cfg.SSO.Issuer,
+		TokenTTL:    cfg.SSO.LogoutTokenTTL,
+		Interval:    cfg.SSO.LogoutDispatchInterval,
+		BatchSize:   cfg.SSO.LogoutBatchSize,
+		MaxAttempts: cfg.SSO.LogoutMaxAttempts,
+		RetryBase:   cfg.SSO.LogoutRetryBase,
+		RetryMax:    cfg.SSO.LogoutRetryMax,
+		Timeout:     cfg.SSO.LogoutTimeout,
+	})
+	go logoutDispatcher.Run(ctx)
+
+	return &App{GRPCServer: grpcApp, HTTPServer: httpApp, cancel: cancel}
+}
+
+// Stop shuts down the servers and the background jobs.
+func (a *App) Stop() {
+	a.GRPCServer.Stop()
+	a.HTTPServer.Stop()
+	a.cancel()
+}
+
+func directoryBackend(log *slog.Logger, cfg config.LDAPConfig, userRepo *pg.UserRepository, roleRepo *pg.RoleRepository) *auth.DirectoryBackend {
+	if cfg.BaseDN == "" || !strings.Contains(cfg.UserFilter, "{email}") {
+		panic("LDAP_BASE_DN must be set and LDAP_USER_FILTER must contain {email}")
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			panic(err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			panic("no certificates in LDAP_CA_FILE")
+		}
+	}
+
+	client := ldap.New(ldap.Config{
+		URL:          cfg.URL,
+		StartTLS:     cfg.StartTLS,
+		TLS:          tlsConfig,
+		BindDN:       cfg.BindDN,
+		BindPassword: cfg.BindPassword,
+		BaseDN:       cfg.BaseDN,
+		UserFilter:   cfg.UserFilter,
+		EmailAttr:    cfg.EmailAttr,
+		GroupAttr:    cfg.GroupAttr,
+		Timeout:      cfg.Timeout,
+	})
+
+	return auth.NewDirectoryBackend(log, client, userRepo, roleRepo, cfg.GroupRoles)
+}
+
+// mailSender sends mail through the configured SMTP server, or only logs it when there is none.
+func mailSender(log *slog.Logger, cfg config.MailConfig) passwordless.MailSender {
+	if cfg.SMTPAddr == "" {
+		return mail.NewLogSender(log)
+	}
+	return mail.NewSMTPSender(mail.Config{
+		Addr:     cfg.SMTPAddr,
+		From:     cfg.From,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		Timeout:  cfg.Timeout,
+	})
+}
+
+func identityProvider(cfg config.SAMLConfig) *saml.IdentityProvider {
+	if cfg.CertFile == "" || cfg.RequestTTL <= 0 || cfg.AssertionTTL <= 0 || !strings.Contains(cfg.LoginURL, "{request}") {
+		panic("SAML_CERT_FILE must be set, SAML_REQUEST_TTL and SAML_ASSERTION_TTL must be positive and SAML_LOGIN_URL must contain {request}")
+	}
+
+	keyPEM, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		panic(err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		panic("no PEM data in SAML_KEY_FILE")
+	}
+	var key *rsa.PrivateKey
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		key, _ = parsed.(*rsa.PrivateKey)
+	} else {
+		key, _ = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	if key == nil {
+		panic("SAML_KEY_FILE must hold an RSA private key")
+	}
+
+	certPEM, err := os.ReadFile(cfg.CertFile)
+	if err != nil {
+		panic(err)
+	}
+	block, _ = pem.Decode(certPEM)
+	if block == nil {
+		panic("no PEM data in SAML_CERT_FILE")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		panic(err)
+	}
+
+	baseURL := strings.TrimRight(cfg.BaseURL, "/") + "/saml"
+	entityID := cfg.EntityID
+	if entityID == "" {
+		entityID = baseURL + "/metadata"
+	}
+
+	return &saml.IdentityProvider{
+		EntityID:     entityID,
+		SSOURL:       baseURL + "/sso",
+		SLOURL:       baseURL + "/slo",
+		Key:          key,
+		Certificate:  cert,
+		AssertionTTL: cfg.AssertionTTL,
+	}
+}
+
+// pruneAuditLog deletes audit entries past their retention, once at startup and then every interval.
+func pruneAuditLog(ctx context.Context, log *slog.Logger, adminService *admin.AdminService, cfg config.AuditConfig) {
+	ticker := time.NewTicker(cfg.PruneInterval)
+	defer ticker.Stop()
+
+	for {
+		// Errors are logged by the service; the next run tries again.
+		_, _ = adminService.PruneAuditLog(ctx, cfg.Retention)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
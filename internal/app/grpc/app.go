@@ -5,24 +5,85 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"time"
 
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/services/admin"
+	"auth/internal/services/apps"
 	"auth/internal/services/auth"
+	"auth/internal/services/authz"
+	"auth/internal/services/federation"
+	"auth/internal/services/orgs"
+	"auth/internal/services/passwordless"
+	"auth/internal/services/phone"
+	"auth/internal/services/profile"
+	"auth/internal/services/rbac"
+	"auth/internal/services/revocations"
+	"auth/internal/services/samlidp"
+	"auth/internal/services/serviceaccounts"
+	"auth/internal/services/sso"
+	"auth/internal/services/tokens"
+	"auth/internal/services/webhooks"
+	admingrpc "auth/internal/transport/grpc/admin"
+	appsgrpc "auth/internal/transport/grpc/apps"
 	authgrpc "auth/internal/transport/grpc/auth"
-
+	"auth/internal/transport/grpc/authn"
+	authzgrpc "auth/internal/transport/grpc/authz"
+	federationgrpc "auth/internal/transport/grpc/federation"
+	orgsgrpc "auth/internal/transport/grpc/orgs"
+	passwordlessgrpc "auth/internal/transport/grpc/passwordless"
+	phonegrpc "auth/internal/transport/grpc/phone"
+	profilegrpc "auth/internal/transport/grpc/profile"
+	rbacgrpc "auth/internal/transport/grpc/rbac"
+	revocationsgrpc "auth/internal/transport/grpc/revocations"
+	samlgrpc "auth/internal/transport/grpc/saml"
+	serviceaccountsgrpc "auth/internal/transport/grpc/serviceaccounts"
+	ssogrpc "auth/internal/transport/grpc/sso"
+	tokensgrpc "auth/internal/transport/grpc/tokens"
+	webhooksgrpc "auth/internal/transport/grpc/webhooks"
+	"auth/pkg/requestmeta"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/selector"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
+const gracefulStopTimeout = 10 * time.Second
+
 type App struct {
 	log        *slog.Logger
 	gRPCServer *grpc.Server
 	port       int
 }
 
-func New(log *slog.Logger, authService auth.AuthService, port int) *App {
+type Services struct {
+	Auth            auth.AuthService
+	Profile         profile.ProfileService
+	Admin           admin.AdminService
+	Apps            apps.AppService
+	RBAC            rbac.RBACService
+	Authz           authz.AuthzService
+	Orgs            orgs.OrgService
+	Tokens          tokens.TokenService
+	ServiceAccounts serviceaccounts.ServiceAccountService
+	Webhooks        webhooks.WebhookService
+	Revocations     revocations.RevocationService
+	Federation      federation.FederationService
+	Passwordless    passwordless.PasswordlessService
+	Phone           phone.PhoneService
+	SSO             sso.SSOService
+	// SAML is nil unless the service acts as a SAML identity provider.
+	SAML *samlidp.SAMLService
+}
+
+func New(log *slog.Logger, services Services, port int) *App {
 	loggingOpts := []logging.Option{
 		logging.WithLogOnEvents(
 			logging.PayloadReceived, logging.PayloadSent,
@@ -39,10 +100,46 @@ func New(log *slog.Logger, authService auth.AuthService, port int) *App {
 
 	gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
 		recovery.UnaryServerInterceptor(recoveryOpts...),
-		logging.UnaryServerInterceptor(InterceptorLogger(log), loggingOpts...),
+		RequestMetaInterceptor(),
+		selector.UnaryServerInterceptor(
+			logging.UnaryServerInterceptor(InterceptorLogger(log), loggingOpts...),
+			selector.MatchFunc(func(_ context.Context, c interceptors.CallMeta) bool { return !carriesSecrets(c) }),
+		),
+		// Calls carrying secrets are logged without their payloads.
+		selector.UnaryServerInterceptor(
+			logging.UnaryServerInterceptor(InterceptorLogger(log), logging.WithLogOnEvents(logging.StartCall, logging.FinishCall)),
+			selector.MatchFunc(func(_ context.Context, c interceptors.CallMeta) bool { return carriesSecrets(c) }),
+		),
+	), grpc.ChainStreamInterceptor(
+		recovery.StreamServerInterceptor(recoveryOpts...),
+		// Streams can run for hours, so only their start and end are logged.
+		logging.StreamServerInterceptor(InterceptorLogger(log), logging.WithLogOnEvents(logging.StartCall, logging.FinishCall)),
 	))
 
-	authgrpc.Register(gRPCServer, authService)
+	// Personal access tokens are only accepted by the APIs their scopes name.
+	verifier := services.Tokens
+	authgrpc.Register(gRPCServer, services.Auth, verifier)
+	profilegrpc.Register(gRPCServer, services.Profile, authn.Scoped(verifier, models.ScopeProfile))
+	admingrpc.Register(gRPCServer, services.Admin, authn.Scoped(verifier, models.ScopeAdmin))
+	appsgrpc.Register(gRPCServer, services.Apps, authn.Scoped(verifier, models.ScopeApps))
+	rbacgrpc.Register(gRPCServer, services.RBAC, authn.Scoped(verifier, models.ScopeRBAC))
+	authzgrpc.Register(gRPCServer, services.Authz, authn.Scoped(verifier, models.ScopeAuthz))
+	orgsgrpc.Register(gRPCServer, services.Orgs, authn.Scoped(verifier, models.ScopeOrgs))
+	tokensgrpc.Register(gRPCServer, services.Tokens, authn.Scoped(verifier, models.ScopeTokens))
+	serviceaccountsgrpc.Register(gRPCServer, services.ServiceAccounts, authn.Scoped(verifier, models.ScopeAdmin))
+	webhooksgrpc.Register(gRPCServer, services.Webhooks, authn.Scoped(verifier, models.ScopeApps))
+	// Resource servers watch revocations with service account tokens, so they aren't scoped.
+	revocationsgrpc.Register(gRPCServer, services.Revocations, verifier)
+	// Federated sign-in is public while provider and identity management need different scopes,
+	// so the service scopes its verifiers itself.
+	federationgrpc.Register(gRPCServer, services.Federation, verifier)
+	passwordlessgrpc.Register(gRPCServer, services.Passwordless)
+	// A verified number signs the user in, so verifying one takes a token that may edit the profile.
+	phonegrpc.Register(gRPCServer, services.Phone, authn.Scoped(verifier, models.ScopeProfile))
+	ssogrpc.Register(gRPCServer, services.SSO)
+	if services.SAML != nil {
+		samlgrpc.Register(gRPCServer, services.SAML, verifier)
+	}
 
 	return &App{
 		log:        log,
@@ -79,7 +176,67 @@ func (a *App) Stop() {
 
 	a.log.With(slog.String("op", op)).Info("stopping gRPC server", slog.Int("port", a.port))
 
-	a.gRPCServer.GracefulStop()
+	// Streams like WatchRevocations only end when their clients go away, so they are cut off
+	// once the calls that do finish have had their time.
+	stopped := make(chan struct{})
+	go func() {
+		a.gRPCServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(gracefulStopTimeout):
+		a.gRPCServer.Stop()
+	}
+}
+
+// RequestMetaInterceptor puts the client address and user agent of the call into its context,
+// where the audit log picks them up.
+func RequestMetaInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var meta requestmeta.Meta
+		if p, ok := peer.FromContext(ctx); ok {
+			meta.IP = p.Addr.String()
+			if host, _, err := net.SplitHostPort(meta.IP); err == nil {
+				meta.IP = host
+			}
+		}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if ua := md.Get("user-agent"); len(ua) > 0 {
+				meta.UserAgent = ua[0]
+			}
+		}
+
+		return handler(requestmeta.NewContext(ctx, meta), req)
+	}
+}
+
+// secretServices are the services whose calls carry passwords, one-time codes, tokens or
+// assertions in their requests or responses.
+var secretServices = map[string]bool{
+	ssov1.Auth_ServiceDesc.ServiceName:         true,
+	ssov1.SSO_ServiceDesc.ServiceName:          true,
+	ssov1.Phone_ServiceDesc.ServiceName:        true,
+	ssov1.Passwordless_ServiceDesc.ServiceName: true,
+	ssov1.Federation_ServiceDesc.ServiceName:   true,
+	ssov1.SAML_ServiceDesc.ServiceName:         true,
+	ssov1.Webhooks_ServiceDesc.ServiceName:     true,
+}
+
+// secretMethods are the methods of the other services that do.
+var secretMethods = map[string]bool{
+	ssov1.Apps_CreateApp_FullMethodName:                    true,
+	ssov1.Apps_RotateAppSecret_FullMethodName:              true,
+	ssov1.Tokens_CreateToken_FullMethodName:                true,
+	ssov1.ServiceAccounts_ExchangeAssertion_FullMethodName: true,
+	ssov1.Admin_Impersonate_FullMethodName:                 true,
+	ssov1.Orgs_CreateInvitation_FullMethodName:             true,
+	ssov1.Orgs_ResendInvitation_FullMethodName:             true,
+}
+
+func carriesSecrets(c interceptors.CallMeta) bool {
+	return secretServices[c.Service] || secretMethods[c.FullMethod()]
 }
 
 func InterceptorLogger(l *slog.Logger) logging.Logger {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"auth/pkg/password"
 	"auth/pkg/storage/postgres"
 	"auth/pkg/storage/redis"
 
@@ -12,12 +13,200 @@ import (
 )
 
 type Config struct {
-	Postgres postgres.Config
-	Redis    redis.Config
+	Postgres        postgres.Config
+	Redis           redis.Config
+	Password        password.Config
+	Session         SessionConfig
+	Invitations     InvitationConfig
+	ServiceAccounts ServiceAccountConfig
+	Impersonation   ImpersonationConfig
+	Audit           AuditConfig
+	Webhooks        WebhookConfig
+	Revocations     RevocationConfig
+	Federation      FederationConfig
+	LDAP            LDAPConfig
+	SAML            SAMLConfig
+	SCIM            SCIMConfig
+	Mail            MailConfig
+	Passwordless    PasswordlessConfig
+	Phone           PhoneConfig
+	SSO             SSOConfig
 
 	Env            string        `env:"ENV" env-default:"local"`
 	GRPCServerPort int           `env:"GRPC_SERVER_PORT"`
+	HTTPServerPort int           `env:"HTTP_SERVER_PORT" env-default:"8080"`
 	Timeout        time.Duration `env:"SERVER_TIMEOUT" env-default:"10h"`
+	// SecretsKey is the base64 encoded 32-byte key used to encrypt app secrets at rest.
+	SecretsKey string `env:"APP_SECRETS_KEY"`
+}
+
+// SessionConfig holds the token lifetimes and session limits used for apps that don't override them.
+type SessionConfig struct {
+	AccessTTL          time.Duration `env:"ACCESS_TOKEN_TTL" env-default:"15m"`
+	RefreshTTL         time.Duration `env:"REFRESH_TOKEN_TTL" env-default:"360h"`
+	RefreshIdleTimeout time.Duration `env:"REFRESH_IDLE_TIMEOUT" env-default:"0"`
+	MaxSessions        int           `env:"MAX_SESSIONS_PER_USER" env-default:"0"`
+	// MaxAuthzClaimsBytes limits how much of an access token roles and permissions may take.
+	MaxAuthzClaimsBytes int `env:"MAX_AUTHZ_CLAIMS_BYTES" env-default:"4096"`
+}
+
+// InvitationConfig controls invitation links and whether registration is open to everyone.
+type InvitationConfig struct {
+	SigningKey string        `env:"INVITATION_SIGNING_KEY"`
+	TTL        time.Duration `env:"INVITATION_TTL" env-default:"168h"`
+	// AcceptURL is the link handed to invitees; "{token}" is replaced with the invitation token.
+	AcceptURL string `env:"INVITATION_ACCEPT_URL" env-default:"http://localhost:3000/invitations/accept?token={token}"`
+	// InviteOnly requires an invitation for every registration. Single apps can be made invite-only instead.
+	InviteOnly bool `env:"INVITE_ONLY" env-default:"false"`
+}
+
+// ServiceAccountConfig controls the JWT-bearer assertions service accounts sign in with.
+type ServiceAccountConfig struct {
+	// AssertionAudience is the "aud" every assertion must carry, usually the URL of this service.
+	AssertionAudience string        `env:"SERVICE_ACCOUNT_ASSERTION_AUDIENCE" env-default:"auth"`
+	MaxAssertionTTL   time.Duration `env:"SERVICE_ACCOUNT_MAX_ASSERTION_TTL" env-default:"5m"`
+}
+
+// ImpersonationConfig limits the tokens support staff get when they sign in as a user.
+type ImpersonationConfig struct {
+	TTL        time.Duration `env:"IMPERSONATION_TTL" env-default:"15m"`
+	Scopes     []string      `env:"IMPERSONATION_SCOPES" env-separator:"," env-default:"profile,orgs"`
+	NotifyUser bool          `env:"IMPERSONATION_NOTIFY_USER" env-default:"true"`
+}
+
+// AuditConfig controls how long audit entries are kept.
+type AuditConfig struct {
+	// Retention is how old entries get before they are pruned; zero keeps them forever.
+	Retention     time.Duration `env:"AUDIT_RETENTION" env-default:"0"`
+	PruneInterval time.Duration `env:"AUDIT_PRUNE_INTERVAL" env-default:"24h"`
+}
+
+// WebhookConfig controls how identity events are delivered to webhooks.
+type WebhookConfig struct {
+	DispatchInterval time.Duration `env:"WEBHOOK_DISPATCH_INTERVAL" env-default:"5s"`
+	BatchSize        int           `env:"WEBHOOK_BATCH_SIZE" env-default:"100"`
+	// MaxAttempts is how often a delivery is tried before it is dead-lettered.
+	MaxAttempts int           `env:"WEBHOOK_MAX_ATTEMPTS" env-default:"8"`
+	RetryBase   time.Duration `env:"WEBHOOK_RETRY_BASE" env-default:"30s"`
+	RetryMax    time.Duration `env:"WEBHOOK_RETRY_MAX" env-default:"6h"`
+	Timeout     time.Duration `env:"WEBHOOK_TIMEOUT" env-default:"10s"`
+}
+
+// RevocationConfig controls the feed resource servers watch for revoked tokens.
+type RevocationConfig struct {
+	// FeedLength is about how many revocations are kept for watchers that reconnect.
+	FeedLength int64 `env:"REVOCATION_FEED_LENGTH" env-default:"100000"`
+	BatchSize  int   `env:"REVOCATION_BATCH_SIZE" env-default:"100"`
+	// PollTimeout is how long a watcher waits on Redis for new revocations at a time. Each
+	// watcher holds a Redis connection while it waits.
+	PollTimeout time.Duration `env:"REVOCATION_POLL_TIMEOUT" env-default:"5s"`
+}
+
+// FederationConfig controls sign-in with upstream identity providers.
+type FederationConfig struct {
+	// StateTTL is how long a user has to come back from the provider.
+	StateTTL time.Duration `env:"FEDERATION_STATE_TTL" env-default:"10m"`
+	// HTTPTimeout bounds each request to a provider.
+	HTTPTimeout time.Duration `env:"FEDERATION_HTTP_TIMEOUT" env-default:"10s"`
+}
+
+// LDAPConfig lets users log in with the password of an LDAP directory. It is off without a URL.
+type LDAPConfig struct {
+	// URL is an ldap:// or ldaps:// URL of the directory.
+	URL      string `env:"LDAP_URL"`
+	StartTLS bool   `env:"LDAP_START_TLS" env-default:"false"`
+	// CAFile is a PEM file of the CAs that sign the directory's certificate. The system's are
+	// trusted without it.
+	CAFile             string `env:"LDAP_CA_FILE"`
+	InsecureSkipVerify bool   `env:"LDAP_INSECURE_SKIP_VERIFY" env-default:"false"`
+	BindDN             string `env:"LDAP_BIND_DN"`
+	BindPassword       string `env:"LDAP_BIND_PASSWORD"`
+	BaseDN             string `env:"LDAP_BASE_DN"`
+	// UserFilter finds a user's entry; "{email}" is replaced with the email they log in with.
+	UserFilter string `env:"LDAP_USER_FILTER" env-default:"(&(objectClass=person)(mail={email}))"`
+	EmailAttr  string `env:"LDAP_EMAIL_ATTR" env-default:"mail"`
+	GroupAttr  string `env:"LDAP_GROUP_ATTR" env-default:"memberOf"`
+	// GroupRoles maps group DNs to the IDs of the roles their members get, as
+	// "cn=admins,ou=groups,dc=example,dc=com:3;cn=devs,ou=groups,dc=example,dc=com:4".
+	GroupRoles map[string]int64 `env:"LDAP_GROUP_ROLES" env-separator:";"`
+	Timeout    time.Duration    `env:"LDAP_TIMEOUT" env-default:"10s"`
+}
+
+// SAMLConfig lets users sign in to SAML service providers. It is off without a key file.
+type SAMLConfig struct {
+	// EntityID defaults to the metadata URL.
+	EntityID string `env:"SAML_ENTITY_ID"`
+	// BaseURL is where the HTTP server is reached from browsers; the SSO, logout and metadata
+	// endpoints live under BaseURL + "/saml".
+	BaseURL  string `env:"SAML_BASE_URL" env-default:"http://localhost:8080"`
+	KeyFile  string `env:"SAML_KEY_FILE"`
+	CertFile string `env:"SAML_CERT_FILE"`
+	// LoginURL is the page users sign in on; "{request}" is replaced with the ID of the request.
+	LoginURL     string        `env:"SAML_LOGIN_URL" env-default:"http://localhost:3000/saml/login?request={request}"`
+	RequestTTL   time.Duration `env:"SAML_REQUEST_TTL" env-default:"10m"`
+	AssertionTTL time.Duration `env:"SAML_ASSERTION_TTL" env-default:"5m"`
+}
+
+// SCIMConfig is for the SCIM 2.0 provisioning endpoints, served under BaseURL + "/scim/v2".
+type SCIMConfig struct {
+	BaseURL string `env:"SCIM_BASE_URL" env-default:"http://localhost:8080"`
+}
+
+// MailConfig is for the SMTP server mail goes out through. Without an address mail is only
+// logged.
+type MailConfig struct {
+	SMTPAddr     string        `env:"MAIL_SMTP_ADDR"`
+	SMTPUsername string        `env:"MAIL_SMTP_USERNAME"`
+	SMTPPassword string        `env:"MAIL_SMTP_PASSWORD"`
+	From         string        `env:"MAIL_FROM" env-default:"SSO <no-reply@localhost>"`
+	Timeout      time.Duration `env:"MAIL_TIMEOUT" env-default:"10s"`
+}
+
+// PasswordlessConfig controls sign-in with mailed links and codes.
+type PasswordlessConfig struct {
+	TTL         time.Duration `env:"PASSWORDLESS_TTL" env-default:"10m"`
+	MaxAttempts int           `env:"PASSWORDLESS_MAX_ATTEMPTS" env-default:"5"`
+	// LinkURL is the page magic links open; "{token}" is replaced with the link's token.
+	LinkURL string `env:"PASSWORDLESS_LINK_URL" env-default:"http://localhost:3000/login/link?token={token}"`
+}
+
+// PhoneConfig controls sign-in and verification with texted codes. Texts are only logged until
+// an SMS provider is plugged in.
+type PhoneConfig struct {
+	TTL          time.Duration `env:"PHONE_CODE_TTL" env-default:"5m"`
+	MaxAttempts  int           `env:"PHONE_MAX_ATTEMPTS" env-default:"5"`
+	NumberLimit  int           `env:"SMS_NUMBER_LIMIT" env-default:"5"`
+	NumberWindow time.Duration `env:"SMS_NUMBER_WINDOW" env-default:"1h"`
+	// PrefixLimit caps texts to all numbers sharing their first PrefixDigits digits.
+	PrefixLimit  int           `env:"SMS_PREFIX_LIMIT" env-default:"50"`
+	PrefixWindow time.Duration `env:"SMS_PREFIX_WINDOW" env-default:"1h"`
+	PrefixDigits int           `env:"SMS_PREFIX_DIGITS" env-default:"6"`
+	// SecondFactor tells whether texted codes reach users, who step up with them to enter
+	// organizations requiring MFA. While it is off organizations can't require MFA.
+	SecondFactor bool `env:"PHONE_SECOND_FACTOR" env-default:"true"`
+}
+
+// SSOConfig controls the browser sessions that sign users in to every app at once. The
+// endpoints live under "/sso" on the HTTP server.
+type SSOConfig struct {
+	// LoginURL is the page users sign in on; "{request}" is replaced with the ID of the request.
+	LoginURL     string        `env:"SSO_LOGIN_URL" env-default:"http://localhost:3000/login?request={request}"`
+	SessionTTL   time.Duration `env:"SSO_SESSION_TTL" env-default:"24h"`
+	RequestTTL   time.Duration `env:"SSO_REQUEST_TTL" env-default:"10m"`
+	CodeTTL      time.Duration `env:"SSO_CODE_TTL" env-default:"1m"`
+	CookieName   string        `env:"SSO_COOKIE_NAME" env-default:"sso_session"`
+	CookieSecure bool          `env:"SSO_COOKIE_SECURE" env-default:"true"`
+	// Issuer identifies the SSO in ID tokens, logout tokens and front-channel logout requests; it
+	// is where the HTTP server is reached from browsers.
+	Issuer string `env:"SSO_ISSUER" env-default:"http://localhost:8080"`
+	// Logout tokens are sent to back-channel logout URIs like webhook deliveries.
+	LogoutTokenTTL         time.Duration `env:"SSO_LOGOUT_TOKEN_TTL" env-default:"2m"`
+	LogoutDispatchInterval time.Duration `env:"SSO_LOGOUT_DISPATCH_INTERVAL" env-default:"5s"`
+	LogoutBatchSize        int           `env:"SSO_LOGOUT_BATCH_SIZE" env-default:"100"`
+	LogoutMaxAttempts      int           `env:"SSO_LOGOUT_MAX_ATTEMPTS" env-default:"8"`
+	LogoutRetryBase        time.Duration `env:"SSO_LOGOUT_RETRY_BASE" env-default:"30s"`
+	LogoutRetryMax         time.Duration `env:"SSO_LOGOUT_RETRY_MAX" env-default:"1h"`
+	LogoutTimeout          time.Duration `env:"SSO_LOGOUT_TIMEOUT" env-default:"10s"`
 }
 
 func MustLoad() Config {
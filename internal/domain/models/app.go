@@ -1,8 +1,85 @@
 package models
 
+import "time"
+
+const (
+	SecretKindAccess  = "access"
+	SecretKindRefresh = "refresh"
+)
+
+const (
+	GrantPassword     = "password"
+	GrantRefreshToken = "refresh_token"
+	// GrantJWTBearer lets the app's service accounts exchange signed assertions for tokens (RFC 7523).
+	GrantJWTBearer = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	// GrantFederated lets users of the app sign in with an upstream identity provider.
+	GrantFederated = "federated"
+	// GrantSAML lets the app's SAML service providers sign users in.
+	GrantSAML = "saml"
+	// GrantPasswordless lets users of the app sign in with a link or code mailed to them.
+	GrantPasswordless = "passwordless"
+	// GrantPhone lets users of the app sign in with a code texted to their phone.
+	GrantPhone = "phone"
+	// GrantSSO lets the app sign in users who already have an SSO session in their browser.
+	GrantSSO = "sso"
+)
+
 type App struct {
-	ID            int
-	Name          string
+	ID   int
+	Name string
+	// AccessSecret and RefreshSecret are the newest secrets of each kind and are used for signing.
 	AccessSecret  string
 	RefreshSecret string
+	// AccessSecrets holds every access secret that is still valid, newest first, including
+	// ones that were rotated out but are inside their grace period.
+	AccessSecrets []string
+	// TokenClaims names the ProfileClaimNames projected into the app's access tokens.
+	TokenClaims []string
+	// IDTokenClaims names the ProfileClaimNames projected into the ID tokens the app gets from
+	// the SSO code exchange.
+	IDTokenClaims []string
+	RedirectURIs  []string
+	GrantTypes    []string
+	// Zero values below mean the service defaults apply.
+	AccessTTL time.Duration
+	// RefreshTTL is the absolute lifetime of a login session, no matter how often it is refreshed.
+	RefreshTTL time.Duration
+	// RefreshIdleTimeout ends a session that has not been refreshed for this long.
+	RefreshIdleTimeout time.Duration
+	// MaxSessions caps concurrent sessions per user; the oldest ones are ended first.
+	MaxSessions int
+	// InviteOnly rejects registrations for this app that don't come with a valid invitation.
+	InviteOnly bool
+	// BackchannelLogoutURI receives logout tokens when users sign out of the SSO session the
+	// app's sessions belong to. Apps without one are signed out in the browser through
+	// FrontchannelLogoutURI instead, if they have that.
+	BackchannelLogoutURI  string
+	FrontchannelLogoutURI string
+	Enabled               bool
+}
+
+func (a App) AllowsGrant(grant string) bool {
+	for _, g := range a.GrantTypes {
+		if g == grant {
+			return true
+		}
+	}
+	return false
+}
+
+// AppUpdate describes a partial app change: nil fields are left as they are.
+type AppUpdate struct {
+	Name                  *string
+	TokenClaims           []string
+	IDTokenClaims         []string
+	RedirectURIs          []string
+	GrantTypes            []string
+	AccessTTL             *time.Duration
+	RefreshTTL            *time.Duration
+	RefreshIdleTimeout    *time.Duration
+	MaxSessions           *int
+	InviteOnly            *bool
+	BackchannelLogoutURI  *string
+	FrontchannelLogoutURI *string
+	Enabled               *bool
 }
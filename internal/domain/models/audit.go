@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+type AuditEntry struct {
+	ID           int64
+	ActorID      int64
+	Action       string
+	TargetUserID int64
+	// ServiceAccountID is the service account the entry is about, so machine activity can be
+	// reviewed apart from that of people.
+	ServiceAccountID int64
+	AppID            int
+	// IP and UserAgent default to those of the request the entry is recorded in.
+	IP        string
+	UserAgent string
+	// Outcome is OutcomeSuccess unless set.
+	Outcome   string
+	Details   map[string]any
+	CreatedAt time.Time
+}
+
+// AuditFilter selects audit entries; zero fields don't filter. An Action ending in "." matches
+// every action with that prefix, such as "admin." for all admin actions.
+type AuditFilter struct {
+	ActorID          int64
+	TargetUserID     int64
+	ServiceAccountID int64
+	AppID            int
+	Action           string
+	Outcome          string
+	Since            time.Time
+	Until            time.Time
+	// BeforeID continues a listing after the entry with this ID; entries come newest first.
+	BeforeID int64
+	Limit    int
+}
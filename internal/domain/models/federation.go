@@ -0,0 +1,76 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// IdentityProvider is an upstream OpenID Connect provider users can sign in with.
+type IdentityProvider struct {
+	ID   int
+	Slug string
+	Name string
+	// Issuer is the provider's issuer URL, its configuration is discovered from there.
+	Issuer   string
+	ClientID string
+	// ClientSecret is never returned by listings.
+	ClientSecret string
+	// Scopes are requested on top of "openid".
+	Scopes []string
+	// AllowedEmailDomains limits who may sign in; empty allows everyone.
+	AllowedEmailDomains []string
+	// JITProvisioning creates accounts for people signing in for the first time.
+	JITProvisioning bool
+	// LinkByEmail links a first sign-in to the account with the same email, if the provider
+	// has verified that email.
+	LinkByEmail bool
+	Disabled    bool
+	CreatedAt   time.Time
+}
+
+// AllowsEmail reports whether users with the email may sign in with the provider.
+func (p IdentityProvider) AllowsEmail(email string) bool {
+	if len(p.AllowedEmailDomains) == 0 {
+		return true
+	}
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return false
+	}
+	for _, allowed := range p.AllowedEmailDomains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// UserIdentity links a user to their account at a provider.
+type UserIdentity struct {
+	ID           int64
+	UserID       int64
+	ProviderID   int
+	ProviderSlug string
+	Subject      string
+	Email        string
+	CreatedAt    time.Time
+	LastLoginAt  time.Time
+}
+
+// ExternalIdentity is who a provider says signed in.
+type ExternalIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// FederatedLoginState is what is kept between sending the user to a provider and their return.
+type FederatedLoginState struct {
+	ProviderID   int    `json:"provider_id"`
+	AppID        int    `json:"app_id"`
+	OrgID        int64  `json:"org_id,omitempty"`
+	RedirectURI  string `json:"redirect_uri"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
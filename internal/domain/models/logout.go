@@ -0,0 +1,12 @@
+package models
+
+// Logout is a logout token owed to an app for one of its sessions that ended with the user's
+// SSO session. It is delivered like a webhook delivery and has the same statuses.
+type Logout struct {
+	ID     int64
+	AppID  int
+	UserID int64
+	// SessionID is the app session, which the app knows from the sid claim of its tokens.
+	SessionID string
+	Attempts  int
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+var OrgRoles = []string{OrgRoleOwner, OrgRoleAdmin, OrgRoleMember}
+
+type Organization struct {
+	ID   int64
+	Slug string
+	Name string
+	// AllowedEmailDomains restricts membership to users with these email domains when set.
+	AllowedEmailDomains []string
+	RequireMFA          bool
+	CreatedAt           time.Time
+}
+
+// AllowsEmail reports whether a user with this email may belong to the organization.
+func (o Organization) AllowsEmail(email string) bool {
+	if len(o.AllowedEmailDomains) == 0 {
+		return true
+	}
+
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return false
+	}
+	return slices.Contains(o.AllowedEmailDomains, strings.ToLower(domain))
+}
+
+type OrganizationUpdate struct {
+	Name                *string
+	AllowedEmailDomains []string
+	RequireMFA          *bool
+}
+
+type Membership struct {
+	OrgID     int64
+	UserID    int64
+	Email     string
+	Role      string
+	CreatedAt time.Time
+}
+
+// OrgMembership is an organization as seen by one of its members.
+type OrgMembership struct {
+	Organization Organization
+	Role         string
+}
+
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+type Invitation struct {
+	ID    int64
+	OrgID int64
+	Email string
+	Role  string
+	// Nonce is embedded in the invitation link; resending replaces it so older links stop working.
+	Nonce      string
+	InvitedBy  int64
+	ExpiresAt  time.Time
+	AcceptedAt time.Time
+	AcceptedBy int64
+	RevokedAt  time.Time
+	CreatedAt  time.Time
+}
+
+func (i Invitation) Status(now time.Time) string {
+	switch {
+	case !i.AcceptedAt.IsZero():
+		return InvitationAccepted
+	case !i.RevokedAt.IsZero():
+		return InvitationRevoked
+	case !now.Before(i.ExpiresAt):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Ways a passwordless login proves the user owns their email.
+const (
+	PasswordlessLink = "link"
+	PasswordlessCode = "code"
+)
+
+// PasswordlessChallenge is a passwordless login waiting for the secret mailed to the user.
+type PasswordlessChallenge struct {
+	Email string `json:"email"`
+	// UserID is zero when there was no account for the email yet.
+	UserID int64  `json:"user_id,omitempty"`
+	AppID  int    `json:"app_id"`
+	OrgID  int64  `json:"org_id,omitempty"`
+	Method string `json:"method"`
+	// SecretHash is the MAC of the mailed secret, keyed with the challenge ID.
+	SecretHash []byte    `json:"secret_hash"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
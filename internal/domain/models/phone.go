@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// What a texted code is for.
+const (
+	PhoneLogin  = "login"
+	PhoneVerify = "verify"
+	PhoneStepUp = "step_up"
+)
+
+// PhoneChallenge is a code texted to a phone number, waiting to be entered.
+type PhoneChallenge struct {
+	Phone   string `json:"phone"`
+	Purpose string `json:"purpose"`
+	// UserID is zero for logins of numbers that don't belong to a user yet.
+	UserID int64 `json:"user_id,omitempty"`
+	AppID  int   `json:"app_id,omitempty"`
+	OrgID  int64 `json:"org_id,omitempty"`
+	// CodeHash is the MAC of the texted code, keyed with the challenge ID.
+	CodeHash  []byte    `json:"code_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+type Profile struct {
+	UserID       int64
+	Email        string
+	DisplayName  string
+	GivenName    string
+	FamilyName   string
+	Locale       string
+	Timezone     string
+	Phone        string
+	UserMetadata map[string]any
+	AppMetadata  map[string]any
+}
+
+// ProfileUpdate describes a partial profile change: nil fields are left as they are.
+// Metadata maps are merged into the stored objects, and keys set to nil are removed.
+type ProfileUpdate struct {
+	DisplayName  *string
+	GivenName    *string
+	FamilyName   *string
+	Locale       *string
+	Timezone     *string
+	Phone        *string
+	UserMetadata map[string]any
+	AppMetadata  map[string]any
+}
+
+// ProfileClaimNames are the attributes an app can have projected into its access tokens.
+var ProfileClaimNames = []string{
+	"name", "given_name", "family_name", "locale", "zoneinfo", "phone_number", "user_metadata", "app_metadata",
+}
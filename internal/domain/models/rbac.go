@@ -0,0 +1,24 @@
+package models
+
+// Role groups permissions of a single app. Permissions holds permission names.
+type Role struct {
+	ID          int64
+	AppID       int
+	Name        string
+	Description string
+	Permissions []string
+}
+
+type Permission struct {
+	ID          int64
+	AppID       int
+	Name        string
+	Description string
+}
+
+// Authorization is what a user is allowed to do in an app: the names of the
+// roles assigned to them and the union of the permissions of those roles.
+type Authorization struct {
+	Roles       []string
+	Permissions []string
+}
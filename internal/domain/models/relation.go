@@ -0,0 +1,68 @@
+package models
+
+type Object struct {
+	Namespace string
+	ID        string
+}
+
+func (o Object) String() string {
+	return o.Namespace + ":" + o.ID
+}
+
+// Subject is either a concrete subject such as user:42 or, when Relation is set,
+// the userset of everyone holding that relation on the object, such as group:eng#member.
+type Subject struct {
+	Namespace string
+	ID        string
+	Relation  string
+}
+
+func (s Subject) Object() Object {
+	return Object{Namespace: s.Namespace, ID: s.ID}
+}
+
+func (s Subject) String() string {
+	if s.Relation == "" {
+		return s.Namespace + ":" + s.ID
+	}
+	return s.Namespace + ":" + s.ID + "#" + s.Relation
+}
+
+type RelationTuple struct {
+	Object   Object
+	Relation string
+	Subject  Subject
+}
+
+type NamespaceConfig struct {
+	Name      string                    `json:"-"`
+	Relations map[string]RelationConfig `json:"relations"`
+}
+
+// RelationConfig rewrites a relation as the union of usersets. An empty Union
+// means the relation holds only its directly written tuples.
+type RelationConfig struct {
+	Union []Userset `json:"union,omitempty"`
+}
+
+// Userset is one member of a rewrite: the tuples written for the relation itself,
+// another relation of the same object, or a relation of objects reached through a tupleset.
+type Userset struct {
+	This            bool            `json:"this,omitempty"`
+	ComputedUserset string          `json:"computed_userset,omitempty"`
+	TupleToUserset  *TupleToUserset `json:"tuple_to_userset,omitempty"`
+}
+
+type TupleToUserset struct {
+	Tupleset        string `json:"tupleset"`
+	ComputedUserset string `json:"computed_userset"`
+}
+
+// ExpandNode is one node of the tree showing how a relation of an object is derived.
+type ExpandNode struct {
+	Operation string
+	Object    Object
+	Relation  string
+	Subjects  []Subject
+	Children  []ExpandNode
+}
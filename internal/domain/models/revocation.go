@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Kinds of revocation pushed to resource servers. Each names which tokens stop being valid.
+const (
+	// RevokedSession ends a refresh session. Access tokens whose "sid" is SessionID are revoked.
+	RevokedSession = "session"
+	// RevokedToken revokes the one token whose "jti" is TokenID.
+	RevokedToken = "token"
+	// RevokedUser revokes every token of the user, or of the service account, issued at or
+	// before RevokedAt.
+	RevokedUser = "user"
+	// RevokedKey retires a key. For an app it is the kind of secret that was rotated; tokens
+	// signed with the replaced secret stop working at RevokedAt, once the grace period is over.
+	RevokedKey = "key"
+)
+
+// Revocation is an entry of the revocation feed. Only the fields its kind uses are set.
+type Revocation struct {
+	// Offset is the position of the entry in the feed. Watching from an offset resumes right
+	// after that entry.
+	Offset           string
+	Kind             string
+	UserID           int64
+	AppID            int
+	ServiceAccountID int64
+	SessionID        string
+	TokenID          string
+	KeyID            string
+	RevokedAt        time.Time
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// ServiceProvider is an application users sign in to with SAML, registered under an app.
+type ServiceProvider struct {
+	ID    int
+	AppID int
+	// EntityID is the issuer the service provider puts in its requests.
+	EntityID string
+	Name     string
+	// ACSURL is where assertions are posted to.
+	ACSURL string
+	// SLOURL is where logout responses go; empty if the service provider has no single logout.
+	SLOURL string
+	// Certificate is the PEM encoded certificate the service provider signs requests with.
+	// Without one requests are accepted unsigned and single logout is refused.
+	Certificate  string
+	NameIDFormat string
+	// AttributeMap renames profile fields for the service provider: keys are profile fields,
+	// values the attribute names sent. Fields missing from a non-empty map are not sent.
+	AttributeMap map[string]string
+	Disabled     bool
+	CreatedAt    time.Time
+}
+
+// SAMLLoginState is what is kept between an AuthnRequest and the user signing in.
+type SAMLLoginState struct {
+	ServiceProviderID int    `json:"service_provider_id"`
+	RequestID         string `json:"request_id"`
+	ACSURL            string `json:"acs_url"`
+	RelayState        string `json:"relay_state,omitempty"`
+}
+
+// SAMLPost is the form the browser posts to a service provider to sign the user in.
+type SAMLPost struct {
+	ACSURL       string
+	SAMLResponse string
+	RelayState   string
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// ProvisionedUser is a user as a SCIM provisioning client manages it.
+type ProvisionedUser struct {
+	ID int64
+	// ExternalID is the identifier the provisioning client knows the user by.
+	ExternalID  string
+	Email       string
+	Active      bool
+	DisplayName string
+	GivenName   string
+	FamilyName  string
+	Locale      string
+	Timezone    string
+	Phone       string
+	Groups      []GroupRef
+	// Version is bumped by every update of the user.
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type GroupRef struct {
+	ID          int
+	DisplayName string
+}
+
+// Group is a set of users provisioned by a SCIM client.
+type Group struct {
+	ID          int
+	DisplayName string
+	ExternalID  string
+	Members     []GroupMember
+	Version     int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type GroupMember struct {
+	UserID int64
+	Email  string
+}
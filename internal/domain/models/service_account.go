@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ServiceAccount is a non-human principal of an app. It signs in with JWT-bearer assertions
+// made with one of its keys and holds roles of its app like a user does.
+type ServiceAccount struct {
+	ID          int64
+	AppID       int
+	Name        string
+	Description string
+	Disabled    bool
+	CreatedBy   int64
+	CreatedAt   time.Time
+}
+
+// ServiceAccountKey is a public key a service account signs its assertions with. Accounts can
+// have several, so keys can be rotated without downtime.
+type ServiceAccountKey struct {
+	// ID is the "kid" assertions name the key by.
+	ID               string
+	ServiceAccountID int64
+	PublicKey        string
+	Algorithm        string
+	// A zero ExpiresAt means the key does not expire.
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (k ServiceAccountKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Prompt values of authorize requests, as in OpenID Connect.
+const (
+	// PromptNone fails the request rather than asking the user to sign in.
+	PromptNone = "none"
+	// PromptLogin asks the user to sign in even with a valid SSO session.
+	PromptLogin = "login"
+)
+
+// SSOAuthorizeRequest is an app asking to sign in the user of a browser. It is kept while the
+// user signs in.
+type SSOAuthorizeRequest struct {
+	AppID       int    `json:"app_id"`
+	OrgID       int64  `json:"org_id,omitempty"`
+	RedirectURI string `json:"redirect_uri"`
+	State       string `json:"state,omitempty"`
+	// CreatedAt is when the request was made; the sign-in answering it must be later.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SSOCode is what an authorization code sent back to an app is redeemed for.
+type SSOCode struct {
+	SessionID   string `json:"session_id"`
+	UserID      int64  `json:"user_id"`
+	AppID       int    `json:"app_id"`
+	OrgID       int64  `json:"org_id,omitempty"`
+	RedirectURI string `json:"redirect_uri"`
+}
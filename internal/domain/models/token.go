@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Scopes a personal access token can be limited to. Each one opens one of the APIs.
+const (
+	ScopeProfile = "profile"
+	ScopeOrgs    = "orgs"
+	ScopeAuthz   = "authz"
+	ScopeRBAC    = "rbac"
+	ScopeApps    = "apps"
+	ScopeAdmin   = "admin"
+)
+
+// ScopeTokens guards token management. It is never granted, so a personal access token
+// can't be used to mint or revoke others.
+const ScopeTokens = "tokens"
+
+var TokenScopes = []string{ScopeProfile, ScopeOrgs, ScopeAuthz, ScopeRBAC, ScopeApps, ScopeAdmin}
+
+type PersonalAccessToken struct {
+	ID     int64
+	UserID int64
+	Name   string
+	// TokenHash is the SHA-256 of the token; the token itself is only shown once, at creation.
+	TokenHash string
+	// DisplayPrefix is the start of the token, enough to recognise it in a listing.
+	DisplayPrefix string
+	Scopes        []string
+	// A zero ExpiresAt means the token does not expire.
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
+
+func (t PersonalAccessToken) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
+}
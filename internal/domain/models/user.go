@@ -1,7 +1,25 @@
 package models
 
 type User struct {
-	ID       int64
-	Email    string
-	PassHash []byte
+	ID int64
+	// Email is empty for users who signed up with a phone number.
+	Email                 string
+	PassHash              []byte
+	PassAlgo              string
+	IsAdmin               bool
+	Disabled              bool
+	EmailVerified         bool
+	PasswordResetRequired bool
+	// Phone is in E.164 format. Once verified, it identifies the user like their email does.
+	Phone         string
+	PhoneVerified bool
+}
+
+type UserFilter struct {
+	// Query matches users whose email contains it, case-insensitively.
+	Query         string
+	Disabled      *bool
+	EmailVerified *bool
+	AfterID       int64
+	Limit         int
 }
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	EventUserRegistered    = "user.registered"
+	EventUserEmailVerified = "user.email_verified"
+	EventUserPhoneVerified = "user.phone_verified"
+	EventUserDeleted       = "user.deleted"
+)
+
+var EventTypes = []string{EventUserRegistered, EventUserEmailVerified, EventUserPhoneVerified, EventUserDeleted}
+
+// Event is a change to an identity that downstream services are told about through webhooks.
+type Event struct {
+	ID        int64
+	Type      string
+	UserID    int64
+	Payload   map[string]any
+	CreatedAt time.Time
+}
+
+// Webhook is an URL of an app that events are posted to, signed with Secret.
+type Webhook struct {
+	ID    int64
+	AppID int
+	URL   string
+	// Secret is only returned when the webhook is created.
+	Secret string
+	// EventTypes the webhook receives; empty means all of them.
+	EventTypes []string
+	Disabled   bool
+	CreatedAt  time.Time
+}
+
+func (w Webhook) Subscribed(eventType string) bool {
+	return len(w.EventTypes) == 0 || slices.Contains(w.EventTypes, eventType)
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// DeliveryDead is a delivery that failed too often and is only retried when replayed.
+	DeliveryDead = "dead"
+)
+
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int64
+	EventID        int64
+	EventType      string
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	DeliveredAt    time.Time
+	CreatedAt      time.Time
+}
+
+// DueDelivery is a delivery claimed for sending together with what is needed to send it.
+type DueDelivery struct {
+	ID       int64
+	Attempts int
+	Webhook  Webhook
+	Event    Event
+}
@@ -3,10 +3,25 @@ package sessions
 import "time"
 
 type RefreshSession struct {
+	// ID stays the same while the refresh token is rotated. Access tokens carry it as "sid".
+	// Sessions created before it was introduced don't have one.
+	ID        string    `json:"id,omitempty"`
 	UserID    int64     `json:"user_id"`
 	UserEmail string    `json:"user_email"`
 	AppID     int       `json:"app_id"`
+	OrgID     int64     `json:"org_id,omitempty"`
 	IP        string    `json:"ip,omitempty"`
 	UserAgent string    `json:"user_agent,omitempty"`
-	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	// ExpiresAt is when this refresh token stops working. With an idle timeout it is
+	// pushed forward on every refresh, but never past AbsoluteExpiresAt.
+	ExpiresAt         time.Time `json:"expires_at"`
+	AbsoluteExpiresAt time.Time `json:"absolute_expires_at,omitempty"`
+	// AuthTime is when the user last authenticated in the session, with the AMR methods
+	// verified so far. Stepping up adds a method and moves AuthTime forward.
+	AuthTime time.Time `json:"auth_time,omitempty"`
+	AMR      []string  `json:"amr,omitempty"`
+	// SSOSessionID is the browser SSO session the app session was started from or joined.
+	// Signing out of it ends this session too.
+	SSOSessionID string `json:"sso_session_id,omitempty"`
 }
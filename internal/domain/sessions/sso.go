@@ -0,0 +1,14 @@
+package sessions
+
+import "time"
+
+// SSOSession is a user's sign-in at the SSO itself, kept by the browser in a cookie. While it
+// lasts, apps the browser is sent to start sessions without asking for credentials again.
+type SSOSession struct {
+	UserID int64 `json:"user_id"`
+	// AuthTime and AMR are when and how the user last signed in, like in refresh sessions.
+	AuthTime  time.Time `json:"auth_time"`
+	AMR       []string  `json:"amr,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
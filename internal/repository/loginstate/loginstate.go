@@ -0,0 +1,335 @@
+package loginstate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Storage keeps logins in progress: federated ones keyed by the state parameter sent to the
+// provider, SAML and SSO ones by the ID handed to the login page and passwordless and phone ones
+// by the ID of their challenge. It also keeps SSO authorization codes and counts the text
+// messages sent, for rate limiting.
+type Storage struct {
+	rdb *redis.Client
+}
+
+func New(rdb *redis.Client) *Storage {
+	return &Storage{rdb: rdb}
+}
+
+func stateKey(state string) string {
+	return "login_state:" + state
+}
+
+func samlKey(requestID string) string {
+	return "saml_request:" + requestID
+}
+
+func ssoRequestKey(requestID string) string {
+	return "sso_request:" + requestID
+}
+
+func ssoCodeKey(code string) string {
+	return "sso_code:" + code
+}
+
+func passwordlessKey(challengeID string) string {
+	return "passwordless:" + challengeID
+}
+
+func phoneKey(challengeID string) string {
+	return "phone_challenge:" + challengeID
+}
+
+func smsKey(scope string) string {
+	return "sms_sent:" + scope
+}
+
+// countAttempt counts a guess at a challenge's secret, unless the challenge is gone. HINCRBY
+// on its own would bring an expired challenge back without a TTL.
+var countAttempt = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return -1
+end
+return redis.call("HINCRBY", KEYS[1], "attempts", 1)
+`)
+
+// countInWindow counts in a window of ARGV[1] milliseconds that starts with the first count.
+var countInWindow = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+func (s *Storage) Save(ctx context.Context, state string, login models.FederatedLoginState, ttl time.Duration) error {
+	const op = "repository.loginstate.redis.Save"
+
+	if err := s.save(ctx, stateKey(state), login, ttl); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Take returns the login and forgets it, so each state can complete one login only.
+func (s *Storage) Take(ctx context.Context, state string) (models.FederatedLoginState, error) {
+	const op = "repository.loginstate.redis.Take"
+
+	var login models.FederatedLoginState
+	if err := s.take(ctx, stateKey(state), &login); err != nil {
+		return models.FederatedLoginState{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return login, nil
+}
+
+func (s *Storage) SaveSAML(ctx context.Context, requestID string, login models.SAMLLoginState, ttl time.Duration) error {
+	const op = "repository.loginstate.redis.SaveSAML"
+
+	if err := s.save(ctx, samlKey(requestID), login, ttl); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// TakeSAML returns the login and forgets it, so each AuthnRequest is answered once.
+func (s *Storage) TakeSAML(ctx context.Context, requestID string) (models.SAMLLoginState, error) {
+	const op = "repository.loginstate.redis.TakeSAML"
+
+	var login models.SAMLLoginState
+	if err := s.take(ctx, samlKey(requestID), &login); err != nil {
+		return models.SAMLLoginState{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return login, nil
+}
+
+func (s *Storage) SaveSSORequest(ctx context.Context, requestID string, req models.SSOAuthorizeRequest, ttl time.Duration) error {
+	const op = "repository.loginstate.redis.SaveSSORequest"
+
+	if err := s.save(ctx, ssoRequestKey(requestID), req, ttl); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// TakeSSORequest returns the authorize request and forgets it, so each is answered once.
+func (s *Storage) TakeSSORequest(ctx context.Context, requestID string) (models.SSOAuthorizeRequest, error) {
+	const op = "repository.loginstate.redis.TakeSSORequest"
+
+	var req models.SSOAuthorizeRequest
+	if err := s.take(ctx, ssoRequestKey(requestID), &req); err != nil {
+		return models.SSOAuthorizeRequest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return req, nil
+}
+
+func (s *Storage) SaveSSOCode(ctx context.Context, code string, grant models.SSOCode, ttl time.Duration) error {
+	const op = "repository.loginstate.redis.SaveSSOCode"
+
+	if err := s.save(ctx, ssoCodeKey(code), grant, ttl); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// TakeSSOCode returns what the code is for and forgets it, so each code is redeemed once.
+func (s *Storage) TakeSSOCode(ctx context.Context, code string) (models.SSOCode, error) {
+	const op = "repository.loginstate.redis.TakeSSOCode"
+
+	var grant models.SSOCode
+	if err := s.take(ctx, ssoCodeKey(code), &grant); err != nil {
+		return models.SSOCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return grant, nil
+}
+
+func (s *Storage) save(ctx context.Context, key string, login any, ttl time.Duration) error {
+	data, err := json.Marshal(login)
+	if err != nil {
+		return err
+	}
+
+	return s.rdb.Set(ctx, key, data, ttl).Err()
+}
+
+func (s *Storage) take(ctx context.Context, key string, login any) error {
+	data, err := s.rdb.GetDel(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return repository.ErrStateNotFound
+		}
+		return err
+	}
+
+	return json.Unmarshal(data, login)
+}
+
+func (s *Storage) SavePasswordless(ctx context.Context, challengeID string, challenge models.PasswordlessChallenge, ttl time.Duration) error {
+	const op = "repository.loginstate.redis.SavePasswordless"
+
+	if err := s.saveChallenge(ctx, passwordlessKey(challengeID), challenge, ttl); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetPasswordless(ctx context.Context, challengeID string) (models.PasswordlessChallenge, error) {
+	const op = "repository.loginstate.redis.GetPasswordless"
+
+	var challenge models.PasswordlessChallenge
+	if err := s.getChallenge(ctx, passwordlessKey(challengeID), &challenge); err != nil {
+		return models.PasswordlessChallenge{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return challenge, nil
+}
+
+// CountPasswordlessAttempt records a guess at the challenge's secret and returns how many
+// there have been, this one included.
+func (s *Storage) CountPasswordlessAttempt(ctx context.Context, challengeID string) (int, error) {
+	const op = "repository.loginstate.redis.CountPasswordlessAttempt"
+
+	n, err := s.countAttempt(ctx, passwordlessKey(challengeID))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// DeletePasswordless forgets the challenge. Only the first of concurrent calls succeeds, so
+// it also makes sure a challenge completes one login only.
+func (s *Storage) DeletePasswordless(ctx context.Context, challengeID string) error {
+	const op = "repository.loginstate.redis.DeletePasswordless"
+
+	if err := s.deleteChallenge(ctx, passwordlessKey(challengeID)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SavePhone(ctx context.Context, challengeID string, challenge models.PhoneChallenge, ttl time.Duration) error {
+	const op = "repository.loginstate.redis.SavePhone"
+
+	if err := s.saveChallenge(ctx, phoneKey(challengeID), challenge, ttl); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetPhone(ctx context.Context, challengeID string) (models.PhoneChallenge, error) {
+	const op = "repository.loginstate.redis.GetPhone"
+
+	var challenge models.PhoneChallenge
+	if err := s.getChallenge(ctx, phoneKey(challengeID), &challenge); err != nil {
+		return models.PhoneChallenge{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return challenge, nil
+}
+
+// CountPhoneAttempt records a guess at the challenge's code and returns how many there have
+// been, this one included.
+func (s *Storage) CountPhoneAttempt(ctx context.Context, challengeID string) (int, error) {
+	const op = "repository.loginstate.redis.CountPhoneAttempt"
+
+	n, err := s.countAttempt(ctx, phoneKey(challengeID))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// DeletePhone forgets the challenge. Only the first of concurrent calls succeeds.
+func (s *Storage) DeletePhone(ctx context.Context, challengeID string) error {
+	const op = "repository.loginstate.redis.DeletePhone"
+
+	if err := s.deleteChallenge(ctx, phoneKey(challengeID)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CountSMS records a text message sent to scope and returns how many were sent to it in the
+// current window, this one included. Windows start with the first message.
+func (s *Storage) CountSMS(ctx context.Context, scope string, window time.Duration) (int, error) {
+	const op = "repository.loginstate.redis.CountSMS"
+
+	n, err := countInWindow.Run(ctx, s.rdb, []string{smsKey(scope)}, window.Milliseconds()).Int()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+func (s *Storage) saveChallenge(ctx context.Context, key string, challenge any, ttl time.Duration) error {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "challenge", data, "attempts", 0)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (s *Storage) getChallenge(ctx context.Context, key string, challenge any) error {
+	data, err := s.rdb.HGet(ctx, key, "challenge").Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return repository.ErrStateNotFound
+		}
+		return err
+	}
+
+	return json.Unmarshal(data, challenge)
+}
+
+func (s *Storage) countAttempt(ctx context.Context, key string) (int, error) {
+	n, err := countAttempt.Run(ctx, s.rdb, []string{key}).Int()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, repository.ErrStateNotFound
+	}
+
+	return n, nil
+}
+
+func (s *Storage) deleteChallenge(ctx context.Context, key string) error {
+	n, err := s.rdb.Del(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repository.ErrStateNotFound
+	}
+
+	return nil
+}
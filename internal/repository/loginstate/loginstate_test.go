@@ -0,0 +1,184 @@
+package loginstate_test
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/repository/loginstate"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+var rdb *redis.Client
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:7-alpine",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForListeningPort("6379/tcp").WithStartupTimeout(10 * time.Second),
+	}
+
+	redisContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		log.Fatalf("could not start redis container: %v", err)
+	}
+	defer redisContainer.Terminate(ctx)
+
+	host, _ := redisContainer.Host(ctx)
+	port, _ := redisContainer.MappedPort(ctx, "6379")
+
+	rdb = redis.NewClient(&redis.Options{
+		Addr: host + ":" + port.Port(),
+	})
+
+	m.Run()
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	storage := loginstate.New(rdb)
+
+	login := models.FederatedLoginState{ProviderID: 1, AppID: 2, RedirectURI: "https://app/cb", Nonce: "n", CodeVerifier: "v"}
+	assert.NoError(t, storage.Save(ctx, "state-1", login, time.Minute))
+
+	got, err := storage.Take(ctx, "state-1")
+	assert.NoError(t, err)
+	assert.Equal(t, login, got)
+
+	_, err = storage.Take(ctx, "state-1")
+	assert.ErrorIs(t, err, repository.ErrStateNotFound, "states are single use")
+
+	assert.NoError(t, storage.Save(ctx, "state-2", login, 10*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+	_, err = storage.Take(ctx, "state-2")
+	assert.ErrorIs(t, err, repository.ErrStateNotFound, "states expire")
+}
+
+func TestStorage_SAML(t *testing.T) {
+	ctx := context.Background()
+	storage := loginstate.New(rdb)
+
+	login := models.SAMLLoginState{ServiceProviderID: 1, RequestID: "_req", ACSURL: "https://sp/acs", RelayState: "/page"}
+	assert.NoError(t, storage.SaveSAML(ctx, "login-1", login, time.Minute))
+
+	_, err := storage.Take(ctx, "login-1")
+	assert.ErrorIs(t, err, repository.ErrStateNotFound, "SAML logins don't share keys with federated ones")
+
+	got, err := storage.TakeSAML(ctx, "login-1")
+	assert.NoError(t, err)
+	assert.Equal(t, login, got)
+
+	_, err = storage.TakeSAML(ctx, "login-1")
+	assert.ErrorIs(t, err, repository.ErrStateNotFound)
+}
+
+func TestStorage_SSO(t *testing.T) {
+	ctx := context.Background()
+	storage := loginstate.New(rdb)
+
+	req := models.SSOAuthorizeRequest{AppID: 2, RedirectURI: "https://app/cb", State: "xyz", CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	assert.NoError(t, storage.SaveSSORequest(ctx, "request-1", req, time.Minute))
+
+	got, err := storage.TakeSSORequest(ctx, "request-1")
+	assert.NoError(t, err)
+	assert.Equal(t, req, got)
+
+	_, err = storage.TakeSSORequest(ctx, "request-1")
+	assert.ErrorIs(t, err, repository.ErrStateNotFound)
+
+	code := models.SSOCode{SessionID: "sso-1", UserID: 1, AppID: 2, RedirectURI: "https://app/cb"}
+	assert.NoError(t, storage.SaveSSOCode(ctx, "code-1", code, time.Minute))
+
+	_, err = storage.TakeSSORequest(ctx, "code-1")
+	assert.ErrorIs(t, err, repository.ErrStateNotFound, "codes don't share keys with requests")
+
+	gotCode, err := storage.TakeSSOCode(ctx, "code-1")
+	assert.NoError(t, err)
+	assert.Equal(t, code, gotCode)
+
+	_, err = storage.TakeSSOCode(ctx, "code-1")
+	assert.ErrorIs(t, err, repository.ErrStateNotFound, "codes are single use")
+}
+
+func TestStorage_Passwordless(t *testing.T) {
+	ctx := context.Background()
+	storage := loginstate.New(rdb)
+
+	challenge := models.PasswordlessChallenge{Email: "ann@example.com", UserID: 1, AppID: 2, Method: models.PasswordlessCode, SecretHash: []byte("hash")}
+	challenge.ExpiresAt = time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	assert.NoError(t, storage.SavePasswordless(ctx, "challenge-1", challenge, time.Minute))
+
+	got, err := storage.GetPasswordless(ctx, "challenge-1")
+	assert.NoError(t, err)
+	assert.Equal(t, challenge, got)
+
+	for want := 1; want <= 3; want++ {
+		n, err := storage.CountPasswordlessAttempt(ctx, "challenge-1")
+		assert.NoError(t, err)
+		assert.Equal(t, want, n)
+	}
+
+	assert.NoError(t, storage.DeletePasswordless(ctx, "challenge-1"))
+	assert.ErrorIs(t, storage.DeletePasswordless(ctx, "challenge-1"), repository.ErrStateNotFound, "challenges are single use")
+	_, err = storage.GetPasswordless(ctx, "challenge-1")
+	assert.ErrorIs(t, err, repository.ErrStateNotFound)
+
+	_, err = storage.CountPasswordlessAttempt(ctx, "challenge-1")
+	assert.ErrorIs(t, err, repository.ErrStateNotFound)
+	exists, err := rdb.Exists(ctx, "passwordless:challenge-1").Result()
+	assert.NoError(t, err)
+	assert.Zero(t, exists, "counting doesn't bring a challenge back")
+}
+
+func TestStorage_Phone(t *testing.T) {
+	ctx := context.Background()
+	storage := loginstate.New(rdb)
+
+	challenge := models.PhoneChallenge{Phone: "+447911123456", Purpose: models.PhoneLogin, AppID: 2, CodeHash: []byte("hash")}
+	challenge.ExpiresAt = time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	assert.NoError(t, storage.SavePhone(ctx, "challenge-1", challenge, time.Minute))
+
+	got, err := storage.GetPhone(ctx, "challenge-1")
+	assert.NoError(t, err)
+	assert.Equal(t, challenge, got)
+
+	n, err := storage.CountPhoneAttempt(ctx, "challenge-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.NoError(t, storage.DeletePhone(ctx, "challenge-1"))
+	assert.ErrorIs(t, storage.DeletePhone(ctx, "challenge-1"), repository.ErrStateNotFound)
+	_, err = storage.CountPhoneAttempt(ctx, "challenge-1")
+	assert.ErrorIs(t, err, repository.ErrStateNotFound)
+}
+
+func TestStorage_CountSMS(t *testing.T) {
+	ctx := context.Background()
+	storage := loginstate.New(rdb)
+
+	for want := 1; want <= 3; want++ {
+		n, err := storage.CountSMS(ctx, "number:+447911123456", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, want, n)
+	}
+
+	n, err := storage.CountSMS(ctx, "number:+447911654321", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n, "numbers are counted apart")
+
+	ttl, err := rdb.PTTL(ctx, "sms_sent:number:+447911123456").Result()
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(5*time.Second), "the window isn't extended by later messages")
+}
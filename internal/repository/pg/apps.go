@@ -6,23 +6,37 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+type SecretCipher interface {
+	Seal(plaintext []byte) []byte
+	Open(ciphertext []byte) ([]byte, error)
+}
+
 type AppRepository struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	cipher SecretCipher
+}
+
+func NewAppRepository(db *sqlx.DB, cipher SecretCipher) *AppRepository {
+	return &AppRepository{db: db, cipher: cipher}
 }
 
-func NewAppRepository(db *sqlx.DB) *AppRepository {
-	return &AppRepository{db: db}
+var appColumns = []string{
+	"id", "name", "token_claims", "id_token_claims", "redirect_uris", "grant_types",
+	"access_ttl_seconds", "refresh_ttl_seconds", "refresh_idle_timeout_seconds", "max_sessions", "invite_only",
+	"backchannel_logout_uri", "frontchannel_logout_uri", "enabled",
 }
 
 func (r *AppRepository) Get(ctx context.Context, appID int) (app models.App, err error) {
 	const op = "repository.app.postgres.Get"
 
-	query := sq.Select("*").
+	query := sq.Select(appColumns...).
 		From("apps").
 		Where(sq.Eq{"id": appID}).
 		PlaceholderFormat(sq.Dollar)
@@ -32,11 +46,393 @@ func (r *AppRepository) Get(ctx context.Context, appID int) (app models.App, err
 		return app, fmt.Errorf("%s: build query: %w", op, err)
 	}
 
-	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&app.ID, &app.Name, &app.AccessSecret, &app.RefreshSecret); err != nil {
+	app, err = scanApp(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return app, fmt.Errorf("%s: %w", op, repository.ErrAppNotFound)
 		}
 		return app, fmt.Errorf("%s: %w", op, err)
 	}
+
+	if err := r.loadSecrets(ctx, &app); err != nil {
+		return app, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return app, nil
 }
+
+// List returns apps without their secrets.
+func (r *AppRepository) List(ctx context.Context) ([]models.App, error) {
+	const op = "repository.app.postgres.List"
+
+	query := sq.Select(appColumns...).
+		From("apps").
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var apps []models.App
+	for rows.Next() {
+		app, err := scanApp(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		apps = append(apps, app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return apps, nil
+}
+
+func (r *AppRepository) Create(ctx context.Context, app models.App) (int, error) {
+	const op = "repository.app.postgres.Create"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := sq.Insert("apps").
+		Columns("name", "token_claims", "id_token_claims", "redirect_uris", "grant_types",
+			"access_ttl_seconds", "refresh_ttl_seconds", "refresh_idle_timeout_seconds", "max_sessions", "invite_only",
+			"backchannel_logout_uri", "frontchannel_logout_uri", "enabled").
+		Values(app.Name, pq.Array(orEmpty(app.TokenClaims)), pq.Array(orEmpty(app.IDTokenClaims)), pq.Array(orEmpty(app.RedirectURIs)), pq.Array(orEmpty(app.GrantTypes)),
+			ttlSeconds(app.AccessTTL), ttlSeconds(app.RefreshTTL), ttlSeconds(app.RefreshIdleTimeout), positive(app.MaxSessions), app.InviteOnly,
+			app.BackchannelLogoutURI, app.FrontchannelLogoutURI, app.Enabled).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var id int
+	if err := tx.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("%s: %w", op, repository.ErrAppExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := r.insertSecret(ctx, tx, id, models.SecretKindAccess, app.AccessSecret); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := r.insertSecret(ctx, tx, id, models.SecretKindRefresh, app.RefreshSecret); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *AppRepository) Update(ctx context.Context, appID int, upd models.AppUpdate) error {
+	const op = "repository.app.postgres.Update"
+
+	query := sq.Update("apps").
+		Where(sq.Eq{"id": appID}).
+		PlaceholderFormat(sq.Dollar)
+
+	set := 0
+	setColumn := func(column string, value any) {
+		query = query.Set(column, value)
+		set++
+	}
+
+	if upd.Name != nil {
+		setColumn("name", *upd.Name)
+	}
+	if upd.TokenClaims != nil {
+		setColumn("token_claims", pq.Array(upd.TokenClaims))
+	}
+	if upd.IDTokenClaims != nil {
+		setColumn("id_token_claims", pq.Array(upd.IDTokenClaims))
+	}
+	if upd.RedirectURIs != nil {
+		setColumn("redirect_uris", pq.Array(upd.RedirectURIs))
+	}
+	if upd.GrantTypes != nil {
+		setColumn("grant_types", pq.Array(upd.GrantTypes))
+	}
+	if upd.AccessTTL != nil {
+		setColumn("access_ttl_seconds", ttlSeconds(*upd.AccessTTL))
+	}
+	if upd.RefreshTTL != nil {
+		setColumn("refresh_ttl_seconds", ttlSeconds(*upd.RefreshTTL))
+	}
+	if upd.RefreshIdleTimeout != nil {
+		setColumn("refresh_idle_timeout_seconds", ttlSeconds(*upd.RefreshIdleTimeout))
+	}
+	if upd.MaxSessions != nil {
+		setColumn("max_sessions", positive(*upd.MaxSessions))
+	}
+	if upd.InviteOnly != nil {
+		setColumn("invite_only", *upd.InviteOnly)
+	}
+	if upd.BackchannelLogoutURI != nil {
+		setColumn("backchannel_logout_uri", *upd.BackchannelLogoutURI)
+	}
+	if upd.FrontchannelLogoutURI != nil {
+		setColumn("frontchannel_logout_uri", *upd.FrontchannelLogoutURI)
+	}
+	if upd.Enabled != nil {
+		setColumn("enabled", *upd.Enabled)
+	}
+
+	if set == 0 {
+		return nil
+	}
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := r.db.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, repository.ErrAppExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrAppNotFound)
+	}
+
+	return nil
+}
+
+func (r *AppRepository) Delete(ctx context.Context, appID int) error {
+	const op = "repository.app.postgres.Delete"
+
+	query := sq.Delete("apps").
+		Where(sq.Eq{"id": appID}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := r.db.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrAppNotFound)
+	}
+
+	return nil
+}
+
+// RotateSecret adds a new secret of the given kind. Secrets it replaces stay valid for grace,
+// so tokens signed with them keep verifying while clients pick up the new one.
+func (r *AppRepository) RotateSecret(ctx context.Context, appID int, kind, secret string, grace time.Duration) error {
+	const op = "repository.app.postgres.RotateSecret"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var locked int
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM apps WHERE id = $1 FOR UPDATE", appID).Scan(&locked); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%s: %w", op, repository.ErrAppNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	expiresAt := time.Now().Add(grace).UTC()
+
+	query := sq.Update("app_secrets").
+		Set("expires_at", expiresAt).
+		Where(sq.Eq{"app_id": appID, "kind": kind}).
+		Where(sq.Or{sq.Eq{"expires_at": nil}, sq.Gt{"expires_at": expiresAt}}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := r.insertSecret(ctx, tx, appID, kind, secret); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// EncryptLegacySecrets encrypts secrets that were migrated from the plaintext apps columns.
+func (r *AppRepository) EncryptLegacySecrets(ctx context.Context) (int, error) {
+	const op = "repository.app.postgres.EncryptLegacySecrets"
+
+	rows, err := r.db.QueryxContext(ctx, "SELECT id, secret FROM app_secrets WHERE NOT encrypted")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	type legacy struct {
+		id     int64
+		secret []byte
+	}
+	var secrets []legacy
+	for rows.Next() {
+		var l legacy
+		if err := rows.Scan(&l.id, &l.secret); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		secrets = append(secrets, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, l := range secrets {
+		_, err := r.db.ExecContext(ctx,
+			"UPDATE app_secrets SET secret = $1, encrypted = true WHERE id = $2 AND NOT encrypted",
+			r.cipher.Seal(l.secret), l.id,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return len(secrets), nil
+}
+
+func (r *AppRepository) insertSecret(ctx context.Context, tx *sqlx.Tx, appID int, kind, secret string) error {
+	query := sq.Insert("app_secrets").
+		Columns("app_id", "kind", "secret").
+		Values(appID, kind, r.cipher.Seal([]byte(secret))).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, sqlStr, args...)
+	return err
+}
+
+func (r *AppRepository) loadSecrets(ctx context.Context, app *models.App) error {
+	query := sq.Select("kind", "secret", "encrypted").
+		From("app_secrets").
+		Where(sq.Eq{"app_id": app.ID}).
+		Where(sq.Or{sq.Eq{"expires_at": nil}, sq.Expr("expires_at > now()")}).
+		OrderBy("created_at DESC", "id DESC").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			kind      string
+			secret    []byte
+			encrypted bool
+		)
+		if err := rows.Scan(&kind, &secret, &encrypted); err != nil {
+			return err
+		}
+
+		if encrypted {
+			if secret, err = r.cipher.Open(secret); err != nil {
+				return fmt.Errorf("decrypt %s secret: %w", kind, err)
+			}
+		}
+
+		switch kind {
+		case models.SecretKindAccess:
+			if app.AccessSecret == "" {
+				app.AccessSecret = string(secret)
+			}
+			app.AccessSecrets = append(app.AccessSecrets, string(secret))
+		case models.SecretKindRefresh:
+			if app.RefreshSecret == "" {
+				app.RefreshSecret = string(secret)
+			}
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanApp(row sqlx.ColScanner) (app models.App, err error) {
+	var accessTTL, refreshTTL, idleTimeout, maxSessions sql.NullInt64
+
+	err = row.Scan(
+		&app.ID, &app.Name, pq.Array(&app.TokenClaims), pq.Array(&app.IDTokenClaims), pq.Array(&app.RedirectURIs), pq.Array(&app.GrantTypes),
+		&accessTTL, &refreshTTL, &idleTimeout, &maxSessions, &app.InviteOnly,
+		&app.BackchannelLogoutURI, &app.FrontchannelLogoutURI, &app.Enabled,
+	)
+	if err != nil {
+		return app, err
+	}
+
+	app.AccessTTL = time.Duration(accessTTL.Int64) * time.Second
+	app.RefreshTTL = time.Duration(refreshTTL.Int64) * time.Second
+	app.RefreshIdleTimeout = time.Duration(idleTimeout.Int64) * time.Second
+	app.MaxSessions = int(maxSessions.Int64)
+
+	return app, nil
+}
+
+func ttlSeconds(ttl time.Duration) any {
+	if ttl <= 0 {
+		return nil
+	}
+	return int64(ttl / time.Second)
+}
+
+func positive(n int) any {
+	if n <= 0 {
+		return nil
+	}
+	return n
+}
+
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,341 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/pkg/requestmeta"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+// auditLockKey serializes appends so every entry links to the one before it.
+const auditLockKey = 0x61756469
+
+type AuditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+var auditColumns = []string{
+	"id", "actor_id", "action", "target_user_id", "service_account_id", "app_id",
+	"ip", "user_agent", "outcome", "details", "created_at", "prev_hash", "hash",
+}
+
+// Record appends the entry to the audit log. Each entry stores the hash of the one before it and
+// a hash over its own content including that link, so changing or removing an entry in the
+// middle of the log breaks the chain. IP and user agent default to those in ctx.
+func (r *AuditRepository) Record(ctx context.Context, entry models.AuditEntry) error {
+	const op = "repository.audit.postgres.Record"
+
+	meta := requestmeta.FromContext(ctx)
+	if entry.IP == "" {
+		entry.IP = meta.IP
+	}
+	if entry.UserAgent == "" {
+		entry.UserAgent = meta.UserAgent
+	}
+	if entry.Outcome == "" {
+		entry.Outcome = models.OutcomeSuccess
+	}
+	// Postgres keeps microseconds; the hash must cover the time as it will be read back.
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	raw, err := json.Marshal(entry.Details)
+	if err != nil {
+		return fmt.Errorf("%s: marshal details: %w", op, err)
+	}
+	details, err := canonicalDetails(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", auditLockKey); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var prevHash string
+	err = tx.QueryRowContext(ctx, "SELECT hash FROM audit_log WHERE hash <> '' ORDER BY id DESC LIMIT 1").Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	hash, err := auditHash(prevHash, entry, details)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := sq.Insert("audit_log").
+		Columns(
+			"actor_id", "action", "target_user_id", "service_account_id", "app_id",
+			"ip", "user_agent", "outcome", "details", "created_at", "prev_hash", "hash",
+		).
+		Values(
+			nullableID(entry.ActorID), entry.Action, nullableID(entry.TargetUserID), nullableID(entry.ServiceAccountID), nullableID(int64(entry.AppID)),
+			entry.IP, entry.UserAgent, entry.Outcome, string(details), entry.CreatedAt, prevHash, hash,
+		).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *AuditRepository) Query(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	const op = "repository.audit.postgres.Query"
+
+	query := sq.Select(auditColumns...).
+		From("audit_log").
+		OrderBy("id DESC").
+		PlaceholderFormat(sq.Dollar)
+
+	if filter.ActorID != 0 {
+		query = query.Where(sq.Eq{"actor_id": filter.ActorID})
+	}
+	if filter.TargetUserID != 0 {
+		query = query.Where(sq.Eq{"target_user_id": filter.TargetUserID})
+	}
+	if filter.ServiceAccountID != 0 {
+		query = query.Where(sq.Eq{"service_account_id": filter.ServiceAccountID})
+	}
+	if filter.AppID != 0 {
+		query = query.Where(sq.Eq{"app_id": filter.AppID})
+	}
+	if strings.HasSuffix(filter.Action, ".") {
+		query = query.Where(sq.Like{"action": escapeLike(filter.Action) + "%"})
+	} else if filter.Action != "" {
+		query = query.Where(sq.Eq{"action": filter.Action})
+	}
+	if filter.Outcome != "" {
+		query = query.Where(sq.Eq{"outcome": filter.Outcome})
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where(sq.GtOrEq{"created_at": filter.Since})
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where(sq.Lt{"created_at": filter.Until})
+	}
+	if filter.BeforeID > 0 {
+		query = query.Where(sq.Lt{"id": filter.BeforeID})
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(uint64(filter.Limit))
+	}
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		entry, _, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entries, nil
+}
+
+// Prune deletes entries created before the given time and returns how many were deleted. The
+// chain stays verifiable from the oldest remaining entry on.
+func (r *AuditRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	const op = "repository.audit.postgres.Prune"
+
+	res, err := r.db.ExecContext(ctx, "DELETE FROM audit_log WHERE created_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// Verify walks the hash chain from the oldest entry. It returns how many entries were checked
+// and the ID of the first one whose content or link doesn't match, or zero if the chain is intact.
+// Entries written before hashing was introduced are skipped.
+func (r *AuditRepository) Verify(ctx context.Context) (checked int, brokenID int64, err error) {
+	const op = "repository.audit.postgres.Verify"
+
+	query := sq.Select(auditColumns...).
+		From("audit_log").
+		Where("hash <> ''").
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var prevHash string
+	first := true
+	for rows.Next() {
+		entry, chain, err := scanAuditEntry(rows)
+		if err != nil {
+			return checked, 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		// Pruning removes the start of the chain, so the oldest entry left is trusted as its anchor.
+		if first {
+			prevHash = chain.prev
+			first = false
+		}
+
+		details, err := canonicalDetails(chain.details)
+		if err != nil {
+			return checked, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		hash, err := auditHash(prevHash, entry, details)
+		if err != nil {
+			return checked, 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		checked++
+		if chain.prev != prevHash || chain.hash != hash {
+			return checked, entry.ID, nil
+		}
+		prevHash = chain.hash
+	}
+	if err := rows.Err(); err != nil {
+		return checked, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return checked, 0, nil
+}
+
+// auditHash is the SHA-256 over the link to the previous entry and the content of this one.
+func auditHash(prevHash string, entry models.AuditEntry, details []byte) (string, error) {
+	data, err := json.Marshal(struct {
+		Prev             string          `json:"prev"`
+		ActorID          int64           `json:"actor_id"`
+		Action           string          `json:"action"`
+		TargetUserID     int64           `json:"target_user_id"`
+		ServiceAccountID int64           `json:"service_account_id"`
+		AppID            int             `json:"app_id"`
+		IP               string          `json:"ip"`
+		UserAgent        string          `json:"user_agent"`
+		Outcome          string          `json:"outcome"`
+		Details          json.RawMessage `json:"details"`
+		CreatedAt        string          `json:"created_at"`
+	}{
+		Prev:             prevHash,
+		ActorID:          entry.ActorID,
+		Action:           entry.Action,
+		TargetUserID:     entry.TargetUserID,
+		ServiceAccountID: entry.ServiceAccountID,
+		AppID:            entry.AppID,
+		IP:               entry.IP,
+		UserAgent:        entry.UserAgent,
+		Outcome:          entry.Outcome,
+		Details:          details,
+		CreatedAt:        entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal audit entry: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalDetails encodes details the same way whether they come from the caller or back from
+// JSONB, which reorders keys and drops the original formatting.
+func canonicalDetails(raw []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var details map[string]any
+	if err := dec.Decode(&details); err != nil {
+		return nil, fmt.Errorf("decode details: %w", err)
+	}
+	if len(details) == 0 {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(details)
+}
+
+type auditChain struct {
+	details []byte
+	prev    string
+	hash    string
+}
+
+func scanAuditEntry(row sqlx.ColScanner) (entry models.AuditEntry, chain auditChain, err error) {
+	var actorID, targetUserID, serviceAccountID, appID sql.NullInt64
+
+	err = row.Scan(
+		&entry.ID, &actorID, &entry.Action, &targetUserID, &serviceAccountID, &appID,
+		&entry.IP, &entry.UserAgent, &entry.Outcome, &chain.details, &entry.CreatedAt, &chain.prev, &chain.hash,
+	)
+	if err != nil {
+		return entry, chain, err
+	}
+
+	entry.ActorID = actorID.Int64
+	entry.TargetUserID = targetUserID.Int64
+	entry.ServiceAccountID = serviceAccountID.Int64
+	entry.AppID = int(appID.Int64)
+
+	if err := json.Unmarshal(chain.details, &entry.Details); err != nil {
+		return entry, chain, fmt.Errorf("decode details: %w", err)
+	}
+
+	return entry, chain, nil
+}
+
+func nullableID(id int64) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
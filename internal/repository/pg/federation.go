@@ -0,0 +1,379 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/pkg/password"
+	"context"
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type FederationRepository struct {
+	db     *sqlx.DB
+	cipher SecretCipher
+}
+
+func NewFederationRepository(db *sqlx.DB, cipher SecretCipher) *FederationRepository {
+	return &FederationRepository{db: db, cipher: cipher}
+}
+
+var providerColumns = []string{
+	"id", "slug", "name", "issuer", "client_id", "scopes", "allowed_email_domains",
+	"jit_provisioning", "link_by_email", "disabled", "created_at",
+}
+
+var identityColumns = []string{
+	"i.id", "i.user_id", "i.provider_id", "p.slug", "i.subject", "i.email", "i.created_at", "i.last_login_at",
+}
+
+// CreateProvider stores the provider with its client secret encrypted.
+func (r *FederationRepository) CreateProvider(ctx context.Context, p models.IdentityProvider) (int, error) {
+	const op = "repository.federation.postgres.CreateProvider"
+
+	query := sq.Insert("identity_providers").
+		Columns(
+			"slug", "name", "issuer", "client_id", "client_secret", "scopes",
+			"allowed_email_domains", "jit_provisioning", "link_by_email",
+		).
+		Values(
+			p.Slug, p.Name, p.Issuer, p.ClientID, r.cipher.Seal([]byte(p.ClientSecret)), pq.Array(orEmpty(p.Scopes)),
+			pq.Array(orEmpty(p.AllowedEmailDomains)), p.JITProvisioning, p.LinkByEmail,
+		).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var id int
+	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("%s: %w", op, repository.ErrProviderExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// GetProvider returns the provider with its client secret.
+func (r *FederationRepository) GetProvider(ctx context.Context, providerID int) (models.IdentityProvider, error) {
+	const op = "repository.federation.postgres.GetProvider"
+
+	p, err := r.getProviderBy(ctx, sq.Eq{"id": providerID})
+	if err != nil {
+		return models.IdentityProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// GetProviderBySlug returns the provider with its client secret.
+func (r *FederationRepository) GetProviderBySlug(ctx context.Context, slug string) (models.IdentityProvider, error) {
+	const op = "repository.federation.postgres.GetProviderBySlug"
+
+	p, err := r.getProviderBy(ctx, sq.Eq{"slug": slug})
+	if err != nil {
+		return models.IdentityProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+func (r *FederationRepository) getProviderBy(ctx context.Context, pred sq.Eq) (models.IdentityProvider, error) {
+	query := sq.Select(append(providerColumns, "client_secret")...).
+		From("identity_providers").
+		Where(pred).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.IdentityProvider{}, fmt.Errorf("build query: %w", err)
+	}
+
+	var (
+		p      models.IdentityProvider
+		secret []byte
+	)
+	err = r.db.QueryRowxContext(ctx, sqlStr, args...).Scan(
+		&p.ID, &p.Slug, &p.Name, &p.Issuer, &p.ClientID, pq.Array(&p.Scopes), pq.Array(&p.AllowedEmailDomains),
+		&p.JITProvisioning, &p.LinkByEmail, &p.Disabled, &p.CreatedAt, &secret,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.IdentityProvider{}, repository.ErrProviderNotFound
+		}
+		return models.IdentityProvider{}, err
+	}
+
+	plain, err := r.cipher.Open(secret)
+	if err != nil {
+		return models.IdentityProvider{}, fmt.Errorf("decrypt client secret: %w", err)
+	}
+	p.ClientSecret = string(plain)
+
+	return p, nil
+}
+
+// ListProviders returns all providers without their client secrets.
+func (r *FederationRepository) ListProviders(ctx context.Context) ([]models.IdentityProvider, error) {
+	const op = "repository.federation.postgres.ListProviders"
+
+	query := sq.Select(providerColumns...).
+		From("identity_providers").
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var providers []models.IdentityProvider
+	for rows.Next() {
+		var p models.IdentityProvider
+		err := rows.Scan(
+			&p.ID, &p.Slug, &p.Name, &p.Issuer, &p.ClientID, pq.Array(&p.Scopes), pq.Array(&p.AllowedEmailDomains),
+			&p.JITProvisioning, &p.LinkByEmail, &p.Disabled, &p.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		providers = append(providers, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return providers, nil
+}
+
+func (r *FederationRepository) SetProviderDisabled(ctx context.Context, providerID int, disabled bool) error {
+	const op = "repository.federation.postgres.SetProviderDisabled"
+
+	query := sq.Update("identity_providers").
+		Set("disabled", disabled).
+		Where(sq.Eq{"id": providerID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrProviderNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteProvider removes the provider together with the identities linked to it.
+func (r *FederationRepository) DeleteProvider(ctx context.Context, providerID int) error {
+	const op = "repository.federation.postgres.DeleteProvider"
+
+	query := sq.Delete("identity_providers").
+		Where(sq.Eq{"id": providerID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrProviderNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetIdentity returns the identity with the subject at the provider.
+func (r *FederationRepository) GetIdentity(ctx context.Context, providerID int, subject string) (models.UserIdentity, error) {
+	const op = "repository.federation.postgres.GetIdentity"
+
+	query := sq.Select(identityColumns...).
+		From("user_identities i").
+		Join("identity_providers p ON p.id = i.provider_id").
+		Where(sq.Eq{"i.provider_id": providerID, "i.subject": subject}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.UserIdentity{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	identity, err := scanIdentity(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.UserIdentity{}, fmt.Errorf("%s: %w", op, repository.ErrIdentityNotFound)
+		}
+		return models.UserIdentity{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return identity, nil
+}
+
+func (r *FederationRepository) ListIdentities(ctx context.Context, userID int64) ([]models.UserIdentity, error) {
+	const op = "repository.federation.postgres.ListIdentities"
+
+	query := sq.Select(identityColumns...).
+		From("user_identities i").
+		Join("identity_providers p ON p.id = i.provider_id").
+		Where(sq.Eq{"i.user_id": userID}).
+		OrderBy("i.id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var identities []models.UserIdentity
+	for rows.Next() {
+		identity, err := scanIdentity(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		identities = append(identities, identity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return identities, nil
+}
+
+// LinkIdentity links an existing user to their account at the provider.
+func (r *FederationRepository) LinkIdentity(ctx context.Context, identity models.UserIdentity) (int64, error) {
+	const op = "repository.federation.postgres.LinkIdentity"
+
+	id, err := insertIdentity(ctx, r.db, identity)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *FederationRepository) UnlinkIdentity(ctx context.Context, userID int64, providerID int) error {
+	const op = "repository.federation.postgres.UnlinkIdentity"
+
+	query := sq.Delete("user_identities").
+		Where(sq.Eq{"user_id": userID, "provider_id": providerID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrIdentityNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// TouchIdentity records a sign-in with the identity and refreshes the email the provider reported.
+func (r *FederationRepository) TouchIdentity(ctx context.Context, identityID int64, email string) error {
+	const op = "repository.federation.postgres.TouchIdentity"
+
+	query := sq.Update("user_identities").
+		Set("last_login_at", sq.Expr("now()")).
+		Set("email", email).
+		Where(sq.Eq{"id": identityID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrIdentityNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CreateUser creates a user without a password together with their identity at the provider.
+func (r *FederationRepository) CreateUser(ctx context.Context, email string, emailVerified bool, identity models.UserIdentity) (int64, error) {
+	const op = "repository.federation.postgres.CreateUser"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO users (email, pass_hash, pass_algo, email_verified) VALUES ($1, '', $2, $3) RETURNING id",
+		email, password.AlgoNone, emailVerified,
+	).Scan(&userID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("%s: %w", op, repository.ErrUserExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	identity.UserID = userID
+	if _, err := insertIdentity(ctx, tx, identity); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	payload := map[string]any{"user_id": userID, "email": email, "provider_id": identity.ProviderID}
+	if err := enqueueEvent(ctx, tx, models.EventUserRegistered, userID, payload); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+func insertIdentity(ctx context.Context, db sqlx.QueryerContext, identity models.UserIdentity) (int64, error) {
+	query := sq.Insert("user_identities").
+		Columns("user_id", "provider_id", "subject", "email", "last_login_at").
+		Values(identity.UserID, identity.ProviderID, identity.Subject, identity.Email, sq.Expr("now()")).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query: %w", err)
+	}
+
+	var id int64
+	if err := db.QueryRowxContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505":
+				return 0, repository.ErrIdentityExists
+			case "23503":
+				if pqErr.Constraint == "user_identities_provider_id_fkey" {
+					return 0, repository.ErrProviderNotFound
+				}
+				return 0, repository.ErrUserNotFound
+			}
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func scanIdentity(row sqlx.ColScanner) (identity models.UserIdentity, err error) {
+	var lastLoginAt sql.NullTime
+
+	err = row.Scan(
+		&identity.ID, &identity.UserID, &identity.ProviderID, &identity.ProviderSlug,
+		&identity.Subject, &identity.Email, &identity.CreatedAt, &lastLoginAt,
+	)
+	identity.LastLoginAt = lastLoginAt.Time
+
+	return identity, err
+}
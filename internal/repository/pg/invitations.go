@@ -0,0 +1,240 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var invitationColumns = []string{
+	"id", "org_id", "email", "role", "nonce", "invited_by",
+	"expires_at", "accepted_at", "accepted_by", "revoked_at", "created_at",
+}
+
+// openInvitation matches invitations that were neither accepted nor revoked. They may still be expired.
+var openInvitation = sq.Expr("accepted_at IS NULL AND revoked_at IS NULL")
+
+func (r *OrgRepository) CreateInvitation(ctx context.Context, inv models.Invitation) (int64, error) {
+	const op = "repository.org.postgres.CreateInvitation"
+
+	query := sq.Insert("invitations").
+		Columns("org_id", "email", "role", "nonce", "invited_by", "expires_at").
+		Values(inv.OrgID, inv.Email, inv.Role, inv.Nonce, nullableID(inv.InvitedBy), inv.ExpiresAt).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var id int64
+	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505":
+				return 0, fmt.Errorf("%s: %w", op, repository.ErrInvitationExists)
+			case "23503":
+				return 0, fmt.Errorf("%s: %w", op, repository.ErrOrgNotFound)
+			}
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *OrgRepository) GetInvitation(ctx context.Context, invitationID int64) (models.Invitation, error) {
+	const op = "repository.org.postgres.GetInvitation"
+
+	query := sq.Select(invitationColumns...).
+		From("invitations").
+		Where(sq.Eq{"id": invitationID}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	inv, err := scanInvitation(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Invitation{}, fmt.Errorf("%s: %w", op, repository.ErrInvitationNotFound)
+		}
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return inv, nil
+}
+
+func (r *OrgRepository) ListInvitations(ctx context.Context, orgID int64) ([]models.Invitation, error) {
+	const op = "repository.org.postgres.ListInvitations"
+
+	query := sq.Select(invitationColumns...).
+		From("invitations").
+		Where(sq.Eq{"org_id": orgID}).
+		OrderBy("created_at DESC", "id DESC").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var invitations []models.Invitation
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		invitations = append(invitations, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return invitations, nil
+}
+
+// RevokeInvitation makes an open invitation unusable.
+func (r *OrgRepository) RevokeInvitation(ctx context.Context, orgID, invitationID int64) error {
+	const op = "repository.org.postgres.RevokeInvitation"
+
+	query := sq.Update("invitations").
+		Set("revoked_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": invitationID, "org_id": orgID}).
+		Where(openInvitation).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrInvitationNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RenewInvitation gives an open invitation a new nonce and expiry, invalidating links sent before.
+func (r *OrgRepository) RenewInvitation(ctx context.Context, orgID, invitationID int64, nonce string, expiresAt time.Time) error {
+	const op = "repository.org.postgres.RenewInvitation"
+
+	query := sq.Update("invitations").
+		Set("nonce", nonce).
+		Set("expires_at", expiresAt).
+		Where(sq.Eq{"id": invitationID, "org_id": orgID}).
+		Where(openInvitation).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrInvitationNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AcceptInvitation marks the invitation accepted and makes the invited user a member, all in one
+// transaction. With a non-nil passHash a new account is created for the invited email and
+// ErrUserExists is returned if there already is one; with a nil passHash the account must exist.
+// ErrInvitationNotFound is returned unless the invitation is open, unexpired and has this nonce.
+func (r *OrgRepository) AcceptInvitation(ctx context.Context, invitationID int64, nonce string, passHash []byte) (userID int64, err error) {
+	const op = "repository.org.postgres.AcceptInvitation"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var orgID int64
+	var email, role string
+	err = tx.QueryRowContext(ctx, `
+		SELECT org_id, email, role FROM invitations
+		WHERE id = $1 AND nonce = $2 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > now()
+		FOR UPDATE`,
+		invitationID, nonce).Scan(&orgID, &email, &role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("%s: %w", op, repository.ErrInvitationNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if passHash != nil {
+		// Following the link proves the address belongs to the new user.
+		err = tx.QueryRowContext(ctx,
+			"INSERT INTO users (email, pass_hash, email_verified) VALUES ($1, $2, true) RETURNING id",
+			email, passHash).Scan(&userID)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				return 0, fmt.Errorf("%s: %w", op, repository.ErrUserExists)
+			}
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		payload := map[string]any{"user_id": userID, "email": email, "org_id": orgID}
+		if err := enqueueEvent(ctx, tx, models.EventUserRegistered, userID, payload); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		if err := tx.QueryRowContext(ctx, "SELECT id FROM users WHERE email = $1", email).Scan(&userID); err != nil {
+			if err == sql.ErrNoRows {
+				return 0, fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+			}
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// Someone who already is a member keeps their current role.
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO memberships (org_id, user_id, role) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+		orgID, userID, role)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE invitations SET accepted_at = now(), accepted_by = $2 WHERE id = $1",
+		invitationID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+func scanInvitation(row sqlx.ColScanner) (inv models.Invitation, err error) {
+	var invitedBy, acceptedBy sql.NullInt64
+	var acceptedAt, revokedAt sql.NullTime
+
+	err = row.Scan(
+		&inv.ID, &inv.OrgID, &inv.Email, &inv.Role, &inv.Nonce, &invitedBy,
+		&inv.ExpiresAt, &acceptedAt, &acceptedBy, &revokedAt, &inv.CreatedAt,
+	)
+	if err != nil {
+		return inv, err
+	}
+
+	inv.InvitedBy = invitedBy.Int64
+	inv.AcceptedBy = acceptedBy.Int64
+	inv.AcceptedAt = acceptedAt.Time
+	inv.RevokedAt = revokedAt.Time
+
+	return inv, nil
+}
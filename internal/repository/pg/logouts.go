@@ -0,0 +1,128 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+// LogoutRepository queues the logout tokens apps are sent over the back channel.
+type LogoutRepository struct {
+	db *sqlx.DB
+}
+
+func NewLogoutRepository(db *sqlx.DB) *LogoutRepository {
+	return &LogoutRepository{db: db}
+}
+
+// Enqueue queues the logouts to be sent right away.
+func (r *LogoutRepository) Enqueue(ctx context.Context, logouts []models.Logout) error {
+	const op = "repository.logout.postgres.Enqueue"
+
+	if len(logouts) == 0 {
+		return nil
+	}
+
+	query := sq.Insert("logout_deliveries").
+		Columns("app_id", "user_id", "session_id").
+		PlaceholderFormat(sq.Dollar)
+	for _, l := range logouts {
+		query = query.Values(l.AppID, l.UserID, l.SessionID)
+	}
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ClaimDue returns up to limit pending logouts that are due and postpones them by lease, like
+// WebhookRepository.ClaimDue.
+func (r *LogoutRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.Logout, error) {
+	const op = "repository.logout.postgres.ClaimDue"
+
+	rows, err := r.db.QueryxContext(ctx, `
+		UPDATE logout_deliveries SET next_attempt_at = now() + $2 * interval '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM logout_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, app_id, user_id, session_id, attempts`,
+		limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var due []models.Logout
+	for rows.Next() {
+		var l models.Logout
+		if err := rows.Scan(&l.ID, &l.AppID, &l.UserID, &l.SessionID, &l.Attempts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		due = append(due, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return due, nil
+}
+
+func (r *LogoutRepository) MarkDelivered(ctx context.Context, logoutID int64, statusCode int) error {
+	const op = "repository.logout.postgres.MarkDelivered"
+
+	query := sq.Update("logout_deliveries").
+		Set("status", models.DeliveryDelivered).
+		Set("attempts", sq.Expr("attempts + 1")).
+		Set("last_status_code", statusCode).
+		Set("last_error", "").
+		Set("delivered_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": logoutID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrLogoutNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt. The logout is tried again at retryAt, or dead-lettered if
+// retryAt is zero. A zero statusCode means no response was received.
+func (r *LogoutRepository) MarkFailed(ctx context.Context, logoutID int64, statusCode int, lastErr string, retryAt time.Time) error {
+	const op = "repository.logout.postgres.MarkFailed"
+
+	query := sq.Update("logout_deliveries").
+		Set("attempts", sq.Expr("attempts + 1")).
+		Set("last_status_code", positive(statusCode)).
+		Set("last_error", lastErr).
+		Where(sq.Eq{"id": logoutID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if retryAt.IsZero() {
+		query = query.Set("status", models.DeliveryDead)
+	} else {
+		query = query.Set("next_attempt_at", retryAt)
+	}
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrLogoutNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
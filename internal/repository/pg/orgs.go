@@ -0,0 +1,364 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type OrgRepository struct {
+	db *sqlx.DB
+}
+
+func NewOrgRepository(db *sqlx.DB) *OrgRepository {
+	return &OrgRepository{db: db}
+}
+
+var orgColumns = []string{"o.id", "o.slug", "o.name", "o.allowed_email_domains", "o.require_mfa", "o.created_at"}
+
+// Create stores the organization and makes ownerID its first owner.
+func (r *OrgRepository) Create(ctx context.Context, org models.Organization, ownerID int64) (int64, error) {
+	const op = "repository.org.postgres.Create"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := sq.Insert("organizations").
+		Columns("slug", "name", "allowed_email_domains", "require_mfa").
+		Values(org.Slug, org.Name, pq.Array(orEmpty(org.AllowedEmailDomains)), org.RequireMFA).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var id int64
+	if err := tx.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("%s: %w", op, repository.ErrOrgExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := addMember(ctx, tx, id, ownerID, models.OrgRoleOwner); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *OrgRepository) Get(ctx context.Context, orgID int64) (models.Organization, error) {
+	const op = "repository.org.postgres.Get"
+
+	query := sq.Select(orgColumns...).
+		From("organizations o").
+		Where(sq.Eq{"o.id": orgID}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.Organization{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	org, err := scanOrg(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Organization{}, fmt.Errorf("%s: %w", op, repository.ErrOrgNotFound)
+		}
+		return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return org, nil
+}
+
+// ListForUser returns the organizations the user belongs to together with their role in each.
+func (r *OrgRepository) ListForUser(ctx context.Context, userID int64) ([]models.OrgMembership, error) {
+	const op = "repository.org.postgres.ListForUser"
+
+	query := sq.Select(append(orgColumns, "m.role")...).
+		From("organizations o").
+		Join("memberships m ON m.org_id = o.id").
+		Where(sq.Eq{"m.user_id": userID}).
+		OrderBy("o.name").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var res []models.OrgMembership
+	for rows.Next() {
+		var m models.OrgMembership
+		o := &m.Organization
+		if err := rows.Scan(&o.ID, &o.Slug, &o.Name, pq.Array(&o.AllowedEmailDomains), &o.RequireMFA, &o.CreatedAt, &m.Role); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+func (r *OrgRepository) Update(ctx context.Context, orgID int64, upd models.OrganizationUpdate) error {
+	const op = "repository.org.postgres.Update"
+
+	query := sq.Update("organizations").
+		Where(sq.Eq{"id": orgID}).
+		PlaceholderFormat(sq.Dollar)
+
+	set := 0
+	if upd.Name != nil {
+		query = query.Set("name", *upd.Name)
+		set++
+	}
+	if upd.AllowedEmailDomains != nil {
+		query = query.Set("allowed_email_domains", pq.Array(upd.AllowedEmailDomains))
+		set++
+	}
+	if upd.RequireMFA != nil {
+		query = query.Set("require_mfa", *upd.RequireMFA)
+		set++
+	}
+
+	if set == 0 {
+		return nil
+	}
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrOrgNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *OrgRepository) Delete(ctx context.Context, orgID int64) error {
+	const op = "repository.org.postgres.Delete"
+
+	query := sq.Delete("organizations").
+		Where(sq.Eq{"id": orgID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrOrgNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *OrgRepository) GetMembership(ctx context.Context, orgID, userID int64) (models.Membership, error) {
+	const op = "repository.org.postgres.GetMembership"
+
+	query := sq.Select("m.org_id", "m.user_id", "u.email", "m.role", "m.created_at").
+		From("memberships m").
+		Join("users u ON u.id = m.user_id").
+		Where(sq.Eq{"m.org_id": orgID, "m.user_id": userID}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.Membership{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	m, err := scanMembership(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Membership{}, fmt.Errorf("%s: %w", op, repository.ErrMembershipNotFound)
+		}
+		return models.Membership{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return m, nil
+}
+
+func (r *OrgRepository) ListMembers(ctx context.Context, orgID int64) ([]models.Membership, error) {
+	const op = "repository.org.postgres.ListMembers"
+
+	query := sq.Select("m.org_id", "m.user_id", "u.email", "m.role", "m.created_at").
+		From("memberships m").
+		Join("users u ON u.id = m.user_id").
+		Where(sq.Eq{"m.org_id": orgID}).
+		OrderBy("u.email").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var members []models.Membership
+	for rows.Next() {
+		m, err := scanMembership(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return members, nil
+}
+
+func (r *OrgRepository) AddMember(ctx context.Context, orgID, userID int64, role string) error {
+	const op = "repository.org.postgres.AddMember"
+
+	if err := addMember(ctx, r.db, orgID, userID, role); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetMemberRole changes the member's role. Demoting the last owner fails with ErrLastOwner.
+func (r *OrgRepository) SetMemberRole(ctx context.Context, orgID, userID int64, role string) error {
+	const op = "repository.org.postgres.SetMemberRole"
+
+	err := r.changeMembers(ctx, orgID, func(tx *sqlx.Tx) error {
+		query := sq.Update("memberships").
+			Set("role", role).
+			Where(sq.Eq{"org_id": orgID, "user_id": userID}).
+			PlaceholderFormat(sq.Dollar)
+		return execAffecting(ctx, tx, query, repository.ErrMembershipNotFound)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RemoveMember takes the user out of the organization. Removing the last owner fails with ErrLastOwner.
+func (r *OrgRepository) RemoveMember(ctx context.Context, orgID, userID int64) error {
+	const op = "repository.org.postgres.RemoveMember"
+
+	err := r.changeMembers(ctx, orgID, func(tx *sqlx.Tx) error {
+		query := sq.Delete("memberships").
+			Where(sq.Eq{"org_id": orgID, "user_id": userID}).
+			PlaceholderFormat(sq.Dollar)
+		return execAffecting(ctx, tx, query, repository.ErrMembershipNotFound)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// changeMembers runs fn with the organization row locked and rolls back if no owner is left afterwards.
+func (r *OrgRepository) changeMembers(ctx context.Context, orgID int64, fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM organizations WHERE id = $1 FOR UPDATE", orgID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return repository.ErrOrgNotFound
+		}
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	var owners int
+	if err := tx.QueryRowContext(ctx, "SELECT count(*) FROM memberships WHERE org_id = $1 AND role = $2", orgID, models.OrgRoleOwner).Scan(&owners); err != nil {
+		return err
+	}
+	if owners == 0 {
+		return repository.ErrLastOwner
+	}
+
+	return tx.Commit()
+}
+
+func addMember(ctx context.Context, db sqlx.ExecerContext, orgID, userID int64, role string) error {
+	query := sq.Insert("memberships").
+		Columns("org_id", "user_id", "role").
+		Values(orgID, userID, role).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, sqlStr, args...); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch {
+			case pqErr.Code == "23505":
+				return repository.ErrMembershipExists
+			case pqErr.Code == "23503" && pqErr.Constraint == "memberships_user_id_fkey":
+				return repository.ErrUserNotFound
+			case pqErr.Code == "23503":
+				return repository.ErrOrgNotFound
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func execAffecting(ctx context.Context, db sqlx.ExecerContext, query sq.Sqlizer, notFound error) error {
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	res, err := db.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+func scanOrg(row sqlx.ColScanner) (org models.Organization, err error) {
+	err = row.Scan(&org.ID, &org.Slug, &org.Name, pq.Array(&org.AllowedEmailDomains), &org.RequireMFA, &org.CreatedAt)
+	return org, err
+}
+
+func scanMembership(row sqlx.ColScanner) (m models.Membership, err error) {
+	err = row.Scan(&m.OrgID, &m.UserID, &m.Email, &m.Role, &m.CreatedAt)
+	return m, err
+}
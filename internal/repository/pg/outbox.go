@@ -0,0 +1,35 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+// enqueueEvent adds an event to the outbox. It takes the transaction of the change the event
+// describes, so the event is published if and only if the change is committed.
+func enqueueEvent(ctx context.Context, tx sqlx.ExecerContext, eventType string, userID int64, payload map[string]any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal event payload: %w", err)
+	}
+
+	query := sq.Insert("outbox_events").
+		Columns("type", "user_id", "payload").
+		Values(eventType, nullableID(userID), string(data)).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("enqueue %s event: %w", eventType, err)
+	}
+
+	return nil
+}
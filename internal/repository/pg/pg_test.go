@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -13,6 +14,9 @@ import (
 	"auth/internal/domain/models"
 	"auth/internal/repository"
 	"auth/internal/repository/pg"
+	"auth/pkg/requestmeta"
+	"auth/pkg/scim"
+	"auth/pkg/secretbox"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -27,6 +31,17 @@ import (
 var db *sqlx.DB
 var userRepo *pg.UserRepository
 var appRepo *pg.AppRepository
+var auditRepo *pg.AuditRepository
+var roleRepo *pg.RoleRepository
+var relationRepo *pg.RelationRepository
+var orgRepo *pg.OrgRepository
+var tokenRepo *pg.TokenRepository
+var serviceAccountRepo *pg.ServiceAccountRepository
+var webhookRepo *pg.WebhookRepository
+var logoutRepo *pg.LogoutRepository
+var federationRepo *pg.FederationRepository
+var samlRepo *pg.SAMLRepository
+var scimRepo *pg.SCIMRepository
 
 func TestMain(m *testing.M) {
 	ctx := context.Background()
@@ -73,7 +88,23 @@ func TestMain(m *testing.M) {
 	}
 
 	userRepo = pg.NewUserRepository(db)
-	appRepo = pg.NewAppRepository(db)
+	box, err := secretbox.New([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		log.Fatalf("could not init secretbox: %v", err)
+	}
+
+	appRepo = pg.NewAppRepository(db, box)
+	auditRepo = pg.NewAuditRepository(db)
+	roleRepo = pg.NewRoleRepository(db)
+	relationRepo = pg.NewRelationRepository(db)
+	orgRepo = pg.NewOrgRepository(db)
+	tokenRepo = pg.NewTokenRepository(db)
+	serviceAccountRepo = pg.NewServiceAccountRepository(db)
+	webhookRepo = pg.NewWebhookRepository(db, box)
+	logoutRepo = pg.NewLogoutRepository(db)
+	federationRepo = pg.NewFederationRepository(db, box)
+	samlRepo = pg.NewSAMLRepository(db)
+	scimRepo = pg.NewSCIMRepository(db)
 
 	code := m.Run()
 	os.Exit(code)
@@ -93,6 +124,7 @@ func TestUserRepository_CreateAndGet(t *testing.T) {
 			ID:       id,
 			Email:    "test@mail.com",
 			PassHash: []byte("hash123"),
+			PassAlgo: "bcrypt",
 		}, user)
 	})
 
@@ -107,6 +139,227 @@ func TestUserRepository_CreateAndGet(t *testing.T) {
 	})
 }
 
+func TestUserRepository_Import(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("import new user", func(t *testing.T) {
+		id, created, err := userRepo.Import(ctx, "legacy@mail.com", []byte("salt$abc"), "sha512-salted")
+		assert.NoError(t, err)
+		assert.True(t, created)
+
+		user, err := userRepo.Get(ctx, "legacy@mail.com")
+		assert.NoError(t, err)
+		assert.Equal(t, id, user.ID)
+		assert.Equal(t, "sha512-salted", user.PassAlgo)
+	})
+
+	t.Run("import is idempotent", func(t *testing.T) {
+		_, created, err := userRepo.Import(ctx, "legacy@mail.com", []byte("other"), "pbkdf2-sha256")
+		assert.NoError(t, err)
+		assert.False(t, created)
+
+		user, err := userRepo.Get(ctx, "legacy@mail.com")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("salt$abc"), user.PassHash)
+	})
+
+	t.Run("update pass hash", func(t *testing.T) {
+		user, err := userRepo.Get(ctx, "legacy@mail.com")
+		assert.NoError(t, err)
+
+		err = userRepo.UpdatePassHash(ctx, user.ID, []byte("bcrypt-hash"), "bcrypt")
+		assert.NoError(t, err)
+
+		user, err = userRepo.Get(ctx, "legacy@mail.com")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("bcrypt-hash"), user.PassHash)
+		assert.Equal(t, "bcrypt", user.PassAlgo)
+	})
+
+	t.Run("update missing user", func(t *testing.T) {
+		err := userRepo.UpdatePassHash(ctx, 99999, []byte("hash"), "bcrypt")
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+}
+
+func TestUserRepository_CreateShadow(t *testing.T) {
+	ctx := context.Background()
+
+	id, err := userRepo.CreateShadow(ctx, "directory@mail.com")
+	assert.NoError(t, err)
+
+	user, err := userRepo.Get(ctx, "directory@mail.com")
+	assert.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Equal(t, "ldap", user.PassAlgo)
+	assert.True(t, user.EmailVerified)
+
+	_, err = userRepo.CreateShadow(ctx, "directory@mail.com")
+	assert.ErrorIs(t, err, repository.ErrUserExists)
+}
+
+func TestUserRepository_CreatePasswordless(t *testing.T) {
+	ctx := context.Background()
+
+	id, err := userRepo.CreatePasswordless(ctx, "passwordless@mail.com")
+	assert.NoError(t, err)
+
+	user, err := userRepo.GetByID(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "none", user.PassAlgo)
+	assert.Empty(t, user.PassHash)
+	assert.True(t, user.EmailVerified)
+
+	_, err = userRepo.CreatePasswordless(ctx, "passwordless@mail.com")
+	assert.ErrorIs(t, err, repository.ErrUserExists)
+}
+
+func TestUserRepository_Phone(t *testing.T) {
+	ctx := context.Background()
+
+	first, err := userRepo.CreateWithPhone(ctx, "+447911000001")
+	assert.NoError(t, err)
+	second, err := userRepo.CreateWithPhone(ctx, "+447911000002")
+	assert.NoError(t, err, "users without an email don't clash")
+	_, err = userRepo.CreateWithPhone(ctx, "+447911000001")
+	assert.ErrorIs(t, err, repository.ErrUserExists)
+
+	user, err := userRepo.GetByPhone(ctx, "+447911000001")
+	assert.NoError(t, err)
+	assert.Equal(t, first, user.ID)
+	assert.Empty(t, user.Email)
+	assert.True(t, user.PhoneVerified)
+	_, err = userRepo.Get(ctx, "")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	assert.ErrorIs(t, userRepo.SetPhoneVerified(ctx, second, "+447911000001"), repository.ErrPhoneTaken)
+
+	// Changing the number in the profile unverifies it, so it no longer signs anyone in.
+	other := "+447911000003"
+	_, err = userRepo.UpdateProfile(ctx, second, models.ProfileUpdate{Phone: &other})
+	assert.NoError(t, err)
+	_, err = userRepo.GetByPhone(ctx, other)
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	_, err = userRepo.GetByPhone(ctx, "+447911000002")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	assert.NoError(t, userRepo.SetPhoneVerified(ctx, second, other))
+	user, err = userRepo.GetByPhone(ctx, other)
+	assert.NoError(t, err)
+	assert.Equal(t, second, user.ID)
+}
+
+func TestUserRepository_Profile(t *testing.T) {
+	ctx := context.Background()
+
+	id, err := userRepo.Create(ctx, "profile@mail.com", []byte("hash"))
+	assert.NoError(t, err)
+
+	t.Run("empty profile", func(t *testing.T) {
+		profile, err := userRepo.GetProfile(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, "profile@mail.com", profile.Email)
+		assert.Empty(t, profile.DisplayName)
+		assert.Equal(t, map[string]any{}, profile.UserMetadata)
+	})
+
+	t.Run("partial update merges metadata", func(t *testing.T) {
+		name, locale := "Jane", "en-GB"
+		_, err := userRepo.UpdateProfile(ctx, id, models.ProfileUpdate{
+			DisplayName:  &name,
+			UserMetadata: map[string]any{"theme": "dark", "beta": true},
+		})
+		assert.NoError(t, err)
+
+		profile, err := userRepo.UpdateProfile(ctx, id, models.ProfileUpdate{
+			Locale:       &locale,
+			UserMetadata: map[string]any{"beta": nil},
+			AppMetadata:  map[string]any{"plan": "pro"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Jane", profile.DisplayName)
+		assert.Equal(t, "en-GB", profile.Locale)
+		assert.Equal(t, map[string]any{"theme": "dark"}, profile.UserMetadata)
+		assert.Equal(t, map[string]any{"plan": "pro"}, profile.AppMetadata)
+	})
+
+	t.Run("profile not found", func(t *testing.T) {
+		_, err := userRepo.GetProfile(ctx, 99999)
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+}
+
+func TestUserRepository_Admin(t *testing.T) {
+	ctx := context.Background()
+
+	var ids []int64
+	for _, email := range []string{"admin-a@corp.com", "admin-b@corp.com", "admin-c@corp.com"} {
+		id, err := userRepo.Create(ctx, email, []byte("hash"))
+		assert.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	t.Run("list with cursor", func(t *testing.T) {
+		page, err := userRepo.List(ctx, models.UserFilter{Query: "@corp.com", Limit: 2})
+		assert.NoError(t, err)
+		assert.Len(t, page, 2)
+		assert.Equal(t, ids[0], page[0].ID)
+
+		page, err = userRepo.List(ctx, models.UserFilter{Query: "@CORP.com", AfterID: page[1].ID, Limit: 2})
+		assert.NoError(t, err)
+		assert.Len(t, page, 1)
+		assert.Equal(t, ids[2], page[0].ID)
+	})
+
+	t.Run("disable and filter", func(t *testing.T) {
+		assert.NoError(t, userRepo.SetDisabled(ctx, ids[1], true))
+
+		disabled := true
+		users, err := userRepo.List(ctx, models.UserFilter{Query: "@corp.com", Disabled: &disabled})
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, ids[1], users[0].ID)
+		assert.True(t, users[0].Disabled)
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		assert.NoError(t, userRepo.SetEmailVerified(ctx, ids[0], true))
+		assert.NoError(t, userRepo.SetPasswordResetRequired(ctx, ids[0], true))
+
+		user, err := userRepo.GetByID(ctx, ids[0])
+		assert.NoError(t, err)
+		assert.True(t, user.EmailVerified)
+		assert.True(t, user.PasswordResetRequired)
+
+		assert.NoError(t, userRepo.ChangePassword(ctx, ids[0], []byte("new-hash"), "bcrypt"))
+
+		user, err = userRepo.GetByID(ctx, ids[0])
+		assert.NoError(t, err)
+		assert.False(t, user.PasswordResetRequired)
+		assert.Equal(t, []byte("new-hash"), user.PassHash)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.NoError(t, userRepo.Delete(ctx, ids[2]))
+
+		_, err := userRepo.GetByID(ctx, ids[2])
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+		err = userRepo.Delete(ctx, ids[2])
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+
+	t.Run("audit record", func(t *testing.T) {
+		err := auditRepo.Record(ctx, models.AuditEntry{
+			ActorID:      ids[0],
+			Action:       "admin.delete_user",
+			TargetUserID: ids[2],
+			Details:      map[string]any{"reason": "test"},
+		})
+		assert.NoError(t, err)
+	})
+}
+
 func TestAppRepository_Get(t *testing.T) {
 	ctx := context.Background()
 
@@ -118,20 +371,48 @@ func TestAppRepository_Get(t *testing.T) {
 
 		err := db.QueryRowContext(
 			ctx,
-			`INSERT INTO apps (name, access_secret, refresh_secret)
-			VALUES ($1, $2, $3) RETURNING id`,
-			name, access, refresh,
+			`INSERT INTO apps (name) VALUES ($1) RETURNING id`,
+			name,
 		).Scan(&id)
 		assert.NoError(t, err)
 
-		app, err := appRepo.Get(ctx, id)
+		_, err = db.ExecContext(
+			ctx,
+			`INSERT INTO app_secrets (app_id, kind, secret, encrypted)
+			VALUES ($1, 'access', $2, false), ($1, 'refresh', $3, false)`,
+			id, []byte(access), []byte(refresh),
+		)
 		assert.NoError(t, err)
-		assert.Equal(t, models.App{
+
+		expected := models.App{
 			ID:            id,
 			Name:          name,
 			AccessSecret:  access,
 			RefreshSecret: refresh,
-		}, app)
+			AccessSecrets: []string{access},
+			TokenClaims:   []string{},
+			IDTokenClaims: []string{},
+			RedirectURIs:  []string{},
+			GrantTypes:    []string{"password", "refresh_token"},
+			Enabled:       true,
+		}
+
+		app, err := appRepo.Get(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, app)
+
+		n, err := appRepo.EncryptLegacySecrets(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		var stored []byte
+		err = db.QueryRowContext(ctx, `SELECT secret FROM app_secrets WHERE app_id = $1 AND kind = 'access'`, id).Scan(&stored)
+		assert.NoError(t, err)
+		assert.NotEqual(t, []byte(access), stored)
+
+		app, err = appRepo.Get(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, app)
 	})
 
 	t.Run("app not found", func(t *testing.T) {
@@ -140,6 +421,313 @@ func TestAppRepository_Get(t *testing.T) {
 	})
 }
 
+func TestAppRepository_Manage(t *testing.T) {
+	ctx := context.Background()
+
+	id, err := appRepo.Create(ctx, models.App{
+		Name:          "managed_app",
+		AccessSecret:  "access-1",
+		RefreshSecret: "refresh-1",
+		RedirectURIs:  []string{"https://app.example.com/callback"},
+		GrantTypes:    []string{"password"},
+		AccessTTL:     5 * time.Minute,
+		Enabled:       true,
+	})
+	assert.NoError(t, err)
+
+	t.Run("duplicate name", func(t *testing.T) {
+		_, err := appRepo.Create(ctx, models.App{Name: "managed_app", AccessSecret: "a", RefreshSecret: "r"})
+		assert.ErrorIs(t, err, repository.ErrAppExists)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		enabled := false
+		idle := 30 * time.Minute
+		maxSessions := 3
+		backchannel := "https://app.example.com/logout"
+		err := appRepo.Update(ctx, id, models.AppUpdate{
+			GrantTypes:           []string{"password", "refresh_token"},
+			RefreshIdleTimeout:   &idle,
+			MaxSessions:          &maxSessions,
+			BackchannelLogoutURI: &backchannel,
+			Enabled:              &enabled,
+		})
+		assert.NoError(t, err)
+
+		app, err := appRepo.Get(ctx, id)
+		assert.NoError(t, err)
+		assert.False(t, app.Enabled)
+		assert.Equal(t, 5*time.Minute, app.AccessTTL)
+		assert.Equal(t, 30*time.Minute, app.RefreshIdleTimeout)
+		assert.Equal(t, 3, app.MaxSessions)
+		assert.Equal(t, "https://app.example.com/logout", app.BackchannelLogoutURI)
+		assert.Empty(t, app.FrontchannelLogoutURI)
+		assert.Equal(t, []string{"password", "refresh_token"}, app.GrantTypes)
+	})
+
+	t.Run("rotate with grace period", func(t *testing.T) {
+		err := appRepo.RotateSecret(ctx, id, models.SecretKindAccess, "access-2", time.Hour)
+		assert.NoError(t, err)
+
+		app, err := appRepo.Get(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, "access-2", app.AccessSecret)
+		assert.Equal(t, []string{"access-2", "access-1"}, app.AccessSecrets)
+		assert.Equal(t, "refresh-1", app.RefreshSecret)
+	})
+
+	t.Run("rotate without grace period", func(t *testing.T) {
+		err := appRepo.RotateSecret(ctx, id, models.SecretKindAccess, "access-3", 0)
+		assert.NoError(t, err)
+
+		app, err := appRepo.Get(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"access-3"}, app.AccessSecrets)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.NoError(t, appRepo.Delete(ctx, id))
+
+		_, err := appRepo.Get(ctx, id)
+		assert.ErrorIs(t, err, repository.ErrAppNotFound)
+	})
+}
+
+func TestRoleRepository(t *testing.T) {
+	ctx := context.Background()
+
+	appID, err := appRepo.Create(ctx, models.App{Name: "rbac_app", AccessSecret: "a", RefreshSecret: "r", Enabled: true})
+	assert.NoError(t, err)
+	userID, err := userRepo.Create(ctx, "rbac@example.com", []byte("hash"))
+	assert.NoError(t, err)
+
+	editor, err := roleRepo.CreateRole(ctx, models.Role{AppID: appID, Name: "editor"})
+	assert.NoError(t, err)
+	viewer, err := roleRepo.CreateRole(ctx, models.Role{AppID: appID, Name: "viewer"})
+	assert.NoError(t, err)
+
+	_, err = roleRepo.CreateRole(ctx, models.Role{AppID: appID, Name: "editor"})
+	assert.ErrorIs(t, err, repository.ErrRoleExists)
+	_, err = roleRepo.CreateRole(ctx, models.Role{AppID: 999999, Name: "editor"})
+	assert.ErrorIs(t, err, repository.ErrAppNotFound)
+
+	for _, name := range []string{"posts:read", "posts:write"} {
+		_, err := roleRepo.CreatePermission(ctx, models.Permission{AppID: appID, Name: name})
+		assert.NoError(t, err)
+	}
+
+	t.Run("set role permissions", func(t *testing.T) {
+		assert.NoError(t, roleRepo.SetRolePermissions(ctx, editor, []string{"posts:read", "posts:write"}))
+		assert.NoError(t, roleRepo.SetRolePermissions(ctx, viewer, []string{"posts:read"}))
+
+		err := roleRepo.SetRolePermissions(ctx, viewer, []string{"posts:read", "posts:delete"})
+		assert.ErrorIs(t, err, repository.ErrPermissionNotFound)
+
+		role, err := roleRepo.GetRole(ctx, viewer)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"posts:read"}, role.Permissions)
+	})
+
+	t.Run("assign and authorize", func(t *testing.T) {
+		assert.NoError(t, roleRepo.AssignRole(ctx, userID, editor))
+		assert.NoError(t, roleRepo.AssignRole(ctx, userID, viewer))
+		assert.NoError(t, roleRepo.AssignRole(ctx, userID, viewer))
+		assert.ErrorIs(t, roleRepo.AssignRole(ctx, 999999, viewer), repository.ErrUserNotFound)
+		assert.ErrorIs(t, roleRepo.AssignRole(ctx, userID, 999999), repository.ErrRoleNotFound)
+
+		authz, err := roleRepo.UserAuthorization(ctx, userID, appID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"editor", "viewer"}, authz.Roles)
+		assert.Equal(t, []string{"posts:read", "posts:write"}, authz.Permissions)
+	})
+
+	t.Run("revoke and delete", func(t *testing.T) {
+		assert.NoError(t, roleRepo.RevokeRole(ctx, userID, editor))
+		assert.ErrorIs(t, roleRepo.RevokeRole(ctx, userID, editor), repository.ErrRoleNotFound)
+
+		roles, err := roleRepo.UserRoles(ctx, userID, appID)
+		assert.NoError(t, err)
+		assert.Len(t, roles, 1)
+		assert.Equal(t, "viewer", roles[0].Name)
+
+		assert.NoError(t, roleRepo.DeleteRole(ctx, viewer))
+
+		authz, err := roleRepo.UserAuthorization(ctx, userID, appID)
+		assert.NoError(t, err)
+		assert.Empty(t, authz.Roles)
+		assert.Empty(t, authz.Permissions)
+	})
+}
+
+func TestRelationRepository(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := relationRepo.GetNamespace(ctx, "doc")
+	assert.ErrorIs(t, err, repository.ErrNamespaceNotFound)
+
+	cfg := models.NamespaceConfig{Name: "doc", Relations: map[string]models.RelationConfig{
+		"owner":  {},
+		"viewer": {Union: []models.Userset{{This: true}, {ComputedUserset: "owner"}}},
+	}}
+	assert.NoError(t, relationRepo.WriteNamespace(ctx, cfg))
+
+	got, err := relationRepo.GetNamespace(ctx, "doc")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg, got)
+
+	before, err := relationRepo.Revision(ctx)
+	assert.NoError(t, err)
+
+	doc := models.Object{Namespace: "doc", ID: "readme"}
+	owner := models.RelationTuple{Object: doc, Relation: "owner", Subject: models.Subject{Namespace: "user", ID: "1"}}
+	viewer := models.RelationTuple{Object: doc, Relation: "viewer", Subject: models.Subject{Namespace: "group", ID: "eng", Relation: "member"}}
+
+	rev, err := relationRepo.WriteTuples(ctx, []models.RelationTuple{owner, viewer, owner}, nil)
+	assert.NoError(t, err)
+	assert.Greater(t, rev, before)
+
+	tuples, err := relationRepo.ReadTuples(ctx, doc, "viewer")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RelationTuple{viewer}, tuples)
+
+	ids, err := relationRepo.ObjectIDs(ctx, "doc", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"readme"}, ids)
+
+	rev2, err := relationRepo.WriteTuples(ctx, nil, []models.RelationTuple{viewer})
+	assert.NoError(t, err)
+	assert.Greater(t, rev2, rev)
+
+	tuples, err = relationRepo.ReadTuples(ctx, doc, "viewer")
+	assert.NoError(t, err)
+	assert.Empty(t, tuples)
+
+	current, err := relationRepo.Revision(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, rev2, current)
+}
+
+func TestOrgRepository(t *testing.T) {
+	ctx := context.Background()
+
+	ownerID, err := userRepo.Create(ctx, "owner@acme.test", []byte("hash"))
+	assert.NoError(t, err)
+	memberID, err := userRepo.Create(ctx, "member@acme.test", []byte("hash"))
+	assert.NoError(t, err)
+
+	orgID, err := orgRepo.Create(ctx, models.Organization{Slug: "acme", Name: "Acme", AllowedEmailDomains: []string{"acme.test"}}, ownerID)
+	assert.NoError(t, err)
+
+	_, err = orgRepo.Create(ctx, models.Organization{Slug: "acme", Name: "Other"}, ownerID)
+	assert.ErrorIs(t, err, repository.ErrOrgExists)
+
+	org, err := orgRepo.Get(ctx, orgID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme.test"}, org.AllowedEmailDomains)
+
+	assert.NoError(t, orgRepo.AddMember(ctx, orgID, memberID, models.OrgRoleMember))
+	assert.ErrorIs(t, orgRepo.AddMember(ctx, orgID, memberID, models.OrgRoleMember), repository.ErrMembershipExists)
+	assert.ErrorIs(t, orgRepo.AddMember(ctx, orgID, 999999, models.OrgRoleMember), repository.ErrUserNotFound)
+
+	members, err := orgRepo.ListMembers(ctx, orgID)
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+
+	t.Run("last owner", func(t *testing.T) {
+		assert.ErrorIs(t, orgRepo.SetMemberRole(ctx, orgID, ownerID, models.OrgRoleAdmin), repository.ErrLastOwner)
+		assert.ErrorIs(t, orgRepo.RemoveMember(ctx, orgID, ownerID), repository.ErrLastOwner)
+
+		assert.NoError(t, orgRepo.SetMemberRole(ctx, orgID, memberID, models.OrgRoleOwner))
+		assert.NoError(t, orgRepo.SetMemberRole(ctx, orgID, ownerID, models.OrgRoleAdmin))
+
+		m, err := orgRepo.GetMembership(ctx, orgID, ownerID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.OrgRoleAdmin, m.Role)
+	})
+
+	t.Run("list for user", func(t *testing.T) {
+		list, err := orgRepo.ListForUser(ctx, memberID)
+		assert.NoError(t, err)
+		if assert.Len(t, list, 1) {
+			assert.Equal(t, "acme", list[0].Organization.Slug)
+			assert.Equal(t, models.OrgRoleOwner, list[0].Role)
+		}
+	})
+
+	assert.NoError(t, orgRepo.RemoveMember(ctx, orgID, ownerID))
+	_, err = orgRepo.GetMembership(ctx, orgID, ownerID)
+	assert.ErrorIs(t, err, repository.ErrMembershipNotFound)
+
+	assert.NoError(t, orgRepo.Delete(ctx, orgID))
+	_, err = orgRepo.Get(ctx, orgID)
+	assert.ErrorIs(t, err, repository.ErrOrgNotFound)
+}
+
+func TestOrgRepository_Invitations(t *testing.T) {
+	ctx := context.Background()
+
+	ownerID, err := userRepo.Create(ctx, "owner@invite.test", []byte("hash"))
+	assert.NoError(t, err)
+	orgID, err := orgRepo.Create(ctx, models.Organization{Slug: "invite", Name: "Invite"}, ownerID)
+	assert.NoError(t, err)
+
+	inv := models.Invitation{
+		OrgID:     orgID,
+		Email:     "new@invite.test",
+		Role:      models.OrgRoleAdmin,
+		Nonce:     "first",
+		InvitedBy: ownerID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	inv.ID, err = orgRepo.CreateInvitation(ctx, inv)
+	assert.NoError(t, err)
+
+	_, err = orgRepo.CreateInvitation(ctx, models.Invitation{OrgID: orgID, Email: "NEW@invite.test", Role: models.OrgRoleMember, Nonce: "x", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.ErrorIs(t, err, repository.ErrInvitationExists)
+
+	assert.NoError(t, orgRepo.RenewInvitation(ctx, orgID, inv.ID, "second", time.Now().Add(2*time.Hour)))
+
+	_, err = orgRepo.AcceptInvitation(ctx, inv.ID, "first", []byte("hash"))
+	assert.ErrorIs(t, err, repository.ErrInvitationNotFound)
+
+	_, err = orgRepo.AcceptInvitation(ctx, inv.ID, "second", nil)
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	userID, err := orgRepo.AcceptInvitation(ctx, inv.ID, "second", []byte("hash"))
+	assert.NoError(t, err)
+
+	user, err := userRepo.GetByID(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "new@invite.test", user.Email)
+	assert.True(t, user.EmailVerified)
+
+	m, err := orgRepo.GetMembership(ctx, orgID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.OrgRoleAdmin, m.Role)
+
+	got, err := orgRepo.GetInvitation(ctx, inv.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.InvitationAccepted, got.Status(time.Now()))
+	assert.Equal(t, userID, got.AcceptedBy)
+
+	_, err = orgRepo.AcceptInvitation(ctx, inv.ID, "second", nil)
+	assert.ErrorIs(t, err, repository.ErrInvitationNotFound)
+	assert.ErrorIs(t, orgRepo.RevokeInvitation(ctx, orgID, inv.ID), repository.ErrInvitationNotFound)
+
+	t.Run("revoke", func(t *testing.T) {
+		id, err := orgRepo.CreateInvitation(ctx, models.Invitation{OrgID: orgID, Email: "later@invite.test", Role: models.OrgRoleMember, Nonce: "n", ExpiresAt: time.Now().Add(time.Hour)})
+		assert.NoError(t, err)
+		assert.NoError(t, orgRepo.RevokeInvitation(ctx, orgID, id))
+
+		_, err = orgRepo.AcceptInvitation(ctx, id, "n", []byte("hash"))
+		assert.ErrorIs(t, err, repository.ErrInvitationNotFound)
+
+		list, err := orgRepo.ListInvitations(ctx, orgID)
+		assert.NoError(t, err)
+		assert.Len(t, list, 2)
+	})
+}
+
 func migrationsPath() string {
 	pwd, _ := os.Getwd()
 	root := filepath.Join(pwd, "..", "..", "..")
@@ -148,3 +736,543 @@ func migrationsPath() string {
 
 	return "file://" + migrationsPath
 }
+
+func TestTokenRepository(t *testing.T) {
+	ctx := context.Background()
+
+	userID, err := userRepo.Create(ctx, "pat@mail.com", []byte("hash"))
+	assert.NoError(t, err)
+
+	token := models.PersonalAccessToken{
+		UserID:        userID,
+		Name:          "ci",
+		TokenHash:     "hash-1",
+		DisplayPrefix: "pat_abcd",
+		Scopes:        []string{models.ScopeProfile, models.ScopeOrgs},
+	}
+	token.ID, err = tokenRepo.Create(ctx, token)
+	assert.NoError(t, err)
+
+	_, err = tokenRepo.Create(ctx, models.PersonalAccessToken{UserID: 99999, Name: "x", TokenHash: "hash-2", Scopes: []string{}})
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	got, err := tokenRepo.GetByHash(ctx, "hash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID, got.ID)
+	assert.Equal(t, token.Scopes, got.Scopes)
+	assert.True(t, got.ExpiresAt.IsZero())
+	assert.True(t, got.LastUsedAt.IsZero())
+
+	now := time.Now()
+	assert.NoError(t, tokenRepo.TouchLastUsed(ctx, token.ID, now, time.Minute))
+	assert.NoError(t, tokenRepo.TouchLastUsed(ctx, token.ID, now.Add(time.Second), time.Minute))
+	got, err = tokenRepo.GetByHash(ctx, "hash-1")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, now, got.LastUsedAt, time.Millisecond)
+
+	list, err := tokenRepo.ListForUser(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	assert.ErrorIs(t, tokenRepo.Delete(ctx, token.ID, userID+1), repository.ErrTokenNotFound)
+	assert.NoError(t, tokenRepo.Delete(ctx, token.ID, userID))
+
+	_, err = tokenRepo.GetByHash(ctx, "hash-1")
+	assert.ErrorIs(t, err, repository.ErrTokenNotFound)
+}
+
+func TestServiceAccountRepository(t *testing.T) {
+	ctx := context.Background()
+
+	appID, err := appRepo.Create(ctx, models.App{Name: "machines_app", AccessSecret: "a", RefreshSecret: "r", Enabled: true})
+	assert.NoError(t, err)
+	roleID, err := roleRepo.CreateRole(ctx, models.Role{AppID: appID, Name: "worker"})
+	assert.NoError(t, err)
+	_, err = roleRepo.CreatePermission(ctx, models.Permission{AppID: appID, Name: "jobs:run"})
+	assert.NoError(t, err)
+	assert.NoError(t, roleRepo.SetRolePermissions(ctx, roleID, []string{"jobs:run"}))
+
+	id, err := serviceAccountRepo.Create(ctx, models.ServiceAccount{AppID: appID, Name: "billing"})
+	assert.NoError(t, err)
+
+	_, err = serviceAccountRepo.Create(ctx, models.ServiceAccount{AppID: appID, Name: "billing"})
+	assert.ErrorIs(t, err, repository.ErrServiceAccountExists)
+
+	assert.NoError(t, serviceAccountRepo.AddKey(ctx, models.ServiceAccountKey{ID: "kid-1", ServiceAccountID: id, PublicKey: "pem", Algorithm: "EdDSA"}))
+	assert.ErrorIs(t, serviceAccountRepo.AddKey(ctx, models.ServiceAccountKey{ID: "kid-2", ServiceAccountID: 99999, PublicKey: "pem", Algorithm: "EdDSA"}), repository.ErrServiceAccountNotFound)
+
+	key, err := serviceAccountRepo.GetKey(ctx, "kid-1")
+	assert.NoError(t, err)
+	assert.Equal(t, id, key.ServiceAccountID)
+	assert.True(t, key.ExpiresAt.IsZero())
+
+	assert.NoError(t, serviceAccountRepo.AssignRole(ctx, id, roleID))
+	assert.NoError(t, serviceAccountRepo.AssignRole(ctx, id, roleID))
+	assert.ErrorIs(t, serviceAccountRepo.AssignRole(ctx, id, 99999), repository.ErrRoleNotFound)
+
+	authz, err := serviceAccountRepo.Authorization(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"worker"}, authz.Roles)
+	assert.Equal(t, []string{"jobs:run"}, authz.Permissions)
+
+	assert.NoError(t, serviceAccountRepo.SetDisabled(ctx, id, true))
+	sa, err := serviceAccountRepo.Get(ctx, id)
+	assert.NoError(t, err)
+	assert.True(t, sa.Disabled)
+
+	assert.ErrorIs(t, serviceAccountRepo.DeleteKey(ctx, id+1, "kid-1"), repository.ErrKeyNotFound)
+	assert.NoError(t, serviceAccountRepo.DeleteKey(ctx, id, "kid-1"))
+
+	assert.NoError(t, serviceAccountRepo.Delete(ctx, id))
+	_, err = serviceAccountRepo.Get(ctx, id)
+	assert.ErrorIs(t, err, repository.ErrServiceAccountNotFound)
+}
+
+func TestAuditRepository(t *testing.T) {
+	ctx := requestmeta.NewContext(context.Background(), requestmeta.Meta{IP: "10.0.0.1", UserAgent: "test-agent"})
+
+	actorID, err := userRepo.Create(ctx, "auditor@mail.com", []byte("hash"))
+	assert.NoError(t, err)
+
+	for i, outcome := range []string{models.OutcomeSuccess, models.OutcomeFailure, ""} {
+		err := auditRepo.Record(ctx, models.AuditEntry{
+			ActorID: actorID,
+			Action:  "audittest.login",
+			AppID:   7,
+			Outcome: outcome,
+			Details: map[string]any{"attempt": i, "note": "<b>&</b>", "nested": map[string]any{"b": 1.5, "a": true}},
+		})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, auditRepo.Record(ctx, models.AuditEntry{ActorID: actorID, Action: "audittest.other", IP: "192.0.2.1"}))
+
+	t.Run("query", func(t *testing.T) {
+		entries, err := auditRepo.Query(ctx, models.AuditFilter{ActorID: actorID, Action: "audittest."})
+		assert.NoError(t, err)
+		if !assert.Len(t, entries, 4) {
+			return
+		}
+		assert.Equal(t, "audittest.other", entries[0].Action)
+		assert.Equal(t, "192.0.2.1", entries[0].IP)
+		assert.Equal(t, "10.0.0.1", entries[1].IP)
+		assert.Equal(t, "test-agent", entries[1].UserAgent)
+		assert.Equal(t, 7, entries[1].AppID)
+		assert.Equal(t, models.OutcomeSuccess, entries[1].Outcome)
+
+		failed, err := auditRepo.Query(ctx, models.AuditFilter{ActorID: actorID, Outcome: models.OutcomeFailure})
+		assert.NoError(t, err)
+		if !assert.Len(t, failed, 1) {
+			return
+		}
+		assert.Equal(t, float64(1), failed[0].Details["attempt"])
+
+		page, err := auditRepo.Query(ctx, models.AuditFilter{ActorID: actorID, BeforeID: entries[1].ID, Limit: 1})
+		assert.NoError(t, err)
+		if !assert.Len(t, page, 1) {
+			return
+		}
+		assert.Equal(t, entries[2].ID, page[0].ID)
+
+		none, err := auditRepo.Query(ctx, models.AuditFilter{ActorID: actorID, Action: "audittest"})
+		assert.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("append only", func(t *testing.T) {
+		_, err := db.ExecContext(ctx, `UPDATE audit_log SET action = 'x' WHERE actor_id = $1`, actorID)
+		assert.Error(t, err)
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		checked, brokenID, err := auditRepo.Verify(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, brokenID)
+		assert.GreaterOrEqual(t, checked, 4)
+
+		entries, err := auditRepo.Query(ctx, models.AuditFilter{ActorID: actorID, Action: "audittest.login"})
+		assert.NoError(t, err)
+		if !assert.Len(t, entries, 3) {
+			return
+		}
+		tampered := entries[1].ID
+
+		setOutcome := func(outcome string) {
+			_, err := db.ExecContext(ctx, `
+				ALTER TABLE audit_log DISABLE TRIGGER audit_log_append_only;
+				UPDATE audit_log SET outcome = '`+outcome+`' WHERE id = `+strconv.FormatInt(tampered, 10)+`;
+				ALTER TABLE audit_log ENABLE TRIGGER audit_log_append_only;`)
+			assert.NoError(t, err)
+		}
+
+		setOutcome(models.OutcomeSuccess)
+		_, brokenID, err = auditRepo.Verify(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, tampered, brokenID)
+
+		setOutcome(models.OutcomeFailure)
+		_, brokenID, err = auditRepo.Verify(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, brokenID)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		n, err := auditRepo.Prune(ctx, time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+
+		n, err = auditRepo.Prune(ctx, time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, n, int64(4))
+
+		assert.NoError(t, auditRepo.Record(ctx, models.AuditEntry{ActorID: actorID, Action: "audittest.after_prune"}))
+		_, brokenID, err := auditRepo.Verify(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, brokenID)
+	})
+}
+
+func TestWebhookRepository(t *testing.T) {
+	ctx := context.Background()
+
+	// Events written by earlier tests have no subscribers yet.
+	_, err := webhookRepo.FanOut(ctx, 10000)
+	assert.NoError(t, err)
+
+	appID, err := appRepo.Create(ctx, models.App{Name: "hooks_app", AccessSecret: "a", RefreshSecret: "r", Enabled: true})
+	assert.NoError(t, err)
+
+	_, err = webhookRepo.Create(ctx, models.Webhook{AppID: 99999, URL: "https://example.com/hook", Secret: "s"})
+	assert.ErrorIs(t, err, repository.ErrAppNotFound)
+
+	id, err := webhookRepo.Create(ctx, models.Webhook{
+		AppID:      appID,
+		URL:        "https://example.com/hook",
+		Secret:     "hook-secret",
+		EventTypes: []string{models.EventUserRegistered},
+	})
+	assert.NoError(t, err)
+	_, err = webhookRepo.Create(ctx, models.Webhook{
+		AppID:      appID,
+		URL:        "https://example.com/deleted",
+		Secret:     "other-secret",
+		EventTypes: []string{models.EventUserDeleted},
+	})
+	assert.NoError(t, err)
+
+	wh, err := webhookRepo.Get(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{models.EventUserRegistered}, wh.EventTypes)
+	assert.Empty(t, wh.Secret)
+
+	list, err := webhookRepo.List(ctx, appID)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	userID, err := userRepo.Create(ctx, "hooked@mail.com", []byte("hash"))
+	assert.NoError(t, err)
+
+	n, err := webhookRepo.FanOut(ctx, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = webhookRepo.FanOut(ctx, 100)
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	t.Run("claim and retry", func(t *testing.T) {
+		due, err := webhookRepo.ClaimDue(ctx, 10, time.Minute)
+		assert.NoError(t, err)
+		if !assert.Len(t, due, 1) {
+			return
+		}
+		assert.Equal(t, id, due[0].Webhook.ID)
+		assert.Equal(t, "hook-secret", due[0].Webhook.Secret)
+		assert.Equal(t, models.EventUserRegistered, due[0].Event.Type)
+		assert.Equal(t, userID, due[0].Event.UserID)
+		assert.Equal(t, "hooked@mail.com", due[0].Event.Payload["email"])
+
+		// Leased deliveries are not handed out twice.
+		again, err := webhookRepo.ClaimDue(ctx, 10, time.Minute)
+		assert.NoError(t, err)
+		assert.Empty(t, again)
+
+		assert.NoError(t, webhookRepo.MarkFailed(ctx, due[0].ID, 500, "unexpected status 500", time.Now().Add(time.Hour)))
+		assert.NoError(t, webhookRepo.MarkFailed(ctx, due[0].ID, 0, "connection refused", time.Time{}))
+
+		dead, err := webhookRepo.ListDeliveries(ctx, id, models.DeliveryDead, 10)
+		assert.NoError(t, err)
+		if !assert.Len(t, dead, 1) {
+			return
+		}
+		assert.Equal(t, 2, dead[0].Attempts)
+		assert.Equal(t, "connection refused", dead[0].LastError)
+	})
+
+	t.Run("replay and deliver", func(t *testing.T) {
+		n, err := webhookRepo.Replay(ctx, id, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		due, err := webhookRepo.ClaimDue(ctx, 10, time.Minute)
+		assert.NoError(t, err)
+		if !assert.Len(t, due, 1) {
+			return
+		}
+		assert.Zero(t, due[0].Attempts)
+
+		assert.NoError(t, webhookRepo.MarkDelivered(ctx, due[0].ID, 204))
+		assert.ErrorIs(t, webhookRepo.MarkDelivered(ctx, 99999, 204), repository.ErrWebhookNotFound)
+
+		delivered, err := webhookRepo.ListDeliveries(ctx, id, models.DeliveryDelivered, 10)
+		assert.NoError(t, err)
+		if !assert.Len(t, delivered, 1) {
+			return
+		}
+		assert.Equal(t, 204, delivered[0].LastStatusCode)
+		assert.False(t, delivered[0].DeliveredAt.IsZero())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.NoError(t, webhookRepo.Delete(ctx, id))
+		assert.ErrorIs(t, webhookRepo.Delete(ctx, id), repository.ErrWebhookNotFound)
+
+		_, err := webhookRepo.Get(ctx, id)
+		assert.ErrorIs(t, err, repository.ErrWebhookNotFound)
+	})
+}
+
+func TestLogoutRepository(t *testing.T) {
+	ctx := context.Background()
+
+	appID, err := appRepo.Create(ctx, models.App{Name: "logout_app", AccessSecret: "a", RefreshSecret: "r", Enabled: true})
+	assert.NoError(t, err)
+
+	assert.NoError(t, logoutRepo.Enqueue(ctx, nil))
+	assert.NoError(t, logoutRepo.Enqueue(ctx, []models.Logout{
+		{AppID: appID, UserID: 1, SessionID: "session-1"},
+		{AppID: appID, UserID: 1, SessionID: "session-2"},
+	}))
+
+	due, err := logoutRepo.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	if !assert.Len(t, due, 2) {
+		return
+	}
+	assert.Equal(t, appID, due[0].AppID)
+	assert.Equal(t, int64(1), due[0].UserID)
+	assert.ElementsMatch(t, []string{"session-1", "session-2"}, []string{due[0].SessionID, due[1].SessionID})
+
+	// Leased logouts are not handed out twice.
+	again, err := logoutRepo.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, again)
+
+	assert.NoError(t, logoutRepo.MarkDelivered(ctx, due[0].ID, 200))
+	assert.NoError(t, logoutRepo.MarkFailed(ctx, due[1].ID, 503, "unexpected status 503", time.Now().Add(-time.Second)))
+	assert.ErrorIs(t, logoutRepo.MarkDelivered(ctx, 99999, 200), repository.ErrLogoutNotFound)
+
+	retried, err := logoutRepo.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	if !assert.Len(t, retried, 1) {
+		return
+	}
+	assert.Equal(t, due[1].ID, retried[0].ID)
+	assert.Equal(t, 1, retried[0].Attempts)
+
+	assert.NoError(t, logoutRepo.MarkFailed(ctx, retried[0].ID, 0, "connection refused", time.Time{}))
+
+	var status string
+	assert.NoError(t, db.QueryRowContext(ctx, `SELECT status FROM logout_deliveries WHERE id = $1`, retried[0].ID).Scan(&status))
+	assert.Equal(t, models.DeliveryDead, status)
+}
+
+func TestFederationRepository(t *testing.T) {
+	ctx := context.Background()
+
+	providerID, err := federationRepo.CreateProvider(ctx, models.IdentityProvider{
+		Slug:                "corp",
+		Name:                "Corp",
+		Issuer:              "https://idp.example.com",
+		ClientID:            "client",
+		ClientSecret:        "client-secret",
+		Scopes:              []string{"email"},
+		AllowedEmailDomains: []string{"example.com"},
+		JITProvisioning:     true,
+	})
+	assert.NoError(t, err)
+
+	_, err = federationRepo.CreateProvider(ctx, models.IdentityProvider{Slug: "corp", Name: "Other", Issuer: "https://other", ClientID: "c"})
+	assert.ErrorIs(t, err, repository.ErrProviderExists)
+
+	p, err := federationRepo.GetProviderBySlug(ctx, "corp")
+	assert.NoError(t, err)
+	assert.Equal(t, providerID, p.ID)
+	assert.Equal(t, "client-secret", p.ClientSecret)
+	assert.Equal(t, []string{"example.com"}, p.AllowedEmailDomains)
+	assert.True(t, p.JITProvisioning)
+
+	list, err := federationRepo.ListProviders(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Empty(t, list[0].ClientSecret)
+	}
+
+	assert.NoError(t, federationRepo.SetProviderDisabled(ctx, providerID, true))
+	p, err = federationRepo.GetProvider(ctx, providerID)
+	assert.NoError(t, err)
+	assert.True(t, p.Disabled)
+
+	userID, err := federationRepo.CreateUser(ctx, "federated@mail.com", true, models.UserIdentity{ProviderID: providerID, Subject: "sub-1", Email: "federated@mail.com"})
+	assert.NoError(t, err)
+
+	user, err := userRepo.GetByID(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "none", user.PassAlgo)
+	assert.True(t, user.EmailVerified)
+
+	_, err = federationRepo.CreateUser(ctx, "federated@mail.com", true, models.UserIdentity{ProviderID: providerID, Subject: "sub-2"})
+	assert.ErrorIs(t, err, repository.ErrUserExists)
+
+	identity, err := federationRepo.GetIdentity(ctx, providerID, "sub-1")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, identity.UserID)
+	assert.Equal(t, "corp", identity.ProviderSlug)
+	assert.NoError(t, federationRepo.TouchIdentity(ctx, identity.ID, "new@mail.com"))
+
+	_, err = federationRepo.GetIdentity(ctx, providerID, "absent")
+	assert.ErrorIs(t, err, repository.ErrIdentityNotFound)
+
+	otherID, err := userRepo.Create(ctx, "linked@mail.com", []byte("hash"))
+	assert.NoError(t, err)
+	_, err = federationRepo.LinkIdentity(ctx, models.UserIdentity{UserID: otherID, ProviderID: providerID, Subject: "sub-1"})
+	assert.ErrorIs(t, err, repository.ErrIdentityExists, "a subject belongs to one user")
+	_, err = federationRepo.LinkIdentity(ctx, models.UserIdentity{UserID: otherID, ProviderID: 99999, Subject: "sub-3"})
+	assert.ErrorIs(t, err, repository.ErrProviderNotFound)
+	_, err = federationRepo.LinkIdentity(ctx, models.UserIdentity{UserID: otherID, ProviderID: providerID, Subject: "sub-3"})
+	assert.NoError(t, err)
+
+	identities, err := federationRepo.ListIdentities(ctx, otherID)
+	assert.NoError(t, err)
+	assert.Len(t, identities, 1)
+
+	assert.NoError(t, federationRepo.UnlinkIdentity(ctx, otherID, providerID))
+	assert.ErrorIs(t, federationRepo.UnlinkIdentity(ctx, otherID, providerID), repository.ErrIdentityNotFound)
+
+	assert.NoError(t, federationRepo.DeleteProvider(ctx, providerID))
+	identities, err = federationRepo.ListIdentities(ctx, userID)
+	assert.NoError(t, err)
+	assert.Empty(t, identities)
+}
+
+func TestSAMLRepository(t *testing.T) {
+	ctx := context.Background()
+
+	appID, err := appRepo.Create(ctx, models.App{Name: "saml_app", AccessSecret: "a", RefreshSecret: "r", Enabled: true})
+	assert.NoError(t, err)
+
+	spID, err := samlRepo.CreateServiceProvider(ctx, models.ServiceProvider{
+		AppID:        appID,
+		EntityID:     "https://wiki.example.com",
+		Name:         "Wiki",
+		ACSURL:       "https://wiki.example.com/acs",
+		NameIDFormat: "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress",
+		AttributeMap: map[string]string{"email": "mail"},
+	})
+	assert.NoError(t, err)
+
+	_, err = samlRepo.CreateServiceProvider(ctx, models.ServiceProvider{AppID: appID, EntityID: "https://wiki.example.com", Name: "Other", ACSURL: "https://other"})
+	assert.ErrorIs(t, err, repository.ErrServiceProviderExists)
+	_, err = samlRepo.CreateServiceProvider(ctx, models.ServiceProvider{AppID: 99999, EntityID: "https://other", Name: "Other", ACSURL: "https://other"})
+	assert.ErrorIs(t, err, repository.ErrAppNotFound)
+
+	sp, err := samlRepo.GetServiceProviderByEntityID(ctx, "https://wiki.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, spID, sp.ID)
+	assert.Equal(t, appID, sp.AppID)
+	assert.Equal(t, map[string]string{"email": "mail"}, sp.AttributeMap)
+	assert.Empty(t, sp.SLOURL)
+
+	_, err = samlRepo.GetServiceProviderByEntityID(ctx, "https://absent")
+	assert.ErrorIs(t, err, repository.ErrServiceProviderNotFound)
+
+	assert.NoError(t, samlRepo.SetServiceProviderDisabled(ctx, spID, true))
+	sp, err = samlRepo.GetServiceProvider(ctx, spID)
+	assert.NoError(t, err)
+	assert.True(t, sp.Disabled)
+
+	list, err := samlRepo.ListServiceProviders(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	assert.NoError(t, samlRepo.DeleteServiceProvider(ctx, spID))
+	assert.ErrorIs(t, samlRepo.DeleteServiceProvider(ctx, spID), repository.ErrServiceProviderNotFound)
+}
+
+func TestSCIMRepository(t *testing.T) {
+	ctx := context.Background()
+
+	annID, err := scimRepo.CreateUser(ctx, models.ProvisionedUser{
+		ExternalID: "hr-1",
+		Email:      "scim.ann@mail.com",
+		Active:     true,
+		GivenName:  "Ann",
+		FamilyName: "Lee",
+	}, nil, "none")
+	assert.NoError(t, err)
+	bobID, err := scimRepo.CreateUser(ctx, models.ProvisionedUser{ExternalID: "hr-2", Email: "scim.bob@mail.com", Active: true}, nil, "none")
+	assert.NoError(t, err)
+
+	_, err = scimRepo.CreateUser(ctx, models.ProvisionedUser{ExternalID: "hr-1", Email: "scim.other@mail.com"}, nil, "none")
+	assert.ErrorIs(t, err, repository.ErrUserExists)
+
+	ann, err := scimRepo.GetUser(ctx, annID)
+	assert.NoError(t, err)
+	assert.Equal(t, "hr-1", ann.ExternalID)
+	assert.Equal(t, "Lee", ann.FamilyName)
+	assert.Equal(t, int64(1), ann.Version)
+
+	filter, err := scim.ParseFilter(`userName eq "SCIM.ANN@mail.com" or externalId eq "hr-2"`)
+	assert.NoError(t, err)
+	users, total, err := scimRepo.ListUsers(ctx, filter, 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, users, 1)
+
+	filter, _ = scim.ParseFilter(`nickName eq "x"`)
+	_, _, err = scimRepo.ListUsers(ctx, filter, 0, 10)
+	var serr *scim.Error
+	assert.ErrorAs(t, err, &serr)
+
+	ann.Active = false
+	assert.NoError(t, scimRepo.UpdateUser(ctx, ann, nil, ""))
+	assert.ErrorIs(t, scimRepo.UpdateUser(ctx, ann, nil, ""), repository.ErrVersionMismatch)
+	ann, err = scimRepo.GetUser(ctx, annID)
+	assert.NoError(t, err)
+	assert.False(t, ann.Active)
+	assert.Equal(t, int64(2), ann.Version)
+
+	groupID, err := scimRepo.CreateGroup(ctx, models.Group{DisplayName: "Engineering", Members: []models.GroupMember{{UserID: annID}}})
+	assert.NoError(t, err)
+	_, err = scimRepo.CreateGroup(ctx, models.Group{DisplayName: "Engineering"})
+	assert.ErrorIs(t, err, repository.ErrGroupExists)
+	_, err = scimRepo.CreateGroup(ctx, models.Group{DisplayName: "Ghosts", Members: []models.GroupMember{{UserID: 99999}}})
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	group, err := scimRepo.GetGroup(ctx, groupID)
+	assert.NoError(t, err)
+	group.Members = []models.GroupMember{{UserID: bobID}}
+	assert.NoError(t, scimRepo.UpdateGroup(ctx, group))
+
+	filter, _ = scim.ParseFilter(`members eq "` + strconv.FormatInt(bobID, 10) + `"`)
+	groups, total, err := scimRepo.ListGroups(ctx, filter, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []models.GroupMember{{UserID: bobID, Email: "scim.bob@mail.com"}}, groups[0].Members)
+
+	bob, err := scimRepo.GetUser(ctx, bobID)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.GroupRef{{ID: groupID, DisplayName: "Engineering"}}, bob.Groups)
+
+	assert.NoError(t, scimRepo.DeleteGroup(ctx, groupID))
+	assert.ErrorIs(t, scimRepo.DeleteGroup(ctx, groupID), repository.ErrGroupNotFound)
+}
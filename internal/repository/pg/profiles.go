@@ -0,0 +1,130 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+var profileColumns = []string{
+	"id", "email", "display_name", "given_name", "family_name",
+	"locale", "timezone", "phone", "user_metadata", "app_metadata",
+}
+
+func (r *UserRepository) GetProfile(ctx context.Context, userID int64) (profile models.Profile, err error) {
+	const op = "repository.user.postgres.GetProfile"
+
+	query := sq.Select(profileColumns...).
+		From("users").
+		Where(sq.Eq{"id": userID}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return profile, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	profile, err = scanProfile(r.db.QueryRowContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return profile, fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+		}
+		return profile, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return profile, nil
+}
+
+func (r *UserRepository) UpdateProfile(ctx context.Context, userID int64, upd models.ProfileUpdate) (profile models.Profile, err error) {
+	const op = "repository.user.postgres.UpdateProfile"
+
+	query := sq.Update("users").
+		Where(sq.Eq{"id": userID}).
+		Suffix("RETURNING " + strings.Join(profileColumns, ", ")).
+		PlaceholderFormat(sq.Dollar)
+
+	set := 0
+	for _, f := range []struct {
+		column string
+		value  *string
+	}{
+		{"display_name", upd.DisplayName},
+		{"given_name", upd.GivenName},
+		{"family_name", upd.FamilyName},
+		{"locale", upd.Locale},
+		{"timezone", upd.Timezone},
+		{"phone", upd.Phone},
+	} {
+		if f.value != nil {
+			query = query.Set(f.column, *f.value)
+			set++
+		}
+	}
+	if upd.Phone != nil {
+		// A changed number has to be verified again before it identifies the user.
+		query = query.Set("phone_verified", sq.Expr("phone_verified AND phone = ?", *upd.Phone))
+	}
+
+	for _, f := range []struct {
+		column string
+		value  map[string]any
+	}{
+		{"user_metadata", upd.UserMetadata},
+		{"app_metadata", upd.AppMetadata},
+	} {
+		if f.value == nil {
+			continue
+		}
+		data, err := json.Marshal(f.value)
+		if err != nil {
+			return profile, fmt.Errorf("%s: marshal %s: %w", op, f.column, err)
+		}
+		query = query.Set(f.column, sq.Expr("jsonb_strip_nulls("+f.column+" || ?::jsonb)", string(data)))
+		set++
+	}
+
+	if set == 0 {
+		return r.GetProfile(ctx, userID)
+	}
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return profile, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	profile, err = scanProfile(r.db.QueryRowContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return profile, fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+		}
+		return profile, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return profile, nil
+}
+
+func scanProfile(row *sql.Row) (profile models.Profile, err error) {
+	var userMetadata, appMetadata []byte
+
+	if err := row.Scan(
+		&profile.UserID, &profile.Email, &profile.DisplayName, &profile.GivenName, &profile.FamilyName,
+		&profile.Locale, &profile.Timezone, &profile.Phone, &userMetadata, &appMetadata,
+	); err != nil {
+		return profile, err
+	}
+
+	if err := json.Unmarshal(userMetadata, &profile.UserMetadata); err != nil {
+		return profile, fmt.Errorf("unmarshal user_metadata: %w", err)
+	}
+	if err := json.Unmarshal(appMetadata, &profile.AppMetadata); err != nil {
+		return profile, fmt.Errorf("unmarshal app_metadata: %w", err)
+	}
+
+	return profile, nil
+}
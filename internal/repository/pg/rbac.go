@@ -0,0 +1,357 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type RoleRepository struct {
+	db *sqlx.DB
+}
+
+func NewRoleRepository(db *sqlx.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+var roleColumns = []string{
+	"r.id", "r.app_id", "r.name", "r.description",
+	"ARRAY(SELECT p.name FROM role_permissions rp JOIN permissions p ON p.id = rp.permission_id WHERE rp.role_id = r.id ORDER BY p.name)",
+}
+
+func (r *RoleRepository) CreateRole(ctx context.Context, role models.Role) (int64, error) {
+	const op = "repository.role.postgres.CreateRole"
+
+	query := sq.Insert("roles").
+		Columns("app_id", "name", "description").
+		Values(role.AppID, role.Name, role.Description).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	id, err := r.insertReturningID(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, appScopedError(err, repository.ErrRoleExists))
+	}
+
+	return id, nil
+}
+
+func (r *RoleRepository) GetRole(ctx context.Context, roleID int64) (models.Role, error) {
+	const op = "repository.role.postgres.GetRole"
+
+	query := sq.Select(roleColumns...).
+		From("roles r").
+		Where(sq.Eq{"r.id": roleID}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.Role{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	role, err := scanRole(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Role{}, fmt.Errorf("%s: %w", op, repository.ErrRoleNotFound)
+		}
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return role, nil
+}
+
+func (r *RoleRepository) ListRoles(ctx context.Context, appID int) ([]models.Role, error) {
+	const op = "repository.role.postgres.ListRoles"
+
+	query := sq.Select(roleColumns...).
+		From("roles r").
+		Where(sq.Eq{"r.app_id": appID}).
+		OrderBy("r.name").
+		PlaceholderFormat(sq.Dollar)
+
+	roles, err := r.queryRoles(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepository) DeleteRole(ctx context.Context, roleID int64) error {
+	const op = "repository.role.postgres.DeleteRole"
+
+	query := sq.Delete("roles").
+		Where(sq.Eq{"id": roleID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrRoleNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *RoleRepository) CreatePermission(ctx context.Context, perm models.Permission) (int64, error) {
+	const op = "repository.role.postgres.CreatePermission"
+
+	query := sq.Insert("permissions").
+		Columns("app_id", "name", "description").
+		Values(perm.AppID, perm.Name, perm.Description).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	id, err := r.insertReturningID(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, appScopedError(err, repository.ErrPermissionExists))
+	}
+
+	return id, nil
+}
+
+func (r *RoleRepository) ListPermissions(ctx context.Context, appID int) ([]models.Permission, error) {
+	const op = "repository.role.postgres.ListPermissions"
+
+	query := sq.Select("id", "app_id", "name", "description").
+		From("permissions").
+		Where(sq.Eq{"app_id": appID}).
+		OrderBy("name").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var perms []models.Permission
+	for rows.Next() {
+		var perm models.Permission
+		if err := rows.Scan(&perm.ID, &perm.AppID, &perm.Name, &perm.Description); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		perms = append(perms, perm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return perms, nil
+}
+
+func (r *RoleRepository) DeletePermission(ctx context.Context, permissionID int64) error {
+	const op = "repository.role.postgres.DeletePermission"
+
+	query := sq.Delete("permissions").
+		Where(sq.Eq{"id": permissionID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrPermissionNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetRolePermissions replaces the permissions of a role. Every name must be a
+// permission of the role's app, otherwise nothing is changed.
+func (r *RoleRepository) SetRolePermissions(ctx context.Context, roleID int64, names []string) error {
+	const op = "repository.role.postgres.SetRolePermissions"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var appID int
+	err = tx.QueryRowContext(ctx, "SELECT app_id FROM roles WHERE id = $1 FOR UPDATE", roleID).Scan(&appID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%s: %w", op, repository.ErrRoleNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role_id = $1", roleID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(names) > 0 {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO role_permissions (role_id, permission_id)
+			SELECT $1, id FROM permissions WHERE app_id = $2 AND name = ANY($3)`,
+			roleID, appID, pq.Array(names))
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if int(n) != countDistinct(names) {
+			return fmt.Errorf("%s: %w", op, repository.ErrPermissionNotFound)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AssignRole gives the role to the user. Assigning a role the user already has is not an error.
+func (r *RoleRepository) AssignRole(ctx context.Context, userID, roleID int64) error {
+	const op = "repository.role.postgres.AssignRole"
+
+	query := sq.Insert("user_roles").
+		Columns("user_id", "role_id").
+		Values(userID, roleID).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, sqlStr, args...); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			if pqErr.Constraint == "user_roles_user_id_fkey" {
+				return fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+			}
+			return fmt.Errorf("%s: %w", op, repository.ErrRoleNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *RoleRepository) RevokeRole(ctx context.Context, userID, roleID int64) error {
+	const op = "repository.role.postgres.RevokeRole"
+
+	query := sq.Delete("user_roles").
+		Where(sq.Eq{"user_id": userID, "role_id": roleID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrRoleNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *RoleRepository) UserRoles(ctx context.Context, userID int64, appID int) ([]models.Role, error) {
+	const op = "repository.role.postgres.UserRoles"
+
+	query := sq.Select(roleColumns...).
+		From("roles r").
+		Join("user_roles ur ON ur.role_id = r.id").
+		Where(sq.Eq{"ur.user_id": userID, "r.app_id": appID}).
+		OrderBy("r.name").
+		PlaceholderFormat(sq.Dollar)
+
+	roles, err := r.queryRoles(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepository) UserAuthorization(ctx context.Context, userID int64, appID int) (models.Authorization, error) {
+	const op = "repository.role.postgres.UserAuthorization"
+
+	var authz models.Authorization
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			ARRAY(SELECT r.name FROM roles r JOIN user_roles ur ON ur.role_id = r.id
+				WHERE ur.user_id = $1 AND r.app_id = $2 ORDER BY r.name),
+			ARRAY(SELECT DISTINCT p.name FROM permissions p
+				JOIN role_permissions rp ON rp.permission_id = p.id
+				JOIN user_roles ur ON ur.role_id = rp.role_id
+				WHERE ur.user_id = $1 AND p.app_id = $2 ORDER BY p.name)`,
+		userID, appID).Scan(pq.Array(&authz.Roles), pq.Array(&authz.Permissions))
+	if err != nil {
+		return models.Authorization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return authz, nil
+}
+
+func (r *RoleRepository) insertReturningID(ctx context.Context, query sq.InsertBuilder) (int64, error) {
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query: %w", err)
+	}
+
+	var id int64
+	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *RoleRepository) queryRoles(ctx context.Context, query sq.SelectBuilder) ([]models.Role, error) {
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []models.Role
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// appScopedError maps constraint violations on tables keyed by app to repository errors.
+func appScopedError(err, exists error) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code {
+		case "23505":
+			return exists
+		case "23503":
+			return repository.ErrAppNotFound
+		}
+	}
+	return err
+}
+
+func scanRole(row sqlx.ColScanner) (role models.Role, err error) {
+	err = row.Scan(&role.ID, &role.AppID, &role.Name, &role.Description, pq.Array(&role.Permissions))
+	return role, err
+}
+
+func countDistinct(s []string) int {
+	seen := make(map[string]struct{}, len(s))
+	for _, v := range s {
+		seen[v] = struct{}{}
+	}
+	return len(seen)
+}
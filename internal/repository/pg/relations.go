@@ -0,0 +1,207 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+type RelationRepository struct {
+	db *sqlx.DB
+}
+
+func NewRelationRepository(db *sqlx.DB) *RelationRepository {
+	return &RelationRepository{db: db}
+}
+
+func (r *RelationRepository) GetNamespace(ctx context.Context, name string) (models.NamespaceConfig, error) {
+	const op = "repository.relation.postgres.GetNamespace"
+
+	var data []byte
+	err := r.db.QueryRowContext(ctx, "SELECT config FROM authz_namespaces WHERE name = $1", name).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.NamespaceConfig{}, fmt.Errorf("%s: %w", op, repository.ErrNamespaceNotFound)
+		}
+		return models.NamespaceConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cfg := models.NamespaceConfig{Name: name}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return models.NamespaceConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cfg, nil
+}
+
+func (r *RelationRepository) WriteNamespace(ctx context.Context, cfg models.NamespaceConfig) error {
+	const op = "repository.relation.postgres.WriteNamespace"
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("%s: marshal config: %w", op, err)
+	}
+
+	query := sq.Insert("authz_namespaces").
+		Columns("name", "config").
+		Values(cfg.Name, string(data)).
+		Suffix("ON CONFLICT (name) DO UPDATE SET config = EXCLUDED.config, updated_at = now()").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Revision returns the latest committed tuple revision.
+func (r *RelationRepository) Revision(ctx context.Context) (int64, error) {
+	const op = "repository.relation.postgres.Revision"
+
+	var rev int64
+	if err := r.db.QueryRowContext(ctx, "SELECT COALESCE(max(rev), 0) FROM authz_revisions").Scan(&rev); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rev, nil
+}
+
+// WriteTuples applies writes and deletes atomically under a new revision, which it returns.
+// Writing a tuple that exists or deleting one that doesn't is not an error.
+func (r *RelationRepository) WriteTuples(ctx context.Context, writes, deletes []models.RelationTuple) (int64, error) {
+	const op = "repository.relation.postgres.WriteTuples"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var rev int64
+	if err := tx.QueryRowContext(ctx, "INSERT INTO authz_revisions DEFAULT VALUES RETURNING rev").Scan(&rev); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, t := range deletes {
+		query := sq.Delete("relation_tuples").
+			Where(tupleKey(t)).
+			PlaceholderFormat(sq.Dollar)
+
+		if err := execTx(ctx, tx, query); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if len(writes) > 0 {
+		query := sq.Insert("relation_tuples").
+			Columns("namespace", "object_id", "relation", "subject_namespace", "subject_id", "subject_relation", "created_rev").
+			Suffix("ON CONFLICT DO NOTHING").
+			PlaceholderFormat(sq.Dollar)
+		for _, t := range writes {
+			query = query.Values(t.Object.Namespace, t.Object.ID, t.Relation, t.Subject.Namespace, t.Subject.ID, t.Subject.Relation, rev)
+		}
+
+		if err := execTx(ctx, tx, query); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rev, nil
+}
+
+// ReadTuples returns the tuples written for relation on obj.
+func (r *RelationRepository) ReadTuples(ctx context.Context, obj models.Object, relation string) ([]models.RelationTuple, error) {
+	const op = "repository.relation.postgres.ReadTuples"
+
+	query := sq.Select("subject_namespace", "subject_id", "subject_relation").
+		From("relation_tuples").
+		Where(sq.Eq{"namespace": obj.Namespace, "object_id": obj.ID, "relation": relation}).
+		OrderBy("subject_namespace", "subject_id", "subject_relation").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var tuples []models.RelationTuple
+	for rows.Next() {
+		t := models.RelationTuple{Object: obj, Relation: relation}
+		if err := rows.Scan(&t.Subject.Namespace, &t.Subject.ID, &t.Subject.Relation); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tuples = append(tuples, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tuples, nil
+}
+
+// ObjectIDs returns up to limit distinct ids of objects in the namespace that have any tuples.
+func (r *RelationRepository) ObjectIDs(ctx context.Context, namespace string, limit int) ([]string, error) {
+	const op = "repository.relation.postgres.ObjectIDs"
+
+	query := sq.Select("DISTINCT object_id").
+		From("relation_tuples").
+		Where(sq.Eq{"namespace": namespace}).
+		OrderBy("object_id").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var ids []string
+	if err := r.db.SelectContext(ctx, &ids, sqlStr, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ids, nil
+}
+
+func tupleKey(t models.RelationTuple) sq.Eq {
+	return sq.Eq{
+		"namespace":         t.Object.Namespace,
+		"object_id":         t.Object.ID,
+		"relation":          t.Relation,
+		"subject_namespace": t.Subject.Namespace,
+		"subject_id":        t.Subject.ID,
+		"subject_relation":  t.Subject.Relation,
+	}
+}
+
+func execTx(ctx context.Context, tx *sqlx.Tx, query sq.Sqlizer) error {
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, sqlStr, args...)
+	return err
+}
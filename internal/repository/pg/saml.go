@@ -0,0 +1,193 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type SAMLRepository struct {
+	db *sqlx.DB
+}
+
+func NewSAMLRepository(db *sqlx.DB) *SAMLRepository {
+	return &SAMLRepository{db: db}
+}
+
+var serviceProviderColumns = []string{
+	"id", "app_id", "entity_id", "name", "acs_url", "slo_url", "certificate",
+	"name_id_format", "attribute_map", "disabled", "created_at",
+}
+
+func (r *SAMLRepository) CreateServiceProvider(ctx context.Context, sp models.ServiceProvider) (int, error) {
+	const op = "repository.saml.postgres.CreateServiceProvider"
+
+	attributeMap, err := json.Marshal(orEmptyMap(sp.AttributeMap))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := sq.Insert("saml_service_providers").
+		Columns("app_id", "entity_id", "name", "acs_url", "slo_url", "certificate", "name_id_format", "attribute_map").
+		Values(sp.AppID, sp.EntityID, sp.Name, sp.ACSURL, sp.SLOURL, sp.Certificate, sp.NameIDFormat, attributeMap).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var id int
+	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505":
+				return 0, fmt.Errorf("%s: %w", op, repository.ErrServiceProviderExists)
+			case "23503":
+				return 0, fmt.Errorf("%s: %w", op, repository.ErrAppNotFound)
+			}
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *SAMLRepository) GetServiceProvider(ctx context.Context, spID int) (models.ServiceProvider, error) {
+	const op = "repository.saml.postgres.GetServiceProvider"
+
+	sp, err := r.getServiceProviderBy(ctx, sq.Eq{"id": spID})
+	if err != nil {
+		return models.ServiceProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sp, nil
+}
+
+func (r *SAMLRepository) GetServiceProviderByEntityID(ctx context.Context, entityID string) (models.ServiceProvider, error) {
+	const op = "repository.saml.postgres.GetServiceProviderByEntityID"
+
+	sp, err := r.getServiceProviderBy(ctx, sq.Eq{"entity_id": entityID})
+	if err != nil {
+		return models.ServiceProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sp, nil
+}
+
+func (r *SAMLRepository) getServiceProviderBy(ctx context.Context, pred sq.Eq) (models.ServiceProvider, error) {
+	query := sq.Select(serviceProviderColumns...).
+		From("saml_service_providers").
+		Where(pred).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.ServiceProvider{}, fmt.Errorf("build query: %w", err)
+	}
+
+	sp, err := scanServiceProvider(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ServiceProvider{}, repository.ErrServiceProviderNotFound
+		}
+		return models.ServiceProvider{}, err
+	}
+
+	return sp, nil
+}
+
+func (r *SAMLRepository) ListServiceProviders(ctx context.Context) ([]models.ServiceProvider, error) {
+	const op = "repository.saml.postgres.ListServiceProviders"
+
+	query := sq.Select(serviceProviderColumns...).
+		From("saml_service_providers").
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var sps []models.ServiceProvider
+	for rows.Next() {
+		sp, err := scanServiceProvider(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		sps = append(sps, sp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sps, nil
+}
+
+func (r *SAMLRepository) SetServiceProviderDisabled(ctx context.Context, spID int, disabled bool) error {
+	const op = "repository.saml.postgres.SetServiceProviderDisabled"
+
+	query := sq.Update("saml_service_providers").
+		Set("disabled", disabled).
+		Where(sq.Eq{"id": spID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrServiceProviderNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SAMLRepository) DeleteServiceProvider(ctx context.Context, spID int) error {
+	const op = "repository.saml.postgres.DeleteServiceProvider"
+
+	query := sq.Delete("saml_service_providers").
+		Where(sq.Eq{"id": spID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrServiceProviderNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func scanServiceProvider(row sqlx.ColScanner) (sp models.ServiceProvider, err error) {
+	var attributeMap []byte
+
+	if err := row.Scan(
+		&sp.ID, &sp.AppID, &sp.EntityID, &sp.Name, &sp.ACSURL, &sp.SLOURL, &sp.Certificate,
+		&sp.NameIDFormat, &attributeMap, &sp.Disabled, &sp.CreatedAt,
+	); err != nil {
+		return sp, err
+	}
+
+	if err := json.Unmarshal(attributeMap, &sp.AttributeMap); err != nil {
+		return sp, fmt.Errorf("unmarshal attribute_map: %w", err)
+	}
+
+	return sp, nil
+}
+
+func orEmptyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
@@ -0,0 +1,620 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/pkg/scim"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// SCIMRepository stores the users and groups SCIM clients provision. Updates only apply to the
+// version they were read at and fail with repository.ErrVersionMismatch otherwise.
+type SCIMRepository struct {
+	db *sqlx.DB
+}
+
+func NewSCIMRepository(db *sqlx.DB) *SCIMRepository {
+	return &SCIMRepository{db: db}
+}
+
+var provisionedUserColumns = []string{
+	"u.id", "COALESCE(u.external_id, '')", "u.email", "NOT u.disabled", "u.display_name", "u.given_name",
+	"u.family_name", "u.locale", "u.timezone", "u.phone", "u.version", "u.created_at", "u.updated_at",
+}
+
+// CreateUser inserts a provisioned user. The provisioning client vouches for the email.
+func (r *SCIMRepository) CreateUser(ctx context.Context, user models.ProvisionedUser, passHash []byte, passAlgo string) (int64, error) {
+	const op = "repository.scim.postgres.CreateUser"
+
+	query := sq.Insert("users").
+		Columns("email", "pass_hash", "pass_algo", "email_verified", "external_id", "disabled",
+			"display_name", "given_name", "family_name", "locale", "timezone", "phone").
+		Values(user.Email, orEmptyBytes(passHash), passAlgo, true, nullIfEmpty(user.ExternalID), !user.Active,
+			user.DisplayName, user.GivenName, user.FamilyName, user.Locale, user.Timezone, user.Phone).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	if err := tx.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("%s: %w", op, repository.ErrUserExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	payload := map[string]any{"user_id": id, "email": user.Email, "provisioned": true}
+	if err := enqueueEvent(ctx, tx, models.EventUserRegistered, id, payload); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *SCIMRepository) GetUser(ctx context.Context, userID int64) (models.ProvisionedUser, error) {
+	const op = "repository.scim.postgres.GetUser"
+
+	users, _, err := r.listUsers(ctx, sq.Eq{"u.id": userID}, 0, 1, false)
+	if err != nil {
+		return models.ProvisionedUser{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(users) == 0 {
+		return models.ProvisionedUser{}, fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+	}
+
+	return users[0], nil
+}
+
+// ListUsers returns a page of the users matching filter, ordered by ID, and how many match in
+// total. A nil filter matches every user.
+func (r *SCIMRepository) ListUsers(ctx context.Context, filter scim.Filter, offset, limit int) ([]models.ProvisionedUser, int, error) {
+	const op = "repository.scim.postgres.ListUsers"
+
+	where, err := filterSQL(filter, userFilterAttrs, "")
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	users, total, err := r.listUsers(ctx, where, offset, limit, true)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, total, nil
+}
+
+func (r *SCIMRepository) listUsers(ctx context.Context, where sq.Sqlizer, offset, limit int, count bool) ([]models.ProvisionedUser, int, error) {
+	total := 0
+	if count {
+		var err error
+		if total, err = r.count(ctx, "users u", where); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := sq.Select(provisionedUserColumns...).
+		From("users u").
+		Where(where).
+		OrderBy("u.id").
+		Offset(uint64(offset)).
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []models.ProvisionedUser
+	var ids []int64
+	for rows.Next() {
+		var u models.ProvisionedUser
+		if err := rows.Scan(&u.ID, &u.ExternalID, &u.Email, &u.Active, &u.DisplayName, &u.GivenName,
+			&u.FamilyName, &u.Locale, &u.Timezone, &u.Phone, &u.Version, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, u)
+		ids = append(ids, u.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(users) == 0 {
+		return nil, total, nil
+	}
+
+	groups, err := r.db.QueryxContext(ctx, `
+		SELECT m.user_id, g.id, g.display_name FROM group_members m JOIN groups g ON g.id = m.group_id
+		WHERE m.user_id = ANY($1) ORDER BY g.id`, pq.Array(ids))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer groups.Close()
+
+	byUser := make(map[int64][]models.GroupRef, len(users))
+	for groups.Next() {
+		var userID int64
+		var ref models.GroupRef
+		if err := groups.Scan(&userID, &ref.ID, &ref.DisplayName); err != nil {
+			return nil, 0, err
+		}
+		byUser[userID] = append(byUser[userID], ref)
+	}
+	if err := groups.Err(); err != nil {
+		return nil, 0, err
+	}
+	for i := range users {
+		users[i].Groups = byUser[users[i].ID]
+	}
+
+	return users, total, nil
+}
+
+// UpdateUser overwrites the provisioned attributes of the user at user.Version. A nil passHash
+// keeps the password.
+func (r *SCIMRepository) UpdateUser(ctx context.Context, user models.ProvisionedUser, passHash []byte, passAlgo string) error {
+	const op = "repository.scim.postgres.UpdateUser"
+
+	query := sq.Update("users").
+		Set("email", user.Email).
+		Set("external_id", nullIfEmpty(user.ExternalID)).
+		Set("disabled", !user.Active).
+		Set("display_name", user.DisplayName).
+		Set("given_name", user.GivenName).
+		Set("family_name", user.FamilyName).
+		Set("locale", user.Locale).
+		Set("timezone", user.Timezone).
+		Set("phone", user.Phone).
+		Set("phone_verified", sq.Expr("phone_verified AND phone = ?", user.Phone)).
+		Where(sq.Eq{"id": user.ID, "version": user.Version}).
+		PlaceholderFormat(sq.Dollar)
+	if passHash != nil {
+		query = query.Set("pass_hash", passHash).Set("pass_algo", passAlgo)
+	}
+
+	err := execAffecting(ctx, r.db, query, repository.ErrVersionMismatch)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, repository.ErrUserExists)
+		}
+		if err == repository.ErrVersionMismatch {
+			err = r.mismatch(ctx, "users", user.ID, repository.ErrUserNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+var groupColumns = []string{
+	"g.id", "g.display_name", "COALESCE(g.external_id, '')", "g.version", "g.created_at", "g.updated_at",
+}
+
+// CreateGroup inserts a group with its members.
+func (r *SCIMRepository) CreateGroup(ctx context.Context, group models.Group) (int, error) {
+	const op = "repository.scim.postgres.CreateGroup"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO groups (display_name, external_id) VALUES ($1, $2) RETURNING id",
+		group.DisplayName, nullIfEmpty(group.ExternalID),
+	).Scan(&id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("%s: %w", op, repository.ErrGroupExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := addGroupMembers(ctx, tx, id, group.Members); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *SCIMRepository) GetGroup(ctx context.Context, groupID int) (models.Group, error) {
+	const op = "repository.scim.postgres.GetGroup"
+
+	groups, _, err := r.listGroups(ctx, sq.Eq{"g.id": groupID}, 0, 1, false)
+	if err != nil {
+		return models.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(groups) == 0 {
+		return models.Group{}, fmt.Errorf("%s: %w", op, repository.ErrGroupNotFound)
+	}
+
+	return groups[0], nil
+}
+
+// ListGroups returns a page of the groups matching filter, ordered by ID, and how many match in
+// total. A nil filter matches every group.
+func (r *SCIMRepository) ListGroups(ctx context.Context, filter scim.Filter, offset, limit int) ([]models.Group, int, error) {
+	const op = "repository.scim.postgres.ListGroups"
+
+	where, err := filterSQL(filter, groupFilterAttrs, "")
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	groups, total, err := r.listGroups(ctx, where, offset, limit, true)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return groups, total, nil
+}
+
+func (r *SCIMRepository) listGroups(ctx context.Context, where sq.Sqlizer, offset, limit int, count bool) ([]models.Group, int, error) {
+	total := 0
+	if count {
+		var err error
+		if total, err = r.count(ctx, "groups g", where); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := sq.Select(groupColumns...).
+		From("groups g").
+		Where(where).
+		OrderBy("g.id").
+		Offset(uint64(offset)).
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var groups []models.Group
+	var ids []int
+	for rows.Next() {
+		var g models.Group
+		if err := rows.Scan(&g.ID, &g.DisplayName, &g.ExternalID, &g.Version, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+		groups = append(groups, g)
+		ids = append(ids, g.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(groups) == 0 {
+		return nil, total, nil
+	}
+
+	members, err := r.db.QueryxContext(ctx, `
+		SELECT m.group_id, u.id, u.email FROM group_members m JOIN users u ON u.id = m.user_id
+		WHERE m.group_id = ANY($1) ORDER BY u.id`, pq.Array(ids))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer members.Close()
+
+	byGroup := make(map[int][]models.GroupMember, len(groups))
+	for members.Next() {
+		var groupID int
+		var m models.GroupMember
+		if err := members.Scan(&groupID, &m.UserID, &m.Email); err != nil {
+			return nil, 0, err
+		}
+		byGroup[groupID] = append(byGroup[groupID], m)
+	}
+	if err := members.Err(); err != nil {
+		return nil, 0, err
+	}
+	for i := range groups {
+		groups[i].Members = byGroup[groups[i].ID]
+	}
+
+	return groups, total, nil
+}
+
+// UpdateGroup overwrites the group at group.Version, members included.
+func (r *SCIMRepository) UpdateGroup(ctx context.Context, group models.Group) error {
+	const op = "repository.scim.postgres.UpdateGroup"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := sq.Update("groups").
+		Set("display_name", group.DisplayName).
+		Set("external_id", nullIfEmpty(group.ExternalID)).
+		Where(sq.Eq{"id": group.ID, "version": group.Version}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, tx, query, repository.ErrVersionMismatch); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, repository.ErrGroupExists)
+		}
+		if err == repository.ErrVersionMismatch {
+			err = r.mismatch(ctx, "groups", int64(group.ID), repository.ErrGroupNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	userIDs := make([]int64, 0, len(group.Members))
+	for _, m := range group.Members {
+		userIDs = append(userIDs, m.UserID)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM group_members WHERE group_id = $1 AND NOT user_id = ANY($2)", group.ID, pq.Array(userIDs),
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := addGroupMembers(ctx, tx, group.ID, group.Members); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SCIMRepository) DeleteGroup(ctx context.Context, groupID int) error {
+	const op = "repository.scim.postgres.DeleteGroup"
+
+	query := sq.Delete("groups").
+		Where(sq.Eq{"id": groupID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrGroupNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func addGroupMembers(ctx context.Context, tx *sqlx.Tx, groupID int, members []models.GroupMember) error {
+	for _, m := range members {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO group_members (group_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", groupID, m.UserID)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+				return repository.ErrUserNotFound
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *SCIMRepository) count(ctx context.Context, from string, where sq.Sqlizer) (int, error) {
+	sqlStr, args, err := sq.Select("COUNT(*)").From(from).Where(where).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build query: %w", err)
+	}
+
+	var n int
+	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// mismatch tells a row that is gone from one that changed after a conditional update hit nothing.
+func (r *SCIMRepository) mismatch(ctx context.Context, table string, id int64, notFound error) error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return notFound
+	}
+	return repository.ErrVersionMismatch
+}
+
+type filterKind int
+
+const (
+	// filterText compares case-exactly, filterFold case-insensitively.
+	filterText filterKind = iota
+	filterFold
+	filterBool
+	filterTime
+	filterMember
+)
+
+type filterAttr struct {
+	expr string
+	kind filterKind
+}
+
+var userFilterAttrs = map[string]filterAttr{
+	"id":                 {"u.id::text", filterText},
+	"externalid":         {"COALESCE(u.external_id, '')", filterText},
+	"username":           {"u.email", filterFold},
+	"emails":             {"u.email", filterFold},
+	"emails.value":       {"u.email", filterFold},
+	"active":             {"NOT u.disabled", filterBool},
+	"displayname":        {"u.display_name", filterFold},
+	"name.givenname":     {"u.given_name", filterFold},
+	"name.familyname":    {"u.family_name", filterFold},
+	"locale":             {"u.locale", filterFold},
+	"timezone":           {"u.timezone", filterFold},
+	"phonenumbers":       {"u.phone", filterText},
+	"phonenumbers.value": {"u.phone", filterText},
+	"meta.created":       {"u.created_at", filterTime},
+	"meta.lastmodified":  {"u.updated_at", filterTime},
+}
+
+var groupFilterAttrs = map[string]filterAttr{
+	"id":                {"g.id::text", filterText},
+	"externalid":        {"COALESCE(g.external_id, '')", filterText},
+	"displayname":       {"g.display_name", filterFold},
+	"members":           {"g.id", filterMember},
+	"members.value":     {"g.id", filterMember},
+	"meta.created":      {"g.created_at", filterTime},
+	"meta.lastmodified": {"g.updated_at", filterTime},
+}
+
+var sqlOps = map[string]string{"eq": "=", "ne": "<>", "gt": ">", "ge": ">=", "lt": "<", "le": "<="}
+
+// filterSQL translates a SCIM filter into a WHERE clause over the given attributes. prefix is the
+// multi-valued attribute a value path filter applies to.
+func filterSQL(f scim.Filter, attrs map[string]filterAttr, prefix string) (sq.Sqlizer, error) {
+	switch f := f.(type) {
+	case nil:
+		return sq.Expr("true"), nil
+	case *scim.Logical:
+		left, err := filterSQL(f.Left, attrs, prefix)
+		if err != nil {
+			return nil, err
+		}
+		right, err := filterSQL(f.Right, attrs, prefix)
+		if err != nil {
+			return nil, err
+		}
+		if f.Op == "and" {
+			return sq.And{left, right}, nil
+		}
+		return sq.Or{left, right}, nil
+	case *scim.Not:
+		inner, err := filterSQL(f.Filter, attrs, prefix)
+		if err != nil {
+			return nil, err
+		}
+		sqlStr, args, err := inner.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		return sq.Expr("NOT ("+sqlStr+")", args...), nil
+	case *scim.ValuePath:
+		return filterSQL(f.Filter, attrs, f.Attr+".")
+	case *scim.Compare:
+		return compareSQL(f, attrs, prefix)
+	}
+	return nil, scim.BadRequest(scim.ErrorInvalidFilter, "unsupported filter")
+}
+
+func compareSQL(c *scim.Compare, attrs map[string]filterAttr, prefix string) (sq.Sqlizer, error) {
+	name := prefix + c.Attr
+	attr, ok := attrs[name]
+	if !ok {
+		return nil, scim.BadRequest(scim.ErrorInvalidFilter, "cannot filter by %q", name)
+	}
+
+	if c.Op == "pr" || c.Value == nil {
+		present := "true"
+		switch attr.kind {
+		case filterText, filterFold:
+			present = attr.expr + " <> ''"
+		case filterMember:
+			present = "EXISTS (SELECT 1 FROM group_members m WHERE m.group_id = " + attr.expr + ")"
+		}
+		if c.Op == "eq" {
+			return sq.Expr("NOT (" + present + ")"), nil
+		}
+		return sq.Expr(present), nil
+	}
+
+	switch attr.kind {
+	case filterBool:
+		v, ok := c.Value.(bool)
+		if !ok || (c.Op != "eq" && c.Op != "ne") {
+			return nil, scim.BadRequest(scim.ErrorInvalidFilter, "%q only compares eq or ne to true or false", name)
+		}
+		return sq.Expr(attr.expr+" "+sqlOps[c.Op]+" ?", v), nil
+	case filterTime:
+		s, _ := c.Value.(string)
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil || sqlOps[c.Op] == "" {
+			return nil, scim.BadRequest(scim.ErrorInvalidFilter, "%q compares to RFC 3339 times", name)
+		}
+		return sq.Expr(attr.expr+" "+sqlOps[c.Op]+" ?", t), nil
+	case filterMember:
+		v := fmt.Sprint(c.Value)
+		if c.Op != "eq" {
+			return nil, scim.BadRequest(scim.ErrorInvalidFilter, "%q only compares eq", name)
+		}
+		return sq.Expr("EXISTS (SELECT 1 FROM group_members m WHERE m.group_id = "+attr.expr+" AND m.user_id::text = ?)", v), nil
+	}
+
+	v, ok := c.Value.(string)
+	if !ok {
+		if n, isNum := c.Value.(float64); isNum {
+			v, ok = fmt.Sprint(n), true
+		}
+	}
+	if !ok {
+		return nil, scim.BadRequest(scim.ErrorInvalidFilter, "%q compares to strings", name)
+	}
+
+	expr, like := attr.expr, "LIKE"
+	if attr.kind == filterFold {
+		like = "ILIKE"
+		if op := sqlOps[c.Op]; op != "" {
+			return sq.Expr("lower("+expr+") "+op+" lower(?)", v), nil
+		}
+	}
+	switch c.Op {
+	case "co":
+		return sq.Expr(expr+" "+like+" ?", "%"+escapeLike(v)+"%"), nil
+	case "sw":
+		return sq.Expr(expr+" "+like+" ?", escapeLike(v)+"%"), nil
+	case "ew":
+		return sq.Expr(expr+" "+like+" ?", "%"+escapeLike(v)), nil
+	}
+	return sq.Expr(expr+" "+sqlOps[c.Op]+" ?", v), nil
+}
+
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func orEmptyBytes(b []byte) []byte {
+	if b == nil {
+		return []byte{}
+	}
+	return b
+}
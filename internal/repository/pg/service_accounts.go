@@ -0,0 +1,326 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type ServiceAccountRepository struct {
+	db *sqlx.DB
+}
+
+func NewServiceAccountRepository(db *sqlx.DB) *ServiceAccountRepository {
+	return &ServiceAccountRepository{db: db}
+}
+
+var serviceAccountColumns = []string{"id", "app_id", "name", "description", "disabled", "created_by", "created_at"}
+
+var serviceAccountKeyColumns = []string{"id", "service_account_id", "public_key", "algorithm", "expires_at", "created_at"}
+
+func (r *ServiceAccountRepository) Create(ctx context.Context, sa models.ServiceAccount) (int64, error) {
+	const op = "repository.service_account.postgres.Create"
+
+	query := sq.Insert("service_accounts").
+		Columns("app_id", "name", "description", "created_by").
+		Values(sa.AppID, sa.Name, sa.Description, nullableID(sa.CreatedBy)).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var id int64
+	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, appScopedError(err, repository.ErrServiceAccountExists))
+	}
+
+	return id, nil
+}
+
+func (r *ServiceAccountRepository) Get(ctx context.Context, id int64) (models.ServiceAccount, error) {
+	const op = "repository.service_account.postgres.Get"
+
+	query := sq.Select(serviceAccountColumns...).
+		From("service_accounts").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.ServiceAccount{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	sa, err := scanServiceAccount(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ServiceAccount{}, fmt.Errorf("%s: %w", op, repository.ErrServiceAccountNotFound)
+		}
+		return models.ServiceAccount{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sa, nil
+}
+
+func (r *ServiceAccountRepository) List(ctx context.Context, appID int) ([]models.ServiceAccount, error) {
+	const op = "repository.service_account.postgres.List"
+
+	query := sq.Select(serviceAccountColumns...).
+		From("service_accounts").
+		Where(sq.Eq{"app_id": appID}).
+		OrderBy("name").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var accounts []models.ServiceAccount
+	for rows.Next() {
+		sa, err := scanServiceAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		accounts = append(accounts, sa)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return accounts, nil
+}
+
+func (r *ServiceAccountRepository) SetDisabled(ctx context.Context, id int64, disabled bool) error {
+	const op = "repository.service_account.postgres.SetDisabled"
+
+	query := sq.Update("service_accounts").
+		Set("disabled", disabled).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrServiceAccountNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Delete removes the service account together with its keys and role assignments.
+func (r *ServiceAccountRepository) Delete(ctx context.Context, id int64) error {
+	const op = "repository.service_account.postgres.Delete"
+
+	query := sq.Delete("service_accounts").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrServiceAccountNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *ServiceAccountRepository) AddKey(ctx context.Context, key models.ServiceAccountKey) error {
+	const op = "repository.service_account.postgres.AddKey"
+
+	var expiresAt any
+	if !key.ExpiresAt.IsZero() {
+		expiresAt = key.ExpiresAt
+	}
+
+	query := sq.Insert("service_account_keys").
+		Columns("id", "service_account_id", "public_key", "algorithm", "expires_at").
+		Values(key.ID, key.ServiceAccountID, key.PublicKey, key.Algorithm, expiresAt).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, sqlStr, args...); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return fmt.Errorf("%s: %w", op, repository.ErrServiceAccountNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *ServiceAccountRepository) GetKey(ctx context.Context, keyID string) (models.ServiceAccountKey, error) {
+	const op = "repository.service_account.postgres.GetKey"
+
+	query := sq.Select(serviceAccountKeyColumns...).
+		From("service_account_keys").
+		Where(sq.Eq{"id": keyID}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.ServiceAccountKey{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	key, err := scanServiceAccountKey(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ServiceAccountKey{}, fmt.Errorf("%s: %w", op, repository.ErrKeyNotFound)
+		}
+		return models.ServiceAccountKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+func (r *ServiceAccountRepository) ListKeys(ctx context.Context, serviceAccountID int64) ([]models.ServiceAccountKey, error) {
+	const op = "repository.service_account.postgres.ListKeys"
+
+	query := sq.Select(serviceAccountKeyColumns...).
+		From("service_account_keys").
+		Where(sq.Eq{"service_account_id": serviceAccountID}).
+		OrderBy("created_at DESC").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []models.ServiceAccountKey
+	for rows.Next() {
+		key, err := scanServiceAccountKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+func (r *ServiceAccountRepository) DeleteKey(ctx context.Context, serviceAccountID int64, keyID string) error {
+	const op = "repository.service_account.postgres.DeleteKey"
+
+	query := sq.Delete("service_account_keys").
+		Where(sq.Eq{"id": keyID, "service_account_id": serviceAccountID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrKeyNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AssignRole gives the role to the service account. Assigning a role it already has is not an error.
+func (r *ServiceAccountRepository) AssignRole(ctx context.Context, serviceAccountID, roleID int64) error {
+	const op = "repository.service_account.postgres.AssignRole"
+
+	query := sq.Insert("service_account_roles").
+		Columns("service_account_id", "role_id").
+		Values(serviceAccountID, roleID).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, sqlStr, args...); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			if pqErr.Constraint == "service_account_roles_service_account_id_fkey" {
+				return fmt.Errorf("%s: %w", op, repository.ErrServiceAccountNotFound)
+			}
+			return fmt.Errorf("%s: %w", op, repository.ErrRoleNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *ServiceAccountRepository) RevokeRole(ctx context.Context, serviceAccountID, roleID int64) error {
+	const op = "repository.service_account.postgres.RevokeRole"
+
+	query := sq.Delete("service_account_roles").
+		Where(sq.Eq{"service_account_id": serviceAccountID, "role_id": roleID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrRoleNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Authorization returns the names of the roles of the service account and of the permissions they grant.
+func (r *ServiceAccountRepository) Authorization(ctx context.Context, serviceAccountID int64) (models.Authorization, error) {
+	const op = "repository.service_account.postgres.Authorization"
+
+	var authz models.Authorization
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			ARRAY(SELECT r.name FROM roles r JOIN service_account_roles sr ON sr.role_id = r.id
+				WHERE sr.service_account_id = $1 ORDER BY r.name),
+			ARRAY(SELECT DISTINCT p.name FROM permissions p
+				JOIN role_permissions rp ON rp.permission_id = p.id
+				JOIN service_account_roles sr ON sr.role_id = rp.role_id
+				WHERE sr.service_account_id = $1 ORDER BY p.name)`,
+		serviceAccountID).Scan(pq.Array(&authz.Roles), pq.Array(&authz.Permissions))
+	if err != nil {
+		return models.Authorization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return authz, nil
+}
+
+func scanServiceAccount(row sqlx.ColScanner) (sa models.ServiceAccount, err error) {
+	var createdBy sql.NullInt64
+
+	err = row.Scan(&sa.ID, &sa.AppID, &sa.Name, &sa.Description, &sa.Disabled, &createdBy, &sa.CreatedAt)
+	if err != nil {
+		return sa, err
+	}
+
+	sa.CreatedBy = createdBy.Int64
+
+	return sa, nil
+}
+
+func scanServiceAccountKey(row sqlx.ColScanner) (key models.ServiceAccountKey, err error) {
+	var expiresAt sql.NullTime
+
+	err = row.Scan(&key.ID, &key.ServiceAccountID, &key.PublicKey, &key.Algorithm, &expiresAt, &key.CreatedAt)
+	if err != nil {
+		return key, err
+	}
+
+	key.ExpiresAt = expiresAt.Time
+
+	return key, nil
+}
@@ -0,0 +1,175 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type TokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewTokenRepository(db *sqlx.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+var tokenColumns = []string{
+	"id", "user_id", "name", "token_hash", "display_prefix", "scopes", "expires_at", "last_used_at", "created_at",
+}
+
+func (r *TokenRepository) Create(ctx context.Context, token models.PersonalAccessToken) (int64, error) {
+	const op = "repository.token.postgres.Create"
+
+	var expiresAt any
+	if !token.ExpiresAt.IsZero() {
+		expiresAt = token.ExpiresAt
+	}
+
+	query := sq.Insert("personal_access_tokens").
+		Columns("user_id", "name", "token_hash", "display_prefix", "scopes", "expires_at").
+		Values(token.UserID, token.Name, token.TokenHash, token.DisplayPrefix, pq.Array(token.Scopes), expiresAt).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var id int64
+	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return 0, fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *TokenRepository) GetByHash(ctx context.Context, hash string) (models.PersonalAccessToken, error) {
+	const op = "repository.token.postgres.GetByHash"
+
+	query := sq.Select(tokenColumns...).
+		From("personal_access_tokens").
+		Where(sq.Eq{"token_hash": hash}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.PersonalAccessToken{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	token, err := scanToken(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, repository.ErrTokenNotFound)
+		}
+		return models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
+
+func (r *TokenRepository) ListForUser(ctx context.Context, userID int64) ([]models.PersonalAccessToken, error) {
+	const op = "repository.token.postgres.ListForUser"
+
+	query := sq.Select(tokenColumns...).
+		From("personal_access_tokens").
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("created_at DESC", "id DESC").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var tokens []models.PersonalAccessToken
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// Delete revokes the token. With a non-zero userID only a token of that user is deleted.
+func (r *TokenRepository) Delete(ctx context.Context, tokenID, userID int64) error {
+	const op = "repository.token.postgres.Delete"
+
+	pred := sq.Eq{"id": tokenID}
+	if userID != 0 {
+		pred["user_id"] = userID
+	}
+
+	query := sq.Delete("personal_access_tokens").
+		Where(pred).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrTokenNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// TouchLastUsed records a use of the token. Uses closer together than granularity are not
+// written, so busy tokens don't cause a write per request.
+func (r *TokenRepository) TouchLastUsed(ctx context.Context, tokenID int64, at time.Time, granularity time.Duration) error {
+	const op = "repository.token.postgres.TouchLastUsed"
+
+	query := sq.Update("personal_access_tokens").
+		Set("last_used_at", at).
+		Where(sq.Eq{"id": tokenID}).
+		Where(sq.Or{sq.Eq{"last_used_at": nil}, sq.Lt{"last_used_at": at.Add(-granularity)}}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func scanToken(row sqlx.ColScanner) (token models.PersonalAccessToken, err error) {
+	var expiresAt, lastUsedAt sql.NullTime
+
+	err = row.Scan(
+		&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.DisplayPrefix,
+		pq.Array(&token.Scopes), &expiresAt, &lastUsedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		return token, err
+	}
+
+	token.ExpiresAt = expiresAt.Time
+	token.LastUsedAt = lastUsedAt.Time
+
+	return token, nil
+}
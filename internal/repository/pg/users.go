@@ -3,9 +3,11 @@ package pg
 import (
 	"auth/internal/domain/models"
 	"auth/internal/repository"
+	"auth/pkg/password"
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
@@ -34,8 +36,14 @@ func (r *UserRepository) Create(ctx context.Context, email string, passHash []by
 		return 0, fmt.Errorf("%s: build query: %w", op, err)
 	}
 
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
 	var id int64
-	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+	if err := tx.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Code == "23505" {
 				return -1, fmt.Errorf("%s: %w", op, repository.ErrUserExists)
@@ -45,28 +53,474 @@ func (r *UserRepository) Create(ctx context.Context, email string, passHash []by
 		return -1, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err := enqueueEvent(ctx, tx, models.EventUserRegistered, id, map[string]any{"user_id": id, "email": email}); err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// CreateShadow creates a user whose password is checked by an LDAP directory. The directory
+// vouches for the email.
+func (r *UserRepository) CreateShadow(ctx context.Context, email string) (int64, error) {
+	const op = "repository.user.postgres.CreateShadow"
+
+	id, err := r.createVerified(ctx, email, password.AlgoDirectory, map[string]any{"directory": true})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// CreatePasswordless creates a user without a password for an email they have just proven
+// they own.
+func (r *UserRepository) CreatePasswordless(ctx context.Context, email string) (int64, error) {
+	const op = "repository.user.postgres.CreatePasswordless"
+
+	id, err := r.createVerified(ctx, email, password.AlgoNone, map[string]any{"passwordless": true})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// CreateWithPhone creates a user without an email or password for a phone number they have
+// just proven they own.
+func (r *UserRepository) CreateWithPhone(ctx context.Context, phone string) (int64, error) {
+	const op = "repository.user.postgres.CreateWithPhone"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO users (email, pass_hash, pass_algo, phone, phone_verified) VALUES ('', '', $1, $2, true) RETURNING id",
+		password.AlgoNone, phone,
+	).Scan(&id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("%s: %w", op, repository.ErrUserExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := enqueueEvent(ctx, tx, models.EventUserRegistered, id, map[string]any{"user_id": id, "phone": phone}); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// createVerified creates a user with a verified email and no password hash. details go into
+// the registration event.
+func (r *UserRepository) createVerified(ctx context.Context, email, passAlgo string, details map[string]any) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO users (email, pass_hash, pass_algo, email_verified) VALUES ($1, '', $2, true) RETURNING id",
+		email, passAlgo,
+	).Scan(&id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return 0, repository.ErrUserExists
+		}
+		return 0, err
+	}
+
+	payload := map[string]any{"user_id": id, "email": email}
+	for k, v := range details {
+		payload[k] = v
+	}
+	if err := enqueueEvent(ctx, tx, models.EventUserRegistered, id, payload); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return id, nil
 }
 
+var userColumns = []string{
+	"id", "email", "pass_hash", "pass_algo",
+	"is_admin", "disabled", "email_verified", "password_reset_required",
+	"phone", "phone_verified",
+}
+
 func (r *UserRepository) Get(ctx context.Context, email string) (user models.User, err error) {
 	const op = "repository.user.postgres.Get"
 
-	query := sq.Select("id", "email", "pass_hash").
+	// Users who signed up with a phone number have no email.
+	if email == "" {
+		return user, fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+	}
+
+	user, err = r.getBy(ctx, sq.Eq{"email": email})
+	if err != nil {
+		return user, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, userID int64) (user models.User, err error) {
+	const op = "repository.user.postgres.GetByID"
+
+	user, err = r.getBy(ctx, sq.Eq{"id": userID})
+	if err != nil {
+		return user, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// GetByPhone returns the user who verified the phone number. Unverified numbers identify no one.
+func (r *UserRepository) GetByPhone(ctx context.Context, phone string) (user models.User, err error) {
+	const op = "repository.user.postgres.GetByPhone"
+
+	user, err = r.getBy(ctx, sq.Eq{"phone": phone, "phone_verified": true})
+	if err != nil {
+		return user, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) getBy(ctx context.Context, pred sq.Eq) (user models.User, err error) {
+	query := sq.Select(userColumns...).
 		From("users").
-		Where(sq.Eq{"email": email}).
+		Where(pred).
 		PlaceholderFormat(sq.Dollar)
 
 	sqlStr, args, err := query.ToSql()
 	if err != nil {
-		return user, fmt.Errorf("%s: build query: %w", op, err)
+		return user, fmt.Errorf("build query: %w", err)
 	}
 
-	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&user.ID, &user.Email, &user.PassHash); err != nil {
+	user, err = scanUser(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
 		if err == sql.ErrNoRows {
-			return user, fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+			return user, repository.ErrUserNotFound
 		}
-		return user, fmt.Errorf("%s: %w", op, err)
+		return user, err
 	}
 
 	return user, nil
 }
+
+// List returns users matching filter ordered by ID, starting after filter.AfterID.
+func (r *UserRepository) List(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	const op = "repository.user.postgres.List"
+
+	query := sq.Select(userColumns...).
+		From("users").
+		Where(sq.Gt{"id": filter.AfterID}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar)
+
+	if filter.Query != "" {
+		query = query.Where(sq.ILike{"email": "%" + escapeLike(filter.Query) + "%"})
+	}
+	if filter.Disabled != nil {
+		query = query.Where(sq.Eq{"disabled": *filter.Disabled})
+	}
+	if filter.EmailVerified != nil {
+		query = query.Where(sq.Eq{"email_verified": *filter.EmailVerified})
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(uint64(filter.Limit))
+	}
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) SetDisabled(ctx context.Context, userID int64, disabled bool) error {
+	const op = "repository.user.postgres.SetDisabled"
+
+	if err := r.setFlag(ctx, userID, "disabled", disabled); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetEmailVerified sets whether the email of the user is verified. Verifying an email that
+// wasn't verified before publishes an event.
+func (r *UserRepository) SetEmailVerified(ctx context.Context, userID int64, verified bool) error {
+	const op = "repository.user.postgres.SetEmailVerified"
+
+	if !verified {
+		if err := r.setFlag(ctx, userID, "email_verified", false); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var email string
+	var wasVerified bool
+	err = tx.QueryRowContext(ctx, "SELECT email, email_verified FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&email, &wasVerified)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if wasVerified {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET email_verified = true WHERE id = $1", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := enqueueEvent(ctx, tx, models.EventUserEmailVerified, userID, map[string]any{"user_id": userID, "email": email}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetPhoneVerified makes phone the user's verified phone number. It fails with
+// repository.ErrPhoneTaken if another user has verified it.
+func (r *UserRepository) SetPhoneVerified(ctx context.Context, userID int64, phone string) error {
+	const op = "repository.user.postgres.SetPhoneVerified"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var current string
+	var wasVerified bool
+	err = tx.QueryRowContext(ctx, "SELECT phone, phone_verified FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&current, &wasVerified)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if wasVerified && current == phone {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET phone = $2, phone_verified = true WHERE id = $1", userID, phone); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, repository.ErrPhoneTaken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := enqueueEvent(ctx, tx, models.EventUserPhoneVerified, userID, map[string]any{"user_id": userID, "phone": phone}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) SetPasswordResetRequired(ctx context.Context, userID int64, required bool) error {
+	const op = "repository.user.postgres.SetPasswordResetRequired"
+
+	if err := r.setFlag(ctx, userID, "password_reset_required", required); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ChangePassword replaces the user's password and clears a required password reset with it.
+func (r *UserRepository) ChangePassword(ctx context.Context, userID int64, passHash []byte, passAlgo string) error {
+	const op = "repository.user.postgres.ChangePassword"
+
+	query := sq.Update("users").
+		Set("pass_hash", passHash).
+		Set("pass_algo", passAlgo).
+		Set("password_reset_required", false).
+		Where(sq.Eq{"id": userID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := r.execAffectingUser(ctx, query); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) setFlag(ctx context.Context, userID int64, column string, value bool) error {
+	query := sq.Update("users").
+		Set(column, value).
+		Where(sq.Eq{"id": userID}).
+		PlaceholderFormat(sq.Dollar)
+
+	return r.execAffectingUser(ctx, query)
+}
+
+func (r *UserRepository) Delete(ctx context.Context, userID int64) error {
+	const op = "repository.user.postgres.Delete"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var email string
+	if err := tx.QueryRowContext(ctx, "DELETE FROM users WHERE id = $1 RETURNING email", userID).Scan(&email); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := enqueueEvent(ctx, tx, models.EventUserDeleted, userID, map[string]any{"user_id": userID, "email": email}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) execAffectingUser(ctx context.Context, query sq.Sqlizer) error {
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return repository.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func scanUser(row sqlx.ColScanner) (user models.User, err error) {
+	err = row.Scan(
+		&user.ID, &user.Email, &user.PassHash, &user.PassAlgo,
+		&user.IsAdmin, &user.Disabled, &user.EmailVerified, &user.PasswordResetRequired,
+		&user.Phone, &user.PhoneVerified,
+	)
+	return user, err
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Import inserts a user with an externally produced password hash. Existing emails are left untouched
+// and reported with created == false, so repeated imports of the same file are no-ops.
+func (r *UserRepository) Import(ctx context.Context, email string, passHash []byte, passAlgo string) (userID int64, created bool, err error) {
+	const op = "repository.user.postgres.Import"
+
+	query := sq.Insert("users").
+		Columns("email", "pass_hash", "pass_algo").
+		Values(email, passHash, passAlgo).
+		Suffix("ON CONFLICT (email) WHERE email <> '' DO NOTHING RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx, sqlStr, args...).Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	payload := map[string]any{"user_id": userID, "email": email, "imported": true}
+	if err := enqueueEvent(ctx, tx, models.EventUserRegistered, userID, payload); err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, true, nil
+}
+
+func (r *UserRepository) UpdatePassHash(ctx context.Context, userID int64, passHash []byte, passAlgo string) error {
+	const op = "repository.user.postgres.UpdatePassHash"
+
+	query := sq.Update("users").
+		Set("pass_hash", passHash).
+		Set("pass_algo", passAlgo).
+		Where(sq.Eq{"id": userID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := r.execAffectingUser(ctx, query); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
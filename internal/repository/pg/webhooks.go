@@ -0,0 +1,379 @@
+package pg
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type WebhookRepository struct {
+	db     *sqlx.DB
+	cipher SecretCipher
+}
+
+func NewWebhookRepository(db *sqlx.DB, cipher SecretCipher) *WebhookRepository {
+	return &WebhookRepository{db: db, cipher: cipher}
+}
+
+var webhookColumns = []string{"id", "app_id", "url", "event_types", "disabled", "created_at"}
+
+var deliveryColumns = []string{
+	"d.id", "d.webhook_id", "d.event_id", "e.type", "d.status", "d.attempts", "d.next_attempt_at",
+	"d.last_status_code", "d.last_error", "d.delivered_at", "d.created_at",
+}
+
+// Create stores the webhook with its secret encrypted.
+func (r *WebhookRepository) Create(ctx context.Context, wh models.Webhook) (int64, error) {
+	const op = "repository.webhook.postgres.Create"
+
+	query := sq.Insert("webhooks").
+		Columns("app_id", "url", "secret", "event_types").
+		Values(wh.AppID, wh.URL, r.cipher.Seal([]byte(wh.Secret)), pq.Array(orEmpty(wh.EventTypes))).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var id int64
+	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return 0, fmt.Errorf("%s: %w", op, repository.ErrAppNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// Get returns the webhook without its secret.
+func (r *WebhookRepository) Get(ctx context.Context, webhookID int64) (models.Webhook, error) {
+	const op = "repository.webhook.postgres.Get"
+
+	query := sq.Select(webhookColumns...).
+		From("webhooks").
+		Where(sq.Eq{"id": webhookID}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	wh, err := scanWebhook(r.db.QueryRowxContext(ctx, sqlStr, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Webhook{}, fmt.Errorf("%s: %w", op, repository.ErrWebhookNotFound)
+		}
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return wh, nil
+}
+
+// List returns the webhooks of the app without their secrets.
+func (r *WebhookRepository) List(ctx context.Context, appID int) ([]models.Webhook, error) {
+	const op = "repository.webhook.postgres.List"
+
+	query := sq.Select(webhookColumns...).
+		From("webhooks").
+		Where(sq.Eq{"app_id": appID}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		wh, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		webhooks = append(webhooks, wh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhooks, nil
+}
+
+// Delete removes the webhook together with its deliveries.
+func (r *WebhookRepository) Delete(ctx context.Context, webhookID int64) error {
+	const op = "repository.webhook.postgres.Delete"
+
+	query := sq.Delete("webhooks").
+		Where(sq.Eq{"id": webhookID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrWebhookNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the latest deliveries of the webhook, optionally only those with the given status.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error) {
+	const op = "repository.webhook.postgres.ListDeliveries"
+
+	query := sq.Select(deliveryColumns...).
+		From("webhook_deliveries d").
+		Join("outbox_events e ON e.id = d.event_id").
+		Where(sq.Eq{"d.webhook_id": webhookID}).
+		OrderBy("d.id DESC").
+		PlaceholderFormat(sq.Dollar)
+
+	if status != "" {
+		query = query.Where(sq.Eq{"d.status": status})
+	}
+	if limit > 0 {
+		query = query.Limit(uint64(limit))
+	}
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deliveries, nil
+}
+
+// Replay queues deliveries of the webhook to be sent again from scratch. Without IDs every
+// dead-lettered delivery of the webhook is replayed. It returns how many deliveries were queued.
+func (r *WebhookRepository) Replay(ctx context.Context, webhookID int64, deliveryIDs []int64) (int64, error) {
+	const op = "repository.webhook.postgres.Replay"
+
+	query := sq.Update("webhook_deliveries").
+		Set("status", models.DeliveryPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", sq.Expr("now()")).
+		Set("last_error", "").
+		Where(sq.Eq{"webhook_id": webhookID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if len(deliveryIDs) > 0 {
+		query = query.Where("id = ANY(?)", pq.Array(deliveryIDs))
+	} else {
+		query = query.Where(sq.Eq{"status": models.DeliveryDead})
+	}
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := r.db.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// FanOut creates a delivery for every enabled webhook subscribed to each event not dispatched
+// yet and marks those events dispatched. It handles at most limit events and returns how many.
+// Concurrent dispatchers skip the events another one is working on.
+func (r *WebhookRepository) FanOut(ctx context.Context, limit int) (int64, error) {
+	const op = "repository.webhook.postgres.FanOut"
+
+	res, err := r.db.ExecContext(ctx, `
+		WITH events AS (
+			SELECT id, type FROM outbox_events
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), fanned AS (
+			INSERT INTO webhook_deliveries (webhook_id, event_id)
+			SELECT w.id, e.id FROM events e
+			JOIN webhooks w ON NOT w.disabled AND (cardinality(w.event_types) = 0 OR e.type = ANY(w.event_types))
+			ON CONFLICT DO NOTHING
+		)
+		UPDATE outbox_events SET dispatched_at = now() WHERE id IN (SELECT id FROM events)`,
+		limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// ClaimDue returns up to limit pending deliveries that are due and postpones them by lease, so
+// no other dispatcher picks them up while they are being sent. A dispatcher that dies while
+// sending leaves them to be retried once the lease is over.
+func (r *WebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.DueDelivery, error) {
+	const op = "repository.webhook.postgres.ClaimDue"
+
+	rows, err := r.db.QueryxContext(ctx, `
+		WITH claimed AS (
+			UPDATE webhook_deliveries SET next_attempt_at = now() + $2 * interval '1 millisecond'
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= now()
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, webhook_id, event_id, attempts
+		)
+		SELECT c.id, c.attempts, w.id, w.app_id, w.url, w.secret, e.id, e.type, e.user_id, e.payload, e.created_at
+		FROM claimed c
+		JOIN webhooks w ON w.id = c.webhook_id
+		JOIN outbox_events e ON e.id = c.event_id
+		ORDER BY c.id`,
+		limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var due []models.DueDelivery
+	for rows.Next() {
+		var (
+			d       models.DueDelivery
+			secret  []byte
+			userID  sql.NullInt64
+			payload []byte
+		)
+		err := rows.Scan(
+			&d.ID, &d.Attempts, &d.Webhook.ID, &d.Webhook.AppID, &d.Webhook.URL, &secret,
+			&d.Event.ID, &d.Event.Type, &userID, &payload, &d.Event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		plain, err := r.cipher.Open(secret)
+		if err != nil {
+			return nil, fmt.Errorf("%s: decrypt webhook secret: %w", op, err)
+		}
+		d.Webhook.Secret = string(plain)
+		d.Event.UserID = userID.Int64
+
+		if err := json.Unmarshal(payload, &d.Event.Payload); err != nil {
+			return nil, fmt.Errorf("%s: decode event payload: %w", op, err)
+		}
+
+		due = append(due, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return due, nil
+}
+
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, deliveryID int64, statusCode int) error {
+	const op = "repository.webhook.postgres.MarkDelivered"
+
+	query := sq.Update("webhook_deliveries").
+		Set("status", models.DeliveryDelivered).
+		Set("attempts", sq.Expr("attempts + 1")).
+		Set("last_status_code", statusCode).
+		Set("last_error", "").
+		Set("delivered_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": deliveryID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrWebhookNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt. The delivery is tried again at retryAt, or dead-lettered
+// if retryAt is zero. A zero statusCode means no response was received.
+func (r *WebhookRepository) MarkFailed(ctx context.Context, deliveryID int64, statusCode int, lastErr string, retryAt time.Time) error {
+	const op = "repository.webhook.postgres.MarkFailed"
+
+	query := sq.Update("webhook_deliveries").
+		Set("attempts", sq.Expr("attempts + 1")).
+		Set("last_status_code", positive(statusCode)).
+		Set("last_error", lastErr).
+		Where(sq.Eq{"id": deliveryID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if retryAt.IsZero() {
+		query = query.Set("status", models.DeliveryDead)
+	} else {
+		query = query.Set("next_attempt_at", retryAt)
+	}
+
+	if err := execAffecting(ctx, r.db, query, repository.ErrWebhookNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func scanWebhook(row sqlx.ColScanner) (wh models.Webhook, err error) {
+	err = row.Scan(&wh.ID, &wh.AppID, &wh.URL, pq.Array(&wh.EventTypes), &wh.Disabled, &wh.CreatedAt)
+	return wh, err
+}
+
+func scanDelivery(row sqlx.ColScanner) (d models.WebhookDelivery, err error) {
+	var statusCode sql.NullInt64
+	var deliveredAt sql.NullTime
+
+	err = row.Scan(
+		&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&statusCode, &d.LastError, &deliveredAt, &d.CreatedAt,
+	)
+	if err != nil {
+		return d, err
+	}
+
+	d.LastStatusCode = int(statusCode.Int64)
+	d.DeliveredAt = deliveredAt.Time
+
+	return d, nil
+}
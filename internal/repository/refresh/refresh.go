@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"auth/internal/domain/sessions"
@@ -19,18 +20,35 @@ func New(rdb *redis.Client) *RefreshStorage {
 	return &RefreshStorage{rdb: rdb}
 }
 
+func tokenKey(token string) string {
+	return "refresh:" + token
+}
+
+// userKey holds the set of refresh tokens issued to a user, so they can all be revoked at once.
+func userKey(userID int64) string {
+	return "refresh_user:" + strconv.FormatInt(userID, 10)
+}
+
 func (s *RefreshStorage) Save(ctx context.Context, token string, session sessions.RefreshSession) error {
 	data, err := json.Marshal(session)
 	if err != nil {
 		return err
 	}
-	key := "refresh:" + token
-	return s.rdb.Set(ctx, key, data, time.Until(session.ExpiresAt)).Err()
+
+	ttl := time.Until(session.ExpiresAt)
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, tokenKey(token), data, ttl)
+		pipe.SAdd(ctx, userKey(session.UserID), token)
+		pipe.ExpireGT(ctx, userKey(session.UserID), ttl)
+		pipe.ExpireNX(ctx, userKey(session.UserID), ttl)
+		return nil
+	})
+	return err
 }
 
 func (s *RefreshStorage) Get(ctx context.Context, token string) (*sessions.RefreshSession, error) {
-	key := "refresh:" + token
-	data, err := s.rdb.Get(ctx, key).Bytes()
+	data, err := s.rdb.Get(ctx, tokenKey(token)).Bytes()
 	if err == redis.Nil {
 		return nil, fmt.Errorf("refresh token not found")
 	} else if err != nil {
@@ -45,6 +63,76 @@ func (s *RefreshStorage) Get(ctx context.Context, token string) (*sessions.Refre
 }
 
 func (s *RefreshStorage) Delete(ctx context.Context, token string) error {
-	key := "refresh:" + token
-	return s.rdb.Del(ctx, key).Err()
+	session, err := s.Get(ctx, token)
+	if err != nil {
+		return s.rdb.Del(ctx, tokenKey(token)).Err()
+	}
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, tokenKey(token))
+		pipe.SRem(ctx, userKey(session.UserID), token)
+		return nil
+	})
+	return err
+}
+
+func (s *RefreshStorage) DeleteAllForUser(ctx context.Context, userID int64) error {
+	tokens, err := s.rdb.SMembers(ctx, userKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(tokens)+1)
+	for _, token := range tokens {
+		keys = append(keys, tokenKey(token))
+	}
+	keys = append(keys, userKey(userID))
+
+	return s.rdb.Del(ctx, keys...).Err()
+}
+
+// ListForUser returns the live sessions of a user keyed by refresh token.
+// Tokens that have expired in the meantime are dropped from the user's index.
+func (s *RefreshStorage) ListForUser(ctx context.Context, userID int64) (map[string]sessions.RefreshSession, error) {
+	tokens, err := s.rdb.SMembers(ctx, userKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return map[string]sessions.RefreshSession{}, nil
+	}
+
+	keys := make([]string, len(tokens))
+	for i, token := range tokens {
+		keys[i] = tokenKey(token)
+	}
+
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]sessions.RefreshSession, len(tokens))
+	var stale []any
+	for i, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			stale = append(stale, tokens[i])
+			continue
+		}
+
+		var session sessions.RefreshSession
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+		}
+		res[tokens[i]] = session
+	}
+
+	if len(stale) > 0 {
+		if err := s.rdb.SRem(ctx, userKey(userID), stale...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
 }
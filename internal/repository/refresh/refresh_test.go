@@ -79,3 +79,54 @@ func TestRefreshStorage_SaveGetDelete(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestRefreshStorage_DeleteAllForUser(t *testing.T) {
+	ctx := context.Background()
+	session := sessions.RefreshSession{
+		UserID:    42,
+		AppID:     1,
+		ExpiresAt: time.Now().Add(1 * time.Hour),
+	}
+	other := sessions.RefreshSession{
+		UserID:    43,
+		AppID:     1,
+		ExpiresAt: time.Now().Add(1 * time.Hour),
+	}
+
+	assert.NoError(t, storage.Save(ctx, "user42-a", session))
+	assert.NoError(t, storage.Save(ctx, "user42-b", session))
+	assert.NoError(t, storage.Save(ctx, "user43-a", other))
+
+	err := storage.DeleteAllForUser(ctx, 42)
+	assert.NoError(t, err)
+
+	_, err = storage.Get(ctx, "user42-a")
+	assert.Error(t, err)
+	_, err = storage.Get(ctx, "user42-b")
+	assert.Error(t, err)
+
+	_, err = storage.Get(ctx, "user43-a")
+	assert.NoError(t, err)
+}
+
+func TestRefreshStorage_ListForUser(t *testing.T) {
+	ctx := context.Background()
+	session := sessions.RefreshSession{
+		UserID:    44,
+		AppID:     2,
+		ExpiresAt: time.Now().Add(1 * time.Hour),
+	}
+
+	assert.NoError(t, storage.Save(ctx, "user44-a", session))
+	assert.NoError(t, storage.Save(ctx, "user44-b", session))
+	assert.NoError(t, rdb.Del(ctx, "refresh:user44-b").Err())
+
+	got, err := storage.ListForUser(ctx, 44)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, 2, got["user44-a"].AppID)
+
+	members, err := rdb.SMembers(ctx, "refresh_user:44").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user44-a"}, members)
+}
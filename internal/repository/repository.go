@@ -5,5 +5,51 @@ import "errors"
 var (
 	ErrUserExists   = errors.New("user already exists")
 	ErrUserNotFound = errors.New("user not found")
+	ErrPhoneTaken   = errors.New("phone number is verified by another user")
 	ErrAppNotFound  = errors.New("app not found")
+	ErrAppExists    = errors.New("app already exists")
+
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrRoleExists         = errors.New("role already exists")
+	ErrPermissionNotFound = errors.New("permission not found")
+	ErrPermissionExists   = errors.New("permission already exists")
+
+	ErrNamespaceNotFound = errors.New("namespace not found")
+
+	ErrOrgNotFound        = errors.New("organization not found")
+	ErrOrgExists          = errors.New("organization already exists")
+	ErrMembershipNotFound = errors.New("membership not found")
+	ErrMembershipExists   = errors.New("membership already exists")
+	ErrLastOwner          = errors.New("organization must keep at least one owner")
+
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrInvitationExists   = errors.New("invitation already exists")
+
+	ErrTokenNotFound = errors.New("token not found")
+
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	ErrServiceAccountExists   = errors.New("service account already exists")
+	ErrKeyNotFound            = errors.New("key not found")
+
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	ErrProviderNotFound = errors.New("identity provider not found")
+	ErrProviderExists   = errors.New("identity provider already exists")
+	ErrIdentityNotFound = errors.New("identity not found")
+	ErrIdentityExists   = errors.New("identity already linked")
+	ErrStateNotFound    = errors.New("login state not found")
+
+	ErrSSOSessionNotFound = errors.New("sso session not found")
+	ErrLogoutNotFound     = errors.New("logout not found")
+
+	ErrServiceProviderNotFound = errors.New("service provider not found")
+	ErrServiceProviderExists   = errors.New("service provider already exists")
+
+	ErrGroupNotFound = errors.New("group not found")
+	ErrGroupExists   = errors.New("group already exists")
+	// ErrVersionMismatch is returned for updates of a resource that changed since it was read.
+	ErrVersionMismatch = errors.New("resource was modified concurrently")
+
+	ErrInvalidOffset = errors.New("invalid feed offset")
+	ErrOffsetExpired = errors.New("feed offset is no longer available")
 )
@@ -0,0 +1,210 @@
+package revocations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const streamKey = "revocations"
+
+// startOffset is the offset before the first entry of the stream.
+const startOffset = "0-0"
+
+// Feed keeps recent revocations in a Redis stream. Stream IDs serve as offsets, so a reader that
+// reconnects can carry on where it stopped as long as its offset hasn't been trimmed away.
+type Feed struct {
+	rdb    *redis.Client
+	maxLen int64
+}
+
+// New creates a feed that keeps about maxLen of the latest revocations.
+func New(rdb *redis.Client, maxLen int64) *Feed {
+	return &Feed{rdb: rdb, maxLen: maxLen}
+}
+
+func (f *Feed) Publish(ctx context.Context, r models.Revocation) error {
+	const op = "repository.revocations.redis.Publish"
+
+	err := f.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey,
+		MaxLen: f.maxLen,
+		Approx: true,
+		Values: map[string]any{
+			"kind":               r.Kind,
+			"user_id":            r.UserID,
+			"app_id":             r.AppID,
+			"service_account_id": r.ServiceAccountID,
+			"session_id":         r.SessionID,
+			"token_id":           r.TokenID,
+			"key_id":             r.KeyID,
+			"revoked_at":         r.RevokedAt.UnixMilli(),
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Latest returns the offset of the newest entry, so reading from it only yields what comes next.
+func (f *Feed) Latest(ctx context.Context) (string, error) {
+	const op = "repository.revocations.redis.Latest"
+
+	info, err := f.rdb.XInfoStream(ctx, streamKey).Result()
+	if err != nil {
+		if isNoStream(err) {
+			return startOffset, nil
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return info.LastGeneratedID, nil
+}
+
+// Check returns ErrOffsetExpired if entries after offset have already been trimmed, and
+// ErrInvalidOffset if it isn't an offset at all.
+func (f *Feed) Check(ctx context.Context, offset string) error {
+	const op = "repository.revocations.redis.Check"
+
+	want, err := parseOffset(offset)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	info, err := f.rdb.XInfoStream(ctx, streamKey).Result()
+	if err != nil {
+		if isNoStream(err) {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Trimming records the newest ID it removed. Anything newer than offset removed means
+	// the reader would miss it. Redis before 7.0 doesn't track it, so nothing can be told there.
+	if info.MaxDeletedEntryID == "" {
+		return nil
+	}
+	trimmed, err := parseOffset(info.MaxDeletedEntryID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if want.less(trimmed) {
+		return fmt.Errorf("%s: %w", op, repository.ErrOffsetExpired)
+	}
+
+	return nil
+}
+
+// Read returns up to count entries after offset, waiting up to block for the first one to arrive.
+// It returns no entries if none did.
+func (f *Feed) Read(ctx context.Context, offset string, count int, block time.Duration) ([]models.Revocation, error) {
+	const op = "repository.revocations.redis.Read"
+
+	streams, err := f.rdb.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{streamKey, offset},
+		Count:   int64(count),
+		Block:   block,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var res []models.Revocation
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			r, err := parseEntry(msg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: entry %s: %w", op, msg.ID, err)
+			}
+			res = append(res, r)
+		}
+	}
+
+	return res, nil
+}
+
+func parseEntry(msg redis.XMessage) (models.Revocation, error) {
+	str := func(field string) string {
+		v, _ := msg.Values[field].(string)
+		return v
+	}
+	num := func(field string) (int64, error) {
+		v := str(field)
+		if v == "" {
+			return 0, nil
+		}
+		return strconv.ParseInt(v, 10, 64)
+	}
+
+	r := models.Revocation{
+		Offset:    msg.ID,
+		Kind:      str("kind"),
+		SessionID: str("session_id"),
+		TokenID:   str("token_id"),
+		KeyID:     str("key_id"),
+	}
+
+	var err error
+	if r.UserID, err = num("user_id"); err != nil {
+		return r, err
+	}
+	appID, err := num("app_id")
+	if err != nil {
+		return r, err
+	}
+	r.AppID = int(appID)
+	if r.ServiceAccountID, err = num("service_account_id"); err != nil {
+		return r, err
+	}
+	revokedAt, err := num("revoked_at")
+	if err != nil {
+		return r, err
+	}
+	r.RevokedAt = time.UnixMilli(revokedAt).UTC()
+
+	return r, nil
+}
+
+// streamID is a parsed Redis stream ID, which orders by time and then sequence.
+type streamID struct {
+	ms, seq uint64
+}
+
+func parseOffset(offset string) (streamID, error) {
+	msStr, seqStr, ok := strings.Cut(offset, "-")
+	if !ok {
+		return streamID{}, repository.ErrInvalidOffset
+	}
+
+	ms, err := strconv.ParseUint(msStr, 10, 64)
+	if err != nil {
+		return streamID{}, repository.ErrInvalidOffset
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil {
+		return streamID{}, repository.ErrInvalidOffset
+	}
+
+	return streamID{ms: ms, seq: seq}, nil
+}
+
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || id.ms == other.ms && id.seq < other.seq
+}
+
+func isNoStream(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "no such key")
+}
@@ -0,0 +1,133 @@
+package revocations_test
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/repository/revocations"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+var rdb *redis.Client
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:7-alpine",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForListeningPort("6379/tcp").WithStartupTimeout(10 * time.Second),
+	}
+
+	redisContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		log.Fatalf("could not start redis container: %v", err)
+	}
+	defer redisContainer.Terminate(ctx)
+
+	host, _ := redisContainer.Host(ctx)
+	port, _ := redisContainer.MappedPort(ctx, "6379")
+
+	rdb = redis.NewClient(&redis.Options{
+		Addr: host + ":" + port.Port(),
+	})
+
+	m.Run()
+}
+
+func TestFeed(t *testing.T) {
+	ctx := context.Background()
+	assert.NoError(t, rdb.FlushDB(ctx).Err())
+
+	feed := revocations.New(rdb, 1000)
+
+	t.Run("empty feed", func(t *testing.T) {
+		offset, err := feed.Latest(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, feed.Check(ctx, offset))
+
+		list, err := feed.Read(ctx, offset, 10, 10*time.Millisecond)
+		assert.NoError(t, err)
+		assert.Empty(t, list)
+	})
+
+	t.Run("publish and resume", func(t *testing.T) {
+		start, err := feed.Latest(ctx)
+		assert.NoError(t, err)
+
+		revokedAt := time.Now().Truncate(time.Millisecond).UTC()
+		assert.NoError(t, feed.Publish(ctx, models.Revocation{Kind: models.RevokedSession, UserID: 7, AppID: 2, SessionID: "sid-1", RevokedAt: revokedAt}))
+		assert.NoError(t, feed.Publish(ctx, models.Revocation{Kind: models.RevokedToken, UserID: 7, TokenID: "pat_3", RevokedAt: revokedAt}))
+
+		list, err := feed.Read(ctx, start, 1, time.Second)
+		assert.NoError(t, err)
+		if !assert.Len(t, list, 1) {
+			return
+		}
+		assert.Equal(t, models.RevokedSession, list[0].Kind)
+		assert.Equal(t, int64(7), list[0].UserID)
+		assert.Equal(t, 2, list[0].AppID)
+		assert.Equal(t, "sid-1", list[0].SessionID)
+		assert.Equal(t, revokedAt, list[0].RevokedAt)
+
+		// Resuming from the first entry yields only the second.
+		rest, err := feed.Read(ctx, list[0].Offset, 10, time.Second)
+		assert.NoError(t, err)
+		if !assert.Len(t, rest, 1) {
+			return
+		}
+		assert.Equal(t, "pat_3", rest[0].TokenID)
+
+		latest, err := feed.Latest(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, rest[0].Offset, latest)
+	})
+
+	t.Run("blocks until published", func(t *testing.T) {
+		latest, err := feed.Latest(ctx)
+		assert.NoError(t, err)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_ = feed.Publish(ctx, models.Revocation{Kind: models.RevokedUser, UserID: 9, RevokedAt: time.Now()})
+		}()
+
+		list, err := feed.Read(ctx, latest, 10, 5*time.Second)
+		assert.NoError(t, err)
+		if assert.Len(t, list, 1) {
+			assert.Equal(t, models.RevokedUser, list[0].Kind)
+		}
+	})
+
+	t.Run("invalid offset", func(t *testing.T) {
+		assert.ErrorIs(t, feed.Check(ctx, "yesterday"), repository.ErrInvalidOffset)
+	})
+}
+
+func TestFeed_Trimmed(t *testing.T) {
+	ctx := context.Background()
+	assert.NoError(t, rdb.FlushDB(ctx).Err())
+
+	feed := revocations.New(rdb, 10)
+
+	for i := range 500 {
+		assert.NoError(t, feed.Publish(ctx, models.Revocation{Kind: models.RevokedUser, UserID: int64(i), RevokedAt: time.Now()}))
+	}
+
+	assert.ErrorIs(t, feed.Check(ctx, "0-1"), repository.ErrOffsetExpired)
+
+	latest, err := feed.Latest(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, feed.Check(ctx, latest))
+}
@@ -0,0 +1,123 @@
+package ssosessions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"auth/internal/domain/sessions"
+	"auth/internal/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Storage keeps SSO sessions keyed by the ID in the browser's cookie. They expire with the
+// session.
+type Storage struct {
+	rdb *redis.Client
+}
+
+func New(rdb *redis.Client) *Storage {
+	return &Storage{rdb: rdb}
+}
+
+func sessionKey(sessionID string) string {
+	return "sso_session:" + sessionID
+}
+
+// userKey holds the set of SSO sessions of a user, so they can all be ended at once.
+func userKey(userID int64) string {
+	return "sso_session_user:" + strconv.FormatInt(userID, 10)
+}
+
+func (s *Storage) Save(ctx context.Context, sessionID string, session sessions.SSOSession) error {
+	const op = "repository.ssosessions.redis.Save"
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ttl := time.Until(session.ExpiresAt)
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, sessionKey(sessionID), data, ttl)
+		pipe.SAdd(ctx, userKey(session.UserID), sessionID)
+		pipe.ExpireGT(ctx, userKey(session.UserID), ttl)
+		pipe.ExpireNX(ctx, userKey(session.UserID), ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) Get(ctx context.Context, sessionID string) (sessions.SSOSession, error) {
+	const op = "repository.ssosessions.redis.Get"
+
+	data, err := s.rdb.Get(ctx, sessionKey(sessionID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return sessions.SSOSession{}, fmt.Errorf("%s: %w", op, repository.ErrSSOSessionNotFound)
+		}
+		return sessions.SSOSession{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var session sessions.SSOSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return sessions.SSOSession{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// Delete ends the session. Deleting a session that is already gone is not an error.
+func (s *Storage) Delete(ctx context.Context, sessionID string) error {
+	const op = "repository.ssosessions.redis.Delete"
+
+	session, err := s.Get(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSSOSessionNotFound) {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKey(sessionID))
+		pipe.SRem(ctx, userKey(session.UserID), sessionID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteAllForUser ends every SSO session of the user.
+func (s *Storage) DeleteAllForUser(ctx context.Context, userID int64) error {
+	const op = "repository.ssosessions.redis.DeleteAllForUser"
+
+	sessionIDs, err := s.rdb.SMembers(ctx, userKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys := make([]string, 0, len(sessionIDs)+1)
+	for _, sessionID := range sessionIDs {
+		keys = append(keys, sessionKey(sessionID))
+	}
+	keys = append(keys, userKey(userID))
+
+	if err := s.rdb.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package ssosessions_test
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"auth/internal/domain/sessions"
+	"auth/internal/repository"
+	"auth/internal/repository/ssosessions"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+var rdb *redis.Client
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:7-alpine",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForListeningPort("6379/tcp").WithStartupTimeout(10 * time.Second),
+	}
+
+	redisContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		log.Fatalf("could not start redis container: %v", err)
+	}
+	defer redisContainer.Terminate(ctx)
+
+	host, _ := redisContainer.Host(ctx)
+	port, _ := redisContainer.MappedPort(ctx, "6379")
+
+	rdb = redis.NewClient(&redis.Options{
+		Addr: host + ":" + port.Port(),
+	})
+
+	m.Run()
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	storage := ssosessions.New(rdb)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	session := sessions.SSOSession{UserID: 7, AuthTime: now, AMR: []string{"pwd"}, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	assert.NoError(t, storage.Save(ctx, "sso-1", session))
+
+	got, err := storage.Get(ctx, "sso-1")
+	assert.NoError(t, err)
+	assert.Equal(t, session.UserID, got.UserID)
+	assert.Equal(t, session.AMR, got.AMR)
+	assert.True(t, session.AuthTime.Equal(got.AuthTime))
+
+	ttl, err := rdb.TTL(ctx, "sso_session:sso-1").Result()
+	assert.NoError(t, err)
+	assert.Greater(t, ttl, 59*time.Minute)
+
+	assert.NoError(t, storage.Delete(ctx, "sso-1"))
+	assert.NoError(t, storage.Delete(ctx, "sso-1"))
+
+	_, err = storage.Get(ctx, "sso-1")
+	assert.ErrorIs(t, err, repository.ErrSSOSessionNotFound)
+
+	t.Run("delete all for user", func(t *testing.T) {
+		assert.NoError(t, storage.Save(ctx, "sso-2", session))
+		assert.NoError(t, storage.Save(ctx, "sso-3", session))
+		other := session
+		other.UserID = 8
+		assert.NoError(t, storage.Save(ctx, "sso-4", other))
+
+		assert.NoError(t, storage.DeleteAllForUser(ctx, 7))
+
+		for _, id := range []string{"sso-2", "sso-3"} {
+			_, err := storage.Get(ctx, id)
+			assert.ErrorIs(t, err, repository.ErrSSOSessionNotFound)
+		}
+		_, err := storage.Get(ctx, "sso-4")
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,305 @@
+package admin
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/audit"
+	"auth/pkg/logger"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+const (
+	ActionListUsers          = "admin.list_users"
+	ActionGetUser            = "admin.get_user"
+	ActionDisableUser        = "admin.disable_user"
+	ActionEnableUser         = "admin.enable_user"
+	ActionForceLogout        = "admin.force_logout"
+	ActionForcePasswordReset = "admin.force_password_reset"
+	ActionSetEmailVerified   = "admin.set_email_verified"
+	ActionDeleteUser         = "admin.delete_user"
+)
+
+var (
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+)
+
+type UserRepository interface {
+	GetByID(ctx context.Context, userID int64) (user models.User, err error)
+	List(ctx context.Context, filter models.UserFilter) ([]models.User, error)
+	SetDisabled(ctx context.Context, userID int64, disabled bool) error
+	SetEmailVerified(ctx context.Context, userID int64, verified bool) error
+	SetPasswordResetRequired(ctx context.Context, userID int64, required bool) error
+	Delete(ctx context.Context, userID int64) error
+}
+
+type SessionStorage interface {
+	DeleteAllForUser(ctx context.Context, userID int64) error
+}
+
+type RevocationPublisher interface {
+	Publish(ctx context.Context, r models.Revocation) error
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+	Query(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+	Verify(ctx context.Context) (checked int, brokenID int64, err error)
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+type AdminService struct {
+	log           *slog.Logger
+	userRepo      UserRepository
+	sessions      SessionStorage
+	ssoSessions   SessionStorage
+	audit         AuditRepository
+	impersonator  Impersonator
+	notifier      Notifier
+	impersonation ImpersonationPolicy
+	revocations   RevocationPublisher
+}
+
+func New(log *slog.Logger, userRepo UserRepository, sessions, ssoSessions SessionStorage, audit AuditRepository, impersonator Impersonator, notifier Notifier, impersonation ImpersonationPolicy, revocations RevocationPublisher) *AdminService {
+	return &AdminService{
+		log:           log,
+		userRepo:      userRepo,
+		sessions:      sessions,
+		ssoSessions:   ssoSessions,
+		audit:         audit,
+		impersonator:  impersonator,
+		notifier:      notifier,
+		impersonation: impersonation,
+		revocations:   revocations,
+	}
+}
+
+func (s AdminService) ListUsers(ctx context.Context, actorID int64, filter models.UserFilter, cursor string) (users []models.User, nextCursor string, err error) {
+	const op = "AdminService.ListUsers"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := s.authorize(ctx, actorID); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	filter.AfterID, err = decodeCursor(cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	filter.Limit = min(filter.Limit, maxPageSize)
+
+	// One extra row tells whether another page exists.
+	pageSize := filter.Limit
+	filter.Limit++
+
+	users, err = s.userRepo.List(ctx, filter)
+	if err != nil {
+		log.Error("failed to list users", logger.Err(err))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		nextCursor = encodeCursor(users[pageSize-1].ID)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionListUsers,
+		Details: map[string]any{"query": filter.Query, "results": len(users)},
+	})
+
+	return users, nextCursor, nil
+}
+
+func (s AdminService) GetUser(ctx context.Context, actorID, userID int64) (models.User, error) {
+	const op = "AdminService.GetUser"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int64("userID", userID))
+
+	if err := s.authorize(ctx, actorID); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			log.Error("failed to get user", logger.Err(err))
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: actorID, Action: ActionGetUser, TargetUserID: userID})
+
+	return user, nil
+}
+
+// DisableUser blocks logins and token refreshes for the user and ends their current sessions.
+func (s AdminService) DisableUser(ctx context.Context, actorID, userID int64, reason string) error {
+	const op = "AdminService.DisableUser"
+
+	return s.mutate(ctx, op, actorID, userID, ActionDisableUser, map[string]any{"reason": reason}, func() error {
+		if err := s.userRepo.SetDisabled(ctx, userID, true); err != nil {
+			return err
+		}
+		return s.revokeUser(ctx, userID)
+	})
+}
+
+func (s AdminService) EnableUser(ctx context.Context, actorID, userID int64) error {
+	const op = "AdminService.EnableUser"
+
+	return s.mutate(ctx, op, actorID, userID, ActionEnableUser, nil, func() error {
+		return s.userRepo.SetDisabled(ctx, userID, false)
+	})
+}
+
+func (s AdminService) ForceLogout(ctx context.Context, actorID, userID int64) error {
+	const op = "AdminService.ForceLogout"
+
+	return s.mutate(ctx, op, actorID, userID, ActionForceLogout, nil, func() error {
+		if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+			return err
+		}
+		return s.revokeUser(ctx, userID)
+	})
+}
+
+// ForcePasswordReset ends the user's sessions and keeps them from starting new ones until they
+// change their password with AuthService.ChangePassword.
+func (s AdminService) ForcePasswordReset(ctx context.Context, actorID, userID int64) error {
+	const op = "AdminService.ForcePasswordReset"
+
+	return s.mutate(ctx, op, actorID, userID, ActionForcePasswordReset, nil, func() error {
+		if err := s.userRepo.SetPasswordResetRequired(ctx, userID, true); err != nil {
+			return err
+		}
+		return s.revokeUser(ctx, userID)
+	})
+}
+
+func (s AdminService) SetEmailVerified(ctx context.Context, actorID, userID int64, verified bool) error {
+	const op = "AdminService.SetEmailVerified"
+
+	return s.mutate(ctx, op, actorID, userID, ActionSetEmailVerified, map[string]any{"verified": verified}, func() error {
+		return s.userRepo.SetEmailVerified(ctx, userID, verified)
+	})
+}
+
+func (s AdminService) DeleteUser(ctx context.Context, actorID, userID int64) error {
+	const op = "AdminService.DeleteUser"
+
+	return s.mutate(ctx, op, actorID, userID, ActionDeleteUser, nil, func() error {
+		if err := s.userRepo.Delete(ctx, userID); err != nil {
+			return err
+		}
+		return s.revokeUser(ctx, userID)
+	})
+}
+
+// revokeUser ends the app and SSO sessions of the user and tells resource servers to reject the access
+// tokens already issued. Failing to tell them is only logged, as those tokens expire soon anyway.
+func (s AdminService) revokeUser(ctx context.Context, userID int64) error {
+	if err := s.sessions.DeleteAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.ssoSessions.DeleteAllForUser(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.revocations.Publish(ctx, models.Revocation{Kind: models.RevokedUser, UserID: userID, RevokedAt: time.Now()}); err != nil {
+		s.log.Error("failed to publish revocation", slog.Int64("userID", userID), logger.Err(err))
+	}
+
+	return nil
+}
+
+func (s AdminService) mutate(ctx context.Context, op string, actorID, userID int64, action string, details map[string]any, fn func() error) error {
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int64("userID", userID))
+
+	if err := s.authorize(ctx, actorID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := fn(); err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			log.Error("admin action failed", slog.String("action", action), logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: actorID, Action: action, TargetUserID: userID, Details: details})
+
+	log.Info("admin action performed", slog.String("action", action))
+
+	return nil
+}
+
+func (s AdminService) authorize(ctx context.Context, actorID int64) error {
+	if err := RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		if errors.Is(err, ErrPermissionDenied) {
+			s.log.Warn("non-admin attempted admin action", slog.Int64("actorID", actorID))
+		}
+		return err
+	}
+	return nil
+}
+
+type UserGetter interface {
+	GetByID(ctx context.Context, userID int64) (user models.User, err error)
+}
+
+// RequireAdmin returns ErrPermissionDenied unless actorID belongs to an enabled admin.
+func RequireAdmin(ctx context.Context, users UserGetter, actorID int64) error {
+	actor, err := users.GetByID(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrPermissionDenied
+		}
+		return err
+	}
+
+	if !actor.IsAdmin || actor.Disabled {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
+
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id < 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return id, nil
+}
@@ -0,0 +1,129 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/services/audit"
+	"auth/pkg/logger"
+)
+
+const (
+	ActionQueryAuditLog  = "admin.query_audit_log"
+	ActionVerifyAuditLog = "admin.verify_audit_log"
+	ActionPruneAuditLog  = "audit.prune"
+)
+
+// AuditVerification is the result of checking the hash chain of the audit log.
+type AuditVerification struct {
+	Checked int
+	// BrokenID is the first entry that was changed or follows a removed one, or zero.
+	BrokenID int64
+}
+
+func (v AuditVerification) Intact() bool {
+	return v.BrokenID == 0
+}
+
+// QueryAuditLog lists audit entries matching the filter, newest first. Reading the log is
+// itself recorded.
+func (s AdminService) QueryAuditLog(ctx context.Context, actorID int64, filter models.AuditFilter, cursor string) (entries []models.AuditEntry, nextCursor string, err error) {
+	const op = "AdminService.QueryAuditLog"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := s.authorize(ctx, actorID); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	filter.BeforeID, err = decodeCursor(cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	filter.Limit = min(filter.Limit, maxPageSize)
+
+	// One extra row tells whether another page exists.
+	pageSize := filter.Limit
+	filter.Limit++
+
+	entries, err = s.audit.Query(ctx, filter)
+	if err != nil {
+		log.Error("failed to query audit log", logger.Err(err))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		nextCursor = encodeCursor(entries[pageSize-1].ID)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionQueryAuditLog,
+		Details: map[string]any{"action": filter.Action, "target_user_id": filter.TargetUserID, "results": len(entries)},
+	})
+
+	return entries, nextCursor, nil
+}
+
+// VerifyAuditLog checks that no audit entry was changed or removed, other than by pruning.
+func (s AdminService) VerifyAuditLog(ctx context.Context, actorID int64) (AuditVerification, error) {
+	const op = "AdminService.VerifyAuditLog"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := s.authorize(ctx, actorID); err != nil {
+		return AuditVerification{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	checked, brokenID, err := s.audit.Verify(ctx)
+	if err != nil {
+		log.Error("failed to verify audit log", logger.Err(err))
+		return AuditVerification{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := AuditVerification{Checked: checked, BrokenID: brokenID}
+	if !res.Intact() {
+		log.Warn("audit log hash chain is broken", slog.Int64("entryID", brokenID))
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionVerifyAuditLog,
+		Details: map[string]any{"checked": checked, "broken_id": brokenID},
+	})
+
+	return res, nil
+}
+
+// PruneAuditLog deletes audit entries older than the retention period. It is run by the
+// service itself, so there is no actor to check.
+func (s AdminService) PruneAuditLog(ctx context.Context, retention time.Duration) (int64, error) {
+	const op = "AdminService.PruneAuditLog"
+
+	log := s.log.With(slog.String("op", op))
+
+	before := time.Now().Add(-retention)
+
+	n, err := s.audit.Prune(ctx, before)
+	if err != nil {
+		log.Error("failed to prune audit log", logger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n > 0 {
+		log.Info("pruned audit log", slog.Int64("deleted", n))
+		audit.Record(ctx, log, s.audit, models.AuditEntry{
+			Action:  ActionPruneAuditLog,
+			Details: map[string]any{"before": before.UTC().Format(time.RFC3339), "deleted": n},
+		})
+	}
+
+	return n, nil
+}
@@ -0,0 +1,193 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store keeps users in memory and records the audit entries written, the tokens issued and the
+// notifications sent.
+type store struct {
+	users    map[int64]models.User
+	entries  []models.AuditEntry
+	issued   []issued
+	notified []models.User
+}
+
+type issued struct {
+	actor, target models.User
+	appID         int
+	ttl           time.Duration
+	scopes        []string
+}
+
+func newStore() *store {
+	return &store{users: map[int64]models.User{
+		1: {ID: 1, Email: "root@example.com", IsAdmin: true},
+		2: {ID: 2, Email: "ann@example.com"},
+		3: {ID: 3, Email: "ops@example.com", IsAdmin: true},
+		4: {ID: 4, Email: "gone@example.com", Disabled: true},
+	}}
+}
+
+func (s *store) GetByID(_ context.Context, userID int64) (models.User, error) {
+	u, ok := s.users[userID]
+	if !ok {
+		return models.User{}, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (s *store) List(context.Context, models.UserFilter) ([]models.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *store) SetDisabled(context.Context, int64, bool) error {
+	return errors.New("not implemented")
+}
+
+func (s *store) SetEmailVerified(context.Context, int64, bool) error {
+	return errors.New("not implemented")
+}
+
+func (s *store) SetPasswordResetRequired(context.Context, int64, bool) error {
+	return errors.New("not implemented")
+}
+
+func (s *store) Delete(context.Context, int64) error {
+	return errors.New("not implemented")
+}
+
+func (s *store) DeleteAllForUser(context.Context, int64) error {
+	return errors.New("not implemented")
+}
+
+func (s *store) Record(_ context.Context, entry models.AuditEntry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *store) Query(context.Context, models.AuditFilter) ([]models.AuditEntry, error) {
+	return s.entries, nil
+}
+
+func (s *store) Verify(context.Context) (int, int64, error) {
+	return len(s.entries), 0, nil
+}
+
+func (s *store) Prune(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *store) Publish(context.Context, models.Revocation) error {
+	return nil
+}
+
+func (s *store) IssueImpersonationToken(_ context.Context, actor, target models.User, appID int, ttl time.Duration, scopes []string) (string, time.Time, error) {
+	s.issued = append(s.issued, issued{actor: actor, target: target, appID: appID, ttl: ttl, scopes: scopes})
+	return "impersonation-token", time.Now().Add(ttl), nil
+}
+
+func (s *store) NotifyImpersonation(_ context.Context, user, _ models.User, _ string, _ time.Time) error {
+	s.notified = append(s.notified, user)
+	return nil
+}
+
+func newTestService(policy ImpersonationPolicy) (*AdminService, *store) {
+	st := newStore()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(log, st, st, st, st, st, st, policy, st), st
+}
+
+var testPolicy = ImpersonationPolicy{
+	TTL:        15 * time.Minute,
+	Scopes:     []string{models.ScopeProfile, models.ScopeOrgs},
+	NotifyUser: true,
+}
+
+func TestImpersonate(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService(testPolicy)
+
+	token, expiresAt, err := s.Impersonate(ctx, 1, 2, 7, " ticket 42 ")
+	require.NoError(t, err)
+	assert.Equal(t, "impersonation-token", token)
+	assert.WithinDuration(t, time.Now().Add(testPolicy.TTL), expiresAt, time.Second)
+
+	require.Len(t, st.issued, 1)
+	assert.Equal(t, int64(1), st.issued[0].actor.ID)
+	assert.Equal(t, int64(2), st.issued[0].target.ID)
+	assert.Equal(t, 7, st.issued[0].appID)
+	assert.Equal(t, testPolicy.TTL, st.issued[0].ttl)
+	assert.Equal(t, testPolicy.Scopes, st.issued[0].scopes)
+
+	require.Len(t, st.entries, 1)
+	entry := st.entries[0]
+	assert.Equal(t, ActionImpersonate, entry.Action)
+	assert.Equal(t, int64(1), entry.ActorID)
+	assert.Equal(t, int64(2), entry.TargetUserID)
+	assert.Equal(t, "ticket 42", entry.Details["reason"])
+	assert.Equal(t, 7, entry.Details["app_id"])
+	assert.Equal(t, expiresAt, entry.Details["expires_at"])
+
+	require.Len(t, st.notified, 1)
+	assert.Equal(t, int64(2), st.notified[0].ID)
+}
+
+func TestImpersonateRefused(t *testing.T) {
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		actorID, userID int64
+		reason          string
+		err             error
+	}{
+		"no reason":     {actorID: 1, userID: 2, reason: " ", err: ErrReasonRequired},
+		"not an admin":  {actorID: 2, userID: 4, reason: "ticket", err: ErrPermissionDenied},
+		"self":          {actorID: 1, userID: 1, reason: "ticket", err: ErrCannotImpersonate},
+		"admin":         {actorID: 1, userID: 3, reason: "ticket", err: ErrCannotImpersonate},
+		"disabled user": {actorID: 1, userID: 4, reason: "ticket", err: ErrCannotImpersonate},
+		"unknown user":  {actorID: 1, userID: 99, reason: "ticket", err: repository.ErrUserNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, st := newTestService(testPolicy)
+
+			_, _, err := s.Impersonate(ctx, tc.actorID, tc.userID, 7, tc.reason)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Empty(t, st.issued)
+			assert.Empty(t, st.entries)
+			assert.Empty(t, st.notified)
+		})
+	}
+
+	t.Run("no scopes configured", func(t *testing.T) {
+		policy := testPolicy
+		policy.Scopes = nil
+		s, st := newTestService(policy)
+
+		_, _, err := s.Impersonate(ctx, 1, 2, 7, "ticket")
+		assert.ErrorIs(t, err, ErrNoImpersonationScopes)
+		assert.Empty(t, st.issued)
+	})
+}
+
+func TestImpersonateWithoutNotification(t *testing.T) {
+	policy := testPolicy
+	policy.NotifyUser = false
+	s, st := newTestService(policy)
+
+	_, _, err := s.Impersonate(context.Background(), 1, 2, 7, "ticket")
+	require.NoError(t, err)
+	assert.Empty(t, st.notified)
+	assert.Len(t, st.entries, 1)
+}
@@ -0,0 +1,343 @@
+package apps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/audit"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+)
+
+const (
+	secretBytes    = 32
+	maxGracePeriod = 30 * 24 * time.Hour
+)
+
+const (
+	ActionCreateApp    = "apps.create"
+	ActionUpdateApp    = "apps.update"
+	ActionDeleteApp    = "apps.delete"
+	ActionRotateSecret = "apps.rotate_secret"
+)
+
+var knownGrantTypes = []string{models.GrantPassword, models.GrantRefreshToken, models.GrantJWTBearer, models.GrantFederated, models.GrantSAML, models.GrantPasswordless, models.GrantPhone, models.GrantSSO}
+
+type AppRepository interface {
+	Get(ctx context.Context, appID int) (app models.App, err error)
+	List(ctx context.Context) ([]models.App, error)
+	Create(ctx context.Context, app models.App) (int, error)
+	Update(ctx context.Context, appID int, upd models.AppUpdate) error
+	Delete(ctx context.Context, appID int) error
+	RotateSecret(ctx context.Context, appID int, kind, secret string, grace time.Duration) error
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+type RevocationPublisher interface {
+	Publish(ctx context.Context, r models.Revocation) error
+}
+
+type AppService struct {
+	log         *slog.Logger
+	appRepo     AppRepository
+	userRepo    admin.UserGetter
+	audit       AuditRepository
+	revocations RevocationPublisher
+}
+
+func New(log *slog.Logger, appRepo AppRepository, userRepo admin.UserGetter, audit AuditRepository, revocations RevocationPublisher) *AppService {
+	return &AppService{log: log, appRepo: appRepo, userRepo: userRepo, audit: audit, revocations: revocations}
+}
+
+// CreateApp registers an app and returns it with freshly generated secrets.
+// This is the only time the secrets are returned in plaintext.
+func (s AppService) CreateApp(ctx context.Context, actorID int64, app models.App) (models.App, error) {
+	const op = "AppService.CreateApp"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.String("name", app.Name))
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if app.GrantTypes == nil {
+		app.GrantTypes = slices.Clone(knownGrantTypes)
+	}
+	if err := validate(models.AppUpdate{
+		Name:                  &app.Name,
+		TokenClaims:           app.TokenClaims,
+		IDTokenClaims:         app.IDTokenClaims,
+		RedirectURIs:          app.RedirectURIs,
+		GrantTypes:            app.GrantTypes,
+		AccessTTL:             &app.AccessTTL,
+		RefreshTTL:            &app.RefreshTTL,
+		RefreshIdleTimeout:    &app.RefreshIdleTimeout,
+		MaxSessions:           &app.MaxSessions,
+		BackchannelLogoutURI:  &app.BackchannelLogoutURI,
+		FrontchannelLogoutURI: &app.FrontchannelLogoutURI,
+	}); err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app.AccessSecret = jwt.GenerateRandomToken(secretBytes)
+	app.RefreshSecret = jwt.GenerateRandomToken(secretBytes)
+	app.AccessSecrets = []string{app.AccessSecret}
+
+	id, err := s.appRepo.Create(ctx, app)
+	if err != nil {
+		if !errors.Is(err, repository.ErrAppExists) {
+			log.Error("failed to create app", logger.Err(err))
+		}
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+	app.ID = id
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionCreateApp,
+		Details: map[string]any{"app_id": id, "name": app.Name},
+	})
+
+	log.Info("app created", slog.Int("appID", id))
+
+	return app, nil
+}
+
+// GetApp returns app settings. Secrets are never returned after creation.
+func (s AppService) GetApp(ctx context.Context, actorID int64, appID int) (models.App, error) {
+	const op = "AppService.GetApp"
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := s.appRepo.Get(ctx, appID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrAppNotFound) {
+			s.log.Error("failed to get app", slog.String("op", op), logger.Err(err))
+		}
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return withoutSecrets(app), nil
+}
+
+func (s AppService) ListApps(ctx context.Context, actorID int64) ([]models.App, error) {
+	const op = "AppService.ListApps"
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	apps, err := s.appRepo.List(ctx)
+	if err != nil {
+		s.log.Error("failed to list apps", slog.String("op", op), logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range apps {
+		apps[i] = withoutSecrets(apps[i])
+	}
+
+	return apps, nil
+}
+
+func (s AppService) UpdateApp(ctx context.Context, actorID int64, appID int, upd models.AppUpdate) (models.App, error) {
+	const op = "AppService.UpdateApp"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int("appID", appID))
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := validate(upd); err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.appRepo.Update(ctx, appID, upd); err != nil {
+		if !errors.Is(err, repository.ErrAppNotFound) && !errors.Is(err, repository.ErrAppExists) {
+			log.Error("failed to update app", logger.Err(err))
+		}
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := s.appRepo.Get(ctx, appID)
+	if err != nil {
+		log.Error("failed to get updated app", logger.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionUpdateApp,
+		Details: map[string]any{"app_id": appID},
+	})
+
+	return withoutSecrets(app), nil
+}
+
+func (s AppService) DeleteApp(ctx context.Context, actorID int64, appID int) error {
+	const op = "AppService.DeleteApp"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int("appID", appID))
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.appRepo.Delete(ctx, appID); err != nil {
+		if !errors.Is(err, repository.ErrAppNotFound) {
+			log.Error("failed to delete app", logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionDeleteApp,
+		Details: map[string]any{"app_id": appID},
+	})
+
+	log.Info("app deleted")
+
+	return nil
+}
+
+// RotateSecret issues a new secret of the given kind and returns it. Until grace has passed
+// the previous secret keeps working, which lets clients switch over without downtime.
+func (s AppService) RotateSecret(ctx context.Context, actorID int64, appID int, kind string, grace time.Duration) (string, error) {
+	const op = "AppService.RotateSecret"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int("appID", appID), slog.String("kind", kind))
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if kind != models.SecretKindAccess && kind != models.SecretKindRefresh {
+		return "", fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "kind", Reason: "must be access or refresh"})
+	}
+	if grace < 0 || grace > maxGracePeriod {
+		return "", fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "grace_period", Reason: fmt.Sprintf("must be between 0 and %s", maxGracePeriod)})
+	}
+
+	secret := jwt.GenerateRandomToken(secretBytes)
+
+	if err := s.appRepo.RotateSecret(ctx, appID, kind, secret, grace); err != nil {
+		if !errors.Is(err, repository.ErrAppNotFound) {
+			log.Error("failed to rotate secret", logger.Err(err))
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionRotateSecret,
+		Details: map[string]any{"app_id": appID, "kind": kind, "grace_seconds": int64(grace / time.Second)},
+	})
+	s.revoke(ctx, log, models.Revocation{Kind: models.RevokedKey, AppID: appID, KeyID: kind, RevokedAt: time.Now().Add(grace)})
+
+	log.Info("app secret rotated")
+
+	return secret, nil
+}
+
+func withoutSecrets(app models.App) models.App {
+	app.AccessSecret = ""
+	app.RefreshSecret = ""
+	app.AccessSecrets = nil
+	return app
+}
+
+func validate(upd models.AppUpdate) error {
+	if upd.Name != nil && *upd.Name == "" {
+		return &serviceerr.FieldError{Field: "name", Reason: "must not be empty"}
+	}
+
+	if err := validateClaims("token_claims", upd.TokenClaims); err != nil {
+		return err
+	}
+	if err := validateClaims("id_token_claims", upd.IDTokenClaims); err != nil {
+		return err
+	}
+
+	for _, raw := range upd.RedirectURIs {
+		if err := validateURI("redirect_uris", raw); err != nil {
+			return err
+		}
+	}
+	// Empty logout URIs unregister them.
+	if upd.BackchannelLogoutURI != nil && *upd.BackchannelLogoutURI != "" {
+		if err := validateURI("backchannel_logout_uri", *upd.BackchannelLogoutURI); err != nil {
+			return err
+		}
+	}
+	if upd.FrontchannelLogoutURI != nil && *upd.FrontchannelLogoutURI != "" {
+		if err := validateURI("frontchannel_logout_uri", *upd.FrontchannelLogoutURI); err != nil {
+			return err
+		}
+	}
+
+	for _, grant := range upd.GrantTypes {
+		if !slices.Contains(knownGrantTypes, grant) {
+			return &serviceerr.FieldError{Field: "grant_types", Reason: fmt.Sprintf("unknown grant type %q", grant)}
+		}
+	}
+
+	if upd.AccessTTL != nil && *upd.AccessTTL < 0 {
+		return &serviceerr.FieldError{Field: "access_ttl", Reason: "must not be negative"}
+	}
+	if upd.RefreshTTL != nil && *upd.RefreshTTL < 0 {
+		return &serviceerr.FieldError{Field: "refresh_ttl", Reason: "must not be negative"}
+	}
+	if upd.RefreshIdleTimeout != nil && *upd.RefreshIdleTimeout < 0 {
+		return &serviceerr.FieldError{Field: "refresh_idle_timeout", Reason: "must not be negative"}
+	}
+	if upd.MaxSessions != nil && *upd.MaxSessions < 0 {
+		return &serviceerr.FieldError{Field: "max_sessions", Reason: "must not be negative"}
+	}
+
+	return nil
+}
+
+func validateClaims(field string, claims []string) error {
+	for _, claim := range claims {
+		if !slices.Contains(models.ProfileClaimNames, claim) {
+			return &serviceerr.FieldError{Field: field, Reason: fmt.Sprintf("unknown claim %q", claim)}
+		}
+	}
+	return nil
+}
+
+func validateURI(field, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Host == "" || u.Fragment != "" {
+		return &serviceerr.FieldError{Field: field, Reason: fmt.Sprintf("%q must be an absolute URL without fragment", raw)}
+	}
+	if u.Scheme != "https" && u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1" {
+		return &serviceerr.FieldError{Field: field, Reason: fmt.Sprintf("%q must use https", raw)}
+	}
+	return nil
+}
+
+func (s AppService) revoke(ctx context.Context, log *slog.Logger, r models.Revocation) {
+	if r.RevokedAt.IsZero() {
+		r.RevokedAt = time.Now()
+	}
+	if err := s.revocations.Publish(ctx, r); err != nil {
+		log.Error("failed to publish revocation", slog.String("kind", r.Kind), logger.Err(err))
+	}
+}
@@ -0,0 +1,22 @@
+// Package audit writes the audit entries of services.
+package audit
+
+import (
+	"context"
+	"log/slog"
+
+	"auth/internal/domain/models"
+	"auth/pkg/logger"
+)
+
+type Recorder interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+// Record writes entry. By the time an entry is written the change it records has happened, so
+// a failed write is logged rather than failing the request.
+func Record(ctx context.Context, log *slog.Logger, r Recorder, entry models.AuditEntry) {
+	if err := r.Record(ctx, entry); err != nil {
+		log.Error("failed to write audit entry", slog.String("action", entry.Action), logger.Err(err))
+	}
+}
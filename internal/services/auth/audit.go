@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"auth/internal/domain/models"
+	"auth/internal/domain/sessions"
+	"auth/internal/repository"
+	"auth/internal/services/audit"
+	passwd "auth/pkg/password"
+)
+
+const (
+	ActionRegister           = "auth.register"
+	ActionLogin              = "auth.login"
+	ActionLoginFederated     = "auth.login_federated"
+	ActionLoginPasswordless  = "auth.login_passwordless"
+	ActionLoginPhone         = "auth.login_phone"
+	ActionLoginSSO           = "auth.login_sso"
+	ActionRefresh            = "auth.refresh"
+	ActionStepUp             = "auth.step_up"
+	ActionSwitchOrganization = "auth.switch_organization"
+	ActionAcceptInvitation   = "auth.accept_invitation"
+	ActionEndSession         = "auth.end_session"
+	ActionChangePassword     = "auth.change_password"
+)
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+// auditedReasons are the failures worth naming in the audit log. Anything else is recorded as
+// an internal error, so storage errors don't end up in it.
+var auditedReasons = []error{
+	ErrInvalidCredentials, ErrInvalidToken, ErrUserDisabled, ErrPasswordReset, ErrAppDisabled,
+	ErrGrantNotAllowed, ErrSessionExpired, ErrNotOrgMember, ErrEmailDomain, ErrMFARequired,
+	ErrInvitationRequired, ErrInvalidInvitation, ErrExternalPassword, ErrPasswordUnchanged,
+	repository.ErrUserExists, repository.ErrAppNotFound, repository.ErrOrgNotFound,
+}
+
+// record writes the outcome of an authentication event to the audit log. A non-nil err marks
+// the event as failed. Failing to write is logged and otherwise ignored, like elsewhere.
+func (s AuthService) record(ctx context.Context, log *slog.Logger, entry models.AuditEntry, err error) {
+	if err != nil {
+		entry.Outcome = models.OutcomeFailure
+		if entry.Details == nil {
+			entry.Details = map[string]any{}
+		}
+		entry.Details["reason"] = failureReason(err)
+	}
+
+	audit.Record(ctx, log, s.audit, entry)
+}
+
+// sessionEntry describes an event on a refresh session, which is nil if the token wasn't found.
+func sessionEntry(action string, session *sessions.RefreshSession, details map[string]any) models.AuditEntry {
+	entry := models.AuditEntry{Action: action, Details: details}
+	if session != nil {
+		entry.ActorID = session.UserID
+		entry.TargetUserID = session.UserID
+		entry.AppID = session.AppID
+	}
+	return entry
+}
+
+func failureReason(err error) string {
+	for _, reason := range auditedReasons {
+		if errors.Is(err, reason) {
+			return reason.Error()
+		}
+	}
+
+	var verr *passwd.ValidationError
+	if errors.As(err, &verr) {
+		return "password rejected by policy"
+	}
+
+	return "internal error"
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"auth/internal/domain/models"
@@ -12,52 +13,160 @@ import (
 	"auth/internal/repository"
 	"auth/pkg/jwt"
 	"auth/pkg/logger"
-
-	"golang.org/x/crypto/bcrypt"
+	passwd "auth/pkg/password"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrUserDisabled       = errors.New("user is disabled")
+	ErrPasswordReset      = errors.New("password reset required")
+	ErrAppDisabled        = errors.New("app is disabled")
+	ErrGrantNotAllowed    = errors.New("grant type not allowed for app")
+	ErrSessionExpired     = errors.New("session expired")
+	ErrNotOrgMember       = errors.New("user is not a member of the organization")
+	ErrEmailDomain        = errors.New("email domain is not allowed by the organization")
+	ErrMFARequired        = errors.New("organization requires multi-factor authentication")
+	ErrInvitationRequired = errors.New("registration requires an invitation")
+	ErrInvalidInvitation  = errors.New("invitation is invalid or expired")
+	ErrExternalPassword   = errors.New("password is managed by another credential backend")
+	ErrPasswordUnchanged  = errors.New("new password must differ from the current one")
+	ErrFactorUsed         = errors.New("session is already authenticated with this factor")
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, email string, passHash []byte) (userID int64, err error)
 	Get(ctx context.Context, email string) (user models.User, err error)
+	GetByID(ctx context.Context, userID int64) (user models.User, err error)
+	UpdatePassHash(ctx context.Context, userID int64, passHash []byte, passAlgo string) error
+	// ChangePassword sets the password and clears PasswordResetRequired in one step.
+	ChangePassword(ctx context.Context, userID int64, passHash []byte, passAlgo string) error
+	GetProfile(ctx context.Context, userID int64) (profile models.Profile, err error)
 }
 
 type AppRepository interface {
 	Get(ctx context.Context, appID int) (app models.App, err error)
 }
 
+type RoleRepository interface {
+	UserAuthorization(ctx context.Context, userID int64, appID int) (models.Authorization, error)
+}
+
+type OrgRepository interface {
+	Get(ctx context.Context, orgID int64) (models.Organization, error)
+	GetMembership(ctx context.Context, orgID, userID int64) (models.Membership, error)
+	GetInvitation(ctx context.Context, invitationID int64) (models.Invitation, error)
+	AcceptInvitation(ctx context.Context, invitationID int64, nonce string, passHash []byte) (userID int64, err error)
+}
+
+type PasswordPolicy interface {
+	Validate(password, email string) error
+}
+
 type RefreshStorage interface {
 	Save(ctx context.Context, token string, session sessions.RefreshSession) error
 	Get(ctx context.Context, token string) (*sessions.RefreshSession, error)
 	Delete(ctx context.Context, token string) error
+	ListForUser(ctx context.Context, userID int64) (map[string]sessions.RefreshSession, error)
+}
+
+// SSOSessionStorage holds the browser sessions that sign users in to every app at once.
+type SSOSessionStorage interface {
+	DeleteAllForUser(ctx context.Context, userID int64) error
+}
+
+type RevocationPublisher interface {
+	Publish(ctx context.Context, r models.Revocation) error
 }
 
 type AuthService struct {
-	log                   *slog.Logger
-	userRepo              UserRepository
-	appRepo               AppRepository
-	refreshStorage        RefreshStorage
-	accessTTL, refreshTTL time.Duration
+	log            *slog.Logger
+	userRepo       UserRepository
+	appRepo        AppRepository
+	roleRepo       RoleRepository
+	orgRepo        OrgRepository
+	refreshStorage RefreshStorage
+	ssoSessions    SSOSessionStorage
+	audit          AuditRepository
+	passwordPolicy PasswordPolicy
+	defaults       SessionPolicy
+	invitations    InvitationPolicy
+	revocations    RevocationPublisher
+	backends       []CredentialBackend
 }
 
-func New(log *slog.Logger, userRepo UserRepository, appRepo AppRepository, refreshStorage RefreshStorage, accessTTL, refreshTTL time.Duration) *AuthService {
-	return &AuthService{log: log, userRepo: userRepo, appRepo: appRepo, refreshStorage: refreshStorage, accessTTL: accessTTL, refreshTTL: refreshTTL}
+// New creates the service. Login checks passwords stored with users first and then asks the
+// given backends in order.
+func New(log *slog.Logger, userRepo UserRepository, appRepo AppRepository, roleRepo RoleRepository, orgRepo OrgRepository, refreshStorage RefreshStorage, ssoSessions SSOSessionStorage, audit AuditRepository, passwordPolicy PasswordPolicy, defaults SessionPolicy, invitations InvitationPolicy, revocations RevocationPublisher, backends ...CredentialBackend) *AuthService {
+	backends = append([]CredentialBackend{localBackend{log: log, userRepo: userRepo}}, backends...)
+	return &AuthService{log: log, userRepo: userRepo, appRepo: appRepo, roleRepo: roleRepo, orgRepo: orgRepo, refreshStorage: refreshStorage, ssoSessions: ssoSessions, audit: audit, passwordPolicy: passwordPolicy, defaults: defaults, invitations: invitations, revocations: revocations, backends: backends}
 }
 
-func (s AuthService) Register(ctx context.Context, email, password string) (userID int64, err error) {
+// Register creates an account. When registration is invite-only, globally or for the app
+// registered for, a valid invitation token for the same email is required; with a token the
+// account is created together with the invited membership.
+func (s AuthService) Register(ctx context.Context, email, password string, appID int, invitationToken string) (userID int64, err error) {
 	const op = "AuthService.Register"
 
-	log := s.log.With(slog.String("op", op), slog.String("email", email))
+	log := s.log.With(slog.String("op", op), slog.String("email", email), slog.Int("appID", appID))
+
+	defer func() {
+		s.record(ctx, log, models.AuditEntry{
+			ActorID:      userID,
+			Action:       ActionRegister,
+			TargetUserID: userID,
+			AppID:        appID,
+			Details:      map[string]any{"email": email, "invited": invitationToken != ""},
+		}, err)
+	}()
+
+	if invitationToken == "" {
+		inviteOnly, err := s.inviteOnly(ctx, appID)
+		if err != nil {
+			if !errors.Is(err, repository.ErrAppNotFound) {
+				log.Error("failed to get app", logger.Err(err))
+			}
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if inviteOnly {
+			log.Info("registration without invitation rejected")
+			return 0, fmt.Errorf("%s: %w", op, ErrInvitationRequired)
+		}
+	}
 
-	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err := s.passwordPolicy.Validate(password, email); err != nil {
+		var verr *passwd.ValidationError
+		if errors.As(err, &verr) {
+			log.Info("password rejected by policy", logger.Err(err))
+		} else {
+			log.Error("failed to validate password", logger.Err(err))
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := passwd.Hash(password)
 	if err != nil {
 		log.Error("failed to generate password hash", logger.Err(err))
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if invitationToken != "" {
+		inv, err := s.invitation(ctx, log, invitationToken)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if !strings.EqualFold(inv.Email, email) {
+			log.Info("invitation is for another email", slog.Int64("invitationID", inv.ID))
+			return 0, fmt.Errorf("%s: %w", op, ErrInvalidInvitation)
+		}
+
+		uid, err := s.acceptInvitation(ctx, log, inv, passHash)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		return uid, nil
+	}
+
 	uid, err := s.userRepo.Create(ctx, email, passHash)
 	if err != nil {
 		if errors.Is(err, repository.ErrUserExists) {
@@ -71,59 +180,302 @@ func (s AuthService) Register(ctx context.Context, email, password string) (user
 	return uid, nil
 }
 
-func (s AuthService) Login(ctx context.Context, email, password string, appID int, ip, userAgent string) (accessToken, refreshToken string, err error) {
+// Login authenticates the user for an app. A non-zero orgID scopes the session to that organization.
+func (s AuthService) Login(ctx context.Context, email, password string, appID int, orgID int64, ip, userAgent string) (accessToken, refreshToken string, err error) {
 	const op = "AuthService.Login"
 
-	log := s.log.With(slog.String("op", op), slog.String("email", email), slog.Int("appID", appID))
+	log := s.log.With(slog.String("op", op), slog.String("email", email), slog.Int("appID", appID), slog.Int64("orgID", orgID))
+
+	var (
+		user    models.User
+		backend string
+	)
+	defer func() {
+		s.record(ctx, log, models.AuditEntry{
+			ActorID:      user.ID,
+			Action:       ActionLogin,
+			TargetUserID: user.ID,
+			AppID:        appID,
+			Details:      map[string]any{"email": email, "org_id": orgID, "backend": backend},
+		}, err)
+	}()
+
+	user, backend, err = s.authenticate(ctx, log, email, password)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.Disabled {
+		log.Info("login attempt for disabled user")
+		return "", "", fmt.Errorf("%s: %w", op, ErrUserDisabled)
+	}
 
-	user, err := s.userRepo.Get(ctx, email)
+	accessToken, refreshToken, _, err = s.startSession(ctx, log, user, appID, orgID, models.GrantPassword, authentication{amr: []string{jwt.AMRPassword}}, ip, userAgent)
 	if err != nil {
-		if errors.Is(err, repository.ErrUserNotFound) {
-			log.Warn("user not found", logger.Err(err))
-			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user logged in successfully")
+
+	return accessToken, refreshToken, nil
+}
+
+// LoginFederated starts a session for a user an upstream identity provider has signed in.
+// The federation service has already resolved who the user is.
+func (s AuthService) LoginFederated(ctx context.Context, userID int64, appID int, orgID int64, provider, ip, userAgent string) (accessToken, refreshToken string, err error) {
+	const op = "AuthService.LoginFederated"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID), slog.Int("appID", appID), slog.String("provider", provider))
+
+	defer func() {
+		s.record(ctx, log, models.AuditEntry{
+			ActorID:      userID,
+			Action:       ActionLoginFederated,
+			TargetUserID: userID,
+			AppID:        appID,
+			Details:      map[string]any{"provider": provider, "org_id": orgID},
+		}, err)
+	}()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			log.Error("failed to get user", logger.Err(err))
 		}
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.Disabled {
+		log.Info("login attempt for disabled user")
+		return "", "", fmt.Errorf("%s: %w", op, ErrUserDisabled)
+	}
+
+	accessToken, refreshToken, _, err = s.startSession(ctx, log, user, appID, orgID, models.GrantFederated, authentication{}, ip, userAgent)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user logged in successfully")
 
-		log.Error("failed to get user", logger.Err(err))
+	return accessToken, refreshToken, nil
+}
+
+// LoginPasswordless starts a session for a user who has proven they own their email with a
+// mailed link or code.
+func (s AuthService) LoginPasswordless(ctx context.Context, userID int64, appID int, orgID int64, method, ip, userAgent string) (accessToken, refreshToken string, err error) {
+	const op = "AuthService.LoginPasswordless"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID), slog.Int("appID", appID), slog.String("method", method))
+
+	defer func() {
+		s.record(ctx, log, models.AuditEntry{
+			ActorID:      userID,
+			Action:       ActionLoginPasswordless,
+			TargetUserID: userID,
+			AppID:        appID,
+			Details:      map[string]any{"method": method, "org_id": orgID},
+		}, err)
+	}()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			log.Error("failed to get user", logger.Err(err))
+		}
 		return "", "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
-		log.Info("invalid credentials", logger.Err(err))
-		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	if user.Disabled {
+		log.Info("login attempt for disabled user")
+		return "", "", fmt.Errorf("%s: %w", op, ErrUserDisabled)
 	}
 
-	app, err := s.appRepo.Get(ctx, appID)
+	accessToken, refreshToken, _, err = s.startSession(ctx, log, user, appID, orgID, models.GrantPasswordless, authentication{amr: []string{jwt.AMROTP}}, ip, userAgent)
 	if err != nil {
-		log.Error("faiiled to get app", logger.Err(err))
 		return "", "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	accessToken, err = jwt.GenerateJWT(app.AccessSecret, user.ID, user.Email, app.ID, s.accessTTL)
+	log.Info("user logged in successfully")
+
+	return accessToken, refreshToken, nil
+}
+
+// LoginPhone starts a session for a user who has proven they own their phone number with a
+// texted code.
+func (s AuthService) LoginPhone(ctx context.Context, userID int64, appID int, orgID int64, ip, userAgent string) (accessToken, refreshToken string, err error) {
+	const op = "AuthService.LoginPhone"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID), slog.Int("appID", appID))
+
+	defer func() {
+		s.record(ctx, log, models.AuditEntry{
+			ActorID:      userID,
+			Action:       ActionLoginPhone,
+			TargetUserID: userID,
+			AppID:        appID,
+			Details:      map[string]any{"org_id": orgID},
+		}, err)
+	}()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		log.Error("faiiled to generate access token", logger.Err(err))
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			log.Error("failed to get user", logger.Err(err))
+		}
 		return "", "", fmt.Errorf("%s: %w", op, err)
 	}
 
+	if user.Disabled {
+		log.Info("login attempt for disabled user")
+		return "", "", fmt.Errorf("%s: %w", op, ErrUserDisabled)
+	}
+
+	accessToken, refreshToken, _, err = s.startSession(ctx, log, user, appID, orgID, models.GrantPhone, authentication{amr: []string{jwt.AMRSMS}}, ip, userAgent)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user logged in successfully")
+
+	return accessToken, refreshToken, nil
+}
+
+// LoginSSO starts an app session for the user of an SSO session, who signed in to the SSO
+// earlier. The app session keeps the sign-in's auth_time and methods and ends with the SSO
+// session. Next to the access and refresh tokens the app gets an ID token.
+func (s AuthService) LoginSSO(ctx context.Context, ssoSessionID string, ssoSession sessions.SSOSession, appID int, orgID int64, ip, userAgent string) (accessToken, refreshToken, idToken string, err error) {
+	const op = "AuthService.LoginSSO"
+
+	userID := ssoSession.UserID
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID), slog.Int("appID", appID))
+
+	defer func() {
+		s.record(ctx, log, models.AuditEntry{
+			ActorID:      userID,
+			Action:       ActionLoginSSO,
+			TargetUserID: userID,
+			AppID:        appID,
+			Details:      map[string]any{"org_id": orgID},
+		}, err)
+	}()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			log.Error("failed to get user", logger.Err(err))
+		}
+		return "", "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.Disabled {
+		log.Info("login attempt for disabled user")
+		return "", "", "", fmt.Errorf("%s: %w", op, ErrUserDisabled)
+	}
+
+	authn := authentication{time: ssoSession.AuthTime, amr: ssoSession.AMR, ssoSessionID: ssoSessionID, idToken: true}
+	accessToken, refreshToken, idToken, err = s.startSession(ctx, log, user, appID, orgID, models.GrantSSO, authn, ip, userAgent)
+	if err != nil {
+		return "", "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user logged in successfully")
+
+	return accessToken, refreshToken, idToken, nil
+}
+
+// authentication is how and when the user of a new session signed in.
+type authentication struct {
+	// time is zero for sign-ins happening now.
+	time time.Time
+	// amr leaves out the methods of upstream identity providers, which aren't known.
+	amr []string
+	// ssoSessionID is the SSO session the app session is started from, if any.
+	ssoSessionID string
+	// idToken asks for an ID token next to the access and refresh tokens.
+	idToken bool
+}
+
+// startSession issues the tokens of a new session of a user authenticated as authn says, with an
+// ID token if authn asks for one. Users who have to reset their password get no session,
+// whichever way they signed in.
+func (s AuthService) startSession(ctx context.Context, log *slog.Logger, user models.User, appID int, orgID int64, grant string, authn authentication, ip, userAgent string) (accessToken, refreshToken, idToken string, err error) {
+	if user.PasswordResetRequired {
+		log.Info("login attempt while password reset is required")
+		return "", "", "", ErrPasswordReset
+	}
+
+	app, err := s.appRepo.Get(ctx, appID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrAppNotFound) {
+			log.Error("failed to get app", logger.Err(err))
+		}
+		return "", "", "", err
+	}
+
+	if err := checkApp(app, grant); err != nil {
+		log.Info("login rejected by app settings", logger.Err(err))
+		return "", "", "", err
+	}
+
+	membership, err := s.orgMembership(ctx, log, user, orgID, authn.amr)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	opts, err := s.tokenOptions(ctx, app, user.ID, membership)
+	if err != nil {
+		log.Error("failed to build token claims", logger.Err(err))
+		return "", "", "", err
+	}
+
+	policy := s.policyFor(app)
+	sessionID := jwt.GenerateRandomToken(sessionIDBytes)
+	now := time.Now().UTC()
+	if authn.time.IsZero() {
+		authn.time = now
+	}
+
+	accessToken, err = jwt.GenerateJWT(app.AccessSecret, user.ID, user.Email, app.ID, policy.AccessTTL,
+		append(opts, jwt.WithSessionID(sessionID), jwt.WithAuthentication(authn.time, authn.amr))...)
+	if err != nil {
+		log.Error("faiiled to generate access token", logger.Err(err))
+		return "", "", "", err
+	}
+
+	if authn.idToken {
+		idToken, err = s.idToken(ctx, app, user, policy.AccessTTL, sessionID, authn)
+		if err != nil {
+			log.Error("failed to generate id token", logger.Err(err))
+			return "", "", "", err
+		}
+	}
+
 	refreshToken = jwt.GenerateRandomToken(32)
-	expiresAt := time.Now().Add(s.refreshTTL).UTC()
 
 	session := sessions.RefreshSession{
-		UserID:    user.ID,
-		UserEmail: user.Email,
-		AppID:     app.ID,
-		IP:        ip,
-		UserAgent: userAgent,
-		ExpiresAt: expiresAt,
+		ID:                sessionID,
+		UserID:            user.ID,
+		UserEmail:         user.Email,
+		AppID:             app.ID,
+		OrgID:             orgID,
+		IP:                ip,
+		UserAgent:         userAgent,
+		CreatedAt:         now,
+		AbsoluteExpiresAt: now.Add(policy.RefreshTTL),
+		AuthTime:          authn.time,
+		AMR:               authn.amr,
+		SSOSessionID:      authn.ssoSessionID,
 	}
+	session.ExpiresAt = policy.nextExpiry(now, session.AbsoluteExpiresAt)
 
 	if err := s.refreshStorage.Save(ctx, refreshToken, session); err != nil {
 		log.Error("failed to save refresh token", logger.Err(err))
-		return "", "", fmt.Errorf("%s: %w", op, err)
+		return "", "", "", err
 	}
 
-	log.Info("user logged in successfully")
+	s.enforceSessionLimit(ctx, log, user.ID, app.ID, policy.MaxSessions)
 
-	return accessToken, refreshToken, nil
+	return accessToken, refreshToken, idToken, nil
 }
 
 func (s AuthService) Refresh(ctx context.Context, refreshToken string) (access, refresh string, err error) {
@@ -131,36 +483,147 @@ func (s AuthService) Refresh(ctx context.Context, refreshToken string) (access,
 
 	log := s.log.With(slog.String("op", op))
 
-	session, err := s.refreshStorage.Get(ctx, refreshToken)
+	var session *sessions.RefreshSession
+	defer func() {
+		s.record(ctx, log, sessionEntry(ActionRefresh, session, nil), err)
+	}()
+
+	session, err = s.refreshStorage.Get(ctx, refreshToken)
 	if err != nil {
 		log.Error("failed to get refresh token", logger.Err(err))
 		return "", "", fmt.Errorf("%s: %w", op, err)
 	}
 
+	access, refresh, err = s.rotate(ctx, log, refreshToken, *session, session.OrgID)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return access, refresh, nil
+}
+
+// SwitchOrganization exchanges a refresh token for tokens scoped to another organization of
+// the same user. An orgID of zero drops the organization scope. The old refresh token stops working.
+func (s AuthService) SwitchOrganization(ctx context.Context, refreshToken string, orgID int64) (access, refresh string, err error) {
+	const op = "AuthService.SwitchOrganization"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("orgID", orgID))
+
+	var session *sessions.RefreshSession
+	defer func() {
+		s.record(ctx, log, sessionEntry(ActionSwitchOrganization, session, map[string]any{"org_id": orgID}), err)
+	}()
+
+	session, err = s.refreshStorage.Get(ctx, refreshToken)
+	if err != nil {
+		log.Error("failed to get refresh token", logger.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	access, refresh, err = s.rotate(ctx, log, refreshToken, *session, orgID)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("organization switched", slog.Int64("userID", session.UserID), slog.Int64("fromOrgID", session.OrgID))
+
+	return access, refresh, nil
+}
+
+// rotate replaces refreshToken with a new token pair for the session, scoped to orgID.
+func (s AuthService) rotate(ctx context.Context, log *slog.Logger, refreshToken string, session sessions.RefreshSession, orgID int64) (access, refresh string, err error) {
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		log.Error("failed to get session user", logger.Err(err))
+		return "", "", err
+	}
+
+	if user.Disabled {
+		log.Info("refresh attempt for disabled user", slog.Int64("userID", user.ID))
+		if err := s.refreshStorage.Delete(ctx, refreshToken); err != nil {
+			log.Error("failed to delete refresh token", logger.Err(err))
+		}
+		return "", "", ErrUserDisabled
+	}
+
+	if user.PasswordResetRequired {
+		log.Info("refresh attempt while password reset is required", slog.Int64("userID", user.ID))
+		if err := s.refreshStorage.Delete(ctx, refreshToken); err != nil {
+			log.Error("failed to delete refresh token", logger.Err(err))
+		}
+		return "", "", ErrPasswordReset
+	}
+
 	app, err := s.appRepo.Get(ctx, session.AppID)
 	if err != nil {
 		log.Error("failed to get app", logger.Err(err))
-		return "", "", fmt.Errorf("%s: app not found", op)
+		return "", "", fmt.Errorf("app not found")
 	}
 
-	accessToken, err := jwt.GenerateJWT(app.AccessSecret, session.UserID, session.UserEmail, app.ID, s.accessTTL)
+	if err := checkApp(app, models.GrantRefreshToken); err != nil {
+		log.Info("refresh rejected by app settings", logger.Err(err))
+		return "", "", err
+	}
+
+	membership, err := s.orgMembership(ctx, log, user, orgID, session.AMR)
+	if err != nil {
+		return "", "", err
+	}
+
+	opts, err := s.tokenOptions(ctx, app, session.UserID, membership)
+	if err != nil {
+		log.Error("failed to build token claims", logger.Err(err))
+		return "", "", err
+	}
+	opts = append(opts, jwt.WithSessionID(session.ID))
+	// Sessions started before authentications were recorded have no auth_time to give.
+	if !session.AuthTime.IsZero() {
+		opts = append(opts, jwt.WithAuthentication(session.AuthTime, session.AMR))
+	}
+
+	policy := s.policyFor(app)
+
+	accessToken, err := jwt.GenerateJWT(app.AccessSecret, session.UserID, session.UserEmail, app.ID, policy.AccessTTL, opts...)
 	if err != nil {
 		log.Error("failed to generate access token", logger.Err(err))
-		return "", "", fmt.Errorf("%s: %w", op, err)
+		return "", "", err
+	}
+
+	now := time.Now().UTC()
+
+	// The absolute lifetime is fixed at login; sessions created before it was
+	// tracked fall back to the expiry they were saved with.
+	absolute := session.AbsoluteExpiresAt
+	if absolute.IsZero() {
+		absolute = session.ExpiresAt
+	}
+	if !now.Before(absolute) {
+		log.Info("refresh session reached its absolute lifetime")
+		if err := s.refreshStorage.Delete(ctx, refreshToken); err != nil {
+			log.Error("failed to delete refresh token", logger.Err(err))
+		}
+		return "", "", ErrSessionExpired
 	}
 
 	newRefresh := jwt.GenerateRandomToken(32)
 	newSession := sessions.RefreshSession{
-		UserID:    session.UserID,
-		UserEmail: session.UserEmail,
-		AppID:     app.ID,
-		IP:        session.IP,
-		UserAgent: session.UserAgent,
-		ExpiresAt: time.Now().Add(s.refreshTTL).UTC(),
+		ID:                session.ID,
+		UserID:            session.UserID,
+		UserEmail:         session.UserEmail,
+		AppID:             app.ID,
+		OrgID:             orgID,
+		IP:                session.IP,
+		UserAgent:         session.UserAgent,
+		CreatedAt:         session.CreatedAt,
+		ExpiresAt:         policy.nextExpiry(now, absolute),
+		AbsoluteExpiresAt: absolute,
+		AuthTime:          session.AuthTime,
+		AMR:               session.AMR,
+		SSOSessionID:      session.SSOSessionID,
 	}
 
 	if err := s.refreshStorage.Save(ctx, newRefresh, newSession); err != nil {
-		return "", "", fmt.Errorf("%s: failed to save refresh token: %w", op, err)
+		return "", "", fmt.Errorf("failed to save refresh token: %w", err)
 	}
 
 	if err := s.refreshStorage.Delete(ctx, refreshToken); err != nil {
@@ -0,0 +1,454 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/domain/sessions"
+	"auth/internal/repository"
+	"auth/pkg/jwt"
+	passwd "auth/pkg/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memStore keeps users, apps, organizations, refresh and SSO sessions in memory and records the
+// revocations published and the audit actions written.
+type memStore struct {
+	users       map[int64]models.User
+	apps        map[int]models.App
+	orgs        map[int64]models.Organization
+	members     map[int64][]int64
+	refresh     map[string]sessions.RefreshSession
+	sso         map[string]sessions.SSOSession
+	revocations []models.Revocation
+	recorded    []string
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		users: make(map[int64]models.User),
+		apps: map[int]models.App{
+			1: {
+				ID: 1, Enabled: true, AccessSecret: "access-secret", AccessSecrets: []string{"access-secret"},
+				GrantTypes: []string{models.GrantPassword, models.GrantRefreshToken, models.GrantSSO, models.GrantPhone},
+			},
+		},
+		orgs:    make(map[int64]models.Organization),
+		members: make(map[int64][]int64),
+		refresh: make(map[string]sessions.RefreshSession),
+		sso:     make(map[string]sessions.SSOSession),
+	}
+}
+
+func (s *memStore) addUser(t *testing.T, user models.User, password string) models.User {
+	t.Helper()
+	hash, err := passwd.Hash(password)
+	require.NoError(t, err)
+	user.ID = int64(len(s.users) + 1)
+	user.PassHash, user.PassAlgo = hash, passwd.AlgoBcrypt
+	s.users[user.ID] = user
+	return user
+}
+
+func (s *memStore) UserAuthorization(context.Context, int64, int) (models.Authorization, error) {
+	return models.Authorization{}, nil
+}
+
+func (s *memStore) Publish(_ context.Context, r models.Revocation) error {
+	s.revocations = append(s.revocations, r)
+	return nil
+}
+
+func (s *memStore) Record(_ context.Context, entry models.AuditEntry) error {
+	s.recorded = append(s.recorded, entry.Action)
+	return nil
+}
+
+// userRepo serves the store's users.
+type userRepo struct{ *memStore }
+
+func (r userRepo) Create(context.Context, string, []byte) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (r userRepo) Get(_ context.Context, email string) (models.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return models.User{}, repository.ErrUserNotFound
+}
+
+func (r userRepo) GetByID(_ context.Context, userID int64) (models.User, error) {
+	u, ok := r.users[userID]
+	if !ok {
+		return models.User{}, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (r userRepo) UpdatePassHash(_ context.Context, userID int64, passHash []byte, passAlgo string) error {
+	u := r.users[userID]
+	u.PassHash, u.PassAlgo = passHash, passAlgo
+	r.users[userID] = u
+	return nil
+}
+
+func (r userRepo) ChangePassword(_ context.Context, userID int64, passHash []byte, passAlgo string) error {
+	u := r.users[userID]
+	u.PassHash, u.PassAlgo, u.PasswordResetRequired = passHash, passAlgo, false
+	r.users[userID] = u
+	return nil
+}
+
+func (r userRepo) GetProfile(context.Context, int64) (models.Profile, error) {
+	return models.Profile{DisplayName: "Ann", Locale: "en"}, nil
+}
+
+// appRepo serves the store's apps.
+type appRepo struct{ *memStore }
+
+func (r appRepo) Get(_ context.Context, appID int) (models.App, error) {
+	app, ok := r.apps[appID]
+	if !ok {
+		return models.App{}, repository.ErrAppNotFound
+	}
+	return app, nil
+}
+
+// orgRepo serves the store's organizations. Invitations are not kept.
+type orgRepo struct{ *memStore }
+
+func (r orgRepo) Get(_ context.Context, orgID int64) (models.Organization, error) {
+	org, ok := r.orgs[orgID]
+	if !ok {
+		return models.Organization{}, repository.ErrOrgNotFound
+	}
+	return org, nil
+}
+
+func (r orgRepo) GetMembership(_ context.Context, orgID, userID int64) (models.Membership, error) {
+	if !slices.Contains(r.members[orgID], userID) {
+		return models.Membership{}, repository.ErrMembershipNotFound
+	}
+	return models.Membership{OrgID: orgID, UserID: userID, Role: models.OrgRoleMember}, nil
+}
+
+func (r orgRepo) GetInvitation(context.Context, int64) (models.Invitation, error) {
+	return models.Invitation{}, repository.ErrInvitationNotFound
+}
+
+func (r orgRepo) AcceptInvitation(context.Context, int64, string, []byte) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+// refreshRepo serves the store's refresh sessions.
+type refreshRepo struct{ *memStore }
+
+func (r refreshRepo) Save(_ context.Context, token string, session sessions.RefreshSession) error {
+	r.refresh[token] = session
+	return nil
+}
+
+func (r refreshRepo) Get(_ context.Context, token string) (*sessions.RefreshSession, error) {
+	session, ok := r.refresh[token]
+	if !ok {
+		return nil, errors.New("refresh token not found")
+	}
+	return &session, nil
+}
+
+func (r refreshRepo) Delete(_ context.Context, token string) error {
+	delete(r.refresh, token)
+	return nil
+}
+
+func (r refreshRepo) ListForUser(_ context.Context, userID int64) (map[string]sessions.RefreshSession, error) {
+	res := make(map[string]sessions.RefreshSession)
+	for token, session := range r.refresh {
+		if session.UserID == userID {
+			res[token] = session
+		}
+	}
+	return res, nil
+}
+
+// ssoRepo serves the store's SSO sessions.
+type ssoRepo struct{ *memStore }
+
+func (r ssoRepo) DeleteAllForUser(_ context.Context, userID int64) error {
+	for id, session := range r.sso {
+		if session.UserID == userID {
+			delete(r.sso, id)
+		}
+	}
+	return nil
+}
+
+type acceptAll struct{}
+
+func (acceptAll) Validate(string, string) error { return nil }
+
+const testIssuer = "https://sso.example.com"
+
+func newTestService() (*AuthService, *memStore) {
+	st := newMemStore()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := New(log, userRepo{st}, appRepo{st}, st, orgRepo{st}, refreshRepo{st}, ssoRepo{st}, st, acceptAll{},
+		SessionPolicy{AccessTTL: time.Minute, RefreshTTL: time.Hour, Issuer: testIssuer}, InvitationPolicy{}, st)
+	return s, st
+}
+
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService()
+
+	user := st.addUser(t, models.User{Email: "user@example.com"}, "old-password")
+
+	_, refresh, err := s.Login(ctx, user.Email, "old-password", 1, 0, "", "")
+	require.NoError(t, err)
+
+	user.PasswordResetRequired = true
+	st.users[user.ID] = user
+
+	t.Run("no session is started", func(t *testing.T) {
+		_, _, err := s.Login(ctx, user.Email, "old-password", 1, 0, "", "")
+		assert.ErrorIs(t, err, ErrPasswordReset)
+
+		_, _, _, err = s.LoginSSO(ctx, "sso-1", sessions.SSOSession{UserID: user.ID, AuthTime: time.Now()}, 1, 0, "", "")
+		assert.ErrorIs(t, err, ErrPasswordReset)
+	})
+
+	t.Run("sessions can't be refreshed", func(t *testing.T) {
+		_, _, err := s.Refresh(ctx, refresh)
+		assert.ErrorIs(t, err, ErrPasswordReset)
+		assert.NotContains(t, st.refresh, refresh)
+	})
+
+	t.Run("change password", func(t *testing.T) {
+		err := s.ChangePassword(ctx, user.Email, "wrong-password", "new-password")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		err = s.ChangePassword(ctx, user.Email, "old-password", "old-password")
+		assert.ErrorIs(t, err, ErrPasswordUnchanged)
+		assert.True(t, st.users[user.ID].PasswordResetRequired)
+
+		require.NoError(t, s.ChangePassword(ctx, user.Email, "old-password", "new-password"))
+		assert.False(t, st.users[user.ID].PasswordResetRequired)
+		assert.Contains(t, st.recorded, ActionChangePassword)
+
+		_, _, err = s.Login(ctx, user.Email, "old-password", 1, 0, "", "")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		_, _, err = s.Login(ctx, user.Email, "new-password", 1, 0, "", "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("changing the password ends sessions", func(t *testing.T) {
+		_, refresh, err := s.Login(ctx, user.Email, "new-password", 1, 0, "", "")
+		require.NoError(t, err)
+		st.sso["sso-1"] = sessions.SSOSession{UserID: user.ID, AuthTime: time.Now()}
+		st.sso["sso-2"] = sessions.SSOSession{UserID: user.ID + 1, AuthTime: time.Now()}
+
+		require.NoError(t, s.ChangePassword(ctx, user.Email, "new-password", "newer-password"))
+		assert.NotContains(t, st.refresh, refresh)
+		assert.NotContains(t, st.sso, "sso-1", "a stolen SSO cookie must not outlive the password")
+		assert.Contains(t, st.sso, "sso-2")
+		assert.Equal(t, models.RevokedUser, st.revocations[len(st.revocations)-1].Kind)
+	})
+}
+
+func TestLoginSSO(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService()
+
+	user := st.addUser(t, models.User{Email: "user@example.com"}, "password")
+	app := st.apps[1]
+	app.IDTokenClaims = []string{"name"}
+	st.apps[1] = app
+
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	ssoSession := sessions.SSOSession{UserID: user.ID, AuthTime: authTime, AMR: []string{jwt.AMRPassword}}
+	access, refresh, id, err := s.LoginSSO(ctx, "sso-1", ssoSession, 1, 0, "", "")
+	require.NoError(t, err)
+
+	claims, err := jwt.ParseIDJWT("access-secret", id, testIssuer, 1)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.FormatInt(user.ID, 10), claims.Subject)
+	assert.Equal(t, user.Email, claims.Email)
+	assert.Equal(t, st.refresh[refresh].ID, claims.SessionID)
+	assert.Equal(t, authTime, claims.AuthTime.Time)
+	assert.Equal(t, jwt.ProfileClaims{Name: "Ann"}, claims.ProfileClaims, "only the ID token claims of the app")
+
+	accessClaims, err := s.VerifyAccessToken(ctx, access)
+	require.NoError(t, err)
+	assert.Empty(t, accessClaims.ProfileClaims, "the app puts no profile claims in access tokens")
+}
+
+func TestOrganizationRequiringMFA(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService()
+
+	user := st.addUser(t, models.User{Email: "user@example.com"}, "password")
+	st.orgs[7] = models.Organization{ID: 7, RequireMFA: true}
+	st.members[7] = []int64{user.ID}
+
+	t.Run("single factor", func(t *testing.T) {
+		_, _, err := s.Login(ctx, user.Email, "password", 1, 7, "", "")
+		assert.ErrorIs(t, err, ErrMFARequired)
+
+		_, refresh, err := s.Login(ctx, user.Email, "password", 1, 0, "", "")
+		require.NoError(t, err)
+
+		_, _, err = s.SwitchOrganization(ctx, refresh, 7)
+		assert.ErrorIs(t, err, ErrMFARequired)
+	})
+
+	t.Run("stepped up", func(t *testing.T) {
+		_, refresh, err := s.Login(ctx, user.Email, "password", 1, 0, "", "")
+		require.NoError(t, err)
+
+		_, refresh, err = s.StepUpVerified(ctx, refresh, user.ID, jwt.AMRSMS)
+		require.NoError(t, err)
+
+		access, _, err := s.SwitchOrganization(ctx, refresh, 7)
+		require.NoError(t, err)
+
+		claims, err := jwt.ParseJWT("access-secret", access)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), claims.OrgID)
+		assert.Equal(t, jwt.ACRMultiFactor, claims.ACR)
+	})
+}
+
+func TestStepUp(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService()
+
+	user := st.addUser(t, models.User{Email: "user@example.com"}, "password")
+
+	acr := func(t *testing.T, access string) string {
+		t.Helper()
+		claims, err := jwt.ParseJWT("access-secret", access)
+		require.NoError(t, err)
+		return claims.ACR
+	}
+
+	t.Run("texted code after password", func(t *testing.T) {
+		access, refresh, err := s.Login(ctx, user.Email, "password", 1, 0, "", "")
+		require.NoError(t, err)
+		require.Equal(t, jwt.ACRSingleFactor, acr(t, access))
+
+		_, _, err = s.StepUp(ctx, refresh, "password")
+		assert.ErrorIs(t, err, ErrFactorUsed)
+
+		access, refresh, err = s.StepUpVerified(ctx, refresh, user.ID, jwt.AMRSMS)
+		require.NoError(t, err)
+		assert.Equal(t, jwt.ACRMultiFactor, acr(t, access))
+
+		_, _, err = s.StepUpVerified(ctx, refresh, user.ID, jwt.AMRSMS)
+		assert.ErrorIs(t, err, ErrFactorUsed)
+	})
+
+	t.Run("password after texted code", func(t *testing.T) {
+		access, refresh, err := s.LoginPhone(ctx, user.ID, 1, 0, "", "")
+		require.NoError(t, err)
+		require.Equal(t, jwt.ACRSingleFactor, acr(t, access))
+
+		_, _, err = s.StepUp(ctx, refresh, "wrong-password")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		access, _, err = s.StepUp(ctx, refresh, "password")
+		require.NoError(t, err)
+		assert.Equal(t, jwt.ACRMultiFactor, acr(t, access))
+	})
+}
+
+func TestVerifyAccessToken(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService()
+
+	user := st.addUser(t, models.User{Email: "user@example.com"}, "password")
+
+	access, _, err := s.Login(ctx, user.Email, "password", 1, 0, "", "")
+	require.NoError(t, err)
+
+	claims, err := s.VerifyAccessToken(ctx, access)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+
+	t.Run("app disabled", func(t *testing.T) {
+		app := st.apps[1]
+		app.Enabled = false
+		st.apps[1] = app
+		defer func() { app.Enabled = true; st.apps[1] = app }()
+
+		_, err := s.VerifyAccessToken(ctx, access)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("user disabled", func(t *testing.T) {
+		disabled := user
+		disabled.Disabled = true
+		st.users[user.ID] = disabled
+		defer func() { st.users[user.ID] = user }()
+
+		_, err := s.VerifyAccessToken(ctx, access)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("user deleted", func(t *testing.T) {
+		delete(st.users, user.ID)
+		defer func() { st.users[user.ID] = user }()
+
+		_, err := s.VerifyAccessToken(ctx, access)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		app := st.apps[1]
+		app.AccessSecrets = []string{"other-secret"}
+		st.apps[1] = app
+		defer func() { app.AccessSecrets = []string{"access-secret"}; st.apps[1] = app }()
+
+		_, err := s.VerifyAccessToken(ctx, access)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestIssueImpersonationToken(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService()
+
+	actor := st.addUser(t, models.User{Email: "support@example.com", IsAdmin: true}, "password")
+	target := st.addUser(t, models.User{Email: "user@example.com"}, "password")
+
+	t.Run("ttl is clamped to the access token ttl", func(t *testing.T) {
+		token, expiresAt, err := s.IssueImpersonationToken(ctx, actor, target, 1, time.Hour, []string{models.ScopeProfile})
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+
+		claims, err := s.VerifyAccessToken(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, target.ID, claims.UserID)
+		require.NotNil(t, claims.Act)
+		assert.Equal(t, actor.Email, claims.Act.Email)
+		assert.Equal(t, []string{models.ScopeProfile}, claims.Scopes)
+		assert.WithinDuration(t, expiresAt, claims.ExpiresAt.Time, time.Second)
+	})
+
+	t.Run("shorter ttl is kept", func(t *testing.T) {
+		_, expiresAt, err := s.IssueImpersonationToken(ctx, actor, target, 1, 30*time.Second, []string{models.ScopeProfile})
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(30*time.Second), expiresAt, time.Second)
+	})
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/pkg/logger"
+	passwd "auth/pkg/password"
+)
+
+// CredentialBackend checks an email and password against one store of credentials. Login asks
+// the backends in order; one that doesn't know the email or doesn't accept the password returns
+// ErrInvalidCredentials so the next one is asked.
+type CredentialBackend interface {
+	Name() string
+	Authenticate(ctx context.Context, email, password string) (models.User, error)
+}
+
+// authenticate returns the user of the first backend accepting the password.
+func (s AuthService) authenticate(ctx context.Context, log *slog.Logger, email, password string) (models.User, string, error) {
+	for _, b := range s.backends {
+		user, err := b.Authenticate(ctx, email, password)
+		if err == nil {
+			return user, b.Name(), nil
+		}
+		if !errors.Is(err, ErrInvalidCredentials) {
+			log.Error("credential backend failed", slog.String("backend", b.Name()), logger.Err(err))
+			return models.User{}, "", err
+		}
+	}
+
+	log.Info("invalid credentials")
+	return models.User{}, "", ErrInvalidCredentials
+}
+
+const localBackendName = "local"
+
+// localBackend checks the password hashes stored with users. Hashes of imported legacy algorithms
+// are replaced with bcrypt ones on the first successful login.
+type localBackend struct {
+	log      *slog.Logger
+	userRepo UserRepository
+}
+
+func (b localBackend) Name() string {
+	return localBackendName
+}
+
+func (b localBackend) Authenticate(ctx context.Context, email, password string) (models.User, error) {
+	const op = "localBackend.Authenticate"
+
+	log := b.log.With(slog.String("op", op), slog.String("email", email))
+
+	user, err := b.userRepo.Get(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return models.User{}, ErrInvalidCredentials
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := passwd.Verify(user.PassAlgo, user.PassHash, password); err != nil {
+		if errors.Is(err, passwd.ErrMismatch) {
+			return models.User{}, ErrInvalidCredentials
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if passwd.NeedsRehash(user.PassAlgo) {
+		b.upgradePassHash(ctx, log, user, password)
+	}
+
+	return user, nil
+}
+
+func (b localBackend) upgradePassHash(ctx context.Context, log *slog.Logger, user models.User, password string) {
+	passHash, err := passwd.Hash(password)
+	if err != nil {
+		log.Error("failed to rehash legacy password", logger.Err(err))
+		return
+	}
+
+	if err := b.userRepo.UpdatePassHash(ctx, user.ID, passHash, passwd.AlgoBcrypt); err != nil {
+		log.Error("failed to upgrade legacy password hash", logger.Err(err))
+		return
+	}
+
+	log.Info("legacy password hash upgraded", slog.String("from", user.PassAlgo))
+}
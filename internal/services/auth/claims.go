@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+)
+
+func checkApp(app models.App, grant string) error {
+	if !app.Enabled {
+		return ErrAppDisabled
+	}
+	if !app.AllowsGrant(grant) {
+		return ErrGrantNotAllowed
+	}
+	return nil
+}
+
+// tokenOptions collects the app-specific claims that go into an access token on top of the identity claims.
+func (s AuthService) tokenOptions(ctx context.Context, app models.App, userID int64, membership models.Membership) ([]jwt.Option, error) {
+	var opts []jwt.Option
+
+	if membership.OrgID != 0 {
+		opts = append(opts, jwt.WithOrganization(membership.OrgID, membership.Role))
+	}
+
+	if len(app.TokenClaims) > 0 {
+		profile, err := s.userRepo.GetProfile(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, jwt.WithProfile(projectProfile(profile, app.TokenClaims)))
+	}
+
+	authz, err := s.roleRepo.UserAuthorization(ctx, userID, app.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(authz.Roles) > 0 {
+		opts = append(opts, jwt.WithAuthorization(authz.Roles, authz.Permissions, s.defaults.MaxAuthzClaimsBytes))
+	}
+
+	return opts, nil
+}
+
+// idToken issues the ID token of a new session, with the profile attributes the app asks for in
+// its ID tokens. It lives as long as the access token issued with it.
+func (s AuthService) idToken(ctx context.Context, app models.App, user models.User, ttl time.Duration, sessionID string, authn authentication) (string, error) {
+	opts := []jwt.Option{jwt.WithSessionID(sessionID), jwt.WithAuthentication(authn.time, authn.amr)}
+
+	if len(app.IDTokenClaims) > 0 {
+		profile, err := s.userRepo.GetProfile(ctx, user.ID)
+		if err != nil {
+			return "", err
+		}
+		opts = append(opts, jwt.WithProfile(projectProfile(profile, app.IDTokenClaims)))
+	}
+
+	return jwt.GenerateIDJWT(app.AccessSecret, s.defaults.Issuer, user.ID, user.Email, app.ID, ttl, opts...)
+}
+
+func projectProfile(profile models.Profile, attrs []string) jwt.ProfileClaims {
+	var claims jwt.ProfileClaims
+
+	for _, attr := range attrs {
+		switch attr {
+		case "name":
+			claims.Name = profile.DisplayName
+		case "given_name":
+			claims.GivenName = profile.GivenName
+		case "family_name":
+			claims.FamilyName = profile.FamilyName
+		case "locale":
+			claims.Locale = profile.Locale
+		case "zoneinfo":
+			claims.Zoneinfo = profile.Timezone
+		case "phone_number":
+			claims.PhoneNumber = profile.Phone
+		case "user_metadata":
+			claims.UserMetadata = profile.UserMetadata
+		case "app_metadata":
+			claims.AppMetadata = profile.AppMetadata
+		}
+	}
+
+	return claims
+}
+
+// VerifyAccessToken checks an access token against the secret of the app that issued it.
+// Tokens of a disabled app, or of a user who was disabled or deleted since they were issued,
+// are rejected.
+func (s AuthService) VerifyAccessToken(ctx context.Context, token string) (*jwt.Claims, error) {
+	const op = "AuthService.VerifyAccessToken"
+
+	log := s.log.With(slog.String("op", op))
+
+	unverified, err := jwt.ParseUnverified(token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	app, err := s.appRepo.Get(ctx, unverified.AppID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAppNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		log.Error("failed to get app", logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	claims, err := verifySignature(app, token)
+	if err != nil {
+		log.Info("access token rejected", logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	if !app.Enabled {
+		log.Info("access token rejected: app disabled", slog.Int("appID", app.ID))
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	// Service accounts are checked when they get a token; disabling one revokes its tokens.
+	if claims.IsServiceAccount() {
+		return claims, nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			log.Info("access token rejected: user deleted", slog.Int64("userID", claims.UserID))
+			return nil, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		log.Error("failed to get user", logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.Disabled {
+		log.Info("access token rejected: user disabled", slog.Int64("userID", user.ID))
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+// verifySignature parses token with the app's secrets. During a secret rotation tokens
+// signed with the previous secret are still accepted.
+func verifySignature(app models.App, token string) (*jwt.Claims, error) {
+	for _, secret := range app.AccessSecrets {
+		claims, err := jwt.ParseJWT(secret, token)
+		if err == nil {
+			return claims, nil
+		}
+		if !errors.Is(err, jwt.ErrSignatureInvalid) {
+			return nil, err
+		}
+	}
+	return nil, errors.New("no matching app secret")
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/pkg/ldap"
+	"auth/pkg/logger"
+	passwd "auth/pkg/password"
+)
+
+type Directory interface {
+	Authenticate(ctx context.Context, email, password string) (ldap.Entry, error)
+}
+
+type DirectoryUserRepository interface {
+	Get(ctx context.Context, email string) (models.User, error)
+	CreateShadow(ctx context.Context, email string) (userID int64, err error)
+}
+
+type DirectoryRoleRepository interface {
+	AssignRole(ctx context.Context, userID, roleID int64) error
+	RevokeRole(ctx context.Context, userID, roleID int64) error
+}
+
+// DirectoryBackend checks passwords with an LDAP directory. Directory users are shadowed into
+// users on their first login, and on every login get the roles their groups map to.
+type DirectoryBackend struct {
+	log        *slog.Logger
+	dir        Directory
+	userRepo   DirectoryUserRepository
+	roleRepo   DirectoryRoleRepository
+	groupRoles map[string]int64
+}
+
+// NewDirectoryBackend creates the backend. groupRoles maps group DNs to the IDs of the roles
+// their members get.
+func NewDirectoryBackend(log *slog.Logger, dir Directory, userRepo DirectoryUserRepository, roleRepo DirectoryRoleRepository, groupRoles map[string]int64) *DirectoryBackend {
+	return &DirectoryBackend{log: log, dir: dir, userRepo: userRepo, roleRepo: roleRepo, groupRoles: groupRoles}
+}
+
+func (b *DirectoryBackend) Name() string {
+	return "ldap"
+}
+
+func (b *DirectoryBackend) Authenticate(ctx context.Context, email, password string) (models.User, error) {
+	const op = "DirectoryBackend.Authenticate"
+
+	log := b.log.With(slog.String("op", op), slog.String("email", email))
+
+	entry, err := b.dir.Authenticate(ctx, email, password)
+	if err != nil {
+		switch {
+		case errors.Is(err, ldap.ErrUserNotFound), errors.Is(err, ldap.ErrInvalidCredentials):
+			return models.User{}, ErrInvalidCredentials
+		case errors.Is(err, ldap.ErrAmbiguousUser):
+			log.Warn("user filter matches several directory entries")
+			return models.User{}, ErrInvalidCredentials
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.String("dn", entry.DN))
+
+	user, err := b.shadow(ctx, log, entry.Email)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			return models.User{}, err
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := b.syncRoles(ctx, log, user.ID, entry); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// shadow returns the user shadowing the directory entry, creating it on the first login.
+func (b *DirectoryBackend) shadow(ctx context.Context, log *slog.Logger, email string) (models.User, error) {
+	user, err := b.userRepo.Get(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		_, err = b.userRepo.CreateShadow(ctx, email)
+		switch {
+		case err == nil:
+			log.Info("directory user shadowed")
+		case !errors.Is(err, repository.ErrUserExists):
+			return models.User{}, err
+		}
+		user, err = b.userRepo.Get(ctx, email)
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	// The directory must not take over accounts that sign in some other way.
+	if user.PassAlgo != passwd.AlgoDirectory {
+		log.Warn("email belongs to a user outside the directory", slog.Int64("userID", user.ID))
+		return models.User{}, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// syncRoles gives the user the mapped roles of their groups and takes away the mapped roles of
+// groups they have left. Roles no group maps to are left alone.
+func (b *DirectoryBackend) syncRoles(ctx context.Context, log *slog.Logger, userID int64, entry ldap.Entry) error {
+	member := make(map[int64]bool, len(b.groupRoles))
+	for group, roleID := range b.groupRoles {
+		member[roleID] = member[roleID] || entry.MemberOf(group)
+	}
+
+	for roleID, ok := range member {
+		if !ok {
+			if err := b.roleRepo.RevokeRole(ctx, userID, roleID); err != nil && !errors.Is(err, repository.ErrRoleNotFound) {
+				return err
+			}
+			continue
+		}
+
+		if err := b.roleRepo.AssignRole(ctx, userID, roleID); err != nil {
+			if !errors.Is(err, repository.ErrRoleNotFound) {
+				return err
+			}
+			log.Warn("group is mapped to a missing role", slog.Int64("roleID", roleID), logger.Err(err))
+		}
+	}
+
+	return nil
+}
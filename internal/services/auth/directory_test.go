@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/pkg/ldap"
+	"auth/pkg/ldap/ldaptest"
+	passwd "auth/pkg/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	adminsGroup = "cn=admins,ou=groups,dc=example,dc=com"
+	devsGroup   = "cn=devs,ou=groups,dc=example,dc=com"
+	adminRole   = 1
+	devRole     = 2
+)
+
+type directoryStore struct {
+	users map[string]models.User
+	roles map[[2]int64]bool
+}
+
+func (s *directoryStore) Get(_ context.Context, email string) (models.User, error) {
+	u, ok := s.users[email]
+	if !ok {
+		return models.User{}, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (s *directoryStore) CreateShadow(_ context.Context, email string) (int64, error) {
+	if _, ok := s.users[email]; ok {
+		return 0, repository.ErrUserExists
+	}
+	id := int64(len(s.users) + 1)
+	s.users[email] = models.User{ID: id, Email: email, PassAlgo: passwd.AlgoDirectory, EmailVerified: true}
+	return id, nil
+}
+
+func (s *directoryStore) AssignRole(_ context.Context, userID, roleID int64) error {
+	s.roles[[2]int64{userID, roleID}] = true
+	return nil
+}
+
+func (s *directoryStore) RevokeRole(_ context.Context, userID, roleID int64) error {
+	if !s.roles[[2]int64{userID, roleID}] {
+		return repository.ErrRoleNotFound
+	}
+	delete(s.roles, [2]int64{userID, roleID})
+	return nil
+}
+
+func TestDirectoryBackend(t *testing.T) {
+	ctx := context.Background()
+
+	dir := ldaptest.New()
+	defer dir.Close()
+	dir.Add(ldaptest.Entry{DN: "cn=auth,dc=example,dc=com", Password: "svc-pass"})
+	dir.Add(ldaptest.Entry{
+		DN:         "uid=ann,ou=people,dc=example,dc=com",
+		Password:   "ann-pass",
+		Attributes: map[string][]string{"mail": {"ann@example.com"}, "memberOf": {adminsGroup}},
+	})
+	dir.Add(ldaptest.Entry{
+		DN:         "uid=bob,ou=people,dc=example,dc=com",
+		Password:   "bob-pass",
+		Attributes: map[string][]string{"mail": {"bob@example.com"}},
+	})
+
+	st := &directoryStore{users: map[string]models.User{}, roles: map[[2]int64]bool{}}
+	client := ldap.New(ldap.Config{
+		URL:          dir.URL,
+		StartTLS:     true,
+		TLS:          dir.ClientTLS(),
+		BindDN:       "cn=auth,dc=example,dc=com",
+		BindPassword: "svc-pass",
+		BaseDN:       "dc=example,dc=com",
+		UserFilter:   "(mail={email})",
+		EmailAttr:    "mail",
+		GroupAttr:    "memberOf",
+	})
+	b := NewDirectoryBackend(slog.New(slog.NewTextHandler(io.Discard, nil)), client, st, st,
+		map[string]int64{adminsGroup: adminRole, devsGroup: devRole})
+
+	t.Run("first login shadows the user", func(t *testing.T) {
+		user, err := b.Authenticate(ctx, "ann@example.com", "ann-pass")
+		require.NoError(t, err)
+		assert.Equal(t, passwd.AlgoDirectory, user.PassAlgo)
+		assert.Equal(t, st.users["ann@example.com"].ID, user.ID)
+		assert.True(t, st.roles[[2]int64{user.ID, adminRole}])
+		assert.False(t, st.roles[[2]int64{user.ID, devRole}])
+
+		again, err := b.Authenticate(ctx, "ann@example.com", "ann-pass")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, again.ID)
+		assert.Len(t, st.users, 1)
+	})
+
+	t.Run("mapped roles of left groups are revoked", func(t *testing.T) {
+		_, err := b.Authenticate(ctx, "bob@example.com", "bob-pass")
+		require.NoError(t, err)
+		bob := st.users["bob@example.com"]
+
+		st.roles[[2]int64{bob.ID, adminRole}] = true
+		st.roles[[2]int64{bob.ID, 99}] = true
+
+		_, err = b.Authenticate(ctx, "bob@example.com", "bob-pass")
+		require.NoError(t, err)
+		assert.False(t, st.roles[[2]int64{bob.ID, adminRole}])
+		assert.True(t, st.roles[[2]int64{bob.ID, 99}], "roles no group maps to are kept")
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		_, err := b.Authenticate(ctx, "ann@example.com", "bob-pass")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := b.Authenticate(ctx, "carl@example.com", "carl-pass")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("local account is not taken over", func(t *testing.T) {
+		st.users["ann@example.com"] = models.User{ID: 1, Email: "ann@example.com", PassAlgo: passwd.AlgoBcrypt}
+
+		_, err := b.Authenticate(ctx, "ann@example.com", "ann-pass")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+	passwd "auth/pkg/password"
+)
+
+// InvitationPolicy decides who may register and verifies invitation links.
+type InvitationPolicy struct {
+	SigningKey string
+	// InviteOnly requires an invitation for every registration, not just for invite-only apps.
+	InviteOnly bool
+}
+
+// AcceptInvitation adds the invited user to the organization. If there is no account for the
+// invited email yet, one is created with the given password; otherwise the password has to
+// be the account's current one.
+func (s AuthService) AcceptInvitation(ctx context.Context, token, password string) (userID int64, created bool, err error) {
+	const op = "AuthService.AcceptInvitation"
+
+	log := s.log.With(slog.String("op", op))
+
+	var inv models.Invitation
+	defer func() {
+		s.record(ctx, log, models.AuditEntry{
+			ActorID:      userID,
+			Action:       ActionAcceptInvitation,
+			TargetUserID: userID,
+			Details:      map[string]any{"invitation_id": inv.ID, "org_id": inv.OrgID, "created": created},
+		}, err)
+	}()
+
+	inv, err = s.invitation(ctx, log, token)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.Int64("invitationID", inv.ID), slog.Int64("orgID", inv.OrgID))
+
+	user, err := s.userRepo.Get(ctx, inv.Email)
+	switch {
+	case err == nil:
+		if err := passwd.Verify(user.PassAlgo, user.PassHash, password); err != nil {
+			if errors.Is(err, passwd.ErrMismatch) {
+				log.Info("invalid credentials for invited account")
+				return 0, false, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}
+			log.Error("failed to verify password", logger.Err(err))
+			return 0, false, fmt.Errorf("%s: %w", op, err)
+		}
+		if user.Disabled {
+			log.Info("invitation accepted by disabled user")
+			return 0, false, fmt.Errorf("%s: %w", op, ErrUserDisabled)
+		}
+
+		userID, err = s.acceptInvitation(ctx, log, inv, nil)
+		if err != nil {
+			return 0, false, fmt.Errorf("%s: %w", op, err)
+		}
+		return userID, false, nil
+
+	case errors.Is(err, repository.ErrUserNotFound):
+		if err := s.passwordPolicy.Validate(password, inv.Email); err != nil {
+			var verr *passwd.ValidationError
+			if !errors.As(err, &verr) {
+				log.Error("failed to validate password", logger.Err(err))
+			}
+			return 0, false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		passHash, err := passwd.Hash(password)
+		if err != nil {
+			log.Error("failed to generate password hash", logger.Err(err))
+			return 0, false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		userID, err = s.acceptInvitation(ctx, log, inv, passHash)
+		if err != nil {
+			return 0, false, fmt.Errorf("%s: %w", op, err)
+		}
+		return userID, true, nil
+
+	default:
+		log.Error("failed to get user", logger.Err(err))
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+}
+
+// invitation checks the signed invitation token and returns the open invitation it refers to.
+func (s AuthService) invitation(ctx context.Context, log *slog.Logger, token string) (models.Invitation, error) {
+	claims, err := jwt.ParseInvitationJWT(s.invitations.SigningKey, token)
+	if err != nil {
+		log.Info("invalid invitation token", logger.Err(err))
+		return models.Invitation{}, ErrInvalidInvitation
+	}
+
+	inv, err := s.orgRepo.GetInvitation(ctx, claims.InvitationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvitationNotFound) {
+			log.Info("invitation token for unknown invitation")
+			return models.Invitation{}, ErrInvalidInvitation
+		}
+		log.Error("failed to get invitation", logger.Err(err))
+		return models.Invitation{}, err
+	}
+
+	if inv.Nonce != claims.ID {
+		log.Info("superseded invitation token", slog.Int64("invitationID", inv.ID))
+		return models.Invitation{}, ErrInvalidInvitation
+	}
+	if status := inv.Status(time.Now()); status != models.InvitationPending {
+		log.Info("invitation is not pending", slog.Int64("invitationID", inv.ID), slog.String("status", status))
+		return models.Invitation{}, ErrInvalidInvitation
+	}
+
+	return inv, nil
+}
+
+func (s AuthService) acceptInvitation(ctx context.Context, log *slog.Logger, inv models.Invitation, passHash []byte) (int64, error) {
+	userID, err := s.orgRepo.AcceptInvitation(ctx, inv.ID, inv.Nonce, passHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvitationNotFound):
+			log.Info("invitation was used or changed concurrently")
+			return 0, ErrInvalidInvitation
+		case errors.Is(err, repository.ErrUserExists), errors.Is(err, repository.ErrUserNotFound):
+			log.Info("invited account changed concurrently", logger.Err(err))
+			return 0, err
+		}
+		log.Error("failed to accept invitation", logger.Err(err))
+		return 0, err
+	}
+
+	log.Info("invitation accepted", slog.Int64("userID", userID))
+
+	return userID, nil
+}
+
+// inviteOnly reports whether registering for appID requires an invitation.
+func (s AuthService) inviteOnly(ctx context.Context, appID int) (bool, error) {
+	if s.invitations.InviteOnly {
+		return true, nil
+	}
+	if appID == 0 {
+		return false, nil
+	}
+
+	app, err := s.appRepo.Get(ctx, appID)
+	if err != nil {
+		return false, err
+	}
+
+	return app.InviteOnly, nil
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+)
+
+// orgMembership checks that the user, authenticated with the amr methods, may sign in to the
+// organization and returns their membership. An orgID of zero means the session is not scoped to
+// an organization.
+func (s AuthService) orgMembership(ctx context.Context, log *slog.Logger, user models.User, orgID int64, amr []string) (models.Membership, error) {
+	if orgID == 0 {
+		return models.Membership{}, nil
+	}
+
+	org, err := s.orgRepo.Get(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrgNotFound) {
+			log.Info("login to unknown organization")
+			return models.Membership{}, ErrNotOrgMember
+		}
+		log.Error("failed to get organization", logger.Err(err))
+		return models.Membership{}, err
+	}
+
+	membership, err := s.orgRepo.GetMembership(ctx, orgID, user.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrMembershipNotFound) {
+			log.Info("login to organization by non-member", slog.Int64("userID", user.ID))
+			return models.Membership{}, ErrNotOrgMember
+		}
+		log.Error("failed to get membership", logger.Err(err))
+		return models.Membership{}, err
+	}
+
+	if !org.AllowsEmail(user.Email) {
+		log.Info("login rejected by organization email domain policy", slog.Int64("userID", user.ID))
+		return models.Membership{}, ErrEmailDomain
+	}
+
+	// Sign-ins take a single factor, so organizations that enforce MFA are entered by switching
+	// to them after stepping up.
+	if org.RequireMFA && !jwt.ACRAtLeast(jwt.ACRFor(amr), jwt.ACRMultiFactor) {
+		log.Info("login rejected by organization MFA policy", slog.Int64("userID", user.ID))
+		return models.Membership{}, ErrMFARequired
+	}
+
+	return membership, nil
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"auth/internal/domain/models"
+	"auth/pkg/logger"
+	passwd "auth/pkg/password"
+)
+
+// ChangePassword replaces the password of a local account, which is how users get past a
+// password reset an admin required: the new password clears the requirement. Every session
+// of the user ends, so whoever knew the old password is signed out.
+func (s AuthService) ChangePassword(ctx context.Context, email, currentPassword, newPassword string) (err error) {
+	const op = "AuthService.ChangePassword"
+
+	log := s.log.With(slog.String("op", op), slog.String("email", email))
+
+	var user models.User
+	defer func() {
+		s.record(ctx, log, models.AuditEntry{
+			ActorID:      user.ID,
+			Action:       ActionChangePassword,
+			TargetUserID: user.ID,
+			Details:      map[string]any{"email": email},
+		}, err)
+	}()
+
+	user, backend, err := s.authenticate(ctx, log, email, currentPassword)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Directory passwords are changed in the directory.
+	if backend != localBackendName {
+		log.Info("password change for account of another backend", slog.String("backend", backend))
+		return fmt.Errorf("%s: %w", op, ErrExternalPassword)
+	}
+
+	if user.Disabled {
+		log.Info("password change for disabled user")
+		return fmt.Errorf("%s: %w", op, ErrUserDisabled)
+	}
+
+	if newPassword == currentPassword {
+		return fmt.Errorf("%s: %w", op, ErrPasswordUnchanged)
+	}
+
+	if err := s.passwordPolicy.Validate(newPassword, email); err != nil {
+		var verr *passwd.ValidationError
+		if errors.As(err, &verr) {
+			log.Info("password rejected by policy", logger.Err(err))
+		} else {
+			log.Error("failed to validate password", logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := passwd.Hash(newPassword)
+	if err != nil {
+		log.Error("failed to generate password hash", logger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.userRepo.ChangePassword(ctx, user.ID, passHash, passwd.AlgoBcrypt); err != nil {
+		log.Error("failed to change password", logger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.endAllSessions(ctx, log, user.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("password changed", slog.Int64("userID", user.ID))
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/pkg/logger"
+)
+
+const sessionIDBytes = 16
+
+// SessionPolicy controls token lifetimes and session limits. Apps can override the
+// lifetimes and MaxSessions; zero values on an app mean the service-wide defaults apply.
+type SessionPolicy struct {
+	AccessTTL          time.Duration
+	RefreshTTL         time.Duration
+	RefreshIdleTimeout time.Duration
+	MaxSessions        int
+	// MaxAuthzClaimsBytes caps the size of the roles and permissions claims.
+	MaxAuthzClaimsBytes int
+	// Issuer identifies the SSO in ID tokens.
+	Issuer string
+}
+
+func (s AuthService) policyFor(app models.App) SessionPolicy {
+	policy := s.defaults
+
+	if app.AccessTTL > 0 {
+		policy.AccessTTL = app.AccessTTL
+	}
+	if app.RefreshTTL > 0 {
+		policy.RefreshTTL = app.RefreshTTL
+	}
+	if app.RefreshIdleTimeout > 0 {
+		policy.RefreshIdleTimeout = app.RefreshIdleTimeout
+	}
+	if app.MaxSessions > 0 {
+		policy.MaxSessions = app.MaxSessions
+	}
+
+	return policy
+}
+
+// nextExpiry is when a refresh token issued at now stops working.
+func (p SessionPolicy) nextExpiry(now, absolute time.Time) time.Time {
+	if p.RefreshIdleTimeout <= 0 {
+		return absolute
+	}
+
+	idle := now.Add(p.RefreshIdleTimeout)
+	if idle.Before(absolute) {
+		return idle
+	}
+	return absolute
+}
+
+// enforceSessionLimit ends the oldest sessions of the user in the app once there are more than max.
+// Failures are only logged: the login that triggered the check has already succeeded.
+func (s AuthService) enforceSessionLimit(ctx context.Context, log *slog.Logger, userID int64, appID, max int) {
+	if max <= 0 {
+		return
+	}
+
+	all, err := s.refreshStorage.ListForUser(ctx, userID)
+	if err != nil {
+		log.Error("failed to list user sessions", logger.Err(err))
+		return
+	}
+
+	type entry struct {
+		token     string
+		id        string
+		createdAt time.Time
+	}
+	var appSessions []entry
+	for token, session := range all {
+		if session.AppID == appID {
+			appSessions = append(appSessions, entry{token: token, id: session.ID, createdAt: session.CreatedAt})
+		}
+	}
+
+	if len(appSessions) <= max {
+		return
+	}
+
+	sort.Slice(appSessions, func(i, j int) bool {
+		return appSessions[i].createdAt.Before(appSessions[j].createdAt)
+	})
+
+	for _, e := range appSessions[:len(appSessions)-max] {
+		if err := s.refreshStorage.Delete(ctx, e.token); err != nil {
+			log.Error("failed to end excess session", logger.Err(err))
+			continue
+		}
+		if e.id != "" {
+			s.revoke(ctx, log, models.Revocation{Kind: models.RevokedSession, UserID: userID, AppID: appID, SessionID: e.id})
+		}
+	}
+
+	log.Info("ended sessions over the limit", slog.Int("ended", len(appSessions)-max))
+}
+
+// EndSession ends the user's session with the ID, as put in the "sid" claim, and revokes the
+// access tokens issued in it. Ending a session that is already gone is not an error.
+func (s AuthService) EndSession(ctx context.Context, userID int64, sessionID string) error {
+	const op = "AuthService.EndSession"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	if sessionID == "" {
+		return nil
+	}
+
+	all, err := s.refreshStorage.ListForUser(ctx, userID)
+	if err != nil {
+		log.Error("failed to list user sessions", logger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for token, session := range all {
+		if session.ID != sessionID {
+			continue
+		}
+
+		if err := s.refreshStorage.Delete(ctx, token); err != nil {
+			log.Error("failed to end session", logger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		s.revoke(ctx, log, models.Revocation{Kind: models.RevokedSession, UserID: userID, AppID: session.AppID, SessionID: sessionID})
+		s.record(ctx, log, sessionEntry(ActionEndSession, &session, nil), nil)
+
+		log.Info("session ended")
+		return nil
+	}
+
+	return nil
+}
+
+// endAllSessions ends every session of the user, SSO sessions included, and revokes the access
+// tokens issued so far.
+func (s AuthService) endAllSessions(ctx context.Context, log *slog.Logger, userID int64) error {
+	if err := s.ssoSessions.DeleteAllForUser(ctx, userID); err != nil {
+		log.Error("failed to end SSO sessions", logger.Err(err))
+		return err
+	}
+
+	all, err := s.refreshStorage.ListForUser(ctx, userID)
+	if err != nil {
+		log.Error("failed to list user sessions", logger.Err(err))
+		return err
+	}
+
+	for token := range all {
+		if err := s.refreshStorage.Delete(ctx, token); err != nil {
+			log.Error("failed to end session", logger.Err(err))
+			return err
+		}
+	}
+	s.revoke(ctx, log, models.Revocation{Kind: models.RevokedUser, UserID: userID})
+
+	return nil
+}
+
+// revoke tells resource servers about a revocation. The revocation has already taken effect
+// for this service, so failing to publish it is only logged.
+func (s AuthService) revoke(ctx context.Context, log *slog.Logger, r models.Revocation) {
+	if r.RevokedAt.IsZero() {
+		r.RevokedAt = time.Now()
+	}
+	if err := s.revocations.Publish(ctx, r); err != nil {
+		log.Error("failed to publish revocation", slog.String("kind", r.Kind), logger.Err(err))
+	}
+}
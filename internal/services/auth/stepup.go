@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"auth/internal/domain/sessions"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+)
+
+// StepUp adds the password of the session's user to a session started with another factor, like
+// a texted code or a federated sign-in, and exchanges the refresh token for tokens with a higher
+// acr and a fresh auth_time. Sessions the password started step up with another factor. The old
+// refresh token stops working.
+func (s AuthService) StepUp(ctx context.Context, refreshToken, password string) (access, refresh string, err error) {
+	const op = "AuthService.StepUp"
+
+	log := s.log.With(slog.String("op", op))
+
+	var session *sessions.RefreshSession
+	defer func() {
+		s.record(ctx, log, sessionEntry(ActionStepUp, session, map[string]any{"method": jwt.AMRPassword}), err)
+	}()
+
+	session, err = s.refreshStorage.Get(ctx, refreshToken)
+	if err != nil {
+		log.Error("failed to get refresh token", logger.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("userID", session.UserID))
+
+	// Checked before the password, so that a refused step-up doesn't test it.
+	if slices.Contains(session.AMR, jwt.AMRPassword) {
+		log.Info("step-up with a factor the session already has", slog.String("method", jwt.AMRPassword))
+		return "", "", fmt.Errorf("%s: %w", op, ErrFactorUsed)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		log.Error("failed to get session user", logger.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Users who signed up with a phone number have no email and no password to step up with.
+	if user.Email == "" {
+		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+	verified, _, err := s.authenticate(ctx, log, user.Email, password)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if verified.ID != user.ID {
+		log.Warn("step-up password belongs to another user", slog.Int64("verifiedUserID", verified.ID))
+		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	access, refresh, err = s.stepUp(ctx, log, refreshToken, *session, jwt.AMRPassword)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return access, refresh, nil
+}
+
+// StepUpVerified is StepUp for factors other services verify, like codes texted to the user's
+// phone. method is the amr value of the factor, which userID verified.
+func (s AuthService) StepUpVerified(ctx context.Context, refreshToken string, userID int64, method string) (access, refresh string, err error) {
+	const op = "AuthService.StepUpVerified"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID), slog.String("method", method))
+
+	var session *sessions.RefreshSession
+	defer func() {
+		s.record(ctx, log, sessionEntry(ActionStepUp, session, map[string]any{"method": method}), err)
+	}()
+
+	session, err = s.refreshStorage.Get(ctx, refreshToken)
+	if err != nil {
+		log.Error("failed to get refresh token", logger.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if session.UserID != userID {
+		log.Warn("step-up for a session of another user", slog.Int64("sessionUserID", session.UserID))
+		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	access, refresh, err = s.stepUp(ctx, log, refreshToken, *session, method)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return access, refresh, nil
+}
+
+// stepUp adds method to the methods the session was authenticated with and rotates it. Levels
+// reached stay with the session; auth_time tells how recent the last step was. A method the
+// session already has is refused: it would refresh auth_time without raising acr, passing off a
+// single factor as a recent multi-factor authentication.
+func (s AuthService) stepUp(ctx context.Context, log *slog.Logger, refreshToken string, session sessions.RefreshSession, method string) (access, refresh string, err error) {
+	if slices.Contains(session.AMR, method) {
+		log.Info("step-up with a factor the session already has", slog.String("method", method))
+		return "", "", ErrFactorUsed
+	}
+	session.AuthTime = time.Now().UTC()
+	session.AMR = append(slices.Clone(session.AMR), method)
+
+	access, refresh, err = s.rotate(ctx, log, refreshToken, session, session.OrgID)
+	if err != nil {
+		return "", "", err
+	}
+
+	log.Info("session stepped up", slog.String("acr", jwt.ACRFor(session.AMR)), slog.Any("amr", session.AMR))
+
+	return access, refresh, nil
+}
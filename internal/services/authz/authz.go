@@ -0,0 +1,405 @@
+package authz
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/audit"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/logger"
+)
+
+const (
+	ActionWriteNamespace = "authz.write_namespace"
+	ActionWriteTuples    = "authz.write_tuples"
+)
+
+const (
+	maxTuplesPerWrite = 100
+	// listObjectsScanLimit bounds how many candidate objects ListObjects checks.
+	listObjectsScanLimit = 1000
+)
+
+var (
+	ErrUnknownRelation         = errors.New("unknown relation")
+	ErrInvalidConsistencyToken = errors.New("invalid consistency token")
+	ErrMaxDepth                = errors.New("maximum check depth exceeded")
+)
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// UserNamespace is the namespace users are subjects in, as in "user:42".
+const UserNamespace = "user"
+
+type RelationStore interface {
+	GetNamespace(ctx context.Context, name string) (models.NamespaceConfig, error)
+	WriteNamespace(ctx context.Context, cfg models.NamespaceConfig) error
+	Revision(ctx context.Context) (int64, error)
+	WriteTuples(ctx context.Context, writes, deletes []models.RelationTuple) (int64, error)
+	ReadTuples(ctx context.Context, obj models.Object, relation string) ([]models.RelationTuple, error)
+	ObjectIDs(ctx context.Context, namespace string, limit int) ([]string, error)
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+type AuthzService struct {
+	log      *slog.Logger
+	store    RelationStore
+	userRepo admin.UserGetter
+	audit    AuditRepository
+}
+
+func New(log *slog.Logger, store RelationStore, userRepo admin.UserGetter, audit AuditRepository) *AuthzService {
+	return &AuthzService{log: log, store: store, userRepo: userRepo, audit: audit}
+}
+
+func (s AuthzService) WriteNamespace(ctx context.Context, actorID int64, cfg models.NamespaceConfig) error {
+	const op = "AuthzService.WriteNamespace"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.String("namespace", cfg.Name))
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := validateNamespace(cfg); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.store.WriteNamespace(ctx, cfg); err != nil {
+		log.Error("failed to write namespace", logger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: actorID, Action: ActionWriteNamespace, Details: map[string]any{"namespace": cfg.Name}})
+
+	return nil
+}
+
+// GetNamespace returns a namespace config. Only admins can read them.
+func (s AuthzService) GetNamespace(ctx context.Context, actorID int64, name string) (models.NamespaceConfig, error) {
+	const op = "AuthzService.GetNamespace"
+
+	if err := s.authorizeRead(ctx, op, actorID, nil); err != nil {
+		return models.NamespaceConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cfg, err := s.store.GetNamespace(ctx, name)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNamespaceNotFound) {
+			s.log.Error("failed to get namespace", slog.String("op", op), logger.Err(err))
+		}
+		return models.NamespaceConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cfg, nil
+}
+
+// WriteTuples applies the changes atomically and returns a consistency token for them.
+func (s AuthzService) WriteTuples(ctx context.Context, actorID int64, writes, deletes []models.RelationTuple) (string, error) {
+	const op = "AuthzService.WriteTuples"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(writes)+len(deletes) == 0 || len(writes)+len(deletes) > maxTuplesPerWrite {
+		return "", fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "writes", Reason: fmt.Sprintf("must hold 1 to %d tuples together with deletes", maxTuplesPerWrite)})
+	}
+
+	ev := newEvaluator(ctx, s.store)
+	for _, t := range writes {
+		if _, err := ev.relation(t.Object.Namespace, t.Relation); err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	rev, err := s.store.WriteTuples(ctx, writes, deletes)
+	if err != nil {
+		log.Error("failed to write tuples", logger.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionWriteTuples,
+		Details: map[string]any{"writes": tupleStrings(writes), "deletes": tupleStrings(deletes), "revision": rev},
+	})
+
+	return encodeToken(rev), nil
+}
+
+// Check reports whether subject has relation on obj. The answer reflects at least the
+// changes covered by token, and the returned token covers everything the answer saw.
+// Users other than admins can only check their own relations.
+func (s AuthzService) Check(ctx context.Context, actorID int64, obj models.Object, relation string, subject models.Subject, token string) (allowed bool, revToken string, err error) {
+	const op = "AuthzService.Check"
+
+	if err := s.authorizeRead(ctx, op, actorID, &subject); err != nil {
+		return false, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	revToken, err = s.snapshot(ctx, token)
+	if err != nil {
+		return false, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	allowed, err = newEvaluator(ctx, s.store).check(obj, relation, subject, 0)
+	if err != nil {
+		s.logEvalError(op, err)
+		return false, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return allowed, revToken, nil
+}
+
+// Expand returns the tree of subjects that have relation on obj. It lists other users, so
+// only admins can expand relations.
+func (s AuthzService) Expand(ctx context.Context, actorID int64, obj models.Object, relation, token string) (tree models.ExpandNode, revToken string, err error) {
+	const op = "AuthzService.Expand"
+
+	if err := s.authorizeRead(ctx, op, actorID, nil); err != nil {
+		return models.ExpandNode{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	revToken, err = s.snapshot(ctx, token)
+	if err != nil {
+		return models.ExpandNode{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	tree, err = newEvaluator(ctx, s.store).expand(obj, relation, 0)
+	if err != nil {
+		s.logEvalError(op, err)
+		return models.ExpandNode{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tree, revToken, nil
+}
+
+// ListObjects returns ids of objects in namespace on which subject has relation. Only
+// objects with at least one tuple can grant anything, so those are the candidates; at
+// most listObjectsScanLimit of them are considered. Users other than admins can only list
+// their own objects.
+func (s AuthzService) ListObjects(ctx context.Context, actorID int64, namespace, relation string, subject models.Subject, token string) (ids []string, revToken string, err error) {
+	const op = "AuthzService.ListObjects"
+
+	if err := s.authorizeRead(ctx, op, actorID, &subject); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	revToken, err = s.snapshot(ctx, token)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	ev := newEvaluator(ctx, s.store)
+	if _, err := ev.relation(namespace, relation); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	candidates, err := s.store.ObjectIDs(ctx, namespace, listObjectsScanLimit)
+	if err != nil {
+		s.log.Error("failed to list candidate objects", slog.String("op", op), logger.Err(err))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, id := range candidates {
+		ok, err := ev.check(models.Object{Namespace: namespace, ID: id}, relation, subject, 0)
+		if err != nil {
+			s.logEvalError(op, err)
+			return nil, "", fmt.Errorf("%s: %w", op, err)
+		}
+		if ok {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, revToken, nil
+}
+
+// snapshot checks that token does not come from the future and returns a token for the
+// current revision. Reads always go to the latest state, which is at least as fresh as
+// any token issued before.
+func (s AuthzService) snapshot(ctx context.Context, token string) (string, error) {
+	rev, err := s.store.Revision(ctx)
+	if err != nil {
+		s.log.Error("failed to get revision", logger.Err(err))
+		return "", err
+	}
+
+	if token != "" {
+		requested, err := decodeToken(token)
+		if err != nil || requested > rev {
+			return "", ErrInvalidConsistencyToken
+		}
+	}
+
+	return encodeToken(rev), nil
+}
+
+// authorizeRead lets admins read every relation. Other users may only ask about subject, and
+// only when it is themselves; a nil subject is for admins alone.
+func (s AuthzService) authorizeRead(ctx context.Context, op string, actorID int64, subject *models.Subject) error {
+	if subject != nil && actorID != 0 && *subject == (models.Subject{Namespace: UserNamespace, ID: strconv.FormatInt(actorID, 10)}) {
+		return nil
+	}
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		if !errors.Is(err, admin.ErrPermissionDenied) {
+			s.log.Error("failed to check admin", slog.String("op", op), logger.Err(err))
+		}
+		return err
+	}
+	return nil
+}
+
+func (s AuthzService) logEvalError(op string, err error) {
+	if isExpected(err) {
+		return
+	}
+	s.log.Error("failed to evaluate relation", slog.String("op", op), logger.Err(err))
+}
+
+func isExpected(err error) bool {
+	return errors.Is(err, repository.ErrNamespaceNotFound) ||
+		errors.Is(err, ErrUnknownRelation) ||
+		errors.Is(err, ErrMaxDepth)
+}
+
+func validateNamespace(cfg models.NamespaceConfig) error {
+	if !namePattern.MatchString(cfg.Name) {
+		return &serviceerr.FieldError{Field: "name", Reason: "must be lowercase letters, digits or _ and start with a letter"}
+	}
+	if len(cfg.Relations) == 0 {
+		return &serviceerr.FieldError{Field: "relations", Reason: "must define at least one relation"}
+	}
+
+	for name, rel := range cfg.Relations {
+		if !namePattern.MatchString(name) {
+			return &serviceerr.FieldError{Field: "relations", Reason: fmt.Sprintf("invalid relation name %q", name)}
+		}
+
+		for _, us := range rel.Union {
+			set := 0
+			if us.This {
+				set++
+			}
+			if us.ComputedUserset != "" {
+				set++
+				if _, ok := cfg.Relations[us.ComputedUserset]; !ok {
+					return &serviceerr.FieldError{Field: "relations", Reason: fmt.Sprintf("%s: computed_userset %q is not a relation of %s", name, us.ComputedUserset, cfg.Name)}
+				}
+			}
+			if us.TupleToUserset != nil {
+				set++
+				if _, ok := cfg.Relations[us.TupleToUserset.Tupleset]; !ok {
+					return &serviceerr.FieldError{Field: "relations", Reason: fmt.Sprintf("%s: tupleset %q is not a relation of %s", name, us.TupleToUserset.Tupleset, cfg.Name)}
+				}
+				if us.TupleToUserset.ComputedUserset == "" {
+					return &serviceerr.FieldError{Field: "relations", Reason: fmt.Sprintf("%s: tuple_to_userset needs a computed_userset", name)}
+				}
+			}
+			if set != 1 {
+				return &serviceerr.FieldError{Field: "relations", Reason: fmt.Sprintf("%s: each userset must set exactly one of this, computed_userset, tuple_to_userset", name)}
+			}
+		}
+	}
+
+	if name, ok := computedCycle(cfg); ok {
+		return &serviceerr.FieldError{Field: "relations", Reason: fmt.Sprintf("%s: computed usersets form a cycle", name)}
+	}
+
+	return nil
+}
+
+// computedCycle finds a relation that reaches itself through computed usersets alone.
+func computedCycle(cfg models.NamespaceConfig) (string, bool) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting:
+			return true
+		case done:
+			return false
+		}
+		state[name] = visiting
+		for _, us := range cfg.Relations[name].Union {
+			if us.ComputedUserset != "" && visit(us.ComputedUserset) {
+				return true
+			}
+		}
+		state[name] = done
+		return false
+	}
+
+	for name := range cfg.Relations {
+		if visit(name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// ParseObject parses "namespace:id".
+func ParseObject(s string) (models.Object, error) {
+	ns, id, ok := strings.Cut(s, ":")
+	if !ok || ns == "" || id == "" || strings.Contains(id, "#") {
+		return models.Object{}, fmt.Errorf("%q must look like namespace:id", s)
+	}
+	return models.Object{Namespace: ns, ID: id}, nil
+}
+
+// ParseSubject parses "namespace:id" or the userset form "namespace:id#relation".
+func ParseSubject(s string) (models.Subject, error) {
+	ref, relation, hasRelation := strings.Cut(s, "#")
+	obj, err := ParseObject(ref)
+	if err != nil || (hasRelation && relation == "") {
+		return models.Subject{}, fmt.Errorf("%q must look like namespace:id or namespace:id#relation", s)
+	}
+	return models.Subject{Namespace: obj.Namespace, ID: obj.ID, Relation: relation}, nil
+}
+
+func tupleStrings(tuples []models.RelationTuple) []string {
+	res := make([]string, len(tuples))
+	for i, t := range tuples {
+		res[i] = t.Object.String() + "#" + t.Relation + "@" + t.Subject.String()
+	}
+	return res
+}
+
+func encodeToken(rev int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(rev, 10)))
+}
+
+func decodeToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidConsistencyToken
+	}
+
+	rev, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || rev < 0 {
+		return 0, ErrInvalidConsistencyToken
+	}
+
+	return rev, nil
+}
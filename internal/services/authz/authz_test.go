@@ -0,0 +1,268 @@
+package authz
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/serviceerr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memStore struct {
+	namespaces map[string]models.NamespaceConfig
+	tuples     []models.RelationTuple
+	rev        int64
+}
+
+func (m *memStore) GetNamespace(_ context.Context, name string) (models.NamespaceConfig, error) {
+	cfg, ok := m.namespaces[name]
+	if !ok {
+		return models.NamespaceConfig{}, repository.ErrNamespaceNotFound
+	}
+	return cfg, nil
+}
+
+func (m *memStore) WriteNamespace(_ context.Context, cfg models.NamespaceConfig) error {
+	m.namespaces[cfg.Name] = cfg
+	return nil
+}
+
+func (m *memStore) Revision(context.Context) (int64, error) { return m.rev, nil }
+
+func (m *memStore) WriteTuples(_ context.Context, writes, _ []models.RelationTuple) (int64, error) {
+	m.tuples = append(m.tuples, writes...)
+	m.rev++
+	return m.rev, nil
+}
+
+func (m *memStore) ReadTuples(_ context.Context, obj models.Object, relation string) ([]models.RelationTuple, error) {
+	var res []models.RelationTuple
+	for _, t := range m.tuples {
+		if t.Object == obj && t.Relation == relation {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (m *memStore) ObjectIDs(_ context.Context, namespace string, _ int) ([]string, error) {
+	seen := map[string]bool{}
+	var ids []string
+	for _, t := range m.tuples {
+		if t.Object.Namespace == namespace && !seen[t.Object.ID] {
+			seen[t.Object.ID] = true
+			ids = append(ids, t.Object.ID)
+		}
+	}
+	return ids, nil
+}
+
+// users knows one admin, adminID; every other user exists but isn't an admin.
+type users struct{}
+
+const adminID = 100
+
+func (users) GetByID(_ context.Context, userID int64) (models.User, error) {
+	return models.User{ID: userID, IsAdmin: userID == adminID}, nil
+}
+
+func tuple(t *testing.T, obj, relation, subject string) models.RelationTuple {
+	o, err := ParseObject(obj)
+	require.NoError(t, err)
+	s, err := ParseSubject(subject)
+	require.NoError(t, err)
+	return models.RelationTuple{Object: o, Relation: relation, Subject: s}
+}
+
+func newTestService(t *testing.T) (AuthzService, *memStore) {
+	store := &memStore{namespaces: map[string]models.NamespaceConfig{
+		"group": {Name: "group", Relations: map[string]models.RelationConfig{"member": {}}},
+		"folder": {Name: "folder", Relations: map[string]models.RelationConfig{
+			"viewer": {},
+		}},
+		"doc": {Name: "doc", Relations: map[string]models.RelationConfig{
+			"parent": {},
+			"owner":  {},
+			"editor": {Union: []models.Userset{{This: true}, {ComputedUserset: "owner"}}},
+			"viewer": {Union: []models.Userset{
+				{This: true},
+				{ComputedUserset: "editor"},
+				{TupleToUserset: &models.TupleToUserset{Tupleset: "parent", ComputedUserset: "viewer"}},
+			}},
+		}},
+	}}
+
+	store.tuples = []models.RelationTuple{
+		tuple(t, "doc:readme", "owner", "user:1"),
+		tuple(t, "doc:readme", "viewer", "group:eng#member"),
+		tuple(t, "doc:readme", "parent", "folder:root"),
+		tuple(t, "folder:root", "viewer", "user:4"),
+		tuple(t, "group:eng", "member", "user:2"),
+		tuple(t, "group:eng", "member", "group:ops#member"),
+		tuple(t, "group:ops", "member", "user:3"),
+		tuple(t, "group:ops", "member", "group:eng#member"),
+	}
+	store.rev = 1
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return AuthzService{log: log, store: store, userRepo: users{}}, store
+}
+
+func TestCheck(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	doc := models.Object{Namespace: "doc", ID: "readme"}
+
+	cases := []struct {
+		relation string
+		subject  string
+		want     bool
+	}{
+		{"owner", "user:1", true},
+		{"editor", "user:1", true},
+		{"viewer", "user:1", true},
+		{"viewer", "user:2", true},
+		{"viewer", "user:3", true},
+		{"viewer", "user:4", true},
+		{"editor", "user:2", false},
+		{"viewer", "user:5", false},
+		{"viewer", "group:eng#member", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.relation+"@"+c.subject, func(t *testing.T) {
+			subject, err := ParseSubject(c.subject)
+			require.NoError(t, err)
+
+			allowed, token, err := s.Check(ctx, adminID, doc, c.relation, subject, "")
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, allowed)
+			assert.NotEmpty(t, token)
+		})
+	}
+
+	t.Run("unknown relation", func(t *testing.T) {
+		_, _, err := s.Check(ctx, adminID, doc, "admin", models.Subject{Namespace: "user", ID: "1"}, "")
+		assert.ErrorIs(t, err, ErrUnknownRelation)
+	})
+}
+
+func TestConsistencyToken(t *testing.T) {
+	s, store := newTestService(t)
+	ctx := context.Background()
+	doc := models.Object{Namespace: "doc", ID: "readme"}
+	user := models.Subject{Namespace: "user", ID: "9"}
+
+	_, token, err := s.Check(ctx, adminID, doc, "viewer", user, "")
+	require.NoError(t, err)
+
+	_, err = store.WriteTuples(ctx, []models.RelationTuple{{Object: doc, Relation: "viewer", Subject: user}}, nil)
+	require.NoError(t, err)
+
+	allowed, newToken, err := s.Check(ctx, adminID, doc, "viewer", user, token)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	assert.NotEqual(t, token, newToken)
+
+	_, _, err = s.Check(ctx, adminID, doc, "viewer", user, encodeToken(store.rev+1))
+	assert.ErrorIs(t, err, ErrInvalidConsistencyToken)
+}
+
+func TestListObjects(t *testing.T) {
+	s, store := newTestService(t)
+	ctx := context.Background()
+	store.tuples = append(store.tuples, tuple(t, "doc:other", "owner", "user:5"))
+
+	ids, _, err := s.ListObjects(ctx, adminID, "doc", "viewer", models.Subject{Namespace: "user", ID: "3"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"readme"}, ids)
+
+	ids, _, err = s.ListObjects(ctx, adminID, "doc", "viewer", models.Subject{Namespace: "user", ID: "5"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"other"}, ids)
+}
+
+func TestExpand(t *testing.T) {
+	s, _ := newTestService(t)
+
+	tree, _, err := s.Expand(context.Background(), adminID, models.Object{Namespace: "doc", ID: "readme"}, "viewer", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "union", tree.Operation)
+	require.Len(t, tree.Children, 3)
+	assert.Equal(t, []models.Subject{{Namespace: "group", ID: "eng", Relation: "member"}}, tree.Children[0].Subjects)
+	assert.Equal(t, "editor", tree.Children[1].Relation)
+	assert.Equal(t, "tuple_to_userset", tree.Children[2].Operation)
+	require.Len(t, tree.Children[2].Children, 1)
+	assert.Equal(t, models.Object{Namespace: "folder", ID: "root"}, tree.Children[2].Children[0].Object)
+}
+
+func TestValidateNamespace(t *testing.T) {
+	valid := models.NamespaceConfig{Name: "doc", Relations: map[string]models.RelationConfig{
+		"owner":  {},
+		"viewer": {Union: []models.Userset{{This: true}, {ComputedUserset: "owner"}}},
+	}}
+	assert.NoError(t, validateNamespace(valid))
+
+	cycle := models.NamespaceConfig{Name: "doc", Relations: map[string]models.RelationConfig{
+		"editor": {Union: []models.Userset{{ComputedUserset: "viewer"}}},
+		"viewer": {Union: []models.Userset{{ComputedUserset: "editor"}}},
+	}}
+	var ferr *serviceerr.FieldError
+	assert.ErrorAs(t, validateNamespace(cycle), &ferr)
+
+	unknown := models.NamespaceConfig{Name: "doc", Relations: map[string]models.RelationConfig{
+		"viewer": {Union: []models.Userset{{ComputedUserset: "owner"}}},
+	}}
+	assert.ErrorAs(t, validateNamespace(unknown), &ferr)
+}
+
+func TestReadAccess(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	doc := models.Object{Namespace: "doc", ID: "readme"}
+
+	t.Run("own relations", func(t *testing.T) {
+		allowed, _, err := s.Check(ctx, 2, doc, "viewer", models.Subject{Namespace: UserNamespace, ID: "2"}, "")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		ids, _, err := s.ListObjects(ctx, 2, "doc", "viewer", models.Subject{Namespace: UserNamespace, ID: "2"}, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"readme"}, ids)
+	})
+
+	t.Run("relations of others", func(t *testing.T) {
+		_, _, err := s.Check(ctx, 2, doc, "viewer", models.Subject{Namespace: UserNamespace, ID: "1"}, "")
+		assert.ErrorIs(t, err, admin.ErrPermissionDenied)
+
+		_, _, err = s.Check(ctx, 2, doc, "viewer", models.Subject{Namespace: "group", ID: "eng", Relation: "member"}, "")
+		assert.ErrorIs(t, err, admin.ErrPermissionDenied)
+
+		_, _, err = s.ListObjects(ctx, 2, "doc", "viewer", models.Subject{Namespace: UserNamespace, ID: "1"}, "")
+		assert.ErrorIs(t, err, admin.ErrPermissionDenied)
+	})
+
+	t.Run("admin only", func(t *testing.T) {
+		_, _, err := s.Expand(ctx, 2, doc, "viewer", "")
+		assert.ErrorIs(t, err, admin.ErrPermissionDenied)
+
+		_, err = s.GetNamespace(ctx, 2, "doc")
+		assert.ErrorIs(t, err, admin.ErrPermissionDenied)
+
+		_, err = s.GetNamespace(ctx, adminID, "doc")
+		assert.NoError(t, err)
+	})
+
+	t.Run("service accounts", func(t *testing.T) {
+		_, _, err := s.Check(ctx, 0, doc, "viewer", models.Subject{Namespace: UserNamespace, ID: "0"}, "")
+		assert.ErrorIs(t, err, admin.ErrPermissionDenied)
+	})
+}
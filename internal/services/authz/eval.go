@@ -0,0 +1,191 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+)
+
+const maxDepth = 25
+
+// evaluator walks userset rewrites for a single request. Namespace configs are loaded once per request.
+type evaluator struct {
+	ctx        context.Context
+	store      RelationStore
+	namespaces map[string]models.NamespaceConfig
+	// path holds the object#relation pairs being checked, so that cycles in the tuple graph end the walk.
+	path map[string]bool
+}
+
+func newEvaluator(ctx context.Context, store RelationStore) *evaluator {
+	return &evaluator{ctx: ctx, store: store, namespaces: map[string]models.NamespaceConfig{}, path: map[string]bool{}}
+}
+
+func (e *evaluator) relation(namespace, relation string) (models.RelationConfig, error) {
+	cfg, ok := e.namespaces[namespace]
+	if !ok {
+		var err error
+		cfg, err = e.store.GetNamespace(e.ctx, namespace)
+		if err != nil {
+			return models.RelationConfig{}, err
+		}
+		e.namespaces[namespace] = cfg
+	}
+
+	rel, ok := cfg.Relations[relation]
+	if !ok {
+		return models.RelationConfig{}, fmt.Errorf("%w: %s#%s", ErrUnknownRelation, namespace, relation)
+	}
+	return rel, nil
+}
+
+func usersets(rel models.RelationConfig) []models.Userset {
+	if len(rel.Union) == 0 {
+		return []models.Userset{{This: true}}
+	}
+	return rel.Union
+}
+
+func (e *evaluator) check(obj models.Object, relation string, subject models.Subject, depth int) (bool, error) {
+	if depth > maxDepth {
+		return false, ErrMaxDepth
+	}
+
+	key := obj.String() + "#" + relation
+	if e.path[key] {
+		return false, nil
+	}
+	e.path[key] = true
+	defer delete(e.path, key)
+
+	rel, err := e.relation(obj.Namespace, relation)
+	if err != nil {
+		return false, err
+	}
+
+	for _, us := range usersets(rel) {
+		var ok bool
+		switch {
+		case us.This:
+			ok, err = e.checkDirect(obj, relation, subject, depth)
+		case us.ComputedUserset != "":
+			ok, err = e.check(obj, us.ComputedUserset, subject, depth+1)
+		case us.TupleToUserset != nil:
+			ok, err = e.checkTupleToUserset(obj, *us.TupleToUserset, subject, depth)
+		}
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+func (e *evaluator) checkDirect(obj models.Object, relation string, subject models.Subject, depth int) (bool, error) {
+	tuples, err := e.store.ReadTuples(e.ctx, obj, relation)
+	if err != nil {
+		return false, err
+	}
+
+	for _, t := range tuples {
+		if t.Subject == subject {
+			return true, nil
+		}
+	}
+
+	for _, t := range tuples {
+		if t.Subject.Relation == "" {
+			continue
+		}
+		ok, err := e.check(t.Subject.Object(), t.Subject.Relation, subject, depth+1)
+		if err != nil && !isMissingRelation(err) {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (e *evaluator) checkTupleToUserset(obj models.Object, ttu models.TupleToUserset, subject models.Subject, depth int) (bool, error) {
+	tuples, err := e.store.ReadTuples(e.ctx, obj, ttu.Tupleset)
+	if err != nil {
+		return false, err
+	}
+
+	for _, t := range tuples {
+		ok, err := e.check(t.Subject.Object(), ttu.ComputedUserset, subject, depth+1)
+		if err != nil && !isMissingRelation(err) {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (e *evaluator) expand(obj models.Object, relation string, depth int) (models.ExpandNode, error) {
+	if depth > maxDepth {
+		return models.ExpandNode{}, ErrMaxDepth
+	}
+
+	rel, err := e.relation(obj.Namespace, relation)
+	if err != nil {
+		return models.ExpandNode{}, err
+	}
+
+	node := models.ExpandNode{Operation: "union", Object: obj, Relation: relation}
+
+	for _, us := range usersets(rel) {
+		switch {
+		case us.This:
+			tuples, err := e.store.ReadTuples(e.ctx, obj, relation)
+			if err != nil {
+				return models.ExpandNode{}, err
+			}
+			leaf := models.ExpandNode{Operation: "this", Object: obj, Relation: relation}
+			for _, t := range tuples {
+				leaf.Subjects = append(leaf.Subjects, t.Subject)
+			}
+			node.Children = append(node.Children, leaf)
+		case us.ComputedUserset != "":
+			child, err := e.expand(obj, us.ComputedUserset, depth+1)
+			if err != nil {
+				return models.ExpandNode{}, err
+			}
+			node.Children = append(node.Children, child)
+		case us.TupleToUserset != nil:
+			tuples, err := e.store.ReadTuples(e.ctx, obj, us.TupleToUserset.Tupleset)
+			if err != nil {
+				return models.ExpandNode{}, err
+			}
+			ttu := models.ExpandNode{Operation: "tuple_to_userset", Object: obj, Relation: us.TupleToUserset.Tupleset}
+			for _, t := range tuples {
+				child, err := e.expand(t.Subject.Object(), us.TupleToUserset.ComputedUserset, depth+1)
+				if err != nil {
+					if isMissingRelation(err) {
+						continue
+					}
+					return models.ExpandNode{}, err
+				}
+				ttu.Children = append(ttu.Children, child)
+			}
+			node.Children = append(node.Children, ttu)
+		}
+	}
+
+	return node, nil
+}
+
+// isMissingRelation is true when a tuple points at a relation its namespace doesn't
+// define. Such tuples simply contribute nobody.
+func isMissingRelation(err error) bool {
+	return errors.Is(err, ErrUnknownRelation) || errors.Is(err, repository.ErrNamespaceNotFound)
+}
@@ -0,0 +1,584 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/audit"
+	"auth/internal/services/auth"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+	"auth/pkg/oidc"
+	passwd "auth/pkg/password"
+)
+
+const (
+	ActionCreateProvider  = "federation.create_provider"
+	ActionDeleteProvider  = "federation.delete_provider"
+	ActionDisableProvider = "federation.disable_provider"
+	ActionEnableProvider  = "federation.enable_provider"
+	ActionLinkIdentity    = "federation.link_identity"
+	ActionProvisionUser   = "federation.provision_user"
+	ActionUnlinkIdentity  = "federation.unlink_identity"
+)
+
+var (
+	ErrProviderDisabled = errors.New("identity provider is disabled")
+	ErrInvalidState     = errors.New("login state is invalid or expired")
+	ErrUpstream         = errors.New("identity provider did not complete the login")
+	ErrEmailDomain      = errors.New("email domain is not allowed by the identity provider")
+	// ErrAccountExists is returned when the identity isn't linked yet but its email belongs to an
+	// account it can't be linked to automatically.
+	ErrAccountExists = errors.New("an account with this email already exists")
+	// ErrNotLinked is returned when the identity isn't linked to an account and none may be created.
+	ErrNotLinked = errors.New("identity is not linked to an account")
+	// ErrLastIdentity is returned when unlinking would leave the user no way to sign in.
+	ErrLastIdentity = errors.New("identity is the only way the user can sign in")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+type Repository interface {
+	CreateProvider(ctx context.Context, p models.IdentityProvider) (int, error)
+	GetProvider(ctx context.Context, providerID int) (models.IdentityProvider, error)
+	GetProviderBySlug(ctx context.Context, slug string) (models.IdentityProvider, error)
+	ListProviders(ctx context.Context) ([]models.IdentityProvider, error)
+	SetProviderDisabled(ctx context.Context, providerID int, disabled bool) error
+	DeleteProvider(ctx context.Context, providerID int) error
+	GetIdentity(ctx context.Context, providerID int, subject string) (models.UserIdentity, error)
+	ListIdentities(ctx context.Context, userID int64) ([]models.UserIdentity, error)
+	LinkIdentity(ctx context.Context, identity models.UserIdentity) (int64, error)
+	UnlinkIdentity(ctx context.Context, userID int64, providerID int) error
+	TouchIdentity(ctx context.Context, identityID int64, email string) error
+	CreateUser(ctx context.Context, email string, emailVerified bool, identity models.UserIdentity) (int64, error)
+}
+
+type UserRepository interface {
+	Get(ctx context.Context, email string) (models.User, error)
+	GetByID(ctx context.Context, userID int64) (models.User, error)
+}
+
+type AppRepository interface {
+	Get(ctx context.Context, appID int) (models.App, error)
+}
+
+type StateStorage interface {
+	Save(ctx context.Context, state string, login models.FederatedLoginState, ttl time.Duration) error
+	Take(ctx context.Context, state string) (models.FederatedLoginState, error)
+}
+
+// SessionIssuer starts sessions for users the service has signed in.
+type SessionIssuer interface {
+	LoginFederated(ctx context.Context, userID int64, appID int, orgID int64, provider, ip, userAgent string) (accessToken, refreshToken string, err error)
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+// Connector signs users in with one upstream provider.
+type Connector interface {
+	AuthCodeURL(redirectURI, state, nonce, codeVerifier string) string
+	Exchange(ctx context.Context, code, redirectURI, codeVerifier, nonce string) (models.ExternalIdentity, error)
+}
+
+// ConnectorFactory sets up the connector of a provider.
+type ConnectorFactory func(ctx context.Context, p models.IdentityProvider) (Connector, error)
+
+type Policy struct {
+	// StateTTL is how long a user has to come back from the provider.
+	StateTTL time.Duration
+	// InviteOnly stops accounts from being created on first sign-in, like it stops registration.
+	InviteOnly bool
+}
+
+type FederationService struct {
+	log        *slog.Logger
+	repo       Repository
+	userRepo   UserRepository
+	appRepo    AppRepository
+	states     StateStorage
+	sessions   SessionIssuer
+	audit      AuditRepository
+	connectors *connectorCache
+	policy     Policy
+}
+
+func New(log *slog.Logger, repo Repository, userRepo UserRepository, appRepo AppRepository, states StateStorage, sessions SessionIssuer, audit AuditRepository, connect ConnectorFactory, policy Policy) *FederationService {
+	return &FederationService{
+		log:        log,
+		repo:       repo,
+		userRepo:   userRepo,
+		appRepo:    appRepo,
+		states:     states,
+		sessions:   sessions,
+		audit:      audit,
+		connectors: &connectorCache{connect: connect, byID: make(map[int]Connector)},
+		policy:     policy,
+	}
+}
+
+// StartLogin begins signing in to the app with the provider and returns the URL to send the
+// user to. The provider sends them back to redirectURI, which must be registered for the app,
+// with the code and state CompleteLogin takes.
+func (s FederationService) StartLogin(ctx context.Context, providerSlug string, appID int, orgID int64, redirectURI string) (string, error) {
+	const op = "FederationService.StartLogin"
+
+	log := s.log.With(slog.String("op", op), slog.String("provider", providerSlug), slog.Int("appID", appID))
+
+	provider, err := s.repo.GetProviderBySlug(ctx, providerSlug)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get identity provider", logger.Err(err))
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if provider.Disabled {
+		return "", fmt.Errorf("%s: %w", op, ErrProviderDisabled)
+	}
+
+	app, err := s.appRepo.Get(ctx, appID)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get app", logger.Err(err))
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if !slices.Contains(app.RedirectURIs, redirectURI) {
+		return "", fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "redirect_uri", Reason: "is not registered for the app"})
+	}
+
+	connector, err := s.connectors.get(ctx, provider)
+	if err != nil {
+		log.Error("failed to set up identity provider", logger.Err(err))
+		return "", fmt.Errorf("%s: %w", op, ErrUpstream)
+	}
+
+	state := jwt.GenerateRandomToken(32)
+	login := models.FederatedLoginState{
+		ProviderID:   provider.ID,
+		AppID:        appID,
+		OrgID:        orgID,
+		RedirectURI:  redirectURI,
+		Nonce:        jwt.GenerateRandomToken(16),
+		CodeVerifier: oidc.NewCodeVerifier(),
+	}
+	if err := s.states.Save(ctx, state, login, s.policy.StateTTL); err != nil {
+		log.Error("failed to save login state", logger.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return connector.AuthCodeURL(redirectURI, state, login.Nonce, login.CodeVerifier), nil
+}
+
+// CompleteLogin finishes a login started by StartLogin once the provider has sent the user back
+// with code and state, and starts a session for them.
+//
+// The identity is signed in as the user it is linked to. An identity signing in for the first
+// time is linked to the account with the same email if the provider allows it and has verified
+// the email, or gets a new account if the provider provisions them.
+func (s FederationService) CompleteLogin(ctx context.Context, state, code, ip, userAgent string) (accessToken, refreshToken string, err error) {
+	const op = "FederationService.CompleteLogin"
+
+	log := s.log.With(slog.String("op", op))
+
+	login, err := s.states.Take(ctx, state)
+	if err != nil {
+		if errors.Is(err, repository.ErrStateNotFound) {
+			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidState)
+		}
+		log.Error("failed to get login state", logger.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int("providerID", login.ProviderID), slog.Int("appID", login.AppID))
+
+	provider, err := s.repo.GetProvider(ctx, login.ProviderID)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get identity provider", logger.Err(err))
+		}
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if provider.Disabled {
+		return "", "", fmt.Errorf("%s: %w", op, ErrProviderDisabled)
+	}
+
+	connector, err := s.connectors.get(ctx, provider)
+	if err != nil {
+		log.Error("failed to set up identity provider", logger.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, ErrUpstream)
+	}
+
+	identity, err := connector.Exchange(ctx, code, login.RedirectURI, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		log.Warn("identity provider login failed", logger.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, ErrUpstream)
+	}
+
+	userID, err := s.resolve(ctx, log, provider, identity, login.AppID)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	accessToken, refreshToken, err = s.sessions.LoginFederated(ctx, userID, login.AppID, login.OrgID, provider.Slug, ip, userAgent)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// resolve finds or creates the user the identity signs in as.
+func (s FederationService) resolve(ctx context.Context, log *slog.Logger, provider models.IdentityProvider, identity models.ExternalIdentity, appID int) (int64, error) {
+	log = log.With(slog.String("subject", identity.Subject))
+
+	if !provider.AllowsEmail(identity.Email) {
+		log.Info("email domain rejected", slog.String("email", identity.Email))
+		return 0, ErrEmailDomain
+	}
+
+	linked, err := s.repo.GetIdentity(ctx, provider.ID, identity.Subject)
+	if err == nil {
+		if err := s.repo.TouchIdentity(ctx, linked.ID, identity.Email); err != nil {
+			log.Error("failed to record identity login", logger.Err(err))
+		}
+		return linked.UserID, nil
+	}
+	if !errors.Is(err, repository.ErrIdentityNotFound) {
+		log.Error("failed to get identity", logger.Err(err))
+		return 0, err
+	}
+
+	if identity.Email == "" {
+		log.Info("identity without email can't be linked")
+		return 0, ErrNotLinked
+	}
+
+	link := models.UserIdentity{ProviderID: provider.ID, Subject: identity.Subject, Email: identity.Email}
+	details := map[string]any{"provider": provider.Slug, "subject": identity.Subject, "email": identity.Email}
+
+	user, err := s.userRepo.Get(ctx, identity.Email)
+	switch {
+	case err == nil:
+		if !provider.LinkByEmail || !identity.EmailVerified {
+			log.Info("identity matches an account it may not be linked to")
+			return 0, ErrAccountExists
+		}
+
+		link.UserID = user.ID
+		if _, err := s.repo.LinkIdentity(ctx, link); err != nil {
+			log.Error("failed to link identity", logger.Err(err))
+			return 0, err
+		}
+		audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: user.ID, Action: ActionLinkIdentity, TargetUserID: user.ID, AppID: appID, Details: details})
+		log.Info("identity linked by email", slog.Int64("userID", user.ID))
+
+		return user.ID, nil
+	case !errors.Is(err, repository.ErrUserNotFound):
+		log.Error("failed to get user", logger.Err(err))
+		return 0, err
+	}
+
+	if !provider.JITProvisioning {
+		log.Info("identity is not linked and provisioning is off")
+		return 0, ErrNotLinked
+	}
+	inviteOnly, err := s.inviteOnly(ctx, appID)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get app", logger.Err(err))
+		}
+		return 0, err
+	}
+	if inviteOnly {
+		log.Info("provisioning rejected, registration is invite-only")
+		return 0, auth.ErrInvitationRequired
+	}
+
+	userID, err := s.repo.CreateUser(ctx, identity.Email, identity.EmailVerified, link)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserExists) {
+			return 0, ErrAccountExists
+		}
+		log.Error("failed to provision user", logger.Err(err))
+		return 0, err
+	}
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: userID, Action: ActionProvisionUser, TargetUserID: userID, AppID: appID, Details: details})
+	log.Info("user provisioned", slog.Int64("userID", userID))
+
+	return userID, nil
+}
+
+func (s FederationService) inviteOnly(ctx context.Context, appID int) (bool, error) {
+	if s.policy.InviteOnly {
+		return true, nil
+	}
+
+	app, err := s.appRepo.Get(ctx, appID)
+	if err != nil {
+		return false, err
+	}
+
+	return app.InviteOnly, nil
+}
+
+// ListIdentities returns the provider identities linked to the user.
+func (s FederationService) ListIdentities(ctx context.Context, userID int64) ([]models.UserIdentity, error) {
+	const op = "FederationService.ListIdentities"
+
+	identities, err := s.repo.ListIdentities(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to list identities", slog.String("op", op), slog.Int64("userID", userID), logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return identities, nil
+}
+
+// UnlinkIdentity removes the user's identity at the provider. Users without a password must
+// keep at least one identity.
+func (s FederationService) UnlinkIdentity(ctx context.Context, userID int64, providerID int) error {
+	const op = "FederationService.UnlinkIdentity"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID), slog.Int("providerID", providerID))
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get user", logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.PassAlgo == passwd.AlgoNone {
+		identities, err := s.repo.ListIdentities(ctx, userID)
+		if err != nil {
+			log.Error("failed to list identities", logger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		others := slices.ContainsFunc(identities, func(i models.UserIdentity) bool { return i.ProviderID != providerID })
+		if !others {
+			return fmt.Errorf("%s: %w", op, ErrLastIdentity)
+		}
+	}
+
+	if err := s.repo.UnlinkIdentity(ctx, userID, providerID); err != nil {
+		if !isExpected(err) {
+			log.Error("failed to unlink identity", logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID:      userID,
+		Action:       ActionUnlinkIdentity,
+		TargetUserID: userID,
+		Details:      map[string]any{"provider_id": providerID},
+	})
+
+	log.Info("identity unlinked")
+
+	return nil
+}
+
+// CreateProvider registers an upstream provider. Its configuration is discovered right away,
+// so a wrong issuer or an unreachable provider is reported here rather than at sign-in.
+func (s FederationService) CreateProvider(ctx context.Context, actorID int64, p models.IdentityProvider) (models.IdentityProvider, error) {
+	const op = "FederationService.CreateProvider"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.String("slug", p.Slug))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return models.IdentityProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	p.Name = strings.TrimSpace(p.Name)
+	if err := validateProvider(p); err != nil {
+		return models.IdentityProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.connectors.connect(ctx, p); err != nil {
+		log.Info("identity provider discovery failed", logger.Err(err))
+		return models.IdentityProvider{}, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "issuer", Reason: "provider configuration could not be discovered"})
+	}
+
+	id, err := s.repo.CreateProvider(ctx, p)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to create identity provider", logger.Err(err))
+		}
+		return models.IdentityProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := s.repo.GetProvider(ctx, id)
+	if err != nil {
+		log.Error("failed to get identity provider", logger.Err(err))
+		return models.IdentityProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+	created.ClientSecret = ""
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionCreateProvider,
+		Details: map[string]any{"provider_id": id, "slug": p.Slug, "issuer": p.Issuer},
+	})
+
+	log.Info("identity provider created", slog.Int("providerID", id))
+
+	return created, nil
+}
+
+func (s FederationService) ListProviders(ctx context.Context, actorID int64) ([]models.IdentityProvider, error) {
+	const op = "FederationService.ListProviders"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	providers, err := s.repo.ListProviders(ctx)
+	if err != nil {
+		log.Error("failed to list identity providers", logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return providers, nil
+}
+
+// SetProviderDisabled stops or resumes sign-ins with the provider. Linked identities are kept.
+func (s FederationService) SetProviderDisabled(ctx context.Context, actorID int64, providerID int, disabled bool) error {
+	const op = "FederationService.SetProviderDisabled"
+
+	action := ActionEnableProvider
+	if disabled {
+		action = ActionDisableProvider
+	}
+
+	return s.mutate(ctx, op, actorID, providerID, action, func() error {
+		return s.repo.SetProviderDisabled(ctx, providerID, disabled)
+	})
+}
+
+// DeleteProvider removes the provider and unlinks every identity at it.
+func (s FederationService) DeleteProvider(ctx context.Context, actorID int64, providerID int) error {
+	const op = "FederationService.DeleteProvider"
+
+	return s.mutate(ctx, op, actorID, providerID, ActionDeleteProvider, func() error {
+		if err := s.repo.DeleteProvider(ctx, providerID); err != nil {
+			return err
+		}
+		s.connectors.forget(providerID)
+		return nil
+	})
+}
+
+// mutate runs an admin-only change of a provider and audits it.
+func (s FederationService) mutate(ctx context.Context, op string, actorID int64, providerID int, action string, fn func() error) error {
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int("providerID", providerID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := fn(); err != nil {
+		if !isExpected(err) {
+			log.Error("identity provider action failed", slog.String("action", action), logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: actorID, Action: action, Details: map[string]any{"provider_id": providerID}})
+
+	log.Info("identity provider action performed", slog.String("action", action))
+
+	return nil
+}
+
+func validateProvider(p models.IdentityProvider) error {
+	if !slugPattern.MatchString(p.Slug) {
+		return &serviceerr.FieldError{Field: "slug", Reason: "must be 1-50 lowercase letters, digits or dashes"}
+	}
+	if p.Name == "" {
+		return &serviceerr.FieldError{Field: "name", Reason: "must not be empty"}
+	}
+	u, err := url.Parse(p.Issuer)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return &serviceerr.FieldError{Field: "issuer", Reason: "must be an absolute http(s) URL without query or fragment"}
+	}
+	if p.ClientID == "" {
+		return &serviceerr.FieldError{Field: "client_id", Reason: "must not be empty"}
+	}
+	for _, domain := range p.AllowedEmailDomains {
+		if domain == "" || strings.Contains(domain, "@") {
+			return &serviceerr.FieldError{Field: "allowed_email_domains", Reason: fmt.Sprintf("%q is not a domain", domain)}
+		}
+	}
+	return nil
+}
+
+func (s FederationService) requireAdmin(ctx context.Context, log *slog.Logger, actorID int64) error {
+	err := admin.RequireAdmin(ctx, s.userRepo, actorID)
+	if err != nil && !errors.Is(err, admin.ErrPermissionDenied) {
+		log.Error("failed to check admin", logger.Err(err))
+	}
+	return err
+}
+
+func isExpected(err error) bool {
+	var ferr *serviceerr.FieldError
+	return errors.As(err, &ferr) ||
+		errors.Is(err, repository.ErrProviderNotFound) ||
+		errors.Is(err, repository.ErrProviderExists) ||
+		errors.Is(err, repository.ErrIdentityNotFound) ||
+		errors.Is(err, repository.ErrUserNotFound) ||
+		errors.Is(err, repository.ErrAppNotFound)
+}
+
+// connectorCache keeps the connectors of providers, so discovery runs once per provider rather
+// than on every login. Providers can't be changed, only deleted, so entries never go stale.
+type connectorCache struct {
+	connect ConnectorFactory
+
+	mu   sync.Mutex
+	byID map[int]Connector
+}
+
+func (c *connectorCache) get(ctx context.Context, p models.IdentityProvider) (Connector, error) {
+	c.mu.Lock()
+	connector, ok := c.byID[p.ID]
+	c.mu.Unlock()
+	if ok {
+		return connector, nil
+	}
+
+	connector, err := c.connect(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.byID[p.ID] = connector
+	c.mu.Unlock()
+
+	return connector, nil
+}
+
+func (c *connectorCache) forget(providerID int) {
+	c.mu.Lock()
+	delete(c.byID, providerID)
+	c.mu.Unlock()
+}
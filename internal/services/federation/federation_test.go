@@ -0,0 +1,395 @@
+package federation
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/auth"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/oidc/oidctest"
+	passwd "auth/pkg/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const callback = "https://app.example.com/callback"
+
+// store keeps users, providers, identities and login states in memory.
+type store struct {
+	users      map[int64]models.User
+	providers  map[int]models.IdentityProvider
+	identities []models.UserIdentity
+	states     map[string]models.FederatedLoginState
+	nextID     int64
+}
+
+func newStore() *store {
+	return &store{
+		users: map[int64]models.User{
+			1: {ID: 1, Email: "admin@example.com", IsAdmin: true},
+			2: {ID: 2, Email: "bob@example.com", PassAlgo: passwd.AlgoBcrypt},
+		},
+		providers: make(map[int]models.IdentityProvider),
+		states:    make(map[string]models.FederatedLoginState),
+		nextID:    10,
+	}
+}
+
+func (s *store) Get(_ context.Context, email string) (models.User, error) {
+	for _, u := range s.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return models.User{}, repository.ErrUserNotFound
+}
+
+func (s *store) GetByID(_ context.Context, userID int64) (models.User, error) {
+	u, ok := s.users[userID]
+	if !ok {
+		return models.User{}, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (s *store) CreateProvider(_ context.Context, p models.IdentityProvider) (int, error) {
+	for _, existing := range s.providers {
+		if existing.Slug == p.Slug {
+			return 0, repository.ErrProviderExists
+		}
+	}
+	p.ID = len(s.providers) + 1
+	s.providers[p.ID] = p
+	return p.ID, nil
+}
+
+func (s *store) GetProvider(_ context.Context, providerID int) (models.IdentityProvider, error) {
+	p, ok := s.providers[providerID]
+	if !ok {
+		return models.IdentityProvider{}, repository.ErrProviderNotFound
+	}
+	return p, nil
+}
+
+func (s *store) GetProviderBySlug(_ context.Context, slug string) (models.IdentityProvider, error) {
+	for _, p := range s.providers {
+		if p.Slug == slug {
+			return p, nil
+		}
+	}
+	return models.IdentityProvider{}, repository.ErrProviderNotFound
+}
+
+func (s *store) ListProviders(context.Context) ([]models.IdentityProvider, error) {
+	var res []models.IdentityProvider
+	for _, p := range s.providers {
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+func (s *store) SetProviderDisabled(_ context.Context, providerID int, disabled bool) error {
+	p, ok := s.providers[providerID]
+	if !ok {
+		return repository.ErrProviderNotFound
+	}
+	p.Disabled = disabled
+	s.providers[providerID] = p
+	return nil
+}
+
+func (s *store) DeleteProvider(_ context.Context, providerID int) error {
+	delete(s.providers, providerID)
+	return nil
+}
+
+func (s *store) GetIdentity(_ context.Context, providerID int, subject string) (models.UserIdentity, error) {
+	for _, i := range s.identities {
+		if i.ProviderID == providerID && i.Subject == subject {
+			return i, nil
+		}
+	}
+	return models.UserIdentity{}, repository.ErrIdentityNotFound
+}
+
+func (s *store) ListIdentities(_ context.Context, userID int64) ([]models.UserIdentity, error) {
+	var res []models.UserIdentity
+	for _, i := range s.identities {
+		if i.UserID == userID {
+			res = append(res, i)
+		}
+	}
+	return res, nil
+}
+
+func (s *store) LinkIdentity(_ context.Context, identity models.UserIdentity) (int64, error) {
+	s.nextID++
+	identity.ID = s.nextID
+	s.identities = append(s.identities, identity)
+	return identity.ID, nil
+}
+
+func (s *store) UnlinkIdentity(_ context.Context, userID int64, providerID int) error {
+	for i, identity := range s.identities {
+		if identity.UserID == userID && identity.ProviderID == providerID {
+			s.identities = append(s.identities[:i], s.identities[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrIdentityNotFound
+}
+
+func (s *store) TouchIdentity(context.Context, int64, string) error {
+	return nil
+}
+
+func (s *store) CreateUser(ctx context.Context, email string, emailVerified bool, identity models.UserIdentity) (int64, error) {
+	if _, err := s.Get(ctx, email); err == nil {
+		return 0, repository.ErrUserExists
+	}
+	s.nextID++
+	s.users[s.nextID] = models.User{ID: s.nextID, Email: email, EmailVerified: emailVerified, PassAlgo: passwd.AlgoNone}
+	identity.UserID = s.nextID
+	_, _ = s.LinkIdentity(ctx, identity)
+	return identity.UserID, nil
+}
+
+func (s *store) Save(_ context.Context, state string, login models.FederatedLoginState, _ time.Duration) error {
+	s.states[state] = login
+	return nil
+}
+
+func (s *store) Take(_ context.Context, state string) (models.FederatedLoginState, error) {
+	login, ok := s.states[state]
+	if !ok {
+		return models.FederatedLoginState{}, repository.ErrStateNotFound
+	}
+	delete(s.states, state)
+	return login, nil
+}
+
+type apps map[int]models.App
+
+func (a apps) Get(_ context.Context, appID int) (models.App, error) {
+	app, ok := a[appID]
+	if !ok {
+		return models.App{}, repository.ErrAppNotFound
+	}
+	return app, nil
+}
+
+// sessions hands out the ID of the user as access token.
+type sessions struct{}
+
+func (sessions) LoginFederated(_ context.Context, userID int64, _ int, _ int64, _, _, _ string) (string, string, error) {
+	return strconv.FormatInt(userID, 10), "refresh", nil
+}
+
+type noAudit struct{}
+
+func (noAudit) Record(context.Context, models.AuditEntry) error { return nil }
+
+func newTestService(t *testing.T) (FederationService, *store, *oidctest.Server) {
+	provider := oidctest.New("client", "secret")
+	t.Cleanup(provider.Close)
+
+	st := newStore()
+	appRepo := apps{1: {ID: 1, RedirectURIs: []string{callback}}, 2: {ID: 2, RedirectURIs: []string{callback}, InviteOnly: true}}
+
+	s := New(slog.New(slog.NewTextHandler(io.Discard, nil)), st, st, appRepo, st, sessions{}, noAudit{}, OIDCConnectors(http.DefaultClient), Policy{StateTTL: time.Minute})
+	return *s, st, provider
+}
+
+func createProvider(t *testing.T, s FederationService, issuer string, mod func(*models.IdentityProvider)) models.IdentityProvider {
+	p := models.IdentityProvider{Slug: "corp", Name: "Corp", Issuer: issuer, ClientID: "client", ClientSecret: "secret"}
+	if mod != nil {
+		mod(&p)
+	}
+	created, err := s.CreateProvider(context.Background(), 1, p)
+	require.NoError(t, err)
+	return created
+}
+
+// login goes through the whole flow for the app and returns the access token it ended with.
+func login(s FederationService, provider *oidctest.Server, appID int) (string, error) {
+	ctx := context.Background()
+
+	authURL, err := s.StartLogin(ctx, "corp", appID, 0, callback)
+	if err != nil {
+		return "", err
+	}
+
+	code, state, err := provider.Authorize(authURL)
+	if err != nil {
+		return "", err
+	}
+
+	access, _, err := s.CompleteLogin(ctx, state, code, "", "")
+	return access, err
+}
+
+func userOf(access string) int64 {
+	userID, _ := strconv.ParseInt(access, 10, 64)
+	return userID
+}
+
+func TestCompleteLogin(t *testing.T) {
+	t.Run("provisions and then signs in the same user", func(t *testing.T) {
+		s, st, provider := newTestService(t)
+		createProvider(t, s, provider.Issuer(), func(p *models.IdentityProvider) { p.JITProvisioning = true })
+		provider.SetUser(oidctest.User{Subject: "u-1", Email: "ann@example.com", EmailVerified: true})
+
+		access, err := login(s, provider, 1)
+		require.NoError(t, err)
+		userID := userOf(access)
+		assert.Equal(t, passwd.AlgoNone, st.users[userID].PassAlgo)
+		assert.True(t, st.users[userID].EmailVerified)
+
+		access, err = login(s, provider, 1)
+		require.NoError(t, err)
+		assert.Equal(t, userID, userOf(access))
+		assert.Len(t, st.identities, 1)
+	})
+
+	t.Run("no provisioning", func(t *testing.T) {
+		s, _, provider := newTestService(t)
+		createProvider(t, s, provider.Issuer(), nil)
+		provider.SetUser(oidctest.User{Subject: "u-1", Email: "ann@example.com", EmailVerified: true})
+
+		_, err := login(s, provider, 1)
+		assert.ErrorIs(t, err, ErrNotLinked)
+	})
+
+	t.Run("invite only", func(t *testing.T) {
+		s, _, provider := newTestService(t)
+		createProvider(t, s, provider.Issuer(), func(p *models.IdentityProvider) { p.JITProvisioning = true })
+		provider.SetUser(oidctest.User{Subject: "u-1", Email: "ann@example.com", EmailVerified: true})
+
+		_, err := login(s, provider, 2)
+		assert.ErrorIs(t, err, auth.ErrInvitationRequired)
+	})
+
+	t.Run("links by verified email", func(t *testing.T) {
+		s, st, provider := newTestService(t)
+		createProvider(t, s, provider.Issuer(), func(p *models.IdentityProvider) { p.LinkByEmail = true })
+
+		provider.SetUser(oidctest.User{Subject: "u-2", Email: "bob@example.com"})
+		_, err := login(s, provider, 1)
+		assert.ErrorIs(t, err, ErrAccountExists, "unverified emails are never linked")
+
+		provider.SetUser(oidctest.User{Subject: "u-2", Email: "bob@example.com", EmailVerified: true})
+		access, err := login(s, provider, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), userOf(access))
+		assert.Len(t, st.identities, 1)
+	})
+
+	t.Run("existing email without linking", func(t *testing.T) {
+		s, _, provider := newTestService(t)
+		createProvider(t, s, provider.Issuer(), func(p *models.IdentityProvider) { p.JITProvisioning = true })
+		provider.SetUser(oidctest.User{Subject: "u-2", Email: "bob@example.com", EmailVerified: true})
+
+		_, err := login(s, provider, 1)
+		assert.ErrorIs(t, err, ErrAccountExists)
+	})
+
+	t.Run("email domain", func(t *testing.T) {
+		s, _, provider := newTestService(t)
+		createProvider(t, s, provider.Issuer(), func(p *models.IdentityProvider) {
+			p.JITProvisioning = true
+			p.AllowedEmailDomains = []string{"corp.example"}
+		})
+		provider.SetUser(oidctest.User{Subject: "u-1", Email: "ann@example.com", EmailVerified: true})
+
+		_, err := login(s, provider, 1)
+		assert.ErrorIs(t, err, ErrEmailDomain)
+	})
+
+	t.Run("state is single use", func(t *testing.T) {
+		s, _, provider := newTestService(t)
+		createProvider(t, s, provider.Issuer(), func(p *models.IdentityProvider) { p.JITProvisioning = true })
+		provider.SetUser(oidctest.User{Subject: "u-1", Email: "ann@example.com", EmailVerified: true})
+
+		authURL, err := s.StartLogin(context.Background(), "corp", 1, 0, callback)
+		require.NoError(t, err)
+		code, state, err := provider.Authorize(authURL)
+		require.NoError(t, err)
+
+		_, _, err = s.CompleteLogin(context.Background(), state, code, "", "")
+		require.NoError(t, err)
+		_, _, err = s.CompleteLogin(context.Background(), state, code, "", "")
+		assert.ErrorIs(t, err, ErrInvalidState)
+	})
+
+	t.Run("disabled provider", func(t *testing.T) {
+		s, _, provider := newTestService(t)
+		p := createProvider(t, s, provider.Issuer(), nil)
+		require.NoError(t, s.SetProviderDisabled(context.Background(), 1, p.ID, true))
+
+		_, err := s.StartLogin(context.Background(), "corp", 1, 0, callback)
+		assert.ErrorIs(t, err, ErrProviderDisabled)
+	})
+}
+
+func TestStartLogin(t *testing.T) {
+	s, _, provider := newTestService(t)
+	createProvider(t, s, provider.Issuer(), nil)
+
+	authURL, err := s.StartLogin(context.Background(), "corp", 1, 0, callback)
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, provider.Issuer()+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+
+	_, err = s.StartLogin(context.Background(), "corp", 1, 0, "https://evil.example.com/callback")
+	var ferr *serviceerr.FieldError
+	assert.ErrorAs(t, err, &ferr)
+
+	_, err = s.StartLogin(context.Background(), "unknown", 1, 0, callback)
+	assert.ErrorIs(t, err, repository.ErrProviderNotFound)
+}
+
+func TestCreateProvider(t *testing.T) {
+	s, _, provider := newTestService(t)
+
+	_, err := s.CreateProvider(context.Background(), 2, models.IdentityProvider{Slug: "corp"})
+	assert.ErrorIs(t, err, admin.ErrPermissionDenied)
+
+	_, err = s.CreateProvider(context.Background(), 1, models.IdentityProvider{
+		Slug: "corp", Name: "Corp", Issuer: provider.Issuer() + "/other", ClientID: "client",
+	})
+	var ferr *serviceerr.FieldError
+	require.ErrorAs(t, err, &ferr)
+	assert.Equal(t, "issuer", ferr.Field, "discovery must succeed")
+
+	p := createProvider(t, s, provider.Issuer(), nil)
+	assert.Empty(t, p.ClientSecret)
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	s, st, provider := newTestService(t)
+	p := createProvider(t, s, provider.Issuer(), func(p *models.IdentityProvider) { p.JITProvisioning = true })
+	provider.SetUser(oidctest.User{Subject: "u-1", Email: "ann@example.com", EmailVerified: true})
+
+	access, err := login(s, provider, 1)
+	require.NoError(t, err)
+	userID := userOf(access)
+
+	err = s.UnlinkIdentity(context.Background(), userID, p.ID)
+	assert.ErrorIs(t, err, ErrLastIdentity)
+
+	_, err = st.LinkIdentity(context.Background(), models.UserIdentity{UserID: 2, ProviderID: p.ID, Subject: "u-2"})
+	require.NoError(t, err)
+	assert.NoError(t, s.UnlinkIdentity(context.Background(), 2, p.ID), "users with a password can unlink their only identity")
+}
@@ -0,0 +1,47 @@
+package federation
+
+import (
+	"context"
+	"net/http"
+
+	"auth/internal/domain/models"
+	"auth/pkg/oidc"
+)
+
+// OIDCConnectors sets up connectors for OpenID Connect providers from their discovery documents.
+func OIDCConnectors(httpClient *http.Client) ConnectorFactory {
+	return func(ctx context.Context, p models.IdentityProvider) (Connector, error) {
+		client, err := oidc.Discover(ctx, httpClient, oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			Scopes:       p.Scopes,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return oidcConnector{client: client}, nil
+	}
+}
+
+type oidcConnector struct {
+	client *oidc.Client
+}
+
+func (c oidcConnector) AuthCodeURL(redirectURI, state, nonce, codeVerifier string) string {
+	return c.client.AuthCodeURL(redirectURI, state, nonce, codeVerifier)
+}
+
+func (c oidcConnector) Exchange(ctx context.Context, code, redirectURI, codeVerifier, nonce string) (models.ExternalIdentity, error) {
+	claims, err := c.client.Exchange(ctx, code, redirectURI, codeVerifier, nonce)
+	if err != nil {
+		return models.ExternalIdentity{}, err
+	}
+
+	return models.ExternalIdentity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
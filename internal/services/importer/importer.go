@@ -0,0 +1,212 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/pkg/logger"
+	passwd "auth/pkg/password"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown import format")
+	ErrMissingColumn = errors.New("missing required column")
+)
+
+type UserRepository interface {
+	Get(ctx context.Context, email string) (user models.User, err error)
+	Import(ctx context.Context, email string, passHash []byte, passAlgo string) (userID int64, created bool, err error)
+}
+
+type Record struct {
+	Email string `json:"email"`
+	Hash  string `json:"hash"`
+	Algo  string `json:"algo"`
+}
+
+type RowError struct {
+	Line  int
+	Email string
+	Err   error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d (%s): %v", e.Line, e.Email, e.Err)
+}
+
+type Report struct {
+	Created int
+	Skipped int
+	Errors  []RowError
+}
+
+type Importer struct {
+	log      *slog.Logger
+	userRepo UserRepository
+}
+
+func New(log *slog.Logger, userRepo UserRepository) *Importer {
+	return &Importer{log: log, userRepo: userRepo}
+}
+
+// Import reads users from src and inserts those whose email is not registered yet.
+// Row-level problems are collected in the report; only read errors abort the run.
+// With dryRun set nothing is written, but the report reflects what would happen.
+func (i *Importer) Import(ctx context.Context, src io.Reader, format string, dryRun bool) (Report, error) {
+	const op = "Importer.Import"
+
+	log := i.log.With(slog.String("op", op), slog.String("format", format), slog.Bool("dry_run", dryRun))
+
+	var report Report
+	// A dry run writes nothing, so it remembers the emails it would have created to report
+	// later rows with the same email as skipped, like a real run does.
+	seen := make(map[string]bool)
+	handle := func(line int, rec Record) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		created, err := i.importRecord(ctx, rec, dryRun, seen)
+		switch {
+		case err != nil:
+			report.Errors = append(report.Errors, RowError{Line: line, Email: rec.Email, Err: err})
+		case created:
+			report.Created++
+		default:
+			report.Skipped++
+		}
+		return nil
+	}
+
+	var err error
+	switch format {
+	case FormatCSV:
+		err = readCSV(src, handle, &report)
+	case FormatJSONL:
+		err = readJSONL(src, handle, &report)
+	default:
+		err = fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		log.Error("import aborted", logger.Err(err))
+		return report, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("import finished",
+		slog.Int("created", report.Created),
+		slog.Int("skipped", report.Skipped),
+		slog.Int("failed", len(report.Errors)),
+	)
+
+	return report, nil
+}
+
+func (i *Importer) importRecord(ctx context.Context, rec Record, dryRun bool, seen map[string]bool) (created bool, err error) {
+	if !strings.Contains(rec.Email, "@") {
+		return false, errors.New("invalid email")
+	}
+	if err := passwd.CheckFormat(rec.Algo, []byte(rec.Hash)); err != nil {
+		return false, err
+	}
+
+	if dryRun {
+		if seen[rec.Email] {
+			return false, nil
+		}
+		_, err := i.userRepo.Get(ctx, rec.Email)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			seen[rec.Email] = true
+			return true, nil
+		}
+		return false, err
+	}
+
+	_, created, err = i.userRepo.Import(ctx, rec.Email, []byte(rec.Hash), rec.Algo)
+	return created, err
+}
+
+func readCSV(src io.Reader, handle func(line int, rec Record) error, report *Report) error {
+	r := csv.NewReader(src)
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	r.FieldsPerRecord = len(header)
+
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"email", "hash", "algo"} {
+		if _, ok := cols[name]; !ok {
+			return fmt.Errorf("%w: %q", ErrMissingColumn, name)
+		}
+	}
+
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				report.Errors = append(report.Errors, RowError{Line: perr.Line, Err: err})
+				continue
+			}
+			return err
+		}
+		line, _ := r.FieldPos(0)
+
+		rec := Record{
+			Email: strings.TrimSpace(row[cols["email"]]),
+			Hash:  strings.TrimSpace(row[cols["hash"]]),
+			Algo:  strings.TrimSpace(row[cols["algo"]]),
+		}
+		if err := handle(line, rec); err != nil {
+			return err
+		}
+	}
+}
+
+func readJSONL(src io.Reader, handle func(line int, rec Record) error, report *Report) error {
+	sc := bufio.NewScanner(src)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for line := 1; sc.Scan(); line++ {
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			report.Errors = append(report.Errors, RowError{Line: line, Err: err})
+			continue
+		}
+		rec.Email = strings.TrimSpace(rec.Email)
+
+		if err := handle(line, rec); err != nil {
+			return err
+		}
+	}
+
+	return sc.Err()
+}
@@ -0,0 +1,141 @@
+package importer
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	passwd "auth/pkg/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const hash = "pbkdf2_sha256$1000$salt$c2VjcmV0"
+
+// userRepo keeps imported users in memory, keyed by email.
+type userRepo map[string]models.User
+
+func (r userRepo) Get(_ context.Context, email string) (models.User, error) {
+	u, ok := r[email]
+	if !ok {
+		return models.User{}, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (r userRepo) Import(_ context.Context, email string, passHash []byte, passAlgo string) (int64, bool, error) {
+	if u, ok := r[email]; ok {
+		return u.ID, false, nil
+	}
+	u := models.User{ID: int64(len(r) + 1), Email: email, PassHash: passHash, PassAlgo: passAlgo}
+	r[email] = u
+	return u.ID, true, nil
+}
+
+func newTestImporter(emails ...string) (*Importer, userRepo) {
+	repo := make(userRepo)
+	for _, email := range emails {
+		repo[email] = models.User{ID: int64(len(repo) + 1), Email: email}
+	}
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), repo), repo
+}
+
+func TestImportCSV(t *testing.T) {
+	imp, repo := newTestImporter("old@example.com")
+
+	src := "email,hash,algo\n" +
+		"new@example.com," + hash + "," + passwd.AlgoPBKDF2SHA256 + "\n" +
+		"old@example.com," + hash + "," + passwd.AlgoPBKDF2SHA256 + "\n" +
+		"not-an-email," + hash + "," + passwd.AlgoPBKDF2SHA256 + "\n" +
+		"bad@example.com,nonsense," + passwd.AlgoPBKDF2SHA256 + "\n" +
+		"short@example.com\n"
+
+	report, err := imp.Import(context.Background(), strings.NewReader(src), FormatCSV, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Skipped)
+	require.Len(t, report.Errors, 3)
+	assert.Equal(t, 4, report.Errors[0].Line)
+	assert.Equal(t, "not-an-email", report.Errors[0].Email)
+	assert.Equal(t, 5, report.Errors[1].Line)
+	assert.ErrorIs(t, report.Errors[1].Err, passwd.ErrMalformedHash)
+	assert.Equal(t, 6, report.Errors[2].Line)
+
+	assert.Equal(t, []byte(hash), repo["new@example.com"].PassHash)
+	assert.Equal(t, passwd.AlgoPBKDF2SHA256, repo["new@example.com"].PassAlgo)
+	assert.NotContains(t, repo, "bad@example.com")
+}
+
+func TestImportJSONL(t *testing.T) {
+	imp, repo := newTestImporter("old@example.com")
+
+	src := `{"email": "new@example.com", "hash": "` + hash + `", "algo": "` + passwd.AlgoPBKDF2SHA256 + `"}` + "\n" +
+		"\n" +
+		`{"email": " old@example.com ", "hash": "` + hash + `", "algo": "` + passwd.AlgoPBKDF2SHA256 + `"}` + "\n" +
+		`{"email": "broken@example.com"` + "\n" +
+		`{"email": "md5@example.com", "hash": "` + hash + `", "algo": "md5"}` + "\n"
+
+	report, err := imp.Import(context.Background(), strings.NewReader(src), FormatJSONL, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Skipped)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, 4, report.Errors[0].Line)
+	assert.Equal(t, 5, report.Errors[1].Line)
+	assert.ErrorIs(t, report.Errors[1].Err, passwd.ErrUnknownAlgorithm)
+
+	assert.Contains(t, repo, "new@example.com")
+	assert.Len(t, repo, 2)
+}
+
+func TestImportDuplicates(t *testing.T) {
+	src := "email,hash,algo\n" +
+		"dup@example.com," + hash + "," + passwd.AlgoPBKDF2SHA256 + "\n" +
+		"dup@example.com," + hash + "," + passwd.AlgoPBKDF2SHA256 + "\n" +
+		"old@example.com," + hash + "," + passwd.AlgoPBKDF2SHA256 + "\n"
+
+	for _, dryRun := range []bool{false, true} {
+		imp, _ := newTestImporter("old@example.com")
+
+		report, err := imp.Import(context.Background(), strings.NewReader(src), FormatCSV, dryRun)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, report.Created, "dry run: %v", dryRun)
+		assert.Equal(t, 2, report.Skipped, "dry run: %v", dryRun)
+		assert.Empty(t, report.Errors, "dry run: %v", dryRun)
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	imp, repo := newTestImporter("old@example.com")
+
+	src := "email,hash,algo\n" +
+		"new@example.com," + hash + "," + passwd.AlgoPBKDF2SHA256 + "\n" +
+		"old@example.com," + hash + "," + passwd.AlgoPBKDF2SHA256 + "\n" +
+		"bad@example.com,nonsense," + passwd.AlgoPBKDF2SHA256 + "\n"
+
+	report, err := imp.Import(context.Background(), strings.NewReader(src), FormatCSV, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Len(t, report.Errors, 1)
+	assert.Len(t, repo, 1)
+}
+
+func TestImportAborts(t *testing.T) {
+	imp, _ := newTestImporter()
+
+	_, err := imp.Import(context.Background(), strings.NewReader("email,hash\n"), FormatCSV, false)
+	assert.ErrorIs(t, err, ErrMissingColumn)
+
+	_, err = imp.Import(context.Background(), strings.NewReader(""), "xml", false)
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
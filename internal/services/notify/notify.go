@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"auth/internal/domain/models"
+)
+
+// LogNotifier writes user notifications to the service log. It stands in until notifications
+// can be delivered to users directly.
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+func NewLogNotifier(log *slog.Logger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+func (n LogNotifier) NotifyImpersonation(_ context.Context, user, actor models.User, reason string, expiresAt time.Time) error {
+	n.log.Info("notify user: account accessed by support",
+		slog.Int64("userID", user.ID),
+		slog.String("email", user.Email),
+		slog.Int64("actorID", actor.ID),
+		slog.String("reason", reason),
+		slog.Time("expiresAt", expiresAt),
+	)
+	return nil
+}
@@ -0,0 +1,158 @@
+package orgs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+)
+
+const (
+	ActionInvite           = "orgs.invite"
+	ActionResendInvitation = "orgs.resend_invitation"
+	ActionRevokeInvitation = "orgs.revoke_invitation"
+)
+
+// InvitationSettings control how invitation links are made.
+type InvitationSettings struct {
+	SigningKey string
+	TTL        time.Duration
+	// AcceptURL is the link given to invitees; "{token}" is replaced with the signed invitation token.
+	AcceptURL string
+}
+
+// CreateInvitation invites an email address to the organization with the given role and
+// returns the invitation together with the link to accept it. There is no mail delivery,
+// so passing the link on is up to the caller.
+func (s OrgService) CreateInvitation(ctx context.Context, actorID, orgID int64, email, role string) (models.Invitation, string, error) {
+	const op = "OrgService.CreateInvitation"
+
+	email = strings.TrimSpace(email)
+	if !strings.Contains(email, "@") {
+		return models.Invitation{}, "", fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "email", Reason: "is not an email address"})
+	}
+	if !slices.Contains(models.OrgRoles, role) {
+		return models.Invitation{}, "", fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "role", Reason: "must be one of " + strings.Join(models.OrgRoles, ", ")})
+	}
+
+	inv := models.Invitation{
+		OrgID:     orgID,
+		Email:     email,
+		Role:      role,
+		Nonce:     jwt.GenerateRandomToken(16),
+		InvitedBy: actorID,
+		ExpiresAt: time.Now().Add(s.invitations.TTL).UTC(),
+	}
+
+	err := s.mutate(ctx, op, actorID, orgID, requiredRole(role), ActionInvite, 0, map[string]any{"org_id": orgID, "email": email, "role": role}, func() error {
+		org, err := s.orgRepo.Get(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		if !org.AllowsEmail(email) {
+			return &serviceerr.FieldError{Field: "email", Reason: "domain is not allowed by the organization"}
+		}
+
+		inv.ID, err = s.orgRepo.CreateInvitation(ctx, inv)
+		return err
+	})
+	if err != nil {
+		return models.Invitation{}, "", err
+	}
+
+	link, err := s.invitationLink(inv)
+	if err != nil {
+		s.log.Error("failed to sign invitation", slog.String("op", op), logger.Err(err))
+		return models.Invitation{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return inv, link, nil
+}
+
+// ListInvitations returns every invitation of the organization, newest first.
+func (s OrgService) ListInvitations(ctx context.Context, actorID, orgID int64) ([]models.Invitation, error) {
+	const op = "OrgService.ListInvitations"
+
+	role, err := s.authorize(ctx, actorID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if roleRank(role) < roleRank(models.OrgRoleAdmin) {
+		return nil, fmt.Errorf("%s: %w", op, admin.ErrPermissionDenied)
+	}
+
+	invitations, err := s.orgRepo.ListInvitations(ctx, orgID)
+	if err != nil {
+		s.log.Error("failed to list invitations", slog.String("op", op), logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return invitations, nil
+}
+
+// ResendInvitation extends an open invitation and returns a new link for it. Links handed
+// out before stop working.
+func (s OrgService) ResendInvitation(ctx context.Context, actorID, orgID, invitationID int64) (models.Invitation, string, error) {
+	const op = "OrgService.ResendInvitation"
+
+	current, err := s.orgRepo.GetInvitation(ctx, invitationID)
+	if err == nil && current.OrgID != orgID {
+		err = repository.ErrInvitationNotFound
+	}
+	if err != nil {
+		if !isExpected(err) {
+			s.log.Error("failed to get invitation", slog.String("op", op), logger.Err(err))
+		}
+		return models.Invitation{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	nonce := jwt.GenerateRandomToken(16)
+	expiresAt := time.Now().Add(s.invitations.TTL).UTC()
+
+	err = s.mutate(ctx, op, actorID, orgID, requiredRole(current.Role), ActionResendInvitation, 0, map[string]any{"org_id": orgID, "invitation_id": invitationID}, func() error {
+		return s.orgRepo.RenewInvitation(ctx, orgID, invitationID, nonce, expiresAt)
+	})
+	if err != nil {
+		return models.Invitation{}, "", err
+	}
+
+	inv, err := s.orgRepo.GetInvitation(ctx, invitationID)
+	if err != nil {
+		s.log.Error("failed to get renewed invitation", slog.String("op", op), logger.Err(err))
+		return models.Invitation{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	link, err := s.invitationLink(inv)
+	if err != nil {
+		s.log.Error("failed to sign invitation", slog.String("op", op), logger.Err(err))
+		return models.Invitation{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return inv, link, nil
+}
+
+func (s OrgService) RevokeInvitation(ctx context.Context, actorID, orgID, invitationID int64) error {
+	const op = "OrgService.RevokeInvitation"
+
+	return s.mutate(ctx, op, actorID, orgID, models.OrgRoleAdmin, ActionRevokeInvitation, 0, map[string]any{"org_id": orgID, "invitation_id": invitationID}, func() error {
+		return s.orgRepo.RevokeInvitation(ctx, orgID, invitationID)
+	})
+}
+
+func (s OrgService) invitationLink(inv models.Invitation) (string, error) {
+	token, err := jwt.GenerateInvitationJWT(s.invitations.SigningKey, inv.ID, inv.Nonce, inv.ExpiresAt)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(s.invitations.AcceptURL, "{token}", url.QueryEscape(token)), nil
+}
@@ -0,0 +1,411 @@
+package orgs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/audit"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/logger"
+)
+
+const (
+	ActionCreate        = "orgs.create"
+	ActionUpdate        = "orgs.update"
+	ActionDelete        = "orgs.delete"
+	ActionAddMember     = "orgs.add_member"
+	ActionRemoveMember  = "orgs.remove_member"
+	ActionSetMemberRole = "orgs.set_member_role"
+)
+
+var (
+	slugPattern   = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+	domainPattern = regexp.MustCompile(`^(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+)
+
+var errMFAUnavailable = &serviceerr.FieldError{Field: "require_mfa", Reason: "members have no second factor to sign in with"}
+
+type OrgRepository interface {
+	Create(ctx context.Context, org models.Organization, ownerID int64) (int64, error)
+	Get(ctx context.Context, orgID int64) (models.Organization, error)
+	ListForUser(ctx context.Context, userID int64) ([]models.OrgMembership, error)
+	Update(ctx context.Context, orgID int64, upd models.OrganizationUpdate) error
+	Delete(ctx context.Context, orgID int64) error
+	GetMembership(ctx context.Context, orgID, userID int64) (models.Membership, error)
+	ListMembers(ctx context.Context, orgID int64) ([]models.Membership, error)
+	AddMember(ctx context.Context, orgID, userID int64, role string) error
+	SetMemberRole(ctx context.Context, orgID, userID int64, role string) error
+	RemoveMember(ctx context.Context, orgID, userID int64) error
+	CreateInvitation(ctx context.Context, inv models.Invitation) (int64, error)
+	GetInvitation(ctx context.Context, invitationID int64) (models.Invitation, error)
+	ListInvitations(ctx context.Context, orgID int64) ([]models.Invitation, error)
+	RenewInvitation(ctx context.Context, orgID, invitationID int64, nonce string, expiresAt time.Time) error
+	RevokeInvitation(ctx context.Context, orgID, invitationID int64) error
+}
+
+type UserRepository interface {
+	Get(ctx context.Context, email string) (models.User, error)
+	GetByID(ctx context.Context, userID int64) (models.User, error)
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+// Policy limits what organizations can require of their members.
+type Policy struct {
+	// MFAAvailable tells whether members have a second factor to step up with. Without one an
+	// organization requiring MFA could not be entered, so the setting is refused.
+	MFAAvailable bool
+}
+
+type OrgService struct {
+	log         *slog.Logger
+	orgRepo     OrgRepository
+	userRepo    UserRepository
+	audit       AuditRepository
+	invitations InvitationSettings
+	policy      Policy
+}
+
+func New(log *slog.Logger, orgRepo OrgRepository, userRepo UserRepository, audit AuditRepository, invitations InvitationSettings, policy Policy) *OrgService {
+	return &OrgService{log: log, orgRepo: orgRepo, userRepo: userRepo, audit: audit, invitations: invitations, policy: policy}
+}
+
+// CreateOrganization creates an organization owned by the actor.
+func (s OrgService) CreateOrganization(ctx context.Context, actorID int64, org models.Organization) (models.Organization, error) {
+	const op = "OrgService.CreateOrganization"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	org.Slug = strings.ToLower(org.Slug)
+	if !slugPattern.MatchString(org.Slug) {
+		return models.Organization{}, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "slug", Reason: "must be 1-63 lowercase letters, digits or dashes"})
+	}
+	if strings.TrimSpace(org.Name) == "" {
+		return models.Organization{}, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "name", Reason: "is required"})
+	}
+
+	domains, err := normalizeDomains(org.AllowedEmailDomains)
+	if err != nil {
+		return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+	}
+	org.AllowedEmailDomains = domains
+
+	if org.RequireMFA && !s.policy.MFAAvailable {
+		return models.Organization{}, fmt.Errorf("%s: %w", op, errMFAUnavailable)
+	}
+
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		log.Error("failed to get actor", logger.Err(err))
+		return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !org.AllowsEmail(actor.Email) {
+		return models.Organization{}, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "allowed_email_domains", Reason: "must include the creator's email domain"})
+	}
+
+	id, err := s.orgRepo.Create(ctx, org, actorID)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to create organization", logger.Err(err))
+		}
+		return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := s.orgRepo.Get(ctx, id)
+	if err != nil {
+		log.Error("failed to get created organization", logger.Err(err))
+		return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionCreate,
+		Details: map[string]any{"org_id": id, "slug": org.Slug},
+	})
+
+	log.Info("organization created", slog.Int64("orgID", id))
+
+	return created, nil
+}
+
+// GetOrganization returns the organization to its members and to admins.
+func (s OrgService) GetOrganization(ctx context.Context, actorID, orgID int64) (models.Organization, error) {
+	const op = "OrgService.GetOrganization"
+
+	if _, err := s.authorize(ctx, actorID, orgID); err != nil {
+		return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	org, err := s.orgRepo.Get(ctx, orgID)
+	if err != nil {
+		if !isExpected(err) {
+			s.log.Error("failed to get organization", slog.String("op", op), logger.Err(err))
+		}
+		return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return org, nil
+}
+
+func (s OrgService) ListMyOrganizations(ctx context.Context, actorID int64) ([]models.OrgMembership, error) {
+	const op = "OrgService.ListMyOrganizations"
+
+	orgs, err := s.orgRepo.ListForUser(ctx, actorID)
+	if err != nil {
+		s.log.Error("failed to list organizations", slog.String("op", op), logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orgs, nil
+}
+
+// UpdateOrganization changes the organization settings. Organization owners and admins may do this.
+func (s OrgService) UpdateOrganization(ctx context.Context, actorID, orgID int64, upd models.OrganizationUpdate) (models.Organization, error) {
+	const op = "OrgService.UpdateOrganization"
+
+	if upd.Name != nil && strings.TrimSpace(*upd.Name) == "" {
+		return models.Organization{}, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "name", Reason: "is required"})
+	}
+	if upd.AllowedEmailDomains != nil {
+		domains, err := normalizeDomains(upd.AllowedEmailDomains)
+		if err != nil {
+			return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+		}
+		upd.AllowedEmailDomains = domains
+	}
+	if upd.RequireMFA != nil && *upd.RequireMFA && !s.policy.MFAAvailable {
+		return models.Organization{}, fmt.Errorf("%s: %w", op, errMFAUnavailable)
+	}
+
+	err := s.mutate(ctx, op, actorID, orgID, models.OrgRoleAdmin, ActionUpdate, 0, map[string]any{"org_id": orgID}, func() error {
+		return s.orgRepo.Update(ctx, orgID, upd)
+	})
+	if err != nil {
+		return models.Organization{}, err
+	}
+
+	org, err := s.orgRepo.Get(ctx, orgID)
+	if err != nil {
+		s.log.Error("failed to get updated organization", slog.String("op", op), logger.Err(err))
+		return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return org, nil
+}
+
+// DeleteOrganization removes the organization and all of its memberships. Only owners may do this.
+func (s OrgService) DeleteOrganization(ctx context.Context, actorID, orgID int64) error {
+	const op = "OrgService.DeleteOrganization"
+
+	return s.mutate(ctx, op, actorID, orgID, models.OrgRoleOwner, ActionDelete, 0, map[string]any{"org_id": orgID}, func() error {
+		return s.orgRepo.Delete(ctx, orgID)
+	})
+}
+
+func (s OrgService) ListMembers(ctx context.Context, actorID, orgID int64) ([]models.Membership, error) {
+	const op = "OrgService.ListMembers"
+
+	if _, err := s.authorize(ctx, actorID, orgID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	members, err := s.orgRepo.ListMembers(ctx, orgID)
+	if err != nil {
+		s.log.Error("failed to list members", slog.String("op", op), logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return members, nil
+}
+
+// AddMember adds an existing user to the organization. Only owners can add other owners.
+func (s OrgService) AddMember(ctx context.Context, actorID, orgID int64, email, role string) (models.Membership, error) {
+	const op = "OrgService.AddMember"
+
+	if !slices.Contains(models.OrgRoles, role) {
+		return models.Membership{}, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "role", Reason: "must be one of " + strings.Join(models.OrgRoles, ", ")})
+	}
+
+	user, err := s.userRepo.Get(ctx, email)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			s.log.Error("failed to get user", slog.String("op", op), logger.Err(err))
+		}
+		return models.Membership{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.mutate(ctx, op, actorID, orgID, requiredRole(role), ActionAddMember, user.ID, map[string]any{"org_id": orgID, "role": role}, func() error {
+		org, err := s.orgRepo.Get(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		if !org.AllowsEmail(user.Email) {
+			return &serviceerr.FieldError{Field: "email", Reason: "domain is not allowed by the organization"}
+		}
+		return s.orgRepo.AddMember(ctx, orgID, user.ID, role)
+	})
+	if err != nil {
+		return models.Membership{}, err
+	}
+
+	membership, err := s.orgRepo.GetMembership(ctx, orgID, user.ID)
+	if err != nil {
+		s.log.Error("failed to get added membership", slog.String("op", op), logger.Err(err))
+		return models.Membership{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return membership, nil
+}
+
+// SetMemberRole changes a member's role. Granting or taking away the owner role requires being an owner.
+func (s OrgService) SetMemberRole(ctx context.Context, actorID, orgID, userID int64, role string) error {
+	const op = "OrgService.SetMemberRole"
+
+	if !slices.Contains(models.OrgRoles, role) {
+		return fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "role", Reason: "must be one of " + strings.Join(models.OrgRoles, ", ")})
+	}
+
+	current, err := s.orgRepo.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		if !isExpected(err) {
+			s.log.Error("failed to get membership", slog.String("op", op), logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	required := requiredRole(role)
+	if current.Role == models.OrgRoleOwner {
+		required = models.OrgRoleOwner
+	}
+
+	return s.mutate(ctx, op, actorID, orgID, required, ActionSetMemberRole, userID, map[string]any{"org_id": orgID, "role": role}, func() error {
+		return s.orgRepo.SetMemberRole(ctx, orgID, userID, role)
+	})
+}
+
+// RemoveMember takes a user out of the organization. Members can always leave on their own;
+// removing an owner requires being an owner.
+func (s OrgService) RemoveMember(ctx context.Context, actorID, orgID, userID int64) error {
+	const op = "OrgService.RemoveMember"
+
+	current, err := s.orgRepo.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		if !isExpected(err) {
+			s.log.Error("failed to get membership", slog.String("op", op), logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	required := requiredRole(current.Role)
+	if actorID == userID {
+		required = models.OrgRoleMember
+	}
+
+	return s.mutate(ctx, op, actorID, orgID, required, ActionRemoveMember, userID, map[string]any{"org_id": orgID}, func() error {
+		return s.orgRepo.RemoveMember(ctx, orgID, userID)
+	})
+}
+
+// authorize returns the actor's role in the organization. Global admins who are not members
+// are treated as owners.
+func (s OrgService) authorize(ctx context.Context, actorID, orgID int64) (string, error) {
+	membership, err := s.orgRepo.GetMembership(ctx, orgID, actorID)
+	if err == nil {
+		return membership.Role, nil
+	}
+	if !errors.Is(err, repository.ErrMembershipNotFound) {
+		return "", err
+	}
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return "", err
+	}
+
+	return models.OrgRoleOwner, nil
+}
+
+func (s OrgService) mutate(ctx context.Context, op string, actorID, orgID int64, required, action string, targetUserID int64, details map[string]any, fn func() error) error {
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int64("orgID", orgID))
+
+	role, err := s.authorize(ctx, actorID, orgID)
+	if err != nil {
+		if !errors.Is(err, admin.ErrPermissionDenied) {
+			log.Error("failed to check organization role", logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if roleRank(role) < roleRank(required) {
+		return fmt.Errorf("%s: %w", op, admin.ErrPermissionDenied)
+	}
+
+	if err := fn(); err != nil {
+		if !isExpected(err) {
+			log.Error("organization action failed", slog.String("action", action), logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: actorID, Action: action, TargetUserID: targetUserID, Details: details})
+
+	log.Info("organization action performed", slog.String("action", action))
+
+	return nil
+}
+
+// requiredRole is the role needed to manage members that have role.
+func requiredRole(role string) string {
+	if role == models.OrgRoleOwner {
+		return models.OrgRoleOwner
+	}
+	return models.OrgRoleAdmin
+}
+
+func roleRank(role string) int {
+	switch role {
+	case models.OrgRoleOwner:
+		return 3
+	case models.OrgRoleAdmin:
+		return 2
+	case models.OrgRoleMember:
+		return 1
+	}
+	return 0
+}
+
+func normalizeDomains(domains []string) ([]string, error) {
+	res := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if !domainPattern.MatchString(d) {
+			return nil, &serviceerr.FieldError{Field: "allowed_email_domains", Reason: fmt.Sprintf("%q is not a domain name", d)}
+		}
+		if !slices.Contains(res, d) {
+			res = append(res, d)
+		}
+	}
+	return res, nil
+}
+
+func isExpected(err error) bool {
+	var ferr *serviceerr.FieldError
+	return errors.As(err, &ferr) ||
+		errors.Is(err, repository.ErrOrgNotFound) ||
+		errors.Is(err, repository.ErrOrgExists) ||
+		errors.Is(err, repository.ErrMembershipNotFound) ||
+		errors.Is(err, repository.ErrMembershipExists) ||
+		errors.Is(err, repository.ErrLastOwner) ||
+		errors.Is(err, repository.ErrInvitationNotFound) ||
+		errors.Is(err, repository.ErrInvitationExists) ||
+		errors.Is(err, repository.ErrUserNotFound)
+}
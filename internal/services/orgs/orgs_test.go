@@ -0,0 +1,100 @@
+package orgs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/serviceerr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store keeps organizations in memory; user 1 owns every organization it creates.
+type store struct {
+	OrgRepository
+	orgs map[int64]models.Organization
+}
+
+func (s *store) Create(_ context.Context, org models.Organization, _ int64) (int64, error) {
+	org.ID = int64(len(s.orgs) + 1)
+	s.orgs[org.ID] = org
+	return org.ID, nil
+}
+
+func (s *store) Get(_ context.Context, orgID int64) (models.Organization, error) {
+	org, ok := s.orgs[orgID]
+	if !ok {
+		return models.Organization{}, repository.ErrOrgNotFound
+	}
+	return org, nil
+}
+
+func (s *store) Update(_ context.Context, orgID int64, upd models.OrganizationUpdate) error {
+	org := s.orgs[orgID]
+	if upd.RequireMFA != nil {
+		org.RequireMFA = *upd.RequireMFA
+	}
+	s.orgs[orgID] = org
+	return nil
+}
+
+func (s *store) GetMembership(_ context.Context, orgID, userID int64) (models.Membership, error) {
+	if _, ok := s.orgs[orgID]; !ok || userID != 1 {
+		return models.Membership{}, repository.ErrMembershipNotFound
+	}
+	return models.Membership{OrgID: orgID, UserID: userID, Role: models.OrgRoleOwner}, nil
+}
+
+type users struct{ UserRepository }
+
+func (users) GetByID(_ context.Context, userID int64) (models.User, error) {
+	return models.User{ID: userID, Email: "owner@example.com"}, nil
+}
+
+type discard struct{}
+
+func (discard) Record(context.Context, models.AuditEntry) error { return nil }
+
+func newTestService(policy Policy) (*OrgService, *store) {
+	st := &store{orgs: make(map[int64]models.Organization)}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(log, st, users{}, discard{}, InvitationSettings{}, policy), st
+}
+
+func TestRequireMFA(t *testing.T) {
+	ctx := context.Background()
+	requireMFA := true
+
+	t.Run("no second factor", func(t *testing.T) {
+		s, st := newTestService(Policy{})
+
+		_, err := s.CreateOrganization(ctx, 1, models.Organization{Slug: "acme", Name: "Acme", RequireMFA: true})
+		var ferr *serviceerr.FieldError
+		require.ErrorAs(t, err, &ferr)
+		assert.Equal(t, "require_mfa", ferr.Field)
+		assert.Empty(t, st.orgs)
+
+		org, err := s.CreateOrganization(ctx, 1, models.Organization{Slug: "acme", Name: "Acme"})
+		require.NoError(t, err)
+
+		_, err = s.UpdateOrganization(ctx, 1, org.ID, models.OrganizationUpdate{RequireMFA: &requireMFA})
+		require.ErrorAs(t, err, &ferr)
+		assert.False(t, st.orgs[org.ID].RequireMFA)
+	})
+
+	t.Run("second factor available", func(t *testing.T) {
+		s, _ := newTestService(Policy{MFAAvailable: true})
+
+		org, err := s.CreateOrganization(ctx, 1, models.Organization{Slug: "acme", Name: "Acme"})
+		require.NoError(t, err)
+
+		org, err = s.UpdateOrganization(ctx, 1, org.ID, models.OrganizationUpdate{RequireMFA: &requireMFA})
+		require.NoError(t, err)
+		assert.True(t, org.RequireMFA)
+	})
+}
@@ -0,0 +1,358 @@
+package passwordless
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/audit"
+	"auth/internal/services/auth"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+	"auth/pkg/mail"
+)
+
+const ActionProvisionUser = "passwordless.provision_user"
+
+const (
+	challengeIDBytes = 32
+	linkTokenBytes   = 32
+	codeDigits       = 6
+)
+
+var (
+	ErrUnknownMethod = errors.New("unknown passwordless method")
+	ErrInvalidEmail  = errors.New("invalid email")
+	// ErrInvalidChallenge is returned for challenges that expired, were used or never existed.
+	ErrInvalidChallenge = errors.New("passwordless login is invalid or expired")
+	ErrInvalidSecret    = errors.New("code or link is invalid")
+	// ErrTooManyAttempts is returned once a challenge has taken too many wrong secrets. It can't
+	// be completed anymore.
+	ErrTooManyAttempts = errors.New("too many attempts")
+)
+
+type UserRepository interface {
+	Get(ctx context.Context, email string) (models.User, error)
+	CreatePasswordless(ctx context.Context, email string) (int64, error)
+	SetEmailVerified(ctx context.Context, userID int64, verified bool) error
+}
+
+type AppRepository interface {
+	Get(ctx context.Context, appID int) (models.App, error)
+}
+
+type ChallengeStorage interface {
+	SavePasswordless(ctx context.Context, challengeID string, challenge models.PasswordlessChallenge, ttl time.Duration) error
+	GetPasswordless(ctx context.Context, challengeID string) (models.PasswordlessChallenge, error)
+	CountPasswordlessAttempt(ctx context.Context, challengeID string) (int, error)
+	DeletePasswordless(ctx context.Context, challengeID string) error
+}
+
+type MailSender interface {
+	Send(ctx context.Context, msg mail.Message) error
+}
+
+// SessionIssuer starts sessions for users the service has signed in.
+type SessionIssuer interface {
+	LoginPasswordless(ctx context.Context, userID int64, appID int, orgID int64, method, ip, userAgent string) (accessToken, refreshToken string, err error)
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+type Policy struct {
+	// TTL is how long a mailed link or code works.
+	TTL time.Duration
+	// MaxAttempts is how many secrets a challenge takes before it is dropped.
+	MaxAttempts int
+	// LinkURL is the page magic links open; "{token}" is replaced with the link's token.
+	LinkURL string
+	// InviteOnly stops accounts from being created on first sign-in, like it stops registration.
+	InviteOnly bool
+}
+
+type PasswordlessService struct {
+	log        *slog.Logger
+	userRepo   UserRepository
+	appRepo    AppRepository
+	challenges ChallengeStorage
+	sender     MailSender
+	sessions   SessionIssuer
+	audit      AuditRepository
+	policy     Policy
+}
+
+func New(log *slog.Logger, userRepo UserRepository, appRepo AppRepository, challenges ChallengeStorage, sender MailSender, sessions SessionIssuer, audit AuditRepository, policy Policy) *PasswordlessService {
+	return &PasswordlessService{
+		log:        log,
+		userRepo:   userRepo,
+		appRepo:    appRepo,
+		challenges: challenges,
+		sender:     sender,
+		sessions:   sessions,
+		audit:      audit,
+		policy:     policy,
+	}
+}
+
+// StartPasswordless mails a magic link or a code for signing in to the app and returns the ID
+// of the challenge they answer. CompletePasswordless needs the ID as well as the secret, so the
+// client that started the login keeps it, for example in a cookie; a link or code forwarded to
+// another device is of no use there.
+//
+// Callers can't tell whether the email has an account: unknown emails get a challenge too, and
+// so do disabled users and, where registration is invite-only, unknown emails, just without
+// any mail being sent.
+func (s PasswordlessService) StartPasswordless(ctx context.Context, email string, appID int, orgID int64, method string) (challengeID string, expiresAt time.Time, err error) {
+	const op = "PasswordlessService.StartPasswordless"
+
+	log := s.log.With(slog.String("op", op), slog.String("email", email), slog.Int("appID", appID), slog.String("method", method))
+
+	if method != models.PasswordlessLink && method != models.PasswordlessCode {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, ErrUnknownMethod)
+	}
+	if addr, err := netmail.ParseAddress(email); err != nil || addr.Address != email {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, ErrInvalidEmail)
+	}
+
+	app, err := s.appRepo.Get(ctx, appID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrAppNotFound) {
+			log.Error("failed to get app", logger.Err(err))
+		}
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	// Checked here as well as when the session starts, so no mail goes out for logins that
+	// can't succeed.
+	if !app.Enabled {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, auth.ErrAppDisabled)
+	}
+	if !app.AllowsGrant(models.GrantPasswordless) {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, auth.ErrGrantNotAllowed)
+	}
+
+	challenge := models.PasswordlessChallenge{Email: email, AppID: appID, OrgID: orgID, Method: method}
+	send := true
+
+	user, err := s.userRepo.Get(ctx, email)
+	switch {
+	case err == nil:
+		challenge.UserID = user.ID
+		if user.Disabled {
+			log.Info("passwordless login for disabled user, nothing sent")
+			send = false
+		}
+	case errors.Is(err, repository.ErrUserNotFound):
+		if s.policy.InviteOnly || app.InviteOnly {
+			log.Info("passwordless login for unknown email, registration is invite-only, nothing sent")
+			send = false
+		}
+	default:
+		log.Error("failed to get user", logger.Err(err))
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	secret, err := newSecret(method)
+	if err != nil {
+		log.Error("failed to generate secret", logger.Err(err))
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	challengeID = jwt.GenerateRandomToken(challengeIDBytes)
+	challenge.SecretHash = secretHash(challengeID, secret)
+	challenge.ExpiresAt = time.Now().Add(s.policy.TTL).UTC()
+
+	if err := s.challenges.SavePasswordless(ctx, challengeID, challenge, s.policy.TTL); err != nil {
+		log.Error("failed to save challenge", logger.Err(err))
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if send {
+		if err := s.sender.Send(ctx, s.message(challenge, secret)); err != nil {
+			log.Error("failed to send mail", logger.Err(err))
+			return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+		}
+		log.Info("passwordless login started")
+	}
+
+	return challengeID, challenge.ExpiresAt, nil
+}
+
+// CompletePasswordless finishes a login started by StartPasswordless with the secret that was
+// mailed: the link's token or the code. It starts a session like Login does, creating the
+// account first if the email didn't have one.
+func (s PasswordlessService) CompletePasswordless(ctx context.Context, challengeID, secret, ip, userAgent string) (accessToken, refreshToken string, err error) {
+	const op = "PasswordlessService.CompletePasswordless"
+
+	log := s.log.With(slog.String("op", op))
+
+	challenge, err := s.challenges.GetPasswordless(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStateNotFound) {
+			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidChallenge)
+		}
+		log.Error("failed to get challenge", logger.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.String("email", challenge.Email), slog.Int("appID", challenge.AppID), slog.String("method", challenge.Method))
+
+	// Attempts are counted before the secret is checked, so concurrent guesses can't get past
+	// the limit.
+	attempts, err := s.challenges.CountPasswordlessAttempt(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStateNotFound) {
+			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidChallenge)
+		}
+		log.Error("failed to count attempt", logger.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if attempts > s.policy.MaxAttempts {
+		s.drop(ctx, log, challengeID)
+		return "", "", fmt.Errorf("%s: %w", op, ErrTooManyAttempts)
+	}
+
+	if challenge.Method == models.PasswordlessCode {
+		secret = strings.ReplaceAll(secret, " ", "")
+	}
+	if !hmac.Equal(secretHash(challengeID, secret), challenge.SecretHash) {
+		log.Info("wrong passwordless secret", slog.Int("attempts", attempts))
+		if attempts >= s.policy.MaxAttempts {
+			s.drop(ctx, log, challengeID)
+			return "", "", fmt.Errorf("%s: %w", op, ErrTooManyAttempts)
+		}
+		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidSecret)
+	}
+
+	if err := s.challenges.DeletePasswordless(ctx, challengeID); err != nil {
+		if errors.Is(err, repository.ErrStateNotFound) {
+			log.Info("challenge was completed concurrently")
+			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidChallenge)
+		}
+		log.Error("failed to delete challenge", logger.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	userID := challenge.UserID
+	if userID == 0 {
+		userID, err = s.provision(ctx, log, challenge)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", op, err)
+		}
+	} else if err := s.userRepo.SetEmailVerified(ctx, userID, true); err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		// The login doesn't depend on it.
+		log.Error("failed to mark email verified", logger.Err(err))
+	}
+
+	accessToken, refreshToken, err = s.sessions.LoginPasswordless(ctx, userID, challenge.AppID, challenge.OrgID, challenge.Method, ip, userAgent)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// provision creates the account of an email that signed in for the first time. If one was
+// registered since the login started, the user signs in to that.
+func (s PasswordlessService) provision(ctx context.Context, log *slog.Logger, challenge models.PasswordlessChallenge) (int64, error) {
+	app, err := s.appRepo.Get(ctx, challenge.AppID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrAppNotFound) {
+			log.Error("failed to get app", logger.Err(err))
+		}
+		return 0, err
+	}
+	if s.policy.InviteOnly || app.InviteOnly {
+		log.Info("provisioning rejected, registration is invite-only")
+		return 0, auth.ErrInvitationRequired
+	}
+
+	userID, err := s.userRepo.CreatePasswordless(ctx, challenge.Email)
+	if errors.Is(err, repository.ErrUserExists) {
+		user, err := s.userRepo.Get(ctx, challenge.Email)
+		if err != nil {
+			log.Error("failed to get user", logger.Err(err))
+			return 0, err
+		}
+		return user.ID, nil
+	}
+	if err != nil {
+		log.Error("failed to provision user", logger.Err(err))
+		return 0, err
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID:      userID,
+		Action:       ActionProvisionUser,
+		TargetUserID: userID,
+		AppID:        challenge.AppID,
+		Details:      map[string]any{"email": challenge.Email, "method": challenge.Method},
+	})
+	log.Info("user provisioned", slog.Int64("userID", userID))
+
+	return userID, nil
+}
+
+func (s PasswordlessService) drop(ctx context.Context, log *slog.Logger, challengeID string) {
+	log.Info("passwordless challenge dropped after too many attempts")
+	if err := s.challenges.DeletePasswordless(ctx, challengeID); err != nil && !errors.Is(err, repository.ErrStateNotFound) {
+		log.Error("failed to delete challenge", logger.Err(err))
+	}
+}
+
+func (s PasswordlessService) message(challenge models.PasswordlessChallenge, secret string) mail.Message {
+	expiry := fmt.Sprintf("%d minutes", int(s.policy.TTL.Minutes()))
+
+	if challenge.Method == models.PasswordlessCode {
+		return mail.Message{
+			To:      challenge.Email,
+			Subject: "Your sign-in code",
+			Text: "Your sign-in code is " + secret + ".\n\n" +
+				"It expires in " + expiry + ". If you didn't try to sign in, you can ignore this email.",
+		}
+	}
+
+	link := strings.ReplaceAll(s.policy.LinkURL, "{token}", url.QueryEscape(secret))
+	return mail.Message{
+		To:      challenge.Email,
+		Subject: "Your sign-in link",
+		Text: "Open this link on the device you started signing in on:\n\n" + link + "\n\n" +
+			"It expires in " + expiry + ". If you didn't try to sign in, you can ignore this email.",
+	}
+}
+
+func newSecret(method string) (string, error) {
+	if method == models.PasswordlessLink {
+		return jwt.GenerateRandomToken(linkTokenBytes), nil
+	}
+
+	max := big.NewInt(1)
+	for range codeDigits {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n), nil
+}
+
+// secretHash keys the MAC with the challenge ID, so a stored hash of a code can't be looked up
+// without it.
+func secretHash(challengeID, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(challengeID))
+	mac.Write([]byte(secret))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,276 @@
+package passwordless
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/auth"
+	"auth/pkg/mail"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store keeps users, apps and challenges in memory, records sent mail and hands out a session
+// per login.
+type store struct {
+	users      map[int64]models.User
+	apps       map[int]models.App
+	challenges map[string]models.PasswordlessChallenge
+	attempts   map[string]int
+	sent       []mail.Message
+	logins     []int64
+	recorded   []string
+	nextID     int64
+}
+
+func newStore() *store {
+	all := []string{models.GrantPassword, models.GrantPasswordless}
+	return &store{
+		users: map[int64]models.User{
+			1: {ID: 1, Email: "ann@example.com"},
+			2: {ID: 2, Email: "gone@example.com", Disabled: true},
+		},
+		apps: map[int]models.App{
+			1: {ID: 1, Enabled: true, GrantTypes: all},
+			2: {ID: 2, Enabled: true, GrantTypes: all, InviteOnly: true},
+			3: {ID: 3, Enabled: true, GrantTypes: []string{models.GrantPassword}},
+		},
+		challenges: make(map[string]models.PasswordlessChallenge),
+		attempts:   make(map[string]int),
+		nextID:     10,
+	}
+}
+
+func (s *store) Get(_ context.Context, email string) (models.User, error) {
+	for _, u := range s.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return models.User{}, repository.ErrUserNotFound
+}
+
+func (s *store) CreatePasswordless(ctx context.Context, email string) (int64, error) {
+	if _, err := s.Get(ctx, email); err == nil {
+		return 0, repository.ErrUserExists
+	}
+	s.nextID++
+	s.users[s.nextID] = models.User{ID: s.nextID, Email: email, EmailVerified: true}
+	return s.nextID, nil
+}
+
+func (s *store) SetEmailVerified(_ context.Context, userID int64, verified bool) error {
+	u := s.users[userID]
+	u.EmailVerified = verified
+	s.users[userID] = u
+	return nil
+}
+
+func (s *store) SavePasswordless(_ context.Context, challengeID string, c models.PasswordlessChallenge, _ time.Duration) error {
+	s.challenges[challengeID] = c
+	return nil
+}
+
+func (s *store) GetPasswordless(_ context.Context, challengeID string) (models.PasswordlessChallenge, error) {
+	c, ok := s.challenges[challengeID]
+	if !ok {
+		return models.PasswordlessChallenge{}, repository.ErrStateNotFound
+	}
+	return c, nil
+}
+
+func (s *store) CountPasswordlessAttempt(_ context.Context, challengeID string) (int, error) {
+	if _, ok := s.challenges[challengeID]; !ok {
+		return 0, repository.ErrStateNotFound
+	}
+	s.attempts[challengeID]++
+	return s.attempts[challengeID], nil
+}
+
+func (s *store) DeletePasswordless(_ context.Context, challengeID string) error {
+	if _, ok := s.challenges[challengeID]; !ok {
+		return repository.ErrStateNotFound
+	}
+	delete(s.challenges, challengeID)
+	return nil
+}
+
+func (s *store) Send(_ context.Context, msg mail.Message) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *store) LoginPasswordless(_ context.Context, userID int64, _ int, _ int64, _, _, _ string) (string, string, error) {
+	if s.users[userID].Disabled {
+		return "", "", auth.ErrUserDisabled
+	}
+	s.logins = append(s.logins, userID)
+	return "access", "refresh", nil
+}
+
+func (s *store) Record(_ context.Context, entry models.AuditEntry) error {
+	s.recorded = append(s.recorded, entry.Action)
+	return nil
+}
+
+// appRepo serves the store's apps; the store's own Get looks up users.
+type appRepo struct{ *store }
+
+func (r appRepo) Get(_ context.Context, appID int) (models.App, error) {
+	app, ok := r.apps[appID]
+	if !ok {
+		return models.App{}, repository.ErrAppNotFound
+	}
+	return app, nil
+}
+
+func newService(policy Policy) (*PasswordlessService, *store) {
+	st := newStore()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if policy.TTL == 0 {
+		policy.TTL = 10 * time.Minute
+	}
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = 3
+	}
+	policy.LinkURL = "https://app.example.com/magic?token={token}"
+	return New(log, st, appRepo{st}, st, st, st, st, policy), st
+}
+
+var codePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+func TestCode(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService(Policy{})
+
+	challengeID, expiresAt, err := s.StartPasswordless(ctx, "ann@example.com", 1, 0, models.PasswordlessCode)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), expiresAt, time.Minute)
+	require.Len(t, st.sent, 1)
+	assert.Equal(t, "ann@example.com", st.sent[0].To)
+	assert.Contains(t, st.sent[0].Text, "10 minutes")
+	code := codePattern.FindString(st.sent[0].Text)
+	require.NotEmpty(t, code)
+	assert.NotContains(t, string(st.challenges[challengeID].SecretHash), code, "codes are stored hashed")
+
+	// A forwarded code is no use without the challenge ID of the device that asked for it.
+	_, _, err = s.CompletePasswordless(ctx, "other-device", code, "", "")
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+	access, refresh, err := s.CompletePasswordless(ctx, challengeID, code[:3]+" "+code[3:], "10.0.0.1", "test")
+	require.NoError(t, err)
+	assert.Equal(t, "access", access)
+	assert.Equal(t, "refresh", refresh)
+	assert.Equal(t, []int64{1}, st.logins)
+	assert.True(t, st.users[1].EmailVerified)
+
+	_, _, err = s.CompletePasswordless(ctx, challengeID, code, "", "")
+	assert.ErrorIs(t, err, ErrInvalidChallenge, "challenges are single use")
+}
+
+func TestAttemptLimit(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService(Policy{MaxAttempts: 2})
+
+	challengeID, _, err := s.StartPasswordless(ctx, "ann@example.com", 1, 0, models.PasswordlessCode)
+	require.NoError(t, err)
+	code := codePattern.FindString(st.sent[0].Text)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	_, _, err = s.CompletePasswordless(ctx, challengeID, wrong, "", "")
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+	_, _, err = s.CompletePasswordless(ctx, challengeID, wrong, "", "")
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+	_, _, err = s.CompletePasswordless(ctx, challengeID, code, "", "")
+	assert.ErrorIs(t, err, ErrInvalidChallenge, "the right code no longer works")
+	assert.Empty(t, st.logins)
+}
+
+func TestMagicLink(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService(Policy{})
+
+	challengeID, _, err := s.StartPasswordless(ctx, "new@example.com", 1, 0, models.PasswordlessLink)
+	require.NoError(t, err)
+	require.Len(t, st.sent, 1)
+
+	link := regexp.MustCompile(`https://\S+`).FindString(st.sent[0].Text)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	token := u.Query().Get("token")
+	require.NotEmpty(t, token)
+	assert.NotContains(t, link, challengeID)
+
+	_, _, err = s.CompletePasswordless(ctx, challengeID, token, "", "")
+	require.NoError(t, err)
+
+	created, err := st.Get(ctx, "new@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{created.ID}, st.logins)
+	assert.Equal(t, []string{ActionProvisionUser}, st.recorded)
+}
+
+func TestNothingSent(t *testing.T) {
+	ctx := context.Background()
+
+	for name, start := range map[string]struct {
+		email string
+		appID int
+	}{
+		"disabled user":                  {"gone@example.com", 1},
+		"unknown email, invite-only app": {"new@example.com", 2},
+		// App 0 stands for invite-only registration everywhere.
+		"unknown email, invite-only": {"new@example.com", 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			policy := Policy{}
+			appID := start.appID
+			if appID == 0 {
+				policy.InviteOnly, appID = true, 1
+			}
+			s, st := newService(policy)
+
+			challengeID, _, err := s.StartPasswordless(ctx, start.email, appID, 0, models.PasswordlessCode)
+			require.NoError(t, err, "callers can't tell")
+			assert.NotEmpty(t, challengeID)
+			assert.Empty(t, st.sent)
+		})
+	}
+}
+
+func TestStartRejected(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService(Policy{})
+
+	_, _, err := s.StartPasswordless(ctx, "ann@example.com", 1, 0, "sms")
+	assert.ErrorIs(t, err, ErrUnknownMethod)
+	_, _, err = s.StartPasswordless(ctx, "Ann <ann@example.com>", 1, 0, models.PasswordlessCode)
+	assert.ErrorIs(t, err, ErrInvalidEmail)
+	_, _, err = s.StartPasswordless(ctx, "ann@example.com", 3, 0, models.PasswordlessCode)
+	assert.ErrorIs(t, err, auth.ErrGrantNotAllowed)
+	_, _, err = s.StartPasswordless(ctx, "ann@example.com", 99, 0, models.PasswordlessCode)
+	assert.ErrorIs(t, err, repository.ErrAppNotFound)
+
+	assert.Empty(t, st.sent)
+	assert.Empty(t, st.challenges)
+}
+
+func TestMessage(t *testing.T) {
+	s, _ := newService(Policy{TTL: time.Hour})
+
+	msg := s.message(models.PasswordlessChallenge{Email: "ann@example.com", Method: models.PasswordlessLink}, "a+b/c=")
+	assert.Contains(t, msg.Text, "https://app.example.com/magic?token=a%2Bb%2Fc%3D")
+	assert.Contains(t, msg.Text, "60 minutes")
+}
@@ -0,0 +1,509 @@
+package phone
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/audit"
+	"auth/internal/services/auth"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+	"auth/pkg/sms"
+)
+
+const (
+	ActionProvisionUser = "phone.provision_user"
+	ActionVerify        = "phone.verify"
+)
+
+const (
+	challengeIDBytes = 32
+	codeDigits       = 6
+)
+
+var (
+	ErrInvalidPhone = errors.New("invalid phone number")
+	// ErrInvalidChallenge is returned for challenges that expired, were used or never existed.
+	ErrInvalidChallenge = errors.New("phone code is invalid or expired")
+	ErrInvalidCode      = errors.New("code is invalid")
+	// ErrTooManyAttempts is returned once a challenge has taken too many wrong codes. It can't
+	// be completed anymore.
+	ErrTooManyAttempts = errors.New("too many attempts")
+	// ErrRateLimited is returned when too many codes were texted to the number or to numbers
+	// like it lately.
+	ErrRateLimited = errors.New("too many text messages sent")
+	// ErrNoVerifiedPhone is returned for step-ups of users without a verified phone number.
+	ErrNoVerifiedPhone = errors.New("user has no verified phone number")
+)
+
+type UserRepository interface {
+	GetByID(ctx context.Context, userID int64) (models.User, error)
+	GetByPhone(ctx context.Context, phone string) (models.User, error)
+	CreateWithPhone(ctx context.Context, phone string) (int64, error)
+	SetPhoneVerified(ctx context.Context, userID int64, phone string) error
+}
+
+type AppRepository interface {
+	Get(ctx context.Context, appID int) (models.App, error)
+}
+
+type ChallengeStorage interface {
+	SavePhone(ctx context.Context, challengeID string, challenge models.PhoneChallenge, ttl time.Duration) error
+	GetPhone(ctx context.Context, challengeID string) (models.PhoneChallenge, error)
+	CountPhoneAttempt(ctx context.Context, challengeID string) (int, error)
+	DeletePhone(ctx context.Context, challengeID string) error
+	CountSMS(ctx context.Context, scope string, window time.Duration) (int, error)
+}
+
+// SMSSender is the SMS provider.
+type SMSSender interface {
+	Send(ctx context.Context, msg sms.Message) error
+}
+
+// SessionIssuer starts sessions for users the service has signed in and steps up sessions of
+// users who entered a code.
+type SessionIssuer interface {
+	LoginPhone(ctx context.Context, userID int64, appID int, orgID int64, ip, userAgent string) (accessToken, refreshToken string, err error)
+	StepUpVerified(ctx context.Context, refreshToken string, userID int64, method string) (accessToken, newRefreshToken string, err error)
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+type Policy struct {
+	// TTL is how long a texted code works.
+	TTL time.Duration
+	// MaxAttempts is how many codes a challenge takes before it is dropped.
+	MaxAttempts int
+	// InviteOnly stops accounts from being created on first sign-in, like it stops registration.
+	InviteOnly bool
+	// NumberLimit is how many codes a phone number is sent per NumberWindow.
+	NumberLimit  int
+	NumberWindow time.Duration
+	// PrefixLimit is how many codes are sent per PrefixWindow to numbers starting with the same
+	// PrefixDigits digits. SMS pumping runs through many numbers of the same premium range, so
+	// limiting numbers one by one doesn't stop it.
+	PrefixLimit  int
+	PrefixWindow time.Duration
+	PrefixDigits int
+}
+
+type PhoneService struct {
+	log        *slog.Logger
+	userRepo   UserRepository
+	appRepo    AppRepository
+	challenges ChallengeStorage
+	sender     SMSSender
+	sessions   SessionIssuer
+	audit      AuditRepository
+	policy     Policy
+}
+
+func New(log *slog.Logger, userRepo UserRepository, appRepo AppRepository, challenges ChallengeStorage, sender SMSSender, sessions SessionIssuer, audit AuditRepository, policy Policy) *PhoneService {
+	return &PhoneService{
+		log:        log,
+		userRepo:   userRepo,
+		appRepo:    appRepo,
+		challenges: challenges,
+		sender:     sender,
+		sessions:   sessions,
+		audit:      audit,
+		policy:     policy,
+	}
+}
+
+// StartPhoneLogin texts a code for signing in to the app and returns the ID of the challenge
+// it answers. Like with passwordless logins, the client that started the login keeps the ID.
+//
+// Callers can't tell whether the number has an account: unknown numbers get a challenge too,
+// and so do disabled users and, where registration is invite-only, unknown numbers, just
+// without a text being sent. They count against the rate limits all the same.
+func (s PhoneService) StartPhoneLogin(ctx context.Context, phone string, appID int, orgID int64) (challengeID string, expiresAt time.Time, err error) {
+	const op = "PhoneService.StartPhoneLogin"
+
+	phone, err = sms.Normalize(phone)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, ErrInvalidPhone)
+	}
+
+	log := s.log.With(slog.String("op", op), slog.String("phone", phone), slog.Int("appID", appID))
+
+	app, err := s.appRepo.Get(ctx, appID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrAppNotFound) {
+			log.Error("failed to get app", logger.Err(err))
+		}
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	// Checked here as well as when the session starts, so no text goes out for logins that
+	// can't succeed.
+	if !app.Enabled {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, auth.ErrAppDisabled)
+	}
+	if !app.AllowsGrant(models.GrantPhone) {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, auth.ErrGrantNotAllowed)
+	}
+
+	if err := s.allow(ctx, log, phone); err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	challenge := models.PhoneChallenge{Phone: phone, Purpose: models.PhoneLogin, AppID: appID, OrgID: orgID}
+	send := true
+
+	user, err := s.userRepo.GetByPhone(ctx, phone)
+	switch {
+	case err == nil:
+		challenge.UserID = user.ID
+		if user.Disabled {
+			log.Info("phone login for disabled user, nothing sent")
+			send = false
+		}
+	case errors.Is(err, repository.ErrUserNotFound):
+		if s.policy.InviteOnly || app.InviteOnly {
+			log.Info("phone login for unknown number, registration is invite-only, nothing sent")
+			send = false
+		}
+	default:
+		log.Error("failed to get user", logger.Err(err))
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	challengeID, expiresAt, err = s.start(ctx, log, challenge, send)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return challengeID, expiresAt, nil
+}
+
+// CompletePhoneLogin finishes a login started by StartPhoneLogin with the texted code. It
+// starts a session like Login does, creating the account first if the number didn't have one.
+func (s PhoneService) CompletePhoneLogin(ctx context.Context, challengeID, code, ip, userAgent string) (accessToken, refreshToken string, err error) {
+	const op = "PhoneService.CompletePhoneLogin"
+
+	log := s.log.With(slog.String("op", op))
+
+	challenge, err := s.complete(ctx, log, challengeID, code, models.PhoneLogin, 0)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.String("phone", challenge.Phone), slog.Int("appID", challenge.AppID))
+
+	userID := challenge.UserID
+	if userID == 0 {
+		userID, err = s.provision(ctx, log, challenge)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	accessToken, refreshToken, err = s.sessions.LoginPhone(ctx, userID, challenge.AppID, challenge.OrgID, ip, userAgent)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// StartPhoneVerification texts a code to the number the user wants to verify. Once confirmed,
+// the number signs the user in and replaces the one in their profile.
+func (s PhoneService) StartPhoneVerification(ctx context.Context, userID int64, phone string) (challengeID string, expiresAt time.Time, err error) {
+	const op = "PhoneService.StartPhoneVerification"
+
+	phone, err = sms.Normalize(phone)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, ErrInvalidPhone)
+	}
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID), slog.String("phone", phone))
+
+	if err := s.allow(ctx, log, phone); err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	challenge := models.PhoneChallenge{Phone: phone, Purpose: models.PhoneVerify, UserID: userID}
+	challengeID, expiresAt, err = s.start(ctx, log, challenge, true)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return challengeID, expiresAt, nil
+}
+
+// ConfirmPhoneVerification verifies the number of a challenge started by the same user with
+// StartPhoneVerification. It fails with repository.ErrPhoneTaken if another user has verified
+// the number in the meantime.
+func (s PhoneService) ConfirmPhoneVerification(ctx context.Context, userID int64, challengeID, code string) (phone string, err error) {
+	const op = "PhoneService.ConfirmPhoneVerification"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	challenge, err := s.complete(ctx, log, challengeID, code, models.PhoneVerify, userID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.userRepo.SetPhoneVerified(ctx, userID, challenge.Phone); err != nil {
+		if !errors.Is(err, repository.ErrPhoneTaken) && !errors.Is(err, repository.ErrUserNotFound) {
+			log.Error("failed to verify phone", logger.Err(err))
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID:      userID,
+		Action:       ActionVerify,
+		TargetUserID: userID,
+		Details:      map[string]any{"phone": challenge.Phone},
+	})
+	log.Info("phone verified", slog.String("phone", challenge.Phone))
+
+	return challenge.Phone, nil
+}
+
+// StartStepUp texts a code to the user's verified phone number for stepping up one of their
+// sessions.
+func (s PhoneService) StartStepUp(ctx context.Context, userID int64) (challengeID string, expiresAt time.Time, err error) {
+	const op = "PhoneService.StartStepUp"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			log.Error("failed to get user", logger.Err(err))
+		}
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !user.PhoneVerified {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, ErrNoVerifiedPhone)
+	}
+
+	if err := s.allow(ctx, log, user.Phone); err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	challenge := models.PhoneChallenge{Phone: user.Phone, Purpose: models.PhoneStepUp, UserID: userID}
+	challengeID, expiresAt, err = s.start(ctx, log, challenge, true)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return challengeID, expiresAt, nil
+}
+
+// CompleteStepUp steps up the user's session of refreshToken with the code StartStepUp texted,
+// adding sms to the session's amr. The refresh token is replaced.
+func (s PhoneService) CompleteStepUp(ctx context.Context, userID int64, refreshToken, challengeID, code string) (accessToken, newRefreshToken string, err error) {
+	const op = "PhoneService.CompleteStepUp"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	if _, err := s.complete(ctx, log, challengeID, code, models.PhoneStepUp, userID); err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	accessToken, newRefreshToken, err = s.sessions.StepUpVerified(ctx, refreshToken, userID, jwt.AMRSMS)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return accessToken, newRefreshToken, nil
+}
+
+// allow counts a text to phone against the rate limits and fails if it goes over either.
+func (s PhoneService) allow(ctx context.Context, log *slog.Logger, phone string) error {
+	for _, limit := range []struct {
+		scope  string
+		max    int
+		window time.Duration
+	}{
+		{"number:" + phone, s.policy.NumberLimit, s.policy.NumberWindow},
+		{"prefix:" + sms.Prefix(phone, s.policy.PrefixDigits), s.policy.PrefixLimit, s.policy.PrefixWindow},
+	} {
+		n, err := s.challenges.CountSMS(ctx, limit.scope, limit.window)
+		if err != nil {
+			log.Error("failed to count text message", logger.Err(err))
+			return err
+		}
+		if n > limit.max {
+			log.Warn("text message rate limited", slog.String("scope", limit.scope), slog.Int("count", n))
+			return ErrRateLimited
+		}
+	}
+
+	return nil
+}
+
+func (s PhoneService) start(ctx context.Context, log *slog.Logger, challenge models.PhoneChallenge, send bool) (challengeID string, expiresAt time.Time, err error) {
+	code, err := newCode()
+	if err != nil {
+		log.Error("failed to generate code", logger.Err(err))
+		return "", time.Time{}, err
+	}
+
+	challengeID = jwt.GenerateRandomToken(challengeIDBytes)
+	challenge.CodeHash = codeHash(challengeID, code)
+	challenge.ExpiresAt = time.Now().Add(s.policy.TTL).UTC()
+
+	if err := s.challenges.SavePhone(ctx, challengeID, challenge, s.policy.TTL); err != nil {
+		log.Error("failed to save challenge", logger.Err(err))
+		return "", time.Time{}, err
+	}
+
+	if send {
+		if err := s.sender.Send(ctx, s.message(challenge, code)); err != nil {
+			log.Error("failed to send text message", logger.Err(err))
+			return "", time.Time{}, err
+		}
+		log.Info("phone code sent", slog.String("purpose", challenge.Purpose))
+	}
+
+	return challengeID, challenge.ExpiresAt, nil
+}
+
+// complete checks the code of a challenge for purpose and uses the challenge up. Unless userID
+// is zero, the challenge must have been started by that user.
+func (s PhoneService) complete(ctx context.Context, log *slog.Logger, challengeID, code, purpose string, userID int64) (models.PhoneChallenge, error) {
+	challenge, err := s.challenges.GetPhone(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStateNotFound) {
+			return models.PhoneChallenge{}, ErrInvalidChallenge
+		}
+		log.Error("failed to get challenge", logger.Err(err))
+		return models.PhoneChallenge{}, err
+	}
+	if challenge.Purpose != purpose || (userID != 0 && challenge.UserID != userID) {
+		return models.PhoneChallenge{}, ErrInvalidChallenge
+	}
+
+	// Attempts are counted before the code is checked, so concurrent guesses can't get past
+	// the limit.
+	attempts, err := s.challenges.CountPhoneAttempt(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStateNotFound) {
+			return models.PhoneChallenge{}, ErrInvalidChallenge
+		}
+		log.Error("failed to count attempt", logger.Err(err))
+		return models.PhoneChallenge{}, err
+	}
+	if attempts > s.policy.MaxAttempts {
+		s.drop(ctx, log, challengeID)
+		return models.PhoneChallenge{}, ErrTooManyAttempts
+	}
+
+	code = strings.ReplaceAll(code, " ", "")
+	if !hmac.Equal(codeHash(challengeID, code), challenge.CodeHash) {
+		log.Info("wrong phone code", slog.Int("attempts", attempts))
+		if attempts >= s.policy.MaxAttempts {
+			s.drop(ctx, log, challengeID)
+			return models.PhoneChallenge{}, ErrTooManyAttempts
+		}
+		return models.PhoneChallenge{}, ErrInvalidCode
+	}
+
+	if err := s.challenges.DeletePhone(ctx, challengeID); err != nil {
+		if errors.Is(err, repository.ErrStateNotFound) {
+			log.Info("challenge was completed concurrently")
+			return models.PhoneChallenge{}, ErrInvalidChallenge
+		}
+		log.Error("failed to delete challenge", logger.Err(err))
+		return models.PhoneChallenge{}, err
+	}
+
+	return challenge, nil
+}
+
+// provision creates the account of a number that signed in for the first time. If one was
+// registered since the login started, the user signs in to that.
+func (s PhoneService) provision(ctx context.Context, log *slog.Logger, challenge models.PhoneChallenge) (int64, error) {
+	app, err := s.appRepo.Get(ctx, challenge.AppID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrAppNotFound) {
+			log.Error("failed to get app", logger.Err(err))
+		}
+		return 0, err
+	}
+	if s.policy.InviteOnly || app.InviteOnly {
+		log.Info("provisioning rejected, registration is invite-only")
+		return 0, auth.ErrInvitationRequired
+	}
+
+	userID, err := s.userRepo.CreateWithPhone(ctx, challenge.Phone)
+	if errors.Is(err, repository.ErrUserExists) {
+		user, err := s.userRepo.GetByPhone(ctx, challenge.Phone)
+		if err != nil {
+			log.Error("failed to get user", logger.Err(err))
+			return 0, err
+		}
+		return user.ID, nil
+	}
+	if err != nil {
+		log.Error("failed to provision user", logger.Err(err))
+		return 0, err
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID:      userID,
+		Action:       ActionProvisionUser,
+		TargetUserID: userID,
+		AppID:        challenge.AppID,
+		Details:      map[string]any{"phone": challenge.Phone},
+	})
+	log.Info("user provisioned", slog.Int64("userID", userID))
+
+	return userID, nil
+}
+
+func (s PhoneService) drop(ctx context.Context, log *slog.Logger, challengeID string) {
+	log.Info("phone challenge dropped after too many attempts")
+	if err := s.challenges.DeletePhone(ctx, challengeID); err != nil && !errors.Is(err, repository.ErrStateNotFound) {
+		log.Error("failed to delete challenge", logger.Err(err))
+	}
+}
+
+func (s PhoneService) message(challenge models.PhoneChallenge, code string) sms.Message {
+	kind := "sign-in"
+	switch challenge.Purpose {
+	case models.PhoneVerify:
+		kind = "verification"
+	case models.PhoneStepUp:
+		kind = "confirmation"
+	}
+	text := fmt.Sprintf("Your %s code is %s. It expires in %d minutes.", kind, code, int(s.policy.TTL.Minutes()))
+
+	return sms.Message{To: challenge.Phone, Text: text}
+}
+
+func newCode() (string, error) {
+	max := big.NewInt(1)
+	for range codeDigits {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n), nil
+}
+
+// codeHash keys the MAC with the challenge ID, so a stored hash of a code can't be looked up
+// without it.
+func codeHash(challengeID, code string) []byte {
+	mac := hmac.New(sha256.New, []byte(challengeID))
+	mac.Write([]byte(code))
+	return mac.Sum(nil)
+}
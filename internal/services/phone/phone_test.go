@@ -0,0 +1,378 @@
+package phone
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/auth"
+	"auth/pkg/sms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store keeps users, apps, challenges and text counts in memory and hands out a session per
+// login.
+type store struct {
+	users      map[int64]models.User
+	apps       map[int]models.App
+	challenges map[string]models.PhoneChallenge
+	attempts   map[string]int
+	texts      map[string]int
+	logins     []int64
+	stepUps    []string
+	recorded   []string
+	nextID     int64
+}
+
+func newStore() *store {
+	all := []string{models.GrantPassword, models.GrantPhone}
+	return &store{
+		users: map[int64]models.User{
+			1: {ID: 1, Phone: "+447911000001", PhoneVerified: true},
+			2: {ID: 2, Phone: "+447911000002", PhoneVerified: true, Disabled: true},
+			3: {ID: 3, Email: "ann@example.com", Phone: "+447911000003"},
+		},
+		apps: map[int]models.App{
+			1: {ID: 1, Enabled: true, GrantTypes: all},
+			2: {ID: 2, Enabled: true, GrantTypes: all, InviteOnly: true},
+			3: {ID: 3, Enabled: true, GrantTypes: []string{models.GrantPassword}},
+		},
+		challenges: make(map[string]models.PhoneChallenge),
+		attempts:   make(map[string]int),
+		texts:      make(map[string]int),
+		nextID:     10,
+	}
+}
+
+func (s *store) GetByID(_ context.Context, userID int64) (models.User, error) {
+	u, ok := s.users[userID]
+	if !ok {
+		return models.User{}, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (s *store) GetByPhone(_ context.Context, phone string) (models.User, error) {
+	for _, u := range s.users {
+		if u.Phone == phone && u.PhoneVerified {
+			return u, nil
+		}
+	}
+	return models.User{}, repository.ErrUserNotFound
+}
+
+func (s *store) CreateWithPhone(ctx context.Context, phone string) (int64, error) {
+	if _, err := s.GetByPhone(ctx, phone); err == nil {
+		return 0, repository.ErrUserExists
+	}
+	s.nextID++
+	s.users[s.nextID] = models.User{ID: s.nextID, Phone: phone, PhoneVerified: true}
+	return s.nextID, nil
+}
+
+func (s *store) SetPhoneVerified(ctx context.Context, userID int64, phone string) error {
+	if u, err := s.GetByPhone(ctx, phone); err == nil && u.ID != userID {
+		return repository.ErrPhoneTaken
+	}
+	u := s.users[userID]
+	u.Phone, u.PhoneVerified = phone, true
+	s.users[userID] = u
+	return nil
+}
+
+func (s *store) SavePhone(_ context.Context, challengeID string, c models.PhoneChallenge, _ time.Duration) error {
+	s.challenges[challengeID] = c
+	return nil
+}
+
+func (s *store) GetPhone(_ context.Context, challengeID string) (models.PhoneChallenge, error) {
+	c, ok := s.challenges[challengeID]
+	if !ok {
+		return models.PhoneChallenge{}, repository.ErrStateNotFound
+	}
+	return c, nil
+}
+
+func (s *store) CountPhoneAttempt(_ context.Context, challengeID string) (int, error) {
+	if _, ok := s.challenges[challengeID]; !ok {
+		return 0, repository.ErrStateNotFound
+	}
+	s.attempts[challengeID]++
+	return s.attempts[challengeID], nil
+}
+
+func (s *store) DeletePhone(_ context.Context, challengeID string) error {
+	if _, ok := s.challenges[challengeID]; !ok {
+		return repository.ErrStateNotFound
+	}
+	delete(s.challenges, challengeID)
+	return nil
+}
+
+func (s *store) CountSMS(_ context.Context, scope string, _ time.Duration) (int, error) {
+	s.texts[scope]++
+	return s.texts[scope], nil
+}
+
+func (s *store) LoginPhone(_ context.Context, userID int64, _ int, _ int64, _, _ string) (string, string, error) {
+	if s.users[userID].Disabled {
+		return "", "", auth.ErrUserDisabled
+	}
+	s.logins = append(s.logins, userID)
+	return "access", "refresh", nil
+}
+
+func (s *store) StepUpVerified(_ context.Context, refreshToken string, _ int64, method string) (string, string, error) {
+	s.stepUps = append(s.stepUps, refreshToken+":"+method)
+	return "access", "refresh", nil
+}
+
+func (s *store) Record(_ context.Context, entry models.AuditEntry) error {
+	s.recorded = append(s.recorded, entry.Action)
+	return nil
+}
+
+// appRepo serves the store's apps.
+type appRepo struct{ *store }
+
+func (r appRepo) Get(_ context.Context, appID int) (models.App, error) {
+	app, ok := r.apps[appID]
+	if !ok {
+		return models.App{}, repository.ErrAppNotFound
+	}
+	return app, nil
+}
+
+func newService(policy Policy) (*PhoneService, *store, *sms.MemorySender) {
+	st := newStore()
+	sender := sms.NewMemorySender()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if policy.TTL == 0 {
+		policy.TTL = 5 * time.Minute
+	}
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.NumberLimit == 0 {
+		policy.NumberLimit = 10
+	}
+	if policy.PrefixLimit == 0 {
+		policy.PrefixLimit = 100
+	}
+	if policy.PrefixDigits == 0 {
+		policy.PrefixDigits = 6
+	}
+	return New(log, st, appRepo{st}, st, sender, st, st, policy), st, sender
+}
+
+var codePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+func lastCode(t *testing.T, sender *sms.MemorySender) string {
+	t.Helper()
+
+	sent := sender.Sent()
+	require.NotEmpty(t, sent)
+	code := codePattern.FindString(sent[len(sent)-1].Text)
+	require.NotEmpty(t, code)
+	return code
+}
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+	s, st, sender := newService(Policy{})
+
+	challengeID, expiresAt, err := s.StartPhoneLogin(ctx, "+44 7911 000001", 1, 0)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), expiresAt, time.Minute)
+	require.Len(t, sender.Sent(), 1)
+	assert.Equal(t, "+447911000001", sender.Sent()[0].To)
+	assert.Contains(t, sender.Sent()[0].Text, "5 minutes")
+	code := lastCode(t, sender)
+
+	_, _, err = s.CompletePhoneLogin(ctx, "other-device", code, "", "")
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+	access, refresh, err := s.CompletePhoneLogin(ctx, challengeID, code[:3]+" "+code[3:], "10.0.0.1", "test")
+	require.NoError(t, err)
+	assert.Equal(t, "access", access)
+	assert.Equal(t, "refresh", refresh)
+	assert.Equal(t, []int64{1}, st.logins)
+
+	_, _, err = s.CompletePhoneLogin(ctx, challengeID, code, "", "")
+	assert.ErrorIs(t, err, ErrInvalidChallenge, "challenges are single use")
+}
+
+func TestSignUp(t *testing.T) {
+	ctx := context.Background()
+	s, st, sender := newService(Policy{})
+
+	// The number is on a profile but unverified, so it belongs to no one yet.
+	challengeID, _, err := s.StartPhoneLogin(ctx, "+447911000003", 1, 0)
+	require.NoError(t, err)
+	_, _, err = s.CompletePhoneLogin(ctx, challengeID, lastCode(t, sender), "", "")
+	require.NoError(t, err)
+
+	created, err := st.GetByPhone(ctx, "+447911000003")
+	require.NoError(t, err)
+	assert.NotEqual(t, int64(3), created.ID)
+	assert.Empty(t, created.Email)
+	assert.Equal(t, []int64{created.ID}, st.logins)
+	assert.Equal(t, []string{ActionProvisionUser}, st.recorded)
+}
+
+func TestAttemptLimit(t *testing.T) {
+	ctx := context.Background()
+	s, st, sender := newService(Policy{MaxAttempts: 2})
+
+	challengeID, _, err := s.StartPhoneLogin(ctx, "+447911000001", 1, 0)
+	require.NoError(t, err)
+	code := lastCode(t, sender)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	_, _, err = s.CompletePhoneLogin(ctx, challengeID, wrong, "", "")
+	assert.ErrorIs(t, err, ErrInvalidCode)
+	_, _, err = s.CompletePhoneLogin(ctx, challengeID, wrong, "", "")
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+	_, _, err = s.CompletePhoneLogin(ctx, challengeID, code, "", "")
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+	assert.Empty(t, st.logins)
+}
+
+func TestRateLimits(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("per number", func(t *testing.T) {
+		s, _, sender := newService(Policy{NumberLimit: 2})
+
+		for range 2 {
+			_, _, err := s.StartPhoneLogin(ctx, "+447911000001", 1, 0)
+			require.NoError(t, err)
+		}
+		_, _, err := s.StartPhoneLogin(ctx, "+447911000001", 1, 0)
+		assert.ErrorIs(t, err, ErrRateLimited)
+		_, _, err = s.StartPhoneVerification(ctx, 3, "+447911000001")
+		assert.ErrorIs(t, err, ErrRateLimited, "verifications count too")
+
+		_, _, err = s.StartPhoneLogin(ctx, "+447911000005", 1, 0)
+		assert.NoError(t, err, "other numbers are unaffected")
+		assert.Len(t, sender.Sent(), 3)
+	})
+
+	t.Run("per prefix", func(t *testing.T) {
+		s, _, sender := newService(Policy{PrefixLimit: 3, PrefixDigits: 6})
+
+		for _, n := range []string{"+881234000001", "+881234000002", "+881234000003"} {
+			_, _, err := s.StartPhoneLogin(ctx, n, 1, 0)
+			require.NoError(t, err)
+		}
+		_, _, err := s.StartPhoneLogin(ctx, "+881234000004", 1, 0)
+		assert.ErrorIs(t, err, ErrRateLimited, "a run through a number range is stopped")
+
+		_, _, err = s.StartPhoneLogin(ctx, "+881235000001", 1, 0)
+		assert.NoError(t, err)
+		assert.Len(t, sender.Sent(), 4)
+	})
+}
+
+func TestNothingSent(t *testing.T) {
+	ctx := context.Background()
+
+	for name, start := range map[string]struct {
+		phone string
+		appID int
+	}{
+		"disabled user":                   {"+447911000002", 1},
+		"unknown number, invite-only app": {"+447911000009", 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, _, sender := newService(Policy{})
+
+			challengeID, _, err := s.StartPhoneLogin(ctx, start.phone, start.appID, 0)
+			require.NoError(t, err, "callers can't tell")
+			assert.NotEmpty(t, challengeID)
+			assert.Empty(t, sender.Sent())
+		})
+	}
+}
+
+func TestStartRejected(t *testing.T) {
+	ctx := context.Background()
+	s, st, sender := newService(Policy{})
+
+	_, _, err := s.StartPhoneLogin(ctx, "07911 000001", 1, 0)
+	assert.ErrorIs(t, err, ErrInvalidPhone)
+	_, _, err = s.StartPhoneLogin(ctx, "+447911000001", 3, 0)
+	assert.ErrorIs(t, err, auth.ErrGrantNotAllowed)
+	_, _, err = s.StartPhoneLogin(ctx, "+447911000001", 99, 0)
+	assert.ErrorIs(t, err, repository.ErrAppNotFound)
+
+	assert.Empty(t, sender.Sent())
+	assert.Empty(t, st.challenges)
+	assert.Empty(t, st.texts, "rejected logins don't count against the limits")
+}
+
+func TestVerification(t *testing.T) {
+	ctx := context.Background()
+	s, st, sender := newService(Policy{})
+
+	challengeID, _, err := s.StartPhoneVerification(ctx, 3, "+447911000003")
+	require.NoError(t, err)
+	code := lastCode(t, sender)
+	assert.Contains(t, sender.Sent()[0].Text, "verification code")
+
+	_, _, err = s.CompletePhoneLogin(ctx, challengeID, code, "", "")
+	assert.ErrorIs(t, err, ErrInvalidChallenge, "verification codes don't sign in")
+	_, err = s.ConfirmPhoneVerification(ctx, 1, challengeID, code)
+	assert.ErrorIs(t, err, ErrInvalidChallenge, "only the user who started can confirm")
+
+	phone, err := s.ConfirmPhoneVerification(ctx, 3, challengeID, code)
+	require.NoError(t, err)
+	assert.Equal(t, "+447911000003", phone)
+	assert.True(t, st.users[3].PhoneVerified)
+	assert.Equal(t, []string{ActionVerify}, st.recorded)
+
+	challengeID, _, err = s.StartPhoneVerification(ctx, 3, "+447911000001")
+	require.NoError(t, err)
+	_, err = s.ConfirmPhoneVerification(ctx, 3, challengeID, lastCode(t, sender))
+	assert.ErrorIs(t, err, repository.ErrPhoneTaken)
+}
+
+func TestStepUp(t *testing.T) {
+	ctx := context.Background()
+	s, st, sender := newService(Policy{})
+
+	_, _, err := s.StartStepUp(ctx, 3)
+	assert.ErrorIs(t, err, ErrNoVerifiedPhone, "unverified numbers don't count as a factor")
+
+	challengeID, _, err := s.StartStepUp(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, sender.Sent(), 1)
+	assert.Equal(t, "+447911000001", sender.Sent()[0].To)
+	code := lastCode(t, sender)
+
+	_, _, err = s.CompletePhoneLogin(ctx, challengeID, code, "", "")
+	assert.ErrorIs(t, err, ErrInvalidChallenge, "step-up codes don't sign in")
+
+	access, refresh, err := s.CompleteStepUp(ctx, 1, "session-token", challengeID, code)
+	require.NoError(t, err)
+	assert.Equal(t, "access", access)
+	assert.Equal(t, "refresh", refresh)
+	assert.Equal(t, []string{"session-token:sms"}, st.stepUps)
+	assert.Empty(t, st.logins)
+
+	challengeID, _, err = s.StartStepUp(ctx, 1)
+	require.NoError(t, err)
+	_, _, err = s.CompleteStepUp(ctx, 2, "other-session", challengeID, lastCode(t, sender))
+	assert.ErrorIs(t, err, ErrInvalidChallenge, "codes only step up sessions of the user they were sent to")
+}
@@ -0,0 +1,127 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/logger"
+)
+
+const (
+	maxNameLength   = 256
+	maxMetadataSize = 16 * 1024
+)
+
+var (
+	localeRe = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+	phoneRe  = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+)
+
+type UserRepository interface {
+	GetProfile(ctx context.Context, userID int64) (profile models.Profile, err error)
+	UpdateProfile(ctx context.Context, userID int64, upd models.ProfileUpdate) (profile models.Profile, err error)
+}
+
+type ProfileService struct {
+	log      *slog.Logger
+	userRepo UserRepository
+}
+
+func New(log *slog.Logger, userRepo UserRepository) *ProfileService {
+	return &ProfileService{log: log, userRepo: userRepo}
+}
+
+func (s ProfileService) GetProfile(ctx context.Context, userID int64) (models.Profile, error) {
+	const op = "ProfileService.GetProfile"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	profile, err := s.userRepo.GetProfile(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			log.Error("failed to get profile", logger.Err(err))
+		}
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return profile, nil
+}
+
+// UpdateProfile applies a user-initiated change. App metadata is ignored here: it can only be set by admins.
+func (s ProfileService) UpdateProfile(ctx context.Context, userID int64, upd models.ProfileUpdate) (models.Profile, error) {
+	const op = "ProfileService.UpdateProfile"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	upd.AppMetadata = nil
+
+	if err := Validate(upd); err != nil {
+		log.Info("invalid profile update", logger.Err(err))
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	profile, err := s.userRepo.UpdateProfile(ctx, userID, upd)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			log.Error("failed to update profile", logger.Err(err))
+		}
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("profile updated")
+
+	return profile, nil
+}
+
+func Validate(upd models.ProfileUpdate) error {
+	for field, value := range map[string]*string{
+		"display_name": upd.DisplayName,
+		"given_name":   upd.GivenName,
+		"family_name":  upd.FamilyName,
+	} {
+		if value != nil && utf8.RuneCountInString(*value) > maxNameLength {
+			return &serviceerr.FieldError{Field: field, Reason: fmt.Sprintf("must be at most %d characters", maxNameLength)}
+		}
+	}
+
+	if upd.Locale != nil && *upd.Locale != "" && !localeRe.MatchString(*upd.Locale) {
+		return &serviceerr.FieldError{Field: "locale", Reason: "must be a BCP 47 language tag"}
+	}
+
+	if upd.Timezone != nil && *upd.Timezone != "" {
+		if _, err := time.LoadLocation(*upd.Timezone); err != nil {
+			return &serviceerr.FieldError{Field: "timezone", Reason: "must be an IANA time zone name"}
+		}
+	}
+
+	if upd.Phone != nil && *upd.Phone != "" && !phoneRe.MatchString(*upd.Phone) {
+		return &serviceerr.FieldError{Field: "phone", Reason: "must be in E.164 format"}
+	}
+
+	for field, value := range map[string]map[string]any{
+		"user_metadata": upd.UserMetadata,
+		"app_metadata":  upd.AppMetadata,
+	} {
+		if value == nil {
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return &serviceerr.FieldError{Field: field, Reason: "must be a JSON object"}
+		}
+		if len(data) > maxMetadataSize {
+			return &serviceerr.FieldError{Field: field, Reason: fmt.Sprintf("must be at most %d bytes", maxMetadataSize)}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package provisioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/audit"
+	"auth/pkg/logger"
+	"auth/pkg/scim"
+)
+
+func (s ProvisioningService) ListGroups(ctx context.Context, actorID int64, q Query) (scim.ListResponse, error) {
+	const op = "ProvisioningService.ListGroups"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return scim.ListResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	filter, offset, limit, err := parseQuery(q)
+	if err != nil {
+		return scim.ListResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	groups, total, err := s.repo.ListGroups(ctx, filter, offset, limit)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to list groups", logger.Err(err))
+		}
+		return scim.ListResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resources := make([]scim.Group, 0, len(groups))
+	for _, g := range groups {
+		resources = append(resources, toSCIMGroup(g))
+	}
+
+	return listResponse(resources, len(resources), total, offset), nil
+}
+
+func (s ProvisioningService) GetGroup(ctx context.Context, actorID int64, id string) (scim.Group, error) {
+	const op = "ProvisioningService.GetGroup"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.String("id", id))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	group, err := s.getGroup(ctx, id)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get group", logger.Err(err))
+		}
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toSCIMGroup(group), nil
+}
+
+func (s ProvisioningService) CreateGroup(ctx context.Context, actorID int64, in scim.Group) (scim.Group, error) {
+	const op = "ProvisioningService.CreateGroup"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	group, err := fromSCIMGroup(in)
+	if err != nil {
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := s.repo.CreateGroup(ctx, group)
+	if err != nil {
+		err = memberError(err)
+		if !isExpected(err) {
+			log.Error("failed to create group", logger.Err(err))
+		}
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := s.repo.GetGroup(ctx, id)
+	if err != nil {
+		log.Error("failed to get group", logger.Err(err))
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionCreateGroup,
+		Details: map[string]any{"group_id": id, "display_name": group.DisplayName, "members": len(group.Members)},
+	})
+
+	log.Info("group provisioned", slog.Int("groupID", id))
+
+	return toSCIMGroup(created), nil
+}
+
+func (s ProvisioningService) ReplaceGroup(ctx context.Context, actorID int64, id string, in scim.Group, ifMatch string) (scim.Group, error) {
+	const op = "ProvisioningService.ReplaceGroup"
+
+	return s.updateGroup(ctx, op, actorID, id, ifMatch, func(scim.Group) (scim.Group, error) {
+		return in, nil
+	})
+}
+
+// PatchGroup applies PATCH operations to the group, which is how clients usually add and remove
+// members without sending all of them.
+func (s ProvisioningService) PatchGroup(ctx context.Context, actorID int64, id string, ops []scim.PatchOperation, ifMatch string) (scim.Group, error) {
+	const op = "ProvisioningService.PatchGroup"
+
+	return s.updateGroup(ctx, op, actorID, id, ifMatch, func(current scim.Group) (scim.Group, error) {
+		var patched scim.Group
+		if err := patch(current, ops, &patched, nil); err != nil {
+			return scim.Group{}, err
+		}
+		return patched, nil
+	})
+}
+
+func (s ProvisioningService) updateGroup(ctx context.Context, op string, actorID int64, id, ifMatch string, change func(scim.Group) (scim.Group, error)) (scim.Group, error) {
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.String("id", id))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	current, err := s.getGroup(ctx, id)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get group", logger.Err(err))
+		}
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkVersion(ifMatch, current.Version); err != nil {
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	in, err := change(toSCIMGroup(current))
+	if err != nil {
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+	group, err := fromSCIMGroup(in)
+	if err != nil {
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+	group.ID, group.Version = current.ID, current.Version
+
+	if err := s.repo.UpdateGroup(ctx, group); err != nil {
+		err = memberError(err)
+		if !isExpected(err) {
+			log.Error("failed to update group", logger.Err(err))
+		}
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := s.repo.GetGroup(ctx, group.ID)
+	if err != nil {
+		log.Error("failed to get group", logger.Err(err))
+		return scim.Group{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionUpdateGroup,
+		Details: map[string]any{"group_id": group.ID, "display_name": group.DisplayName, "members": len(group.Members)},
+	})
+
+	log.Info("provisioned group updated")
+
+	return toSCIMGroup(updated), nil
+}
+
+func (s ProvisioningService) DeleteGroup(ctx context.Context, actorID int64, id, ifMatch string) error {
+	const op = "ProvisioningService.DeleteGroup"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.String("id", id))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	current, err := s.getGroup(ctx, id)
+	if err == nil {
+		err = checkVersion(ifMatch, current.Version)
+	}
+	if err == nil {
+		err = s.repo.DeleteGroup(ctx, current.ID)
+	}
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to delete group", logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: actorID, Action: ActionDeleteGroup, Details: map[string]any{"group_id": current.ID}})
+
+	log.Info("provisioned group deleted")
+
+	return nil
+}
+
+func (s ProvisioningService) getGroup(ctx context.Context, id string) (models.Group, error) {
+	groupID, err := strconv.Atoi(id)
+	if err != nil {
+		return models.Group{}, repository.ErrGroupNotFound
+	}
+	return s.repo.GetGroup(ctx, groupID)
+}
+
+func toSCIMGroup(g models.Group) scim.Group {
+	out := scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          strconv.Itoa(g.ID),
+		ExternalID:  g.ExternalID,
+		DisplayName: g.DisplayName,
+		Meta:        meta("Group", g.Version, g.CreatedAt, g.UpdatedAt),
+	}
+	for _, m := range g.Members {
+		out.Members = append(out.Members, scim.MultiValue{Value: strconv.FormatInt(m.UserID, 10), Display: m.Email})
+	}
+	return out
+}
+
+// fromSCIMGroup takes the writable attributes of a group. Members can only be users.
+func fromSCIMGroup(in scim.Group) (models.Group, error) {
+	group := models.Group{
+		DisplayName: strings.TrimSpace(in.DisplayName),
+		ExternalID:  strings.TrimSpace(in.ExternalID),
+	}
+	if group.DisplayName == "" {
+		return models.Group{}, scim.BadRequest(scim.ErrorInvalidValue, "displayName must not be empty")
+	}
+
+	seen := make(map[int64]bool, len(in.Members))
+	for _, m := range in.Members {
+		userID, err := strconv.ParseInt(m.Value, 10, 64)
+		if err != nil || (m.Type != "" && !strings.EqualFold(m.Type, "User")) {
+			return models.Group{}, scim.BadRequest(scim.ErrorInvalidValue, "member %q is not a user", m.Value)
+		}
+		if !seen[userID] {
+			seen[userID] = true
+			group.Members = append(group.Members, models.GroupMember{UserID: userID})
+		}
+	}
+	return group, nil
+}
+
+// memberError reports members that aren't users as the client's mistake rather than a missing group.
+func memberError(err error) error {
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return scim.BadRequest(scim.ErrorInvalidValue, "a member is not a user")
+	}
+	return err
+}
@@ -0,0 +1,533 @@
+// Package provisioning lets SCIM clients, such as an HR system, manage users and groups.
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/audit"
+	"auth/pkg/logger"
+	"auth/pkg/password"
+	"auth/pkg/scim"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 200
+)
+
+const (
+	ActionCreateUser      = "scim.create_user"
+	ActionUpdateUser      = "scim.update_user"
+	ActionDeprovisionUser = "scim.deprovision_user"
+	ActionDeleteUser      = "scim.delete_user"
+	ActionCreateGroup     = "scim.create_group"
+	ActionUpdateGroup     = "scim.update_group"
+	ActionDeleteGroup     = "scim.delete_group"
+)
+
+// ErrPreconditionFailed is returned when the If-Match of a request names another version.
+var ErrPreconditionFailed = errors.New("resource version does not match")
+
+type Repository interface {
+	CreateUser(ctx context.Context, user models.ProvisionedUser, passHash []byte, passAlgo string) (int64, error)
+	GetUser(ctx context.Context, userID int64) (models.ProvisionedUser, error)
+	ListUsers(ctx context.Context, filter scim.Filter, offset, limit int) ([]models.ProvisionedUser, int, error)
+	UpdateUser(ctx context.Context, user models.ProvisionedUser, passHash []byte, passAlgo string) error
+	CreateGroup(ctx context.Context, group models.Group) (int, error)
+	GetGroup(ctx context.Context, groupID int) (models.Group, error)
+	ListGroups(ctx context.Context, filter scim.Filter, offset, limit int) ([]models.Group, int, error)
+	UpdateGroup(ctx context.Context, group models.Group) error
+	DeleteGroup(ctx context.Context, groupID int) error
+}
+
+type UserRepository interface {
+	GetByID(ctx context.Context, userID int64) (models.User, error)
+	Delete(ctx context.Context, userID int64) error
+}
+
+type PasswordValidator interface {
+	Validate(password, email string) error
+}
+
+type SessionStorage interface {
+	DeleteAllForUser(ctx context.Context, userID int64) error
+}
+
+type RevocationPublisher interface {
+	Publish(ctx context.Context, r models.Revocation) error
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+// Query selects a page of resources: StartIndex is 1-based and Count 0 asks for the total only.
+type Query struct {
+	Filter     string
+	StartIndex int
+	Count      *int
+}
+
+type ProvisioningService struct {
+	log         *slog.Logger
+	repo        Repository
+	userRepo    UserRepository
+	passwords   PasswordValidator
+	sessions    SessionStorage
+	ssoSessions SessionStorage
+	revocations RevocationPublisher
+	audit       AuditRepository
+}
+
+func New(log *slog.Logger, repo Repository, userRepo UserRepository, passwords PasswordValidator, sessions, ssoSessions SessionStorage, revocations RevocationPublisher, audit AuditRepository) *ProvisioningService {
+	return &ProvisioningService{
+		log:         log,
+		repo:        repo,
+		userRepo:    userRepo,
+		passwords:   passwords,
+		sessions:    sessions,
+		ssoSessions: ssoSessions,
+		revocations: revocations,
+		audit:       audit,
+	}
+}
+
+func (s ProvisioningService) ListUsers(ctx context.Context, actorID int64, q Query) (scim.ListResponse, error) {
+	const op = "ProvisioningService.ListUsers"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return scim.ListResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	filter, offset, limit, err := parseQuery(q)
+	if err != nil {
+		return scim.ListResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	users, total, err := s.repo.ListUsers(ctx, filter, offset, limit)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to list users", logger.Err(err))
+		}
+		return scim.ListResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resources := make([]scim.User, 0, len(users))
+	for _, u := range users {
+		resources = append(resources, toSCIMUser(u))
+	}
+
+	return listResponse(resources, len(resources), total, offset), nil
+}
+
+func (s ProvisioningService) GetUser(ctx context.Context, actorID int64, id string) (scim.User, error) {
+	const op = "ProvisioningService.GetUser"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.String("id", id))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get user", logger.Err(err))
+		}
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toSCIMUser(user), nil
+}
+
+// CreateUser provisions a user. Users provisioned without a password sign in some other way,
+// such as federation or a password reset.
+func (s ProvisioningService) CreateUser(ctx context.Context, actorID int64, in scim.User) (scim.User, error) {
+	const op = "ProvisioningService.CreateUser"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := fromSCIMUser(in)
+	if err != nil {
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, passAlgo, err := s.hashPassword(in.Password, user.Email)
+	if err != nil {
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if passHash == nil {
+		passAlgo = password.AlgoNone
+	}
+
+	id, err := s.repo.CreateUser(ctx, user, passHash, passAlgo)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to create user", logger.Err(err))
+		}
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := s.repo.GetUser(ctx, id)
+	if err != nil {
+		log.Error("failed to get user", logger.Err(err))
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID:      actorID,
+		Action:       ActionCreateUser,
+		TargetUserID: id,
+		Details:      map[string]any{"external_id": user.ExternalID},
+	})
+
+	log.Info("user provisioned", slog.Int64("userID", id))
+
+	return toSCIMUser(created), nil
+}
+
+// ReplaceUser overwrites the user with in. Setting active to false deprovisions the user: they
+// are disabled and their sessions end.
+func (s ProvisioningService) ReplaceUser(ctx context.Context, actorID int64, id string, in scim.User, ifMatch string) (scim.User, error) {
+	const op = "ProvisioningService.ReplaceUser"
+
+	return s.updateUser(ctx, op, actorID, id, ifMatch, func(scim.User) (scim.User, error) {
+		return in, nil
+	})
+}
+
+// PatchUser applies PATCH operations to the user, with the same deprovisioning as ReplaceUser.
+func (s ProvisioningService) PatchUser(ctx context.Context, actorID int64, id string, ops []scim.PatchOperation, ifMatch string) (scim.User, error) {
+	const op = "ProvisioningService.PatchUser"
+
+	return s.updateUser(ctx, op, actorID, id, ifMatch, func(current scim.User) (scim.User, error) {
+		var patched scim.User
+		if err := patch(current, ops, &patched, normalizeUserPatch); err != nil {
+			return scim.User{}, err
+		}
+		return patched, nil
+	})
+}
+
+func (s ProvisioningService) updateUser(ctx context.Context, op string, actorID int64, id, ifMatch string, change func(scim.User) (scim.User, error)) (scim.User, error) {
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.String("id", id))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	current, err := s.getUser(ctx, id)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get user", logger.Err(err))
+		}
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkVersion(ifMatch, current.Version); err != nil {
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	in, err := change(toSCIMUser(current))
+	if err != nil {
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	user, err := fromSCIMUser(in)
+	if err != nil {
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	user.ID, user.Version = current.ID, current.Version
+
+	passHash, passAlgo, err := s.hashPassword(in.Password, user.Email)
+	if err != nil {
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.UpdateUser(ctx, user, passHash, passAlgo); err != nil {
+		if !isExpected(err) {
+			log.Error("failed to update user", logger.Err(err))
+		}
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	action := ActionUpdateUser
+	if current.Active && !user.Active {
+		action = ActionDeprovisionUser
+		if err := s.revokeUser(ctx, user.ID); err != nil {
+			log.Error("failed to end sessions of deprovisioned user", logger.Err(err))
+			return scim.User{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	updated, err := s.repo.GetUser(ctx, user.ID)
+	if err != nil {
+		log.Error("failed to get user", logger.Err(err))
+		return scim.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: actorID, Action: action, TargetUserID: user.ID})
+
+	log.Info("provisioned user updated", slog.String("action", action))
+
+	return toSCIMUser(updated), nil
+}
+
+// DeleteUser deletes the user and ends their sessions.
+func (s ProvisioningService) DeleteUser(ctx context.Context, actorID int64, id, ifMatch string) error {
+	const op = "ProvisioningService.DeleteUser"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.String("id", id))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	current, err := s.getUser(ctx, id)
+	if err == nil {
+		err = checkVersion(ifMatch, current.Version)
+	}
+	if err == nil {
+		err = s.userRepo.Delete(ctx, current.ID)
+	}
+	if err == nil {
+		err = s.revokeUser(ctx, current.ID)
+	}
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to delete user", logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: actorID, Action: ActionDeleteUser, TargetUserID: current.ID})
+
+	log.Info("provisioned user deleted")
+
+	return nil
+}
+
+func (s ProvisioningService) getUser(ctx context.Context, id string) (models.ProvisionedUser, error) {
+	userID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return models.ProvisionedUser{}, repository.ErrUserNotFound
+	}
+	return s.repo.GetUser(ctx, userID)
+}
+
+// hashPassword hashes a password set by the client; an empty one yields a nil hash.
+func (s ProvisioningService) hashPassword(pass, email string) ([]byte, string, error) {
+	if pass == "" {
+		return nil, "", nil
+	}
+	if err := s.passwords.Validate(pass, email); err != nil {
+		var verr *password.ValidationError
+		if errors.As(err, &verr) {
+			return nil, "", scim.BadRequest(scim.ErrorInvalidValue, "%s", verr.Error())
+		}
+		return nil, "", err
+	}
+	hash, err := password.Hash(pass)
+	if err != nil {
+		return nil, "", err
+	}
+	return hash, password.AlgoBcrypt, nil
+}
+
+// revokeUser ends the app and SSO sessions of the user and tells resource servers to reject the access
+// tokens already issued. Failing to tell them is only logged, as those tokens expire soon anyway.
+func (s ProvisioningService) revokeUser(ctx context.Context, userID int64) error {
+	if err := s.sessions.DeleteAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.ssoSessions.DeleteAllForUser(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.revocations.Publish(ctx, models.Revocation{Kind: models.RevokedUser, UserID: userID, RevokedAt: time.Now()}); err != nil {
+		s.log.Error("failed to publish revocation", slog.Int64("userID", userID), logger.Err(err))
+	}
+
+	return nil
+}
+
+func toSCIMUser(u models.ProvisionedUser) scim.User {
+	active := u.Active
+	out := scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          strconv.FormatInt(u.ID, 10),
+		ExternalID:  u.ExternalID,
+		UserName:    u.Email,
+		DisplayName: u.DisplayName,
+		Locale:      u.Locale,
+		Timezone:    u.Timezone,
+		Active:      &active,
+		Emails:      []scim.MultiValue{{Value: u.Email, Type: "work", Primary: true}},
+		Meta:        meta("User", u.Version, u.CreatedAt, u.UpdatedAt),
+	}
+	if u.GivenName != "" || u.FamilyName != "" {
+		out.Name = &scim.Name{
+			Formatted:  strings.TrimSpace(u.GivenName + " " + u.FamilyName),
+			GivenName:  u.GivenName,
+			FamilyName: u.FamilyName,
+		}
+	}
+	if u.Phone != "" {
+		out.PhoneNumbers = []scim.MultiValue{{Value: u.Phone, Type: "work", Primary: true}}
+	}
+	for _, g := range u.Groups {
+		out.Groups = append(out.Groups, scim.MultiValue{Value: strconv.Itoa(g.ID), Display: g.DisplayName})
+	}
+	return out
+}
+
+// fromSCIMUser takes the writable attributes of a user. The user name is the email users sign in
+// with; a missing active attribute means active.
+func fromSCIMUser(in scim.User) (models.ProvisionedUser, error) {
+	email := strings.TrimSpace(in.UserName)
+	if email == "" || !strings.Contains(email, "@") {
+		return models.ProvisionedUser{}, scim.BadRequest(scim.ErrorInvalidValue, "userName must be an email address")
+	}
+
+	user := models.ProvisionedUser{
+		ExternalID:  strings.TrimSpace(in.ExternalID),
+		Email:       email,
+		Active:      in.Active == nil || *in.Active,
+		DisplayName: in.DisplayName,
+		Locale:      in.Locale,
+		Timezone:    in.Timezone,
+		Phone:       primary(in.PhoneNumbers),
+	}
+	if in.Name != nil {
+		user.GivenName, user.FamilyName = in.Name.GivenName, in.Name.FamilyName
+	}
+	return user, nil
+}
+
+// normalizeUserPatch undoes what some clients send for booleans, such as "active": "False".
+func normalizeUserPatch(obj map[string]any) {
+	for k, v := range obj {
+		if s, ok := v.(string); ok && strings.EqualFold(k, "active") {
+			if b, err := strconv.ParseBool(strings.ToLower(s)); err == nil {
+				obj[k] = b
+			}
+		}
+	}
+}
+
+// patch applies ops to current through its JSON form and decodes the result into out.
+func patch(current any, ops []scim.PatchOperation, out any, normalize func(map[string]any)) error {
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return err
+	}
+
+	if err := scim.ApplyPatch(obj, ops); err != nil {
+		return err
+	}
+	if normalize != nil {
+		normalize(obj)
+	}
+
+	if raw, err = json.Marshal(obj); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return scim.BadRequest(scim.ErrorInvalidValue, "patched resource is invalid: %v", err)
+	}
+	return nil
+}
+
+func primary(values []scim.MultiValue) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+func meta(resourceType string, version int64, created, updated time.Time) *scim.Meta {
+	return &scim.Meta{
+		ResourceType: resourceType,
+		Created:      &created,
+		LastModified: &updated,
+		Version:      scim.ETag(version),
+	}
+}
+
+func parseQuery(q Query) (filter scim.Filter, offset, limit int, err error) {
+	if strings.TrimSpace(q.Filter) != "" {
+		if filter, err = scim.ParseFilter(q.Filter); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	if q.StartIndex > 1 {
+		offset = q.StartIndex - 1
+	}
+
+	limit = DefaultPageSize
+	if q.Count != nil {
+		limit = min(max(*q.Count, 0), MaxPageSize)
+	}
+
+	return filter, offset, limit, nil
+}
+
+func listResponse(resources any, n, total, offset int) scim.ListResponse {
+	return scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   offset + 1,
+		ItemsPerPage: n,
+		Resources:    resources,
+	}
+}
+
+func checkVersion(ifMatch string, version int64) error {
+	if ifMatch != "" && !scim.MatchETag(ifMatch, scim.ETag(version)) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+func (s ProvisioningService) requireAdmin(ctx context.Context, log *slog.Logger, actorID int64) error {
+	err := admin.RequireAdmin(ctx, s.userRepo, actorID)
+	if err != nil && !errors.Is(err, admin.ErrPermissionDenied) {
+		log.Error("failed to check admin", logger.Err(err))
+	}
+	return err
+}
+
+func isExpected(err error) bool {
+	var serr *scim.Error
+	return errors.As(err, &serr) ||
+		errors.Is(err, ErrPreconditionFailed) ||
+		errors.Is(err, repository.ErrUserNotFound) ||
+		errors.Is(err, repository.ErrUserExists) ||
+		errors.Is(err, repository.ErrGroupNotFound) ||
+		errors.Is(err, repository.ErrGroupExists) ||
+		errors.Is(err, repository.ErrVersionMismatch)
+}
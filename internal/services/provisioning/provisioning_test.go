@@ -0,0 +1,413 @@
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sort"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/pkg/password"
+	"auth/pkg/scim"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store keeps provisioned users and groups in memory and records the app and SSO sessions it was
+// asked to end.
+type store struct {
+	users       map[int64]models.ProvisionedUser
+	passwords   map[int64]string
+	admins      map[int64]bool
+	groups      map[int]models.Group
+	nextID      int64
+	ended       []int64
+	ssoEnded    []int64
+	revocations []models.Revocation
+	recorded    []string
+	lastOffset  int
+	lastLimit   int
+}
+
+func newStore() *store {
+	return &store{
+		users:     map[int64]models.ProvisionedUser{1: {ID: 1, Email: "admin@example.com", Active: true, Version: 1}},
+		passwords: make(map[int64]string),
+		admins:    map[int64]bool{1: true},
+		groups:    make(map[int]models.Group),
+		nextID:    10,
+	}
+}
+
+func (s *store) CreateUser(_ context.Context, user models.ProvisionedUser, passHash []byte, passAlgo string) (int64, error) {
+	for _, u := range s.users {
+		if u.Email == user.Email {
+			return 0, repository.ErrUserExists
+		}
+	}
+	s.nextID++
+	user.ID, user.Version = s.nextID, 1
+	user.CreatedAt, user.UpdatedAt = time.Now(), time.Now()
+	s.users[user.ID] = user
+	s.passwords[user.ID] = passAlgo
+	return user.ID, nil
+}
+
+func (s *store) GetUser(_ context.Context, userID int64) (models.ProvisionedUser, error) {
+	u, ok := s.users[userID]
+	if !ok {
+		return models.ProvisionedUser{}, repository.ErrUserNotFound
+	}
+	for _, g := range s.groups {
+		for _, m := range g.Members {
+			if m.UserID == userID {
+				u.Groups = append(u.Groups, models.GroupRef{ID: g.ID, DisplayName: g.DisplayName})
+			}
+		}
+	}
+	return u, nil
+}
+
+func (s *store) ListUsers(_ context.Context, filter scim.Filter, offset, limit int) ([]models.ProvisionedUser, int, error) {
+	s.lastOffset, s.lastLimit = offset, limit
+
+	var out []models.ProvisionedUser
+	for _, u := range s.users {
+		if filter == nil || scim.Match(filter, map[string]any{"userName": u.Email}) {
+			out = append(out, u)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+
+	total := len(out)
+	out = out[min(offset, total):min(offset+limit, total)]
+	return out, total, nil
+}
+
+func (s *store) UpdateUser(_ context.Context, user models.ProvisionedUser, passHash []byte, passAlgo string) error {
+	current, ok := s.users[user.ID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	if current.Version != user.Version {
+		return repository.ErrVersionMismatch
+	}
+	user.Version++
+	user.CreatedAt, user.UpdatedAt = current.CreatedAt, time.Now()
+	s.users[user.ID] = user
+	if passHash != nil {
+		s.passwords[user.ID] = passAlgo
+	}
+	return nil
+}
+
+func (s *store) CreateGroup(_ context.Context, group models.Group) (int, error) {
+	if err := s.checkMembers(group.Members); err != nil {
+		return 0, err
+	}
+	group.ID, group.Version = len(s.groups)+1, 1
+	s.groups[group.ID] = group
+	return group.ID, nil
+}
+
+func (s *store) GetGroup(_ context.Context, groupID int) (models.Group, error) {
+	g, ok := s.groups[groupID]
+	if !ok {
+		return models.Group{}, repository.ErrGroupNotFound
+	}
+	return g, nil
+}
+
+func (s *store) ListGroups(_ context.Context, _ scim.Filter, offset, limit int) ([]models.Group, int, error) {
+	var out []models.Group
+	for _, g := range s.groups {
+		out = append(out, g)
+	}
+	return out, len(out), nil
+}
+
+func (s *store) UpdateGroup(_ context.Context, group models.Group) error {
+	current, ok := s.groups[group.ID]
+	if !ok {
+		return repository.ErrGroupNotFound
+	}
+	if current.Version != group.Version {
+		return repository.ErrVersionMismatch
+	}
+	if err := s.checkMembers(group.Members); err != nil {
+		return err
+	}
+	group.Version++
+	s.groups[group.ID] = group
+	return nil
+}
+
+func (s *store) DeleteGroup(_ context.Context, groupID int) error {
+	if _, ok := s.groups[groupID]; !ok {
+		return repository.ErrGroupNotFound
+	}
+	delete(s.groups, groupID)
+	return nil
+}
+
+func (s *store) checkMembers(members []models.GroupMember) error {
+	for _, m := range members {
+		if _, ok := s.users[m.UserID]; !ok {
+			return repository.ErrUserNotFound
+		}
+	}
+	return nil
+}
+
+func (s *store) GetByID(_ context.Context, userID int64) (models.User, error) {
+	u, ok := s.users[userID]
+	if !ok {
+		return models.User{}, repository.ErrUserNotFound
+	}
+	return models.User{ID: u.ID, Email: u.Email, IsAdmin: s.admins[userID], Disabled: !u.Active}, nil
+}
+
+func (s *store) Delete(_ context.Context, userID int64) error {
+	if _, ok := s.users[userID]; !ok {
+		return repository.ErrUserNotFound
+	}
+	delete(s.users, userID)
+	return nil
+}
+
+func (s *store) DeleteAllForUser(_ context.Context, userID int64) error {
+	s.ended = append(s.ended, userID)
+	return nil
+}
+
+// ssoSessions ends the store's SSO sessions.
+type ssoSessions struct{ *store }
+
+func (s ssoSessions) DeleteAllForUser(_ context.Context, userID int64) error {
+	s.ssoEnded = append(s.ssoEnded, userID)
+	return nil
+}
+
+func (s *store) Publish(_ context.Context, r models.Revocation) error {
+	s.revocations = append(s.revocations, r)
+	return nil
+}
+
+func (s *store) Record(_ context.Context, entry models.AuditEntry) error {
+	s.recorded = append(s.recorded, entry.Action)
+	return nil
+}
+
+func newService() (*ProvisioningService, *store) {
+	st := newStore()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	policy := password.New(password.Config{MinLength: 8, MaxLength: 72}, nil)
+	return New(log, st, st, policy, st, ssoSessions{st}, st, st), st
+}
+
+func ops(t *testing.T, raw string) []scim.PatchOperation {
+	t.Helper()
+	var out []scim.PatchOperation
+	require.NoError(t, json.Unmarshal([]byte(raw), &out))
+	return out
+}
+
+func createUser(t *testing.T, s *ProvisioningService, email string) scim.User {
+	t.Helper()
+	user, err := s.CreateUser(context.Background(), 1, scim.User{
+		UserName:   email,
+		ExternalID: "hr-" + email,
+		Name:       &scim.Name{GivenName: "Ann", FamilyName: "Lee"},
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func TestCreateUser(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService()
+
+	user := createUser(t, s, "ann@example.com")
+	assert.Equal(t, "ann@example.com", user.UserName)
+	assert.Equal(t, "hr-ann@example.com", user.ExternalID)
+	assert.True(t, *user.Active)
+	assert.Equal(t, "Ann Lee", user.Name.Formatted)
+	assert.Equal(t, []scim.MultiValue{{Value: "ann@example.com", Type: "work", Primary: true}}, user.Emails)
+	assert.Equal(t, scim.ETag(1), user.Meta.Version)
+	assert.Equal(t, password.AlgoNone, st.passwords[11])
+	assert.Contains(t, st.recorded, ActionCreateUser)
+
+	withPassword, err := s.CreateUser(ctx, 1, scim.User{UserName: "bob@example.com", Password: "correct horse"})
+	require.NoError(t, err)
+	assert.Equal(t, password.AlgoBcrypt, st.passwords[12])
+	assert.Empty(t, withPassword.Password)
+
+	_, err = s.CreateUser(ctx, 1, scim.User{UserName: "bob@example.com"})
+	assert.ErrorIs(t, err, repository.ErrUserExists)
+
+	var serr *scim.Error
+	_, err = s.CreateUser(ctx, 1, scim.User{UserName: "not-an-email"})
+	assert.ErrorAs(t, err, &serr)
+	_, err = s.CreateUser(ctx, 1, scim.User{UserName: "carl@example.com", Password: "short"})
+	assert.ErrorAs(t, err, &serr)
+
+	_, err = s.CreateUser(ctx, 11, scim.User{UserName: "dan@example.com"})
+	assert.ErrorIs(t, err, admin.ErrPermissionDenied)
+}
+
+func TestDeprovision(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService()
+	user := createUser(t, s, "ann@example.com")
+
+	// Clients send booleans as strings too.
+	patched, err := s.PatchUser(ctx, 1, user.ID, ops(t, `[{"op": "Replace", "path": "active", "value": "False"}]`), "")
+	require.NoError(t, err)
+	assert.False(t, *patched.Active)
+	assert.False(t, st.users[11].Active)
+	assert.Equal(t, []int64{11}, st.ended)
+	assert.Equal(t, []int64{11}, st.ssoEnded)
+	require.Len(t, st.revocations, 1)
+	assert.Equal(t, models.Revocation{Kind: models.RevokedUser, UserID: 11, RevokedAt: st.revocations[0].RevokedAt}, st.revocations[0])
+	assert.Contains(t, st.recorded, ActionDeprovisionUser)
+
+	// Updating an inactive user doesn't end sessions again.
+	_, err = s.PatchUser(ctx, 1, user.ID, ops(t, `[{"op": "replace", "path": "displayName", "value": "Ann"}]`), "")
+	require.NoError(t, err)
+	assert.Len(t, st.ended, 1)
+
+	reactivated, err := s.ReplaceUser(ctx, 1, user.ID, scim.User{UserName: "ann@example.com"}, "")
+	require.NoError(t, err)
+	assert.True(t, *reactivated.Active)
+	assert.Len(t, st.ended, 1)
+}
+
+func TestPatchUser(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService()
+	user := createUser(t, s, "ann@example.com")
+
+	patched, err := s.PatchUser(ctx, 1, user.ID, ops(t, `[
+		{"op": "replace", "value": {"name.familyName": "Park", "displayName": "Ann Park"}},
+		{"op": "add", "path": "phoneNumbers[type eq \"work\"].value", "value": "+1 555 0100"},
+		{"op": "replace", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", "value": "Eng"}
+	]`), "")
+	require.NoError(t, err)
+	assert.Equal(t, "Park", st.users[11].FamilyName)
+	assert.Equal(t, "Ann", st.users[11].GivenName)
+	assert.Equal(t, "Ann Park", patched.DisplayName)
+	assert.Equal(t, "+1 555 0100", st.users[11].Phone)
+	assert.Empty(t, st.ended)
+
+	_, err = s.PatchUser(ctx, 1, user.ID, ops(t, `[{"op": "remove", "path": "userName"}]`), "")
+	var serr *scim.Error
+	assert.ErrorAs(t, err, &serr)
+
+	_, err = s.PatchUser(ctx, 1, "999", ops(t, `[{"op": "remove", "path": "displayName"}]`), "")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	_, err = s.PatchUser(ctx, 1, "abc", ops(t, `[{"op": "remove", "path": "displayName"}]`), "")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+}
+
+func TestIfMatch(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newService()
+	user := createUser(t, s, "ann@example.com")
+
+	_, err := s.ReplaceUser(ctx, 1, user.ID, scim.User{UserName: "ann@example.com"}, scim.ETag(7))
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	updated, err := s.ReplaceUser(ctx, 1, user.ID, scim.User{UserName: "ann@example.com"}, user.Meta.Version)
+	require.NoError(t, err)
+	assert.Equal(t, scim.ETag(2), updated.Meta.Version)
+
+	err = s.DeleteUser(ctx, 1, user.ID, user.Meta.Version)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+}
+
+func TestDeleteUser(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService()
+	user := createUser(t, s, "ann@example.com")
+
+	require.NoError(t, s.DeleteUser(ctx, 1, user.ID, ""))
+	assert.NotContains(t, st.users, int64(11))
+	assert.Equal(t, []int64{11}, st.ended)
+	assert.Len(t, st.revocations, 1)
+	assert.Contains(t, st.recorded, ActionDeleteUser)
+
+	assert.ErrorIs(t, s.DeleteUser(ctx, 1, user.ID, ""), repository.ErrUserNotFound)
+}
+
+func TestListUsers(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService()
+	createUser(t, s, "ann@example.com")
+	createUser(t, s, "bob@example.com")
+
+	list, err := s.ListUsers(ctx, 1, Query{Filter: `userName eq "BOB@example.com"`})
+	require.NoError(t, err)
+	assert.Equal(t, 1, list.TotalResults)
+	assert.Equal(t, 1, list.StartIndex)
+	require.Len(t, list.Resources, 1)
+	assert.Equal(t, "bob@example.com", list.Resources.([]scim.User)[0].UserName)
+	assert.Equal(t, DefaultPageSize, st.lastLimit)
+
+	count := 1
+	list, err = s.ListUsers(ctx, 1, Query{StartIndex: 2, Count: &count})
+	require.NoError(t, err)
+	assert.Equal(t, 3, list.TotalResults)
+	assert.Equal(t, 2, list.StartIndex)
+	assert.Equal(t, 1, list.ItemsPerPage)
+	assert.Equal(t, "ann@example.com", list.Resources.([]scim.User)[0].UserName)
+
+	count = 1000
+	_, err = s.ListUsers(ctx, 1, Query{Count: &count})
+	require.NoError(t, err)
+	assert.Equal(t, MaxPageSize, st.lastLimit)
+
+	_, err = s.ListUsers(ctx, 1, Query{Filter: `userName eq`})
+	var serr *scim.Error
+	assert.ErrorAs(t, err, &serr)
+}
+
+func TestGroups(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService()
+	ann := createUser(t, s, "ann@example.com")
+	bob := createUser(t, s, "bob@example.com")
+
+	group, err := s.CreateGroup(ctx, 1, scim.Group{DisplayName: "Engineering", Members: []scim.MultiValue{{Value: ann.ID}}})
+	require.NoError(t, err)
+	assert.Equal(t, "1", group.ID)
+	assert.Equal(t, []models.GroupMember{{UserID: 11}}, st.groups[1].Members)
+
+	user, err := s.GetUser(ctx, 1, ann.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []scim.MultiValue{{Value: "1", Display: "Engineering"}}, user.Groups)
+
+	patched, err := s.PatchGroup(ctx, 1, group.ID, ops(t, `[
+		{"op": "add", "path": "members", "value": [{"value": "`+bob.ID+`"}]},
+		{"op": "remove", "path": "members[value eq \"`+ann.ID+`\"]"}
+	]`), group.Meta.Version)
+	require.NoError(t, err)
+	assert.Equal(t, []models.GroupMember{{UserID: 12}}, st.groups[1].Members)
+	assert.Equal(t, scim.ETag(2), patched.Meta.Version)
+
+	var serr *scim.Error
+	_, err = s.PatchGroup(ctx, 1, group.ID, ops(t, `[{"op": "add", "path": "members", "value": [{"value": "999"}]}]`), "")
+	assert.ErrorAs(t, err, &serr)
+	_, err = s.CreateGroup(ctx, 1, scim.Group{DisplayName: " "})
+	assert.ErrorAs(t, err, &serr)
+
+	require.NoError(t, s.DeleteGroup(ctx, 1, group.ID, ""))
+	_, err = s.GetGroup(ctx, 1, group.ID)
+	assert.ErrorIs(t, err, repository.ErrGroupNotFound)
+	assert.Equal(t, []string{ActionCreateUser, ActionCreateUser, ActionCreateGroup, ActionUpdateGroup, ActionDeleteGroup}, st.recorded)
+}
@@ -0,0 +1,275 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/audit"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/logger"
+)
+
+const (
+	ActionCreateRole         = "rbac.create_role"
+	ActionDeleteRole         = "rbac.delete_role"
+	ActionSetRolePermissions = "rbac.set_role_permissions"
+	ActionCreatePermission   = "rbac.create_permission"
+	ActionDeletePermission   = "rbac.delete_permission"
+	ActionAssignRole         = "rbac.assign_role"
+	ActionRevokeRole         = "rbac.revoke_role"
+)
+
+// Names end up in token claims, so they are kept short and free of whitespace.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.:\-]{1,64}$`)
+
+type RoleRepository interface {
+	CreateRole(ctx context.Context, role models.Role) (int64, error)
+	GetRole(ctx context.Context, roleID int64) (models.Role, error)
+	ListRoles(ctx context.Context, appID int) ([]models.Role, error)
+	DeleteRole(ctx context.Context, roleID int64) error
+	CreatePermission(ctx context.Context, perm models.Permission) (int64, error)
+	ListPermissions(ctx context.Context, appID int) ([]models.Permission, error)
+	DeletePermission(ctx context.Context, permissionID int64) error
+	SetRolePermissions(ctx context.Context, roleID int64, names []string) error
+	AssignRole(ctx context.Context, userID, roleID int64) error
+	RevokeRole(ctx context.Context, userID, roleID int64) error
+	UserRoles(ctx context.Context, userID int64, appID int) ([]models.Role, error)
+	UserAuthorization(ctx context.Context, userID int64, appID int) (models.Authorization, error)
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+type RBACService struct {
+	log      *slog.Logger
+	roleRepo RoleRepository
+	userRepo admin.UserGetter
+	audit    AuditRepository
+}
+
+func New(log *slog.Logger, roleRepo RoleRepository, userRepo admin.UserGetter, audit AuditRepository) *RBACService {
+	return &RBACService{log: log, roleRepo: roleRepo, userRepo: userRepo, audit: audit}
+}
+
+func (s RBACService) CreateRole(ctx context.Context, actorID int64, role models.Role) (models.Role, error) {
+	const op = "RBACService.CreateRole"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int("appID", role.AppID))
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !namePattern.MatchString(role.Name) {
+		return models.Role{}, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "name", Reason: "must be 1-64 letters, digits or _.:-"})
+	}
+
+	id, err := s.roleRepo.CreateRole(ctx, role)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to create role", logger.Err(err))
+		}
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	role.ID = id
+	role.Permissions = nil
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionCreateRole,
+		Details: map[string]any{"app_id": role.AppID, "role_id": id, "name": role.Name},
+	})
+
+	return role, nil
+}
+
+func (s RBACService) ListRoles(ctx context.Context, actorID int64, appID int) ([]models.Role, error) {
+	const op = "RBACService.ListRoles"
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := s.roleRepo.ListRoles(ctx, appID)
+	if err != nil {
+		s.log.Error("failed to list roles", slog.String("op", op), logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// DeleteRole removes the role and takes it away from every user that had it.
+func (s RBACService) DeleteRole(ctx context.Context, actorID, roleID int64) error {
+	const op = "RBACService.DeleteRole"
+
+	return s.mutate(ctx, op, actorID, ActionDeleteRole, 0, map[string]any{"role_id": roleID}, func() error {
+		return s.roleRepo.DeleteRole(ctx, roleID)
+	})
+}
+
+// SetRolePermissions replaces the permissions granted by the role and returns the updated role.
+func (s RBACService) SetRolePermissions(ctx context.Context, actorID, roleID int64, permissions []string) (models.Role, error) {
+	const op = "RBACService.SetRolePermissions"
+
+	err := s.mutate(ctx, op, actorID, ActionSetRolePermissions, 0, map[string]any{"role_id": roleID, "permissions": permissions}, func() error {
+		return s.roleRepo.SetRolePermissions(ctx, roleID, permissions)
+	})
+	if err != nil {
+		return models.Role{}, err
+	}
+
+	role, err := s.roleRepo.GetRole(ctx, roleID)
+	if err != nil {
+		s.log.Error("failed to get updated role", slog.String("op", op), logger.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return role, nil
+}
+
+func (s RBACService) CreatePermission(ctx context.Context, actorID int64, perm models.Permission) (models.Permission, error) {
+	const op = "RBACService.CreatePermission"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int("appID", perm.AppID))
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return models.Permission{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !namePattern.MatchString(perm.Name) {
+		return models.Permission{}, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "name", Reason: "must be 1-64 letters, digits or _.:-"})
+	}
+
+	id, err := s.roleRepo.CreatePermission(ctx, perm)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to create permission", logger.Err(err))
+		}
+		return models.Permission{}, fmt.Errorf("%s: %w", op, err)
+	}
+	perm.ID = id
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionCreatePermission,
+		Details: map[string]any{"app_id": perm.AppID, "permission_id": id, "name": perm.Name},
+	})
+
+	return perm, nil
+}
+
+func (s RBACService) ListPermissions(ctx context.Context, actorID int64, appID int) ([]models.Permission, error) {
+	const op = "RBACService.ListPermissions"
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	perms, err := s.roleRepo.ListPermissions(ctx, appID)
+	if err != nil {
+		s.log.Error("failed to list permissions", slog.String("op", op), logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return perms, nil
+}
+
+func (s RBACService) DeletePermission(ctx context.Context, actorID, permissionID int64) error {
+	const op = "RBACService.DeletePermission"
+
+	return s.mutate(ctx, op, actorID, ActionDeletePermission, 0, map[string]any{"permission_id": permissionID}, func() error {
+		return s.roleRepo.DeletePermission(ctx, permissionID)
+	})
+}
+
+// AssignRole gives the role to the user. It takes effect with the next token the user gets.
+func (s RBACService) AssignRole(ctx context.Context, actorID, userID, roleID int64) error {
+	const op = "RBACService.AssignRole"
+
+	return s.mutate(ctx, op, actorID, ActionAssignRole, userID, map[string]any{"role_id": roleID}, func() error {
+		return s.roleRepo.AssignRole(ctx, userID, roleID)
+	})
+}
+
+func (s RBACService) RevokeRole(ctx context.Context, actorID, userID, roleID int64) error {
+	const op = "RBACService.RevokeRole"
+
+	return s.mutate(ctx, op, actorID, ActionRevokeRole, userID, map[string]any{"role_id": roleID}, func() error {
+		return s.roleRepo.RevokeRole(ctx, userID, roleID)
+	})
+}
+
+func (s RBACService) ListUserRoles(ctx context.Context, actorID, userID int64, appID int) ([]models.Role, error) {
+	const op = "RBACService.ListUserRoles"
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := s.roleRepo.UserRoles(ctx, userID, appID)
+	if err != nil {
+		s.log.Error("failed to list user roles", slog.String("op", op), logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// GetUserAuthorization returns the full roles and permissions of a user in an app. It is what
+// services call when a token has authz_overflow set. Users can look up themselves; anyone
+// else needs to be an admin.
+func (s RBACService) GetUserAuthorization(ctx context.Context, actorID, userID int64, appID int) (models.Authorization, error) {
+	const op = "RBACService.GetUserAuthorization"
+
+	if actorID != userID {
+		if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+			return models.Authorization{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	authz, err := s.roleRepo.UserAuthorization(ctx, userID, appID)
+	if err != nil {
+		s.log.Error("failed to get user authorization", slog.String("op", op), logger.Err(err))
+		return models.Authorization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return authz, nil
+}
+
+func (s RBACService) mutate(ctx context.Context, op string, actorID int64, action string, targetUserID int64, details map[string]any, fn func() error) error {
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := fn(); err != nil {
+		if !isExpected(err) {
+			log.Error("rbac action failed", slog.String("action", action), logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: actorID, Action: action, TargetUserID: targetUserID, Details: details})
+
+	log.Info("rbac action performed", slog.String("action", action))
+
+	return nil
+}
+
+func isExpected(err error) bool {
+	return errors.Is(err, repository.ErrRoleNotFound) ||
+		errors.Is(err, repository.ErrRoleExists) ||
+		errors.Is(err, repository.ErrPermissionNotFound) ||
+		errors.Is(err, repository.ErrPermissionExists) ||
+		errors.Is(err, repository.ErrUserNotFound) ||
+		errors.Is(err, repository.ErrAppNotFound)
+}
@@ -0,0 +1,99 @@
+package revocations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+)
+
+type Feed interface {
+	Latest(ctx context.Context) (string, error)
+	Check(ctx context.Context, offset string) error
+	Read(ctx context.Context, offset string, count int, block time.Duration) ([]models.Revocation, error)
+}
+
+// WatchPolicy controls how a watcher reads the feed.
+type WatchPolicy struct {
+	BatchSize int
+	// PollTimeout is how long a read waits for new entries before it is made again. It bounds
+	// how long a watcher whose client went away keeps reading.
+	PollTimeout time.Duration
+}
+
+type RevocationService struct {
+	log      *slog.Logger
+	feed     Feed
+	userRepo admin.UserGetter
+	policy   WatchPolicy
+}
+
+func New(log *slog.Logger, feed Feed, userRepo admin.UserGetter, policy WatchPolicy) *RevocationService {
+	return &RevocationService{log: log, feed: feed, userRepo: userRepo, policy: policy}
+}
+
+// Watch passes revocations to send as they are published, until ctx is done or send fails.
+// It starts after offset, or with the next revocation if offset is empty. Service accounts
+// are only told about revocations that aren't limited to another app; users must be admins.
+func (s RevocationService) Watch(ctx context.Context, caller *jwt.Claims, offset string, send func(models.Revocation) error) error {
+	const op = "RevocationService.Watch"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", caller.UserID), slog.Int64("serviceAccountID", caller.ServiceAccountID))
+
+	appID := 0
+	if caller.IsServiceAccount() {
+		appID = caller.AppID
+	} else if err := admin.RequireAdmin(ctx, s.userRepo, caller.UserID); err != nil {
+		if !errors.Is(err, admin.ErrPermissionDenied) {
+			log.Error("failed to check admin", logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var err error
+	if offset == "" {
+		offset, err = s.feed.Latest(ctx)
+	} else {
+		err = s.feed.Check(ctx, offset)
+	}
+	if err != nil {
+		if !errors.Is(err, repository.ErrInvalidOffset) && !errors.Is(err, repository.ErrOffsetExpired) {
+			log.Error("failed to start watching revocations", logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("watching revocations", slog.String("offset", offset))
+
+	for ctx.Err() == nil {
+		list, err := s.feed.Read(ctx, offset, s.policy.BatchSize, s.policy.PollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Error("failed to read revocations", logger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, r := range list {
+			offset = r.Offset
+			if appID != 0 && r.AppID != 0 && r.AppID != appID {
+				continue
+			}
+			if err := send(r); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	log.Info("stopped watching revocations", slog.String("offset", offset))
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package revocations
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/pkg/jwt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memFeed serves a fixed list of revocations whose offsets are their positions.
+type memFeed struct {
+	entries []models.Revocation
+	expired string
+}
+
+func (f *memFeed) Latest(context.Context) (string, error) {
+	return offsetAt(len(f.entries)), nil
+}
+
+func (f *memFeed) Check(_ context.Context, offset string) error {
+	if offset == f.expired {
+		return repository.ErrOffsetExpired
+	}
+	return nil
+}
+
+func (f *memFeed) Read(ctx context.Context, offset string, count int, _ time.Duration) ([]models.Revocation, error) {
+	var res []models.Revocation
+	for _, r := range f.entries {
+		if r.Offset > offset && len(res) < count {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+func offsetAt(i int) string {
+	return string(rune('a' + i))
+}
+
+type users map[int64]models.User
+
+func (u users) GetByID(_ context.Context, userID int64) (models.User, error) {
+	user, ok := u[userID]
+	if !ok {
+		return models.User{}, repository.ErrUserNotFound
+	}
+	return user, nil
+}
+
+var errStop = errors.New("stop")
+
+func newTestService(feed *memFeed) RevocationService {
+	return *New(slog.New(slog.NewTextHandler(io.Discard, nil)), feed, users{1: {ID: 1, IsAdmin: true}, 2: {ID: 2}}, WatchPolicy{BatchSize: 2, PollTimeout: time.Second})
+}
+
+// watch collects revocations until want have arrived.
+func watch(s RevocationService, caller *jwt.Claims, offset string, want int) ([]models.Revocation, error) {
+	var got []models.Revocation
+	err := s.Watch(context.Background(), caller, offset, func(r models.Revocation) error {
+		got = append(got, r)
+		if len(got) == want {
+			return errStop
+		}
+		return nil
+	})
+	return got, err
+}
+
+func TestWatch(t *testing.T) {
+	feed := &memFeed{expired: "trimmed"}
+	for i, r := range []models.Revocation{
+		{Kind: models.RevokedUser, UserID: 5},
+		{Kind: models.RevokedSession, UserID: 5, AppID: 1, SessionID: "s1"},
+		{Kind: models.RevokedSession, UserID: 6, AppID: 2, SessionID: "s2"},
+		{Kind: models.RevokedKey, AppID: 2, KeyID: models.SecretKindAccess},
+	} {
+		r.Offset = offsetAt(i + 1)
+		feed.entries = append(feed.entries, r)
+	}
+	s := newTestService(feed)
+
+	adminClaims := jwt.NewClaims(1, "admin@mail.com", 1, time.Now(), time.Time{})
+	serviceAccount := jwt.NewClaims(0, "", 2, time.Now(), time.Time{}, jwt.WithServiceAccount(9))
+
+	t.Run("admin sees everything after offset", func(t *testing.T) {
+		got, err := watch(s, adminClaims, offsetAt(1), 3)
+		require.ErrorIs(t, err, errStop)
+		assert.Equal(t, []string{"s1", "s2", ""}, []string{got[0].SessionID, got[1].SessionID, got[2].SessionID})
+	})
+
+	t.Run("service account only sees its app", func(t *testing.T) {
+		got, err := watch(s, serviceAccount, offsetAt(0), 3)
+		require.ErrorIs(t, err, errStop)
+		assert.Equal(t, models.RevokedUser, got[0].Kind)
+		assert.Equal(t, "s2", got[1].SessionID)
+		assert.Equal(t, models.RevokedKey, got[2].Kind)
+	})
+
+	t.Run("stops when the caller goes away", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := s.Watch(ctx, adminClaims, "", func(models.Revocation) error {
+			t.Fatal("no revocation expected")
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("expired offset", func(t *testing.T) {
+		_, err := watch(s, adminClaims, "trimmed", 1)
+		assert.ErrorIs(t, err, repository.ErrOffsetExpired)
+	})
+
+	t.Run("users must be admins", func(t *testing.T) {
+		user := jwt.NewClaims(2, "user@mail.com", 1, time.Now(), time.Time{})
+		_, err := watch(s, user, "", 1)
+		assert.ErrorIs(t, err, admin.ErrPermissionDenied)
+	})
+}
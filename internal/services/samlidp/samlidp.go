@@ -0,0 +1,659 @@
+// Package samlidp lets users sign in to SAML service providers with their accounts here.
+package samlidp
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/audit"
+	"auth/internal/services/auth"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+	"auth/pkg/saml"
+)
+
+const (
+	ActionCreateServiceProvider  = "saml.create_service_provider"
+	ActionDeleteServiceProvider  = "saml.delete_service_provider"
+	ActionDisableServiceProvider = "saml.disable_service_provider"
+	ActionEnableServiceProvider  = "saml.enable_service_provider"
+	ActionIssueAssertion         = "saml.issue_assertion"
+	ActionSingleLogout           = "saml.single_logout"
+)
+
+var (
+	ErrServiceProviderDisabled = errors.New("service provider is disabled")
+	ErrInvalidRequest          = errors.New("saml request is invalid or expired")
+	// ErrLogoutNotSupported is returned for logout requests of service providers without a
+	// certificate or logout URL: unsigned requests could log anyone out.
+	ErrLogoutNotSupported = errors.New("service provider is not set up for single logout")
+)
+
+// Attributes are the profile fields that can be sent to service providers, under these names
+// unless the service provider maps them.
+var Attributes = []string{
+	"email", "user_id", "name", "given_name", "family_name", "locale", "zoneinfo", "phone_number", "roles",
+}
+
+type Repository interface {
+	CreateServiceProvider(ctx context.Context, sp models.ServiceProvider) (int, error)
+	GetServiceProvider(ctx context.Context, spID int) (models.ServiceProvider, error)
+	GetServiceProviderByEntityID(ctx context.Context, entityID string) (models.ServiceProvider, error)
+	ListServiceProviders(ctx context.Context) ([]models.ServiceProvider, error)
+	SetServiceProviderDisabled(ctx context.Context, spID int, disabled bool) error
+	DeleteServiceProvider(ctx context.Context, spID int) error
+}
+
+type UserRepository interface {
+	Get(ctx context.Context, email string) (models.User, error)
+	GetByID(ctx context.Context, userID int64) (models.User, error)
+	GetProfile(ctx context.Context, userID int64) (models.Profile, error)
+}
+
+type AppRepository interface {
+	Get(ctx context.Context, appID int) (models.App, error)
+}
+
+type RoleRepository interface {
+	UserAuthorization(ctx context.Context, userID int64, appID int) (models.Authorization, error)
+}
+
+type StateStorage interface {
+	SaveSAML(ctx context.Context, requestID string, login models.SAMLLoginState, ttl time.Duration) error
+	TakeSAML(ctx context.Context, requestID string) (models.SAMLLoginState, error)
+}
+
+// SessionEnder ends the sessions service providers ask to log out of.
+type SessionEnder interface {
+	EndSession(ctx context.Context, userID int64, sessionID string) error
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+type Policy struct {
+	// LoginURL is the page users sign in on before an AuthnRequest is answered. "{request}" in it
+	// is replaced with the ID CompleteLogin takes.
+	LoginURL string
+	// RequestTTL is how long a user has to sign in.
+	RequestTTL time.Duration
+}
+
+type SAMLService struct {
+	log      *slog.Logger
+	idp      *saml.IdentityProvider
+	repo     Repository
+	userRepo UserRepository
+	appRepo  AppRepository
+	roleRepo RoleRepository
+	states   StateStorage
+	sessions SessionEnder
+	audit    AuditRepository
+	policy   Policy
+}
+
+func New(log *slog.Logger, idp *saml.IdentityProvider, repo Repository, userRepo UserRepository, appRepo AppRepository, roleRepo RoleRepository, states StateStorage, sessions SessionEnder, audit AuditRepository, policy Policy) *SAMLService {
+	return &SAMLService{
+		log:      log,
+		idp:      idp,
+		repo:     repo,
+		userRepo: userRepo,
+		appRepo:  appRepo,
+		roleRepo: roleRepo,
+		states:   states,
+		sessions: sessions,
+		audit:    audit,
+		policy:   policy,
+	}
+}
+
+// Metadata describes the identity provider to service providers.
+func (s SAMLService) Metadata() ([]byte, error) {
+	return s.idp.Metadata()
+}
+
+// StartSSO takes an AuthnRequest of a service provider and returns the login page URL to send
+// the user to. Requests of service providers with a certificate must be signed with it.
+func (s SAMLService) StartSSO(ctx context.Context, msg saml.Message) (string, error) {
+	const op = "SAMLService.StartSSO"
+
+	log := s.log.With(slog.String("op", op))
+
+	req, err := saml.ParseAuthnRequest(msg)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.String("issuer", req.Issuer))
+
+	sp, err := s.serviceProvider(ctx, log, req.Issuer)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if sp.Certificate != "" {
+		if req, err = verifyAuthnRequest(msg, sp); err != nil {
+			log.Warn("authn request signature rejected", logger.Err(err))
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if req.ACSURL != "" && req.ACSURL != sp.ACSURL {
+		return "", fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "acs_url", Reason: "is not registered for the service provider"})
+	}
+	if req.Destination != "" && req.Destination != s.idp.SSOURL {
+		return "", fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "destination", Reason: "is not this identity provider"})
+	}
+
+	if err := s.checkApp(ctx, log, sp.AppID); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	loginID := jwt.GenerateRandomToken(32)
+	login := models.SAMLLoginState{ServiceProviderID: sp.ID, RequestID: req.ID, ACSURL: sp.ACSURL, RelayState: msg.RelayState}
+	if err := s.states.SaveSAML(ctx, loginID, login, s.policy.RequestTTL); err != nil {
+		log.Error("failed to save saml request", logger.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return strings.ReplaceAll(s.policy.LoginURL, "{request}", url.QueryEscape(loginID)), nil
+}
+
+func verifyAuthnRequest(msg saml.Message, sp models.ServiceProvider) (saml.AuthnRequest, error) {
+	cert, err := parseCertificate(sp.Certificate)
+	if err != nil {
+		return saml.AuthnRequest{}, err
+	}
+	if msg, err = msg.Verify(cert); err != nil {
+		return saml.AuthnRequest{}, err
+	}
+	return saml.ParseAuthnRequest(msg)
+}
+
+// CompleteLogin answers the AuthnRequest StartSSO handed the loginID out for, on behalf of the
+// signed-in user. The session the user signed in with is the assertion's session index, so
+// single logout can end it.
+func (s SAMLService) CompleteLogin(ctx context.Context, userID int64, sessionID, loginID string) (models.SAMLPost, error) {
+	const op = "SAMLService.CompleteLogin"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID))
+
+	login, err := s.states.TakeSAML(ctx, loginID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStateNotFound) {
+			return models.SAMLPost{}, fmt.Errorf("%s: %w", op, ErrInvalidRequest)
+		}
+		log.Error("failed to get saml request", logger.Err(err))
+		return models.SAMLPost{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sp, err := s.repo.GetServiceProvider(ctx, login.ServiceProviderID)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get service provider", logger.Err(err))
+		}
+		return models.SAMLPost{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if sp.Disabled {
+		return models.SAMLPost{}, fmt.Errorf("%s: %w", op, ErrServiceProviderDisabled)
+	}
+
+	post, err := s.issue(ctx, log, sp, userID, sessionID, login.RequestID, login.RelayState)
+	if err != nil {
+		return models.SAMLPost{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return post, nil
+}
+
+// StartIdPInitiated signs the user in to the service provider without it asking first.
+func (s SAMLService) StartIdPInitiated(ctx context.Context, userID int64, sessionID string, spID int, relayState string) (models.SAMLPost, error) {
+	const op = "SAMLService.StartIdPInitiated"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("userID", userID), slog.Int("spID", spID))
+
+	sp, err := s.repo.GetServiceProvider(ctx, spID)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get service provider", logger.Err(err))
+		}
+		return models.SAMLPost{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if sp.Disabled {
+		return models.SAMLPost{}, fmt.Errorf("%s: %w", op, ErrServiceProviderDisabled)
+	}
+
+	post, err := s.issue(ctx, log, sp, userID, sessionID, "", relayState)
+	if err != nil {
+		return models.SAMLPost{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return post, nil
+}
+
+func (s SAMLService) issue(ctx context.Context, log *slog.Logger, sp models.ServiceProvider, userID int64, sessionID, inResponseTo, relayState string) (models.SAMLPost, error) {
+	log = log.With(slog.Int("spID", sp.ID))
+
+	if err := s.checkApp(ctx, log, sp.AppID); err != nil {
+		return models.SAMLPost{}, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get user", logger.Err(err))
+		}
+		return models.SAMLPost{}, err
+	}
+	if user.Disabled {
+		return models.SAMLPost{}, auth.ErrUserDisabled
+	}
+
+	attributes, err := s.attributes(ctx, sp, user)
+	if err != nil {
+		log.Error("failed to collect attributes", logger.Err(err))
+		return models.SAMLPost{}, err
+	}
+
+	nameID := user.Email
+	if sp.NameIDFormat == saml.NameIDFormatPersistent {
+		nameID = strconv.FormatInt(user.ID, 10)
+	}
+
+	resp, err := s.idp.Response(saml.Assertion{
+		InResponseTo: inResponseTo,
+		Audience:     sp.EntityID,
+		ACSURL:       sp.ACSURL,
+		NameID:       nameID,
+		NameIDFormat: sp.NameIDFormat,
+		SessionIndex: sessionID,
+		AuthnInstant: time.Now(),
+		Attributes:   attributes,
+	})
+	if err != nil {
+		log.Error("failed to issue assertion", logger.Err(err))
+		return models.SAMLPost{}, err
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID:      userID,
+		Action:       ActionIssueAssertion,
+		TargetUserID: userID,
+		AppID:        sp.AppID,
+		Details:      map[string]any{"service_provider_id": sp.ID, "entity_id": sp.EntityID, "idp_initiated": inResponseTo == ""},
+	})
+
+	log.Info("assertion issued")
+
+	return models.SAMLPost{ACSURL: sp.ACSURL, SAMLResponse: resp, RelayState: relayState}, nil
+}
+
+// attributes maps the user's profile to the attributes the service provider gets.
+func (s SAMLService) attributes(ctx context.Context, sp models.ServiceProvider, user models.User) (map[string][]string, error) {
+	names := sp.AttributeMap
+	if len(names) == 0 {
+		names = make(map[string]string, len(Attributes))
+		for _, field := range Attributes {
+			names[field] = field
+		}
+	}
+
+	profile, err := s.userRepo.GetProfile(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := make(map[string][]string, len(names))
+	for field, name := range names {
+		var values []string
+		switch field {
+		case "email":
+			values = []string{user.Email}
+		case "user_id":
+			values = []string{strconv.FormatInt(user.ID, 10)}
+		case "name":
+			values = []string{profile.DisplayName}
+		case "given_name":
+			values = []string{profile.GivenName}
+		case "family_name":
+			values = []string{profile.FamilyName}
+		case "locale":
+			values = []string{profile.Locale}
+		case "zoneinfo":
+			values = []string{profile.Timezone}
+		case "phone_number":
+			values = []string{profile.Phone}
+		case "roles":
+			authz, err := s.roleRepo.UserAuthorization(ctx, user.ID, sp.AppID)
+			if err != nil {
+				return nil, err
+			}
+			values = authz.Roles
+		}
+		if len(values) > 0 && values[0] != "" {
+			attributes[name] = values
+		}
+	}
+
+	return attributes, nil
+}
+
+// SingleLogout ends the sessions a service provider's LogoutRequest names and returns where to
+// send the browser with the response. Only signed requests are accepted.
+func (s SAMLService) SingleLogout(ctx context.Context, msg saml.Message) (string, error) {
+	const op = "SAMLService.SingleLogout"
+
+	log := s.log.With(slog.String("op", op))
+
+	req, err := saml.ParseLogoutRequest(msg)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.String("issuer", req.Issuer))
+
+	sp, err := s.serviceProvider(ctx, log, req.Issuer)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if sp.Certificate == "" || sp.SLOURL == "" {
+		return "", fmt.Errorf("%s: %w", op, ErrLogoutNotSupported)
+	}
+
+	cert, err := parseCertificate(sp.Certificate)
+	if err != nil {
+		log.Error("registered certificate is invalid", logger.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if msg, err = msg.Verify(cert); err != nil {
+		log.Warn("logout request signature rejected", logger.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if req, err = saml.ParseLogoutRequest(msg); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	success := s.logout(ctx, log, sp, req)
+
+	location, err := s.idp.LogoutResponseURL(sp.SLOURL, req.ID, msg.RelayState, success)
+	if err != nil {
+		log.Error("failed to build logout response", logger.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return location, nil
+}
+
+// logout ends the sessions of the request and reports whether all of them are gone. Requests
+// without a session index are refused, as every assertion issued names its session.
+func (s SAMLService) logout(ctx context.Context, log *slog.Logger, sp models.ServiceProvider, req saml.LogoutRequest) bool {
+	var (
+		user models.User
+		err  error
+	)
+	if sp.NameIDFormat == saml.NameIDFormatPersistent {
+		userID, perr := strconv.ParseInt(req.NameID, 10, 64)
+		if perr != nil {
+			log.Info("logout request for an unknown name id")
+			return false
+		}
+		user, err = s.userRepo.GetByID(ctx, userID)
+	} else {
+		user, err = s.userRepo.Get(ctx, req.NameID)
+	}
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			log.Error("failed to get user", logger.Err(err))
+		}
+		return false
+	}
+
+	if len(req.SessionIndexes) == 0 {
+		log.Info("logout request without session index", slog.Int64("userID", user.ID))
+		return false
+	}
+
+	for _, sessionID := range req.SessionIndexes {
+		if err := s.sessions.EndSession(ctx, user.ID, sessionID); err != nil {
+			return false
+		}
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID:      user.ID,
+		Action:       ActionSingleLogout,
+		TargetUserID: user.ID,
+		AppID:        sp.AppID,
+		Details:      map[string]any{"service_provider_id": sp.ID, "sessions": len(req.SessionIndexes)},
+	})
+
+	log.Info("single logout completed", slog.Int64("userID", user.ID))
+
+	return true
+}
+
+func (s SAMLService) serviceProvider(ctx context.Context, log *slog.Logger, entityID string) (models.ServiceProvider, error) {
+	sp, err := s.repo.GetServiceProviderByEntityID(ctx, entityID)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get service provider", logger.Err(err))
+		}
+		return models.ServiceProvider{}, err
+	}
+	if sp.Disabled {
+		return models.ServiceProvider{}, ErrServiceProviderDisabled
+	}
+	return sp, nil
+}
+
+// checkApp makes sure the app the service provider belongs to may sign users in with SAML.
+func (s SAMLService) checkApp(ctx context.Context, log *slog.Logger, appID int) error {
+	app, err := s.appRepo.Get(ctx, appID)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get app", logger.Err(err))
+		}
+		return err
+	}
+	if !app.Enabled {
+		return auth.ErrAppDisabled
+	}
+	if !app.AllowsGrant(models.GrantSAML) {
+		return auth.ErrGrantNotAllowed
+	}
+	return nil
+}
+
+// CreateServiceProvider registers a service provider under an app.
+func (s SAMLService) CreateServiceProvider(ctx context.Context, actorID int64, sp models.ServiceProvider) (models.ServiceProvider, error) {
+	const op = "SAMLService.CreateServiceProvider"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.String("entityID", sp.EntityID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return models.ServiceProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sp.Name = strings.TrimSpace(sp.Name)
+	sp.Certificate = strings.TrimSpace(sp.Certificate)
+	if sp.NameIDFormat == "" {
+		sp.NameIDFormat = saml.NameIDFormatEmail
+	}
+	if err := validateServiceProvider(sp); err != nil {
+		return models.ServiceProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := s.repo.CreateServiceProvider(ctx, sp)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to create service provider", logger.Err(err))
+		}
+		return models.ServiceProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := s.repo.GetServiceProvider(ctx, id)
+	if err != nil {
+		log.Error("failed to get service provider", logger.Err(err))
+		return models.ServiceProvider{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionCreateServiceProvider,
+		AppID:   sp.AppID,
+		Details: map[string]any{"service_provider_id": id, "entity_id": sp.EntityID},
+	})
+
+	log.Info("service provider created", slog.Int("spID", id))
+
+	return created, nil
+}
+
+func (s SAMLService) ListServiceProviders(ctx context.Context, actorID int64) ([]models.ServiceProvider, error) {
+	const op = "SAMLService.ListServiceProviders"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sps, err := s.repo.ListServiceProviders(ctx)
+	if err != nil {
+		log.Error("failed to list service providers", logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sps, nil
+}
+
+// SetServiceProviderDisabled stops or resumes sign-ins to the service provider.
+func (s SAMLService) SetServiceProviderDisabled(ctx context.Context, actorID int64, spID int, disabled bool) error {
+	const op = "SAMLService.SetServiceProviderDisabled"
+
+	action := ActionEnableServiceProvider
+	if disabled {
+		action = ActionDisableServiceProvider
+	}
+
+	return s.mutate(ctx, op, actorID, spID, action, func() error {
+		return s.repo.SetServiceProviderDisabled(ctx, spID, disabled)
+	})
+}
+
+func (s SAMLService) DeleteServiceProvider(ctx context.Context, actorID int64, spID int) error {
+	const op = "SAMLService.DeleteServiceProvider"
+
+	return s.mutate(ctx, op, actorID, spID, ActionDeleteServiceProvider, func() error {
+		return s.repo.DeleteServiceProvider(ctx, spID)
+	})
+}
+
+// mutate runs an admin-only change of a service provider and audits it.
+func (s SAMLService) mutate(ctx context.Context, op string, actorID int64, spID int, action string, fn func() error) error {
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int("spID", spID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := fn(); err != nil {
+		if !isExpected(err) {
+			log.Error("service provider action failed", slog.String("action", action), logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: actorID, Action: action, Details: map[string]any{"service_provider_id": spID}})
+
+	log.Info("service provider action performed", slog.String("action", action))
+
+	return nil
+}
+
+func validateServiceProvider(sp models.ServiceProvider) error {
+	if sp.AppID <= 0 {
+		return &serviceerr.FieldError{Field: "app_id", Reason: "is required"}
+	}
+	if sp.EntityID == "" {
+		return &serviceerr.FieldError{Field: "entity_id", Reason: "must not be empty"}
+	}
+	if sp.Name == "" {
+		return &serviceerr.FieldError{Field: "name", Reason: "must not be empty"}
+	}
+	if !isAbsoluteURL(sp.ACSURL) {
+		return &serviceerr.FieldError{Field: "acs_url", Reason: "must be an absolute http(s) URL"}
+	}
+	if sp.SLOURL != "" && !isAbsoluteURL(sp.SLOURL) {
+		return &serviceerr.FieldError{Field: "slo_url", Reason: "must be an absolute http(s) URL"}
+	}
+	if sp.Certificate != "" {
+		if _, err := parseCertificate(sp.Certificate); err != nil {
+			return &serviceerr.FieldError{Field: "certificate", Reason: "must be a PEM encoded X.509 certificate"}
+		}
+	}
+	if sp.NameIDFormat != saml.NameIDFormatEmail && sp.NameIDFormat != saml.NameIDFormatPersistent {
+		return &serviceerr.FieldError{Field: "name_id_format", Reason: "must be the emailAddress or persistent format"}
+	}
+	for field, name := range sp.AttributeMap {
+		if !isAttribute(field) {
+			return &serviceerr.FieldError{Field: "attribute_map", Reason: fmt.Sprintf("%q is not a profile field", field)}
+		}
+		if strings.TrimSpace(name) == "" {
+			return &serviceerr.FieldError{Field: "attribute_map", Reason: fmt.Sprintf("%q maps to an empty name", field)}
+		}
+	}
+	return nil
+}
+
+func isAbsoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+func isAttribute(field string) bool {
+	for _, a := range Attributes {
+		if a == field {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCertificate(data string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no certificate in PEM data")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func (s SAMLService) requireAdmin(ctx context.Context, log *slog.Logger, actorID int64) error {
+	err := admin.RequireAdmin(ctx, s.userRepo, actorID)
+	if err != nil && !errors.Is(err, admin.ErrPermissionDenied) {
+		log.Error("failed to check admin", logger.Err(err))
+	}
+	return err
+}
+
+func isExpected(err error) bool {
+	var ferr *serviceerr.FieldError
+	return errors.As(err, &ferr) ||
+		errors.Is(err, repository.ErrServiceProviderNotFound) ||
+		errors.Is(err, repository.ErrServiceProviderExists) ||
+		errors.Is(err, repository.ErrUserNotFound) ||
+		errors.Is(err, repository.ErrAppNotFound)
+}
@@ -0,0 +1,389 @@
+package samlidp
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/auth"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/saml"
+	"auth/pkg/saml/samltest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ssoURL   = "https://sso.example.com/saml/sso"
+	sloURL   = "https://sso.example.com/saml/slo"
+	loginURL = "https://sso.example.com/login?saml={request}"
+	appID    = 7
+)
+
+// store keeps users, apps, service providers and SAML requests in memory.
+type store struct {
+	users    map[int64]models.User
+	apps     map[int]models.App
+	sps      map[int]models.ServiceProvider
+	states   map[string]models.SAMLLoginState
+	ended    []string
+	recorded []string
+}
+
+func newStore() *store {
+	return &store{
+		users: map[int64]models.User{
+			1: {ID: 1, Email: "admin@example.com", IsAdmin: true},
+			2: {ID: 2, Email: "ann@example.com"},
+			3: {ID: 3, Email: "gone@example.com", Disabled: true},
+		},
+		apps:   map[int]models.App{appID: {ID: appID, Enabled: true, GrantTypes: []string{models.GrantSAML}}},
+		sps:    make(map[int]models.ServiceProvider),
+		states: make(map[string]models.SAMLLoginState),
+	}
+}
+
+func (s *store) Get(_ context.Context, email string) (models.User, error) {
+	for _, u := range s.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return models.User{}, repository.ErrUserNotFound
+}
+
+func (s *store) GetByID(_ context.Context, userID int64) (models.User, error) {
+	u, ok := s.users[userID]
+	if !ok {
+		return models.User{}, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (s *store) GetProfile(_ context.Context, userID int64) (models.Profile, error) {
+	return models.Profile{UserID: userID, DisplayName: "Ann Example", GivenName: "Ann"}, nil
+}
+
+func (s *store) UserAuthorization(context.Context, int64, int) (models.Authorization, error) {
+	return models.Authorization{Roles: []string{"editor"}}, nil
+}
+
+func (s *store) CreateServiceProvider(_ context.Context, sp models.ServiceProvider) (int, error) {
+	for _, existing := range s.sps {
+		if existing.EntityID == sp.EntityID {
+			return 0, repository.ErrServiceProviderExists
+		}
+	}
+	sp.ID = len(s.sps) + 1
+	s.sps[sp.ID] = sp
+	return sp.ID, nil
+}
+
+func (s *store) GetServiceProvider(_ context.Context, spID int) (models.ServiceProvider, error) {
+	sp, ok := s.sps[spID]
+	if !ok {
+		return models.ServiceProvider{}, repository.ErrServiceProviderNotFound
+	}
+	return sp, nil
+}
+
+func (s *store) GetServiceProviderByEntityID(_ context.Context, entityID string) (models.ServiceProvider, error) {
+	for _, sp := range s.sps {
+		if sp.EntityID == entityID {
+			return sp, nil
+		}
+	}
+	return models.ServiceProvider{}, repository.ErrServiceProviderNotFound
+}
+
+func (s *store) ListServiceProviders(context.Context) ([]models.ServiceProvider, error) {
+	var res []models.ServiceProvider
+	for _, sp := range s.sps {
+		res = append(res, sp)
+	}
+	return res, nil
+}
+
+func (s *store) SetServiceProviderDisabled(_ context.Context, spID int, disabled bool) error {
+	sp, ok := s.sps[spID]
+	if !ok {
+		return repository.ErrServiceProviderNotFound
+	}
+	sp.Disabled = disabled
+	s.sps[spID] = sp
+	return nil
+}
+
+func (s *store) DeleteServiceProvider(_ context.Context, spID int) error {
+	if _, ok := s.sps[spID]; !ok {
+		return repository.ErrServiceProviderNotFound
+	}
+	delete(s.sps, spID)
+	return nil
+}
+
+func (s *store) SaveSAML(_ context.Context, requestID string, login models.SAMLLoginState, _ time.Duration) error {
+	s.states[requestID] = login
+	return nil
+}
+
+func (s *store) TakeSAML(_ context.Context, requestID string) (models.SAMLLoginState, error) {
+	login, ok := s.states[requestID]
+	if !ok {
+		return models.SAMLLoginState{}, repository.ErrStateNotFound
+	}
+	delete(s.states, requestID)
+	return login, nil
+}
+
+func (s *store) EndSession(_ context.Context, userID int64, sessionID string) error {
+	s.ended = append(s.ended, sessionID)
+	return nil
+}
+
+func (s *store) Record(_ context.Context, entry models.AuditEntry) error {
+	s.recorded = append(s.recorded, entry.Action)
+	return nil
+}
+
+// apps serves the store's apps; the store's own Get looks up users.
+type apps struct{ *store }
+
+func (a apps) Get(_ context.Context, id int) (models.App, error) {
+	app, ok := a.apps[id]
+	if !ok {
+		return models.App{}, repository.ErrAppNotFound
+	}
+	return app, nil
+}
+
+func newService(t *testing.T) (*SAMLService, *store, *saml.IdentityProvider) {
+	t.Helper()
+
+	key, cert := samltest.NewCertificate("sso.example.com")
+	idp := &saml.IdentityProvider{
+		EntityID:     "https://sso.example.com/saml/metadata",
+		SSOURL:       ssoURL,
+		SLOURL:       sloURL,
+		Key:          key,
+		Certificate:  cert,
+		AssertionTTL: 5 * time.Minute,
+	}
+
+	st := newStore()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := New(log, idp, st, st, apps{st}, st, st, st, st, Policy{LoginURL: loginURL, RequestTTL: time.Minute})
+
+	return s, st, idp
+}
+
+func register(t *testing.T, s *SAMLService, sp *samltest.ServiceProvider, attributeMap map[string]string) models.ServiceProvider {
+	t.Helper()
+
+	created, err := s.CreateServiceProvider(context.Background(), 1, models.ServiceProvider{
+		AppID:        appID,
+		EntityID:     sp.EntityID,
+		Name:         "Wiki",
+		ACSURL:       sp.ACSURL,
+		SLOURL:       sp.SLOURL,
+		Certificate:  sp.CertificatePEM(),
+		AttributeMap: attributeMap,
+	})
+	require.NoError(t, err)
+	return created
+}
+
+func message(t *testing.T, location string) saml.Message {
+	t.Helper()
+	m, err := saml.ReadMessage(httptest.NewRequest(http.MethodGet, location, nil))
+	require.NoError(t, err)
+	return m
+}
+
+func loginID(t *testing.T, location string) string {
+	t.Helper()
+	u, err := url.Parse(location)
+	require.NoError(t, err)
+	return u.Query().Get("saml")
+}
+
+func TestSPInitiated(t *testing.T) {
+	ctx := context.Background()
+	s, st, idp := newService(t)
+	sp := samltest.NewServiceProvider("https://wiki.example.com", "https://wiki.example.com/acs", "https://wiki.example.com/slo")
+	register(t, s, sp, map[string]string{"email": "mail", "roles": "groups"})
+
+	location, requestID := sp.AuthnRequestURL(ssoURL, "/page")
+	login, err := s.StartSSO(ctx, message(t, location))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(login, "https://sso.example.com/login?saml="))
+
+	post, err := s.CompleteLogin(ctx, 2, "sid-1", loginID(t, login))
+	require.NoError(t, err)
+	assert.Equal(t, sp.ACSURL, post.ACSURL)
+	assert.Equal(t, "/page", post.RelayState)
+
+	a, err := sp.ParseResponse(post.SAMLResponse, idp.Certificate)
+	require.NoError(t, err)
+	assert.Equal(t, requestID, a.InResponseTo)
+	assert.Equal(t, sp.EntityID, a.Audience)
+	assert.Equal(t, "ann@example.com", a.NameID)
+	assert.Equal(t, "sid-1", a.SessionIndex)
+	assert.Equal(t, map[string][]string{"mail": {"ann@example.com"}, "groups": {"editor"}}, a.Attributes)
+	assert.Contains(t, st.recorded, ActionIssueAssertion)
+
+	_, err = s.CompleteLogin(ctx, 2, "sid-1", loginID(t, login))
+	assert.ErrorIs(t, err, ErrInvalidRequest, "requests are answered once")
+}
+
+func TestStartSSO_Rejected(t *testing.T) {
+	ctx := context.Background()
+	s, st, _ := newService(t)
+	sp := samltest.NewServiceProvider("https://wiki.example.com", "https://wiki.example.com/acs", "")
+	registered := register(t, s, sp, nil)
+
+	t.Run("unregistered acs url", func(t *testing.T) {
+		other := *sp
+		other.ACSURL = "https://evil.example.com/acs"
+		location, _ := other.AuthnRequestURL(ssoURL, "")
+
+		_, err := s.StartSSO(ctx, message(t, location))
+		var ferr *serviceerr.FieldError
+		require.ErrorAs(t, err, &ferr)
+		assert.Equal(t, "acs_url", ferr.Field)
+	})
+
+	t.Run("signed with another key", func(t *testing.T) {
+		impostor := samltest.NewServiceProvider(sp.EntityID, sp.ACSURL, "")
+		location, _ := impostor.AuthnRequestURL(ssoURL, "")
+
+		_, err := s.StartSSO(ctx, message(t, location))
+		assert.ErrorIs(t, err, saml.ErrSignature)
+	})
+
+	t.Run("unknown service provider", func(t *testing.T) {
+		unknown := samltest.NewServiceProvider("https://unknown.example.com", "https://unknown.example.com/acs", "")
+		location, _ := unknown.AuthnRequestURL(ssoURL, "")
+
+		_, err := s.StartSSO(ctx, message(t, location))
+		assert.ErrorIs(t, err, repository.ErrServiceProviderNotFound)
+	})
+
+	t.Run("grant not allowed", func(t *testing.T) {
+		st.apps[appID] = models.App{ID: appID, Enabled: true, GrantTypes: []string{models.GrantPassword}}
+		defer func() { st.apps[appID] = models.App{ID: appID, Enabled: true, GrantTypes: []string{models.GrantSAML}} }()
+
+		location, _ := sp.AuthnRequestURL(ssoURL, "")
+		_, err := s.StartSSO(ctx, message(t, location))
+		assert.ErrorIs(t, err, auth.ErrGrantNotAllowed)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		require.NoError(t, s.SetServiceProviderDisabled(ctx, 1, registered.ID, true))
+
+		location, _ := sp.AuthnRequestURL(ssoURL, "")
+		_, err := s.StartSSO(ctx, message(t, location))
+		assert.ErrorIs(t, err, ErrServiceProviderDisabled)
+	})
+}
+
+func TestIdPInitiated(t *testing.T) {
+	ctx := context.Background()
+	s, _, idp := newService(t)
+	sp := samltest.NewServiceProvider("https://wiki.example.com", "https://wiki.example.com/acs", "")
+	registered := register(t, s, sp, nil)
+
+	post, err := s.StartIdPInitiated(ctx, 2, "sid-1", registered.ID, "/home")
+	require.NoError(t, err)
+	assert.Equal(t, "/home", post.RelayState)
+
+	a, err := sp.ParseResponse(post.SAMLResponse, idp.Certificate)
+	require.NoError(t, err)
+	assert.Empty(t, a.InResponseTo)
+	assert.Equal(t, []string{"Ann Example"}, a.Attributes["name"])
+	assert.Equal(t, []string{"2"}, a.Attributes["user_id"])
+	assert.NotContains(t, a.Attributes, "locale", "empty fields aren't sent")
+
+	_, err = s.StartIdPInitiated(ctx, 3, "sid-2", registered.ID, "")
+	assert.ErrorIs(t, err, auth.ErrUserDisabled)
+}
+
+func TestSingleLogout(t *testing.T) {
+	ctx := context.Background()
+	s, st, idp := newService(t)
+	sp := samltest.NewServiceProvider("https://wiki.example.com", "https://wiki.example.com/acs", "https://wiki.example.com/slo")
+	register(t, s, sp, nil)
+
+	location, err := s.SingleLogout(ctx, message(t, sp.LogoutRequestURL(sloURL, "ann@example.com", saml.NameIDFormatEmail, "sid-1")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sid-1"}, st.ended)
+
+	ok, err := sp.ParseLogoutResponse(location, idp.Certificate)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	t.Run("unknown user", func(t *testing.T) {
+		location, err := s.SingleLogout(ctx, message(t, sp.LogoutRequestURL(sloURL, "nobody@example.com", saml.NameIDFormatEmail, "sid-2")))
+		require.NoError(t, err)
+
+		ok, err := sp.ParseLogoutResponse(location, idp.Certificate)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("signed with another key", func(t *testing.T) {
+		impostor := samltest.NewServiceProvider(sp.EntityID, sp.ACSURL, sp.SLOURL)
+
+		_, err := s.SingleLogout(ctx, message(t, impostor.LogoutRequestURL(sloURL, "ann@example.com", saml.NameIDFormatEmail, "sid-3")))
+		assert.ErrorIs(t, err, saml.ErrSignature)
+		assert.NotContains(t, st.ended, "sid-3")
+	})
+
+	t.Run("no logout url", func(t *testing.T) {
+		noSLO := samltest.NewServiceProvider("https://crm.example.com", "https://crm.example.com/acs", "")
+		register(t, s, noSLO, nil)
+
+		_, err := s.SingleLogout(ctx, message(t, noSLO.LogoutRequestURL(sloURL, "ann@example.com", saml.NameIDFormatEmail, "sid-4")))
+		assert.ErrorIs(t, err, ErrLogoutNotSupported)
+	})
+}
+
+func TestCreateServiceProvider(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newService(t)
+
+	valid := models.ServiceProvider{AppID: appID, EntityID: "https://wiki.example.com", Name: "Wiki", ACSURL: "https://wiki.example.com/acs"}
+
+	_, err := s.CreateServiceProvider(ctx, 2, valid)
+	assert.ErrorIs(t, err, admin.ErrPermissionDenied)
+
+	created, err := s.CreateServiceProvider(ctx, 1, valid)
+	require.NoError(t, err)
+	assert.Equal(t, saml.NameIDFormatEmail, created.NameIDFormat)
+
+	_, err = s.CreateServiceProvider(ctx, 1, valid)
+	assert.ErrorIs(t, err, repository.ErrServiceProviderExists)
+
+	for field, sp := range map[string]models.ServiceProvider{
+		"acs_url":        {AppID: appID, EntityID: "a", Name: "A", ACSURL: "/acs"},
+		"certificate":    {AppID: appID, EntityID: "a", Name: "A", ACSURL: "https://a/acs", Certificate: "not a certificate"},
+		"name_id_format": {AppID: appID, EntityID: "a", Name: "A", ACSURL: "https://a/acs", NameIDFormat: "transient"},
+		"attribute_map":  {AppID: appID, EntityID: "a", Name: "A", ACSURL: "https://a/acs", AttributeMap: map[string]string{"password": "pw"}},
+	} {
+		_, err := s.CreateServiceProvider(ctx, 1, sp)
+		var ferr *serviceerr.FieldError
+		if assert.ErrorAs(t, err, &ferr, field) {
+			assert.Equal(t, field, ferr.Field)
+		}
+	}
+}
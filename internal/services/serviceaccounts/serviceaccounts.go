@@ -0,0 +1,457 @@
+package serviceaccounts
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/audit"
+	"auth/internal/services/auth"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+)
+
+const (
+	ActionCreate      = "service_accounts.create"
+	ActionDelete      = "service_accounts.delete"
+	ActionDisable     = "service_accounts.disable"
+	ActionEnable      = "service_accounts.enable"
+	ActionAddKey      = "service_accounts.add_key"
+	ActionRevokeKey   = "service_accounts.revoke_key"
+	ActionAssignRole  = "service_accounts.assign_role"
+	ActionRevokeRole  = "service_accounts.revoke_role"
+	ActionTokenIssued = "service_accounts.token_issued"
+)
+
+// ErrInvalidAssertion is returned for assertions that are malformed, badly signed, expired,
+// or made with a key or by a service account that can't be used.
+var ErrInvalidAssertion = errors.New("invalid assertion")
+
+const maxNameLength = 100
+
+type ServiceAccountRepository interface {
+	Create(ctx context.Context, sa models.ServiceAccount) (int64, error)
+	Get(ctx context.Context, id int64) (models.ServiceAccount, error)
+	List(ctx context.Context, appID int) ([]models.ServiceAccount, error)
+	SetDisabled(ctx context.Context, id int64, disabled bool) error
+	Delete(ctx context.Context, id int64) error
+	AddKey(ctx context.Context, key models.ServiceAccountKey) error
+	GetKey(ctx context.Context, keyID string) (models.ServiceAccountKey, error)
+	ListKeys(ctx context.Context, serviceAccountID int64) ([]models.ServiceAccountKey, error)
+	DeleteKey(ctx context.Context, serviceAccountID int64, keyID string) error
+	AssignRole(ctx context.Context, serviceAccountID, roleID int64) error
+	RevokeRole(ctx context.Context, serviceAccountID, roleID int64) error
+	Authorization(ctx context.Context, serviceAccountID int64) (models.Authorization, error)
+}
+
+type AppRepository interface {
+	Get(ctx context.Context, appID int) (models.App, error)
+}
+
+type RoleRepository interface {
+	GetRole(ctx context.Context, roleID int64) (models.Role, error)
+}
+
+type UserRepository interface {
+	GetByID(ctx context.Context, userID int64) (models.User, error)
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+type RevocationPublisher interface {
+	Publish(ctx context.Context, r models.Revocation) error
+}
+
+// AssertionPolicy controls which assertions are accepted and what tokens they are exchanged for.
+type AssertionPolicy struct {
+	// Audience is the "aud" assertions must be addressed to.
+	Audience string
+	// MaxAssertionTTL bounds the time between "iat" and "exp" of an assertion.
+	MaxAssertionTTL time.Duration
+	// AccessTTL is the token lifetime for apps that don't set their own.
+	AccessTTL           time.Duration
+	MaxAuthzClaimsBytes int
+}
+
+type ServiceAccountService struct {
+	log         *slog.Logger
+	repo        ServiceAccountRepository
+	appRepo     AppRepository
+	roleRepo    RoleRepository
+	userRepo    UserRepository
+	audit       AuditRepository
+	policy      AssertionPolicy
+	revocations RevocationPublisher
+}
+
+func New(log *slog.Logger, repo ServiceAccountRepository, appRepo AppRepository, roleRepo RoleRepository, userRepo UserRepository, audit AuditRepository, policy AssertionPolicy, revocations RevocationPublisher) *ServiceAccountService {
+	return &ServiceAccountService{log: log, repo: repo, appRepo: appRepo, roleRepo: roleRepo, userRepo: userRepo, audit: audit, policy: policy, revocations: revocations}
+}
+
+func (s ServiceAccountService) CreateServiceAccount(ctx context.Context, actorID int64, sa models.ServiceAccount) (models.ServiceAccount, error) {
+	const op = "ServiceAccountService.CreateServiceAccount"
+
+	sa.Name = strings.TrimSpace(sa.Name)
+	if sa.Name == "" || len(sa.Name) > maxNameLength {
+		return models.ServiceAccount{}, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "name", Reason: fmt.Sprintf("must be 1-%d characters", maxNameLength)})
+	}
+	sa.CreatedBy = actorID
+
+	err := s.mutate(ctx, op, actorID, 0, ActionCreate, map[string]any{"app_id": sa.AppID, "name": sa.Name}, func() (int64, error) {
+		id, err := s.repo.Create(ctx, sa)
+		if err != nil {
+			return 0, err
+		}
+		sa, err = s.repo.Get(ctx, id)
+		return id, err
+	})
+	if err != nil {
+		return models.ServiceAccount{}, err
+	}
+
+	return sa, nil
+}
+
+// GetServiceAccount returns the service account with the names of its roles and permissions.
+func (s ServiceAccountService) GetServiceAccount(ctx context.Context, actorID, id int64) (models.ServiceAccount, models.Authorization, error) {
+	const op = "ServiceAccountService.GetServiceAccount"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int64("serviceAccountID", id))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return models.ServiceAccount{}, models.Authorization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sa, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get service account", logger.Err(err))
+		}
+		return models.ServiceAccount{}, models.Authorization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	authz, err := s.repo.Authorization(ctx, id)
+	if err != nil {
+		log.Error("failed to get service account roles", logger.Err(err))
+		return models.ServiceAccount{}, models.Authorization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sa, authz, nil
+}
+
+func (s ServiceAccountService) ListServiceAccounts(ctx context.Context, actorID int64, appID int) ([]models.ServiceAccount, error) {
+	const op = "ServiceAccountService.ListServiceAccounts"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	accounts, err := s.repo.List(ctx, appID)
+	if err != nil {
+		log.Error("failed to list service accounts", logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return accounts, nil
+}
+
+// SetDisabled disables or re-enables a service account. No new tokens are issued to a
+// disabled account, and resource servers are told to reject the ones already issued.
+func (s ServiceAccountService) SetDisabled(ctx context.Context, actorID, id int64, disabled bool) error {
+	const op = "ServiceAccountService.SetDisabled"
+
+	action := ActionEnable
+	if disabled {
+		action = ActionDisable
+	}
+
+	err := s.mutate(ctx, op, actorID, id, action, nil, func() (int64, error) {
+		return id, s.repo.SetDisabled(ctx, id, disabled)
+	})
+	if err != nil {
+		return err
+	}
+
+	if disabled {
+		s.revoke(ctx, s.log.With(slog.String("op", op)), models.Revocation{Kind: models.RevokedUser, ServiceAccountID: id})
+	}
+
+	return nil
+}
+
+func (s ServiceAccountService) DeleteServiceAccount(ctx context.Context, actorID, id int64) error {
+	const op = "ServiceAccountService.DeleteServiceAccount"
+
+	err := s.mutate(ctx, op, actorID, id, ActionDelete, nil, func() (int64, error) {
+		return id, s.repo.Delete(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.revoke(ctx, s.log.With(slog.String("op", op)), models.Revocation{Kind: models.RevokedUser, ServiceAccountID: id})
+
+	return nil
+}
+
+// AddKey registers a PEM encoded public key for the service account and returns it with the
+// key ID assertions have to name in their "kid" header.
+func (s ServiceAccountService) AddKey(ctx context.Context, actorID, id int64, publicKey string, expiresAt time.Time) (models.ServiceAccountKey, error) {
+	const op = "ServiceAccountService.AddKey"
+
+	_, alg, err := jwt.ParsePublicKey(publicKey)
+	if err != nil {
+		return models.ServiceAccountKey{}, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "public_key", Reason: err.Error()})
+	}
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return models.ServiceAccountKey{}, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "expires_at", Reason: "must be in the future"})
+	}
+
+	key := models.ServiceAccountKey{
+		ID:               jwt.GenerateRandomToken(12),
+		ServiceAccountID: id,
+		PublicKey:        publicKey,
+		Algorithm:        alg,
+		ExpiresAt:        expiresAt,
+	}
+
+	err = s.mutate(ctx, op, actorID, id, ActionAddKey, map[string]any{"key_id": key.ID, "algorithm": alg}, func() (int64, error) {
+		if err := s.repo.AddKey(ctx, key); err != nil {
+			return 0, err
+		}
+		key, err = s.repo.GetKey(ctx, key.ID)
+		return id, err
+	})
+	if err != nil {
+		return models.ServiceAccountKey{}, err
+	}
+
+	return key, nil
+}
+
+func (s ServiceAccountService) ListKeys(ctx context.Context, actorID, id int64) ([]models.ServiceAccountKey, error) {
+	const op = "ServiceAccountService.ListKeys"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int64("serviceAccountID", id))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.repo.Get(ctx, id); err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get service account", logger.Err(err))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys, err := s.repo.ListKeys(ctx, id)
+	if err != nil {
+		log.Error("failed to list keys", logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+func (s ServiceAccountService) RevokeKey(ctx context.Context, actorID, id int64, keyID string) error {
+	const op = "ServiceAccountService.RevokeKey"
+
+	err := s.mutate(ctx, op, actorID, id, ActionRevokeKey, map[string]any{"key_id": keyID}, func() (int64, error) {
+		return id, s.repo.DeleteKey(ctx, id, keyID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.revoke(ctx, s.log.With(slog.String("op", op)), models.Revocation{Kind: models.RevokedKey, ServiceAccountID: id, KeyID: keyID})
+
+	return nil
+}
+
+// AssignRole gives the service account a role. The role must belong to the account's app.
+func (s ServiceAccountService) AssignRole(ctx context.Context, actorID, id, roleID int64) error {
+	const op = "ServiceAccountService.AssignRole"
+
+	return s.mutate(ctx, op, actorID, id, ActionAssignRole, map[string]any{"role_id": roleID}, func() (int64, error) {
+		sa, err := s.repo.Get(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		role, err := s.roleRepo.GetRole(ctx, roleID)
+		if err != nil {
+			return 0, err
+		}
+		if role.AppID != sa.AppID {
+			return 0, &serviceerr.FieldError{Field: "role_id", Reason: "role belongs to another app"}
+		}
+		return id, s.repo.AssignRole(ctx, id, roleID)
+	})
+}
+
+func (s ServiceAccountService) RevokeRole(ctx context.Context, actorID, id, roleID int64) error {
+	const op = "ServiceAccountService.RevokeRole"
+
+	return s.mutate(ctx, op, actorID, id, ActionRevokeRole, map[string]any{"role_id": roleID}, func() (int64, error) {
+		return id, s.repo.RevokeRole(ctx, id, roleID)
+	})
+}
+
+// ExchangeAssertion verifies a JWT-bearer assertion made by a service account and issues an
+// access token of the account's app, carrying the account's roles and permissions. The
+// assertion names its key in "kid" and the service account ID in both "iss" and "sub".
+func (s ServiceAccountService) ExchangeAssertion(ctx context.Context, assertion string) (accessToken string, expiresIn time.Duration, err error) {
+	const op = "ServiceAccountService.ExchangeAssertion"
+
+	log := s.log.With(slog.String("op", op))
+
+	var key models.ServiceAccountKey
+	var lookupErr error
+	claims, err := jwt.ParseAssertion(assertion, s.policy.Audience, s.policy.MaxAssertionTTL, func(kid string) (crypto.PublicKey, string, error) {
+		key, lookupErr = s.repo.GetKey(ctx, kid)
+		if lookupErr != nil {
+			return nil, "", lookupErr
+		}
+		if key.Expired(time.Now()) {
+			return nil, "", errors.New("key expired")
+		}
+		pub, alg, err := jwt.ParsePublicKey(key.PublicKey)
+		if err != nil {
+			return nil, "", err
+		}
+		return pub, alg, nil
+	})
+	if lookupErr != nil && !errors.Is(lookupErr, repository.ErrKeyNotFound) {
+		log.Error("failed to get key", logger.Err(lookupErr))
+		return "", 0, fmt.Errorf("%s: %w", op, lookupErr)
+	}
+	if err != nil {
+		log.Info("assertion rejected", logger.Err(err))
+		return "", 0, fmt.Errorf("%s: %w", op, ErrInvalidAssertion)
+	}
+
+	if claims.Subject != strconv.FormatInt(key.ServiceAccountID, 10) {
+		log.Info("assertion rejected: subject does not own the key", slog.String("kid", key.ID))
+		return "", 0, fmt.Errorf("%s: %w", op, ErrInvalidAssertion)
+	}
+
+	log = log.With(slog.Int64("serviceAccountID", key.ServiceAccountID))
+
+	sa, err := s.repo.Get(ctx, key.ServiceAccountID)
+	if err != nil {
+		if errors.Is(err, repository.ErrServiceAccountNotFound) {
+			return "", 0, fmt.Errorf("%s: %w", op, ErrInvalidAssertion)
+		}
+		log.Error("failed to get service account", logger.Err(err))
+		return "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if sa.Disabled {
+		log.Info("assertion rejected: service account disabled")
+		return "", 0, fmt.Errorf("%s: %w", op, ErrInvalidAssertion)
+	}
+
+	app, err := s.appRepo.Get(ctx, sa.AppID)
+	if err != nil {
+		log.Error("failed to get app", logger.Err(err))
+		return "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if !app.Enabled {
+		return "", 0, fmt.Errorf("%s: %w", op, auth.ErrAppDisabled)
+	}
+	if !app.AllowsGrant(models.GrantJWTBearer) {
+		return "", 0, fmt.Errorf("%s: %w", op, auth.ErrGrantNotAllowed)
+	}
+
+	authz, err := s.repo.Authorization(ctx, sa.ID)
+	if err != nil {
+		log.Error("failed to get service account roles", logger.Err(err))
+		return "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ttl := s.policy.AccessTTL
+	if app.AccessTTL > 0 {
+		ttl = app.AccessTTL
+	}
+
+	accessToken, err = jwt.GenerateJWT(app.AccessSecret, 0, "", app.ID, ttl,
+		jwt.WithServiceAccount(sa.ID),
+		jwt.WithAuthorization(authz.Roles, authz.Permissions, s.policy.MaxAuthzClaimsBytes),
+	)
+	if err != nil {
+		log.Error("failed to generate access token", logger.Err(err))
+		return "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		Action:           ActionTokenIssued,
+		ServiceAccountID: sa.ID,
+		Details:          map[string]any{"app_id": app.ID, "key_id": key.ID},
+	})
+
+	log.Info("service account token issued")
+
+	return accessToken, ttl, nil
+}
+
+// mutate runs an admin-only change and audits it against the service account fn returns.
+func (s ServiceAccountService) mutate(ctx context.Context, op string, actorID, id int64, action string, details map[string]any, fn func() (int64, error)) error {
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int64("serviceAccountID", id))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := fn()
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("service account action failed", slog.String("action", action), logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{ActorID: actorID, Action: action, ServiceAccountID: id, Details: details})
+
+	log.Info("service account action performed", slog.String("action", action))
+
+	return nil
+}
+
+func (s ServiceAccountService) requireAdmin(ctx context.Context, log *slog.Logger, actorID int64) error {
+	err := admin.RequireAdmin(ctx, s.userRepo, actorID)
+	if err != nil && !errors.Is(err, admin.ErrPermissionDenied) {
+		log.Error("failed to check admin", logger.Err(err))
+	}
+	return err
+}
+
+func isExpected(err error) bool {
+	var ferr *serviceerr.FieldError
+	return errors.As(err, &ferr) ||
+		errors.Is(err, repository.ErrServiceAccountNotFound) ||
+		errors.Is(err, repository.ErrServiceAccountExists) ||
+		errors.Is(err, repository.ErrKeyNotFound) ||
+		errors.Is(err, repository.ErrRoleNotFound) ||
+		errors.Is(err, repository.ErrAppNotFound)
+}
+
+func (s ServiceAccountService) revoke(ctx context.Context, log *slog.Logger, r models.Revocation) {
+	if r.RevokedAt.IsZero() {
+		r.RevokedAt = time.Now()
+	}
+	if err := s.revocations.Publish(ctx, r); err != nil {
+		log.Error("failed to publish revocation", slog.String("kind", r.Kind), logger.Err(err))
+	}
+}
@@ -0,0 +1,15 @@
+// Package serviceerr holds the errors that several services return.
+package serviceerr
+
+import "fmt"
+
+// FieldError reports a request field that failed validation. Transports turn it into a bad
+// request naming the field.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
@@ -0,0 +1,188 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+)
+
+const maxErrorLength = 500
+
+// LogoutRepository hands out the queued logout tokens and records how sending them went.
+type LogoutRepository interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.Logout, error)
+	MarkDelivered(ctx context.Context, logoutID int64, statusCode int) error
+	MarkFailed(ctx context.Context, logoutID int64, statusCode int, lastErr string, retryAt time.Time) error
+}
+
+// LogoutPolicy controls how logout tokens are signed and how persistently they are sent.
+type LogoutPolicy struct {
+	// Issuer is the iss of logout tokens.
+	Issuer string
+	// TokenTTL is how long a logout token is valid after it was sent.
+	TokenTTL  time.Duration
+	Interval  time.Duration
+	BatchSize int
+	// MaxAttempts is how often a logout is tried before it is dead-lettered.
+	MaxAttempts int
+	// RetryBase is the wait after the first failure. It doubles with every further one up to RetryMax.
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// Timeout bounds a single request to an app.
+	Timeout time.Duration
+}
+
+// LogoutDispatcher posts queued logout tokens to the back-channel logout URIs of apps. Like
+// webhook dispatchers, several can run against the same database.
+type LogoutDispatcher struct {
+	log     *slog.Logger
+	appRepo AppRepository
+	repo    LogoutRepository
+	client  *http.Client
+	policy  LogoutPolicy
+}
+
+func NewLogoutDispatcher(log *slog.Logger, appRepo AppRepository, repo LogoutRepository, policy LogoutPolicy) *LogoutDispatcher {
+	return &LogoutDispatcher{
+		log:     log,
+		appRepo: appRepo,
+		repo:    repo,
+		client: &http.Client{
+			Timeout:       policy.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		policy: policy,
+	}
+}
+
+// Run sends logout tokens until ctx is done.
+func (d *LogoutDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		claimed, err := d.DispatchOnce(ctx)
+		if err == nil && claimed == d.policy.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce sends one batch of due logout tokens and returns how many it tried to send.
+func (d *LogoutDispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	const op = "sso.LogoutDispatcher.DispatchOnce"
+
+	log := d.log.With(slog.String("op", op))
+
+	due, err := d.repo.ClaimDue(ctx, d.policy.BatchSize, 2*d.policy.Timeout)
+	if err != nil {
+		log.Error("failed to claim logouts", logger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var wg sync.WaitGroup
+	for _, logout := range due {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, log, logout)
+		}()
+	}
+	wg.Wait()
+
+	return len(due), nil
+}
+
+func (d *LogoutDispatcher) deliver(ctx context.Context, log *slog.Logger, logout models.Logout) {
+	log = log.With(slog.Int64("logoutID", logout.ID), slog.Int("appID", logout.AppID))
+
+	statusCode, retry, err := d.send(ctx, logout)
+	if err == nil {
+		if err := d.repo.MarkDelivered(ctx, logout.ID, statusCode); err != nil {
+			log.Error("failed to mark logout delivered", logger.Err(err))
+		}
+		return
+	}
+
+	attempts := logout.Attempts + 1
+	var retryAt time.Time
+	if retry && attempts < d.policy.MaxAttempts {
+		retryAt = time.Now().Add(backoff(attempts, d.policy.RetryBase, d.policy.RetryMax))
+		log.Info("back-channel logout failed", slog.Int("attempts", attempts), logger.Err(err))
+	} else {
+		log.Warn("back-channel logout dead-lettered", slog.Int("attempts", attempts), logger.Err(err))
+	}
+
+	lastErr := err.Error()
+	if len(lastErr) > maxErrorLength {
+		lastErr = lastErr[:maxErrorLength]
+	}
+
+	if err := d.repo.MarkFailed(ctx, logout.ID, statusCode, lastErr, retryAt); err != nil {
+		log.Error("failed to mark logout failed", logger.Err(err))
+	}
+}
+
+// send posts a freshly signed logout token to the app. It returns the status code of the
+// response, or zero if there was none, and whether a failure is worth retrying.
+func (d *LogoutDispatcher) send(ctx context.Context, logout models.Logout) (int, bool, error) {
+	app, err := d.appRepo.Get(ctx, logout.AppID)
+	if err != nil {
+		return 0, !errors.Is(err, repository.ErrAppNotFound), fmt.Errorf("get app: %w", err)
+	}
+	if app.BackchannelLogoutURI == "" {
+		return 0, false, errors.New("app no longer has a back-channel logout URI")
+	}
+
+	token, err := jwt.GenerateLogoutJWT(app.AccessSecret, d.policy.Issuer, logout.UserID, app.ID, logout.SessionID, d.policy.TokenTTL)
+	if err != nil {
+		return 0, true, fmt.Errorf("sign logout token: %w", err)
+	}
+
+	body := url.Values{"logout_token": {token}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, app.BackchannelLogoutURI, strings.NewReader(body))
+	if err != nil {
+		return 0, false, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, true, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, false, nil
+}
+
+// backoff is how long to wait before the next attempt after the given number of failed ones.
+func backoff(failed int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < failed && d < max; i++ {
+		d *= 2
+	}
+	return min(d, max)
+}
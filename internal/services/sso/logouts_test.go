@@ -0,0 +1,159 @@
+package sso
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/pkg/jwt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type logoutState struct {
+	logout     models.Logout
+	status     string
+	statusCode int
+	lastErr    string
+	retryAt    time.Time
+}
+
+type memLogouts struct {
+	mu      sync.Mutex
+	logouts map[int64]*logoutState
+}
+
+func (m *memLogouts) ClaimDue(_ context.Context, limit int, _ time.Duration) ([]models.Logout, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var due []models.Logout
+	for _, l := range m.logouts {
+		if l.status == models.DeliveryPending && !l.retryAt.After(time.Now()) && len(due) < limit {
+			due = append(due, l.logout)
+		}
+	}
+	return due, nil
+}
+
+func (m *memLogouts) MarkDelivered(_ context.Context, id int64, statusCode int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := m.logouts[id]
+	l.status = models.DeliveryDelivered
+	l.statusCode = statusCode
+	l.logout.Attempts++
+	return nil
+}
+
+func (m *memLogouts) MarkFailed(_ context.Context, id int64, statusCode int, lastErr string, retryAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := m.logouts[id]
+	l.statusCode = statusCode
+	l.lastErr = lastErr
+	l.retryAt = retryAt
+	l.logout.Attempts++
+	if retryAt.IsZero() {
+		l.status = models.DeliveryDead
+	}
+	return nil
+}
+
+func newTestLogoutDispatcher(uri string, appID, attempts int) (*LogoutDispatcher, *memLogouts) {
+	st := newStore()
+	st.apps[1] = models.App{ID: 1, AccessSecret: "access-secret", BackchannelLogoutURI: uri}
+
+	repo := &memLogouts{logouts: map[int64]*logoutState{
+		1: {status: models.DeliveryPending, logout: models.Logout{ID: 1, AppID: appID, UserID: 7, SessionID: "s-a", Attempts: attempts}},
+	}}
+
+	d := NewLogoutDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), appRepo{st}, repo, LogoutPolicy{
+		Issuer:      "https://sso.example.com",
+		TokenTTL:    2 * time.Minute,
+		Interval:    time.Second,
+		BatchSize:   10,
+		MaxAttempts: 3,
+		RetryBase:   time.Minute,
+		RetryMax:    time.Hour,
+		Timeout:     5 * time.Second,
+	})
+	return d, repo
+}
+
+func TestLogoutDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("posts a signed logout token", func(t *testing.T) {
+		var claims *jwt.LogoutClaims
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			claims, err = jwt.ParseLogoutJWT("access-secret", r.PostFormValue("logout_token"), "https://sso.example.com", 1)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+
+		d, repo := newTestLogoutDispatcher(receiver.URL, 1, 0)
+
+		n, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		assert.Equal(t, models.DeliveryDelivered, repo.logouts[1].status)
+		require.NotNil(t, claims)
+		assert.Equal(t, "s-a", claims.SessionID)
+		assert.Equal(t, "7", claims.Subject)
+	})
+
+	t.Run("retries with backoff", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer receiver.Close()
+
+		d, repo := newTestLogoutDispatcher(receiver.URL, 1, 1)
+
+		_, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+
+		state := repo.logouts[1]
+		assert.Equal(t, models.DeliveryPending, state.status)
+		assert.Equal(t, http.StatusServiceUnavailable, state.statusCode)
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), state.retryAt, 5*time.Second)
+	})
+
+	t.Run("dead-letters after max attempts", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer receiver.Close()
+
+		d, repo := newTestLogoutDispatcher(receiver.URL, 1, 2)
+
+		_, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, models.DeliveryDead, repo.logouts[1].status)
+	})
+
+	t.Run("gives up on deleted apps", func(t *testing.T) {
+		d, repo := newTestLogoutDispatcher("https://one.example.com/logout", 99, 0)
+
+		_, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, models.DeliveryDead, repo.logouts[1].status)
+		assert.Equal(t, 1, repo.logouts[1].logout.Attempts)
+	})
+}
@@ -0,0 +1,506 @@
+// Package sso keeps users signed in to the SSO in their browser, so they sign in once for all
+// apps.
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/domain/sessions"
+	"auth/internal/repository"
+	"auth/internal/services/audit"
+	"auth/internal/services/auth"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+)
+
+const (
+	ActionSignIn  = "sso.sign_in"
+	ActionSignOut = "sso.sign_out"
+)
+
+const (
+	sessionIDBytes = 32
+	requestIDBytes = 32
+	codeBytes      = 32
+)
+
+var (
+	ErrInvalidRedirectURI = errors.New("redirect_uri is not registered for the app")
+	// ErrInvalidRequest is returned for authorize requests that expired, were answered or never
+	// existed.
+	ErrInvalidRequest = errors.New("sso request is invalid or expired")
+	// ErrLoginRequired is returned when the sign-in meant to answer an authorize request
+	// happened before the request.
+	ErrLoginRequired = errors.New("sign-in is older than the request")
+	ErrInvalidCode   = errors.New("authorization code is invalid or expired")
+)
+
+type AppRepository interface {
+	Get(ctx context.Context, appID int) (models.App, error)
+}
+
+type SessionStorage interface {
+	Save(ctx context.Context, sessionID string, session sessions.SSOSession) error
+	Get(ctx context.Context, sessionID string) (sessions.SSOSession, error)
+	Delete(ctx context.Context, sessionID string) error
+}
+
+type StateStorage interface {
+	SaveSSORequest(ctx context.Context, requestID string, req models.SSOAuthorizeRequest, ttl time.Duration) error
+	TakeSSORequest(ctx context.Context, requestID string) (models.SSOAuthorizeRequest, error)
+	SaveSSOCode(ctx context.Context, code string, grant models.SSOCode, ttl time.Duration) error
+	TakeSSOCode(ctx context.Context, code string) (models.SSOCode, error)
+}
+
+type RefreshStorage interface {
+	Get(ctx context.Context, token string) (*sessions.RefreshSession, error)
+	Save(ctx context.Context, token string, session sessions.RefreshSession) error
+	ListForUser(ctx context.Context, userID int64) (map[string]sessions.RefreshSession, error)
+}
+
+// SessionIssuer starts the app sessions of SSO sessions and ends them on sign-out.
+type SessionIssuer interface {
+	LoginSSO(ctx context.Context, ssoSessionID string, ssoSession sessions.SSOSession, appID int, orgID int64, ip, userAgent string) (accessToken, refreshToken, idToken string, err error)
+	EndSession(ctx context.Context, userID int64, sessionID string) error
+}
+
+// LogoutQueue holds the logout tokens to send to the back-channel logout URIs of apps.
+type LogoutQueue interface {
+	Enqueue(ctx context.Context, logouts []models.Logout) error
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+type Policy struct {
+	// LoginURL is the page users sign in on when they have no SSO session. "{request}" in it is
+	// replaced with the ID CompleteLogin takes.
+	LoginURL string
+	// SessionTTL is how long an SSO session lasts after the user last signed in.
+	SessionTTL time.Duration
+	// RequestTTL is how long a user has to sign in.
+	RequestTTL time.Duration
+	// CodeTTL is how long an app has to redeem an authorization code.
+	CodeTTL time.Duration
+	// Issuer identifies the SSO to apps in front-channel logout requests.
+	Issuer string
+}
+
+type SSOService struct {
+	log      *slog.Logger
+	appRepo  AppRepository
+	store    SessionStorage
+	states   StateStorage
+	refresh  RefreshStorage
+	sessions SessionIssuer
+	logouts  LogoutQueue
+	audit    AuditRepository
+	policy   Policy
+}
+
+func New(log *slog.Logger, appRepo AppRepository, store SessionStorage, states StateStorage, refresh RefreshStorage, sessions SessionIssuer, logouts LogoutQueue, audit AuditRepository, policy Policy) *SSOService {
+	return &SSOService{
+		log:      log,
+		appRepo:  appRepo,
+		store:    store,
+		states:   states,
+		refresh:  refresh,
+		sessions: sessions,
+		logouts:  logouts,
+		audit:    audit,
+		policy:   policy,
+	}
+}
+
+// Authorize answers an app's request to sign in the user of the browser with the SSO session
+// sessionID, and returns where to send the browser. With a session that satisfies prompt and
+// maxAge it is the app's redirect URI with a code for ExchangeCode; otherwise it is the login
+// page, or with prompt "none" the redirect URI with a login_required error. A negative maxAge
+// accepts sign-ins of any age.
+//
+// Requests with a redirect URI that isn't registered for the app fail instead, as the browser
+// can't safely be sent there.
+func (s SSOService) Authorize(ctx context.Context, sessionID string, req models.SSOAuthorizeRequest, prompt string, maxAge time.Duration) (string, error) {
+	const op = "SSOService.Authorize"
+
+	log := s.log.With(slog.String("op", op), slog.Int("appID", req.AppID))
+
+	if err := s.checkClient(ctx, log, req.AppID, req.RedirectURI); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if prompt != "" && prompt != models.PromptNone && prompt != models.PromptLogin {
+		return errorRedirect(req, "invalid_request", "unsupported prompt"), nil
+	}
+
+	if prompt != models.PromptLogin {
+		session, ok, err := s.session(ctx, log, sessionID)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		if ok && (maxAge < 0 || time.Since(session.AuthTime) <= maxAge) {
+			location, err := s.issueCode(ctx, log, sessionID, session.UserID, req)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", op, err)
+			}
+			log.Info("signed in with sso session", slog.Int64("userID", session.UserID))
+			return location, nil
+		}
+	}
+
+	if prompt == models.PromptNone {
+		return errorRedirect(req, "login_required", ""), nil
+	}
+
+	requestID := jwt.GenerateRandomToken(requestIDBytes)
+	req.CreatedAt = time.Now().UTC()
+	if err := s.states.SaveSSORequest(ctx, requestID, req, s.policy.RequestTTL); err != nil {
+		log.Error("failed to save authorize request", logger.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return strings.ReplaceAll(s.policy.LoginURL, "{request}", url.QueryEscape(requestID)), nil
+}
+
+// CompleteLogin answers the authorize request Authorize sent the browser to the login page with.
+// The login page signs the user in with any of the login methods and hands over the refresh
+// token it got; the sign-in becomes the browser's SSO session and the refresh token's session
+// joins it. sessionID is the browser's current SSO session, which is reused if it belongs to the
+// same user.
+//
+// It returns the SSO session for the browser's cookie and where to send the browser next.
+func (s SSOService) CompleteLogin(ctx context.Context, requestID, refreshToken, sessionID string) (newSessionID string, expiresAt time.Time, location string, err error) {
+	const op = "SSOService.CompleteLogin"
+
+	log := s.log.With(slog.String("op", op))
+
+	req, err := s.states.TakeSSORequest(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStateNotFound) {
+			return "", time.Time{}, "", fmt.Errorf("%s: %w", op, ErrInvalidRequest)
+		}
+		log.Error("failed to get authorize request", logger.Err(err))
+		return "", time.Time{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	appSession, err := s.refresh.Get(ctx, refreshToken)
+	if err != nil {
+		log.Error("failed to get refresh token", logger.Err(err))
+		return "", time.Time{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("userID", appSession.UserID), slog.Int("appID", req.AppID))
+
+	if appSession.AuthTime.Before(req.CreatedAt) {
+		log.Info("sign-in predates the authorize request")
+		return "", time.Time{}, "", fmt.Errorf("%s: %w", op, ErrLoginRequired)
+	}
+
+	session, ok, err := s.session(ctx, log, sessionID)
+	if err != nil {
+		return "", time.Time{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	now := time.Now().UTC()
+	reused := ok && session.UserID == appSession.UserID
+	if reused {
+		// Methods verified earlier in the browser session still count, like with step-up.
+		for _, method := range appSession.AMR {
+			if !slices.Contains(session.AMR, method) {
+				session.AMR = append(session.AMR, method)
+			}
+		}
+	} else {
+		sessionID = jwt.GenerateRandomToken(sessionIDBytes)
+		session = sessions.SSOSession{UserID: appSession.UserID, AMR: appSession.AMR, CreatedAt: now}
+	}
+	session.AuthTime = appSession.AuthTime
+	session.ExpiresAt = now.Add(s.policy.SessionTTL)
+
+	if err := s.store.Save(ctx, sessionID, session); err != nil {
+		log.Error("failed to save sso session", logger.Err(err))
+		return "", time.Time{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	appSession.SSOSessionID = sessionID
+	if err := s.refresh.Save(ctx, refreshToken, *appSession); err != nil {
+		log.Error("failed to link refresh session", logger.Err(err))
+		return "", time.Time{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	location, err = s.issueCode(ctx, log, sessionID, session.UserID, req)
+	if err != nil {
+		return "", time.Time{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID:      session.UserID,
+		Action:       ActionSignIn,
+		TargetUserID: session.UserID,
+		AppID:        appSession.AppID,
+		Details:      map[string]any{"amr": session.AMR, "reused": reused},
+	})
+
+	log.Info("sso session started")
+
+	return sessionID, session.ExpiresAt, location, nil
+}
+
+// ExchangeCode redeems an authorization code Authorize or CompleteLogin sent to the app's
+// redirect URI for the tokens of a new app session. The code only works once, for the app and
+// redirect URI it was issued to, and only while the SSO session lasts. The ID token tells the
+// app who signed in, and when and how.
+func (s SSOService) ExchangeCode(ctx context.Context, code string, appID int, redirectURI, ip, userAgent string) (accessToken, refreshToken, idToken string, err error) {
+	const op = "SSOService.ExchangeCode"
+
+	log := s.log.With(slog.String("op", op), slog.Int("appID", appID))
+
+	grant, err := s.states.TakeSSOCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, repository.ErrStateNotFound) {
+			return "", "", "", fmt.Errorf("%s: %w", op, ErrInvalidCode)
+		}
+		log.Error("failed to get authorization code", logger.Err(err))
+		return "", "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if grant.AppID != appID || grant.RedirectURI != redirectURI {
+		log.Warn("authorization code redeemed by another client", slog.Int("codeAppID", grant.AppID))
+		return "", "", "", fmt.Errorf("%s: %w", op, ErrInvalidCode)
+	}
+
+	session, ok, err := s.session(ctx, log, grant.SessionID)
+	if err != nil {
+		return "", "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok || session.UserID != grant.UserID {
+		log.Info("sso session ended before the code was redeemed")
+		return "", "", "", fmt.Errorf("%s: %w", op, ErrInvalidCode)
+	}
+
+	accessToken, refreshToken, idToken, err = s.sessions.LoginSSO(ctx, grant.SessionID, session, grant.AppID, grant.OrgID, ip, userAgent)
+	if err != nil {
+		return "", "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return accessToken, refreshToken, idToken, nil
+}
+
+// Logout ends the SSO session and every app session started from or joined to it. Apps with a
+// back-channel logout URI are sent a logout token for each of their sessions in the background.
+// The browser has to load the front-channel logout URIs returned for the other apps, which
+// carry the session as sid.
+//
+// With a redirect URI registered for the app it returns where to send the browser afterwards;
+// otherwise it returns an empty location. Signing out without a session is not an error.
+func (s SSOService) Logout(ctx context.Context, sessionID string, appID int, redirectURI, state string) (location string, frontchannel []string, err error) {
+	const op = "SSOService.Logout"
+
+	log := s.log.With(slog.String("op", op))
+
+	if redirectURI != "" {
+		if err := s.checkRedirectURI(ctx, log, appID, redirectURI); err != nil {
+			return "", nil, fmt.Errorf("%s: %w", op, err)
+		}
+		location = withQuery(redirectURI, url.Values{"state": {state}})
+	}
+
+	session, ok, err := s.session(ctx, log, sessionID)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		return location, nil, nil
+	}
+
+	log = log.With(slog.Int64("userID", session.UserID))
+
+	// Gone first, so no more codes are issued or redeemed for it while its apps are signed out.
+	if err := s.store.Delete(ctx, sessionID); err != nil {
+		log.Error("failed to delete sso session", logger.Err(err))
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ended, err := s.endAppSessions(ctx, log, session.UserID, sessionID)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	backchannel, frontchannel, err := s.notifyApps(ctx, log, session.UserID, ended)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID:      session.UserID,
+		Action:       ActionSignOut,
+		TargetUserID: session.UserID,
+		Details:      map[string]any{"app_sessions": len(ended), "backchannel": backchannel, "frontchannel": len(frontchannel)},
+	})
+
+	log.Info("signed out of sso session", slog.Int("appSessions", len(ended)), slog.Int("backchannel", backchannel))
+
+	return location, frontchannel, nil
+}
+
+// endAppSessions ends the user's app sessions linked to the SSO session and returns them.
+func (s SSOService) endAppSessions(ctx context.Context, log *slog.Logger, userID int64, sessionID string) ([]sessions.RefreshSession, error) {
+	all, err := s.refresh.ListForUser(ctx, userID)
+	if err != nil {
+		log.Error("failed to list user sessions", logger.Err(err))
+		return nil, err
+	}
+
+	var ended []sessions.RefreshSession
+	for _, appSession := range all {
+		if appSession.SSOSessionID != sessionID {
+			continue
+		}
+		if err := s.sessions.EndSession(ctx, userID, appSession.ID); err != nil {
+			return ended, err
+		}
+		ended = append(ended, appSession)
+	}
+
+	return ended, nil
+}
+
+// notifyApps queues logout tokens for the ended sessions of apps with a back-channel logout URI
+// and returns how many, together with the front-channel logout URIs for the sessions of apps
+// with only that. Apps with neither find out when their tokens stop refreshing.
+func (s SSOService) notifyApps(ctx context.Context, log *slog.Logger, userID int64, ended []sessions.RefreshSession) (int, []string, error) {
+	apps := make(map[int]models.App)
+	var (
+		logouts      []models.Logout
+		frontchannel []string
+	)
+	for _, appSession := range ended {
+		app, ok := apps[appSession.AppID]
+		if !ok {
+			var err error
+			app, err = s.app(ctx, log, appSession.AppID)
+			if err != nil && !errors.Is(err, repository.ErrAppNotFound) {
+				return 0, nil, err
+			}
+			apps[appSession.AppID] = app
+		}
+
+		switch {
+		case app.BackchannelLogoutURI != "":
+			logouts = append(logouts, models.Logout{AppID: app.ID, UserID: userID, SessionID: appSession.ID})
+		case app.FrontchannelLogoutURI != "":
+			frontchannel = append(frontchannel, withQuery(app.FrontchannelLogoutURI, url.Values{
+				"iss": {s.policy.Issuer},
+				"sid": {appSession.ID},
+			}))
+		}
+	}
+
+	if err := s.logouts.Enqueue(ctx, logouts); err != nil {
+		log.Error("failed to queue logout tokens", logger.Err(err))
+		return 0, nil, err
+	}
+
+	return len(logouts), frontchannel, nil
+}
+
+// session returns the SSO session with the ID and whether there is one.
+func (s SSOService) session(ctx context.Context, log *slog.Logger, sessionID string) (sessions.SSOSession, bool, error) {
+	if sessionID == "" {
+		return sessions.SSOSession{}, false, nil
+	}
+
+	session, err := s.store.Get(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSSOSessionNotFound) {
+			return sessions.SSOSession{}, false, nil
+		}
+		log.Error("failed to get sso session", logger.Err(err))
+		return sessions.SSOSession{}, false, err
+	}
+
+	return session, true, nil
+}
+
+func (s SSOService) issueCode(ctx context.Context, log *slog.Logger, sessionID string, userID int64, req models.SSOAuthorizeRequest) (string, error) {
+	code := jwt.GenerateRandomToken(codeBytes)
+	grant := models.SSOCode{SessionID: sessionID, UserID: userID, AppID: req.AppID, OrgID: req.OrgID, RedirectURI: req.RedirectURI}
+	if err := s.states.SaveSSOCode(ctx, code, grant, s.policy.CodeTTL); err != nil {
+		log.Error("failed to save authorization code", logger.Err(err))
+		return "", err
+	}
+
+	return withQuery(req.RedirectURI, url.Values{"code": {code}, "state": {req.State}}), nil
+}
+
+// checkClient makes sure the app may sign users in with SSO sessions and redirect to the URI.
+func (s SSOService) checkClient(ctx context.Context, log *slog.Logger, appID int, redirectURI string) error {
+	app, err := s.app(ctx, log, appID)
+	if err != nil {
+		return err
+	}
+	if !app.Enabled {
+		return auth.ErrAppDisabled
+	}
+	if !app.AllowsGrant(models.GrantSSO) {
+		return auth.ErrGrantNotAllowed
+	}
+	if !slices.Contains(app.RedirectURIs, redirectURI) {
+		return ErrInvalidRedirectURI
+	}
+	return nil
+}
+
+// checkRedirectURI is checkClient for signing out, which disabled apps may still send users to.
+func (s SSOService) checkRedirectURI(ctx context.Context, log *slog.Logger, appID int, redirectURI string) error {
+	app, err := s.app(ctx, log, appID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(app.RedirectURIs, redirectURI) {
+		return ErrInvalidRedirectURI
+	}
+	return nil
+}
+
+func (s SSOService) app(ctx context.Context, log *slog.Logger, appID int) (models.App, error) {
+	app, err := s.appRepo.Get(ctx, appID)
+	if err != nil && !errors.Is(err, repository.ErrAppNotFound) {
+		log.Error("failed to get app", logger.Err(err))
+	}
+	return app, err
+}
+
+func errorRedirect(req models.SSOAuthorizeRequest, code, description string) string {
+	values := url.Values{"error": {code}, "state": {req.State}}
+	if description != "" {
+		values.Set("error_description", description)
+	}
+	return withQuery(req.RedirectURI, values)
+}
+
+// withQuery adds the non-empty values to the query of the registered URI, keeping the
+// parameters it has.
+func withQuery(rawURI string, values url.Values) string {
+	u, err := url.Parse(rawURI)
+	if err != nil {
+		return rawURI
+	}
+
+	query := u.Query()
+	for key, vs := range values {
+		if len(vs) > 0 && vs[0] != "" {
+			query.Set(key, vs[0])
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
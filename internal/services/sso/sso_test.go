@@ -0,0 +1,550 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/url"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/domain/sessions"
+	"auth/internal/repository"
+	"auth/internal/services/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store keeps apps, SSO sessions, requests, codes and refresh sessions in memory and records
+// the app sessions started and ended and the logouts queued.
+type store struct {
+	apps     map[int]models.App
+	sessions map[string]sessions.SSOSession
+	requests map[string]models.SSOAuthorizeRequest
+	codes    map[string]models.SSOCode
+	refresh  map[string]sessions.RefreshSession
+	logins   []string
+	ended    []string
+	logouts  []models.Logout
+	recorded []string
+}
+
+func newStore() *store {
+	return &store{
+		apps: map[int]models.App{
+			1: {ID: 1, Enabled: true, GrantTypes: []string{models.GrantPassword, models.GrantSSO}, RedirectURIs: []string{"https://one.example.com/cb"}},
+			2: {ID: 2, Enabled: true, GrantTypes: []string{models.GrantSSO}, RedirectURIs: []string{"https://two.example.com/cb?tenant=a"}},
+			3: {ID: 3, Enabled: true, GrantTypes: []string{models.GrantPassword}, RedirectURIs: []string{"https://three.example.com/cb"}},
+		},
+		sessions: make(map[string]sessions.SSOSession),
+		requests: make(map[string]models.SSOAuthorizeRequest),
+		codes:    make(map[string]models.SSOCode),
+		refresh:  make(map[string]sessions.RefreshSession),
+	}
+}
+
+func (s *store) Save(_ context.Context, sessionID string, session sessions.SSOSession) error {
+	s.sessions[sessionID] = session
+	return nil
+}
+
+func (s *store) Get(_ context.Context, sessionID string) (sessions.SSOSession, error) {
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return sessions.SSOSession{}, repository.ErrSSOSessionNotFound
+	}
+	return session, nil
+}
+
+func (s *store) Delete(_ context.Context, sessionID string) error {
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+func (s *store) DeleteAllForUser(_ context.Context, userID int64) error {
+	for id, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (s *store) SaveSSORequest(_ context.Context, requestID string, req models.SSOAuthorizeRequest, _ time.Duration) error {
+	s.requests[requestID] = req
+	return nil
+}
+
+func (s *store) TakeSSORequest(_ context.Context, requestID string) (models.SSOAuthorizeRequest, error) {
+	req, ok := s.requests[requestID]
+	if !ok {
+		return models.SSOAuthorizeRequest{}, repository.ErrStateNotFound
+	}
+	delete(s.requests, requestID)
+	return req, nil
+}
+
+func (s *store) SaveSSOCode(_ context.Context, code string, grant models.SSOCode, _ time.Duration) error {
+	s.codes[code] = grant
+	return nil
+}
+
+func (s *store) TakeSSOCode(_ context.Context, code string) (models.SSOCode, error) {
+	grant, ok := s.codes[code]
+	if !ok {
+		return models.SSOCode{}, repository.ErrStateNotFound
+	}
+	delete(s.codes, code)
+	return grant, nil
+}
+
+func (s *store) LoginSSO(_ context.Context, ssoSessionID string, _ sessions.SSOSession, _ int, _ int64, _, _ string) (string, string, string, error) {
+	s.logins = append(s.logins, ssoSessionID)
+	return "access", "refresh", "id", nil
+}
+
+func (s *store) EndSession(_ context.Context, _ int64, sessionID string) error {
+	s.ended = append(s.ended, sessionID)
+	for token, session := range s.refresh {
+		if session.ID == sessionID {
+			delete(s.refresh, token)
+		}
+	}
+	return nil
+}
+
+func (s *store) Enqueue(_ context.Context, logouts []models.Logout) error {
+	s.logouts = append(s.logouts, logouts...)
+	return nil
+}
+
+func (s *store) Record(_ context.Context, entry models.AuditEntry) error {
+	s.recorded = append(s.recorded, entry.Action)
+	return nil
+}
+
+// appRepo serves the store's apps; the store's own Get looks up SSO sessions.
+type appRepo struct{ *store }
+
+func (r appRepo) Get(_ context.Context, appID int) (models.App, error) {
+	app, ok := r.apps[appID]
+	if !ok {
+		return models.App{}, repository.ErrAppNotFound
+	}
+	return app, nil
+}
+
+// refreshRepo serves the store's refresh sessions.
+type refreshRepo struct{ *store }
+
+func (r refreshRepo) Get(_ context.Context, token string) (*sessions.RefreshSession, error) {
+	session, ok := r.refresh[token]
+	if !ok {
+		return nil, errors.New("refresh token not found")
+	}
+	return &session, nil
+}
+
+func (r refreshRepo) Save(_ context.Context, token string, session sessions.RefreshSession) error {
+	r.refresh[token] = session
+	return nil
+}
+
+func (r refreshRepo) Delete(_ context.Context, token string) error {
+	delete(r.refresh, token)
+	return nil
+}
+
+func (r refreshRepo) ListForUser(_ context.Context, userID int64) (map[string]sessions.RefreshSession, error) {
+	res := make(map[string]sessions.RefreshSession)
+	for token, session := range r.refresh {
+		if session.UserID == userID {
+			res[token] = session
+		}
+	}
+	return res, nil
+}
+
+func (s *store) UserAuthorization(context.Context, int64, int) (models.Authorization, error) {
+	return models.Authorization{}, nil
+}
+
+func (s *store) Publish(context.Context, models.Revocation) error {
+	return nil
+}
+
+// users knows every user ID; AuthService needs no more than that to start and refresh sessions.
+type users struct{ auth.UserRepository }
+
+func (users) GetByID(_ context.Context, userID int64) (models.User, error) {
+	return models.User{ID: userID, Email: "user@example.com"}, nil
+}
+
+func newService() (*SSOService, *store) {
+	st := newStore()
+	return newServiceWith(st, st), st
+}
+
+// newAuthService returns an SSO service whose app sessions are started and refreshed by a real
+// AuthService over the store.
+func newAuthService() (*SSOService, *auth.AuthService, *store) {
+	st := newStore()
+	for id, app := range st.apps {
+		app.AccessSecret = "access-secret"
+		app.GrantTypes = append(app.GrantTypes, models.GrantRefreshToken)
+		st.apps[id] = app
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	issuer := auth.New(log, users{}, appRepo{st}, st, nil, refreshRepo{st}, st, st, nil,
+		auth.SessionPolicy{AccessTTL: time.Minute, RefreshTTL: time.Hour}, auth.InvitationPolicy{}, st)
+	return newServiceWith(st, issuer), issuer, st
+}
+
+func newServiceWith(st *store, issuer SessionIssuer) *SSOService {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(log, appRepo{st}, st, st, refreshRepo{st}, issuer, st, st, Policy{
+		LoginURL:   "https://sso.example.com/login?request={request}",
+		SessionTTL: 24 * time.Hour,
+		RequestTTL: 10 * time.Minute,
+		CodeTTL:    time.Minute,
+		Issuer:     "https://sso.example.com",
+	})
+}
+
+func parse(t *testing.T, location string) (*url.URL, url.Values) {
+	t.Helper()
+	u, err := url.Parse(location)
+	require.NoError(t, err)
+	return u, u.Query()
+}
+
+func TestAuthorize(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService()
+
+	st.sessions["fresh"] = sessions.SSOSession{UserID: 7, AuthTime: time.Now().Add(-time.Minute), AMR: []string{"pwd"}}
+	st.sessions["old"] = sessions.SSOSession{UserID: 7, AuthTime: time.Now().Add(-2 * time.Hour)}
+
+	req := models.SSOAuthorizeRequest{AppID: 1, OrgID: 5, RedirectURI: "https://one.example.com/cb", State: "xyz"}
+
+	t.Run("client errors are not redirected", func(t *testing.T) {
+		bad := req
+		bad.RedirectURI = "https://evil.example.com/cb"
+		_, err := s.Authorize(ctx, "fresh", bad, "", -1)
+		assert.ErrorIs(t, err, ErrInvalidRedirectURI)
+
+		_, err = s.Authorize(ctx, "fresh", models.SSOAuthorizeRequest{AppID: 3, RedirectURI: "https://three.example.com/cb"}, "", -1)
+		assert.ErrorIs(t, err, auth.ErrGrantNotAllowed)
+
+		_, err = s.Authorize(ctx, "fresh", models.SSOAuthorizeRequest{AppID: 9, RedirectURI: "https://one.example.com/cb"}, "", -1)
+		assert.ErrorIs(t, err, repository.ErrAppNotFound)
+	})
+
+	t.Run("valid session gets a code", func(t *testing.T) {
+		location, err := s.Authorize(ctx, "fresh", req, "", -1)
+		require.NoError(t, err)
+
+		u, query := parse(t, location)
+		assert.Equal(t, "one.example.com", u.Host)
+		assert.Equal(t, "xyz", query.Get("state"))
+		require.Contains(t, st.codes, query.Get("code"))
+		assert.Equal(t, models.SSOCode{SessionID: "fresh", UserID: 7, AppID: 1, OrgID: 5, RedirectURI: req.RedirectURI}, st.codes[query.Get("code")])
+	})
+
+	t.Run("registered query parameters are kept", func(t *testing.T) {
+		location, err := s.Authorize(ctx, "fresh", models.SSOAuthorizeRequest{AppID: 2, RedirectURI: "https://two.example.com/cb?tenant=a"}, models.PromptNone, -1)
+		require.NoError(t, err)
+
+		_, query := parse(t, location)
+		assert.Equal(t, "a", query.Get("tenant"))
+		assert.NotEmpty(t, query.Get("code"))
+		assert.False(t, query.Has("state"))
+	})
+
+	t.Run("without a session the user signs in", func(t *testing.T) {
+		location, err := s.Authorize(ctx, "", req, "", -1)
+		require.NoError(t, err)
+
+		u, query := parse(t, location)
+		assert.Equal(t, "sso.example.com", u.Host)
+		saved, ok := st.requests[query.Get("request")]
+		require.True(t, ok)
+		assert.Equal(t, req.RedirectURI, saved.RedirectURI)
+		assert.Equal(t, "xyz", saved.State)
+		assert.WithinDuration(t, time.Now(), saved.CreatedAt, time.Second)
+
+		location, err = s.Authorize(ctx, "ended", req, "", -1)
+		require.NoError(t, err)
+		assert.Contains(t, location, "https://sso.example.com/login?request=")
+	})
+
+	t.Run("prompt none fails instead", func(t *testing.T) {
+		location, err := s.Authorize(ctx, "", req, models.PromptNone, -1)
+		require.NoError(t, err)
+
+		_, query := parse(t, location)
+		assert.Equal(t, "login_required", query.Get("error"))
+		assert.Equal(t, "xyz", query.Get("state"))
+		assert.False(t, query.Has("code"))
+	})
+
+	t.Run("prompt login ignores the session", func(t *testing.T) {
+		location, err := s.Authorize(ctx, "fresh", req, models.PromptLogin, -1)
+		require.NoError(t, err)
+		assert.Contains(t, location, "https://sso.example.com/login?request=")
+	})
+
+	t.Run("max age", func(t *testing.T) {
+		location, err := s.Authorize(ctx, "old", req, "", time.Hour)
+		require.NoError(t, err)
+		assert.Contains(t, location, "https://sso.example.com/login?request=", "sign-in is too old")
+
+		location, err = s.Authorize(ctx, "old", req, models.PromptNone, time.Hour)
+		require.NoError(t, err)
+		_, query := parse(t, location)
+		assert.Equal(t, "login_required", query.Get("error"))
+
+		location, err = s.Authorize(ctx, "fresh", req, "", 0)
+		require.NoError(t, err)
+		assert.Contains(t, location, "https://sso.example.com/login?request=", "a max age of zero always signs in")
+
+		location, err = s.Authorize(ctx, "fresh", req, "", time.Hour)
+		require.NoError(t, err)
+		_, query = parse(t, location)
+		assert.NotEmpty(t, query.Get("code"))
+	})
+
+	t.Run("unknown prompt", func(t *testing.T) {
+		location, err := s.Authorize(ctx, "fresh", req, "consent", -1)
+		require.NoError(t, err)
+
+		_, query := parse(t, location)
+		assert.Equal(t, "invalid_request", query.Get("error"))
+	})
+}
+
+func TestCompleteLogin(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService()
+
+	req := models.SSOAuthorizeRequest{AppID: 2, RedirectURI: "https://two.example.com/cb?tenant=a", State: "xyz"}
+	start := func() string {
+		location, err := s.Authorize(ctx, "", req, "", -1)
+		require.NoError(t, err)
+		_, query := parse(t, location)
+		return query.Get("request")
+	}
+
+	st.refresh["stale"] = sessions.RefreshSession{ID: "s-stale", UserID: 7, AppID: 1, AuthTime: time.Now().Add(-time.Hour)}
+
+	t.Run("sign-in must follow the request", func(t *testing.T) {
+		requestID := start()
+		_, _, _, err := s.CompleteLogin(ctx, requestID, "stale", "")
+		assert.ErrorIs(t, err, ErrLoginRequired)
+
+		_, _, _, err = s.CompleteLogin(ctx, requestID, "stale", "")
+		assert.ErrorIs(t, err, ErrInvalidRequest, "requests are answered once")
+	})
+
+	requestID := start()
+	st.refresh["portal"] = sessions.RefreshSession{ID: "s-portal", UserID: 7, AppID: 1, AuthTime: time.Now(), AMR: []string{"pwd"}}
+
+	sessionID, expiresAt, location, err := s.CompleteLogin(ctx, requestID, "portal", "")
+	require.NoError(t, err)
+
+	require.Contains(t, st.sessions, sessionID)
+	assert.Equal(t, int64(7), st.sessions[sessionID].UserID)
+	assert.Equal(t, []string{"pwd"}, st.sessions[sessionID].AMR)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), expiresAt, time.Minute)
+	assert.Equal(t, sessionID, st.refresh["portal"].SSOSessionID, "the login page's session joins the sso session")
+
+	_, query := parse(t, location)
+	assert.Equal(t, "xyz", query.Get("state"))
+	assert.Equal(t, sessionID, st.codes[query.Get("code")].SessionID)
+	assert.Contains(t, st.recorded, ActionSignIn)
+
+	t.Run("signing in again keeps the session", func(t *testing.T) {
+		requestID := start()
+		st.refresh["otp"] = sessions.RefreshSession{ID: "s-otp", UserID: 7, AppID: 1, AuthTime: time.Now(), AMR: []string{"sms"}}
+
+		again, _, _, err := s.CompleteLogin(ctx, requestID, "otp", sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, sessionID, again)
+		assert.Equal(t, []string{"pwd", "sms"}, st.sessions[sessionID].AMR)
+	})
+
+	t.Run("another user gets a new session", func(t *testing.T) {
+		requestID := start()
+		st.refresh["bob"] = sessions.RefreshSession{ID: "s-bob", UserID: 8, AppID: 1, AuthTime: time.Now()}
+
+		other, _, _, err := s.CompleteLogin(ctx, requestID, "bob", sessionID)
+		require.NoError(t, err)
+		assert.NotEqual(t, sessionID, other)
+		assert.Equal(t, int64(7), st.sessions[sessionID].UserID)
+	})
+}
+
+func TestExchangeCode(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService()
+
+	st.sessions["sso-1"] = sessions.SSOSession{UserID: 7, AuthTime: time.Now()}
+	req := models.SSOAuthorizeRequest{AppID: 1, RedirectURI: "https://one.example.com/cb"}
+	code := func() string {
+		location, err := s.Authorize(ctx, "sso-1", req, "", -1)
+		require.NoError(t, err)
+		_, query := parse(t, location)
+		return query.Get("code")
+	}
+
+	t.Run("only for the client it was issued to", func(t *testing.T) {
+		c := code()
+		_, _, _, err := s.ExchangeCode(ctx, c, 2, req.RedirectURI, "", "")
+		assert.ErrorIs(t, err, ErrInvalidCode)
+
+		_, _, _, err = s.ExchangeCode(ctx, c, 1, req.RedirectURI, "", "")
+		assert.ErrorIs(t, err, ErrInvalidCode, "a misused code is gone")
+
+		_, _, _, err = s.ExchangeCode(ctx, code(), 1, "https://one.example.com/other", "", "")
+		assert.ErrorIs(t, err, ErrInvalidCode)
+	})
+
+	t.Run("starts an app session of the sso session", func(t *testing.T) {
+		c := code()
+		access, refresh, id, err := s.ExchangeCode(ctx, c, 1, req.RedirectURI, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, "access", access)
+		assert.Equal(t, "refresh", refresh)
+		assert.Equal(t, "id", id)
+		assert.Equal(t, []string{"sso-1"}, st.logins)
+
+		_, _, _, err = s.ExchangeCode(ctx, c, 1, req.RedirectURI, "", "")
+		assert.ErrorIs(t, err, ErrInvalidCode, "codes are single use")
+	})
+
+	t.Run("not after signing out", func(t *testing.T) {
+		c := code()
+		delete(st.sessions, "sso-1")
+
+		_, _, _, err := s.ExchangeCode(ctx, c, 1, req.RedirectURI, "", "")
+		assert.ErrorIs(t, err, ErrInvalidCode)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+	s, st := newService()
+
+	back := st.apps[1]
+	back.BackchannelLogoutURI = "https://one.example.com/logout"
+	back.FrontchannelLogoutURI = "https://one.example.com/front"
+	st.apps[1] = back
+	front := st.apps[2]
+	front.FrontchannelLogoutURI = "https://two.example.com/logout?tenant=a"
+	st.apps[2] = front
+
+	st.sessions["sso-1"] = sessions.SSOSession{UserID: 7, AuthTime: time.Now()}
+	st.refresh["a"] = sessions.RefreshSession{ID: "s-a", UserID: 7, AppID: 1, SSOSessionID: "sso-1"}
+	st.refresh["b"] = sessions.RefreshSession{ID: "s-b", UserID: 7, AppID: 2, SSOSessionID: "sso-1"}
+	st.refresh["neither"] = sessions.RefreshSession{ID: "s-e", UserID: 7, AppID: 3, SSOSessionID: "sso-1"}
+	st.refresh["other-browser"] = sessions.RefreshSession{ID: "s-c", UserID: 7, AppID: 1, SSOSessionID: "sso-2"}
+	st.refresh["direct"] = sessions.RefreshSession{ID: "s-d", UserID: 7, AppID: 1}
+
+	_, _, err := s.Logout(ctx, "sso-1", 1, "https://evil.example.com/", "")
+	assert.ErrorIs(t, err, ErrInvalidRedirectURI)
+	assert.Contains(t, st.sessions, "sso-1")
+
+	location, frontchannel, err := s.Logout(ctx, "sso-1", 1, "https://one.example.com/cb", "bye")
+	require.NoError(t, err)
+	assert.Equal(t, "https://one.example.com/cb?state=bye", location)
+
+	assert.NotContains(t, st.sessions, "sso-1")
+	assert.ElementsMatch(t, []string{"s-a", "s-b", "s-e"}, st.ended)
+	assert.Contains(t, st.refresh, "other-browser")
+	assert.Contains(t, st.refresh, "direct")
+	assert.Contains(t, st.recorded, ActionSignOut)
+
+	// The back channel is preferred, so app 1 isn't signed out in the browser as well.
+	assert.Equal(t, []models.Logout{{AppID: 1, UserID: 7, SessionID: "s-a"}}, st.logouts)
+	require.Len(t, frontchannel, 1)
+	u, query := parse(t, frontchannel[0])
+	assert.Equal(t, "two.example.com", u.Host)
+	assert.Equal(t, "a", query.Get("tenant"))
+	assert.Equal(t, "https://sso.example.com", query.Get("iss"))
+	assert.Equal(t, "s-b", query.Get("sid"))
+
+	location, frontchannel, err = s.Logout(ctx, "sso-1", 0, "", "")
+	require.NoError(t, err)
+	assert.Empty(t, location)
+	assert.Empty(t, frontchannel)
+	assert.Len(t, st.ended, 3, "signing out twice is a no-op")
+	assert.Len(t, st.logouts, 1)
+}
+
+func TestLogoutAfterRefresh(t *testing.T) {
+	ctx := context.Background()
+	s, issuer, st := newAuthService()
+
+	st.sessions["sso-1"] = sessions.SSOSession{UserID: 7, AuthTime: time.Now()}
+	req := models.SSOAuthorizeRequest{AppID: 1, RedirectURI: "https://one.example.com/cb"}
+
+	location, err := s.Authorize(ctx, "sso-1", req, "", -1)
+	require.NoError(t, err)
+	_, query := parse(t, location)
+
+	_, refresh, _, err := s.ExchangeCode(ctx, query.Get("code"), 1, req.RedirectURI, "", "")
+	require.NoError(t, err)
+
+	_, refresh, err = issuer.Refresh(ctx, refresh)
+	require.NoError(t, err)
+	require.Contains(t, st.refresh, refresh)
+	assert.Equal(t, "sso-1", st.refresh[refresh].SSOSessionID, "refreshing keeps the session in the sso session")
+
+	_, _, err = s.Logout(ctx, "sso-1", 0, "", "")
+	require.NoError(t, err)
+
+	assert.NotContains(t, st.refresh, refresh)
+	_, _, err = issuer.Refresh(ctx, refresh)
+	assert.Error(t, err, "the refreshed session ended with the sso session")
+}
+
+func TestLogoutNotifiesRefreshedSessions(t *testing.T) {
+	ctx := context.Background()
+	s, issuer, st := newAuthService()
+
+	back := st.apps[1]
+	back.BackchannelLogoutURI = "https://one.example.com/logout"
+	st.apps[1] = back
+	front := st.apps[2]
+	front.FrontchannelLogoutURI = "https://two.example.com/logout"
+	st.apps[2] = front
+
+	st.sessions["sso-1"] = sessions.SSOSession{UserID: 7, AuthTime: time.Now()}
+	signIn := func(req models.SSOAuthorizeRequest) sessions.RefreshSession {
+		location, err := s.Authorize(ctx, "sso-1", req, "", -1)
+		require.NoError(t, err)
+		_, query := parse(t, location)
+
+		_, refresh, _, err := s.ExchangeCode(ctx, query.Get("code"), req.AppID, req.RedirectURI, "", "")
+		require.NoError(t, err)
+		_, refresh, err = issuer.Refresh(ctx, refresh)
+		require.NoError(t, err)
+		return st.refresh[refresh]
+	}
+
+	one := signIn(models.SSOAuthorizeRequest{AppID: 1, RedirectURI: "https://one.example.com/cb"})
+	two := signIn(models.SSOAuthorizeRequest{AppID: 2, RedirectURI: "https://two.example.com/cb?tenant=a"})
+
+	_, frontchannel, err := s.Logout(ctx, "sso-1", 0, "", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []models.Logout{{AppID: 1, UserID: 7, SessionID: one.ID}}, st.logouts)
+	require.Len(t, frontchannel, 1)
+	_, query := parse(t, frontchannel[0])
+	assert.Equal(t, two.ID, query.Get("sid"))
+	assert.Empty(t, st.refresh)
+}
@@ -0,0 +1,278 @@
+package tokens
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/audit"
+	"auth/internal/services/auth"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+)
+
+const (
+	ActionCreate = "tokens.create"
+	ActionRevoke = "tokens.revoke"
+)
+
+// Prefix starts every personal access token, so leaked tokens are easy to recognise and scan for.
+const Prefix = "pat_"
+
+const (
+	maxNameLength = 100
+	displayLength = len(Prefix) + 8
+	// lastUsedGranularity is how precisely last use is tracked.
+	lastUsedGranularity = time.Minute
+)
+
+type TokenRepository interface {
+	Create(ctx context.Context, token models.PersonalAccessToken) (int64, error)
+	GetByHash(ctx context.Context, hash string) (models.PersonalAccessToken, error)
+	ListForUser(ctx context.Context, userID int64) ([]models.PersonalAccessToken, error)
+	Delete(ctx context.Context, tokenID, userID int64) error
+	TouchLastUsed(ctx context.Context, tokenID int64, at time.Time, granularity time.Duration) error
+}
+
+type UserRepository interface {
+	GetByID(ctx context.Context, userID int64) (models.User, error)
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+type RevocationPublisher interface {
+	Publish(ctx context.Context, r models.Revocation) error
+}
+
+// AccessTokenVerifier verifies the tokens issued at login.
+type AccessTokenVerifier interface {
+	VerifyAccessToken(ctx context.Context, token string) (*jwt.Claims, error)
+}
+
+type TokenService struct {
+	log         *slog.Logger
+	repo        TokenRepository
+	userRepo    UserRepository
+	audit       AuditRepository
+	next        AccessTokenVerifier
+	revocations RevocationPublisher
+}
+
+// New creates the service. Tokens that aren't personal access tokens are verified by next.
+func New(log *slog.Logger, repo TokenRepository, userRepo UserRepository, audit AuditRepository, next AccessTokenVerifier, revocations RevocationPublisher) *TokenService {
+	return &TokenService{log: log, repo: repo, userRepo: userRepo, audit: audit, next: next, revocations: revocations}
+}
+
+// CreateToken creates a personal access token for the actor. The returned secret is the token
+// itself; only its hash is stored, so it can't be shown again. A zero expiresAt never expires.
+func (s TokenService) CreateToken(ctx context.Context, actorID int64, name string, scopes []string, expiresAt time.Time) (models.PersonalAccessToken, string, error) {
+	const op = "TokenService.CreateToken"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID))
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLength {
+		return models.PersonalAccessToken{}, "", fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "name", Reason: fmt.Sprintf("must be 1-%d characters", maxNameLength)})
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return models.PersonalAccessToken{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return models.PersonalAccessToken{}, "", fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "expires_at", Reason: "must be in the future"})
+	}
+
+	secret := Prefix + jwt.GenerateRandomToken(32)
+	token := models.PersonalAccessToken{
+		UserID:        actorID,
+		Name:          name,
+		TokenHash:     hash(secret),
+		DisplayPrefix: secret[:displayLength],
+		Scopes:        scopes,
+		ExpiresAt:     expiresAt,
+	}
+
+	token.ID, err = s.repo.Create(ctx, token)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to create token", logger.Err(err))
+		}
+		return models.PersonalAccessToken{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	token.CreatedAt = time.Now()
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID:      actorID,
+		Action:       ActionCreate,
+		TargetUserID: actorID,
+		Details:      map[string]any{"token_id": token.ID, "name": name, "scopes": scopes},
+	})
+
+	log.Info("personal access token created", slog.Int64("tokenID", token.ID))
+
+	return token, secret, nil
+}
+
+func (s TokenService) ListTokens(ctx context.Context, actorID int64) ([]models.PersonalAccessToken, error) {
+	const op = "TokenService.ListTokens"
+
+	tokens, err := s.repo.ListForUser(ctx, actorID)
+	if err != nil {
+		s.log.Error("failed to list tokens", slog.String("op", op), logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// RevokeToken deletes one of the actor's tokens. Admins may revoke the tokens of other users
+// by passing their userID.
+func (s TokenService) RevokeToken(ctx context.Context, actorID, userID, tokenID int64) error {
+	const op = "TokenService.RevokeToken"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int64("tokenID", tokenID))
+
+	if userID == 0 {
+		userID = actorID
+	}
+	if userID != actorID {
+		if err := admin.RequireAdmin(ctx, s.userRepo, actorID); err != nil {
+			if !errors.Is(err, admin.ErrPermissionDenied) {
+				log.Error("failed to check admin", logger.Err(err))
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := s.repo.Delete(ctx, tokenID, userID); err != nil {
+		if !isExpected(err) {
+			log.Error("failed to revoke token", logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID:      actorID,
+		Action:       ActionRevoke,
+		TargetUserID: userID,
+		Details:      map[string]any{"token_id": tokenID},
+	})
+
+	s.revoke(ctx, log, models.Revocation{Kind: models.RevokedToken, UserID: userID, TokenID: tokenJTI(tokenID)})
+
+	log.Info("personal access token revoked")
+
+	return nil
+}
+
+// VerifyAccessToken accepts personal access tokens as well as the access tokens issued at login.
+// A personal access token yields the claims of its owner, limited to the token's scopes.
+func (s TokenService) VerifyAccessToken(ctx context.Context, token string) (*jwt.Claims, error) {
+	const op = "TokenService.VerifyAccessToken"
+
+	if !IsPersonalAccessToken(token) {
+		return s.next.VerifyAccessToken(ctx, token)
+	}
+
+	log := s.log.With(slog.String("op", op))
+
+	pat, err := s.repo.GetByHash(ctx, hash(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrTokenNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, auth.ErrInvalidToken)
+		}
+		log.Error("failed to get token", logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	if pat.Expired(now) {
+		log.Info("personal access token rejected: expired", slog.Int64("tokenID", pat.ID))
+		return nil, fmt.Errorf("%s: %w", op, auth.ErrInvalidToken)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, pat.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, auth.ErrInvalidToken)
+		}
+		log.Error("failed to get token owner", logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.Disabled {
+		log.Info("personal access token rejected: user disabled", slog.Int64("tokenID", pat.ID))
+		return nil, fmt.Errorf("%s: %w", op, auth.ErrInvalidToken)
+	}
+
+	if err := s.repo.TouchLastUsed(ctx, pat.ID, now, lastUsedGranularity); err != nil {
+		log.Error("failed to record token use", logger.Err(err))
+	}
+
+	claims := jwt.NewClaims(user.ID, user.Email, 0, pat.CreatedAt, pat.ExpiresAt,
+		jwt.WithID(tokenJTI(pat.ID)),
+		jwt.WithScopes(pat.Scopes),
+	)
+
+	return claims, nil
+}
+
+// tokenJTI is the "jti" a personal access token is introspected with.
+func tokenJTI(tokenID int64) string {
+	return Prefix + strconv.FormatInt(tokenID, 10)
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, &serviceerr.FieldError{Field: "scopes", Reason: "at least one scope is required"}
+	}
+
+	res := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !slices.Contains(models.TokenScopes, scope) {
+			return nil, &serviceerr.FieldError{Field: "scopes", Reason: fmt.Sprintf("unknown scope %q", scope)}
+		}
+		if !slices.Contains(res, scope) {
+			res = append(res, scope)
+		}
+	}
+	return res, nil
+}
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func isExpected(err error) bool {
+	var ferr *serviceerr.FieldError
+	return errors.As(err, &ferr) ||
+		errors.Is(err, repository.ErrTokenNotFound) ||
+		errors.Is(err, repository.ErrUserNotFound)
+}
+
+func (s TokenService) revoke(ctx context.Context, log *slog.Logger, r models.Revocation) {
+	if r.RevokedAt.IsZero() {
+		r.RevokedAt = time.Now()
+	}
+	if err := s.revocations.Publish(ctx, r); err != nil {
+		log.Error("failed to publish revocation", slog.String("kind", r.Kind), logger.Err(err))
+	}
+}
@@ -0,0 +1,230 @@
+package tokens
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/auth"
+	"auth/internal/services/serviceerr"
+	"auth/internal/transport/grpc/authn"
+	"auth/pkg/jwt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store keeps users and tokens in memory and records the audit actions written and the
+// revocations published.
+type store struct {
+	users       map[int64]models.User
+	tokens      map[int64]models.PersonalAccessToken
+	recorded    []string
+	revocations []models.Revocation
+	touched     int
+}
+
+func newStore() *store {
+	return &store{
+		users: map[int64]models.User{
+			1: {ID: 1, Email: "ann@example.com"},
+			2: {ID: 2, Email: "bob@example.com"},
+			3: {ID: 3, Email: "root@example.com", IsAdmin: true},
+		},
+		tokens: make(map[int64]models.PersonalAccessToken),
+	}
+}
+
+func (s *store) Create(_ context.Context, token models.PersonalAccessToken) (int64, error) {
+	token.ID = int64(len(s.tokens) + 1)
+	s.tokens[token.ID] = token
+	return token.ID, nil
+}
+
+func (s *store) GetByHash(_ context.Context, hash string) (models.PersonalAccessToken, error) {
+	for _, t := range s.tokens {
+		if t.TokenHash == hash {
+			return t, nil
+		}
+	}
+	return models.PersonalAccessToken{}, repository.ErrTokenNotFound
+}
+
+func (s *store) ListForUser(_ context.Context, userID int64) ([]models.PersonalAccessToken, error) {
+	var res []models.PersonalAccessToken
+	for _, t := range s.tokens {
+		if t.UserID == userID {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (s *store) Delete(_ context.Context, tokenID, userID int64) error {
+	t, ok := s.tokens[tokenID]
+	if !ok || t.UserID != userID {
+		return repository.ErrTokenNotFound
+	}
+	delete(s.tokens, tokenID)
+	return nil
+}
+
+func (s *store) TouchLastUsed(context.Context, int64, time.Time, time.Duration) error {
+	s.touched++
+	return nil
+}
+
+func (s *store) GetByID(_ context.Context, userID int64) (models.User, error) {
+	u, ok := s.users[userID]
+	if !ok {
+		return models.User{}, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (s *store) Record(_ context.Context, entry models.AuditEntry) error {
+	s.recorded = append(s.recorded, entry.Action)
+	return nil
+}
+
+func (s *store) Publish(_ context.Context, r models.Revocation) error {
+	s.revocations = append(s.revocations, r)
+	return nil
+}
+
+// loginTokens stands in for the verifier of the tokens issued at login.
+type loginTokens struct{}
+
+func (loginTokens) VerifyAccessToken(_ context.Context, token string) (*jwt.Claims, error) {
+	if token != "login-token" {
+		return nil, auth.ErrInvalidToken
+	}
+	return jwt.NewClaims(1, "ann@example.com", 1, time.Now(), time.Now().Add(time.Minute)), nil
+}
+
+func newTestService() (*TokenService, *store) {
+	st := newStore()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(log, st, st, st, loginTokens{}, st), st
+}
+
+func TestCreateToken(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService()
+
+	token, secret, err := s.CreateToken(ctx, 1, " ci ", []string{" Profile", "orgs", "PROFILE"}, time.Time{})
+	require.NoError(t, err)
+
+	assert.True(t, IsPersonalAccessToken(secret))
+	assert.Equal(t, "ci", token.Name)
+	assert.Equal(t, []string{models.ScopeProfile, models.ScopeOrgs}, token.Scopes)
+	assert.Equal(t, hash(secret), st.tokens[token.ID].TokenHash)
+	assert.Equal(t, secret[:displayLength], token.DisplayPrefix)
+	assert.Equal(t, []string{ActionCreate}, st.recorded)
+
+	for name, scopes := range map[string][]string{
+		"no scopes":     nil,
+		"unknown scope": {"profile", "email"},
+		"tokens scope":  {models.ScopeTokens},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := s.CreateToken(ctx, 1, "ci", scopes, time.Time{})
+			var ferr *serviceerr.FieldError
+			require.ErrorAs(t, err, &ferr)
+			assert.Equal(t, "scopes", ferr.Field)
+		})
+	}
+
+	t.Run("expiry in the past", func(t *testing.T) {
+		_, _, err := s.CreateToken(ctx, 1, "ci", []string{"profile"}, time.Now().Add(-time.Minute))
+		var ferr *serviceerr.FieldError
+		require.ErrorAs(t, err, &ferr)
+		assert.Equal(t, "expires_at", ferr.Field)
+	})
+}
+
+func TestVerifyAccessToken(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService()
+
+	token, secret, err := s.CreateToken(ctx, 1, "ci", []string{"profile"}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	claims, err := s.VerifyAccessToken(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), claims.UserID)
+	assert.Equal(t, "ann@example.com", claims.UserEmail)
+	assert.Equal(t, []string{models.ScopeProfile}, claims.Scopes)
+	assert.Equal(t, tokenJTI(token.ID), claims.ID)
+	assert.Equal(t, 1, st.touched)
+
+	t.Run("login tokens are passed on", func(t *testing.T) {
+		claims, err := s.VerifyAccessToken(ctx, "login-token")
+		require.NoError(t, err)
+		assert.Nil(t, claims.Scopes)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		_, err := s.VerifyAccessToken(ctx, Prefix+"unknown")
+		assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired := st.tokens[token.ID]
+		expired.ExpiresAt = time.Now().Add(-time.Second)
+		st.tokens[token.ID] = expired
+		defer func() { st.tokens[token.ID] = token }()
+
+		_, err := s.VerifyAccessToken(ctx, secret)
+		assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	})
+
+	t.Run("owner disabled", func(t *testing.T) {
+		st.users[1] = models.User{ID: 1, Email: "ann@example.com", Disabled: true}
+		defer func() { st.users[1] = models.User{ID: 1, Email: "ann@example.com"} }()
+
+		_, err := s.VerifyAccessToken(ctx, secret)
+		assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		err := s.RevokeToken(ctx, 2, 1, token.ID)
+		assert.ErrorIs(t, err, admin.ErrPermissionDenied)
+
+		require.NoError(t, s.RevokeToken(ctx, 1, 0, token.ID))
+		assert.Contains(t, st.recorded, ActionRevoke)
+		require.Len(t, st.revocations, 1)
+		assert.Equal(t, models.RevokedToken, st.revocations[0].Kind)
+		assert.Equal(t, tokenJTI(token.ID), st.revocations[0].TokenID)
+
+		_, err = s.VerifyAccessToken(ctx, secret)
+		assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	})
+}
+
+func TestScoped(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService()
+
+	_, secret, err := s.CreateToken(ctx, 1, "ci", []string{"profile"}, time.Time{})
+	require.NoError(t, err)
+
+	_, err = authn.Scoped(s, models.ScopeProfile).VerifyAccessToken(ctx, secret)
+	assert.NoError(t, err)
+
+	_, err = authn.Scoped(s, models.ScopeAdmin).VerifyAccessToken(ctx, secret)
+	assert.ErrorIs(t, err, authn.ErrInsufficientScope)
+
+	// Personal access tokens never carry the tokens scope, so they can't manage tokens.
+	_, err = authn.Scoped(s, models.ScopeTokens).VerifyAccessToken(ctx, secret)
+	assert.ErrorIs(t, err, authn.ErrInsufficientScope)
+
+	// Login tokens are unrestricted.
+	_, err = authn.Scoped(s, models.ScopeTokens).VerifyAccessToken(ctx, "login-token")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,198 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/pkg/logger"
+	"auth/pkg/webhook"
+)
+
+const maxErrorLength = 500
+
+// DeliveryRepository moves events from the outbox to the webhooks subscribed to them.
+type DeliveryRepository interface {
+	FanOut(ctx context.Context, limit int) (int64, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.DueDelivery, error)
+	MarkDelivered(ctx context.Context, deliveryID int64, statusCode int) error
+	MarkFailed(ctx context.Context, deliveryID int64, statusCode int, lastErr string, retryAt time.Time) error
+}
+
+// DispatchPolicy controls how often and how persistently events are delivered.
+type DispatchPolicy struct {
+	Interval  time.Duration
+	BatchSize int
+	// MaxAttempts is how often a delivery is tried before it is dead-lettered.
+	MaxAttempts int
+	// RetryBase is the wait after the first failure. It doubles with every further one up to RetryMax.
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// Timeout bounds a single request to a webhook.
+	Timeout time.Duration
+}
+
+// Dispatcher posts outbox events to webhooks. Several dispatchers can run against the same
+// database; each delivery is sent by one of them at a time.
+type Dispatcher struct {
+	log    *slog.Logger
+	repo   DeliveryRepository
+	client *http.Client
+	policy DispatchPolicy
+}
+
+func NewDispatcher(log *slog.Logger, repo DeliveryRepository, policy DispatchPolicy) *Dispatcher {
+	return &Dispatcher{
+		log:  log,
+		repo: repo,
+		client: &http.Client{
+			Timeout: policy.Timeout,
+			// A redirect would send the signed event somewhere nobody registered.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		policy: policy,
+	}
+}
+
+// Run dispatches events until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch suggests there is more waiting, so don't sleep on it.
+		claimed, err := d.DispatchOnce(ctx)
+		if err == nil && claimed == d.policy.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce fans out new events and sends one batch of due deliveries. It returns how many
+// deliveries it tried to send.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	const op = "webhooks.Dispatcher.DispatchOnce"
+
+	log := d.log.With(slog.String("op", op))
+
+	if _, err := d.repo.FanOut(ctx, d.policy.BatchSize); err != nil {
+		log.Error("failed to fan out events", logger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Requests run in parallel, so the batch is done within about one timeout.
+	due, err := d.repo.ClaimDue(ctx, d.policy.BatchSize, 2*d.policy.Timeout)
+	if err != nil {
+		log.Error("failed to claim deliveries", logger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range due {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, log, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(due), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, log *slog.Logger, due models.DueDelivery) {
+	log = log.With(slog.Int64("deliveryID", due.ID), slog.Int64("webhookID", due.Webhook.ID), slog.Int64("eventID", due.Event.ID))
+
+	statusCode, err := d.send(ctx, due)
+	if err == nil {
+		if err := d.repo.MarkDelivered(ctx, due.ID, statusCode); err != nil {
+			log.Error("failed to mark delivery delivered", logger.Err(err))
+		}
+		return
+	}
+
+	attempts := due.Attempts + 1
+	var retryAt time.Time
+	if attempts < d.policy.MaxAttempts {
+		retryAt = time.Now().Add(backoff(attempts, d.policy.RetryBase, d.policy.RetryMax))
+		log.Info("webhook delivery failed", slog.Int("attempts", attempts), logger.Err(err))
+	} else {
+		log.Warn("webhook delivery dead-lettered", slog.Int("attempts", attempts), logger.Err(err))
+	}
+
+	lastErr := err.Error()
+	if len(lastErr) > maxErrorLength {
+		lastErr = lastErr[:maxErrorLength]
+	}
+
+	if err := d.repo.MarkFailed(ctx, due.ID, statusCode, lastErr, retryAt); err != nil {
+		log.Error("failed to mark delivery failed", logger.Err(err))
+	}
+}
+
+// send posts the event and returns the status code of the response, or zero if there was none.
+func (d *Dispatcher) send(ctx context.Context, due models.DueDelivery) (int, error) {
+	body, err := json.Marshal(struct {
+		ID        int64          `json:"id"`
+		Type      string         `json:"type"`
+		CreatedAt time.Time      `json:"created_at"`
+		Data      map[string]any `json:"data"`
+	}{
+		ID:        due.Event.ID,
+		Type:      due.Event.Type,
+		CreatedAt: due.Event.CreatedAt.UTC(),
+		Data:      due.Event.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, due.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+
+	// The event ID stays the same across retries and replays, so receivers can drop duplicates.
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.HeaderID, strconv.FormatInt(due.Event.ID, 10))
+	req.Header.Set(webhook.HeaderEvent, due.Event.Type)
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(due.Webhook.Secret, now, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff is how long to wait before the next attempt after the given number of failed ones.
+func backoff(failed int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < failed && d < max; i++ {
+		d *= 2
+	}
+	return min(d, max)
+}
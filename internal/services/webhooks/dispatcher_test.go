@@ -0,0 +1,241 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/pkg/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deliveryState struct {
+	due        models.DueDelivery
+	status     string
+	statusCode int
+	lastErr    string
+	retryAt    time.Time
+}
+
+type memDeliveries struct {
+	mu         sync.Mutex
+	deliveries map[int64]*deliveryState
+}
+
+func (m *memDeliveries) FanOut(context.Context, int) (int64, error) { return 0, nil }
+
+func (m *memDeliveries) ClaimDue(_ context.Context, limit int, _ time.Duration) ([]models.DueDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var due []models.DueDelivery
+	for _, d := range m.deliveries {
+		if d.status == models.DeliveryPending && !d.retryAt.After(time.Now()) && len(due) < limit {
+			due = append(due, d.due)
+		}
+	}
+	return due, nil
+}
+
+func (m *memDeliveries) MarkDelivered(_ context.Context, id int64, statusCode int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d := m.deliveries[id]
+	d.status = models.DeliveryDelivered
+	d.statusCode = statusCode
+	d.due.Attempts++
+	return nil
+}
+
+func (m *memDeliveries) MarkFailed(_ context.Context, id int64, statusCode int, lastErr string, retryAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d := m.deliveries[id]
+	d.statusCode = statusCode
+	d.lastErr = lastErr
+	d.retryAt = retryAt
+	d.due.Attempts++
+	if retryAt.IsZero() {
+		d.status = models.DeliveryDead
+	}
+	return nil
+}
+
+const testSecret = "webhook-secret"
+
+func newTestDispatcher(url string, attempts int) (*Dispatcher, *memDeliveries) {
+	repo := &memDeliveries{deliveries: map[int64]*deliveryState{
+		1: {
+			status: models.DeliveryPending,
+			due: models.DueDelivery{
+				ID:       1,
+				Attempts: attempts,
+				Webhook:  models.Webhook{ID: 10, URL: url, Secret: testSecret},
+				Event: models.Event{
+					ID:        100,
+					Type:      models.EventUserRegistered,
+					UserID:    7,
+					Payload:   map[string]any{"user_id": float64(7), "email": "new@mail.com"},
+					CreatedAt: time.Now(),
+				},
+			},
+		},
+	}}
+
+	d := NewDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, DispatchPolicy{
+		Interval:    time.Second,
+		BatchSize:   10,
+		MaxAttempts: 3,
+		RetryBase:   time.Minute,
+		RetryMax:    time.Hour,
+		Timeout:     5 * time.Second,
+	})
+	return d, repo
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("delivers signed event", func(t *testing.T) {
+		var got struct {
+			ID   int64          `json:"id"`
+			Type string         `json:"type"`
+			Data map[string]any `json:"data"`
+		}
+		var header http.Header
+
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if err := webhook.Verify(testSecret, r.Header, body, time.Now(), time.Minute); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			header = r.Header.Clone()
+			_ = json.Unmarshal(body, &got)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		d, repo := newTestDispatcher(receiver.URL, 0)
+
+		n, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		state := repo.deliveries[1]
+		assert.Equal(t, models.DeliveryDelivered, state.status)
+		assert.Equal(t, http.StatusNoContent, state.statusCode)
+
+		assert.Equal(t, int64(100), got.ID)
+		assert.Equal(t, models.EventUserRegistered, got.Type)
+		assert.Equal(t, "new@mail.com", got.Data["email"])
+		assert.Equal(t, "100", header.Get(webhook.HeaderID))
+		assert.Equal(t, models.EventUserRegistered, header.Get(webhook.HeaderEvent))
+	})
+
+	t.Run("wrong secret is rejected by receiver", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if err := webhook.Verify("other-secret", r.Header, body, time.Now(), time.Minute); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+
+		d, repo := newTestDispatcher(receiver.URL, 0)
+
+		_, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, repo.deliveries[1].statusCode)
+	})
+
+	t.Run("retries with backoff", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer receiver.Close()
+
+		d, repo := newTestDispatcher(receiver.URL, 1)
+
+		_, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+
+		state := repo.deliveries[1]
+		assert.Equal(t, models.DeliveryPending, state.status)
+		assert.Equal(t, 2, state.due.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, state.statusCode)
+		assert.Contains(t, state.lastErr, "500")
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), state.retryAt, 5*time.Second)
+
+		// Not due yet, so nothing is sent.
+		n, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("dead-letters after max attempts", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer receiver.Close()
+
+		d, repo := newTestDispatcher(receiver.URL, 2)
+
+		_, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, models.DeliveryDead, repo.deliveries[1].status)
+		assert.Equal(t, 3, repo.deliveries[1].due.Attempts)
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer target.Close()
+		receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+		defer receiver.Close()
+
+		d, repo := newTestDispatcher(receiver.URL, 0)
+
+		_, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, models.DeliveryPending, repo.deliveries[1].status)
+		assert.Equal(t, http.StatusTemporaryRedirect, repo.deliveries[1].statusCode)
+	})
+
+	t.Run("unreachable receiver", func(t *testing.T) {
+		receiver := httptest.NewServer(http.NotFoundHandler())
+		receiver.Close()
+
+		d, repo := newTestDispatcher(receiver.URL, 0)
+
+		_, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, models.DeliveryPending, repo.deliveries[1].status)
+		assert.Zero(t, repo.deliveries[1].statusCode)
+		assert.NotEmpty(t, repo.deliveries[1].lastErr)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := time.Minute, 10*time.Minute
+
+	assert.Equal(t, time.Minute, backoff(1, base, max))
+	assert.Equal(t, 2*time.Minute, backoff(2, base, max))
+	assert.Equal(t, 8*time.Minute, backoff(4, base, max))
+	assert.Equal(t, max, backoff(5, base, max))
+	assert.Equal(t, max, backoff(100, base, max))
+}
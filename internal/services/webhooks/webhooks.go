@@ -0,0 +1,259 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/audit"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/jwt"
+	"auth/pkg/logger"
+)
+
+const (
+	ActionCreate = "webhooks.create"
+	ActionDelete = "webhooks.delete"
+	ActionReplay = "webhooks.replay"
+)
+
+const (
+	secretBytes         = 32
+	maxURLLength        = 2048
+	defaultDeliveryPage = 50
+	maxDeliveryPage     = 200
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, wh models.Webhook) (int64, error)
+	Get(ctx context.Context, webhookID int64) (models.Webhook, error)
+	List(ctx context.Context, appID int) ([]models.Webhook, error)
+	Delete(ctx context.Context, webhookID int64) error
+	ListDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error)
+	Replay(ctx context.Context, webhookID int64, deliveryIDs []int64) (int64, error)
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+type WebhookService struct {
+	log      *slog.Logger
+	repo     WebhookRepository
+	userRepo admin.UserGetter
+	audit    AuditRepository
+}
+
+func New(log *slog.Logger, repo WebhookRepository, userRepo admin.UserGetter, audit AuditRepository) *WebhookService {
+	return &WebhookService{log: log, repo: repo, userRepo: userRepo, audit: audit}
+}
+
+// CreateWebhook registers an URL of the app that events are posted to and returns it with a
+// freshly generated signing secret. This is the only time the secret is returned.
+func (s WebhookService) CreateWebhook(ctx context.Context, actorID int64, wh models.Webhook) (models.Webhook, error) {
+	const op = "WebhookService.CreateWebhook"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int("appID", wh.AppID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := validate(wh); err != nil {
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	wh.Secret = jwt.GenerateRandomToken(secretBytes)
+
+	id, err := s.repo.Create(ctx, wh)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to create webhook", logger.Err(err))
+		}
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := s.repo.Get(ctx, id)
+	if err != nil {
+		log.Error("failed to get created webhook", logger.Err(err))
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+	created.Secret = wh.Secret
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionCreate,
+		AppID:   wh.AppID,
+		Details: map[string]any{"webhook_id": id, "url": wh.URL, "event_types": wh.EventTypes},
+	})
+
+	log.Info("webhook created", slog.Int64("webhookID", id))
+
+	return created, nil
+}
+
+func (s WebhookService) ListWebhooks(ctx context.Context, actorID int64, appID int) ([]models.Webhook, error) {
+	const op = "WebhookService.ListWebhooks"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int("appID", appID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	webhooks, err := s.repo.List(ctx, appID)
+	if err != nil {
+		log.Error("failed to list webhooks", logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook removes the webhook. Deliveries still pending for it are dropped.
+func (s WebhookService) DeleteWebhook(ctx context.Context, actorID, webhookID int64) error {
+	const op = "WebhookService.DeleteWebhook"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int64("webhookID", webhookID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	wh, err := s.repo.Get(ctx, webhookID)
+	if err == nil {
+		err = s.repo.Delete(ctx, webhookID)
+	}
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to delete webhook", logger.Err(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionDelete,
+		AppID:   wh.AppID,
+		Details: map[string]any{"webhook_id": webhookID, "url": wh.URL},
+	})
+
+	log.Info("webhook deleted")
+
+	return nil
+}
+
+// ListDeliveries returns the latest deliveries of the webhook, newest first. A non-empty
+// status only returns deliveries in that state, such as the dead-lettered ones.
+func (s WebhookService) ListDeliveries(ctx context.Context, actorID, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error) {
+	const op = "WebhookService.ListDeliveries"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int64("webhookID", webhookID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if status != "" && status != models.DeliveryPending && status != models.DeliveryDelivered && status != models.DeliveryDead {
+		return nil, fmt.Errorf("%s: %w", op, &serviceerr.FieldError{Field: "status", Reason: "must be pending, delivered or dead"})
+	}
+	if limit <= 0 {
+		limit = defaultDeliveryPage
+	}
+	limit = min(limit, maxDeliveryPage)
+
+	if _, err := s.repo.Get(ctx, webhookID); err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get webhook", logger.Err(err))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, webhookID, status, limit)
+	if err != nil {
+		log.Error("failed to list deliveries", logger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deliveries, nil
+}
+
+// ReplayDeliveries sends deliveries of the webhook again, with a fresh retry budget. Without
+// IDs every dead-lettered delivery is replayed. It returns how many deliveries were queued.
+func (s WebhookService) ReplayDeliveries(ctx context.Context, actorID, webhookID int64, deliveryIDs []int64) (int64, error) {
+	const op = "WebhookService.ReplayDeliveries"
+
+	log := s.log.With(slog.String("op", op), slog.Int64("actorID", actorID), slog.Int64("webhookID", webhookID))
+
+	if err := s.requireAdmin(ctx, log, actorID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	wh, err := s.repo.Get(ctx, webhookID)
+	if err != nil {
+		if !isExpected(err) {
+			log.Error("failed to get webhook", logger.Err(err))
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := s.repo.Replay(ctx, webhookID, deliveryIDs)
+	if err != nil {
+		log.Error("failed to replay deliveries", logger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	audit.Record(ctx, log, s.audit, models.AuditEntry{
+		ActorID: actorID,
+		Action:  ActionReplay,
+		AppID:   wh.AppID,
+		Details: map[string]any{"webhook_id": webhookID, "delivery_ids": deliveryIDs, "queued": n},
+	})
+
+	log.Info("webhook deliveries replayed", slog.Int64("queued", n))
+
+	return n, nil
+}
+
+func (s WebhookService) requireAdmin(ctx context.Context, log *slog.Logger, actorID int64) error {
+	err := admin.RequireAdmin(ctx, s.userRepo, actorID)
+	if err != nil && !errors.Is(err, admin.ErrPermissionDenied) {
+		log.Error("failed to check admin", logger.Err(err))
+	}
+	return err
+}
+
+func validate(wh models.Webhook) error {
+	if wh.AppID <= 0 {
+		return &serviceerr.FieldError{Field: "app_id", Reason: "is required"}
+	}
+
+	u, err := url.Parse(wh.URL)
+	if err != nil || len(wh.URL) > maxURLLength || !u.IsAbs() || u.Host == "" || u.Fragment != "" {
+		return &serviceerr.FieldError{Field: "url", Reason: "must be an absolute URL without fragment"}
+	}
+	if u.Scheme != "https" && u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1" {
+		return &serviceerr.FieldError{Field: "url", Reason: "must use https"}
+	}
+
+	for _, eventType := range wh.EventTypes {
+		if !slices.Contains(models.EventTypes, eventType) {
+			return &serviceerr.FieldError{Field: "event_types", Reason: fmt.Sprintf("unknown event type %q", eventType)}
+		}
+	}
+
+	return nil
+}
+
+func isExpected(err error) bool {
+	var ferr *serviceerr.FieldError
+	return errors.As(err, &ferr) ||
+		errors.Is(err, repository.ErrWebhookNotFound) ||
+		errors.Is(err, repository.ErrAppNotFound)
+}
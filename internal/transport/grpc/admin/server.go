@@ -0,0 +1,287 @@
+package admingrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/auth"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedAdminServer
+	adminServ AdminService
+	verifier  authn.TokenVerifier
+}
+
+type AdminService interface {
+	ListUsers(ctx context.Context, actorID int64, filter models.UserFilter, cursor string) ([]models.User, string, error)
+	GetUser(ctx context.Context, actorID, userID int64) (models.User, error)
+	DisableUser(ctx context.Context, actorID, userID int64, reason string) error
+	EnableUser(ctx context.Context, actorID, userID int64) error
+	ForceLogout(ctx context.Context, actorID, userID int64) error
+	ForcePasswordReset(ctx context.Context, actorID, userID int64) error
+	SetEmailVerified(ctx context.Context, actorID, userID int64, verified bool) error
+	DeleteUser(ctx context.Context, actorID, userID int64) error
+	Impersonate(ctx context.Context, actorID, userID int64, appID int, reason string) (string, time.Time, error)
+	QueryAuditLog(ctx context.Context, actorID int64, filter models.AuditFilter, cursor string) ([]models.AuditEntry, string, error)
+	VerifyAuditLog(ctx context.Context, actorID int64) (admin.AuditVerification, error)
+}
+
+func Register(gRPCServer *grpc.Server, adminServ AdminService, verifier authn.TokenVerifier) {
+	ssov1.RegisterAdminServer(gRPCServer, &GRPCServer{adminServ: adminServ, verifier: verifier})
+}
+
+func (s *GRPCServer) ListUsers(ctx context.Context, req *ssov1.ListUsersRequest) (*ssov1.ListUsersResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	filter := models.UserFilter{
+		Query:         req.GetQuery(),
+		Disabled:      req.Disabled,
+		EmailVerified: req.EmailVerified,
+		Limit:         int(req.GetPageSize()),
+	}
+
+	users, next, err := s.adminServ.ListUsers(ctx, claims.UserID, filter, req.GetPageToken())
+	if err != nil {
+		return nil, toStatus(err, "failed to list users")
+	}
+
+	resp := &ssov1.ListUsersResponse{NextPageToken: next}
+	for _, u := range users {
+		resp.Users = append(resp.Users, toUser(u))
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) GetUser(ctx context.Context, req *ssov1.GetUserRequest) (*ssov1.User, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetUserId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	user, err := s.adminServ.GetUser(ctx, claims.UserID, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(err, "failed to get user")
+	}
+
+	return toUser(user), nil
+}
+
+func (s *GRPCServer) DisableUser(ctx context.Context, req *ssov1.DisableUserRequest) (*emptypb.Empty, error) {
+	return s.userAction(ctx, req.GetUserId(), "failed to disable user", func(actorID int64) error {
+		return s.adminServ.DisableUser(ctx, actorID, req.GetUserId(), req.GetReason())
+	})
+}
+
+func (s *GRPCServer) EnableUser(ctx context.Context, req *ssov1.EnableUserRequest) (*emptypb.Empty, error) {
+	return s.userAction(ctx, req.GetUserId(), "failed to enable user", func(actorID int64) error {
+		return s.adminServ.EnableUser(ctx, actorID, req.GetUserId())
+	})
+}
+
+func (s *GRPCServer) ForceLogout(ctx context.Context, req *ssov1.ForceLogoutRequest) (*emptypb.Empty, error) {
+	return s.userAction(ctx, req.GetUserId(), "failed to log out user", func(actorID int64) error {
+		return s.adminServ.ForceLogout(ctx, actorID, req.GetUserId())
+	})
+}
+
+func (s *GRPCServer) ForcePasswordReset(ctx context.Context, req *ssov1.ForcePasswordResetRequest) (*emptypb.Empty, error) {
+	return s.userAction(ctx, req.GetUserId(), "failed to force password reset", func(actorID int64) error {
+		return s.adminServ.ForcePasswordReset(ctx, actorID, req.GetUserId())
+	})
+}
+
+func (s *GRPCServer) SetEmailVerified(ctx context.Context, req *ssov1.SetEmailVerifiedRequest) (*emptypb.Empty, error) {
+	return s.userAction(ctx, req.GetUserId(), "failed to set email verification", func(actorID int64) error {
+		return s.adminServ.SetEmailVerified(ctx, actorID, req.GetUserId(), req.GetVerified())
+	})
+}
+
+func (s *GRPCServer) DeleteUser(ctx context.Context, req *ssov1.DeleteUserRequest) (*emptypb.Empty, error) {
+	return s.userAction(ctx, req.GetUserId(), "failed to delete user", func(actorID int64) error {
+		return s.adminServ.DeleteUser(ctx, actorID, req.GetUserId())
+	})
+}
+
+func (s *GRPCServer) userAction(ctx context.Context, userID int64, failMsg string, fn func(actorID int64) error) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if err := fn(claims.UserID); err != nil {
+		return nil, toStatus(err, failMsg)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) Impersonate(ctx context.Context, req *ssov1.ImpersonateRequest) (*ssov1.ImpersonateResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetUserId() == 0 || req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id and app_id are required")
+	}
+
+	// An impersonation token must not be used to start another impersonation.
+	if claims.Act != nil {
+		return nil, status.Error(codes.PermissionDenied, "impersonation tokens can't impersonate")
+	}
+
+	token, expiresAt, err := s.adminServ.Impersonate(ctx, claims.UserID, req.GetUserId(), int(req.GetAppId()), req.GetReason())
+	if err != nil {
+		return nil, toStatus(err, "failed to impersonate user")
+	}
+
+	return &ssov1.ImpersonateResponse{AccessToken: token, ExpiresAt: timestamppb.New(expiresAt)}, nil
+}
+
+func (s *GRPCServer) QueryAuditLog(ctx context.Context, req *ssov1.QueryAuditLogRequest) (*ssov1.QueryAuditLogResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+	if outcome := req.GetOutcome(); outcome != "" && outcome != models.OutcomeSuccess && outcome != models.OutcomeFailure {
+		return nil, status.Error(codes.InvalidArgument, "outcome must be success or failure")
+	}
+
+	filter := models.AuditFilter{
+		ActorID:          req.GetActorId(),
+		TargetUserID:     req.GetTargetUserId(),
+		ServiceAccountID: req.GetServiceAccountId(),
+		AppID:            int(req.GetAppId()),
+		Action:           req.GetAction(),
+		Outcome:          req.GetOutcome(),
+		Limit:            int(req.GetPageSize()),
+	}
+	if req.GetSince() != nil {
+		filter.Since = req.GetSince().AsTime()
+	}
+	if req.GetUntil() != nil {
+		filter.Until = req.GetUntil().AsTime()
+	}
+
+	entries, next, err := s.adminServ.QueryAuditLog(ctx, claims.UserID, filter, req.GetPageToken())
+	if err != nil {
+		return nil, toStatus(err, "failed to query audit log")
+	}
+
+	resp := &ssov1.QueryAuditLogResponse{NextPageToken: next}
+	for _, e := range entries {
+		entry, err := toAuditEntry(e)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to query audit log")
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) VerifyAuditLog(ctx context.Context, req *ssov1.VerifyAuditLogRequest) (*ssov1.VerifyAuditLogResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.adminServ.VerifyAuditLog(ctx, claims.UserID)
+	if err != nil {
+		return nil, toStatus(err, "failed to verify audit log")
+	}
+
+	return &ssov1.VerifyAuditLogResponse{
+		Intact:        res.Intact(),
+		Checked:       int64(res.Checked),
+		BrokenEntryId: res.BrokenID,
+	}, nil
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, admin.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, "invalid page_token")
+	case errors.Is(err, repository.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, admin.ErrReasonRequired):
+		return status.Error(codes.InvalidArgument, "reason is required")
+	case errors.Is(err, admin.ErrCannotImpersonate):
+		return status.Error(codes.FailedPrecondition, "admins, disabled users and yourself can't be impersonated")
+	case errors.Is(err, admin.ErrNoImpersonationScopes):
+		return status.Error(codes.FailedPrecondition, "impersonation is not configured")
+	case errors.Is(err, repository.ErrAppNotFound):
+		return status.Error(codes.NotFound, "app not found")
+	case errors.Is(err, auth.ErrAppDisabled):
+		return status.Error(codes.FailedPrecondition, "app is disabled")
+	default:
+		return grpcerr.Status(err, failMsg)
+	}
+}
+
+func toUser(u models.User) *ssov1.User {
+	return &ssov1.User{
+		Id:                    u.ID,
+		Email:                 u.Email,
+		IsAdmin:               u.IsAdmin,
+		Disabled:              u.Disabled,
+		EmailVerified:         u.EmailVerified,
+		PasswordResetRequired: u.PasswordResetRequired,
+	}
+}
+
+func toAuditEntry(e models.AuditEntry) (*ssov1.AuditEntry, error) {
+	details, err := structpb.NewStruct(e.Details)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ssov1.AuditEntry{
+		Id:               e.ID,
+		ActorId:          e.ActorID,
+		Action:           e.Action,
+		TargetUserId:     e.TargetUserID,
+		ServiceAccountId: e.ServiceAccountID,
+		AppId:            int32(e.AppID),
+		Ip:               e.IP,
+		UserAgent:        e.UserAgent,
+		Outcome:          e.Outcome,
+		Details:          details,
+		CreatedAt:        timestamppb.New(e.CreatedAt),
+	}, nil
+}
@@ -0,0 +1,261 @@
+package appsgrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedAppsServer
+	appServ  AppService
+	verifier authn.TokenVerifier
+}
+
+type AppService interface {
+	CreateApp(ctx context.Context, actorID int64, app models.App) (models.App, error)
+	GetApp(ctx context.Context, actorID int64, appID int) (models.App, error)
+	ListApps(ctx context.Context, actorID int64) ([]models.App, error)
+	UpdateApp(ctx context.Context, actorID int64, appID int, upd models.AppUpdate) (models.App, error)
+	DeleteApp(ctx context.Context, actorID int64, appID int) error
+	RotateSecret(ctx context.Context, actorID int64, appID int, kind string, grace time.Duration) (string, error)
+}
+
+func Register(gRPCServer *grpc.Server, appServ AppService, verifier authn.TokenVerifier) {
+	ssov1.RegisterAppsServer(gRPCServer, &GRPCServer{appServ: appServ, verifier: verifier})
+}
+
+func (s *GRPCServer) CreateApp(ctx context.Context, req *ssov1.CreateAppRequest) (*ssov1.CreateAppResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	app := models.App{
+		Name:                  req.GetName(),
+		RedirectURIs:          req.GetRedirectUris(),
+		GrantTypes:            req.GetGrantTypes(),
+		TokenClaims:           req.GetTokenClaims(),
+		IDTokenClaims:         req.GetIdTokenClaims(),
+		AccessTTL:             req.GetAccessTtl().AsDuration(),
+		RefreshTTL:            req.GetRefreshTtl().AsDuration(),
+		RefreshIdleTimeout:    req.GetRefreshIdleTimeout().AsDuration(),
+		MaxSessions:           int(req.GetMaxSessions()),
+		InviteOnly:            req.GetInviteOnly(),
+		BackchannelLogoutURI:  req.GetBackchannelLogoutUri(),
+		FrontchannelLogoutURI: req.GetFrontchannelLogoutUri(),
+		Enabled:               req.Enabled == nil || *req.Enabled,
+	}
+	if len(app.GrantTypes) == 0 {
+		app.GrantTypes = nil
+	}
+
+	created, err := s.appServ.CreateApp(ctx, claims.UserID, app)
+	if err != nil {
+		return nil, toStatus(err, "failed to create app")
+	}
+
+	return &ssov1.CreateAppResponse{
+		App:           toApp(created),
+		AccessSecret:  created.AccessSecret,
+		RefreshSecret: created.RefreshSecret,
+	}, nil
+}
+
+func (s *GRPCServer) GetApp(ctx context.Context, req *ssov1.GetAppRequest) (*ssov1.App, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	app, err := s.appServ.GetApp(ctx, claims.UserID, int(req.GetAppId()))
+	if err != nil {
+		return nil, toStatus(err, "failed to get app")
+	}
+
+	return toApp(app), nil
+}
+
+func (s *GRPCServer) ListApps(ctx context.Context, req *ssov1.ListAppsRequest) (*ssov1.ListAppsResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.appServ.ListApps(ctx, claims.UserID)
+	if err != nil {
+		return nil, toStatus(err, "failed to list apps")
+	}
+
+	resp := &ssov1.ListAppsResponse{}
+	for _, app := range list {
+		resp.Apps = append(resp.Apps, toApp(app))
+	}
+
+	return resp, nil
+}
+
+// UpdateApp changes only the fields named in update_mask.
+func (s *GRPCServer) UpdateApp(ctx context.Context, req *ssov1.UpdateAppRequest) (*ssov1.App, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+	if req.GetApp() == nil || len(req.GetUpdateMask().GetPaths()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app and update_mask are required")
+	}
+
+	src := req.GetApp()
+	var upd models.AppUpdate
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		switch path {
+		case "name":
+			upd.Name = &src.Name
+		case "redirect_uris":
+			upd.RedirectURIs = nonNil(src.RedirectUris)
+		case "grant_types":
+			upd.GrantTypes = nonNil(src.GrantTypes)
+		case "token_claims":
+			upd.TokenClaims = nonNil(src.TokenClaims)
+		case "id_token_claims":
+			upd.IDTokenClaims = nonNil(src.IdTokenClaims)
+		case "access_ttl":
+			ttl := src.GetAccessTtl().AsDuration()
+			upd.AccessTTL = &ttl
+		case "refresh_ttl":
+			ttl := src.GetRefreshTtl().AsDuration()
+			upd.RefreshTTL = &ttl
+		case "refresh_idle_timeout":
+			timeout := src.GetRefreshIdleTimeout().AsDuration()
+			upd.RefreshIdleTimeout = &timeout
+		case "max_sessions":
+			maxSessions := int(src.GetMaxSessions())
+			upd.MaxSessions = &maxSessions
+		case "invite_only":
+			upd.InviteOnly = &src.InviteOnly
+		case "backchannel_logout_uri":
+			upd.BackchannelLogoutURI = &src.BackchannelLogoutUri
+		case "frontchannel_logout_uri":
+			upd.FrontchannelLogoutURI = &src.FrontchannelLogoutUri
+		case "enabled":
+			upd.Enabled = &src.Enabled
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown update_mask path %q", path)
+		}
+	}
+
+	app, err := s.appServ.UpdateApp(ctx, claims.UserID, int(req.GetAppId()), upd)
+	if err != nil {
+		return nil, toStatus(err, "failed to update app")
+	}
+
+	return toApp(app), nil
+}
+
+func (s *GRPCServer) DeleteApp(ctx context.Context, req *ssov1.DeleteAppRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	if err := s.appServ.DeleteApp(ctx, claims.UserID, int(req.GetAppId())); err != nil {
+		return nil, toStatus(err, "failed to delete app")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) RotateAppSecret(ctx context.Context, req *ssov1.RotateAppSecretRequest) (*ssov1.RotateAppSecretResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	kind := req.GetKind()
+	if kind == "" {
+		kind = models.SecretKindAccess
+	}
+
+	secret, err := s.appServ.RotateSecret(ctx, claims.UserID, int(req.GetAppId()), kind, req.GetGracePeriod().AsDuration())
+	if err != nil {
+		return nil, toStatus(err, "failed to rotate app secret")
+	}
+
+	return &ssov1.RotateAppSecretResponse{Secret: secret}, nil
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, repository.ErrAppNotFound):
+		return status.Error(codes.NotFound, "app not found")
+	case errors.Is(err, repository.ErrAppExists):
+		return status.Error(codes.AlreadyExists, "app already exists")
+	default:
+		return grpcerr.Status(err, failMsg)
+	}
+}
+
+func toApp(app models.App) *ssov1.App {
+	res := &ssov1.App{
+		Id:            int32(app.ID),
+		Name:          app.Name,
+		RedirectUris:  app.RedirectURIs,
+		GrantTypes:    app.GrantTypes,
+		TokenClaims:   app.TokenClaims,
+		IdTokenClaims: app.IDTokenClaims,
+		Enabled:       app.Enabled,
+	}
+	if app.AccessTTL > 0 {
+		res.AccessTtl = durationpb.New(app.AccessTTL)
+	}
+	if app.RefreshTTL > 0 {
+		res.RefreshTtl = durationpb.New(app.RefreshTTL)
+	}
+	if app.RefreshIdleTimeout > 0 {
+		res.RefreshIdleTimeout = durationpb.New(app.RefreshIdleTimeout)
+	}
+	res.MaxSessions = int32(app.MaxSessions)
+	res.InviteOnly = app.InviteOnly
+	res.BackchannelLogoutUri = app.BackchannelLogoutURI
+	res.FrontchannelLogoutUri = app.FrontchannelLogoutURI
+	return res
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -3,44 +3,52 @@ package authgrpc
 import (
 	"context"
 	"errors"
-	"strings"
 
+	ssov1 "auth/gen/go/sso"
 	"auth/internal/repository"
 	"auth/internal/services/auth"
+	"auth/internal/services/tokens"
+	"auth/internal/transport/grpc/authn"
+	"auth/pkg/jwt"
+	"auth/pkg/password"
+	"auth/pkg/requestmeta"
 
-	ssov1 "github.com/CrispyCl/testprotos/gen/go/sso"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	tokenTypeAccess   = "access_token"
+	tokenTypePersonal = "personal_access_token"
 )
 
 type GRPCServer struct {
 	ssov1.UnimplementedAuthServer
 	authServ AuthService
+	verifier authn.TokenVerifier
 }
 
 type AuthService interface {
-	Login(ctx context.Context, email, password string, appID int, ip, userAgent string) (string, string, error)
-	Register(ctx context.Context, email, password string) (userID int64, err error)
+	Login(ctx context.Context, email, password string, appID int, orgID int64, ip, userAgent string) (string, string, error)
+	Register(ctx context.Context, email, password string, appID int, invitationToken string) (userID int64, err error)
 	Refresh(ctx context.Context, refreshToken string) (newAccess, newRefresh string, err error)
+	SwitchOrganization(ctx context.Context, refreshToken string, orgID int64) (newAccess, newRefresh string, err error)
+	AcceptInvitation(ctx context.Context, token, password string) (userID int64, created bool, err error)
+	StepUp(ctx context.Context, refreshToken, password string) (newAccess, newRefresh string, err error)
+	ChangePassword(ctx context.Context, email, currentPassword, newPassword string) error
 }
 
-func Register(gRPCServer *grpc.Server, auth AuthService) {
-	ssov1.RegisterAuthServer(gRPCServer, &GRPCServer{authServ: auth})
+func Register(gRPCServer *grpc.Server, auth AuthService, verifier authn.TokenVerifier) {
+	ssov1.RegisterAuthServer(gRPCServer, &GRPCServer{authServ: auth, verifier: verifier})
 }
 
 func extractMeta(ctx context.Context) (ip, ua string) {
-	if p, ok := peer.FromContext(ctx); ok {
-		ip = strings.Split(p.Addr.String(), ":")[0]
-	}
-	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		if uaVals := md.Get("user-agent"); len(uaVals) > 0 {
-			ua = uaVals[0]
-		}
-	}
-	return
+	meta := requestmeta.FromContext(ctx)
+	return meta.IP, meta.UserAgent
 }
 
 func (s *GRPCServer) Login(
@@ -61,13 +69,25 @@ func (s *GRPCServer) Login(
 
 	ip, ua := extractMeta(ctx)
 
-	access, refresh, err := s.authServ.Login(ctx, req.Email, req.Password, int(req.AppId), ip, ua)
+	access, refresh, err := s.authServ.Login(ctx, req.Email, req.Password, int(req.AppId), req.GetOrgId(), ip, ua)
 
 	if err != nil {
 		if errors.Is(err, auth.ErrInvalidCredentials) {
 			return nil, status.Error(codes.InvalidArgument, "invalid email or password")
 		}
 
+		if errors.Is(err, auth.ErrUserDisabled) {
+			return nil, status.Error(codes.PermissionDenied, "user is disabled")
+		}
+
+		if errors.Is(err, auth.ErrPasswordReset) {
+			return nil, status.Error(codes.FailedPrecondition, "password reset required")
+		}
+
+		if st := orgError(err); st != nil {
+			return nil, st
+		}
+
 		return nil, status.Error(codes.Internal, "failed to login")
 	}
 
@@ -86,13 +106,30 @@ func (s *GRPCServer) Register(
 		return nil, status.Error(codes.InvalidArgument, "password is required")
 	}
 
-	uid, err := s.authServ.Register(ctx, req.Email, req.Password)
+	uid, err := s.authServ.Register(ctx, req.Email, req.Password, int(req.GetAppId()), req.GetInvitationToken())
 
 	if err != nil {
 		if errors.Is(err, repository.ErrUserExists) {
 			return nil, status.Error(codes.AlreadyExists, "user already exists")
 		}
 
+		if errors.Is(err, repository.ErrAppNotFound) {
+			return nil, status.Error(codes.InvalidArgument, "unknown app_id")
+		}
+
+		if errors.Is(err, auth.ErrInvitationRequired) {
+			return nil, status.Error(codes.PermissionDenied, "registration requires an invitation")
+		}
+
+		if errors.Is(err, auth.ErrInvalidInvitation) {
+			return nil, status.Error(codes.InvalidArgument, "invitation is invalid or expired")
+		}
+
+		var verr *password.ValidationError
+		if errors.As(err, &verr) {
+			return nil, passwordPolicyError("password", verr)
+		}
+
 		return nil, status.Error(codes.Internal, "failed to register user")
 	}
 
@@ -107,8 +144,254 @@ func (s *GRPCServer) Refresh(ctx context.Context, req *ssov1.RefreshTokenRequest
 	access, refresh, err := s.authServ.Refresh(ctx, req.RefreshToken)
 
 	if err != nil {
+		if errors.Is(err, auth.ErrUserDisabled) {
+			return nil, status.Error(codes.PermissionDenied, "user is disabled")
+		}
+
+		if errors.Is(err, auth.ErrPasswordReset) {
+			return nil, status.Error(codes.FailedPrecondition, "password reset required")
+		}
+
+		if st := orgError(err); st != nil {
+			return nil, st
+		}
+
 		return nil, status.Error(codes.Internal, "failed to refresh token")
 	}
 
 	return &ssov1.TokenPairResponse{AccessToken: access, RefreshToken: refresh}, nil
 }
+
+// SwitchOrganization trades a refresh token for a token pair scoped to another organization.
+func (s *GRPCServer) SwitchOrganization(ctx context.Context, req *ssov1.SwitchOrganizationRequest) (*ssov1.TokenPairResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	access, refresh, err := s.authServ.SwitchOrganization(ctx, req.GetRefreshToken(), req.GetOrgId())
+
+	if err != nil {
+		if errors.Is(err, auth.ErrUserDisabled) {
+			return nil, status.Error(codes.PermissionDenied, "user is disabled")
+		}
+
+		if errors.Is(err, auth.ErrPasswordReset) {
+			return nil, status.Error(codes.FailedPrecondition, "password reset required")
+		}
+
+		if st := orgError(err); st != nil {
+			return nil, st
+		}
+
+		return nil, status.Error(codes.Internal, "failed to switch organization")
+	}
+
+	return &ssov1.TokenPairResponse{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// StepUp trades a refresh token of a session started without the password, and the user's
+// password, for a token pair with a higher acr and a fresh auth_time.
+func (s *GRPCServer) StepUp(ctx context.Context, req *ssov1.StepUpRequest) (*ssov1.TokenPairResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	if req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "password is required")
+	}
+
+	access, refresh, err := s.authServ.StepUp(ctx, req.GetRefreshToken(), req.GetPassword())
+
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, "invalid password")
+		}
+
+		if errors.Is(err, auth.ErrUserDisabled) {
+			return nil, status.Error(codes.PermissionDenied, "user is disabled")
+		}
+
+		if errors.Is(err, auth.ErrPasswordReset) {
+			return nil, status.Error(codes.FailedPrecondition, "password reset required")
+		}
+
+		if errors.Is(err, auth.ErrSessionExpired) {
+			return nil, status.Error(codes.Unauthenticated, "session expired")
+		}
+
+		if errors.Is(err, auth.ErrFactorUsed) {
+			return nil, status.Error(codes.FailedPrecondition, "session is already authenticated with a password, step up with another factor")
+		}
+
+		if st := orgError(err); st != nil {
+			return nil, st
+		}
+
+		return nil, status.Error(codes.Internal, "failed to step up")
+	}
+
+	return &ssov1.TokenPairResponse{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// ChangePassword replaces the caller's password, clearing a password reset an admin required.
+func (s *GRPCServer) ChangePassword(ctx context.Context, req *ssov1.ChangePasswordRequest) (*emptypb.Empty, error) {
+	if req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if req.GetCurrentPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "current_password is required")
+	}
+
+	if req.GetNewPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "new_password is required")
+	}
+
+	err := s.authServ.ChangePassword(ctx, req.GetEmail(), req.GetCurrentPassword(), req.GetNewPassword())
+
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, "invalid email or password")
+		}
+
+		if errors.Is(err, auth.ErrUserDisabled) {
+			return nil, status.Error(codes.PermissionDenied, "user is disabled")
+		}
+
+		if errors.Is(err, auth.ErrExternalPassword) {
+			return nil, status.Error(codes.FailedPrecondition, "password is managed by the directory")
+		}
+
+		if errors.Is(err, auth.ErrPasswordUnchanged) {
+			return nil, status.Error(codes.InvalidArgument, "new_password must differ from the current one")
+		}
+
+		var verr *password.ValidationError
+		if errors.As(err, &verr) {
+			return nil, passwordPolicyError("new_password", verr)
+		}
+
+		return nil, status.Error(codes.Internal, "failed to change password")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// AcceptInvitation joins the organization an invitation is for, creating the account first if needed.
+func (s *GRPCServer) AcceptInvitation(ctx context.Context, req *ssov1.AcceptInvitationRequest) (*ssov1.AcceptInvitationResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	if req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "password is required")
+	}
+
+	uid, created, err := s.authServ.AcceptInvitation(ctx, req.GetToken(), req.GetPassword())
+
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidInvitation) {
+			return nil, status.Error(codes.InvalidArgument, "invitation is invalid or expired")
+		}
+
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, "invalid email or password")
+		}
+
+		if errors.Is(err, auth.ErrUserDisabled) {
+			return nil, status.Error(codes.PermissionDenied, "user is disabled")
+		}
+
+		if errors.Is(err, repository.ErrUserExists) || errors.Is(err, repository.ErrUserNotFound) {
+			return nil, status.Error(codes.Aborted, "account changed while accepting, try again")
+		}
+
+		var verr *password.ValidationError
+		if errors.As(err, &verr) {
+			return nil, passwordPolicyError("password", verr)
+		}
+
+		return nil, status.Error(codes.Internal, "failed to accept invitation")
+	}
+
+	return &ssov1.AcceptInvitationResponse{UserId: uid, Created: created}, nil
+}
+
+// Introspect reports whether a token is currently valid and whom it belongs to. Tokens that
+// can't be verified are reported as inactive rather than as an error.
+func (s *GRPCServer) Introspect(ctx context.Context, req *ssov1.IntrospectRequest) (*ssov1.IntrospectResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	claims, err := s.verifier.VerifyAccessToken(ctx, req.GetToken())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return &ssov1.IntrospectResponse{Active: false}, nil
+		}
+		return nil, status.Error(codes.Internal, "failed to introspect token")
+	}
+
+	resp := &ssov1.IntrospectResponse{
+		Active:           true,
+		UserId:           claims.UserID,
+		Email:            claims.UserEmail,
+		AppId:            int32(claims.AppID),
+		OrgId:            claims.OrgID,
+		Scopes:           claims.Scopes,
+		TokenType:        tokenTypeAccess,
+		PrincipalType:    claims.PrincipalType,
+		ServiceAccountId: claims.ServiceAccountID,
+		SessionId:        claims.SessionID,
+		Acr:              claims.ACR,
+		Amr:              claims.AMR,
+	}
+	if claims.Act != nil {
+		resp.ActorUserId = claims.Act.UserID
+	}
+	if resp.PrincipalType == "" {
+		resp.PrincipalType = jwt.PrincipalUser
+	}
+	if tokens.IsPersonalAccessToken(req.GetToken()) {
+		resp.TokenType = tokenTypePersonal
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresAt = timestamppb.New(claims.ExpiresAt.Time)
+	}
+	if claims.AuthTime != nil {
+		resp.AuthTime = timestamppb.New(claims.AuthTime.Time)
+	}
+
+	return resp, nil
+}
+
+// orgError maps organization sign-in policy errors, or returns nil for anything else.
+func orgError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrNotOrgMember):
+		return status.Error(codes.PermissionDenied, "user is not a member of the organization")
+	case errors.Is(err, auth.ErrEmailDomain):
+		return status.Error(codes.PermissionDenied, "email domain is not allowed by the organization")
+	case errors.Is(err, auth.ErrMFARequired):
+		return status.Error(codes.FailedPrecondition, "organization requires multi-factor authentication")
+	}
+	return nil
+}
+
+func passwordPolicyError(field string, verr *password.ValidationError) error {
+	br := &errdetails.BadRequest{}
+	for _, v := range verr.Violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: v.Description,
+			Reason:      v.Rule,
+		})
+	}
+
+	st, err := status.New(codes.InvalidArgument, "password does not satisfy policy").WithDetails(br)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "password does not satisfy policy")
+	}
+
+	return st.Err()
+}
@@ -0,0 +1,80 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"auth/internal/services/auth"
+	"auth/pkg/jwt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrInsufficientScope is returned for tokens whose scopes don't cover the API being called.
+var ErrInsufficientScope = errors.New("insufficient token scope")
+
+type TokenVerifier interface {
+	VerifyAccessToken(ctx context.Context, token string) (*jwt.Claims, error)
+}
+
+type scopedVerifier struct {
+	next  TokenVerifier
+	scope string
+}
+
+// Scoped only accepts user tokens that are unrestricted or carry scope. Service account tokens
+// are meant for the app they were issued for and never pass.
+func Scoped(verifier TokenVerifier, scope string) TokenVerifier {
+	return scopedVerifier{next: verifier, scope: scope}
+}
+
+func (v scopedVerifier) VerifyAccessToken(ctx context.Context, token string) (*jwt.Claims, error) {
+	claims, err := v.next.VerifyAccessToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.IsServiceAccount() || !claims.HasScope(v.scope) {
+		return nil, ErrInsufficientScope
+	}
+	return claims, nil
+}
+
+func BearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	for _, v := range md.Get("authorization") {
+		scheme, token, found := strings.Cut(v, " ")
+		if found && strings.EqualFold(scheme, "bearer") && token != "" {
+			return token, true
+		}
+	}
+
+	return "", false
+}
+
+// Authenticate verifies the bearer token of the call and returns its claims, or a gRPC status error.
+func Authenticate(ctx context.Context, verifier TokenVerifier) (*jwt.Claims, error) {
+	token, ok := BearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "access token is required")
+	}
+
+	claims, err := verifier.VerifyAccessToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid access token")
+		}
+		if errors.Is(err, ErrInsufficientScope) {
+			return nil, status.Error(codes.PermissionDenied, "access token lacks the required scope")
+		}
+		return nil, status.Error(codes.Internal, "failed to verify access token")
+	}
+
+	return claims, nil
+}
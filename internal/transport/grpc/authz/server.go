@@ -0,0 +1,230 @@
+package authzgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/authz"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedAuthzServer
+	authzServ AuthzService
+	verifier  authn.TokenVerifier
+}
+
+type AuthzService interface {
+	WriteNamespace(ctx context.Context, actorID int64, cfg models.NamespaceConfig) error
+	GetNamespace(ctx context.Context, actorID int64, name string) (models.NamespaceConfig, error)
+	WriteTuples(ctx context.Context, actorID int64, writes, deletes []models.RelationTuple) (string, error)
+	Check(ctx context.Context, actorID int64, obj models.Object, relation string, subject models.Subject, token string) (bool, string, error)
+	Expand(ctx context.Context, actorID int64, obj models.Object, relation, token string) (models.ExpandNode, string, error)
+	ListObjects(ctx context.Context, actorID int64, namespace, relation string, subject models.Subject, token string) ([]string, string, error)
+}
+
+func Register(gRPCServer *grpc.Server, authzServ AuthzService, verifier authn.TokenVerifier) {
+	ssov1.RegisterAuthzServer(gRPCServer, &GRPCServer{authzServ: authzServ, verifier: verifier})
+}
+
+// WriteNamespace creates or replaces a namespace config. The config struct holds the
+// JSON form of the relations, e.g. {"relations": {"viewer": {"union": [{"this": true}]}}}.
+func (s *GRPCServer) WriteNamespace(ctx context.Context, req *ssov1.WriteNamespaceRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(req.GetConfig().AsMap())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid config")
+	}
+
+	cfg := models.NamespaceConfig{Name: req.GetName()}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, grpcerr.FieldError("config", err.Error())
+	}
+
+	if err := s.authzServ.WriteNamespace(ctx, claims.UserID, cfg); err != nil {
+		return nil, toStatus(err, "failed to write namespace")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) ReadNamespace(ctx context.Context, req *ssov1.ReadNamespaceRequest) (*ssov1.Namespace, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := s.authzServ.GetNamespace(ctx, claims.UserID, req.GetName())
+	if err != nil {
+		return nil, toStatus(err, "failed to read namespace")
+	}
+
+	config, err := toStruct(cfg)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to read namespace")
+	}
+
+	return &ssov1.Namespace{Name: cfg.Name, Config: config}, nil
+}
+
+func (s *GRPCServer) WriteTuples(ctx context.Context, req *ssov1.WriteTuplesRequest) (*ssov1.WriteTuplesResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	writes, err := parseTuples("writes", req.GetWrites())
+	if err != nil {
+		return nil, err
+	}
+	deletes, err := parseTuples("deletes", req.GetDeletes())
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.authzServ.WriteTuples(ctx, claims.UserID, writes, deletes)
+	if err != nil {
+		return nil, toStatus(err, "failed to write tuples")
+	}
+
+	return &ssov1.WriteTuplesResponse{ConsistencyToken: token}, nil
+}
+
+func (s *GRPCServer) Check(ctx context.Context, req *ssov1.CheckRequest) (*ssov1.CheckResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := authz.ParseObject(req.GetObject())
+	if err != nil {
+		return nil, grpcerr.FieldError("object", err.Error())
+	}
+	subject, err := authz.ParseSubject(req.GetSubject())
+	if err != nil {
+		return nil, grpcerr.FieldError("subject", err.Error())
+	}
+
+	allowed, token, err := s.authzServ.Check(ctx, claims.UserID, obj, req.GetRelation(), subject, req.GetConsistencyToken())
+	if err != nil {
+		return nil, toStatus(err, "failed to check relation")
+	}
+
+	return &ssov1.CheckResponse{Allowed: allowed, ConsistencyToken: token}, nil
+}
+
+func (s *GRPCServer) Expand(ctx context.Context, req *ssov1.ExpandRequest) (*ssov1.ExpandResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := authz.ParseObject(req.GetObject())
+	if err != nil {
+		return nil, grpcerr.FieldError("object", err.Error())
+	}
+
+	tree, token, err := s.authzServ.Expand(ctx, claims.UserID, obj, req.GetRelation(), req.GetConsistencyToken())
+	if err != nil {
+		return nil, toStatus(err, "failed to expand relation")
+	}
+
+	return &ssov1.ExpandResponse{Tree: toExpandNode(tree), ConsistencyToken: token}, nil
+}
+
+func (s *GRPCServer) ListObjects(ctx context.Context, req *ssov1.ListObjectsRequest) (*ssov1.ListObjectsResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := authz.ParseSubject(req.GetSubject())
+	if err != nil {
+		return nil, grpcerr.FieldError("subject", err.Error())
+	}
+
+	ids, token, err := s.authzServ.ListObjects(ctx, claims.UserID, req.GetNamespace(), req.GetRelation(), subject, req.GetConsistencyToken())
+	if err != nil {
+		return nil, toStatus(err, "failed to list objects")
+	}
+
+	return &ssov1.ListObjectsResponse{ObjectIds: ids, ConsistencyToken: token}, nil
+}
+
+func parseTuples(field string, list []*ssov1.RelationTuple) ([]models.RelationTuple, error) {
+	tuples := make([]models.RelationTuple, 0, len(list))
+	for _, t := range list {
+		obj, err := authz.ParseObject(t.GetObject())
+		if err != nil {
+			return nil, grpcerr.FieldError(field, err.Error())
+		}
+		subject, err := authz.ParseSubject(t.GetSubject())
+		if err != nil {
+			return nil, grpcerr.FieldError(field, err.Error())
+		}
+		if t.GetRelation() == "" {
+			return nil, grpcerr.FieldError(field, "relation is required")
+		}
+		tuples = append(tuples, models.RelationTuple{Object: obj, Relation: t.GetRelation(), Subject: subject})
+	}
+	return tuples, nil
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, repository.ErrNamespaceNotFound):
+		return status.Error(codes.NotFound, "namespace not found")
+	case errors.Is(err, authz.ErrUnknownRelation):
+		return status.Error(codes.InvalidArgument, "unknown relation")
+	case errors.Is(err, authz.ErrInvalidConsistencyToken):
+		return status.Error(codes.InvalidArgument, "invalid consistency token")
+	case errors.Is(err, authz.ErrMaxDepth):
+		return status.Error(codes.ResourceExhausted, "relation graph is too deep")
+	default:
+		return grpcerr.Status(err, failMsg)
+	}
+}
+
+func toStruct(cfg models.NamespaceConfig) (*structpb.Struct, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return structpb.NewStruct(m)
+}
+
+func toExpandNode(node models.ExpandNode) *ssov1.ExpandNode {
+	res := &ssov1.ExpandNode{
+		Operation: node.Operation,
+		Object:    node.Object.String(),
+		Relation:  node.Relation,
+	}
+	for _, subject := range node.Subjects {
+		res.Subjects = append(res.Subjects, subject.String())
+	}
+	for _, child := range node.Children {
+		res.Children = append(res.Children, toExpandNode(child))
+	}
+	return res
+}
@@ -0,0 +1,257 @@
+package federationgrpc
+
+import (
+	"context"
+	"errors"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/federation"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+	"auth/pkg/requestmeta"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedFederationServer
+	federationServ FederationService
+	adminVerifier  authn.TokenVerifier
+	userVerifier   authn.TokenVerifier
+}
+
+type FederationService interface {
+	StartLogin(ctx context.Context, providerSlug string, appID int, orgID int64, redirectURI string) (string, error)
+	CompleteLogin(ctx context.Context, state, code, ip, userAgent string) (accessToken, refreshToken string, err error)
+	ListIdentities(ctx context.Context, userID int64) ([]models.UserIdentity, error)
+	UnlinkIdentity(ctx context.Context, userID int64, providerID int) error
+	CreateProvider(ctx context.Context, actorID int64, p models.IdentityProvider) (models.IdentityProvider, error)
+	ListProviders(ctx context.Context, actorID int64) ([]models.IdentityProvider, error)
+	SetProviderDisabled(ctx context.Context, actorID int64, providerID int, disabled bool) error
+	DeleteProvider(ctx context.Context, actorID int64, providerID int) error
+}
+
+// Register adds the service. Signing in needs no token; managing providers takes the admin
+// scope and managing one's own identities the profile scope.
+func Register(gRPCServer *grpc.Server, federationServ FederationService, verifier authn.TokenVerifier) {
+	ssov1.RegisterFederationServer(gRPCServer, &GRPCServer{
+		federationServ: federationServ,
+		adminVerifier:  authn.Scoped(verifier, models.ScopeAdmin),
+		userVerifier:   authn.Scoped(verifier, models.ScopeProfile),
+	})
+}
+
+func (s *GRPCServer) StartFederatedLogin(ctx context.Context, req *ssov1.StartFederatedLoginRequest) (*ssov1.StartFederatedLoginResponse, error) {
+	if req.GetProvider() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider is required")
+	}
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+	if req.GetRedirectUri() == "" {
+		return nil, status.Error(codes.InvalidArgument, "redirect_uri is required")
+	}
+
+	authURL, err := s.federationServ.StartLogin(ctx, req.GetProvider(), int(req.GetAppId()), req.GetOrgId(), req.GetRedirectUri())
+	if err != nil {
+		return nil, toStatus(err, "failed to start login")
+	}
+
+	return &ssov1.StartFederatedLoginResponse{AuthorizationUrl: authURL}, nil
+}
+
+func (s *GRPCServer) CompleteFederatedLogin(ctx context.Context, req *ssov1.CompleteFederatedLoginRequest) (*ssov1.TokenPairResponse, error) {
+	if req.GetState() == "" {
+		return nil, status.Error(codes.InvalidArgument, "state is required")
+	}
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	meta := requestmeta.FromContext(ctx)
+
+	access, refresh, err := s.federationServ.CompleteLogin(ctx, req.GetState(), req.GetCode(), meta.IP, meta.UserAgent)
+	if err != nil {
+		return nil, toStatus(err, "failed to login")
+	}
+
+	return &ssov1.TokenPairResponse{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+func (s *GRPCServer) CreateIdentityProvider(ctx context.Context, req *ssov1.CreateIdentityProviderRequest) (*ssov1.IdentityProvider, error) {
+	claims, err := authn.Authenticate(ctx, s.adminVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.federationServ.CreateProvider(ctx, claims.UserID, models.IdentityProvider{
+		Slug:                req.GetSlug(),
+		Name:                req.GetName(),
+		Issuer:              req.GetIssuer(),
+		ClientID:            req.GetClientId(),
+		ClientSecret:        req.GetClientSecret(),
+		Scopes:              req.GetScopes(),
+		AllowedEmailDomains: req.GetAllowedEmailDomains(),
+		JITProvisioning:     req.GetJitProvisioning(),
+		LinkByEmail:         req.GetLinkByEmail(),
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to create identity provider")
+	}
+
+	return toProvider(p), nil
+}
+
+func (s *GRPCServer) ListIdentityProviders(ctx context.Context, _ *ssov1.ListIdentityProvidersRequest) (*ssov1.ListIdentityProvidersResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.adminVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	providers, err := s.federationServ.ListProviders(ctx, claims.UserID)
+	if err != nil {
+		return nil, toStatus(err, "failed to list identity providers")
+	}
+
+	resp := &ssov1.ListIdentityProvidersResponse{Providers: make([]*ssov1.IdentityProvider, 0, len(providers))}
+	for _, p := range providers {
+		resp.Providers = append(resp.Providers, toProvider(p))
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) SetIdentityProviderDisabled(ctx context.Context, req *ssov1.SetIdentityProviderDisabledRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.adminVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := s.federationServ.SetProviderDisabled(ctx, claims.UserID, int(req.GetId()), req.GetDisabled()); err != nil {
+		return nil, toStatus(err, "failed to update identity provider")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) DeleteIdentityProvider(ctx context.Context, req *ssov1.DeleteIdentityProviderRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.adminVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := s.federationServ.DeleteProvider(ctx, claims.UserID, int(req.GetId())); err != nil {
+		return nil, toStatus(err, "failed to delete identity provider")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) ListIdentities(ctx context.Context, _ *ssov1.ListIdentitiesRequest) (*ssov1.ListIdentitiesResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.userVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := s.federationServ.ListIdentities(ctx, claims.UserID)
+	if err != nil {
+		return nil, toStatus(err, "failed to list identities")
+	}
+
+	resp := &ssov1.ListIdentitiesResponse{Identities: make([]*ssov1.UserIdentity, 0, len(identities))}
+	for _, i := range identities {
+		resp.Identities = append(resp.Identities, toIdentity(i))
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) UnlinkIdentity(ctx context.Context, req *ssov1.UnlinkIdentityRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.userVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetProviderId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+
+	if err := s.federationServ.UnlinkIdentity(ctx, claims.UserID, int(req.GetProviderId())); err != nil {
+		return nil, toStatus(err, "failed to unlink identity")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func toProvider(p models.IdentityProvider) *ssov1.IdentityProvider {
+	return &ssov1.IdentityProvider{
+		Id:                  int32(p.ID),
+		Slug:                p.Slug,
+		Name:                p.Name,
+		Issuer:              p.Issuer,
+		ClientId:            p.ClientID,
+		Scopes:              p.Scopes,
+		AllowedEmailDomains: p.AllowedEmailDomains,
+		JitProvisioning:     p.JITProvisioning,
+		LinkByEmail:         p.LinkByEmail,
+		Disabled:            p.Disabled,
+		CreatedAt:           timestamppb.New(p.CreatedAt),
+	}
+}
+
+func toIdentity(i models.UserIdentity) *ssov1.UserIdentity {
+	identity := &ssov1.UserIdentity{
+		ProviderId: int32(i.ProviderID),
+		Provider:   i.ProviderSlug,
+		Subject:    i.Subject,
+		Email:      i.Email,
+		CreatedAt:  timestamppb.New(i.CreatedAt),
+	}
+	if !i.LastLoginAt.IsZero() {
+		identity.LastLoginAt = timestamppb.New(i.LastLoginAt)
+	}
+	return identity
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, repository.ErrProviderNotFound):
+		return status.Error(codes.NotFound, "identity provider not found")
+	case errors.Is(err, repository.ErrProviderExists):
+		return status.Error(codes.AlreadyExists, "identity provider slug is taken")
+	case errors.Is(err, repository.ErrIdentityNotFound):
+		return status.Error(codes.NotFound, "identity not found")
+	case errors.Is(err, repository.ErrAppNotFound):
+		return status.Error(codes.NotFound, "app not found")
+	case errors.Is(err, federation.ErrProviderDisabled):
+		return status.Error(codes.FailedPrecondition, "identity provider is disabled")
+	case errors.Is(err, federation.ErrInvalidState):
+		return status.Error(codes.InvalidArgument, "login state is invalid or expired")
+	case errors.Is(err, federation.ErrUpstream):
+		return status.Error(codes.Unauthenticated, "identity provider did not complete the login")
+	case errors.Is(err, federation.ErrEmailDomain):
+		return status.Error(codes.PermissionDenied, "email domain is not allowed by the identity provider")
+	case errors.Is(err, federation.ErrAccountExists):
+		return status.Error(codes.AlreadyExists, "an account with this email already exists, sign in to it instead")
+	case errors.Is(err, federation.ErrNotLinked):
+		return status.Error(codes.PermissionDenied, "identity is not linked to an account")
+	case errors.Is(err, federation.ErrLastIdentity):
+		return status.Error(codes.FailedPrecondition, "identity is the only way to sign in, set a password first")
+	default:
+		return grpcerr.SignInStatus(err, "federated login", failMsg)
+	}
+}
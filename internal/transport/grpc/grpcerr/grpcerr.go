@@ -0,0 +1,68 @@
+// Package grpcerr maps the errors that several services return to gRPC statuses.
+package grpcerr
+
+import (
+	"errors"
+
+	"auth/internal/services/admin"
+	"auth/internal/services/auth"
+	"auth/internal/services/serviceerr"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Status maps invalid fields and missing permissions. Any other error becomes Internal with
+// failMsg, so servers map their own errors first and fall back to Status.
+func Status(err error, failMsg string) error {
+	var ferr *serviceerr.FieldError
+	switch {
+	case errors.As(err, &ferr):
+		return FieldError(ferr.Field, ferr.Reason)
+	case errors.Is(err, admin.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, "permission denied")
+	default:
+		return status.Error(codes.Internal, failMsg)
+	}
+}
+
+// SignInStatus maps the errors of starting a session for a user. method names the sign-in
+// method an app may not allow, such as "sso login".
+func SignInStatus(err error, method, failMsg string) error {
+	switch {
+	case errors.Is(err, auth.ErrInvitationRequired):
+		return status.Error(codes.PermissionDenied, "registration requires an invitation")
+	case errors.Is(err, auth.ErrUserDisabled):
+		return status.Error(codes.PermissionDenied, "user is disabled")
+	case errors.Is(err, auth.ErrPasswordReset):
+		return status.Error(codes.FailedPrecondition, "password reset required")
+	case errors.Is(err, auth.ErrAppDisabled):
+		return status.Error(codes.FailedPrecondition, "app is disabled")
+	case errors.Is(err, auth.ErrGrantNotAllowed):
+		return status.Error(codes.FailedPrecondition, "app does not allow "+method)
+	case errors.Is(err, auth.ErrNotOrgMember):
+		return status.Error(codes.PermissionDenied, "user is not a member of the organization")
+	case errors.Is(err, auth.ErrEmailDomain):
+		return status.Error(codes.PermissionDenied, "email domain is not allowed by the organization")
+	case errors.Is(err, auth.ErrMFARequired):
+		return status.Error(codes.FailedPrecondition, "organization requires multi-factor authentication")
+	default:
+		return Status(err, failMsg)
+	}
+}
+
+// FieldError is InvalidArgument with a BadRequest detail naming the field.
+func FieldError(field, reason string) error {
+	br := &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+		Field:       field,
+		Description: reason,
+	}}}
+
+	st, err := status.New(codes.InvalidArgument, "invalid "+field).WithDetails(br)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid "+field)
+	}
+
+	return st.Err()
+}
@@ -0,0 +1,386 @@
+package orgsgrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedOrgsServer
+	orgServ  OrgService
+	verifier authn.TokenVerifier
+}
+
+type OrgService interface {
+	CreateOrganization(ctx context.Context, actorID int64, org models.Organization) (models.Organization, error)
+	GetOrganization(ctx context.Context, actorID, orgID int64) (models.Organization, error)
+	ListMyOrganizations(ctx context.Context, actorID int64) ([]models.OrgMembership, error)
+	UpdateOrganization(ctx context.Context, actorID, orgID int64, upd models.OrganizationUpdate) (models.Organization, error)
+	DeleteOrganization(ctx context.Context, actorID, orgID int64) error
+	ListMembers(ctx context.Context, actorID, orgID int64) ([]models.Membership, error)
+	AddMember(ctx context.Context, actorID, orgID int64, email, role string) (models.Membership, error)
+	SetMemberRole(ctx context.Context, actorID, orgID, userID int64, role string) error
+	RemoveMember(ctx context.Context, actorID, orgID, userID int64) error
+	CreateInvitation(ctx context.Context, actorID, orgID int64, email, role string) (models.Invitation, string, error)
+	ListInvitations(ctx context.Context, actorID, orgID int64) ([]models.Invitation, error)
+	ResendInvitation(ctx context.Context, actorID, orgID, invitationID int64) (models.Invitation, string, error)
+	RevokeInvitation(ctx context.Context, actorID, orgID, invitationID int64) error
+}
+
+func Register(gRPCServer *grpc.Server, orgServ OrgService, verifier authn.TokenVerifier) {
+	ssov1.RegisterOrgsServer(gRPCServer, &GRPCServer{orgServ: orgServ, verifier: verifier})
+}
+
+func (s *GRPCServer) CreateOrganization(ctx context.Context, req *ssov1.CreateOrganizationRequest) (*ssov1.Organization, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.orgServ.CreateOrganization(ctx, claims.UserID, models.Organization{
+		Slug:                req.GetSlug(),
+		Name:                req.GetName(),
+		AllowedEmailDomains: req.GetAllowedEmailDomains(),
+		RequireMFA:          req.GetRequireMfa(),
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to create organization")
+	}
+
+	return toOrganization(org), nil
+}
+
+func (s *GRPCServer) GetOrganization(ctx context.Context, req *ssov1.GetOrganizationRequest) (*ssov1.Organization, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetOrgId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "org_id is required")
+	}
+
+	org, err := s.orgServ.GetOrganization(ctx, claims.UserID, req.GetOrgId())
+	if err != nil {
+		return nil, toStatus(err, "failed to get organization")
+	}
+
+	return toOrganization(org), nil
+}
+
+func (s *GRPCServer) ListMyOrganizations(ctx context.Context, _ *emptypb.Empty) (*ssov1.ListMyOrganizationsResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.orgServ.ListMyOrganizations(ctx, claims.UserID)
+	if err != nil {
+		return nil, toStatus(err, "failed to list organizations")
+	}
+
+	resp := &ssov1.ListMyOrganizationsResponse{}
+	for _, m := range list {
+		resp.Organizations = append(resp.Organizations, &ssov1.MyOrganization{
+			Organization: toOrganization(m.Organization),
+			Role:         m.Role,
+		})
+	}
+
+	return resp, nil
+}
+
+// UpdateOrganization changes only the fields named in update_mask.
+func (s *GRPCServer) UpdateOrganization(ctx context.Context, req *ssov1.UpdateOrganizationRequest) (*ssov1.Organization, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetOrgId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "org_id is required")
+	}
+	if req.GetOrganization() == nil || len(req.GetUpdateMask().GetPaths()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "organization and update_mask are required")
+	}
+
+	src := req.GetOrganization()
+	var upd models.OrganizationUpdate
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		switch path {
+		case "name":
+			upd.Name = &src.Name
+		case "allowed_email_domains":
+			upd.AllowedEmailDomains = src.AllowedEmailDomains
+			if upd.AllowedEmailDomains == nil {
+				upd.AllowedEmailDomains = []string{}
+			}
+		case "require_mfa":
+			upd.RequireMFA = &src.RequireMfa
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown update_mask path %q", path)
+		}
+	}
+
+	org, err := s.orgServ.UpdateOrganization(ctx, claims.UserID, req.GetOrgId(), upd)
+	if err != nil {
+		return nil, toStatus(err, "failed to update organization")
+	}
+
+	return toOrganization(org), nil
+}
+
+func (s *GRPCServer) DeleteOrganization(ctx context.Context, req *ssov1.DeleteOrganizationRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetOrgId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "org_id is required")
+	}
+
+	if err := s.orgServ.DeleteOrganization(ctx, claims.UserID, req.GetOrgId()); err != nil {
+		return nil, toStatus(err, "failed to delete organization")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) ListMembers(ctx context.Context, req *ssov1.ListMembersRequest) (*ssov1.ListMembersResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetOrgId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "org_id is required")
+	}
+
+	members, err := s.orgServ.ListMembers(ctx, claims.UserID, req.GetOrgId())
+	if err != nil {
+		return nil, toStatus(err, "failed to list members")
+	}
+
+	resp := &ssov1.ListMembersResponse{}
+	for _, m := range members {
+		resp.Members = append(resp.Members, toMember(m))
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) AddMember(ctx context.Context, req *ssov1.AddMemberRequest) (*ssov1.Member, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetOrgId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "org_id is required")
+	}
+	if req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	role := req.GetRole()
+	if role == "" {
+		role = models.OrgRoleMember
+	}
+
+	m, err := s.orgServ.AddMember(ctx, claims.UserID, req.GetOrgId(), req.GetEmail(), role)
+	if err != nil {
+		return nil, toStatus(err, "failed to add member")
+	}
+
+	return toMember(m), nil
+}
+
+func (s *GRPCServer) SetMemberRole(ctx context.Context, req *ssov1.SetMemberRoleRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetOrgId() == 0 || req.GetUserId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "org_id and user_id are required")
+	}
+
+	if err := s.orgServ.SetMemberRole(ctx, claims.UserID, req.GetOrgId(), req.GetUserId(), req.GetRole()); err != nil {
+		return nil, toStatus(err, "failed to set member role")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) RemoveMember(ctx context.Context, req *ssov1.RemoveMemberRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetOrgId() == 0 || req.GetUserId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "org_id and user_id are required")
+	}
+
+	if err := s.orgServ.RemoveMember(ctx, claims.UserID, req.GetOrgId(), req.GetUserId()); err != nil {
+		return nil, toStatus(err, "failed to remove member")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// CreateInvitation invites an email address and returns the link the invitee accepts with.
+func (s *GRPCServer) CreateInvitation(ctx context.Context, req *ssov1.CreateInvitationRequest) (*ssov1.InvitationLinkResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetOrgId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "org_id is required")
+	}
+	if req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	role := req.GetRole()
+	if role == "" {
+		role = models.OrgRoleMember
+	}
+
+	inv, link, err := s.orgServ.CreateInvitation(ctx, claims.UserID, req.GetOrgId(), req.GetEmail(), role)
+	if err != nil {
+		return nil, toStatus(err, "failed to create invitation")
+	}
+
+	return &ssov1.InvitationLinkResponse{Invitation: toInvitation(inv), Link: link}, nil
+}
+
+func (s *GRPCServer) ListInvitations(ctx context.Context, req *ssov1.ListInvitationsRequest) (*ssov1.ListInvitationsResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetOrgId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "org_id is required")
+	}
+
+	invitations, err := s.orgServ.ListInvitations(ctx, claims.UserID, req.GetOrgId())
+	if err != nil {
+		return nil, toStatus(err, "failed to list invitations")
+	}
+
+	resp := &ssov1.ListInvitationsResponse{}
+	for _, inv := range invitations {
+		resp.Invitations = append(resp.Invitations, toInvitation(inv))
+	}
+
+	return resp, nil
+}
+
+// ResendInvitation extends an open invitation and returns a fresh link; earlier links stop working.
+func (s *GRPCServer) ResendInvitation(ctx context.Context, req *ssov1.ResendInvitationRequest) (*ssov1.InvitationLinkResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetOrgId() == 0 || req.GetInvitationId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "org_id and invitation_id are required")
+	}
+
+	inv, link, err := s.orgServ.ResendInvitation(ctx, claims.UserID, req.GetOrgId(), req.GetInvitationId())
+	if err != nil {
+		return nil, toStatus(err, "failed to resend invitation")
+	}
+
+	return &ssov1.InvitationLinkResponse{Invitation: toInvitation(inv), Link: link}, nil
+}
+
+func (s *GRPCServer) RevokeInvitation(ctx context.Context, req *ssov1.RevokeInvitationRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetOrgId() == 0 || req.GetInvitationId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "org_id and invitation_id are required")
+	}
+
+	if err := s.orgServ.RevokeInvitation(ctx, claims.UserID, req.GetOrgId(), req.GetInvitationId()); err != nil {
+		return nil, toStatus(err, "failed to revoke invitation")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func toOrganization(org models.Organization) *ssov1.Organization {
+	return &ssov1.Organization{
+		Id:                  org.ID,
+		Slug:                org.Slug,
+		Name:                org.Name,
+		AllowedEmailDomains: org.AllowedEmailDomains,
+		RequireMfa:          org.RequireMFA,
+		CreatedAt:           timestamppb.New(org.CreatedAt),
+	}
+}
+
+func toMember(m models.Membership) *ssov1.Member {
+	return &ssov1.Member{
+		UserId:    m.UserID,
+		Email:     m.Email,
+		Role:      m.Role,
+		CreatedAt: timestamppb.New(m.CreatedAt),
+	}
+}
+
+func toInvitation(inv models.Invitation) *ssov1.Invitation {
+	return &ssov1.Invitation{
+		Id:        inv.ID,
+		OrgId:     inv.OrgID,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		Status:    inv.Status(time.Now()),
+		InvitedBy: inv.InvitedBy,
+		ExpiresAt: timestamppb.New(inv.ExpiresAt),
+		CreatedAt: timestamppb.New(inv.CreatedAt),
+	}
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, repository.ErrOrgNotFound):
+		return status.Error(codes.NotFound, "organization not found")
+	case errors.Is(err, repository.ErrOrgExists):
+		return status.Error(codes.AlreadyExists, "organization slug is taken")
+	case errors.Is(err, repository.ErrMembershipNotFound):
+		return status.Error(codes.NotFound, "membership not found")
+	case errors.Is(err, repository.ErrMembershipExists):
+		return status.Error(codes.AlreadyExists, "user is already a member")
+	case errors.Is(err, repository.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, repository.ErrInvitationNotFound):
+		return status.Error(codes.NotFound, "open invitation not found")
+	case errors.Is(err, repository.ErrInvitationExists):
+		return status.Error(codes.AlreadyExists, "an open invitation for this email exists, resend it instead")
+	case errors.Is(err, repository.ErrLastOwner):
+		return status.Error(codes.FailedPrecondition, "organization must keep at least one owner")
+	default:
+		return grpcerr.Status(err, failMsg)
+	}
+}
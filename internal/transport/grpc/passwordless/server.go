@@ -0,0 +1,89 @@
+package passwordlessgrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/repository"
+	"auth/internal/services/passwordless"
+	"auth/internal/transport/grpc/grpcerr"
+	"auth/pkg/requestmeta"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedPasswordlessServer
+	passwordlessServ PasswordlessService
+}
+
+type PasswordlessService interface {
+	StartPasswordless(ctx context.Context, email string, appID int, orgID int64, method string) (challengeID string, expiresAt time.Time, err error)
+	CompletePasswordless(ctx context.Context, challengeID, secret, ip, userAgent string) (accessToken, refreshToken string, err error)
+}
+
+// Register adds the service. Both calls are made before the user has a token.
+func Register(gRPCServer *grpc.Server, passwordlessServ PasswordlessService) {
+	ssov1.RegisterPasswordlessServer(gRPCServer, &GRPCServer{passwordlessServ: passwordlessServ})
+}
+
+func (s *GRPCServer) StartPasswordless(ctx context.Context, req *ssov1.StartPasswordlessRequest) (*ssov1.StartPasswordlessResponse, error) {
+	if req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+	if req.GetMethod() == "" {
+		return nil, status.Error(codes.InvalidArgument, "method is required")
+	}
+
+	challengeID, expiresAt, err := s.passwordlessServ.StartPasswordless(ctx, req.GetEmail(), int(req.GetAppId()), req.GetOrgId(), req.GetMethod())
+	if err != nil {
+		return nil, toStatus(err, "failed to start login")
+	}
+
+	return &ssov1.StartPasswordlessResponse{ChallengeId: challengeID, ExpiresAt: timestamppb.New(expiresAt)}, nil
+}
+
+func (s *GRPCServer) CompletePasswordless(ctx context.Context, req *ssov1.CompletePasswordlessRequest) (*ssov1.TokenPairResponse, error) {
+	if req.GetChallengeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "challenge_id is required")
+	}
+	if req.GetSecret() == "" {
+		return nil, status.Error(codes.InvalidArgument, "secret is required")
+	}
+
+	meta := requestmeta.FromContext(ctx)
+
+	access, refresh, err := s.passwordlessServ.CompletePasswordless(ctx, req.GetChallengeId(), req.GetSecret(), meta.IP, meta.UserAgent)
+	if err != nil {
+		return nil, toStatus(err, "failed to login")
+	}
+
+	return &ssov1.TokenPairResponse{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, passwordless.ErrUnknownMethod):
+		return status.Error(codes.InvalidArgument, "method must be link or code")
+	case errors.Is(err, passwordless.ErrInvalidEmail):
+		return status.Error(codes.InvalidArgument, "invalid email")
+	case errors.Is(err, passwordless.ErrInvalidChallenge):
+		return status.Error(codes.InvalidArgument, "login is invalid or expired")
+	case errors.Is(err, passwordless.ErrInvalidSecret):
+		return status.Error(codes.Unauthenticated, "invalid code or link")
+	case errors.Is(err, passwordless.ErrTooManyAttempts):
+		return status.Error(codes.ResourceExhausted, "too many attempts, start a new login")
+	case errors.Is(err, repository.ErrAppNotFound):
+		return status.Error(codes.InvalidArgument, "unknown app_id")
+	default:
+		return grpcerr.SignInStatus(err, "passwordless login", failMsg)
+	}
+}
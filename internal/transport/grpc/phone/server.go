@@ -0,0 +1,180 @@
+package phonegrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/repository"
+	"auth/internal/services/auth"
+	"auth/internal/services/phone"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+	"auth/pkg/requestmeta"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedPhoneServer
+	phoneServ PhoneService
+	verifier  authn.TokenVerifier
+}
+
+type PhoneService interface {
+	StartPhoneLogin(ctx context.Context, phone string, appID int, orgID int64) (challengeID string, expiresAt time.Time, err error)
+	CompletePhoneLogin(ctx context.Context, challengeID, code, ip, userAgent string) (accessToken, refreshToken string, err error)
+	StartPhoneVerification(ctx context.Context, userID int64, phone string) (challengeID string, expiresAt time.Time, err error)
+	ConfirmPhoneVerification(ctx context.Context, userID int64, challengeID, code string) (phone string, err error)
+	StartStepUp(ctx context.Context, userID int64) (challengeID string, expiresAt time.Time, err error)
+	CompleteStepUp(ctx context.Context, userID int64, refreshToken, challengeID, code string) (accessToken, newRefreshToken string, err error)
+}
+
+// Register adds the service. Logins are made before the user has a token; verifying a number
+// and stepping up take one the verifier accepts.
+func Register(gRPCServer *grpc.Server, phoneServ PhoneService, verifier authn.TokenVerifier) {
+	ssov1.RegisterPhoneServer(gRPCServer, &GRPCServer{phoneServ: phoneServ, verifier: verifier})
+}
+
+func (s *GRPCServer) StartPhoneLogin(ctx context.Context, req *ssov1.StartPhoneLoginRequest) (*ssov1.PhoneChallengeResponse, error) {
+	if req.GetPhone() == "" {
+		return nil, status.Error(codes.InvalidArgument, "phone is required")
+	}
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	challengeID, expiresAt, err := s.phoneServ.StartPhoneLogin(ctx, req.GetPhone(), int(req.GetAppId()), req.GetOrgId())
+	if err != nil {
+		return nil, toStatus(err, "failed to start login")
+	}
+
+	return &ssov1.PhoneChallengeResponse{ChallengeId: challengeID, ExpiresAt: timestamppb.New(expiresAt)}, nil
+}
+
+func (s *GRPCServer) CompletePhoneLogin(ctx context.Context, req *ssov1.CompletePhoneLoginRequest) (*ssov1.TokenPairResponse, error) {
+	if req.GetChallengeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "challenge_id is required")
+	}
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	meta := requestmeta.FromContext(ctx)
+
+	access, refresh, err := s.phoneServ.CompletePhoneLogin(ctx, req.GetChallengeId(), req.GetCode(), meta.IP, meta.UserAgent)
+	if err != nil {
+		return nil, toStatus(err, "failed to login")
+	}
+
+	return &ssov1.TokenPairResponse{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+func (s *GRPCServer) StartPhoneVerification(ctx context.Context, req *ssov1.StartPhoneVerificationRequest) (*ssov1.PhoneChallengeResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetPhone() == "" {
+		return nil, status.Error(codes.InvalidArgument, "phone is required")
+	}
+
+	challengeID, expiresAt, err := s.phoneServ.StartPhoneVerification(ctx, claims.UserID, req.GetPhone())
+	if err != nil {
+		return nil, toStatus(err, "failed to start verification")
+	}
+
+	return &ssov1.PhoneChallengeResponse{ChallengeId: challengeID, ExpiresAt: timestamppb.New(expiresAt)}, nil
+}
+
+func (s *GRPCServer) ConfirmPhoneVerification(ctx context.Context, req *ssov1.ConfirmPhoneVerificationRequest) (*ssov1.ConfirmPhoneVerificationResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetChallengeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "challenge_id is required")
+	}
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	phone, err := s.phoneServ.ConfirmPhoneVerification(ctx, claims.UserID, req.GetChallengeId(), req.GetCode())
+	if err != nil {
+		return nil, toStatus(err, "failed to verify phone")
+	}
+
+	return &ssov1.ConfirmPhoneVerificationResponse{Phone: phone}, nil
+}
+
+func (s *GRPCServer) StartStepUp(ctx context.Context, _ *ssov1.StartPhoneStepUpRequest) (*ssov1.PhoneChallengeResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	challengeID, expiresAt, err := s.phoneServ.StartStepUp(ctx, claims.UserID)
+	if err != nil {
+		return nil, toStatus(err, "failed to start step-up")
+	}
+
+	return &ssov1.PhoneChallengeResponse{ChallengeId: challengeID, ExpiresAt: timestamppb.New(expiresAt)}, nil
+}
+
+func (s *GRPCServer) CompleteStepUp(ctx context.Context, req *ssov1.CompletePhoneStepUpRequest) (*ssov1.TokenPairResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh_token is required")
+	}
+	if req.GetChallengeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "challenge_id is required")
+	}
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	access, refresh, err := s.phoneServ.CompleteStepUp(ctx, claims.UserID, req.GetRefreshToken(), req.GetChallengeId(), req.GetCode())
+	if err != nil {
+		return nil, toStatus(err, "failed to step up")
+	}
+
+	return &ssov1.TokenPairResponse{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, phone.ErrInvalidPhone):
+		return status.Error(codes.InvalidArgument, "phone must be in E.164 format")
+	case errors.Is(err, phone.ErrInvalidChallenge):
+		return status.Error(codes.InvalidArgument, "code is invalid or expired")
+	case errors.Is(err, phone.ErrInvalidCode):
+		return status.Error(codes.Unauthenticated, "invalid code")
+	case errors.Is(err, phone.ErrTooManyAttempts):
+		return status.Error(codes.ResourceExhausted, "too many attempts, request a new code")
+	case errors.Is(err, phone.ErrRateLimited):
+		return status.Error(codes.ResourceExhausted, "too many codes sent, try again later")
+	case errors.Is(err, phone.ErrNoVerifiedPhone):
+		return status.Error(codes.FailedPrecondition, "user has no verified phone number")
+	case errors.Is(err, auth.ErrInvalidToken):
+		return status.Error(codes.InvalidArgument, "refresh token belongs to another user")
+	case errors.Is(err, auth.ErrSessionExpired):
+		return status.Error(codes.Unauthenticated, "session expired")
+	case errors.Is(err, auth.ErrFactorUsed):
+		return status.Error(codes.FailedPrecondition, "session is already authenticated with a texted code")
+	case errors.Is(err, repository.ErrPhoneTaken):
+		return status.Error(codes.AlreadyExists, "phone number belongs to another user")
+	case errors.Is(err, repository.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, repository.ErrAppNotFound):
+		return status.Error(codes.InvalidArgument, "unknown app_id")
+	default:
+		return grpcerr.SignInStatus(err, "phone login", failMsg)
+	}
+}
@@ -0,0 +1,102 @@
+package profilegrpc
+
+import (
+	"context"
+	"errors"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedProfileServer
+	profileServ ProfileService
+	verifier    authn.TokenVerifier
+}
+
+type ProfileService interface {
+	GetProfile(ctx context.Context, userID int64) (models.Profile, error)
+	UpdateProfile(ctx context.Context, userID int64, upd models.ProfileUpdate) (models.Profile, error)
+}
+
+func Register(gRPCServer *grpc.Server, profileServ ProfileService, verifier authn.TokenVerifier) {
+	ssov1.RegisterProfileServer(gRPCServer, &GRPCServer{profileServ: profileServ, verifier: verifier})
+}
+
+func (s *GRPCServer) GetProfile(ctx context.Context, req *ssov1.GetProfileRequest) (*ssov1.ProfileResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.profileServ.GetProfile(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to get profile")
+	}
+
+	return toResponse(p)
+}
+
+func (s *GRPCServer) UpdateProfile(ctx context.Context, req *ssov1.UpdateProfileRequest) (*ssov1.ProfileResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	upd := models.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		GivenName:   req.GivenName,
+		FamilyName:  req.FamilyName,
+		Locale:      req.Locale,
+		Timezone:    req.Timezone,
+		Phone:       req.Phone,
+	}
+	if req.UserMetadata != nil {
+		upd.UserMetadata = req.UserMetadata.AsMap()
+	}
+
+	p, err := s.profileServ.UpdateProfile(ctx, claims.UserID, upd)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, grpcerr.Status(err, "failed to update profile")
+	}
+
+	return toResponse(p)
+}
+
+func toResponse(p models.Profile) (*ssov1.ProfileResponse, error) {
+	userMetadata, err := structpb.NewStruct(p.UserMetadata)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to encode user_metadata")
+	}
+	appMetadata, err := structpb.NewStruct(p.AppMetadata)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to encode app_metadata")
+	}
+
+	return &ssov1.ProfileResponse{
+		UserId:       p.UserID,
+		Email:        p.Email,
+		DisplayName:  p.DisplayName,
+		GivenName:    p.GivenName,
+		FamilyName:   p.FamilyName,
+		Locale:       p.Locale,
+		Timezone:     p.Timezone,
+		Phone:        p.Phone,
+		UserMetadata: userMetadata,
+		AppMetadata:  appMetadata,
+	}, nil
+}
@@ -0,0 +1,301 @@
+package rbacgrpc
+
+import (
+	"context"
+	"errors"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedRBACServer
+	rbacServ RBACService
+	verifier authn.TokenVerifier
+}
+
+type RBACService interface {
+	CreateRole(ctx context.Context, actorID int64, role models.Role) (models.Role, error)
+	ListRoles(ctx context.Context, actorID int64, appID int) ([]models.Role, error)
+	DeleteRole(ctx context.Context, actorID, roleID int64) error
+	SetRolePermissions(ctx context.Context, actorID, roleID int64, permissions []string) (models.Role, error)
+	CreatePermission(ctx context.Context, actorID int64, perm models.Permission) (models.Permission, error)
+	ListPermissions(ctx context.Context, actorID int64, appID int) ([]models.Permission, error)
+	DeletePermission(ctx context.Context, actorID, permissionID int64) error
+	AssignRole(ctx context.Context, actorID, userID, roleID int64) error
+	RevokeRole(ctx context.Context, actorID, userID, roleID int64) error
+	ListUserRoles(ctx context.Context, actorID, userID int64, appID int) ([]models.Role, error)
+	GetUserAuthorization(ctx context.Context, actorID, userID int64, appID int) (models.Authorization, error)
+}
+
+func Register(gRPCServer *grpc.Server, rbacServ RBACService, verifier authn.TokenVerifier) {
+	ssov1.RegisterRBACServer(gRPCServer, &GRPCServer{rbacServ: rbacServ, verifier: verifier})
+}
+
+func (s *GRPCServer) CreateRole(ctx context.Context, req *ssov1.CreateRoleRequest) (*ssov1.Role, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	role, err := s.rbacServ.CreateRole(ctx, claims.UserID, models.Role{
+		AppID:       int(req.GetAppId()),
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to create role")
+	}
+
+	return toRole(role), nil
+}
+
+func (s *GRPCServer) ListRoles(ctx context.Context, req *ssov1.ListRolesRequest) (*ssov1.ListRolesResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	roles, err := s.rbacServ.ListRoles(ctx, claims.UserID, int(req.GetAppId()))
+	if err != nil {
+		return nil, toStatus(err, "failed to list roles")
+	}
+
+	return &ssov1.ListRolesResponse{Roles: toRoles(roles)}, nil
+}
+
+func (s *GRPCServer) DeleteRole(ctx context.Context, req *ssov1.DeleteRoleRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetRoleId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "role_id is required")
+	}
+
+	if err := s.rbacServ.DeleteRole(ctx, claims.UserID, req.GetRoleId()); err != nil {
+		return nil, toStatus(err, "failed to delete role")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// SetRolePermissions replaces the permissions of the role with the given names.
+func (s *GRPCServer) SetRolePermissions(ctx context.Context, req *ssov1.SetRolePermissionsRequest) (*ssov1.Role, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetRoleId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "role_id is required")
+	}
+
+	role, err := s.rbacServ.SetRolePermissions(ctx, claims.UserID, req.GetRoleId(), req.GetPermissions())
+	if err != nil {
+		return nil, toStatus(err, "failed to set role permissions")
+	}
+
+	return toRole(role), nil
+}
+
+func (s *GRPCServer) CreatePermission(ctx context.Context, req *ssov1.CreatePermissionRequest) (*ssov1.Permission, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	perm, err := s.rbacServ.CreatePermission(ctx, claims.UserID, models.Permission{
+		AppID:       int(req.GetAppId()),
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to create permission")
+	}
+
+	return toPermission(perm), nil
+}
+
+func (s *GRPCServer) ListPermissions(ctx context.Context, req *ssov1.ListPermissionsRequest) (*ssov1.ListPermissionsResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	perms, err := s.rbacServ.ListPermissions(ctx, claims.UserID, int(req.GetAppId()))
+	if err != nil {
+		return nil, toStatus(err, "failed to list permissions")
+	}
+
+	resp := &ssov1.ListPermissionsResponse{}
+	for _, perm := range perms {
+		resp.Permissions = append(resp.Permissions, toPermission(perm))
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) DeletePermission(ctx context.Context, req *ssov1.DeletePermissionRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetPermissionId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "permission_id is required")
+	}
+
+	if err := s.rbacServ.DeletePermission(ctx, claims.UserID, req.GetPermissionId()); err != nil {
+		return nil, toStatus(err, "failed to delete permission")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) AssignRole(ctx context.Context, req *ssov1.AssignRoleRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetUserId() == 0 || req.GetRoleId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id and role_id are required")
+	}
+
+	if err := s.rbacServ.AssignRole(ctx, claims.UserID, req.GetUserId(), req.GetRoleId()); err != nil {
+		return nil, toStatus(err, "failed to assign role")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) RevokeRole(ctx context.Context, req *ssov1.RevokeRoleRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetUserId() == 0 || req.GetRoleId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id and role_id are required")
+	}
+
+	if err := s.rbacServ.RevokeRole(ctx, claims.UserID, req.GetUserId(), req.GetRoleId()); err != nil {
+		return nil, toStatus(err, "failed to revoke role")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) ListUserRoles(ctx context.Context, req *ssov1.ListUserRolesRequest) (*ssov1.ListUserRolesResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetUserId() == 0 || req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id and app_id are required")
+	}
+
+	roles, err := s.rbacServ.ListUserRoles(ctx, claims.UserID, req.GetUserId(), int(req.GetAppId()))
+	if err != nil {
+		return nil, toStatus(err, "failed to list user roles")
+	}
+
+	return &ssov1.ListUserRolesResponse{Roles: toRoles(roles)}, nil
+}
+
+// GetUserAuthorization defaults to the caller and the app of the caller's token.
+func (s *GRPCServer) GetUserAuthorization(ctx context.Context, req *ssov1.GetUserAuthorizationRequest) (*ssov1.GetUserAuthorizationResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	userID := req.GetUserId()
+	if userID == 0 {
+		userID = claims.UserID
+	}
+	appID := int(req.GetAppId())
+	if appID == 0 {
+		appID = claims.AppID
+	}
+
+	authz, err := s.rbacServ.GetUserAuthorization(ctx, claims.UserID, userID, appID)
+	if err != nil {
+		return nil, toStatus(err, "failed to get user authorization")
+	}
+
+	return &ssov1.GetUserAuthorizationResponse{Roles: authz.Roles, Permissions: authz.Permissions}, nil
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, repository.ErrAppNotFound):
+		return status.Error(codes.NotFound, "app not found")
+	case errors.Is(err, repository.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, repository.ErrRoleNotFound):
+		return status.Error(codes.NotFound, "role not found")
+	case errors.Is(err, repository.ErrPermissionNotFound):
+		return status.Error(codes.NotFound, "permission not found")
+	case errors.Is(err, repository.ErrRoleExists):
+		return status.Error(codes.AlreadyExists, "role already exists")
+	case errors.Is(err, repository.ErrPermissionExists):
+		return status.Error(codes.AlreadyExists, "permission already exists")
+	default:
+		return grpcerr.Status(err, failMsg)
+	}
+}
+
+func toRole(role models.Role) *ssov1.Role {
+	return &ssov1.Role{
+		Id:          role.ID,
+		AppId:       int32(role.AppID),
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+	}
+}
+
+func toRoles(roles []models.Role) []*ssov1.Role {
+	res := make([]*ssov1.Role, 0, len(roles))
+	for _, role := range roles {
+		res = append(res, toRole(role))
+	}
+	return res
+}
+
+func toPermission(perm models.Permission) *ssov1.Permission {
+	return &ssov1.Permission{
+		Id:          perm.ID,
+		AppId:       int32(perm.AppID),
+		Name:        perm.Name,
+		Description: perm.Description,
+	}
+}
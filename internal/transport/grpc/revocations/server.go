@@ -0,0 +1,83 @@
+package revocationsgrpc
+
+import (
+	"context"
+	"errors"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/transport/grpc/authn"
+	"auth/pkg/jwt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedRevocationsServer
+	revocationServ RevocationService
+	verifier       authn.TokenVerifier
+}
+
+type RevocationService interface {
+	Watch(ctx context.Context, caller *jwt.Claims, offset string, send func(models.Revocation) error) error
+}
+
+// Register adds the service. Unlike the other APIs it is meant for service accounts, so the
+// verifier must not turn their tokens away.
+func Register(gRPCServer *grpc.Server, revocationServ RevocationService, verifier authn.TokenVerifier) {
+	ssov1.RegisterRevocationsServer(gRPCServer, &GRPCServer{revocationServ: revocationServ, verifier: verifier})
+}
+
+// WatchRevocations streams revocations as they happen. Each one carries its offset; a client
+// that reconnects with the last offset it saw misses nothing, unless the offset has aged out of
+// the feed, which is reported as OUT_OF_RANGE.
+func (s *GRPCServer) WatchRevocations(req *ssov1.WatchRevocationsRequest, stream ssov1.Revocations_WatchRevocationsServer) error {
+	ctx := stream.Context()
+
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return err
+	}
+	if !claims.IsServiceAccount() && !claims.HasScope(models.ScopeAdmin) {
+		return status.Error(codes.PermissionDenied, "access token lacks the required scope")
+	}
+
+	err = s.revocationServ.Watch(ctx, claims, req.GetOffset(), func(r models.Revocation) error {
+		return stream.Send(toRevocation(r))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, admin.ErrPermissionDenied):
+			return status.Error(codes.PermissionDenied, "permission denied")
+		case errors.Is(err, repository.ErrInvalidOffset):
+			return status.Error(codes.InvalidArgument, "invalid offset")
+		case errors.Is(err, repository.ErrOffsetExpired):
+			return status.Error(codes.OutOfRange, "offset is no longer available, resync and watch from the latest")
+		case ctx.Err() != nil:
+			return status.FromContextError(ctx.Err()).Err()
+		default:
+			return status.Error(codes.Internal, "failed to watch revocations")
+		}
+	}
+
+	return nil
+}
+
+func toRevocation(r models.Revocation) *ssov1.Revocation {
+	return &ssov1.Revocation{
+		Offset:           r.Offset,
+		Kind:             r.Kind,
+		UserId:           r.UserID,
+		AppId:            int32(r.AppID),
+		ServiceAccountId: r.ServiceAccountID,
+		SessionId:        r.SessionID,
+		TokenId:          r.TokenID,
+		KeyId:            r.KeyID,
+		RevokedAt:        timestamppb.New(r.RevokedAt),
+	}
+}
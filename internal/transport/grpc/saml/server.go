@@ -0,0 +1,194 @@
+package samlgrpc
+
+import (
+	"context"
+	"errors"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/samlidp"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedSAMLServer
+	samlServ      SAMLService
+	adminVerifier authn.TokenVerifier
+	userVerifier  authn.TokenVerifier
+}
+
+type SAMLService interface {
+	CompleteLogin(ctx context.Context, userID int64, sessionID, loginID string) (models.SAMLPost, error)
+	StartIdPInitiated(ctx context.Context, userID int64, sessionID string, spID int, relayState string) (models.SAMLPost, error)
+	CreateServiceProvider(ctx context.Context, actorID int64, sp models.ServiceProvider) (models.ServiceProvider, error)
+	ListServiceProviders(ctx context.Context, actorID int64) ([]models.ServiceProvider, error)
+	SetServiceProviderDisabled(ctx context.Context, actorID int64, spID int, disabled bool) error
+	DeleteServiceProvider(ctx context.Context, actorID int64, spID int) error
+}
+
+// Register adds the service. The login page answers SAML requests with the profile scope of the
+// signed-in user; managing service providers takes the admin scope.
+func Register(gRPCServer *grpc.Server, samlServ SAMLService, verifier authn.TokenVerifier) {
+	ssov1.RegisterSAMLServer(gRPCServer, &GRPCServer{
+		samlServ:      samlServ,
+		adminVerifier: authn.Scoped(verifier, models.ScopeAdmin),
+		userVerifier:  authn.Scoped(verifier, models.ScopeProfile),
+	})
+}
+
+func (s *GRPCServer) CompleteSAMLLogin(ctx context.Context, req *ssov1.CompleteSAMLLoginRequest) (*ssov1.SAMLPost, error) {
+	claims, err := authn.Authenticate(ctx, s.userVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetRequestId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "request_id is required")
+	}
+
+	post, err := s.samlServ.CompleteLogin(ctx, claims.UserID, claims.SessionID, req.GetRequestId())
+	if err != nil {
+		return nil, toStatus(err, "failed to complete sign-in")
+	}
+
+	return toPost(post), nil
+}
+
+func (s *GRPCServer) StartSAMLLogin(ctx context.Context, req *ssov1.StartSAMLLoginRequest) (*ssov1.SAMLPost, error) {
+	claims, err := authn.Authenticate(ctx, s.userVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetServiceProviderId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "service_provider_id is required")
+	}
+
+	post, err := s.samlServ.StartIdPInitiated(ctx, claims.UserID, claims.SessionID, int(req.GetServiceProviderId()), req.GetRelayState())
+	if err != nil {
+		return nil, toStatus(err, "failed to start sign-in")
+	}
+
+	return toPost(post), nil
+}
+
+func (s *GRPCServer) CreateSAMLServiceProvider(ctx context.Context, req *ssov1.CreateSAMLServiceProviderRequest) (*ssov1.SAMLServiceProvider, error) {
+	claims, err := authn.Authenticate(ctx, s.adminVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	sp, err := s.samlServ.CreateServiceProvider(ctx, claims.UserID, models.ServiceProvider{
+		AppID:        int(req.GetAppId()),
+		EntityID:     req.GetEntityId(),
+		Name:         req.GetName(),
+		ACSURL:       req.GetAcsUrl(),
+		SLOURL:       req.GetSloUrl(),
+		Certificate:  req.GetCertificate(),
+		NameIDFormat: req.GetNameIdFormat(),
+		AttributeMap: req.GetAttributeMap(),
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to create service provider")
+	}
+
+	return toServiceProvider(sp), nil
+}
+
+func (s *GRPCServer) ListSAMLServiceProviders(ctx context.Context, _ *ssov1.ListSAMLServiceProvidersRequest) (*ssov1.ListSAMLServiceProvidersResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.adminVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	sps, err := s.samlServ.ListServiceProviders(ctx, claims.UserID)
+	if err != nil {
+		return nil, toStatus(err, "failed to list service providers")
+	}
+
+	resp := &ssov1.ListSAMLServiceProvidersResponse{ServiceProviders: make([]*ssov1.SAMLServiceProvider, 0, len(sps))}
+	for _, sp := range sps {
+		resp.ServiceProviders = append(resp.ServiceProviders, toServiceProvider(sp))
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) SetSAMLServiceProviderDisabled(ctx context.Context, req *ssov1.SetSAMLServiceProviderDisabledRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.adminVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := s.samlServ.SetServiceProviderDisabled(ctx, claims.UserID, int(req.GetId()), req.GetDisabled()); err != nil {
+		return nil, toStatus(err, "failed to update service provider")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) DeleteSAMLServiceProvider(ctx context.Context, req *ssov1.DeleteSAMLServiceProviderRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.adminVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := s.samlServ.DeleteServiceProvider(ctx, claims.UserID, int(req.GetId())); err != nil {
+		return nil, toStatus(err, "failed to delete service provider")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func toPost(p models.SAMLPost) *ssov1.SAMLPost {
+	return &ssov1.SAMLPost{AcsUrl: p.ACSURL, SamlResponse: p.SAMLResponse, RelayState: p.RelayState}
+}
+
+func toServiceProvider(sp models.ServiceProvider) *ssov1.SAMLServiceProvider {
+	return &ssov1.SAMLServiceProvider{
+		Id:           int32(sp.ID),
+		AppId:        int32(sp.AppID),
+		EntityId:     sp.EntityID,
+		Name:         sp.Name,
+		AcsUrl:       sp.ACSURL,
+		SloUrl:       sp.SLOURL,
+		Certificate:  sp.Certificate,
+		NameIdFormat: sp.NameIDFormat,
+		AttributeMap: sp.AttributeMap,
+		Disabled:     sp.Disabled,
+		CreatedAt:    timestamppb.New(sp.CreatedAt),
+	}
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, repository.ErrServiceProviderNotFound):
+		return status.Error(codes.NotFound, "service provider not found")
+	case errors.Is(err, repository.ErrServiceProviderExists):
+		return status.Error(codes.AlreadyExists, "service provider entity id is taken")
+	case errors.Is(err, repository.ErrAppNotFound):
+		return status.Error(codes.NotFound, "app not found")
+	case errors.Is(err, samlidp.ErrServiceProviderDisabled):
+		return status.Error(codes.FailedPrecondition, "service provider is disabled")
+	case errors.Is(err, samlidp.ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, "saml request is invalid or expired")
+	default:
+		return grpcerr.SignInStatus(err, "saml sign-in", failMsg)
+	}
+}
@@ -0,0 +1,304 @@
+package serviceaccountsgrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/auth"
+	"auth/internal/services/serviceaccounts"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedServiceAccountsServer
+	saServ   ServiceAccountService
+	verifier authn.TokenVerifier
+}
+
+type ServiceAccountService interface {
+	CreateServiceAccount(ctx context.Context, actorID int64, sa models.ServiceAccount) (models.ServiceAccount, error)
+	GetServiceAccount(ctx context.Context, actorID, id int64) (models.ServiceAccount, models.Authorization, error)
+	ListServiceAccounts(ctx context.Context, actorID int64, appID int) ([]models.ServiceAccount, error)
+	SetDisabled(ctx context.Context, actorID, id int64, disabled bool) error
+	DeleteServiceAccount(ctx context.Context, actorID, id int64) error
+	AddKey(ctx context.Context, actorID, id int64, publicKey string, expiresAt time.Time) (models.ServiceAccountKey, error)
+	ListKeys(ctx context.Context, actorID, id int64) ([]models.ServiceAccountKey, error)
+	RevokeKey(ctx context.Context, actorID, id int64, keyID string) error
+	AssignRole(ctx context.Context, actorID, id, roleID int64) error
+	RevokeRole(ctx context.Context, actorID, id, roleID int64) error
+	ExchangeAssertion(ctx context.Context, assertion string) (string, time.Duration, error)
+}
+
+func Register(gRPCServer *grpc.Server, saServ ServiceAccountService, verifier authn.TokenVerifier) {
+	ssov1.RegisterServiceAccountsServer(gRPCServer, &GRPCServer{saServ: saServ, verifier: verifier})
+}
+
+func (s *GRPCServer) CreateServiceAccount(ctx context.Context, req *ssov1.CreateServiceAccountRequest) (*ssov1.ServiceAccount, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	sa, err := s.saServ.CreateServiceAccount(ctx, claims.UserID, models.ServiceAccount{
+		AppID:       int(req.GetAppId()),
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to create service account")
+	}
+
+	return toServiceAccount(sa, models.Authorization{}), nil
+}
+
+func (s *GRPCServer) GetServiceAccount(ctx context.Context, req *ssov1.GetServiceAccountRequest) (*ssov1.ServiceAccount, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	sa, authz, err := s.saServ.GetServiceAccount(ctx, claims.UserID, req.GetId())
+	if err != nil {
+		return nil, toStatus(err, "failed to get service account")
+	}
+
+	return toServiceAccount(sa, authz), nil
+}
+
+func (s *GRPCServer) ListServiceAccounts(ctx context.Context, req *ssov1.ListServiceAccountsRequest) (*ssov1.ListServiceAccountsResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	accounts, err := s.saServ.ListServiceAccounts(ctx, claims.UserID, int(req.GetAppId()))
+	if err != nil {
+		return nil, toStatus(err, "failed to list service accounts")
+	}
+
+	resp := &ssov1.ListServiceAccountsResponse{ServiceAccounts: make([]*ssov1.ServiceAccount, 0, len(accounts))}
+	for _, sa := range accounts {
+		resp.ServiceAccounts = append(resp.ServiceAccounts, toServiceAccount(sa, models.Authorization{}))
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) SetServiceAccountDisabled(ctx context.Context, req *ssov1.SetServiceAccountDisabledRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := s.saServ.SetDisabled(ctx, claims.UserID, req.GetId(), req.GetDisabled()); err != nil {
+		return nil, toStatus(err, "failed to update service account")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) DeleteServiceAccount(ctx context.Context, req *ssov1.DeleteServiceAccountRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := s.saServ.DeleteServiceAccount(ctx, claims.UserID, req.GetId()); err != nil {
+		return nil, toStatus(err, "failed to delete service account")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) AddServiceAccountKey(ctx context.Context, req *ssov1.AddServiceAccountKeyRequest) (*ssov1.ServiceAccountKey, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetServiceAccountId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "service_account_id is required")
+	}
+
+	var expiresAt time.Time
+	if req.GetExpiresAt() != nil {
+		expiresAt = req.GetExpiresAt().AsTime()
+	}
+
+	key, err := s.saServ.AddKey(ctx, claims.UserID, req.GetServiceAccountId(), req.GetPublicKey(), expiresAt)
+	if err != nil {
+		return nil, toStatus(err, "failed to add key")
+	}
+
+	return toKey(key), nil
+}
+
+func (s *GRPCServer) ListServiceAccountKeys(ctx context.Context, req *ssov1.ListServiceAccountKeysRequest) (*ssov1.ListServiceAccountKeysResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetServiceAccountId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "service_account_id is required")
+	}
+
+	keys, err := s.saServ.ListKeys(ctx, claims.UserID, req.GetServiceAccountId())
+	if err != nil {
+		return nil, toStatus(err, "failed to list keys")
+	}
+
+	resp := &ssov1.ListServiceAccountKeysResponse{Keys: make([]*ssov1.ServiceAccountKey, 0, len(keys))}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, toKey(key))
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) RevokeServiceAccountKey(ctx context.Context, req *ssov1.RevokeServiceAccountKeyRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetServiceAccountId() == 0 || req.GetKeyId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "service_account_id and key_id are required")
+	}
+
+	if err := s.saServ.RevokeKey(ctx, claims.UserID, req.GetServiceAccountId(), req.GetKeyId()); err != nil {
+		return nil, toStatus(err, "failed to revoke key")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) AssignServiceAccountRole(ctx context.Context, req *ssov1.ServiceAccountRoleRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetServiceAccountId() == 0 || req.GetRoleId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "service_account_id and role_id are required")
+	}
+
+	if err := s.saServ.AssignRole(ctx, claims.UserID, req.GetServiceAccountId(), req.GetRoleId()); err != nil {
+		return nil, toStatus(err, "failed to assign role")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) RevokeServiceAccountRole(ctx context.Context, req *ssov1.ServiceAccountRoleRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetServiceAccountId() == 0 || req.GetRoleId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "service_account_id and role_id are required")
+	}
+
+	if err := s.saServ.RevokeRole(ctx, claims.UserID, req.GetServiceAccountId(), req.GetRoleId()); err != nil {
+		return nil, toStatus(err, "failed to revoke role")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ExchangeAssertion is called by the service accounts themselves and needs no access token.
+func (s *GRPCServer) ExchangeAssertion(ctx context.Context, req *ssov1.ExchangeAssertionRequest) (*ssov1.ExchangeAssertionResponse, error) {
+	if req.GetAssertion() == "" {
+		return nil, status.Error(codes.InvalidArgument, "assertion is required")
+	}
+
+	token, expiresIn, err := s.saServ.ExchangeAssertion(ctx, req.GetAssertion())
+	if err != nil {
+		switch {
+		case errors.Is(err, serviceaccounts.ErrInvalidAssertion):
+			return nil, status.Error(codes.Unauthenticated, "invalid assertion")
+		case errors.Is(err, auth.ErrAppDisabled):
+			return nil, status.Error(codes.FailedPrecondition, "app is disabled")
+		case errors.Is(err, auth.ErrGrantNotAllowed):
+			return nil, status.Error(codes.FailedPrecondition, "app does not allow the jwt-bearer grant")
+		}
+		return nil, status.Error(codes.Internal, "failed to exchange assertion")
+	}
+
+	return &ssov1.ExchangeAssertionResponse{AccessToken: token, ExpiresIn: int64(expiresIn / time.Second)}, nil
+}
+
+func toServiceAccount(sa models.ServiceAccount, authz models.Authorization) *ssov1.ServiceAccount {
+	return &ssov1.ServiceAccount{
+		Id:          sa.ID,
+		AppId:       int32(sa.AppID),
+		Name:        sa.Name,
+		Description: sa.Description,
+		Disabled:    sa.Disabled,
+		CreatedBy:   sa.CreatedBy,
+		CreatedAt:   timestamppb.New(sa.CreatedAt),
+		Roles:       authz.Roles,
+		Permissions: authz.Permissions,
+	}
+}
+
+func toKey(key models.ServiceAccountKey) *ssov1.ServiceAccountKey {
+	res := &ssov1.ServiceAccountKey{
+		Id:        key.ID,
+		Algorithm: key.Algorithm,
+		CreatedAt: timestamppb.New(key.CreatedAt),
+	}
+	if !key.ExpiresAt.IsZero() {
+		res.ExpiresAt = timestamppb.New(key.ExpiresAt)
+	}
+	return res
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, repository.ErrServiceAccountNotFound):
+		return status.Error(codes.NotFound, "service account not found")
+	case errors.Is(err, repository.ErrServiceAccountExists):
+		return status.Error(codes.AlreadyExists, "service account name is taken in this app")
+	case errors.Is(err, repository.ErrKeyNotFound):
+		return status.Error(codes.NotFound, "key not found")
+	case errors.Is(err, repository.ErrRoleNotFound):
+		return status.Error(codes.NotFound, "role not found")
+	case errors.Is(err, repository.ErrAppNotFound):
+		return status.Error(codes.NotFound, "app not found")
+	default:
+		return grpcerr.Status(err, failMsg)
+	}
+}
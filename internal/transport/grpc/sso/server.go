@@ -0,0 +1,64 @@
+package ssogrpc
+
+import (
+	"context"
+	"errors"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/repository"
+	"auth/internal/services/sso"
+	"auth/internal/transport/grpc/grpcerr"
+	"auth/pkg/requestmeta"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedSSOServer
+	ssoServ SSOService
+}
+
+type SSOService interface {
+	ExchangeCode(ctx context.Context, code string, appID int, redirectURI, ip, userAgent string) (accessToken, refreshToken, idToken string, err error)
+}
+
+// Register adds the service. Apps redeem codes before they have a token, so it is public.
+func Register(gRPCServer *grpc.Server, ssoServ SSOService) {
+	ssov1.RegisterSSOServer(gRPCServer, &GRPCServer{ssoServ: ssoServ})
+}
+
+func (s *GRPCServer) ExchangeCode(ctx context.Context, req *ssov1.ExchangeSSOCodeRequest) (*ssov1.ExchangeSSOCodeResponse, error) {
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+	if req.GetRedirectUri() == "" {
+		return nil, status.Error(codes.InvalidArgument, "redirect_uri is required")
+	}
+
+	meta := requestmeta.FromContext(ctx)
+
+	access, refresh, id, err := s.ssoServ.ExchangeCode(ctx, req.GetCode(), int(req.GetAppId()), req.GetRedirectUri(), meta.IP, meta.UserAgent)
+	if err != nil {
+		return nil, toStatus(err, "failed to login")
+	}
+
+	return &ssov1.ExchangeSSOCodeResponse{AccessToken: access, RefreshToken: refresh, IdToken: id}, nil
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, sso.ErrInvalidCode):
+		return status.Error(codes.InvalidArgument, "code is invalid or expired")
+	case errors.Is(err, repository.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, repository.ErrAppNotFound):
+		return status.Error(codes.InvalidArgument, "unknown app_id")
+	default:
+		return grpcerr.SignInStatus(err, "sso login", failMsg)
+	}
+}
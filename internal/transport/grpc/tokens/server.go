@@ -0,0 +1,118 @@
+package tokensgrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedTokensServer
+	tokenServ TokenService
+	verifier  authn.TokenVerifier
+}
+
+type TokenService interface {
+	CreateToken(ctx context.Context, actorID int64, name string, scopes []string, expiresAt time.Time) (models.PersonalAccessToken, string, error)
+	ListTokens(ctx context.Context, actorID int64) ([]models.PersonalAccessToken, error)
+	RevokeToken(ctx context.Context, actorID, userID, tokenID int64) error
+}
+
+func Register(gRPCServer *grpc.Server, tokenServ TokenService, verifier authn.TokenVerifier) {
+	ssov1.RegisterTokensServer(gRPCServer, &GRPCServer{tokenServ: tokenServ, verifier: verifier})
+}
+
+func (s *GRPCServer) CreateToken(ctx context.Context, req *ssov1.CreateTokenRequest) (*ssov1.CreateTokenResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt time.Time
+	if req.GetExpiresAt() != nil {
+		expiresAt = req.GetExpiresAt().AsTime()
+	}
+
+	token, secret, err := s.tokenServ.CreateToken(ctx, claims.UserID, req.GetName(), req.GetScopes(), expiresAt)
+	if err != nil {
+		return nil, toStatus(err, "failed to create token")
+	}
+
+	return &ssov1.CreateTokenResponse{Token: toToken(token), Secret: secret}, nil
+}
+
+func (s *GRPCServer) ListTokens(ctx context.Context, _ *emptypb.Empty) (*ssov1.ListTokensResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.tokenServ.ListTokens(ctx, claims.UserID)
+	if err != nil {
+		return nil, toStatus(err, "failed to list tokens")
+	}
+
+	resp := &ssov1.ListTokensResponse{Tokens: make([]*ssov1.PersonalAccessToken, 0, len(list))}
+	for _, token := range list {
+		resp.Tokens = append(resp.Tokens, toToken(token))
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) RevokeToken(ctx context.Context, req *ssov1.RevokeTokenRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := s.tokenServ.RevokeToken(ctx, claims.UserID, req.GetUserId(), req.GetId()); err != nil {
+		return nil, toStatus(err, "failed to revoke token")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func toToken(token models.PersonalAccessToken) *ssov1.PersonalAccessToken {
+	res := &ssov1.PersonalAccessToken{
+		Id:            token.ID,
+		Name:          token.Name,
+		DisplayPrefix: token.DisplayPrefix,
+		Scopes:        token.Scopes,
+		CreatedAt:     timestamppb.New(token.CreatedAt),
+	}
+	if !token.ExpiresAt.IsZero() {
+		res.ExpiresAt = timestamppb.New(token.ExpiresAt)
+	}
+	if !token.LastUsedAt.IsZero() {
+		res.LastUsedAt = timestamppb.New(token.LastUsedAt)
+	}
+	return res
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, repository.ErrTokenNotFound):
+		return status.Error(codes.NotFound, "token not found")
+	case errors.Is(err, repository.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	default:
+		return grpcerr.Status(err, failMsg)
+	}
+}
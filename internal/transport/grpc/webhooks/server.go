@@ -0,0 +1,182 @@
+package webhooksgrpc
+
+import (
+	"context"
+	"errors"
+
+	ssov1 "auth/gen/go/sso"
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/transport/grpc/authn"
+	"auth/internal/transport/grpc/grpcerr"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCServer struct {
+	ssov1.UnimplementedWebhooksServer
+	webhookServ WebhookService
+	verifier    authn.TokenVerifier
+}
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, actorID int64, wh models.Webhook) (models.Webhook, error)
+	ListWebhooks(ctx context.Context, actorID int64, appID int) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, actorID, webhookID int64) error
+	ListDeliveries(ctx context.Context, actorID, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error)
+	ReplayDeliveries(ctx context.Context, actorID, webhookID int64, deliveryIDs []int64) (int64, error)
+}
+
+func Register(gRPCServer *grpc.Server, webhookServ WebhookService, verifier authn.TokenVerifier) {
+	ssov1.RegisterWebhooksServer(gRPCServer, &GRPCServer{webhookServ: webhookServ, verifier: verifier})
+}
+
+func (s *GRPCServer) CreateWebhook(ctx context.Context, req *ssov1.CreateWebhookRequest) (*ssov1.Webhook, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	wh, err := s.webhookServ.CreateWebhook(ctx, claims.UserID, models.Webhook{
+		AppID:      int(req.GetAppId()),
+		URL:        req.GetUrl(),
+		EventTypes: req.GetEventTypes(),
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to create webhook")
+	}
+
+	return toWebhook(wh), nil
+}
+
+func (s *GRPCServer) ListWebhooks(ctx context.Context, req *ssov1.ListWebhooksRequest) (*ssov1.ListWebhooksResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetAppId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	list, err := s.webhookServ.ListWebhooks(ctx, claims.UserID, int(req.GetAppId()))
+	if err != nil {
+		return nil, toStatus(err, "failed to list webhooks")
+	}
+
+	resp := &ssov1.ListWebhooksResponse{Webhooks: make([]*ssov1.Webhook, 0, len(list))}
+	for _, wh := range list {
+		resp.Webhooks = append(resp.Webhooks, toWebhook(wh))
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) DeleteWebhook(ctx context.Context, req *ssov1.DeleteWebhookRequest) (*emptypb.Empty, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := s.webhookServ.DeleteWebhook(ctx, claims.UserID, req.GetId()); err != nil {
+		return nil, toStatus(err, "failed to delete webhook")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) ListDeliveries(ctx context.Context, req *ssov1.ListDeliveriesRequest) (*ssov1.ListDeliveriesResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetWebhookId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "webhook_id is required")
+	}
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	list, err := s.webhookServ.ListDeliveries(ctx, claims.UserID, req.GetWebhookId(), req.GetStatus(), int(req.GetPageSize()))
+	if err != nil {
+		return nil, toStatus(err, "failed to list deliveries")
+	}
+
+	resp := &ssov1.ListDeliveriesResponse{Deliveries: make([]*ssov1.WebhookDelivery, 0, len(list))}
+	for _, d := range list {
+		resp.Deliveries = append(resp.Deliveries, toDelivery(d))
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) ReplayDeliveries(ctx context.Context, req *ssov1.ReplayDeliveriesRequest) (*ssov1.ReplayDeliveriesResponse, error) {
+	claims, err := authn.Authenticate(ctx, s.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetWebhookId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "webhook_id is required")
+	}
+
+	queued, err := s.webhookServ.ReplayDeliveries(ctx, claims.UserID, req.GetWebhookId(), req.GetDeliveryIds())
+	if err != nil {
+		return nil, toStatus(err, "failed to replay deliveries")
+	}
+
+	return &ssov1.ReplayDeliveriesResponse{Queued: queued}, nil
+}
+
+func toWebhook(wh models.Webhook) *ssov1.Webhook {
+	return &ssov1.Webhook{
+		Id:         wh.ID,
+		AppId:      int32(wh.AppID),
+		Url:        wh.URL,
+		Secret:     wh.Secret,
+		EventTypes: wh.EventTypes,
+		Disabled:   wh.Disabled,
+		CreatedAt:  timestamppb.New(wh.CreatedAt),
+	}
+}
+
+func toDelivery(d models.WebhookDelivery) *ssov1.WebhookDelivery {
+	res := &ssov1.WebhookDelivery{
+		Id:             d.ID,
+		WebhookId:      d.WebhookID,
+		EventId:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       int32(d.Attempts),
+		LastStatusCode: int32(d.LastStatusCode),
+		LastError:      d.LastError,
+		CreatedAt:      timestamppb.New(d.CreatedAt),
+	}
+	if d.Status == models.DeliveryPending {
+		res.NextAttemptAt = timestamppb.New(d.NextAttemptAt)
+	}
+	if !d.DeliveredAt.IsZero() {
+		res.DeliveredAt = timestamppb.New(d.DeliveredAt)
+	}
+	return res
+}
+
+func toStatus(err error, failMsg string) error {
+	switch {
+	case errors.Is(err, repository.ErrWebhookNotFound):
+		return status.Error(codes.NotFound, "webhook not found")
+	case errors.Is(err, repository.ErrAppNotFound):
+		return status.Error(codes.NotFound, "app not found")
+	default:
+		return grpcerr.Status(err, failMsg)
+	}
+}
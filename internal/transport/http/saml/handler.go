@@ -0,0 +1,104 @@
+package samlhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"auth/internal/repository"
+	"auth/internal/services/auth"
+	"auth/internal/services/samlidp"
+	"auth/internal/services/serviceerr"
+	"auth/pkg/saml"
+)
+
+type SAMLService interface {
+	Metadata() ([]byte, error)
+	StartSSO(ctx context.Context, msg saml.Message) (string, error)
+	SingleLogout(ctx context.Context, msg saml.Message) (string, error)
+}
+
+type handler struct {
+	samlServ SAMLService
+}
+
+// Register adds the endpoints service providers send browsers to. They take both the
+// HTTP-Redirect and the HTTP-POST binding.
+func Register(mux *http.ServeMux, samlServ SAMLService) {
+	h := &handler{samlServ: samlServ}
+
+	mux.HandleFunc("GET /saml/metadata", h.metadata)
+	mux.HandleFunc("GET /saml/sso", h.sso)
+	mux.HandleFunc("POST /saml/sso", h.sso)
+	mux.HandleFunc("GET /saml/slo", h.slo)
+	mux.HandleFunc("POST /saml/slo", h.slo)
+}
+
+func (h *handler) metadata(w http.ResponseWriter, _ *http.Request) {
+	out, err := h.samlServ.Metadata()
+	if err != nil {
+		http.Error(w, "failed to build metadata", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	_, _ = w.Write(out)
+}
+
+func (h *handler) sso(w http.ResponseWriter, r *http.Request) {
+	msg, err := saml.ReadMessage(r)
+	if err != nil || msg.Response {
+		http.Error(w, "malformed saml request", http.StatusBadRequest)
+		return
+	}
+
+	location, err := h.samlServ.StartSSO(r.Context(), msg)
+	if err != nil {
+		writeError(w, err, "failed to start sign-in")
+		return
+	}
+
+	http.Redirect(w, r, location, http.StatusFound)
+}
+
+func (h *handler) slo(w http.ResponseWriter, r *http.Request) {
+	msg, err := saml.ReadMessage(r)
+	if err != nil || msg.Response {
+		http.Error(w, "malformed saml request", http.StatusBadRequest)
+		return
+	}
+
+	location, err := h.samlServ.SingleLogout(r.Context(), msg)
+	if err != nil {
+		writeError(w, err, "failed to log out")
+		return
+	}
+
+	http.Redirect(w, r, location, http.StatusFound)
+}
+
+// writeError answers the browser directly: without a trusted request there is nowhere safe to
+// send it back to.
+func writeError(w http.ResponseWriter, err error, failMsg string) {
+	var ferr *serviceerr.FieldError
+	switch {
+	case errors.As(err, &ferr):
+		http.Error(w, ferr.Error(), http.StatusBadRequest)
+	case errors.Is(err, saml.ErrMalformed):
+		http.Error(w, "malformed saml request", http.StatusBadRequest)
+	case errors.Is(err, saml.ErrSignature):
+		http.Error(w, "saml request signature is missing or invalid", http.StatusForbidden)
+	case errors.Is(err, repository.ErrServiceProviderNotFound):
+		http.Error(w, "unknown service provider", http.StatusNotFound)
+	case errors.Is(err, samlidp.ErrServiceProviderDisabled):
+		http.Error(w, "service provider is disabled", http.StatusForbidden)
+	case errors.Is(err, samlidp.ErrLogoutNotSupported):
+		http.Error(w, "service provider is not set up for single logout", http.StatusBadRequest)
+	case errors.Is(err, auth.ErrAppDisabled):
+		http.Error(w, "app is disabled", http.StatusForbidden)
+	case errors.Is(err, auth.ErrGrantNotAllowed):
+		http.Error(w, "app does not allow saml sign-in", http.StatusForbidden)
+	default:
+		http.Error(w, failMsg, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,87 @@
+package samlhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"auth/internal/repository"
+	"auth/pkg/saml"
+	"auth/pkg/saml/samltest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeService struct {
+	got saml.Message
+	err error
+}
+
+func (f *fakeService) Metadata() ([]byte, error) {
+	return []byte("<md:EntityDescriptor/>"), nil
+}
+
+func (f *fakeService) StartSSO(_ context.Context, msg saml.Message) (string, error) {
+	f.got = msg
+	return "https://sso.example.com/login?request=1", f.err
+}
+
+func (f *fakeService) SingleLogout(_ context.Context, msg saml.Message) (string, error) {
+	f.got = msg
+	return "https://sp.example.com/slo?SAMLResponse=x", f.err
+}
+
+func TestHandler(t *testing.T) {
+	serv := &fakeService{}
+	mux := http.NewServeMux()
+	Register(mux, serv)
+
+	sp := samltest.NewServiceProvider("https://sp.example.com", "https://sp.example.com/acs", "")
+
+	t.Run("metadata", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/saml/metadata", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/samlmetadata+xml", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("sso redirects to the login page", func(t *testing.T) {
+		location, _ := sp.AuthnRequestURL("/saml/sso", "relay")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location, nil))
+
+		require.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://sso.example.com/login?request=1", rec.Header().Get("Location"))
+		assert.Equal(t, "relay", serv.got.RelayState)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/saml/sso?SAMLRequest=%%%", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("unknown service provider", func(t *testing.T) {
+		serv.err = repository.ErrServiceProviderNotFound
+		defer func() { serv.err = nil }()
+
+		location, _ := sp.AuthnRequestURL("/saml/sso", "")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location, nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Empty(t, rec.Header().Get("Location"))
+	})
+
+	t.Run("slo redirects to the service provider", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, sp.LogoutRequestURL("/saml/slo", "ann@example.com", saml.NameIDFormatEmail, "sid"), nil))
+
+		require.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://sp.example.com/slo?SAMLResponse=x", rec.Header().Get("Location"))
+	})
+}
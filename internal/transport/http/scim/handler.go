@@ -0,0 +1,505 @@
+package scimhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/auth"
+	"auth/internal/services/provisioning"
+	"auth/internal/transport/grpc/authn"
+	"auth/pkg/scim"
+)
+
+const (
+	maxPayloadSize    = 1 << 20
+	maxBulkOperations = 100
+)
+
+type ProvisioningService interface {
+	ListUsers(ctx context.Context, actorID int64, q provisioning.Query) (scim.ListResponse, error)
+	GetUser(ctx context.Context, actorID int64, id string) (scim.User, error)
+	CreateUser(ctx context.Context, actorID int64, in scim.User) (scim.User, error)
+	ReplaceUser(ctx context.Context, actorID int64, id string, in scim.User, ifMatch string) (scim.User, error)
+	PatchUser(ctx context.Context, actorID int64, id string, ops []scim.PatchOperation, ifMatch string) (scim.User, error)
+	DeleteUser(ctx context.Context, actorID int64, id, ifMatch string) error
+	ListGroups(ctx context.Context, actorID int64, q provisioning.Query) (scim.ListResponse, error)
+	GetGroup(ctx context.Context, actorID int64, id string) (scim.Group, error)
+	CreateGroup(ctx context.Context, actorID int64, in scim.Group) (scim.Group, error)
+	ReplaceGroup(ctx context.Context, actorID int64, id string, in scim.Group, ifMatch string) (scim.Group, error)
+	PatchGroup(ctx context.Context, actorID int64, id string, ops []scim.PatchOperation, ifMatch string) (scim.Group, error)
+	DeleteGroup(ctx context.Context, actorID int64, id, ifMatch string) error
+}
+
+type handler struct {
+	serv     ProvisioningService
+	verifier authn.TokenVerifier
+	// base is the URL resources are located under.
+	base string
+}
+
+// response is what an endpoint answers; id is set for created resources so bulk requests can
+// refer to them.
+type response struct {
+	status   int
+	body     any
+	id       string
+	version  string
+	location string
+}
+
+// operation is a write the Bulk endpoint can run as well.
+type operation func(ctx context.Context, actorID int64, id string, body []byte, ifMatch string) (response, error)
+
+// Register adds the SCIM 2.0 endpoints under /scim/v2. Clients authenticate with an admin's
+// access token, which verifier must be scoped for.
+func Register(mux *http.ServeMux, serv ProvisioningService, verifier authn.TokenVerifier, baseURL string) {
+	h := &handler{serv: serv, verifier: verifier, base: strings.TrimRight(baseURL, "/") + "/scim/v2"}
+
+	mux.HandleFunc("GET /scim/v2/ServiceProviderConfig", h.serviceProviderConfig)
+
+	mux.HandleFunc("GET /scim/v2/Users", h.serve(h.listUsers))
+	mux.HandleFunc("GET /scim/v2/Users/{id}", h.serve(h.getUser))
+	mux.HandleFunc("POST /scim/v2/Users", h.serveOp(h.createUser))
+	mux.HandleFunc("PUT /scim/v2/Users/{id}", h.serveOp(h.replaceUser))
+	mux.HandleFunc("PATCH /scim/v2/Users/{id}", h.serveOp(h.patchUser))
+	mux.HandleFunc("DELETE /scim/v2/Users/{id}", h.serveOp(h.deleteUser))
+
+	mux.HandleFunc("GET /scim/v2/Groups", h.serve(h.listGroups))
+	mux.HandleFunc("GET /scim/v2/Groups/{id}", h.serve(h.getGroup))
+	mux.HandleFunc("POST /scim/v2/Groups", h.serveOp(h.createGroup))
+	mux.HandleFunc("PUT /scim/v2/Groups/{id}", h.serveOp(h.replaceGroup))
+	mux.HandleFunc("PATCH /scim/v2/Groups/{id}", h.serveOp(h.patchGroup))
+	mux.HandleFunc("DELETE /scim/v2/Groups/{id}", h.serveOp(h.deleteGroup))
+
+	mux.HandleFunc("POST /scim/v2/Bulk", h.serve(h.bulk))
+}
+
+func (h *handler) serviceProviderConfig(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"schemas":        []string{scim.SchemaServiceConfig},
+		"patch":          map[string]any{"supported": true},
+		"bulk":           map[string]any{"supported": true, "maxOperations": maxBulkOperations, "maxPayloadSize": maxPayloadSize},
+		"filter":         map[string]any{"supported": true, "maxResults": provisioning.MaxPageSize},
+		"changePassword": map[string]any{"supported": true},
+		"sort":           map[string]any{"supported": false},
+		"etag":           map[string]any{"supported": true},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Access token of an admin, unrestricted or with the admin scope",
+			"primary":     true,
+		}},
+	})
+}
+
+func (h *handler) serve(fn func(ctx context.Context, actorID int64, r *http.Request) (response, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actorID, err := h.authenticate(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxPayloadSize)
+		resp, err := fn(r.Context(), actorID, r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeResponse(w, resp)
+	}
+}
+
+func (h *handler) serveOp(op operation) http.HandlerFunc {
+	return h.serve(func(ctx context.Context, actorID int64, r *http.Request) (response, error) {
+		var body []byte
+		if r.Method != http.MethodDelete {
+			var err error
+			if body, err = io.ReadAll(r.Body); err != nil {
+				return response{}, &scim.Error{Status: http.StatusRequestEntityTooLarge, Detail: "request body is too large"}
+			}
+		}
+		return op(ctx, actorID, r.PathValue("id"), body, r.Header.Get("If-Match"))
+	})
+}
+
+func (h *handler) authenticate(r *http.Request) (int64, error) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return 0, &scim.Error{Status: http.StatusUnauthorized, Detail: "access token is required"}
+	}
+
+	claims, err := h.verifier.VerifyAccessToken(r.Context(), token)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidToken):
+			return 0, &scim.Error{Status: http.StatusUnauthorized, Detail: "invalid access token"}
+		case errors.Is(err, authn.ErrInsufficientScope):
+			return 0, &scim.Error{Status: http.StatusForbidden, Detail: "access token lacks the required scope"}
+		}
+		return 0, fmt.Errorf("verify access token: %w", err)
+	}
+
+	return claims.UserID, nil
+}
+
+func (h *handler) listUsers(ctx context.Context, actorID int64, r *http.Request) (response, error) {
+	q, err := query(r)
+	if err != nil {
+		return response{}, err
+	}
+
+	list, err := h.serv.ListUsers(ctx, actorID, q)
+	if err != nil {
+		return response{}, err
+	}
+	if users, ok := list.Resources.([]scim.User); ok {
+		for i := range users {
+			users[i].Meta.Location = h.base + "/Users/" + users[i].ID
+		}
+	}
+
+	return response{status: http.StatusOK, body: list}, nil
+}
+
+func (h *handler) getUser(ctx context.Context, actorID int64, r *http.Request) (response, error) {
+	user, err := h.serv.GetUser(ctx, actorID, r.PathValue("id"))
+	if err != nil {
+		return response{}, err
+	}
+	return notModified(r, h.user(http.StatusOK, user)), nil
+}
+
+func (h *handler) createUser(ctx context.Context, actorID int64, _ string, body []byte, _ string) (response, error) {
+	var in scim.User
+	if err := decode(body, &in); err != nil {
+		return response{}, err
+	}
+
+	user, err := h.serv.CreateUser(ctx, actorID, in)
+	if err != nil {
+		return response{}, err
+	}
+	return h.user(http.StatusCreated, user), nil
+}
+
+func (h *handler) replaceUser(ctx context.Context, actorID int64, id string, body []byte, ifMatch string) (response, error) {
+	var in scim.User
+	if err := decode(body, &in); err != nil {
+		return response{}, err
+	}
+
+	user, err := h.serv.ReplaceUser(ctx, actorID, id, in, ifMatch)
+	if err != nil {
+		return response{}, err
+	}
+	return h.user(http.StatusOK, user), nil
+}
+
+func (h *handler) patchUser(ctx context.Context, actorID int64, id string, body []byte, ifMatch string) (response, error) {
+	var req scim.PatchRequest
+	if err := decode(body, &req); err != nil {
+		return response{}, err
+	}
+
+	user, err := h.serv.PatchUser(ctx, actorID, id, req.Operations, ifMatch)
+	if err != nil {
+		return response{}, err
+	}
+	return h.user(http.StatusOK, user), nil
+}
+
+func (h *handler) deleteUser(ctx context.Context, actorID int64, id string, _ []byte, ifMatch string) (response, error) {
+	if err := h.serv.DeleteUser(ctx, actorID, id, ifMatch); err != nil {
+		return response{}, err
+	}
+	return response{status: http.StatusNoContent}, nil
+}
+
+func (h *handler) user(status int, user scim.User) response {
+	location := h.base + "/Users/" + user.ID
+	user.Meta.Location = location
+	return response{status: status, body: user, id: user.ID, version: user.Meta.Version, location: location}
+}
+
+func (h *handler) listGroups(ctx context.Context, actorID int64, r *http.Request) (response, error) {
+	q, err := query(r)
+	if err != nil {
+		return response{}, err
+	}
+
+	list, err := h.serv.ListGroups(ctx, actorID, q)
+	if err != nil {
+		return response{}, err
+	}
+	if groups, ok := list.Resources.([]scim.Group); ok {
+		for i := range groups {
+			groups[i].Meta.Location = h.base + "/Groups/" + groups[i].ID
+			h.memberRefs(groups[i].Members)
+		}
+	}
+
+	return response{status: http.StatusOK, body: list}, nil
+}
+
+func (h *handler) getGroup(ctx context.Context, actorID int64, r *http.Request) (response, error) {
+	group, err := h.serv.GetGroup(ctx, actorID, r.PathValue("id"))
+	if err != nil {
+		return response{}, err
+	}
+	return notModified(r, h.group(http.StatusOK, group)), nil
+}
+
+func (h *handler) createGroup(ctx context.Context, actorID int64, _ string, body []byte, _ string) (response, error) {
+	var in scim.Group
+	if err := decode(body, &in); err != nil {
+		return response{}, err
+	}
+
+	group, err := h.serv.CreateGroup(ctx, actorID, in)
+	if err != nil {
+		return response{}, err
+	}
+	return h.group(http.StatusCreated, group), nil
+}
+
+func (h *handler) replaceGroup(ctx context.Context, actorID int64, id string, body []byte, ifMatch string) (response, error) {
+	var in scim.Group
+	if err := decode(body, &in); err != nil {
+		return response{}, err
+	}
+
+	group, err := h.serv.ReplaceGroup(ctx, actorID, id, in, ifMatch)
+	if err != nil {
+		return response{}, err
+	}
+	return h.group(http.StatusOK, group), nil
+}
+
+func (h *handler) patchGroup(ctx context.Context, actorID int64, id string, body []byte, ifMatch string) (response, error) {
+	var req scim.PatchRequest
+	if err := decode(body, &req); err != nil {
+		return response{}, err
+	}
+
+	group, err := h.serv.PatchGroup(ctx, actorID, id, req.Operations, ifMatch)
+	if err != nil {
+		return response{}, err
+	}
+	return h.group(http.StatusOK, group), nil
+}
+
+func (h *handler) deleteGroup(ctx context.Context, actorID int64, id string, _ []byte, ifMatch string) (response, error) {
+	if err := h.serv.DeleteGroup(ctx, actorID, id, ifMatch); err != nil {
+		return response{}, err
+	}
+	return response{status: http.StatusNoContent}, nil
+}
+
+func (h *handler) group(status int, group scim.Group) response {
+	location := h.base + "/Groups/" + group.ID
+	group.Meta.Location = location
+	h.memberRefs(group.Members)
+	return response{status: status, body: group, id: group.ID, version: group.Meta.Version, location: location}
+}
+
+func (h *handler) memberRefs(members []scim.MultiValue) {
+	for i := range members {
+		members[i].Ref = h.base + "/Users/" + members[i].Value
+	}
+}
+
+// bulk runs the operations of a bulk request in order. Later operations can refer to resources
+// created by earlier ones as "bulkId:<id>" in their path or data.
+func (h *handler) bulk(ctx context.Context, actorID int64, r *http.Request) (response, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return response{}, &scim.Error{Status: http.StatusRequestEntityTooLarge, Detail: "request body is too large"}
+	}
+	var req scim.BulkRequest
+	if err := decode(body, &req); err != nil {
+		return response{}, err
+	}
+	if len(req.Operations) > maxBulkOperations {
+		return response{}, &scim.Error{
+			Status: http.StatusRequestEntityTooLarge,
+			Detail: fmt.Sprintf("a bulk request takes at most %d operations", maxBulkOperations),
+		}
+	}
+
+	created := make(map[string]string)
+	results := make([]scim.BulkOperation, 0, len(req.Operations))
+	failures := 0
+	for _, op := range req.Operations {
+		result := h.bulkOperation(ctx, actorID, op, created)
+		results = append(results, result)
+
+		if status, _ := strconv.Atoi(result.Status); status >= http.StatusBadRequest {
+			failures++
+			if req.FailOnErrors > 0 && failures >= req.FailOnErrors {
+				break
+			}
+		}
+	}
+
+	return response{
+		status: http.StatusOK,
+		body:   scim.BulkResponse{Schemas: []string{scim.SchemaBulkResponse}, Operations: results},
+	}, nil
+}
+
+func (h *handler) bulkOperation(ctx context.Context, actorID int64, op scim.BulkOperation, created map[string]string) scim.BulkOperation {
+	result := scim.BulkOperation{Method: op.Method, BulkID: op.BulkID}
+	fail := func(err error) scim.BulkOperation {
+		serr := toSCIMError(err)
+		result.Status = strconv.Itoa(serr.Status)
+		result.Response = serr
+		return result
+	}
+
+	path, data := op.Path, string(op.Data)
+	for bulkID, id := range created {
+		path = strings.ReplaceAll(path, "bulkId:"+bulkID, id)
+		data = strings.ReplaceAll(data, `"bulkId:`+bulkID+`"`, strconv.Quote(id))
+	}
+	if strings.Contains(path, "bulkId:") || strings.Contains(data, `"bulkId:`) {
+		return fail(&scim.Error{Status: http.StatusConflict, Type: scim.ErrorInvalidValue, Detail: "unresolved bulkId reference"})
+	}
+
+	resource, id, _ := strings.Cut(strings.Trim(path, "/"), "/")
+	method := strings.ToUpper(op.Method)
+	if (method == http.MethodPost) != (id == "") || strings.Contains(id, "/") {
+		return fail(scim.BadRequest(scim.ErrorInvalidPath, "%s cannot be sent to %q", op.Method, op.Path))
+	}
+	if method == http.MethodPost && op.BulkID == "" {
+		return fail(scim.BadRequest(scim.ErrorInvalidValue, "POST operations require a bulkId"))
+	}
+
+	fn := h.operations()[resource+" "+method]
+	if fn == nil {
+		return fail(scim.BadRequest(scim.ErrorInvalidPath, "%s cannot be sent to %q", op.Method, op.Path))
+	}
+
+	resp, err := fn(ctx, actorID, id, []byte(data), op.Version)
+	if err != nil {
+		return fail(err)
+	}
+
+	if method == http.MethodPost {
+		created[op.BulkID] = resp.id
+	}
+	result.Status = strconv.Itoa(resp.status)
+	result.Location = resp.location
+	if result.Location == "" {
+		result.Location = h.base + "/" + resource + "/" + id
+	}
+	result.Version = resp.version
+	return result
+}
+
+func (h *handler) operations() map[string]operation {
+	return map[string]operation{
+		"Users POST":    h.createUser,
+		"Users PUT":     h.replaceUser,
+		"Users PATCH":   h.patchUser,
+		"Users DELETE":  h.deleteUser,
+		"Groups POST":   h.createGroup,
+		"Groups PUT":    h.replaceGroup,
+		"Groups PATCH":  h.patchGroup,
+		"Groups DELETE": h.deleteGroup,
+	}
+}
+
+func query(r *http.Request) (provisioning.Query, error) {
+	values := r.URL.Query()
+	q := provisioning.Query{Filter: values.Get("filter")}
+
+	if s := values.Get("startIndex"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return q, scim.BadRequest(scim.ErrorInvalidValue, "startIndex must be a number")
+		}
+		q.StartIndex = n
+	}
+	if s := values.Get("count"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return q, scim.BadRequest(scim.ErrorInvalidValue, "count must be a number")
+		}
+		q.Count = &n
+	}
+
+	return q, nil
+}
+
+func notModified(r *http.Request, resp response) response {
+	if tag := r.Header.Get("If-None-Match"); tag != "" && scim.MatchETag(tag, resp.version) {
+		return response{status: http.StatusNotModified, version: resp.version}
+	}
+	return resp
+}
+
+func decode(body []byte, v any) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return scim.BadRequest(scim.ErrorInvalidSyntax, "request body is not valid JSON: %v", err)
+	}
+	return nil
+}
+
+func writeResponse(w http.ResponseWriter, resp response) {
+	if resp.version != "" {
+		w.Header().Set("ETag", resp.version)
+	}
+	if resp.status == http.StatusCreated {
+		w.Header().Set("Location", resp.location)
+	}
+	if resp.body == nil {
+		w.WriteHeader(resp.status)
+		return
+	}
+	writeJSON(w, resp.status, resp.body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", scim.MediaType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	serr := toSCIMError(err)
+	if serr.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+	}
+	writeJSON(w, serr.Status, serr)
+}
+
+func toSCIMError(err error) *scim.Error {
+	var serr *scim.Error
+	switch {
+	case errors.As(err, &serr):
+		return serr
+	case errors.Is(err, admin.ErrPermissionDenied):
+		return &scim.Error{Status: http.StatusForbidden, Detail: "admin privileges are required"}
+	case errors.Is(err, repository.ErrUserNotFound):
+		return &scim.Error{Status: http.StatusNotFound, Detail: "user not found"}
+	case errors.Is(err, repository.ErrGroupNotFound):
+		return &scim.Error{Status: http.StatusNotFound, Detail: "group not found"}
+	case errors.Is(err, repository.ErrUserExists):
+		return &scim.Error{Status: http.StatusConflict, Type: scim.ErrorUniqueness, Detail: "userName or externalId is already taken"}
+	case errors.Is(err, repository.ErrGroupExists):
+		return &scim.Error{Status: http.StatusConflict, Type: scim.ErrorUniqueness, Detail: "displayName or externalId is already taken"}
+	case errors.Is(err, provisioning.ErrPreconditionFailed), errors.Is(err, repository.ErrVersionMismatch):
+		return &scim.Error{Status: http.StatusPreconditionFailed, Detail: "resource has changed"}
+	default:
+		return &scim.Error{Status: http.StatusInternalServerError, Detail: "internal error"}
+	}
+}
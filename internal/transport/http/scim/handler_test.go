@@ -0,0 +1,274 @@
+package scimhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"auth/internal/repository"
+	"auth/internal/services/admin"
+	"auth/internal/services/auth"
+	"auth/internal/services/provisioning"
+	"auth/internal/transport/grpc/authn"
+	"auth/pkg/jwt"
+	"auth/pkg/scim"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVerifier struct{}
+
+func (fakeVerifier) VerifyAccessToken(_ context.Context, token string) (*jwt.Claims, error) {
+	switch token {
+	case "admin":
+		return &jwt.Claims{UserID: 1}, nil
+	case "user":
+		return &jwt.Claims{UserID: 2}, nil
+	case "scoped":
+		return nil, authn.ErrInsufficientScope
+	}
+	return nil, auth.ErrInvalidToken
+}
+
+// fakeService numbers created users and answers for existing ones with a fixed version.
+type fakeService struct {
+	created []scim.User
+	ifMatch string
+	query   provisioning.Query
+}
+
+func (f *fakeService) ListUsers(_ context.Context, _ int64, q provisioning.Query) (scim.ListResponse, error) {
+	f.query = q
+	return scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: 1,
+		StartIndex:   1,
+		ItemsPerPage: 1,
+		Resources:    []scim.User{f.user("7")},
+	}, nil
+}
+
+func (f *fakeService) GetUser(_ context.Context, actorID int64, id string) (scim.User, error) {
+	if actorID != 1 {
+		return scim.User{}, admin.ErrPermissionDenied
+	}
+	if id != "7" {
+		return scim.User{}, repository.ErrUserNotFound
+	}
+	return f.user(id), nil
+}
+
+func (f *fakeService) CreateUser(_ context.Context, _ int64, in scim.User) (scim.User, error) {
+	if in.UserName == "taken@example.com" {
+		return scim.User{}, repository.ErrUserExists
+	}
+	f.created = append(f.created, in)
+	user := f.user(fmt.Sprint(100 + len(f.created)))
+	user.UserName = in.UserName
+	return user, nil
+}
+
+func (f *fakeService) ReplaceUser(_ context.Context, _ int64, id string, _ scim.User, ifMatch string) (scim.User, error) {
+	f.ifMatch = ifMatch
+	if ifMatch != "" && !scim.MatchETag(ifMatch, scim.ETag(3)) {
+		return scim.User{}, provisioning.ErrPreconditionFailed
+	}
+	return f.user(id), nil
+}
+
+func (f *fakeService) PatchUser(_ context.Context, _ int64, id string, ops []scim.PatchOperation, _ string) (scim.User, error) {
+	if len(ops) == 0 {
+		return scim.User{}, scim.BadRequest(scim.ErrorInvalidSyntax, "no operations")
+	}
+	return f.user(id), nil
+}
+
+func (f *fakeService) DeleteUser(context.Context, int64, string, string) error {
+	return nil
+}
+
+func (f *fakeService) ListGroups(context.Context, int64, provisioning.Query) (scim.ListResponse, error) {
+	return scim.ListResponse{Schemas: []string{scim.SchemaListResponse}, Resources: []scim.Group{}}, nil
+}
+
+func (f *fakeService) GetGroup(context.Context, int64, string) (scim.Group, error) {
+	return scim.Group{}, repository.ErrGroupNotFound
+}
+
+func (f *fakeService) CreateGroup(_ context.Context, _ int64, in scim.Group) (scim.Group, error) {
+	in.ID = "1"
+	in.Meta = &scim.Meta{ResourceType: "Group", Version: scim.ETag(1)}
+	return in, nil
+}
+
+func (f *fakeService) ReplaceGroup(context.Context, int64, string, scim.Group, string) (scim.Group, error) {
+	return scim.Group{}, repository.ErrGroupNotFound
+}
+
+func (f *fakeService) PatchGroup(context.Context, int64, string, []scim.PatchOperation, string) (scim.Group, error) {
+	return scim.Group{}, repository.ErrGroupNotFound
+}
+
+func (f *fakeService) DeleteGroup(context.Context, int64, string, string) error {
+	return nil
+}
+
+func (f *fakeService) user(id string) scim.User {
+	return scim.User{
+		Schemas:  []string{scim.SchemaUser},
+		ID:       id,
+		UserName: "ann@example.com",
+		Meta:     &scim.Meta{ResourceType: "User", Version: scim.ETag(3)},
+	}
+}
+
+func do(mux *http.ServeMux, method, target, token, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body
+}
+
+func TestHandler(t *testing.T) {
+	serv := &fakeService{}
+	mux := http.NewServeMux()
+	Register(mux, serv, fakeVerifier{}, "https://id.example.com/")
+
+	t.Run("service provider config is public", func(t *testing.T) {
+		rec := do(mux, http.MethodGet, "/scim/v2/ServiceProviderConfig", "", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, scim.MediaType, rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("authentication", func(t *testing.T) {
+		rec := do(mux, http.MethodGet, "/scim/v2/Users/7", "", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+		assert.Equal(t, "401", decodeError(t, rec)["status"])
+
+		assert.Equal(t, http.StatusUnauthorized, do(mux, http.MethodGet, "/scim/v2/Users/7", "bogus", "").Code)
+		assert.Equal(t, http.StatusForbidden, do(mux, http.MethodGet, "/scim/v2/Users/7", "scoped", "").Code)
+		assert.Equal(t, http.StatusForbidden, do(mux, http.MethodGet, "/scim/v2/Users/7", "user", "").Code)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		rec := do(mux, http.MethodGet, "/scim/v2/Users/7", "admin", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `W/"3"`, rec.Header().Get("ETag"))
+
+		var user scim.User
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &user))
+		assert.Equal(t, "https://id.example.com/scim/v2/Users/7", user.Meta.Location)
+
+		rec = do(mux, http.MethodGet, "/scim/v2/Users/7", "admin", "", "If-None-Match", `W/"3"`)
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.Bytes())
+
+		assert.Equal(t, http.StatusNotFound, do(mux, http.MethodGet, "/scim/v2/Users/8", "admin", "").Code)
+		assert.Equal(t, http.StatusNotFound, do(mux, http.MethodGet, "/scim/v2/Groups/1", "admin", "").Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		rec := do(mux, http.MethodGet, "/scim/v2/Users?filter=userName+eq+%22ann%40example.com%22&startIndex=2&count=5", "admin", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `userName eq "ann@example.com"`, serv.query.Filter)
+		assert.Equal(t, 2, serv.query.StartIndex)
+		assert.Equal(t, 5, *serv.query.Count)
+		assert.Contains(t, rec.Body.String(), "https://id.example.com/scim/v2/Users/7")
+
+		rec = do(mux, http.MethodGet, "/scim/v2/Users?count=many", "admin", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, scim.ErrorInvalidValue, decodeError(t, rec)["scimType"])
+	})
+
+	t.Run("create", func(t *testing.T) {
+		rec := do(mux, http.MethodPost, "/scim/v2/Users", "admin", `{"userName": "bob@example.com"}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "https://id.example.com/scim/v2/Users/101", rec.Header().Get("Location"))
+
+		rec = do(mux, http.MethodPost, "/scim/v2/Users", "admin", `{"userName": "taken@example.com"}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, scim.ErrorUniqueness, decodeError(t, rec)["scimType"])
+
+		rec = do(mux, http.MethodPost, "/scim/v2/Users", "admin", `{"userName":`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, scim.ErrorInvalidSyntax, decodeError(t, rec)["scimType"])
+	})
+
+	t.Run("if-match", func(t *testing.T) {
+		rec := do(mux, http.MethodPut, "/scim/v2/Users/7", "admin", `{"userName": "ann@example.com"}`, "If-Match", `W/"2"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		assert.Equal(t, `W/"2"`, serv.ifMatch)
+
+		rec = do(mux, http.MethodPut, "/scim/v2/Users/7", "admin", `{"userName": "ann@example.com"}`, "If-Match", `W/"3"`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("patch and delete", func(t *testing.T) {
+		rec := do(mux, http.MethodPatch, "/scim/v2/Users/7", "admin",
+			`{"schemas": ["`+scim.SchemaPatchOp+`"], "Operations": [{"op": "replace", "path": "active", "value": false}]}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = do(mux, http.MethodDelete, "/scim/v2/Users/7", "admin", "")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, rec.Body.Bytes())
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		serv.created = nil
+		rec := do(mux, http.MethodPost, "/scim/v2/Bulk", "admin", `{
+			"schemas": ["`+scim.SchemaBulkRequest+`"],
+			"Operations": [
+				{"method": "POST", "path": "/Users", "bulkId": "ann", "data": {"userName": "ann@example.com"}},
+				{"method": "POST", "path": "/Groups", "bulkId": "eng", "data": {"displayName": "Eng", "members": [{"value": "bulkId:ann"}]}},
+				{"method": "PATCH", "path": "/Users/bulkId:ann", "data": {"Operations": [{"op": "replace", "path": "active", "value": false}]}},
+				{"method": "DELETE", "path": "/Users/bulkId:nobody"},
+				{"method": "POST", "path": "/Users", "data": {"userName": "bob@example.com"}},
+				{"method": "GET", "path": "/Users/7"}
+			]
+		}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp scim.BulkResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Operations, 6)
+
+		var statuses []string
+		for _, op := range resp.Operations {
+			statuses = append(statuses, op.Status)
+		}
+		assert.Equal(t, []string{"201", "201", "200", "409", "400", "400"}, statuses)
+		assert.Equal(t, "https://id.example.com/scim/v2/Users/101", resp.Operations[0].Location)
+		assert.Equal(t, "https://id.example.com/scim/v2/Users/101", resp.Operations[2].Location)
+		assert.Equal(t, `W/"1"`, resp.Operations[1].Version)
+		require.Len(t, serv.created, 1)
+
+		rec = do(mux, http.MethodPost, "/scim/v2/Bulk", "admin", `{
+			"failOnErrors": 1,
+			"Operations": [
+				{"method": "DELETE", "path": "/Groups"},
+				{"method": "DELETE", "path": "/Users/7"}
+			]
+		}`)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Len(t, resp.Operations, 1)
+	})
+}
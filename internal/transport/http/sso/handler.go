@@ -0,0 +1,231 @@
+package ssohttp
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/repository"
+	"auth/internal/services/auth"
+	"auth/internal/services/sso"
+)
+
+type SSOService interface {
+	Authorize(ctx context.Context, sessionID string, req models.SSOAuthorizeRequest, prompt string, maxAge time.Duration) (string, error)
+	CompleteLogin(ctx context.Context, requestID, refreshToken, sessionID string) (newSessionID string, expiresAt time.Time, location string, err error)
+	Logout(ctx context.Context, sessionID string, appID int, redirectURI, state string) (location string, frontchannel []string, err error)
+}
+
+type Settings struct {
+	// CookieName names the cookie holding the browser's SSO session. It is always HttpOnly and
+	// scoped to the host serving it.
+	CookieName string
+	// CookieSecure keeps the cookie off plain HTTP; only local setups turn it off.
+	CookieSecure bool
+	// LoginOrigin is the origin of the login page, which sign-ins are posted from.
+	LoginOrigin string
+}
+
+type handler struct {
+	ssoServ  SSOService
+	settings Settings
+}
+
+// Register adds the endpoints apps send browsers to for signing in and out, and the one the
+// login page posts sign-ins to.
+func Register(mux *http.ServeMux, ssoServ SSOService, settings Settings) {
+	h := &handler{ssoServ: ssoServ, settings: settings}
+
+	mux.HandleFunc("GET /sso/authorize", h.authorize)
+	mux.HandleFunc("POST /sso/login", h.login)
+	mux.HandleFunc("GET /sso/logout", h.logout)
+	mux.HandleFunc("POST /sso/logout", h.logout)
+}
+
+func (h *handler) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	appID, err := strconv.Atoi(query.Get("app_id"))
+	if err != nil || appID <= 0 {
+		http.Error(w, "app_id is required", http.StatusBadRequest)
+		return
+	}
+	var orgID int64
+	if raw := query.Get("org_id"); raw != "" {
+		if orgID, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			http.Error(w, "org_id must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+	maxAge := time.Duration(-1)
+	if raw := query.Get("max_age"); raw != "" {
+		seconds, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || seconds < 0 {
+			http.Error(w, "max_age must be a non-negative number of seconds", http.StatusBadRequest)
+			return
+		}
+		maxAge = time.Duration(seconds) * time.Second
+	}
+
+	req := models.SSOAuthorizeRequest{AppID: appID, OrgID: orgID, RedirectURI: query.Get("redirect_uri"), State: query.Get("state")}
+
+	location, err := h.ssoServ.Authorize(r.Context(), h.sessionID(r), req, query.Get("prompt"), maxAge)
+	if err != nil {
+		writeError(w, err, "failed to authorize")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, location, http.StatusFound)
+}
+
+func (h *handler) login(w http.ResponseWriter, r *http.Request) {
+	// Otherwise any site could sign the browser in to an account of its choosing.
+	if !h.trustedOrigin(r) {
+		http.Error(w, "cross-origin sign-in rejected", http.StatusForbidden)
+		return
+	}
+
+	requestID, refreshToken := r.PostFormValue("request"), r.PostFormValue("refresh_token")
+	if requestID == "" || refreshToken == "" {
+		http.Error(w, "request and refresh_token are required", http.StatusBadRequest)
+		return
+	}
+
+	sessionID, expiresAt, location, err := h.ssoServ.CompleteLogin(r.Context(), requestID, refreshToken, h.sessionID(r))
+	if err != nil {
+		writeError(w, err, "failed to sign in")
+		return
+	}
+
+	http.SetCookie(w, h.newCookie(sessionID, expiresAt))
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, location, http.StatusSeeOther)
+}
+
+func (h *handler) logout(w http.ResponseWriter, r *http.Request) {
+	var appID int
+	if raw := r.FormValue("app_id"); raw != "" {
+		var err error
+		if appID, err = strconv.Atoi(raw); err != nil {
+			http.Error(w, "app_id must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	location, frontchannel, err := h.ssoServ.Logout(r.Context(), h.sessionID(r), appID, r.FormValue("post_logout_redirect_uri"), r.FormValue("state"))
+	if err != nil {
+		writeError(w, err, "failed to sign out")
+		return
+	}
+
+	http.SetCookie(w, h.newCookie("", time.Time{}))
+	if len(frontchannel) > 0 {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := frontchannelPage.Execute(w, frontchannelData{URIs: frontchannel, Next: location}); err != nil {
+			http.Error(w, "failed to sign out", http.StatusInternalServerError)
+		}
+		return
+	}
+	if location == "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("signed out\n"))
+		return
+	}
+
+	http.Redirect(w, r, location, http.StatusFound)
+}
+
+type frontchannelData struct {
+	URIs []string
+	Next string
+}
+
+// frontchannelPage signs the browser out of apps in hidden iframes and moves on to Next once
+// they loaded, or after a few seconds if some of them don't.
+var frontchannelPage = template.Must(template.New("frontchannel").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Signing out</title>
+<script>
+var pending = {{len .URIs}};
+function next() {
+	{{if .Next}}window.location.replace({{.Next}});{{end}}
+}
+function loaded() {
+	if (--pending === 0) next();
+}
+setTimeout(next, 5000);
+</script>
+</head>
+<body>
+<p>Signing out…</p>
+{{range .URIs}}<iframe src="{{.}}" onload="loaded()" hidden></iframe>
+{{end}}{{if .Next}}<noscript><a href="{{.Next}}">Continue</a></noscript>{{end}}
+</body>
+</html>
+`))
+
+func (h *handler) sessionID(r *http.Request) string {
+	c, err := r.Cookie(h.settings.CookieName)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// newCookie holds the session until it expires; an empty session ID deletes the cookie.
+// SameSite=Lax still sends it on the top-level navigations apps start sign-ins with.
+func (h *handler) newCookie(sessionID string, expiresAt time.Time) *http.Cookie {
+	c := &http.Cookie{
+		Name:     h.settings.CookieName,
+		Value:    sessionID,
+		Path:     "/",
+		Expires:  expiresAt,
+		Secure:   h.settings.CookieSecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if sessionID == "" {
+		c.MaxAge = -1
+	}
+	return c
+}
+
+// trustedOrigin reports whether the request came from the login page or this host. Browsers
+// send Origin with every POST; requests without one aren't from a browser.
+func (h *handler) trustedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == h.settings.LoginOrigin {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// writeError shows errors that can't go back to the app, like the ones about the app itself.
+func writeError(w http.ResponseWriter, err error, failMsg string) {
+	switch {
+	case errors.Is(err, sso.ErrInvalidRedirectURI):
+		http.Error(w, "redirect_uri is not registered for the app", http.StatusBadRequest)
+	case errors.Is(err, sso.ErrInvalidRequest):
+		http.Error(w, "sign-in request is invalid or expired", http.StatusBadRequest)
+	case errors.Is(err, sso.ErrLoginRequired):
+		http.Error(w, "sign in again to continue", http.StatusUnauthorized)
+	case errors.Is(err, repository.ErrAppNotFound):
+		http.Error(w, "unknown app_id", http.StatusBadRequest)
+	case errors.Is(err, auth.ErrAppDisabled):
+		http.Error(w, "app is disabled", http.StatusForbidden)
+	case errors.Is(err, auth.ErrGrantNotAllowed):
+		http.Error(w, "app does not allow sso sign-in", http.StatusForbidden)
+	default:
+		http.Error(w, failMsg, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,167 @@
+package ssohttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/internal/domain/models"
+	"auth/internal/services/sso"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeService struct {
+	sessionID    string
+	frontchannel []string
+	req          models.SSOAuthorizeRequest
+	prompt       string
+	maxAge       time.Duration
+	err          error
+}
+
+func (f *fakeService) Authorize(_ context.Context, sessionID string, req models.SSOAuthorizeRequest, prompt string, maxAge time.Duration) (string, error) {
+	f.sessionID, f.req, f.prompt, f.maxAge = sessionID, req, prompt, maxAge
+	return "https://one.example.com/cb?code=c", f.err
+}
+
+func (f *fakeService) CompleteLogin(_ context.Context, _, _, sessionID string) (string, time.Time, string, error) {
+	f.sessionID = sessionID
+	return "sso-2", time.Now().Add(time.Hour), "https://one.example.com/cb?code=c", f.err
+}
+
+func (f *fakeService) Logout(_ context.Context, sessionID string, _ int, redirectURI, _ string) (string, []string, error) {
+	f.sessionID = sessionID
+	return redirectURI, f.frontchannel, f.err
+}
+
+func TestHandler(t *testing.T) {
+	serv := &fakeService{}
+	mux := http.NewServeMux()
+	Register(mux, serv, Settings{CookieName: "sso", CookieSecure: true, LoginOrigin: "https://login.example.com"})
+
+	withCookie := func(r *http.Request) *http.Request {
+		r.AddCookie(&http.Cookie{Name: "sso", Value: "sso-1"})
+		return r
+	}
+
+	t.Run("authorize", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		target := "/sso/authorize?app_id=1&org_id=5&redirect_uri=https%3A%2F%2Fone.example.com%2Fcb&state=xyz&prompt=none&max_age=60"
+		mux.ServeHTTP(rec, withCookie(httptest.NewRequest(http.MethodGet, target, nil)))
+
+		require.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://one.example.com/cb?code=c", rec.Header().Get("Location"))
+		assert.Equal(t, "sso-1", serv.sessionID)
+		assert.Equal(t, models.SSOAuthorizeRequest{AppID: 1, OrgID: 5, RedirectURI: "https://one.example.com/cb", State: "xyz"}, serv.req)
+		assert.Equal(t, models.PromptNone, serv.prompt)
+		assert.Equal(t, time.Minute, serv.maxAge)
+
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sso/authorize?app_id=1&redirect_uri=x", nil))
+		require.Equal(t, http.StatusFound, rec.Code)
+		assert.Empty(t, serv.sessionID)
+		assert.Equal(t, time.Duration(-1), serv.maxAge, "no max_age accepts any")
+	})
+
+	t.Run("authorize rejects bad parameters", func(t *testing.T) {
+		for _, query := range []string{"", "app_id=x", "app_id=1&max_age=-1", "app_id=1&org_id=x"} {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sso/authorize?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+
+	t.Run("authorize errors stay here", func(t *testing.T) {
+		serv.err = sso.ErrInvalidRedirectURI
+		defer func() { serv.err = nil }()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sso/authorize?app_id=1&redirect_uri=https%3A%2F%2Fevil.example.com", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, rec.Header().Get("Location"))
+	})
+
+	login := func(origin string) *httptest.ResponseRecorder {
+		form := url.Values{"request": {"r"}, "refresh_token": {"t"}}
+		r := httptest.NewRequest(http.MethodPost, "/sso/login", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, withCookie(r))
+		return rec
+	}
+
+	t.Run("login sets the cookie", func(t *testing.T) {
+		rec := login("https://login.example.com")
+
+		require.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Equal(t, "https://one.example.com/cb?code=c", rec.Header().Get("Location"))
+		assert.Equal(t, "sso-1", serv.sessionID)
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, "sso", cookies[0].Name)
+		assert.Equal(t, "sso-2", cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.True(t, cookies[0].Secure)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	})
+
+	t.Run("login rejects other origins", func(t *testing.T) {
+		rec := login("https://evil.example.com")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, rec.Result().Cookies())
+	})
+
+	t.Run("login errors", func(t *testing.T) {
+		serv.err = sso.ErrLoginRequired
+		defer func() { serv.err = nil }()
+
+		rec := login("")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, rec.Result().Cookies())
+	})
+
+	t.Run("logout clears the cookie", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, withCookie(httptest.NewRequest(http.MethodGet, "/sso/logout?app_id=1&post_logout_redirect_uri=https%3A%2F%2Fone.example.com%2F", nil)))
+
+		require.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://one.example.com/", rec.Header().Get("Location"))
+		assert.Equal(t, "sso-1", serv.sessionID)
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, "sso", cookies[0].Name)
+		assert.Negative(t, cookies[0].MaxAge)
+
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/sso/logout", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("logout loads front-channel logout URIs", func(t *testing.T) {
+		serv.frontchannel = []string{"https://two.example.com/logout?iss=https%3A%2F%2Fsso.example.com&sid=s-b"}
+		defer func() { serv.frontchannel = nil }()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, withCookie(httptest.NewRequest(http.MethodGet, "/sso/logout?app_id=1&post_logout_redirect_uri=https%3A%2F%2Fone.example.com%2F", nil)))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Location"), "the page continues once the apps are signed out")
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+		assert.Len(t, rec.Result().Cookies(), 1)
+
+		body := rec.Body.String()
+		assert.Contains(t, body, `<iframe src="https://two.example.com/logout?iss=https%3A%2F%2Fsso.example.com&amp;sid=s-b"`)
+		assert.Contains(t, body, `window.location.replace("https://one.example.com/")`)
+	})
+}
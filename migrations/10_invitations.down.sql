@@ -0,0 +1,3 @@
+ALTER TABLE apps DROP COLUMN IF EXISTS invite_only;
+
+DROP TABLE IF EXISTS invitations;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS invitations (
+    id BIGSERIAL PRIMARY KEY,
+    org_id BIGINT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    invited_by INT REFERENCES users (id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    accepted_by INT REFERENCES users (id) ON DELETE SET NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Only one open invitation per address and organization; an expired one has to be resent or revoked.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invitations_open
+    ON invitations (org_id, lower(email))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS invite_only BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_audit_log_service_account_id;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS service_account_id;
+
+DROP TABLE IF EXISTS service_account_roles;
+DROP TABLE IF EXISTS service_account_keys;
+DROP TABLE IF EXISTS service_accounts;
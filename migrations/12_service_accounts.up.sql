@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id BIGSERIAL PRIMARY KEY,
+    app_id INT NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    disabled BOOLEAN NOT NULL DEFAULT false,
+    created_by INT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (app_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS service_account_keys (
+    id TEXT PRIMARY KEY,
+    service_account_id BIGINT NOT NULL REFERENCES service_accounts (id) ON DELETE CASCADE,
+    public_key TEXT NOT NULL,
+    algorithm TEXT NOT NULL,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_account_keys_account_id ON service_account_keys (service_account_id);
+
+CREATE TABLE IF NOT EXISTS service_account_roles (
+    service_account_id BIGINT NOT NULL REFERENCES service_accounts (id) ON DELETE CASCADE,
+    role_id BIGINT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (service_account_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_account_roles_role_id ON service_account_roles (role_id);
+
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS service_account_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_service_account_id ON audit_log (service_account_id) WHERE service_account_id IS NOT NULL;
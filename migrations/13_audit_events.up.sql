@@ -0,0 +1,22 @@
+ALTER TABLE audit_log
+    ADD COLUMN IF NOT EXISTS app_id INT,
+    ADD COLUMN IF NOT EXISTS ip TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS outcome TEXT NOT NULL DEFAULT 'success',
+    ADD COLUMN IF NOT EXISTS prev_hash TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS hash TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log (action text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (created_at);
+
+-- Entries are never changed. Deleting is left to retention pruning, which removes the oldest ones.
+CREATE OR REPLACE FUNCTION audit_log_reject_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_reject_update();
@@ -0,0 +1,29 @@
+-- Upstream OpenID Connect providers users can sign in with. The client secret is encrypted
+-- like app secrets are.
+CREATE TABLE IF NOT EXISTS identity_providers (
+    id SERIAL PRIMARY KEY,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    issuer TEXT NOT NULL,
+    client_id TEXT NOT NULL,
+    client_secret BYTEA NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{email,profile}',
+    allowed_email_domains TEXT[] NOT NULL DEFAULT '{}',
+    jit_provisioning BOOLEAN NOT NULL DEFAULT false,
+    link_by_email BOOLEAN NOT NULL DEFAULT false,
+    disabled BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- A user has at most one identity per provider, and an upstream subject belongs to one user.
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider_id INT NOT NULL REFERENCES identity_providers (id) ON DELETE CASCADE,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_login_at TIMESTAMPTZ,
+    UNIQUE (provider_id, subject),
+    UNIQUE (user_id, provider_id)
+);
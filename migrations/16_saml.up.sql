@@ -0,0 +1,15 @@
+-- Service providers users can sign in to with SAML. Each belongs to an app of the registry, so
+-- disabling the app or taking the saml grant away from it stops sign-ins too.
+CREATE TABLE IF NOT EXISTS saml_service_providers (
+    id SERIAL PRIMARY KEY,
+    app_id INT NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    entity_id TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    acs_url TEXT NOT NULL,
+    slo_url TEXT NOT NULL DEFAULT '',
+    certificate TEXT NOT NULL DEFAULT '',
+    name_id_format TEXT NOT NULL DEFAULT 'urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress',
+    attribute_map JSONB NOT NULL DEFAULT '{}',
+    disabled BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
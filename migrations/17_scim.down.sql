@@ -0,0 +1,12 @@
+DROP TRIGGER IF EXISTS groups_bump_version ON groups;
+DROP TRIGGER IF EXISTS users_bump_version ON users;
+DROP FUNCTION IF EXISTS bump_version();
+
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS external_id;
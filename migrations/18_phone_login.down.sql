@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS users_verified_phone_key;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_verified;
+
+-- Fails while there are users without an email.
+DROP INDEX IF EXISTS users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
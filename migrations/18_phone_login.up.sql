@@ -0,0 +1,9 @@
+-- Users can sign up with a phone number instead of an email, so emails only have to be unique
+-- among users who have one.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email) WHERE email <> '';
+
+-- A verified phone number identifies its user like an email does. Unverified numbers are only
+-- profile data.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified BOOLEAN NOT NULL DEFAULT false;
+CREATE UNIQUE INDEX IF NOT EXISTS users_verified_phone_key ON users (phone) WHERE phone_verified;
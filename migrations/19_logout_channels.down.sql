@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS logout_deliveries;
+ALTER TABLE apps
+    DROP COLUMN IF EXISTS backchannel_logout_uri,
+    DROP COLUMN IF EXISTS frontchannel_logout_uri;
@@ -0,0 +1,21 @@
+ALTER TABLE apps
+    ADD COLUMN IF NOT EXISTS backchannel_logout_uri TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS frontchannel_logout_uri TEXT NOT NULL DEFAULT '';
+
+-- Logout tokens owed to apps for app sessions that ended with their SSO session. The token is
+-- signed when it is sent, so retries don't carry an expired one.
+CREATE TABLE IF NOT EXISTS logout_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    app_id INT NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    session_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_status_code INT,
+    last_error TEXT NOT NULL DEFAULT '',
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_logout_deliveries_due ON logout_deliveries (next_attempt_at) WHERE status = 'pending';
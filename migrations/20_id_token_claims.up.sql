@@ -0,0 +1 @@
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS id_token_claims TEXT[] NOT NULL DEFAULT '{}';
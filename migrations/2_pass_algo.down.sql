@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS pass_algo;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pass_algo TEXT NOT NULL DEFAULT 'bcrypt';
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE apps DROP COLUMN IF EXISTS token_claims;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS display_name,
+    DROP COLUMN IF EXISTS given_name,
+    DROP COLUMN IF EXISTS family_name,
+    DROP COLUMN IF EXISTS locale,
+    DROP COLUMN IF EXISTS timezone,
+    DROP COLUMN IF EXISTS phone,
+    DROP COLUMN IF EXISTS user_metadata,
+    DROP COLUMN IF EXISTS app_metadata;
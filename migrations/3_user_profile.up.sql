@@ -0,0 +1,11 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS display_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS given_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS family_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS phone TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS user_metadata JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS app_metadata JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS token_claims TEXT[] NOT NULL DEFAULT '{}';
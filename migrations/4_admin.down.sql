@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS audit_log;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS is_admin,
+    DROP COLUMN IF EXISTS disabled,
+    DROP COLUMN IF EXISTS email_verified,
+    DROP COLUMN IF EXISTS password_reset_required;
@@ -0,0 +1,23 @@
+-- Encrypted secrets cannot be recovered here: every app gets new random secrets.
+ALTER TABLE apps
+    ADD COLUMN IF NOT EXISTS access_secret TEXT,
+    ADD COLUMN IF NOT EXISTS refresh_secret TEXT;
+
+UPDATE apps SET
+    access_secret = md5(random()::text || id::text),
+    refresh_secret = md5(random()::text || id::text);
+
+ALTER TABLE apps
+    ALTER COLUMN access_secret SET NOT NULL,
+    ALTER COLUMN refresh_secret SET NOT NULL,
+    ADD CONSTRAINT apps_access_secret_key UNIQUE (access_secret),
+    ADD CONSTRAINT apps_refresh_secret_key UNIQUE (refresh_secret);
+
+DROP TABLE IF EXISTS app_secrets;
+
+ALTER TABLE apps
+    DROP COLUMN IF EXISTS redirect_uris,
+    DROP COLUMN IF EXISTS grant_types,
+    DROP COLUMN IF EXISTS access_ttl_seconds,
+    DROP COLUMN IF EXISTS refresh_ttl_seconds,
+    DROP COLUMN IF EXISTS enabled;
@@ -0,0 +1,29 @@
+ALTER TABLE apps
+    ADD COLUMN IF NOT EXISTS redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS grant_types TEXT[] NOT NULL DEFAULT '{password,refresh_token}',
+    ADD COLUMN IF NOT EXISTS access_ttl_seconds INT,
+    ADD COLUMN IF NOT EXISTS refresh_ttl_seconds INT,
+    ADD COLUMN IF NOT EXISTS enabled BOOLEAN NOT NULL DEFAULT true;
+
+-- Secrets that have not been encrypted by the service yet are kept with encrypted = false
+-- and are encrypted in place on the next start.
+CREATE TABLE IF NOT EXISTS app_secrets (
+    id BIGSERIAL PRIMARY KEY,
+    app_id INT NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    secret BYTEA NOT NULL,
+    encrypted BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_app_secrets_app_id ON app_secrets (app_id);
+
+INSERT INTO app_secrets (app_id, kind, secret, encrypted)
+SELECT id, 'access', convert_to(access_secret, 'UTF8'), false FROM apps
+UNION ALL
+SELECT id, 'refresh', convert_to(refresh_secret, 'UTF8'), false FROM apps;
+
+ALTER TABLE apps
+    DROP COLUMN IF EXISTS access_secret,
+    DROP COLUMN IF EXISTS refresh_secret;
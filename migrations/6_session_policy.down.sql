@@ -0,0 +1,3 @@
+ALTER TABLE apps
+    DROP COLUMN IF EXISTS refresh_idle_timeout_seconds,
+    DROP COLUMN IF EXISTS max_sessions;
@@ -0,0 +1,3 @@
+ALTER TABLE apps
+    ADD COLUMN IF NOT EXISTS refresh_idle_timeout_seconds INT,
+    ADD COLUMN IF NOT EXISTS max_sessions INT;
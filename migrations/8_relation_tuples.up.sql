@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS authz_namespaces (
+    name TEXT PRIMARY KEY,
+    config JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Every tuple write gets a revision. Consistency tokens handed out to clients encode one.
+CREATE TABLE IF NOT EXISTS authz_revisions (
+    rev BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS relation_tuples (
+    namespace TEXT NOT NULL,
+    object_id TEXT NOT NULL,
+    relation TEXT NOT NULL,
+    subject_namespace TEXT NOT NULL,
+    subject_id TEXT NOT NULL,
+    subject_relation TEXT NOT NULL DEFAULT '',
+    created_rev BIGINT NOT NULL REFERENCES authz_revisions (rev),
+    PRIMARY KEY (namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+);
+
+CREATE INDEX IF NOT EXISTS idx_relation_tuples_subject ON relation_tuples (subject_namespace, subject_id, subject_relation);
@@ -0,0 +1,93 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnsupportedKey = errors.New("unsupported public key")
+
+const minRSABits = 2048
+
+// ParsePublicKey reads a PEM encoded PKIX public key and returns it with the signing algorithm
+// assertions made with it must use: RS256 for RSA, ES256 for P-256 and EdDSA for Ed25519 keys.
+func ParsePublicKey(data string) (crypto.PublicKey, string, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, "", fmt.Errorf("%w: expected a PEM \"PUBLIC KEY\" block", ErrUnsupportedKey)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupportedKey, err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			return nil, "", fmt.Errorf("%w: RSA keys must have at least %d bits", ErrUnsupportedKey, minRSABits)
+		}
+		return k, jwt.SigningMethodRS256.Alg(), nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, "", fmt.Errorf("%w: only P-256 EC keys are supported", ErrUnsupportedKey)
+		}
+		return k, jwt.SigningMethodES256.Alg(), nil
+	case ed25519.PublicKey:
+		return k, jwt.SigningMethodEdDSA.Alg(), nil
+	}
+
+	return nil, "", fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+}
+
+// AssertionKeyFunc returns the public key registered under kid and its algorithm.
+type AssertionKeyFunc func(kid string) (crypto.PublicKey, string, error)
+
+// ParseAssertion verifies a JWT-bearer assertion (RFC 7523) signed with the key named by its
+// "kid" header. The assertion must be addressed to audience, have iss equal to sub, and be
+// valid for no longer than maxTTL.
+func ParseAssertion(token, audience string, maxTTL time.Duration, keyFunc AssertionKeyFunc) (*jwt.RegisteredClaims, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("kid header is required")
+		}
+
+		key, alg, err := keyFunc(kid)
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != alg {
+			return nil, fmt.Errorf("key %s requires %s", kid, alg)
+		}
+		return key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Subject == "" || claims.Issuer != claims.Subject {
+		return nil, fmt.Errorf("%w: iss and sub must name the same principal", ErrInvalidToken)
+	}
+	if claims.IssuedAt == nil || claims.ExpiresAt.Sub(claims.IssuedAt.Time) > maxTTL {
+		return nil, fmt.Errorf("%w: assertion must have iat and live at most %s", ErrInvalidToken, maxTTL)
+	}
+
+	return &claims, nil
+}
@@ -0,0 +1,117 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// IDTokenClaims are the claims of an OpenID Connect ID token that sign-in relies on.
+type IDTokenClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IDTokenKeyFunc returns the provider key named by kid.
+type IDTokenKeyFunc func(kid string) (crypto.PublicKey, error)
+
+var idTokenMethods = []string{
+	jwt.SigningMethodRS256.Alg(), jwt.SigningMethodRS384.Alg(), jwt.SigningMethodRS512.Alg(),
+	jwt.SigningMethodPS256.Alg(), jwt.SigningMethodES256.Alg(), jwt.SigningMethodES384.Alg(),
+}
+
+// ParseIDToken verifies an ID token issued by issuer to the client audience. Symmetric and
+// unsigned tokens are never accepted, so the provider keys must be public ones.
+func ParseIDToken(token, issuer, audience string, keyFunc IDTokenKeyFunc) (*IDTokenClaims, error) {
+	var claims IDTokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return keyFunc(kid)
+	},
+		jwt.WithValidMethods(idTokenMethods),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: sub is required", ErrInvalidToken)
+	}
+
+	return &claims, nil
+}
+
+// SignIDToken signs claims as an RS256 ID token with key, naming it kid. It is meant for
+// standing in for a provider in tests.
+func SignIDToken(key *rsa.PrivateKey, kid string, claims *IDTokenClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
+
+// AppIDTokenClaims are the claims of the ID tokens issued to apps. The subject is the user ID
+// and the audience the app ID.
+type AppIDTokenClaims struct {
+	Email string `json:"email,omitempty"`
+	// SessionID is the app session the token was issued with, as in the app's logout tokens.
+	SessionID string           `json:"sid,omitempty"`
+	AuthTime  *jwt.NumericDate `json:"auth_time,omitempty"`
+	ACR       string           `json:"acr,omitempty"`
+	AMR       []string         `json:"amr,omitempty"`
+	ProfileClaims
+	jwt.RegisteredClaims
+}
+
+// GenerateIDJWT signs an ID token for the user of the app with the app's access secret. Of the
+// options only the session, authentication and profile ones apply.
+func GenerateIDJWT(secret, issuer string, userID int64, email string, appID int, ttl time.Duration, opts ...Option) (string, error) {
+	now := time.Now()
+	c := NewClaims(userID, email, appID, now, now.Add(ttl), opts...)
+	claims := AppIDTokenClaims{
+		Email:         email,
+		SessionID:     c.SessionID,
+		AuthTime:      c.AuthTime,
+		ACR:           c.ACR,
+		AMR:           c.AMR,
+		ProfileClaims: c.ProfileClaims,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatInt(userID, 10),
+			Audience:  jwt.ClaimStrings{strconv.Itoa(appID)},
+			IssuedAt:  c.IssuedAt,
+			ExpiresAt: c.ExpiresAt,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+// ParseIDJWT verifies an ID token the issuer gave the app.
+func ParseIDJWT(secret, token, issuer string, appID int) (*AppIDTokenClaims, error) {
+	var claims AppIDTokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return []byte(secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(strconv.Itoa(appID)),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &claims, nil
+}
@@ -3,32 +3,306 @@ package jwt
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var (
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrSignatureInvalid = errors.New("token signature is invalid")
+)
+
+// ProfileClaims are optional user attributes an app can ask to have projected into its tokens.
+type ProfileClaims struct {
+	Name         string         `json:"name,omitempty"`
+	GivenName    string         `json:"given_name,omitempty"`
+	FamilyName   string         `json:"family_name,omitempty"`
+	Locale       string         `json:"locale,omitempty"`
+	Zoneinfo     string         `json:"zoneinfo,omitempty"`
+	PhoneNumber  string         `json:"phone_number,omitempty"`
+	UserMetadata map[string]any `json:"user_metadata,omitempty"`
+	AppMetadata  map[string]any `json:"app_metadata,omitempty"`
+}
+
+// AuthzClaims carry the user's roles and permissions in the app the token was issued for.
+// AuthzOverflow is set when they did not fit into the token and have to be looked up instead.
+type AuthzClaims struct {
+	Roles         []string `json:"roles,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`
+	AuthzOverflow bool     `json:"authz_overflow,omitempty"`
+}
+
+// Principal types tell tokens of people apart from tokens of machines.
+const (
+	PrincipalUser           = "user"
+	PrincipalServiceAccount = "service_account"
+)
+
+// Authentication methods (RFC 8176) tokens list in amr. There is no hwk: no flow verifies a
+// hardware key, so a level only one could reach would be a promise tokens can't keep.
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	AMRSMS      = "sms"
+)
+
+// Authentication context levels tokens carry in acr. Each level includes the ones below it.
+const (
+	ACRSingleFactor = "1"
+	ACRMultiFactor  = "2"
+)
+
+// ACRFor returns the level the methods reach together, or "" for none.
+func ACRFor(amr []string) string {
+	switch {
+	case len(amr) > 1:
+		return ACRMultiFactor
+	case len(amr) == 1:
+		return ACRSingleFactor
+	default:
+		return ""
+	}
+}
+
+// ACRAtLeast reports whether level acr includes level min.
+func ACRAtLeast(acr, min string) bool {
+	have, err := strconv.Atoi(acr)
+	if err != nil {
+		return false
+	}
+	want, err := strconv.Atoi(min)
+	if err != nil {
+		return false
+	}
+	return have >= want
+}
+
+type ActorClaims struct {
+	Subject string `json:"sub"`
+	UserID  int64  `json:"user_id"`
+	Email   string `json:"email,omitempty"`
+}
+
 type Claims struct {
 	UserID    int64  `json:"user_id"`
 	UserEmail string `json:"user_email"`
 	AppID     int    `json:"app_id"`
+	// PrincipalType is PrincipalUser or PrincipalServiceAccount. Tokens issued before it was
+	// introduced don't have it and belong to users.
+	PrincipalType    string `json:"principal_type,omitempty"`
+	ServiceAccountID int64  `json:"service_account_id,omitempty"`
+	// SessionID is the refresh session the token was issued from, so it can be revoked with it.
+	SessionID string `json:"sid,omitempty"`
+	OrgID     int64  `json:"org_id,omitempty"`
+	OrgRole   string `json:"org_role,omitempty"`
+	// Scopes restricts what the token may be used for. Tokens without scopes are unrestricted.
+	Scopes []string `json:"scopes,omitempty"`
+	// Act names who is acting on behalf of the user of an impersonation token (RFC 8693).
+	Act *ActorClaims `json:"act,omitempty"`
+	// AuthTime is when the user last authenticated in the session, reaching ACR with the AMR
+	// methods. Tokens not issued from a sign-in don't have them.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	ACR      string           `json:"acr,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
+	ProfileClaims
+	AuthzClaims
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(secret string, userID int64, email string, appID int, ttl time.Duration) (string, error) {
-	claims := Claims{
-		UserID:    userID,
-		UserEmail: email,
-		AppID:     appID,
+// HasScope reports whether the token may be used for scope.
+func (c *Claims) HasScope(scope string) bool {
+	return c.Scopes == nil || slices.Contains(c.Scopes, scope)
+}
+
+func (c *Claims) IsServiceAccount() bool {
+	return c.PrincipalType == PrincipalServiceAccount
+}
+
+// AuthenticatedWithin reports whether the user reached at least level acr no longer than maxAge
+// before now, for resource servers guarding sensitive operations.
+func (c *Claims) AuthenticatedWithin(acr string, maxAge time.Duration, now time.Time) bool {
+	if c.AuthTime == nil || now.Sub(c.AuthTime.Time) > maxAge {
+		return false
+	}
+	return ACRAtLeast(c.ACR, acr)
+}
+
+type Option func(*Claims)
+
+func WithProfile(profile ProfileClaims) Option {
+	return func(c *Claims) {
+		c.ProfileClaims = profile
+	}
+}
+
+// WithOrganization scopes the token to an organization the user is a member of.
+func WithOrganization(orgID int64, role string) Option {
+	return func(c *Claims) {
+		c.OrgID = orgID
+		c.OrgRole = role
+	}
+}
+
+// WithAuthorization adds roles and permissions claims. If together they take more than
+// maxBytes of JSON, permissions are left out, then roles too if they still don't fit,
+// and AuthzOverflow is set. A maxBytes of zero or less means no limit.
+func WithAuthorization(roles, permissions []string, maxBytes int) Option {
+	return func(c *Claims) {
+		authz := AuthzClaims{Roles: roles, Permissions: permissions}
+		if maxBytes > 0 && claimsSize(authz) > maxBytes {
+			authz.Permissions = nil
+			authz.AuthzOverflow = true
+			if claimsSize(authz) > maxBytes {
+				authz.Roles = nil
+			}
+		}
+		c.AuthzClaims = authz
+	}
+}
+
+func claimsSize(authz AuthzClaims) int {
+	data, _ := json.Marshal(authz)
+	return len(data)
+}
+
+// WithServiceAccount makes the token one of a service account rather than of a user.
+func WithServiceAccount(serviceAccountID int64) Option {
+	return func(c *Claims) {
+		c.PrincipalType = PrincipalServiceAccount
+		c.ServiceAccountID = serviceAccountID
+	}
+}
+
+// WithActor marks the token as issued to actorID acting as the token's user. The user becomes
+// the token's subject.
+func WithActor(actorID int64, actorEmail string) Option {
+	return func(c *Claims) {
+		c.Subject = strconv.FormatInt(c.UserID, 10)
+		c.Act = &ActorClaims{Subject: strconv.FormatInt(actorID, 10), UserID: actorID, Email: actorEmail}
+	}
+}
+
+// WithScopes restricts the token to the given scopes.
+func WithScopes(scopes []string) Option {
+	return func(c *Claims) {
+		c.Scopes = scopes
+	}
+}
+
+// WithSessionID ties the token to the refresh session it was issued from.
+func WithSessionID(sessionID string) Option {
+	return func(c *Claims) {
+		c.SessionID = sessionID
+	}
+}
+
+// WithAuthentication records when and how the user authenticated. The acr claim follows from
+// the methods.
+func WithAuthentication(authTime time.Time, amr []string) Option {
+	return func(c *Claims) {
+		c.AuthTime = jwt.NewNumericDate(authTime)
+		c.ACR = ACRFor(amr)
+		c.AMR = amr
+	}
+}
+
+// WithID sets the token identifier (jti).
+func WithID(id string) Option {
+	return func(c *Claims) {
+		c.ID = id
+	}
+}
+
+// NewClaims builds the claims of a token. A zero expiresAt leaves the expiry out.
+func NewClaims(userID int64, email string, appID int, issuedAt, expiresAt time.Time, opts ...Option) *Claims {
+	claims := &Claims{
+		UserID:        userID,
+		UserEmail:     email,
+		AppID:         appID,
+		PrincipalType: PrincipalUser,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt: jwt.NewNumericDate(issuedAt),
+		},
+	}
+	if !expiresAt.IsZero() {
+		claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	}
+	for _, opt := range opts {
+		opt(claims)
+	}
+	return claims
+}
+
+func GenerateJWT(secret string, userID int64, email string, appID int, ttl time.Duration, opts ...Option) (string, error) {
+	now := time.Now()
+	claims := NewClaims(userID, email, appID, now, now.Add(ttl), opts...)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+// ParseUnverified decodes token claims without checking the signature. It is only
+// meant for finding out which app, and therefore which secret, signed the token.
+func ParseUnverified(token string) (*Claims, error) {
+	var claims Claims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &claims, nil
+}
+
+func ParseJWT(secret, token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidToken, ErrSignatureInvalid)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &claims, nil
+}
+
+// InvitationClaims identify an invitation in an invitation link. The ID claim holds the
+// invitation's nonce so that resending the invitation voids earlier links.
+type InvitationClaims struct {
+	InvitationID int64 `json:"invitation_id"`
+	jwt.RegisteredClaims
+}
+
+func GenerateInvitationJWT(secret string, invitationID int64, nonce string, expiresAt time.Time) (string, error) {
+	claims := InvitationClaims{
+		InvitationID: invitationID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        nonce,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secret))
 }
 
+func ParseInvitationJWT(secret, token string) (*InvitationClaims, error) {
+	var claims InvitationClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &claims, nil
+}
+
 func GenerateRandomToken(n int) string {
 	b := make([]byte, n)
 	_, _ = rand.Read(b)
@@ -0,0 +1,231 @@
+package jwt_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/pkg/jwt"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAuthorization(t *testing.T) {
+	parse := func(t *testing.T, opt jwt.Option) *jwt.Claims {
+		token, err := jwt.GenerateJWT("secret", 1, "user@example.com", 2, time.Minute, opt)
+		require.NoError(t, err)
+
+		claims, err := jwt.ParseJWT("secret", token)
+		require.NoError(t, err)
+		return claims
+	}
+
+	roles := []string{"admin", "editor"}
+	perms := []string{"posts:read", "posts:write"}
+
+	t.Run("fits", func(t *testing.T) {
+		claims := parse(t, jwt.WithAuthorization(roles, perms, 1024))
+		assert.Equal(t, roles, claims.Roles)
+		assert.Equal(t, perms, claims.Permissions)
+		assert.False(t, claims.AuthzOverflow)
+	})
+
+	t.Run("permissions overflow", func(t *testing.T) {
+		claims := parse(t, jwt.WithAuthorization(roles, perms, 60))
+		assert.Equal(t, roles, claims.Roles)
+		assert.Empty(t, claims.Permissions)
+		assert.True(t, claims.AuthzOverflow)
+	})
+
+	t.Run("roles overflow", func(t *testing.T) {
+		many := []string{strings.Repeat("r", 100)}
+		claims := parse(t, jwt.WithAuthorization(many, perms, 60))
+		assert.Empty(t, claims.Roles)
+		assert.Empty(t, claims.Permissions)
+		assert.True(t, claims.AuthzOverflow)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		claims := parse(t, jwt.WithAuthorization(roles, perms, 0))
+		assert.Equal(t, perms, claims.Permissions)
+	})
+}
+
+func TestInvitationJWT(t *testing.T) {
+	token, err := jwt.GenerateInvitationJWT("secret", 7, "nonce", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	claims, err := jwt.ParseInvitationJWT("secret", token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), claims.InvitationID)
+	assert.Equal(t, "nonce", claims.ID)
+
+	_, err = jwt.ParseInvitationJWT("other", token)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+
+	expired, err := jwt.GenerateInvitationJWT("secret", 7, "nonce", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	_, err = jwt.ParseInvitationJWT("secret", expired)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+}
+
+func TestClaimsHasScope(t *testing.T) {
+	unrestricted := jwt.NewClaims(1, "a@mail.com", 1, time.Now(), time.Time{})
+	assert.True(t, unrestricted.HasScope("admin"))
+	assert.Nil(t, unrestricted.ExpiresAt)
+
+	scoped := jwt.NewClaims(1, "a@mail.com", 0, time.Now(), time.Now().Add(time.Hour), jwt.WithScopes([]string{"profile"}))
+	assert.True(t, scoped.HasScope("profile"))
+	assert.False(t, scoped.HasScope("admin"))
+	assert.NotNil(t, scoped.ExpiresAt)
+}
+
+func TestParseAssertion(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	key, alg, err := jwt.ParsePublicKey(pemKey)
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", alg)
+
+	keyFunc := func(kid string) (crypto.PublicKey, string, error) {
+		if kid != "k1" {
+			return nil, "", errors.New("unknown key")
+		}
+		return key, alg, nil
+	}
+
+	sign := func(kid string, claims gojwt.RegisteredClaims) string {
+		token := gojwt.NewWithClaims(gojwt.SigningMethodEdDSA, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(priv)
+		require.NoError(t, err)
+		return signed
+	}
+
+	now := time.Now()
+	valid := gojwt.RegisteredClaims{
+		Issuer:    "42",
+		Subject:   "42",
+		Audience:  gojwt.ClaimStrings{"auth"},
+		IssuedAt:  gojwt.NewNumericDate(now),
+		ExpiresAt: gojwt.NewNumericDate(now.Add(time.Minute)),
+	}
+
+	claims, err := jwt.ParseAssertion(sign("k1", valid), "auth", 5*time.Minute, keyFunc)
+	require.NoError(t, err)
+	assert.Equal(t, "42", claims.Subject)
+
+	_, err = jwt.ParseAssertion(sign("k2", valid), "auth", 5*time.Minute, keyFunc)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+
+	_, err = jwt.ParseAssertion(sign("k1", valid), "other", 5*time.Minute, keyFunc)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+
+	longLived := valid
+	longLived.ExpiresAt = gojwt.NewNumericDate(now.Add(time.Hour))
+	_, err = jwt.ParseAssertion(sign("k1", longLived), "auth", 5*time.Minute, keyFunc)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+
+	mismatched := valid
+	mismatched.Issuer = "43"
+	_, err = jwt.ParseAssertion(sign("k1", mismatched), "auth", 5*time.Minute, keyFunc)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+}
+
+func TestWithActor(t *testing.T) {
+	token, err := jwt.GenerateJWT("secret", 7, "customer@example.com", 1, time.Minute, jwt.WithActor(3, "support@example.com"))
+	require.NoError(t, err)
+
+	claims, err := jwt.ParseJWT("secret", token)
+	require.NoError(t, err)
+	assert.Equal(t, "7", claims.Subject)
+	require.NotNil(t, claims.Act)
+	assert.Equal(t, "3", claims.Act.Subject)
+	assert.Equal(t, int64(3), claims.Act.UserID)
+	assert.Equal(t, "support@example.com", claims.Act.Email)
+}
+
+func TestAuthentication(t *testing.T) {
+	assert.Equal(t, "", jwt.ACRFor(nil))
+	assert.Equal(t, jwt.ACRSingleFactor, jwt.ACRFor([]string{jwt.AMRPassword}))
+	assert.Equal(t, jwt.ACRMultiFactor, jwt.ACRFor([]string{jwt.AMRPassword, jwt.AMRSMS}))
+
+	authTime := time.Now().Add(-2 * time.Minute).Truncate(time.Second)
+	token, err := jwt.GenerateJWT("secret", 1, "user@example.com", 2, time.Minute,
+		jwt.WithAuthentication(authTime, []string{jwt.AMRPassword, jwt.AMRSMS}))
+	require.NoError(t, err)
+	claims, err := jwt.ParseJWT("secret", token)
+	require.NoError(t, err)
+
+	assert.True(t, authTime.Equal(claims.AuthTime.Time))
+	assert.Equal(t, jwt.ACRMultiFactor, claims.ACR)
+	assert.Equal(t, []string{"pwd", "sms"}, claims.AMR)
+
+	now := time.Now()
+	assert.True(t, claims.AuthenticatedWithin(jwt.ACRMultiFactor, 5*time.Minute, now))
+	assert.True(t, claims.AuthenticatedWithin(jwt.ACRSingleFactor, 5*time.Minute, now))
+	assert.False(t, (&jwt.Claims{ACR: "1", AuthTime: claims.AuthTime}).AuthenticatedWithin(jwt.ACRMultiFactor, 5*time.Minute, now), "level too low")
+	assert.False(t, claims.AuthenticatedWithin(jwt.ACRMultiFactor, time.Minute, now), "too long ago")
+	assert.False(t, (&jwt.Claims{ACR: "2"}).AuthenticatedWithin(jwt.ACRMultiFactor, time.Hour, now), "no auth_time")
+}
+
+func TestLogoutJWT(t *testing.T) {
+	token, err := jwt.GenerateLogoutJWT("secret", "https://sso.example.com", 1, 2, "session", time.Minute)
+	require.NoError(t, err)
+
+	claims, err := jwt.ParseLogoutJWT("secret", token, "https://sso.example.com", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "session", claims.SessionID)
+	assert.Equal(t, "1", claims.Subject)
+	assert.NotEmpty(t, claims.ID)
+	assert.Contains(t, claims.Events, jwt.BackchannelLogoutEvent)
+
+	_, err = jwt.ParseLogoutJWT("secret", token, "https://sso.example.com", 3)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken, "other app")
+	_, err = jwt.ParseLogoutJWT("secret", token, "https://other.example.com", 2)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken, "other issuer")
+
+	access, err := jwt.GenerateJWT("secret", 1, "user@example.com", 2, time.Minute, jwt.WithSessionID("session"))
+	require.NoError(t, err)
+	_, err = jwt.ParseLogoutJWT("secret", access, "", 2)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken, "access tokens are no logout tokens")
+}
+
+func TestIDJWT(t *testing.T) {
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	token, err := jwt.GenerateIDJWT("secret", "https://sso.example.com", 1, "user@example.com", 2, time.Minute,
+		jwt.WithSessionID("session"),
+		jwt.WithAuthentication(authTime, []string{jwt.AMRPassword, jwt.AMRSMS}),
+		jwt.WithProfile(jwt.ProfileClaims{Name: "Ann", Locale: "en"}),
+		jwt.WithOrganization(3, "owner"),
+	)
+	require.NoError(t, err)
+
+	claims, err := jwt.ParseIDJWT("secret", token, "https://sso.example.com", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "1", claims.Subject)
+	assert.Equal(t, "user@example.com", claims.Email)
+	assert.Equal(t, "session", claims.SessionID)
+	assert.Equal(t, authTime, claims.AuthTime.Time)
+	assert.Equal(t, jwt.ACRMultiFactor, claims.ACR)
+	assert.Equal(t, jwt.ProfileClaims{Name: "Ann", Locale: "en"}, claims.ProfileClaims)
+
+	_, err = jwt.ParseIDJWT("secret", token, "https://sso.example.com", 3)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken, "other app")
+
+	access, err := jwt.GenerateJWT("secret", 1, "user@example.com", 2, time.Minute)
+	require.NoError(t, err)
+	_, err = jwt.ParseIDJWT("secret", access, "https://sso.example.com", 2)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken, "access tokens are no ID tokens")
+}
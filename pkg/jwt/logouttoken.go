@@ -0,0 +1,64 @@
+package jwt
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// BackchannelLogoutEvent marks a token as a logout token (OpenID Connect Back-Channel Logout 1.0).
+const BackchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+const logoutTokenIDBytes = 16
+
+// LogoutClaims tell an app that the user's session SessionID ended. The subject is the user ID
+// and the audience the app ID.
+type LogoutClaims struct {
+	SessionID string                    `json:"sid"`
+	Events    map[string]map[string]any `json:"events"`
+	jwt.RegisteredClaims
+}
+
+// GenerateLogoutJWT signs a logout token for the session of the user in the app with the app's
+// access secret.
+func GenerateLogoutJWT(secret, issuer string, userID int64, appID int, sessionID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := LogoutClaims{
+		SessionID: sessionID,
+		Events:    map[string]map[string]any{BackchannelLogoutEvent: {}},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        GenerateRandomToken(logoutTokenIDBytes),
+			Issuer:    issuer,
+			Subject:   strconv.FormatInt(userID, 10),
+			Audience:  jwt.ClaimStrings{strconv.Itoa(appID)},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+// ParseLogoutJWT verifies a logout token the issuer sent to the app. Apps should also drop
+// tokens whose ID they have already seen.
+func ParseLogoutJWT(secret, token, issuer string, appID int) (*LogoutClaims, error) {
+	var claims LogoutClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return []byte(secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(strconv.Itoa(appID)),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if _, ok := claims.Events[BackchannelLogoutEvent]; !ok || claims.SessionID == "" {
+		return nil, fmt.Errorf("%w: not a logout token", ErrInvalidToken)
+	}
+	return &claims, nil
+}
@@ -0,0 +1,193 @@
+// Package ldap checks passwords against an LDAP directory such as OpenLDAP or Active Directory.
+// A service account looks the user's entry up and a bind as that entry checks the password.
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid directory credentials")
+	ErrUserNotFound       = errors.New("user not found in directory")
+	// ErrAmbiguousUser is returned when the user filter matches more than one entry.
+	ErrAmbiguousUser = errors.New("user filter matches more than one entry")
+)
+
+const defaultTimeout = 10 * time.Second
+
+type Config struct {
+	// URL is an ldap:// or ldaps:// URL of the directory.
+	URL string
+	// StartTLS upgrades ldap:// connections before anything is sent.
+	StartTLS bool
+	// TLS is used for ldaps:// and StartTLS. The server name defaults to the host of the URL.
+	TLS *tls.Config
+
+	// BindDN and BindPassword are the service account users are looked up with. Searches are
+	// anonymous without a BindDN.
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter finds the user's entry; "{email}" is replaced with the escaped email.
+	UserFilter string
+	// EmailAttr holds the user's email, GroupAttr the DNs of the groups the user is in.
+	EmailAttr string
+	GroupAttr string
+
+	Timeout time.Duration
+}
+
+// Entry is the directory entry of an authenticated user.
+type Entry struct {
+	DN     string
+	Email  string
+	Groups []string
+}
+
+// MemberOf reports whether the entry is in the group. DNs are compared ignoring case and spacing.
+func (e Entry) MemberOf(groupDN string) bool {
+	want, err := goldap.ParseDN(groupDN)
+	if err != nil {
+		return false
+	}
+
+	for _, g := range e.Groups {
+		if dn, err := goldap.ParseDN(g); err == nil && dn.EqualFold(want) {
+			return true
+		}
+	}
+	return false
+}
+
+type Client struct {
+	cfg Config
+}
+
+func New(cfg Config) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return &Client{cfg: cfg}
+}
+
+// Authenticate finds the user's entry and binds as it with the password. A user who isn't in the
+// directory gets ErrUserNotFound, a wrong password ErrInvalidCredentials.
+func (c *Client) Authenticate(ctx context.Context, email, password string) (Entry, error) {
+	// Most directories treat a bind with a DN and no password as an anonymous bind that succeeds.
+	if password == "" {
+		return Entry{}, ErrInvalidCredentials
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer conn.Close()
+
+	if c.cfg.BindDN != "" {
+		if err := conn.Bind(c.cfg.BindDN, c.cfg.BindPassword); err != nil {
+			return Entry{}, fmt.Errorf("service account bind: %w", err)
+		}
+	}
+
+	entry, err := c.find(conn, email)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return Entry{}, ErrInvalidCredentials
+		}
+		return Entry{}, fmt.Errorf("user bind: %w", err)
+	}
+
+	found := Entry{
+		DN:     entry.DN,
+		Email:  entry.GetAttributeValue(c.cfg.EmailAttr),
+		Groups: entry.GetAttributeValues(c.cfg.GroupAttr),
+	}
+	if found.Email == "" {
+		found.Email = email
+	}
+
+	return found, nil
+}
+
+func (c *Client) find(conn *goldap.Conn, email string) (*goldap.Entry, error) {
+	filter := strings.ReplaceAll(c.cfg.UserFilter, "{email}", goldap.EscapeFilter(email))
+
+	// Two entries are enough to tell that the filter is ambiguous.
+	req := goldap.NewSearchRequest(c.cfg.BaseDN, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases,
+		2, int(c.cfg.Timeout/time.Second), false, filter, []string{c.cfg.EmailAttr, c.cfg.GroupAttr}, nil)
+
+	res, err := conn.Search(req)
+	if err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+			return nil, ErrAmbiguousUser
+		}
+		return nil, fmt.Errorf("search user: %w", err)
+	}
+
+	switch len(res.Entries) {
+	case 0:
+		return nil, ErrUserNotFound
+	case 1:
+		return res.Entries[0], nil
+	default:
+		return nil, ErrAmbiguousUser
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (*goldap.Conn, error) {
+	timeout := c.cfg.Timeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := goldap.DialURL(c.cfg.URL, goldap.DialWithDialer(&net.Dialer{Timeout: timeout}), goldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	conn.SetTimeout(timeout)
+
+	if c.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("start tls: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+func (c *Client) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if c.cfg.TLS != nil {
+		cfg = c.cfg.TLS.Clone()
+	}
+
+	if cfg.ServerName == "" {
+		u, err := url.Parse(c.cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("parse url: %w", err)
+		}
+		cfg.ServerName = u.Hostname()
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,148 @@
+package ldap_test
+
+import (
+	"context"
+	"testing"
+
+	"auth/pkg/ldap"
+	"auth/pkg/ldap/ldaptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	baseDN    = "dc=example,dc=com"
+	serviceDN = "cn=auth,ou=services,dc=example,dc=com"
+	adminsDN  = "cn=admins,ou=groups,dc=example,dc=com"
+)
+
+func directory(s *ldaptest.Server) {
+	s.Add(ldaptest.Entry{DN: serviceDN, Password: "svc-pass"})
+	s.Add(ldaptest.Entry{
+		DN:       "uid=ann,ou=people,dc=example,dc=com",
+		Password: "ann-pass",
+		Attributes: map[string][]string{
+			"objectClass": {"inetOrgPerson"},
+			"uid":         {"ann"},
+			"mail":        {"Ann@Example.com"},
+			"memberOf":    {adminsDN},
+		},
+	})
+	s.Add(ldaptest.Entry{
+		DN:         "uid=bob,ou=people,dc=example,dc=com",
+		Password:   "bob-pass",
+		Attributes: map[string][]string{"objectClass": {"inetOrgPerson"}, "uid": {"bob"}, "mail": {"shared@example.com"}},
+	})
+	s.Add(ldaptest.Entry{
+		DN:         "uid=bobby,ou=people,dc=example,dc=com",
+		Password:   "bobby-pass",
+		Attributes: map[string][]string{"objectClass": {"inetOrgPerson"}, "uid": {"bobby"}, "mail": {"shared@example.com"}},
+	})
+}
+
+func config(s *ldaptest.Server) ldap.Config {
+	return ldap.Config{
+		URL:          s.URL,
+		TLS:          s.ClientTLS(),
+		BindDN:       serviceDN,
+		BindPassword: "svc-pass",
+		BaseDN:       baseDN,
+		UserFilter:   "(&(objectClass=inetOrgPerson)(mail={email}))",
+		EmailAttr:    "mail",
+		GroupAttr:    "memberOf",
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+
+	s := ldaptest.New()
+	defer s.Close()
+	directory(s)
+
+	client := ldap.New(config(s))
+
+	t.Run("ok", func(t *testing.T) {
+		entry, err := client.Authenticate(ctx, "ann@example.com", "ann-pass")
+		require.NoError(t, err)
+		assert.Equal(t, "uid=ann,ou=people,dc=example,dc=com", entry.DN)
+		assert.Equal(t, "Ann@Example.com", entry.Email)
+		assert.Equal(t, []string{adminsDN}, entry.Groups)
+		assert.True(t, entry.MemberOf("CN=Admins, OU=Groups, DC=example, DC=com"))
+		assert.False(t, entry.MemberOf("cn=dev,ou=groups,dc=example,dc=com"))
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		_, err := client.Authenticate(ctx, "ann@example.com", "bob-pass")
+		assert.ErrorIs(t, err, ldap.ErrInvalidCredentials)
+	})
+
+	t.Run("empty password", func(t *testing.T) {
+		_, err := client.Authenticate(ctx, "ann@example.com", "")
+		assert.ErrorIs(t, err, ldap.ErrInvalidCredentials)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := client.Authenticate(ctx, "carl@example.com", "ann-pass")
+		assert.ErrorIs(t, err, ldap.ErrUserNotFound)
+	})
+
+	t.Run("filter is escaped", func(t *testing.T) {
+		_, err := client.Authenticate(ctx, "*)(uid=ann", "ann-pass")
+		assert.ErrorIs(t, err, ldap.ErrUserNotFound)
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		_, err := client.Authenticate(ctx, "shared@example.com", "bob-pass")
+		assert.ErrorIs(t, err, ldap.ErrAmbiguousUser)
+	})
+
+	t.Run("wrong service password", func(t *testing.T) {
+		cfg := config(s)
+		cfg.BindPassword = "nope"
+
+		_, err := ldap.New(cfg).Authenticate(ctx, "ann@example.com", "ann-pass")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ldap.ErrInvalidCredentials, "the user's password isn't what is wrong")
+	})
+}
+
+func TestTLS(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("start tls", func(t *testing.T) {
+		s := ldaptest.New()
+		defer s.Close()
+		directory(s)
+		s.RequireTLS()
+
+		_, err := ldap.New(config(s)).Authenticate(ctx, "ann@example.com", "ann-pass")
+		require.Error(t, err, "plain binds are refused")
+
+		cfg := config(s)
+		cfg.StartTLS = true
+		_, err = ldap.New(cfg).Authenticate(ctx, "ann@example.com", "ann-pass")
+		require.NoError(t, err)
+	})
+
+	t.Run("ldaps", func(t *testing.T) {
+		s := ldaptest.NewTLS()
+		defer s.Close()
+		directory(s)
+
+		_, err := ldap.New(config(s)).Authenticate(ctx, "ann@example.com", "ann-pass")
+		require.NoError(t, err)
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		s := ldaptest.NewTLS()
+		defer s.Close()
+		directory(s)
+
+		cfg := config(s)
+		cfg.TLS = nil
+		_, err := ldap.New(cfg).Authenticate(ctx, "ann@example.com", "ann-pass")
+		require.Error(t, err)
+	})
+}
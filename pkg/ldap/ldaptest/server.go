@@ -0,0 +1,415 @@
+// Package ldaptest runs an in-process LDAP directory for tests. It understands simple binds,
+// StartTLS and searches with equality, presence, and, or and not filters.
+package ldaptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const startTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// Entry is an entry of the directory. Entries with a password can be bound as.
+type Entry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+type Server struct {
+	// URL is where the directory listens, ldap:// for New and ldaps:// for NewTLS.
+	URL string
+
+	ln   net.Listener
+	tls  *tls.Config
+	cert *x509.Certificate
+	wg   sync.WaitGroup
+
+	mu         sync.Mutex
+	entries    []Entry
+	requireTLS bool
+	conns      map[net.Conn]struct{}
+}
+
+// New starts a directory that offers StartTLS on plain connections. Close it when done.
+func New() *Server {
+	return start(false)
+}
+
+// NewTLS starts a directory that only accepts TLS connections.
+func NewTLS() *Server {
+	return start(true)
+}
+
+func start(implicitTLS bool) *Server {
+	s := &Server{conns: make(map[net.Conn]struct{})}
+	s.tls, s.cert = selfSigned()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("ldaptest: listen: " + err.Error())
+	}
+
+	s.URL = "ldap://" + ln.Addr().String()
+	if implicitTLS {
+		ln = tls.NewListener(ln, s.tls)
+		s.URL = "ldaps://" + ln.Addr().String()
+	}
+	s.ln = ln
+
+	s.wg.Add(1)
+	go s.accept()
+
+	return s
+}
+
+// Add puts an entry into the directory.
+func (s *Server) Add(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+}
+
+// RequireTLS makes the directory refuse binds over plain connections.
+func (s *Server) RequireTLS() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requireTLS = true
+}
+
+// ClientTLS is a client configuration that trusts the directory's certificate.
+func (s *Server) ClientTLS() *tls.Config {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.cert)
+	return &tls.Config{RootCAs: pool}
+}
+
+func (s *Server) Close() {
+	s.ln.Close()
+
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serve(conn)
+		}()
+	}
+}
+
+type session struct {
+	conn   net.Conn
+	secure bool
+	bound  bool
+}
+
+func (s *Server) serve(conn net.Conn) {
+	_, implicitTLS := conn.(*tls.Conn)
+	sess := &session{conn: conn, secure: implicitTLS}
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, sess.conn)
+		s.mu.Unlock()
+		sess.conn.Close()
+	}()
+
+	for {
+		packet, err := ber.ReadPacket(sess.conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		id, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case goldap.ApplicationBindRequest:
+			code := s.bind(sess, op)
+			sess.bound = code == goldap.LDAPResultSuccess
+			sess.write(id, result(goldap.ApplicationBindResponse, code))
+		case goldap.ApplicationSearchRequest:
+			s.search(sess, id, op)
+		case goldap.ApplicationExtendedRequest:
+			if !s.startTLS(sess, id, op) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (s *Server) bind(sess *session, op *ber.Packet) int {
+	if len(op.Children) < 3 {
+		return goldap.LDAPResultProtocolError
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.requireTLS && !sess.secure {
+		return goldap.LDAPResultConfidentialityRequired
+	}
+
+	dn, _ := op.Children[1].Value.(string)
+	password := op.Children[2].Data.String()
+
+	switch {
+	case dn == "" && password == "":
+		return goldap.LDAPResultSuccess
+	case password == "":
+		return goldap.LDAPResultUnwillingToPerform
+	}
+
+	for _, e := range s.entries {
+		if sameDN(e.DN, dn) && e.Password != "" && e.Password == password {
+			return goldap.LDAPResultSuccess
+		}
+	}
+
+	return goldap.LDAPResultInvalidCredentials
+}
+
+func (s *Server) search(sess *session, id int64, op *ber.Packet) {
+	if len(op.Children) < 8 {
+		sess.write(id, result(goldap.ApplicationSearchResultDone, goldap.LDAPResultProtocolError))
+		return
+	}
+	if !sess.bound {
+		sess.write(id, result(goldap.ApplicationSearchResultDone, goldap.LDAPResultInsufficientAccessRights))
+		return
+	}
+
+	base, _ := op.Children[0].Value.(string)
+	sizeLimit, _ := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+
+	var attrs []string
+	for _, a := range op.Children[7].Children {
+		attrs = append(attrs, a.Data.String())
+	}
+
+	s.mu.Lock()
+	var matched []Entry
+	for _, e := range s.entries {
+		if inSubtree(e.DN, base) && matches(e, filter) {
+			matched = append(matched, e)
+		}
+	}
+	s.mu.Unlock()
+
+	code := goldap.LDAPResultSuccess
+	if sizeLimit > 0 && int64(len(matched)) > sizeLimit {
+		matched = matched[:sizeLimit]
+		code = goldap.LDAPResultSizeLimitExceeded
+	}
+
+	for _, e := range matched {
+		sess.write(id, searchEntry(e, attrs))
+	}
+	sess.write(id, result(goldap.ApplicationSearchResultDone, code))
+}
+
+// startTLS upgrades the connection and reports whether it can still be used.
+func (s *Server) startTLS(sess *session, id int64, op *ber.Packet) bool {
+	if len(op.Children) == 0 || op.Children[0].Data.String() != startTLSOID || sess.secure {
+		sess.write(id, result(goldap.ApplicationExtendedResponse, goldap.LDAPResultProtocolError))
+		return true
+	}
+
+	sess.write(id, result(goldap.ApplicationExtendedResponse, goldap.LDAPResultSuccess))
+
+	conn := tls.Server(sess.conn, s.tls)
+	if err := conn.Handshake(); err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	delete(s.conns, sess.conn)
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+
+	sess.conn = conn
+	sess.secure = true
+
+	return true
+}
+
+func (sess *session) write(id int64, op *ber.Packet) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	packet.AppendChild(op)
+
+	sess.conn.Write(packet.Bytes())
+}
+
+func result(tag ber.Tag, code int) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, goldap.LDAPResultCodeMap[uint16(code)], "Diagnostic Message"))
+	return op
+}
+
+func searchEntry(e Entry, attrs []string) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, "DN"))
+
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range e.Attributes {
+		if !requested(attrs, name) {
+			continue
+		}
+
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "Value"))
+		}
+		attr.AppendChild(set)
+		list.AppendChild(attr)
+	}
+	op.AppendChild(list)
+
+	return op
+}
+
+func requested(attrs []string, name string) bool {
+	if len(attrs) == 0 {
+		return true
+	}
+	for _, a := range attrs {
+		if a == "*" || strings.EqualFold(a, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(e Entry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case goldap.FilterAnd:
+		for _, f := range filter.Children {
+			if !matches(e, f) {
+				return false
+			}
+		}
+		return true
+	case goldap.FilterOr:
+		for _, f := range filter.Children {
+			if matches(e, f) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterNot:
+		return len(filter.Children) == 1 && !matches(e, filter.Children[0])
+	case goldap.FilterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		for _, v := range values(e, filter.Children[0].Data.String()) {
+			if strings.EqualFold(v, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterPresent:
+		return len(values(e, filter.Data.String())) > 0
+	default:
+		return false
+	}
+}
+
+func values(e Entry, attr string) []string {
+	for name, vals := range e.Attributes {
+		if strings.EqualFold(name, attr) {
+			return vals
+		}
+	}
+	return nil
+}
+
+func inSubtree(dn, base string) bool {
+	dn, base = normalizeDN(dn), normalizeDN(base)
+	return base == "" || dn == base || strings.HasSuffix(dn, ","+base)
+}
+
+func sameDN(a, b string) bool {
+	return normalizeDN(a) == normalizeDN(b)
+}
+
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, p := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(p))
+	}
+	return strings.Join(parts, ",")
+}
+
+func selfSigned() (*tls.Config, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic("ldaptest: generate key: " + err.Error())
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ldaptest"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		panic("ldaptest: create certificate: " + err.Error())
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic("ldaptest: parse certificate: " + err.Error())
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}},
+	}, cert
+}
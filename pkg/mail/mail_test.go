@@ -0,0 +1,107 @@
+package mail_test
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/pkg/mail"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageBytes(t *testing.T) {
+	data, err := mail.Message{To: "ann@example.com", Subject: "Your code", Text: "Code: 123456\n.\nBye"}.Bytes("SSO <sso@example.com>")
+	require.NoError(t, err)
+
+	header, body, found := strings.Cut(string(data), "\r\n\r\n")
+	require.True(t, found)
+	assert.Contains(t, header, "From: SSO <sso@example.com>\r\n")
+	assert.Contains(t, header, "To: ann@example.com\r\n")
+	assert.Contains(t, header, "Subject: Your code\r\n")
+	assert.Equal(t, "Code: 123456\r\n..\r\nBye\r\n", body)
+
+	_, err = mail.Message{To: "ann@example.com\r\nBcc: eve@example.com", Subject: "x"}.Bytes("sso@example.com")
+	assert.ErrorIs(t, err, mail.ErrInvalidHeader)
+	_, err = mail.Message{To: "ann@example.com", Subject: "x\nBcc: eve@example.com"}.Bytes("sso@example.com")
+	assert.ErrorIs(t, err, mail.ErrInvalidHeader)
+}
+
+// smtpServer accepts one message and sends what it received on the returned channel.
+func smtpServer(t *testing.T) (string, <-chan []string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		var lines []string
+		_ = tp.PrintfLine("220 localhost ready")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			lines = append(lines, line)
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO":
+				_ = tp.PrintfLine("250 localhost")
+			case "DATA":
+				_ = tp.PrintfLine("354 go ahead")
+				data, _ := tp.ReadDotLines()
+				lines = append(lines, data...)
+				_ = tp.PrintfLine("250 queued")
+			case "QUIT":
+				_ = tp.PrintfLine("221 bye")
+				received <- lines
+				return
+			default:
+				_ = tp.PrintfLine("250 ok")
+			}
+		}
+	}()
+
+	return l.Addr().String(), received
+}
+
+func TestSMTPSender(t *testing.T) {
+	addr, received := smtpServer(t)
+	sender := mail.NewSMTPSender(mail.Config{Addr: addr, From: "SSO <sso@example.com>", Timeout: 5 * time.Second})
+
+	err := sender.Send(context.Background(), mail.Message{To: "ann@example.com", Subject: "Sign in", Text: "Your code is 123456"})
+	require.NoError(t, err)
+
+	select {
+	case lines := <-received:
+		assert.Contains(t, lines, "MAIL FROM:<sso@example.com>")
+		assert.Contains(t, lines, "RCPT TO:<ann@example.com>")
+		assert.Contains(t, lines, "Your code is 123456")
+	case <-time.After(5 * time.Second):
+		t.Fatal("server received nothing")
+	}
+
+	err = sender.Send(context.Background(), mail.Message{To: "not an address"})
+	assert.Error(t, err)
+}
+
+func TestLogSender(t *testing.T) {
+	var out strings.Builder
+	sender := mail.NewLogSender(slog.New(slog.NewTextHandler(&out, nil)))
+
+	require.NoError(t, sender.Send(context.Background(), mail.Message{To: "ann@example.com", Subject: "Sign in"}))
+	assert.Contains(t, out.String(), "ann@example.com")
+}
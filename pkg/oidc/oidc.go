@@ -0,0 +1,181 @@
+// Package oidc signs users in with an upstream OpenID Connect provider using the authorization
+// code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"auth/pkg/jwt"
+)
+
+var (
+	ErrDiscovery = errors.New("oidc discovery failed")
+	ErrExchange  = errors.New("oidc code exchange failed")
+	// ErrInvalidIDToken is returned for ID tokens that fail verification or don't match the nonce.
+	ErrInvalidIDToken = errors.New("invalid id token")
+)
+
+const maxResponseBytes = 1 << 20
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Scopes are requested on top of "openid".
+	Scopes []string
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client talks to one provider as one registered client.
+type Client struct {
+	cfg  Config
+	http *http.Client
+	meta metadata
+	keys *keySet
+}
+
+// Discover reads the provider's configuration from its discovery document.
+func Discover(ctx context.Context, httpClient *http.Client, cfg Config) (*Client, error) {
+	var meta metadata
+	if err := getJSON(ctx, httpClient, strings.TrimSuffix(cfg.Issuer, "/")+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	if meta.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("%w: provider names itself %q", ErrDiscovery, meta.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: discovery document lacks endpoints", ErrDiscovery)
+	}
+
+	return &Client{
+		cfg:  cfg,
+		http: httpClient,
+		meta: meta,
+		keys: newKeySet(httpClient, meta.JWKSURI),
+	}, nil
+}
+
+// AuthCodeURL is where the user is sent to sign in with the provider.
+func (c *Client) AuthCodeURL(redirectURI, state, nonce, codeVerifier string) string {
+	scopes := append([]string{"openid"}, c.cfg.Scopes...)
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(c.meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return c.meta.AuthorizationEndpoint + sep + q.Encode()
+}
+
+// Exchange redeems the authorization code and returns the verified claims of the ID token.
+func (c *Client) Exchange(ctx context.Context, code, redirectURI, codeVerifier, nonce string) (*jwt.IDTokenClaims, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// RFC 6749 2.3.1: the credentials are form-encoded before they go into the header.
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: status %d: %v", ErrExchange, resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("%w: status %d: %s %s", ErrExchange, resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrExchange)
+	}
+
+	claims, err := jwt.ParseIDToken(body.IDToken, c.meta.Issuer, c.cfg.ClientID, func(kid string) (crypto.PublicKey, error) {
+		return c.keys.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// NewCodeVerifier returns a random PKCE code verifier (RFC 7636).
+func NewCodeVerifier() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// CodeChallenge is the S256 challenge of a code verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func getJSON(ctx context.Context, httpClient *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(v)
+}
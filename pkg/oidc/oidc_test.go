@@ -0,0 +1,98 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"auth/pkg/oidc"
+	"auth/pkg/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURI = "https://app.example.com/callback"
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	provider := oidctest.New("client", "s3cret")
+	defer provider.Close()
+	provider.SetUser(oidctest.User{Subject: "u-1", Email: "ann@example.com", EmailVerified: true, Name: "Ann"})
+
+	client, err := oidc.Discover(ctx, http.DefaultClient, oidc.Config{
+		Issuer:       provider.Issuer(),
+		ClientID:     "client",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"email", "profile"},
+	})
+	require.NoError(t, err)
+
+	signIn := func(t *testing.T, nonce string) (code, verifier string) {
+		verifier = oidc.NewCodeVerifier()
+		authURL := client.AuthCodeURL(redirectURI, "state-1", nonce, verifier)
+
+		u, err := url.Parse(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+		assert.Equal(t, oidc.CodeChallenge(verifier), u.Query().Get("code_challenge"))
+
+		code, state, err := provider.Authorize(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, "state-1", state)
+		return code, verifier
+	}
+
+	t.Run("sign in", func(t *testing.T) {
+		code, verifier := signIn(t, "nonce-1")
+
+		claims, err := client.Exchange(ctx, code, redirectURI, verifier, "nonce-1")
+		require.NoError(t, err)
+		assert.Equal(t, "u-1", claims.Subject)
+		assert.Equal(t, "ann@example.com", claims.Email)
+		assert.True(t, claims.EmailVerified)
+
+		_, err = client.Exchange(ctx, code, redirectURI, verifier, "nonce-1")
+		assert.ErrorIs(t, err, oidc.ErrExchange, "codes are single use")
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		code, verifier := signIn(t, "nonce-1")
+
+		_, err := client.Exchange(ctx, code, redirectURI, verifier, "other")
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		code, _ := signIn(t, "nonce-1")
+
+		_, err := client.Exchange(ctx, code, redirectURI, oidc.NewCodeVerifier(), "nonce-1")
+		assert.ErrorIs(t, err, oidc.ErrExchange)
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		provider.RotateKey()
+		code, verifier := signIn(t, "nonce-1")
+
+		_, err := client.Exchange(ctx, code, redirectURI, verifier, "nonce-1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("wrong client secret", func(t *testing.T) {
+		other, err := oidc.Discover(ctx, http.DefaultClient, oidc.Config{
+			Issuer: provider.Issuer(), ClientID: "client", ClientSecret: "wrong",
+		})
+		require.NoError(t, err)
+
+		code, verifier := signIn(t, "nonce-1")
+		_, err = other.Exchange(ctx, code, redirectURI, verifier, "nonce-1")
+		assert.ErrorIs(t, err, oidc.ErrExchange)
+	})
+
+	t.Run("issuer mismatch", func(t *testing.T) {
+		_, err := oidc.Discover(ctx, http.DefaultClient, oidc.Config{Issuer: provider.Issuer() + "/"})
+		assert.ErrorIs(t, err, oidc.ErrDiscovery)
+	})
+}
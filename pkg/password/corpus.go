@@ -0,0 +1,123 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const hibpPrefixLen = 5
+
+// Corpus is a set of SHA-1 hashes of breached passwords in the format
+// published by Have I Been Pwned: one "HASH:COUNT" per line.
+type Corpus struct {
+	hashes map[[sha1.Size]byte]struct{}
+}
+
+// RangeCorpus reads a directory of HIBP range files, one per 5-character
+// hash prefix, each holding "SUFFIX:COUNT" lines. Files are read on demand.
+type RangeCorpus struct {
+	dir string
+}
+
+// LoadCorpus loads path as a RangeCorpus if it is a directory and as a Corpus otherwise.
+func LoadCorpus(path string) (BreachedChecker, error) {
+	const op = "password.LoadCorpus"
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if info.IsDir() {
+		return &RangeCorpus{dir: path}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	corpus, err := ReadCorpus(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return corpus, nil
+}
+
+func ReadCorpus(r io.Reader) (*Corpus, error) {
+	c := &Corpus{hashes: make(map[[sha1.Size]byte]struct{})}
+
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		hash, _, _ := strings.Cut(strings.TrimSpace(sc.Text()), ":")
+		if hash == "" {
+			continue
+		}
+
+		var sum [sha1.Size]byte
+		if len(hash) != hex.EncodedLen(sha1.Size) {
+			return nil, fmt.Errorf("line %d: invalid sha1 hash %q", line, hash)
+		}
+		if _, err := hex.Decode(sum[:], []byte(hash)); err != nil {
+			return nil, fmt.Errorf("line %d: invalid sha1 hash %q", line, hash)
+		}
+		c.hashes[sum] = struct{}{}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Corpus) IsBreached(password string) (bool, error) {
+	_, ok := c.hashes[sha1.Sum([]byte(password))]
+	return ok, nil
+}
+
+func (c *RangeCorpus) IsBreached(password string) (bool, error) {
+	const op = "password.RangeCorpus.IsBreached"
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:hibpPrefixLen], hash[hibpPrefixLen:]
+
+	f, err := c.open(prefix)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		s, _, _ := strings.Cut(strings.TrimSpace(sc.Text()), ":")
+		if strings.EqualFold(s, suffix) {
+			return true, nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return false, nil
+}
+
+func (c *RangeCorpus) open(prefix string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(c.dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return os.Open(filepath.Join(c.dir, prefix))
+	}
+	return f, err
+}
@@ -0,0 +1,135 @@
+package password
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	AlgoBcrypt = "bcrypt"
+	// Django-style "pbkdf2_sha256$<iterations>$<salt>$<base64 key>".
+	AlgoPBKDF2SHA256 = "pbkdf2-sha256"
+	// "<salt>$<hex sha512(salt || password)>".
+	AlgoSaltedSHA512 = "sha512-salted"
+	// AlgoNone marks accounts without a password, such as ones created by federated sign-in.
+	// No password matches it.
+	AlgoNone = "none"
+	// AlgoDirectory marks accounts shadowed from an LDAP directory, which checks their password.
+	AlgoDirectory = "ldap"
+)
+
+var (
+	ErrMismatch         = errors.New("password does not match hash")
+	ErrUnknownAlgorithm = errors.New("unknown hash algorithm")
+	ErrMalformedHash    = errors.New("malformed password hash")
+)
+
+func Hash(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+// NeedsRehash reports whether a hash produced by algo should be replaced with a bcrypt hash.
+func NeedsRehash(algo string) bool {
+	return algo != AlgoBcrypt && algo != AlgoNone && algo != AlgoDirectory
+}
+
+func Verify(algo string, hash []byte, password string) error {
+	switch algo {
+	case AlgoBcrypt, "":
+		if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return ErrMismatch
+			}
+			return err
+		}
+		return nil
+	case AlgoPBKDF2SHA256:
+		iter, salt, key, err := parsePBKDF2(hash)
+		if err != nil {
+			return err
+		}
+		got := pbkdf2.Key([]byte(password), salt, iter, len(key), sha256.New)
+		return compare(got, key)
+	case AlgoSaltedSHA512:
+		salt, sum, err := parseSaltedSHA512(hash)
+		if err != nil {
+			return err
+		}
+		got := sha512.Sum512(append(salt, password...))
+		return compare(got[:], sum)
+	case AlgoNone, AlgoDirectory:
+		return ErrMismatch
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownAlgorithm, algo)
+	}
+}
+
+// CheckFormat validates that hash is well formed for algo without verifying any password.
+func CheckFormat(algo string, hash []byte) error {
+	switch algo {
+	case AlgoBcrypt:
+		if _, err := bcrypt.Cost(hash); err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformedHash, err)
+		}
+		return nil
+	case AlgoPBKDF2SHA256:
+		_, _, _, err := parsePBKDF2(hash)
+		return err
+	case AlgoSaltedSHA512:
+		_, _, err := parseSaltedSHA512(hash)
+		return err
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownAlgorithm, algo)
+	}
+}
+
+func compare(got, want []byte) error {
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func parsePBKDF2(hash []byte) (iter int, salt, key []byte, err error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2_sha256" {
+		return 0, nil, nil, ErrMalformedHash
+	}
+
+	iter, err = strconv.Atoi(parts[1])
+	if err != nil || iter <= 0 {
+		return 0, nil, nil, ErrMalformedHash
+	}
+
+	key, err = base64.StdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 || parts[2] == "" {
+		return 0, nil, nil, ErrMalformedHash
+	}
+
+	return iter, []byte(parts[2]), key, nil
+}
+
+func parseSaltedSHA512(hash []byte) (salt, sum []byte, err error) {
+	i := bytes.LastIndexByte(hash, '$')
+	if i <= 0 {
+		return nil, nil, ErrMalformedHash
+	}
+
+	sum, err = hex.DecodeString(string(hash[i+1:]))
+	if err != nil || len(sum) != sha512.Size {
+		return nil, nil, ErrMalformedHash
+	}
+
+	return bytes.Clone(hash[:i]), sum, nil
+}
@@ -0,0 +1,52 @@
+package password_test
+
+import (
+	"testing"
+
+	"auth/pkg/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	bcryptHash, err := password.Hash("legacy-pass")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		algo string
+		hash string
+	}{
+		{name: "bcrypt", algo: password.AlgoBcrypt, hash: string(bcryptHash)},
+		{name: "pbkdf2", algo: password.AlgoPBKDF2SHA256, hash: "pbkdf2_sha256$1000$seasalt$L4csTIskoGZreojj3ofwZyTbnYqR9a6J9dUAf2oAhYI="},
+		{name: "salted sha512", algo: password.AlgoSaltedSHA512, hash: "pepper$5331b6aa238ac701c1aaba8b50e10548c6838cdeaa37eca6218ef8ed1951943309e567ffa427af0caaf20ccedbab12c484e93971060efbb9942627ac2b91d7ed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, password.CheckFormat(tt.algo, []byte(tt.hash)))
+			assert.NoError(t, password.Verify(tt.algo, []byte(tt.hash), "legacy-pass"))
+			assert.ErrorIs(t, password.Verify(tt.algo, []byte(tt.hash), "wrong-pass"), password.ErrMismatch)
+		})
+	}
+
+	t.Run("malformed", func(t *testing.T) {
+		assert.ErrorIs(t, password.CheckFormat(password.AlgoPBKDF2SHA256, []byte("pbkdf2_sha256$x$salt$key")), password.ErrMalformedHash)
+		assert.ErrorIs(t, password.CheckFormat(password.AlgoSaltedSHA512, []byte("nosalt")), password.ErrMalformedHash)
+		assert.ErrorIs(t, password.CheckFormat("md5", []byte("abc")), password.ErrUnknownAlgorithm)
+	})
+
+	t.Run("no password", func(t *testing.T) {
+		assert.ErrorIs(t, password.Verify(password.AlgoNone, nil, ""), password.ErrMismatch)
+		assert.ErrorIs(t, password.Verify(password.AlgoNone, []byte{}, "anything"), password.ErrMismatch)
+		assert.ErrorIs(t, password.Verify(password.AlgoDirectory, []byte{}, "anything"), password.ErrMismatch)
+	})
+
+	t.Run("needs rehash", func(t *testing.T) {
+		assert.False(t, password.NeedsRehash(password.AlgoBcrypt))
+		assert.True(t, password.NeedsRehash(password.AlgoPBKDF2SHA256))
+		assert.False(t, password.NeedsRehash(password.AlgoNone))
+		assert.False(t, password.NeedsRehash(password.AlgoDirectory))
+	})
+}
@@ -0,0 +1,162 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcrypt silently ignores everything after the first 72 bytes of a password.
+const BcryptMaxBytes = 72
+
+const (
+	RuleMinLength     = "MIN_LENGTH"
+	RuleMaxLength     = "MAX_LENGTH"
+	RuleUpper         = "UPPERCASE_REQUIRED"
+	RuleLower         = "LOWERCASE_REQUIRED"
+	RuleDigit         = "DIGIT_REQUIRED"
+	RuleSymbol        = "SYMBOL_REQUIRED"
+	RuleContainsEmail = "CONTAINS_EMAIL"
+	RuleBreached      = "BREACHED"
+)
+
+type Config struct {
+	MinLength      int    `env:"PASSWORD_MIN_LENGTH" env-default:"8"`
+	MaxLength      int    `env:"PASSWORD_MAX_LENGTH" env-default:"72"`
+	RequireUpper   bool   `env:"PASSWORD_REQUIRE_UPPER" env-default:"true"`
+	RequireLower   bool   `env:"PASSWORD_REQUIRE_LOWER" env-default:"true"`
+	RequireDigit   bool   `env:"PASSWORD_REQUIRE_DIGIT" env-default:"true"`
+	RequireSymbol  bool   `env:"PASSWORD_REQUIRE_SYMBOL" env-default:"false"`
+	RejectEmail    bool   `env:"PASSWORD_REJECT_EMAIL" env-default:"true"`
+	BreachedCorpus string `env:"PASSWORD_BREACHED_CORPUS" env-default:""`
+}
+
+type Violation struct {
+	Rule        string
+	Description string
+}
+
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	descs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		descs = append(descs, v.Description)
+	}
+	return "password policy violation: " + strings.Join(descs, "; ")
+}
+
+type BreachedChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+type Policy struct {
+	cfg      Config
+	breached BreachedChecker
+}
+
+func New(cfg Config, breached BreachedChecker) *Policy {
+	if cfg.MaxLength <= 0 || cfg.MaxLength > BcryptMaxBytes {
+		cfg.MaxLength = BcryptMaxBytes
+	}
+	return &Policy{cfg: cfg, breached: breached}
+}
+
+// NewFromConfig builds a policy and loads the breached-password corpus configured in cfg, if any.
+func NewFromConfig(cfg Config) (*Policy, error) {
+	const op = "password.NewFromConfig"
+
+	if cfg.BreachedCorpus == "" {
+		return New(cfg, nil), nil
+	}
+
+	corpus, err := LoadCorpus(cfg.BreachedCorpus)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return New(cfg, corpus), nil
+}
+
+// Validate returns a *ValidationError listing every violated rule, or nil if the password is acceptable.
+func (p *Policy) Validate(password, email string) error {
+	const op = "password.Policy.Validate"
+
+	var violations []Violation
+	add := func(rule, format string, args ...any) {
+		violations = append(violations, Violation{Rule: rule, Description: fmt.Sprintf(format, args...)})
+	}
+
+	if utf8.RuneCountInString(password) < p.cfg.MinLength {
+		add(RuleMinLength, "must be at least %d characters long", p.cfg.MinLength)
+	}
+	if len(password) > p.cfg.MaxLength {
+		add(RuleMaxLength, "must be at most %d bytes long", p.cfg.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.cfg.RequireUpper && !hasUpper {
+		add(RuleUpper, "must contain an uppercase letter")
+	}
+	if p.cfg.RequireLower && !hasLower {
+		add(RuleLower, "must contain a lowercase letter")
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		add(RuleDigit, "must contain a digit")
+	}
+	if p.cfg.RequireSymbol && !hasSymbol {
+		add(RuleSymbol, "must contain a symbol")
+	}
+
+	if p.cfg.RejectEmail && containsEmail(password, email) {
+		add(RuleContainsEmail, "must not contain the email address")
+	}
+
+	if p.breached != nil {
+		breached, err := p.breached.IsBreached(password)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if breached {
+			add(RuleBreached, "has appeared in a known data breach")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+func containsEmail(password, email string) bool {
+	password = strings.ToLower(password)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+
+	if strings.Contains(password, email) {
+		return true
+	}
+
+	// A local part this short would reject too many unrelated passwords.
+	local, _, _ := strings.Cut(email, "@")
+	return len(local) >= 3 && strings.Contains(password, local)
+}
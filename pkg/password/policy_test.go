@@ -0,0 +1,113 @@
+package password_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"auth/pkg/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultConfig = password.Config{
+	MinLength:    8,
+	MaxLength:    72,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+	RejectEmail:  true,
+}
+
+func rules(t *testing.T, err error) []string {
+	t.Helper()
+
+	var verr *password.ValidationError
+	require.ErrorAs(t, err, &verr)
+
+	var res []string
+	for _, v := range verr.Violations {
+		res = append(res, v.Rule)
+	}
+	return res
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	policy := password.New(defaultConfig, nil)
+
+	t.Run("strong password", func(t *testing.T) {
+		assert.NoError(t, policy.Validate("Correct1Horse", "user@mail.com"))
+	})
+
+	t.Run("too short and missing classes", func(t *testing.T) {
+		err := policy.Validate("1", "user@mail.com")
+		assert.ElementsMatch(t, []string{
+			password.RuleMinLength,
+			password.RuleUpper,
+			password.RuleLower,
+		}, rules(t, err))
+	})
+
+	t.Run("longer than bcrypt limit", func(t *testing.T) {
+		err := policy.Validate("Aa1"+strings.Repeat("x", 70), "user@mail.com")
+		assert.Equal(t, []string{password.RuleMaxLength}, rules(t, err))
+	})
+
+	t.Run("contains email local part", func(t *testing.T) {
+		err := policy.Validate("JohnDoe2024", "johndoe@mail.com")
+		assert.Equal(t, []string{password.RuleContainsEmail}, rules(t, err))
+	})
+
+	t.Run("max length is capped", func(t *testing.T) {
+		p := password.New(password.Config{MaxLength: 1000}, nil)
+		err := p.Validate(strings.Repeat("a", 73), "")
+		assert.Equal(t, []string{password.RuleMaxLength}, rules(t, err))
+	})
+}
+
+func TestPolicy_Breached(t *testing.T) {
+	const leaked = "Password1"
+
+	t.Run("full corpus", func(t *testing.T) {
+		corpus, err := password.ReadCorpus(strings.NewReader(sha1Hex(leaked) + ":3861493\n"))
+		require.NoError(t, err)
+
+		policy := password.New(defaultConfig, corpus)
+		assert.Equal(t, []string{password.RuleBreached}, rules(t, policy.Validate(leaked, "user@mail.com")))
+		assert.NoError(t, policy.Validate("Unleaked1Horse", "user@mail.com"))
+	})
+
+	t.Run("range directory", func(t *testing.T) {
+		dir := t.TempDir()
+		hash := sha1Hex(leaked)
+		err := os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(hash[5:]+":3861493\r\n"), 0o600)
+		require.NoError(t, err)
+
+		corpus, err := password.LoadCorpus(dir)
+		require.NoError(t, err)
+
+		policy := password.New(defaultConfig, corpus)
+		assert.Equal(t, []string{password.RuleBreached}, rules(t, policy.Validate(leaked, "user@mail.com")))
+		assert.NoError(t, policy.Validate("Unleaked1Horse", "user@mail.com"))
+	})
+
+	t.Run("invalid corpus line", func(t *testing.T) {
+		_, err := password.ReadCorpus(strings.NewReader("not-a-hash:1\n"))
+		assert.Error(t, err)
+
+		_, err = password.ReadCorpus(strings.NewReader(sha1Hex(leaked) + "00:1\n"))
+		assert.Error(t, err)
+
+		_, err = password.ReadCorpus(strings.NewReader(sha1Hex(leaked)[:38] + ":1\n"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,22 @@
+// Package requestmeta carries facts about the incoming request, such as the client address,
+// through the context to code that records them.
+package requestmeta
+
+import "context"
+
+type Meta struct {
+	IP        string
+	UserAgent string
+}
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, meta Meta) context.Context {
+	return context.WithValue(ctx, ctxKey{}, meta)
+}
+
+// FromContext returns the metadata of the request, or a zero Meta if there is none.
+func FromContext(ctx context.Context) Meta {
+	meta, _ := ctx.Value(ctxKey{}).(Meta)
+	return meta
+}
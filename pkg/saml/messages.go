@@ -0,0 +1,298 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	SigAlgRSASHA256   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	SigAlgECDSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+
+	// maxMessageSize bounds encoded and inflated messages alike.
+	maxMessageSize = 256 << 10
+)
+
+// Message is a SAML protocol message read off a request in either binding.
+type Message struct {
+	Binding string
+	XML     []byte
+	// Response is true for SAMLResponse parameters, false for SAMLRequest ones.
+	Response   bool
+	RelayState string
+
+	// The HTTP-Redirect binding signs the query rather than the XML.
+	signedQuery string
+	sigAlg      string
+	signature   []byte
+}
+
+// ReadMessage reads the SAMLRequest or SAMLResponse of a GET request in the HTTP-Redirect binding
+// or a POST request in the HTTP-POST binding.
+func ReadMessage(r *http.Request) (Message, error) {
+	if r.Method == http.MethodPost {
+		return readPost(r)
+	}
+	return readRedirect(r)
+}
+
+func readRedirect(r *http.Request) (Message, error) {
+	// The signature covers the parameters as they were encoded by the sender, so they are taken
+	// from the raw query.
+	raw := make(map[string]string)
+	for _, pair := range strings.Split(r.URL.RawQuery, "&") {
+		k, v, _ := strings.Cut(pair, "=")
+		if _, ok := raw[k]; !ok {
+			raw[k] = v
+		}
+	}
+
+	m := Message{Binding: BindingRedirect}
+	param := "SAMLRequest"
+	if _, ok := raw["SAMLResponse"]; ok {
+		param, m.Response = "SAMLResponse", true
+	}
+
+	encoded, err := url.QueryUnescape(raw[param])
+	if err != nil || encoded == "" {
+		return Message{}, ErrMalformed
+	}
+	deflated, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return Message{}, ErrMalformed
+	}
+	m.XML, err = io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(deflated)), maxMessageSize))
+	if err != nil {
+		return Message{}, ErrMalformed
+	}
+
+	if m.RelayState, err = url.QueryUnescape(raw["RelayState"]); err != nil {
+		return Message{}, ErrMalformed
+	}
+
+	if sig, ok := raw["Signature"]; ok {
+		if m.sigAlg, err = url.QueryUnescape(raw["SigAlg"]); err != nil {
+			return Message{}, ErrMalformed
+		}
+		if sig, err = url.QueryUnescape(sig); err != nil {
+			return Message{}, ErrMalformed
+		}
+		if m.signature, err = base64.StdEncoding.DecodeString(sig); err != nil {
+			return Message{}, ErrMalformed
+		}
+
+		m.signedQuery = param + "=" + raw[param]
+		if v, ok := raw["RelayState"]; ok {
+			m.signedQuery += "&RelayState=" + v
+		}
+		m.signedQuery += "&SigAlg=" + raw["SigAlg"]
+	}
+
+	return m, nil
+}
+
+func readPost(r *http.Request) (Message, error) {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxMessageSize)
+	if err := r.ParseForm(); err != nil {
+		return Message{}, ErrMalformed
+	}
+
+	m := Message{Binding: BindingPOST, RelayState: r.PostForm.Get("RelayState")}
+	encoded := r.PostForm.Get("SAMLRequest")
+	if encoded == "" {
+		encoded, m.Response = r.PostForm.Get("SAMLResponse"), true
+	}
+	if encoded == "" {
+		return Message{}, ErrMalformed
+	}
+
+	var err error
+	if m.XML, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+		return Message{}, ErrMalformed
+	}
+
+	return m, nil
+}
+
+// Verify checks that the message is signed with the key of cert. Messages in the HTTP-POST binding
+// are returned with just the signed element, so nothing unsigned slips through.
+func (m Message) Verify(cert *x509.Certificate) (Message, error) {
+	if m.Binding == BindingRedirect {
+		var algo x509.SignatureAlgorithm
+		switch m.sigAlg {
+		case SigAlgRSASHA256:
+			algo = x509.SHA256WithRSA
+		case SigAlgECDSASHA256:
+			algo = x509.ECDSAWithSHA256
+		default:
+			return Message{}, ErrSignature
+		}
+		if m.signature == nil || cert.CheckSignature(algo, []byte(m.signedQuery), m.signature) != nil {
+			return Message{}, ErrSignature
+		}
+		return m, nil
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(m.XML); err != nil || doc.Root() == nil {
+		return Message{}, ErrMalformed
+	}
+
+	ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: []*x509.Certificate{cert}})
+	validated, err := ctx.Validate(doc.Root())
+	if err != nil {
+		return Message{}, fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+
+	out := etree.NewDocument()
+	out.SetRoot(validated)
+	if m.XML, err = out.WriteToBytes(); err != nil {
+		return Message{}, err
+	}
+
+	return m, nil
+}
+
+// RedirectURL returns location with the message in the HTTP-Redirect binding under param, which
+// is SAMLRequest or SAMLResponse. The query is signed unless key is nil.
+func RedirectURL(location, param string, xml []byte, relayState string, key *rsa.PrivateKey) (string, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(xml); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	query := param + "=" + url.QueryEscape(base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if relayState != "" {
+		query += "&RelayState=" + url.QueryEscape(relayState)
+	}
+
+	if key != nil {
+		query += "&SigAlg=" + url.QueryEscape(SigAlgRSASHA256)
+
+		digest := sha256.Sum256([]byte(query))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", err
+		}
+		query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(sig))
+	}
+
+	if strings.Contains(location, "?") {
+		return location + "&" + query, nil
+	}
+	return location + "?" + query, nil
+}
+
+// AuthnRequest is a service provider asking to have a user signed in.
+type AuthnRequest struct {
+	ID     string
+	Issuer string
+	// ACSURL is where the service provider wants the response, empty for its default one.
+	ACSURL      string
+	Destination string
+	ForceAuthn  bool
+}
+
+// ParseAuthnRequest parses the AuthnRequest in m.
+func ParseAuthnRequest(m Message) (AuthnRequest, error) {
+	root, err := parseRoot(m, "AuthnRequest")
+	if err != nil {
+		return AuthnRequest{}, err
+	}
+
+	req := AuthnRequest{
+		ID:          root.SelectAttrValue("ID", ""),
+		Issuer:      issuer(root),
+		ACSURL:      root.SelectAttrValue("AssertionConsumerServiceURL", ""),
+		Destination: root.SelectAttrValue("Destination", ""),
+		ForceAuthn:  root.SelectAttrValue("ForceAuthn", "") == "true",
+	}
+	if req.ID == "" || req.Issuer == "" {
+		return AuthnRequest{}, ErrMalformed
+	}
+
+	return req, nil
+}
+
+// LogoutRequest is a service provider asking to end a user's sessions.
+type LogoutRequest struct {
+	ID           string
+	Issuer       string
+	NameID       string
+	NameIDFormat string
+	// SessionIndexes are the sessions to end, all of the user's when empty.
+	SessionIndexes []string
+}
+
+// ParseLogoutRequest parses the LogoutRequest in m.
+func ParseLogoutRequest(m Message) (LogoutRequest, error) {
+	root, err := parseRoot(m, "LogoutRequest")
+	if err != nil {
+		return LogoutRequest{}, err
+	}
+
+	req := LogoutRequest{
+		ID:     root.SelectAttrValue("ID", ""),
+		Issuer: issuer(root),
+	}
+	if nameID := root.SelectElement("NameID"); nameID != nil && nameID.NamespaceURI() == nsAssertion {
+		req.NameID = strings.TrimSpace(nameID.Text())
+		req.NameIDFormat = nameID.SelectAttrValue("Format", "")
+	}
+	for _, el := range root.SelectElements("SessionIndex") {
+		if el.NamespaceURI() == nsProtocol {
+			req.SessionIndexes = append(req.SessionIndexes, strings.TrimSpace(el.Text()))
+		}
+	}
+	if req.ID == "" || req.Issuer == "" || req.NameID == "" {
+		return LogoutRequest{}, ErrMalformed
+	}
+
+	return req, nil
+}
+
+func parseRoot(m Message, tag string) (*etree.Element, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(m.XML); err != nil {
+		return nil, ErrMalformed
+	}
+
+	root := doc.Root()
+	if root == nil || root.Tag != tag || root.NamespaceURI() != nsProtocol {
+		return nil, ErrMalformed
+	}
+	if root.SelectAttrValue("Version", "") != "2.0" {
+		return nil, ErrMalformed
+	}
+
+	return root, nil
+}
+
+func issuer(root *etree.Element) string {
+	el := root.SelectElement("Issuer")
+	if el == nil || el.NamespaceURI() != nsAssertion {
+		return ""
+	}
+	return strings.TrimSpace(el.Text())
+}
@@ -0,0 +1,249 @@
+// Package saml implements the identity provider side of SAML 2.0 web browser SSO and single
+// logout with the HTTP-Redirect and HTTP-POST bindings.
+package saml
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	NameIDFormatEmail      = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	NameIDFormatPersistent = "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent"
+
+	BindingRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	BindingPOST     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+)
+
+const (
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	nsDSig      = "http://www.w3.org/2000/09/xmldsig#"
+
+	statusSuccess   = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	statusResponder = "urn:oasis:names:tc:SAML:2.0:status:Responder"
+
+	timeFormat = "2006-01-02T15:04:05Z"
+	// clockSkew is how far the clocks of service providers may be behind.
+	clockSkew = time.Minute
+)
+
+var (
+	ErrMalformed = errors.New("malformed saml message")
+	ErrSignature = errors.New("saml message signature is missing or invalid")
+)
+
+// IdentityProvider issues assertions about signed-in users to service providers.
+type IdentityProvider struct {
+	EntityID string
+	// SSOURL and SLOURL are where service providers send users to sign in and out.
+	SSOURL      string
+	SLOURL      string
+	Key         *rsa.PrivateKey
+	Certificate *x509.Certificate
+	// AssertionTTL is how long a service provider may take to accept an assertion.
+	AssertionTTL time.Duration
+}
+
+// Assertion is what the identity provider states about a signed-in user.
+type Assertion struct {
+	// InResponseTo is the ID of the AuthnRequest answered, empty for IdP-initiated SSO.
+	InResponseTo string
+	// Audience is the entity ID of the service provider.
+	Audience     string
+	ACSURL       string
+	NameID       string
+	NameIDFormat string
+	// SessionIndex identifies the session the user signed in with, for single logout.
+	SessionIndex string
+	AuthnInstant time.Time
+	Attributes   map[string][]string
+}
+
+// Metadata describes the identity provider to service providers.
+func (idp *IdentityProvider) Metadata() ([]byte, error) {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+
+	entity := doc.CreateElement("md:EntityDescriptor")
+	entity.CreateAttr("xmlns:md", nsMetadata)
+	entity.CreateAttr("entityID", idp.EntityID)
+
+	desc := entity.CreateElement("md:IDPSSODescriptor")
+	desc.CreateAttr("protocolSupportEnumeration", nsProtocol)
+
+	key := desc.CreateElement("md:KeyDescriptor")
+	key.CreateAttr("use", "signing")
+	keyInfo := key.CreateElement("ds:KeyInfo")
+	keyInfo.CreateAttr("xmlns:ds", nsDSig)
+	keyInfo.CreateElement("ds:X509Data").CreateElement("ds:X509Certificate").
+		SetText(base64.StdEncoding.EncodeToString(idp.Certificate.Raw))
+
+	for _, binding := range []string{BindingRedirect, BindingPOST} {
+		slo := desc.CreateElement("md:SingleLogoutService")
+		slo.CreateAttr("Binding", binding)
+		slo.CreateAttr("Location", idp.SLOURL)
+	}
+	for _, format := range []string{NameIDFormatEmail, NameIDFormatPersistent} {
+		desc.CreateElement("md:NameIDFormat").SetText(format)
+	}
+	for _, binding := range []string{BindingRedirect, BindingPOST} {
+		sso := desc.CreateElement("md:SingleSignOnService")
+		sso.CreateAttr("Binding", binding)
+		sso.CreateAttr("Location", idp.SSOURL)
+	}
+
+	doc.Indent(2)
+	return doc.WriteToBytes()
+}
+
+// Response returns the base64 encoded Response with the signed assertion that the browser posts
+// to the service provider's ACS URL.
+func (idp *IdentityProvider) Response(a Assertion) (string, error) {
+	now := time.Now().UTC()
+
+	assertion, err := idp.signedAssertion(a, now)
+	if err != nil {
+		return "", err
+	}
+
+	doc := etree.NewDocument()
+	resp := doc.CreateElement("samlp:Response")
+	resp.CreateAttr("xmlns:samlp", nsProtocol)
+	resp.CreateAttr("xmlns:saml", nsAssertion)
+	resp.CreateAttr("ID", newID())
+	resp.CreateAttr("Version", "2.0")
+	resp.CreateAttr("IssueInstant", now.Format(timeFormat))
+	resp.CreateAttr("Destination", a.ACSURL)
+	if a.InResponseTo != "" {
+		resp.CreateAttr("InResponseTo", a.InResponseTo)
+	}
+	resp.CreateElement("saml:Issuer").SetText(idp.EntityID)
+	resp.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", statusSuccess)
+	resp.AddChild(assertion)
+
+	out, err := doc.WriteToBytes()
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+func (idp *IdentityProvider) signedAssertion(a Assertion, now time.Time) (*etree.Element, error) {
+	notOnOrAfter := now.Add(idp.AssertionTTL).Format(timeFormat)
+
+	el := etree.NewElement("saml:Assertion")
+	el.CreateAttr("xmlns:saml", nsAssertion)
+	el.CreateAttr("ID", newID())
+	el.CreateAttr("Version", "2.0")
+	el.CreateAttr("IssueInstant", now.Format(timeFormat))
+	el.CreateElement("saml:Issuer").SetText(idp.EntityID)
+
+	subject := el.CreateElement("saml:Subject")
+	nameID := subject.CreateElement("saml:NameID")
+	nameID.CreateAttr("Format", a.NameIDFormat)
+	nameID.SetText(a.NameID)
+	confirmation := subject.CreateElement("saml:SubjectConfirmation")
+	confirmation.CreateAttr("Method", "urn:oasis:names:tc:SAML:2.0:cm:bearer")
+	data := confirmation.CreateElement("saml:SubjectConfirmationData")
+	if a.InResponseTo != "" {
+		data.CreateAttr("InResponseTo", a.InResponseTo)
+	}
+	data.CreateAttr("NotOnOrAfter", notOnOrAfter)
+	data.CreateAttr("Recipient", a.ACSURL)
+
+	conditions := el.CreateElement("saml:Conditions")
+	conditions.CreateAttr("NotBefore", now.Add(-clockSkew).Format(timeFormat))
+	conditions.CreateAttr("NotOnOrAfter", notOnOrAfter)
+	conditions.CreateElement("saml:AudienceRestriction").CreateElement("saml:Audience").SetText(a.Audience)
+
+	authn := el.CreateElement("saml:AuthnStatement")
+	authn.CreateAttr("AuthnInstant", a.AuthnInstant.UTC().Format(timeFormat))
+	if a.SessionIndex != "" {
+		authn.CreateAttr("SessionIndex", a.SessionIndex)
+	}
+	authn.CreateElement("saml:AuthnContext").CreateElement("saml:AuthnContextClassRef").
+		SetText("urn:oasis:names:tc:SAML:2.0:ac:classes:PasswordProtectedTransport")
+
+	if len(a.Attributes) > 0 {
+		names := make([]string, 0, len(a.Attributes))
+		for name := range a.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		statement := el.CreateElement("saml:AttributeStatement")
+		for _, name := range names {
+			attr := statement.CreateElement("saml:Attribute")
+			attr.CreateAttr("Name", name)
+			attr.CreateAttr("NameFormat", "urn:oasis:names:tc:SAML:2.0:attrname-format:basic")
+			for _, v := range a.Attributes[name] {
+				attr.CreateElement("saml:AttributeValue").SetText(v)
+			}
+		}
+	}
+
+	ctx, err := dsig.NewSigningContext(idp.Key, [][]byte{idp.Certificate.Raw})
+	if err != nil {
+		return nil, err
+	}
+	ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+
+	sig, err := ctx.ConstructSignature(el, true)
+	if err != nil {
+		return nil, fmt.Errorf("sign assertion: %w", err)
+	}
+	// The schema wants the signature right after the issuer.
+	el.InsertChildAt(1, sig)
+
+	return el, nil
+}
+
+// LogoutResponseURL returns where to send the browser to answer a LogoutRequest: the service
+// provider's logout URL with a signed LogoutResponse in the HTTP-Redirect binding.
+func (idp *IdentityProvider) LogoutResponseURL(sloURL, inResponseTo, relayState string, success bool) (string, error) {
+	status := statusSuccess
+	if !success {
+		status = statusResponder
+	}
+
+	doc := etree.NewDocument()
+	resp := doc.CreateElement("samlp:LogoutResponse")
+	resp.CreateAttr("xmlns:samlp", nsProtocol)
+	resp.CreateAttr("xmlns:saml", nsAssertion)
+	resp.CreateAttr("ID", newID())
+	resp.CreateAttr("Version", "2.0")
+	resp.CreateAttr("IssueInstant", time.Now().UTC().Format(timeFormat))
+	resp.CreateAttr("Destination", sloURL)
+	resp.CreateAttr("InResponseTo", inResponseTo)
+	resp.CreateElement("saml:Issuer").SetText(idp.EntityID)
+	resp.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", status)
+
+	out, err := doc.WriteToBytes()
+	if err != nil {
+		return "", err
+	}
+
+	return RedirectURL(sloURL, "SAMLResponse", out, relayState, idp.Key)
+}
+
+// newID returns a message ID. IDs must not start with a digit.
+func newID() string {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return "_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,216 @@
+package saml_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/pkg/saml"
+	"auth/pkg/saml/samltest"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ssoURL = "https://sso.example.com/saml/sso"
+	sloURL = "https://sso.example.com/saml/slo"
+)
+
+func identityProvider() *saml.IdentityProvider {
+	key, cert := samltest.NewCertificate("sso.example.com")
+	return &saml.IdentityProvider{
+		EntityID:     "https://sso.example.com/saml/metadata",
+		SSOURL:       ssoURL,
+		SLOURL:       sloURL,
+		Key:          key,
+		Certificate:  cert,
+		AssertionTTL: 5 * time.Minute,
+	}
+}
+
+func serviceProvider() *samltest.ServiceProvider {
+	return samltest.NewServiceProvider("https://wiki.example.com", "https://wiki.example.com/acs", "https://wiki.example.com/slo")
+}
+
+func read(t *testing.T, location string) saml.Message {
+	t.Helper()
+	m, err := saml.ReadMessage(httptest.NewRequest(http.MethodGet, location, nil))
+	require.NoError(t, err)
+	return m
+}
+
+func TestMetadata(t *testing.T) {
+	idp := identityProvider()
+
+	out, err := idp.Metadata()
+	require.NoError(t, err)
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromBytes(out))
+	assert.Equal(t, idp.EntityID, doc.Root().SelectAttrValue("entityID", ""))
+
+	cert := doc.FindElement("//X509Certificate")
+	require.NotNil(t, cert)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(idp.Certificate.Raw), cert.Text())
+
+	sso := doc.FindElements("//SingleSignOnService")
+	require.Len(t, sso, 2)
+	assert.Equal(t, ssoURL, sso[0].SelectAttrValue("Location", ""))
+}
+
+func TestAuthnRequest(t *testing.T) {
+	sp := serviceProvider()
+
+	location, id := sp.AuthnRequestURL(ssoURL, "/wiki/page?a=1&b=2")
+	m := read(t, location)
+	assert.Equal(t, saml.BindingRedirect, m.Binding)
+	assert.False(t, m.Response)
+	assert.Equal(t, "/wiki/page?a=1&b=2", m.RelayState)
+
+	req, err := saml.ParseAuthnRequest(m)
+	require.NoError(t, err)
+	assert.Equal(t, id, req.ID)
+	assert.Equal(t, sp.EntityID, req.Issuer)
+	assert.Equal(t, sp.ACSURL, req.ACSURL)
+	assert.Equal(t, ssoURL, req.Destination)
+
+	t.Run("signature", func(t *testing.T) {
+		_, err := m.Verify(sp.Certificate)
+		require.NoError(t, err)
+
+		other := serviceProvider()
+		_, err = m.Verify(other.Certificate)
+		assert.ErrorIs(t, err, saml.ErrSignature)
+	})
+
+	t.Run("tampered relay state", func(t *testing.T) {
+		u, err := url.Parse(location)
+		require.NoError(t, err)
+		q := u.Query()
+		q.Set("RelayState", "https://evil.example.com")
+		u.RawQuery = q.Encode()
+
+		_, err = read(t, u.String()).Verify(sp.Certificate)
+		assert.ErrorIs(t, err, saml.ErrSignature)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		u, _, _ := strings.Cut(location, "&SigAlg=")
+		_, err := read(t, u).Verify(sp.Certificate)
+		assert.ErrorIs(t, err, saml.ErrSignature)
+	})
+
+	t.Run("wrong message", func(t *testing.T) {
+		_, err := saml.ParseLogoutRequest(m)
+		assert.ErrorIs(t, err, saml.ErrMalformed)
+	})
+}
+
+func TestPostBinding(t *testing.T) {
+	sp := serviceProvider()
+
+	doc := etree.NewDocument()
+	req := doc.CreateElement("samlp:AuthnRequest")
+	req.CreateAttr("xmlns:samlp", "urn:oasis:names:tc:SAML:2.0:protocol")
+	req.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	req.CreateAttr("ID", "_post")
+	req.CreateAttr("Version", "2.0")
+	req.CreateElement("saml:Issuer").SetText(sp.EntityID)
+
+	ctx, err := dsig.NewSigningContext(sp.Key, [][]byte{sp.Certificate.Raw})
+	require.NoError(t, err)
+	signed, err := ctx.SignEnveloped(req)
+	require.NoError(t, err)
+	doc.SetRoot(signed)
+	out, err := doc.WriteToBytes()
+	require.NoError(t, err)
+
+	post := func(xml []byte) saml.Message {
+		form := url.Values{"SAMLRequest": {base64.StdEncoding.EncodeToString(xml)}, "RelayState": {"state"}}
+		r := httptest.NewRequest(http.MethodPost, ssoURL, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		m, err := saml.ReadMessage(r)
+		require.NoError(t, err)
+		return m
+	}
+
+	m := post(out)
+	assert.Equal(t, saml.BindingPOST, m.Binding)
+	assert.Equal(t, "state", m.RelayState)
+
+	verified, err := m.Verify(sp.Certificate)
+	require.NoError(t, err)
+	parsed, err := saml.ParseAuthnRequest(verified)
+	require.NoError(t, err)
+	assert.Equal(t, "_post", parsed.ID)
+
+	tampered := strings.Replace(string(out), sp.EntityID, "https://evil.example.com", 1)
+	_, err = post([]byte(tampered)).Verify(sp.Certificate)
+	assert.ErrorIs(t, err, saml.ErrSignature)
+}
+
+func TestResponse(t *testing.T) {
+	idp := identityProvider()
+	sp := serviceProvider()
+
+	resp, err := idp.Response(saml.Assertion{
+		InResponseTo: "_req",
+		Audience:     sp.EntityID,
+		ACSURL:       sp.ACSURL,
+		NameID:       "ann@example.com",
+		NameIDFormat: saml.NameIDFormatEmail,
+		SessionIndex: "sid-1",
+		AuthnInstant: time.Now(),
+		Attributes:   map[string][]string{"email": {"ann@example.com"}, "roles": {"admin", "dev"}},
+	})
+	require.NoError(t, err)
+
+	a, err := sp.ParseResponse(resp, idp.Certificate)
+	require.NoError(t, err)
+	assert.Equal(t, "_req", a.InResponseTo)
+	assert.Equal(t, idp.EntityID, a.Issuer)
+	assert.Equal(t, sp.EntityID, a.Audience)
+	assert.Equal(t, sp.ACSURL, a.Recipient)
+	assert.Equal(t, "ann@example.com", a.NameID)
+	assert.Equal(t, saml.NameIDFormatEmail, a.NameIDFormat)
+	assert.Equal(t, "sid-1", a.SessionIndex)
+	assert.Equal(t, []string{"admin", "dev"}, a.Attributes["roles"])
+
+	other := identityProvider()
+	_, err = sp.ParseResponse(resp, other.Certificate)
+	assert.Error(t, err)
+}
+
+func TestLogout(t *testing.T) {
+	idp := identityProvider()
+	sp := serviceProvider()
+
+	m := read(t, sp.LogoutRequestURL(sloURL, "ann@example.com", saml.NameIDFormatEmail, "sid-1"))
+	m, err := m.Verify(sp.Certificate)
+	require.NoError(t, err)
+
+	req, err := saml.ParseLogoutRequest(m)
+	require.NoError(t, err)
+	assert.Equal(t, sp.EntityID, req.Issuer)
+	assert.Equal(t, "ann@example.com", req.NameID)
+	assert.Equal(t, saml.NameIDFormatEmail, req.NameIDFormat)
+	assert.Equal(t, []string{"sid-1"}, req.SessionIndexes)
+
+	for _, success := range []bool{true, false} {
+		location, err := idp.LogoutResponseURL(sp.SLOURL, req.ID, "relay", success)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(location, sp.SLOURL+"?"))
+		assert.Equal(t, "relay", read(t, location).RelayState)
+
+		ok, err := sp.ParseLogoutResponse(location, idp.Certificate)
+		require.NoError(t, err)
+		assert.Equal(t, success, ok)
+	}
+}
@@ -0,0 +1,238 @@
+// Package samltest provides a service provider to test the identity provider against.
+package samltest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"auth/pkg/saml"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+)
+
+// ServiceProvider signs requests with its own key and checks what the identity provider sends.
+type ServiceProvider struct {
+	EntityID    string
+	ACSURL      string
+	SLOURL      string
+	Key         *rsa.PrivateKey
+	Certificate *x509.Certificate
+}
+
+// NewServiceProvider creates a service provider with a fresh self-signed certificate.
+func NewServiceProvider(entityID, acsURL, sloURL string) *ServiceProvider {
+	key, cert := NewCertificate(entityID)
+	return &ServiceProvider{EntityID: entityID, ACSURL: acsURL, SLOURL: sloURL, Key: key, Certificate: cert}
+}
+
+// NewCertificate creates an RSA key and a self-signed certificate for it.
+func NewCertificate(cn string) (*rsa.PrivateKey, *x509.Certificate) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+
+	return key, cert
+}
+
+// CertificatePEM returns the certificate to register the service provider with.
+func (sp *ServiceProvider) CertificatePEM() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: sp.Certificate.Raw}))
+}
+
+// AuthnRequestURL returns a signed SP-initiated login URL and the ID of its AuthnRequest.
+func (sp *ServiceProvider) AuthnRequestURL(ssoURL, relayState string) (string, string) {
+	id := newID()
+
+	doc := etree.NewDocument()
+	req := doc.CreateElement("samlp:AuthnRequest")
+	req.CreateAttr("xmlns:samlp", nsProtocol)
+	req.CreateAttr("xmlns:saml", nsAssertion)
+	req.CreateAttr("ID", id)
+	req.CreateAttr("Version", "2.0")
+	req.CreateAttr("IssueInstant", time.Now().UTC().Format(time.RFC3339))
+	req.CreateAttr("Destination", ssoURL)
+	req.CreateAttr("AssertionConsumerServiceURL", sp.ACSURL)
+	req.CreateAttr("ProtocolBinding", saml.BindingPOST)
+	req.CreateElement("saml:Issuer").SetText(sp.EntityID)
+
+	return sp.redirect(ssoURL, "SAMLRequest", doc, relayState), id
+}
+
+// LogoutRequestURL returns a signed URL asking to end the session of the user.
+func (sp *ServiceProvider) LogoutRequestURL(sloURL, nameID, nameIDFormat, sessionIndex string) string {
+	doc := etree.NewDocument()
+	req := doc.CreateElement("samlp:LogoutRequest")
+	req.CreateAttr("xmlns:samlp", nsProtocol)
+	req.CreateAttr("xmlns:saml", nsAssertion)
+	req.CreateAttr("ID", newID())
+	req.CreateAttr("Version", "2.0")
+	req.CreateAttr("IssueInstant", time.Now().UTC().Format(time.RFC3339))
+	req.CreateAttr("Destination", sloURL)
+	req.CreateElement("saml:Issuer").SetText(sp.EntityID)
+	el := req.CreateElement("saml:NameID")
+	el.CreateAttr("Format", nameIDFormat)
+	el.SetText(nameID)
+	if sessionIndex != "" {
+		req.CreateElement("samlp:SessionIndex").SetText(sessionIndex)
+	}
+
+	return sp.redirect(sloURL, "SAMLRequest", doc, "")
+}
+
+func (sp *ServiceProvider) redirect(location, param string, doc *etree.Document, relayState string) string {
+	out, err := doc.WriteToBytes()
+	if err != nil {
+		panic(err)
+	}
+	u, err := saml.RedirectURL(location, param, out, relayState, sp.Key)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// Assertion is what a service provider takes from a verified assertion.
+type Assertion struct {
+	InResponseTo string
+	Issuer       string
+	Audience     string
+	Recipient    string
+	NameID       string
+	NameIDFormat string
+	SessionIndex string
+	Attributes   map[string][]string
+}
+
+// ParseResponse checks that the base64 encoded Response carries an assertion signed with
+// idpCert and returns it.
+func (sp *ServiceProvider) ParseResponse(samlResponse string, idpCert *x509.Certificate) (Assertion, error) {
+	raw, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return Assertion{}, err
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(raw); err != nil {
+		return Assertion{}, err
+	}
+	resp := doc.Root()
+	if resp == nil || resp.Tag != "Response" || resp.NamespaceURI() != nsProtocol {
+		return Assertion{}, errors.New("not a response")
+	}
+	if status := resp.FindElement("./Status/StatusCode"); status == nil || !strings.HasSuffix(status.SelectAttrValue("Value", ""), ":Success") {
+		return Assertion{}, errors.New("response isn't a success")
+	}
+
+	el := resp.SelectElement("Assertion")
+	if el == nil {
+		return Assertion{}, errors.New("response has no assertion")
+	}
+	ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: []*x509.Certificate{idpCert}})
+	if el, err = ctx.Validate(el); err != nil {
+		return Assertion{}, fmt.Errorf("assertion signature: %w", err)
+	}
+
+	a := Assertion{
+		InResponseTo: resp.SelectAttrValue("InResponseTo", ""),
+		Issuer:       text(el.SelectElement("Issuer")),
+		Audience:     text(el.FindElement("./Conditions/AudienceRestriction/Audience")),
+		Attributes:   make(map[string][]string),
+	}
+	if data := el.FindElement("./Subject/SubjectConfirmation/SubjectConfirmationData"); data != nil {
+		a.Recipient = data.SelectAttrValue("Recipient", "")
+	}
+	if nameID := el.FindElement("./Subject/NameID"); nameID != nil {
+		a.NameID = text(nameID)
+		a.NameIDFormat = nameID.SelectAttrValue("Format", "")
+	}
+	if authn := el.SelectElement("AuthnStatement"); authn != nil {
+		a.SessionIndex = authn.SelectAttrValue("SessionIndex", "")
+	}
+	for _, attr := range el.FindElements("./AttributeStatement/Attribute") {
+		name := attr.SelectAttrValue("Name", "")
+		for _, v := range attr.SelectElements("AttributeValue") {
+			a.Attributes[name] = append(a.Attributes[name], text(v))
+		}
+	}
+
+	return a, nil
+}
+
+// ParseLogoutResponse checks the signature of the LogoutResponse in the redirect URL and returns
+// whether it reports success.
+func (sp *ServiceProvider) ParseLogoutResponse(location string, idpCert *x509.Certificate) (bool, error) {
+	r, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return false, err
+	}
+	m, err := saml.ReadMessage(r)
+	if err != nil {
+		return false, err
+	}
+	if !m.Response {
+		return false, errors.New("not a response")
+	}
+	if m, err = m.Verify(idpCert); err != nil {
+		return false, err
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(m.XML); err != nil {
+		return false, err
+	}
+	status := doc.FindElement("/LogoutResponse/Status/StatusCode")
+	if status == nil {
+		return false, errors.New("logout response has no status")
+	}
+
+	return strings.HasSuffix(status.SelectAttrValue("Value", ""), ":Success"), nil
+}
+
+func text(el *etree.Element) string {
+	if el == nil {
+		return ""
+	}
+	return strings.TrimSpace(el.Text())
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return "_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,385 @@
+package scim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter is a parsed filter expression: one of *Compare, *Logical, *Not or *ValuePath.
+type Filter interface {
+	isFilter()
+}
+
+// Compare is "attr op value", or "attr pr" with a nil Value. Attr is lower-cased and stripped of
+// its schema URN; Value is a string, bool, float64 or nil.
+type Compare struct {
+	Attr  string
+	Op    string
+	Value any
+}
+
+// Logical joins two filters with "and" or "or".
+type Logical struct {
+	Op          string
+	Left, Right Filter
+}
+
+type Not struct {
+	Filter Filter
+}
+
+// ValuePath is "attr[filter]": it matches when an element of the multi-valued attr matches Filter.
+type ValuePath struct {
+	Attr   string
+	Filter Filter
+}
+
+func (*Compare) isFilter()   {}
+func (*Logical) isFilter()   {}
+func (*Not) isFilter()       {}
+func (*ValuePath) isFilter() {}
+
+var compareOps = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true,
+}
+
+// ParseFilter parses the filter query parameter. Errors are *Error with type invalidFilter.
+func ParseFilter(s string) (Filter, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	f, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, BadRequest(ErrorInvalidFilter, "unexpected %q", p.peek().text)
+	}
+	return f, nil
+}
+
+// AttrName lower-cases attr and strips the schema URN it may be qualified with.
+func AttrName(attr string) string {
+	return strings.ToLower(stripURN(attr))
+}
+
+func stripURN(attr string) string {
+	if strings.HasPrefix(strings.ToLower(attr), "urn:") {
+		if i := strings.LastIndex(attr, ":"); i >= 0 {
+			return attr[i+1:]
+		}
+	}
+	return attr
+}
+
+type token struct {
+	text string
+	// quoted is set for string literals, whose text is already unescaped.
+	quoted bool
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.IndexByte("()[]", c) >= 0:
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, BadRequest(ErrorInvalidFilter, "unterminated string")
+			}
+			var text string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &text); err != nil {
+				return nil, BadRequest(ErrorInvalidFilter, "invalid string %s", s[i:end+1])
+			}
+			tokens = append(tokens, token{text: text, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(s) && !unicode.IsSpace(rune(s[end])) && strings.IndexByte(`()[]"`, s[end]) < 0 {
+				end++
+			}
+			tokens = append(tokens, token{text: s[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	if !t.quoted && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if t := p.peek(); t.quoted || t.text != text {
+		return BadRequest(ErrorInvalidFilter, "expected %q", text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) or() (Filter, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "or", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Filter, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "and", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (Filter, error) {
+	if p.keyword("not") {
+		f, err := p.group()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Filter: f}, nil
+	}
+	if t := p.peek(); !t.quoted && t.text == "(" {
+		return p.group()
+	}
+	return p.attrExpr()
+}
+
+func (p *parser) group() (Filter, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	f, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (p *parser) attrExpr() (Filter, error) {
+	t := p.peek()
+	if p.done() || t.quoted || strings.ContainsAny(t.text, "()[]") {
+		return nil, BadRequest(ErrorInvalidFilter, "expected an attribute")
+	}
+	p.pos++
+	attr := AttrName(t.text)
+
+	if next := p.peek(); !next.quoted && next.text == "[" {
+		p.pos++
+		f, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &ValuePath{Attr: attr, Filter: f}, nil
+	}
+
+	if p.keyword("pr") {
+		return &Compare{Attr: attr, Op: "pr"}, nil
+	}
+
+	op := strings.ToLower(p.peek().text)
+	if p.peek().quoted || !compareOps[op] {
+		return nil, BadRequest(ErrorInvalidFilter, "expected an operator after %q", t.text)
+	}
+	p.pos++
+
+	if p.done() {
+		return nil, BadRequest(ErrorInvalidFilter, "expected a value after %q", op)
+	}
+	v := p.peek()
+	p.pos++
+	if v.quoted {
+		return &Compare{Attr: attr, Op: op, Value: v.text}, nil
+	}
+	switch v.text {
+	case "true":
+		return &Compare{Attr: attr, Op: op, Value: true}, nil
+	case "false":
+		return &Compare{Attr: attr, Op: op, Value: false}, nil
+	case "null":
+		return &Compare{Attr: attr, Op: op}, nil
+	}
+	n, err := strconv.ParseFloat(v.text, 64)
+	if err != nil {
+		return nil, BadRequest(ErrorInvalidFilter, "invalid value %q", v.text)
+	}
+	return &Compare{Attr: attr, Op: op, Value: n}, nil
+}
+
+// Match evaluates f against a decoded JSON object, such as an element of a multi-valued
+// attribute. Strings compare case-insensitively.
+func Match(f Filter, obj map[string]any) bool {
+	switch f := f.(type) {
+	case *Logical:
+		if f.Op == "and" {
+			return Match(f.Left, obj) && Match(f.Right, obj)
+		}
+		return Match(f.Left, obj) || Match(f.Right, obj)
+	case *Not:
+		return !Match(f.Filter, obj)
+	case *ValuePath:
+		items, _ := lookup(obj, f.Attr).([]any)
+		for _, item := range items {
+			if m, ok := item.(map[string]any); ok && Match(f.Filter, m) {
+				return true
+			}
+		}
+		return false
+	case *Compare:
+		return compare(lookup(obj, f.Attr), f.Op, f.Value)
+	}
+	return false
+}
+
+// lookup resolves a dotted, lower-cased attribute path in obj, whose keys may be in any case.
+func lookup(obj map[string]any, attr string) any {
+	var cur any = obj
+	for _, part := range strings.Split(attr, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = nil
+		for k, v := range m {
+			if strings.EqualFold(k, part) {
+				cur = v
+				break
+			}
+		}
+	}
+	return cur
+}
+
+func compare(got any, op string, want any) bool {
+	if op == "pr" {
+		switch got := got.(type) {
+		case nil:
+			return false
+		case string:
+			return got != ""
+		case []any:
+			return len(got) > 0
+		}
+		return true
+	}
+
+	switch got := got.(type) {
+	case string:
+		want, ok := want.(string)
+		if !ok {
+			return op == "ne"
+		}
+		a, b := strings.ToLower(got), strings.ToLower(want)
+		switch op {
+		case "eq":
+			return a == b
+		case "ne":
+			return a != b
+		case "co":
+			return strings.Contains(a, b)
+		case "sw":
+			return strings.HasPrefix(a, b)
+		case "ew":
+			return strings.HasSuffix(a, b)
+		case "gt":
+			return a > b
+		case "ge":
+			return a >= b
+		case "lt":
+			return a < b
+		case "le":
+			return a <= b
+		}
+	case bool:
+		want, ok := want.(bool)
+		switch op {
+		case "eq":
+			return ok && got == want
+		case "ne":
+			return !ok || got != want
+		}
+	case float64:
+		want, ok := want.(float64)
+		if !ok {
+			return op == "ne"
+		}
+		switch op {
+		case "eq":
+			return got == want
+		case "ne":
+			return got != want
+		case "gt":
+			return got > want
+		case "ge":
+			return got >= want
+		case "lt":
+			return got < want
+		case "le":
+			return got <= want
+		}
+	case nil:
+		return (op == "eq" && want == nil) || (op == "ne" && want != nil)
+	}
+	return false
+}
@@ -0,0 +1,298 @@
+package scim
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Path is a PATCH target: attr, attr.sub, attr[filter] or attr[filter].sub. Attr and Sub keep
+// the case the client used.
+type Path struct {
+	Attr   string
+	Sub    string
+	Filter Filter
+}
+
+// ParsePath parses the path of a PATCH operation. Errors are *Error with type invalidPath.
+func ParsePath(s string) (Path, error) {
+	if open := strings.IndexByte(s, '['); open >= 0 {
+		end := strings.LastIndexByte(s, ']')
+		if end < open {
+			return Path{}, BadRequest(ErrorInvalidPath, "unbalanced brackets in %q", s)
+		}
+		f, err := ParseFilter(s[open+1 : end])
+		if err != nil {
+			return Path{}, BadRequest(ErrorInvalidPath, "invalid filter in %q", s)
+		}
+		p := Path{Attr: stripURN(s[:open]), Filter: f}
+		if rest := s[end+1:]; rest != "" {
+			if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+				return Path{}, BadRequest(ErrorInvalidPath, "invalid path %q", s)
+			}
+			p.Sub = rest[1:]
+		}
+		return p, nil
+	}
+
+	name := stripURN(s)
+	if name == "" || strings.ContainsAny(name, " ]\"") {
+		return Path{}, BadRequest(ErrorInvalidPath, "invalid path %q", s)
+	}
+	attr, sub, _ := strings.Cut(name, ".")
+	return Path{Attr: attr, Sub: sub}, nil
+}
+
+// ApplyPatch applies PATCH operations to a resource decoded into a JSON object. Attribute names
+// compare case-insensitively; unknown attributes are kept and left for the caller to ignore.
+func ApplyPatch(obj map[string]any, ops []PatchOperation) error {
+	for _, op := range ops {
+		if err := applyOp(obj, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyOp(obj map[string]any, op PatchOperation) error {
+	var value any
+	if len(op.Value) > 0 {
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return BadRequest(ErrorInvalidValue, "value of %s %q is not valid JSON", op.Op, op.Path)
+		}
+	}
+
+	kind := strings.ToLower(op.Op)
+	switch kind {
+	case "add", "replace":
+		if op.Path == "" {
+			attrs, ok := value.(map[string]any)
+			if !ok {
+				return BadRequest(ErrorInvalidValue, "%s without a path takes an object", op.Op)
+			}
+			for name, v := range attrs {
+				if strings.EqualFold(name, "schemas") {
+					continue
+				}
+				path, err := ParsePath(name)
+				if err != nil {
+					return err
+				}
+				if err := set(obj, path, v, kind == "add"); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		path, err := ParsePath(op.Path)
+		if err != nil {
+			return err
+		}
+		return set(obj, path, value, kind == "add")
+	case "remove":
+		if op.Path == "" {
+			return BadRequest(ErrorNoTarget, "remove requires a path")
+		}
+		path, err := ParsePath(op.Path)
+		if err != nil {
+			return err
+		}
+		remove(obj, path, value)
+		return nil
+	}
+	return BadRequest(ErrorInvalidSyntax, "unknown operation %q", op.Op)
+}
+
+func set(obj map[string]any, path Path, value any, add bool) error {
+	key := keyOf(obj, path.Attr)
+
+	if path.Filter != nil {
+		items, _ := obj[key].([]any)
+		matched := false
+		for i, item := range items {
+			m, ok := item.(map[string]any)
+			if !ok || !Match(path.Filter, m) {
+				continue
+			}
+			matched = true
+			items[i] = setElement(m, path.Sub, value)
+		}
+		if !matched {
+			// Clients address typed values, such as emails[type eq "work"].value, whether or not
+			// the element exists yet.
+			m, ok := template(path.Filter)
+			if !ok {
+				return BadRequest(ErrorNoTarget, "no %s element matches the filter", path.Attr)
+			}
+			items = append(items, setElement(m, path.Sub, value))
+		}
+		obj[key] = items
+		return nil
+	}
+
+	if path.Sub != "" {
+		m, _ := obj[key].(map[string]any)
+		if m == nil {
+			m = map[string]any{}
+		}
+		m[keyOf(m, path.Sub)] = value
+		obj[key] = m
+		return nil
+	}
+
+	switch existing := obj[key].(type) {
+	case []any:
+		if add {
+			obj[key] = appendUnique(existing, value)
+			return nil
+		}
+	case map[string]any:
+		if v, ok := value.(map[string]any); ok {
+			for k, sub := range v {
+				existing[keyOf(existing, k)] = sub
+			}
+			return nil
+		}
+	}
+	obj[key] = value
+	return nil
+}
+
+func setElement(m map[string]any, sub string, value any) any {
+	if sub != "" {
+		m[keyOf(m, sub)] = value
+		return m
+	}
+	if v, ok := value.(map[string]any); ok {
+		for k, x := range v {
+			m[keyOf(m, k)] = x
+		}
+		return m
+	}
+	return value
+}
+
+// template builds the element a filter of "eq" comparisons joined by "and" describes.
+func template(f Filter) (map[string]any, bool) {
+	switch f := f.(type) {
+	case *Compare:
+		if f.Op != "eq" || strings.Contains(f.Attr, ".") {
+			return nil, false
+		}
+		return map[string]any{f.Attr: f.Value}, true
+	case *Logical:
+		if f.Op != "and" {
+			return nil, false
+		}
+		left, ok := template(f.Left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := template(f.Right)
+		if !ok {
+			return nil, false
+		}
+		for k, v := range right {
+			left[k] = v
+		}
+		return left, true
+	}
+	return nil, false
+}
+
+func remove(obj map[string]any, path Path, value any) {
+	key := keyOf(obj, path.Attr)
+
+	items, multi := obj[key].([]any)
+	switch {
+	case path.Filter != nil:
+		kept := items[:0]
+		for _, item := range items {
+			m, ok := item.(map[string]any)
+			if !ok || !Match(path.Filter, m) {
+				kept = append(kept, item)
+				continue
+			}
+			if path.Sub != "" {
+				delete(m, keyOf(m, path.Sub))
+				kept = append(kept, m)
+			}
+		}
+		obj[key] = kept
+	case path.Sub != "":
+		if multi {
+			for _, item := range items {
+				if m, ok := item.(map[string]any); ok {
+					delete(m, keyOf(m, path.Sub))
+				}
+			}
+		} else if m, ok := obj[key].(map[string]any); ok {
+			delete(m, keyOf(m, path.Sub))
+		}
+	case multi && value != nil:
+		// Some clients name the elements to remove in the value instead of a filter.
+		drop, ok := value.([]any)
+		if !ok {
+			drop = []any{value}
+		}
+		kept := items[:0]
+		for _, item := range items {
+			if !containsValue(drop, item) {
+				kept = append(kept, item)
+			}
+		}
+		obj[key] = kept
+	default:
+		delete(obj, key)
+	}
+}
+
+func appendUnique(items []any, value any) []any {
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	}
+	for _, v := range values {
+		if !containsValue(items, v) {
+			items = append(items, v)
+		}
+	}
+	return items
+}
+
+// containsValue reports whether items holds v, comparing elements by their "value" sub-attribute
+// when both have one.
+func containsValue(items []any, v any) bool {
+	want, hasValue := elementValue(v)
+	for _, item := range items {
+		if got, ok := elementValue(item); ok && hasValue {
+			if reflect.DeepEqual(got, want) {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func elementValue(v any) (any, bool) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	value, ok := m[keyOf(m, "value")]
+	return value, ok
+}
+
+// keyOf returns the key of obj that names attr, or attr itself when there is none.
+func keyOf(obj map[string]any, attr string) string {
+	for k := range obj {
+		if strings.EqualFold(k, attr) {
+			return k
+		}
+	}
+	return attr
+}
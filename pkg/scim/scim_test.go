@@ -0,0 +1,184 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"auth/pkg/scim"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := scim.ParseFilter(`userName eq "ann@example.com"`)
+	require.NoError(t, err)
+	assert.Equal(t, &scim.Compare{Attr: "username", Op: "eq", Value: "ann@example.com"}, f)
+
+	f, err = scim.ParseFilter(`urn:ietf:params:scim:schemas:core:2.0:User:name.givenName sw "A" and (active eq true or not (title pr))`)
+	require.NoError(t, err)
+	assert.Equal(t, &scim.Logical{
+		Op:   "and",
+		Left: &scim.Compare{Attr: "name.givenname", Op: "sw", Value: "A"},
+		Right: &scim.Logical{
+			Op:    "or",
+			Left:  &scim.Compare{Attr: "active", Op: "eq", Value: true},
+			Right: &scim.Not{Filter: &scim.Compare{Attr: "title", Op: "pr"}},
+		},
+	}, f)
+
+	f, err = scim.ParseFilter(`emails[type eq "work" and value co "@example.com"]`)
+	require.NoError(t, err)
+	vp, ok := f.(*scim.ValuePath)
+	require.True(t, ok)
+	assert.Equal(t, "emails", vp.Attr)
+
+	f, err = scim.ParseFilter(`meta.version gt 3 OR externalId eq "a \"quoted\" id"`)
+	require.NoError(t, err)
+	assert.Equal(t, &scim.Compare{Attr: "externalid", Op: "eq", Value: `a "quoted" id`}, f.(*scim.Logical).Right)
+	assert.Equal(t, 3.0, f.(*scim.Logical).Left.(*scim.Compare).Value)
+
+	for _, bad := range []string{
+		`userName`,
+		`userName eq`,
+		`userName like "a"`,
+		`userName eq "a`,
+		`(userName eq "a"`,
+		`userName eq "a" extra`,
+		`emails[type eq "work"`,
+		`userName eq bare`,
+	} {
+		_, err := scim.ParseFilter(bad)
+		var serr *scim.Error
+		require.ErrorAs(t, err, &serr, bad)
+		assert.Equal(t, scim.ErrorInvalidFilter, serr.Type, bad)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	obj := map[string]any{
+		"value":   "Ann@Example.com",
+		"type":    "work",
+		"primary": true,
+		"emails":  []any{map[string]any{"value": "a@x.com", "type": "home"}},
+	}
+
+	for filter, want := range map[string]bool{
+		`value eq "ann@example.com"`:                 true,
+		`value ew "@example.com" and type eq "work"`: true,
+		`type eq "home" or primary eq false`:         false,
+		`not (type eq "home")`:                       true,
+		`display pr`:                                 false,
+		`emails[type eq "home"]`:                     true,
+		`emails[type eq "work"]`:                     false,
+	} {
+		f, err := scim.ParseFilter(filter)
+		require.NoError(t, err, filter)
+		assert.Equal(t, want, scim.Match(f, obj), filter)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	p, err := scim.ParsePath("name.givenName")
+	require.NoError(t, err)
+	assert.Equal(t, scim.Path{Attr: "name", Sub: "givenName"}, p)
+
+	p, err = scim.ParsePath(`emails[type eq "work"].value`)
+	require.NoError(t, err)
+	assert.Equal(t, "emails", p.Attr)
+	assert.Equal(t, "value", p.Sub)
+	assert.NotNil(t, p.Filter)
+
+	_, err = scim.ParsePath(`members[value eq "1"`)
+	assert.Error(t, err)
+	_, err = scim.ParsePath(`members[value eq "1"]x`)
+	assert.Error(t, err)
+}
+
+func patch(t *testing.T, obj map[string]any, ops string) error {
+	t.Helper()
+	var parsed []scim.PatchOperation
+	require.NoError(t, json.Unmarshal([]byte(ops), &parsed))
+	return scim.ApplyPatch(obj, parsed)
+}
+
+func TestApplyPatch(t *testing.T) {
+	t.Run("user attributes", func(t *testing.T) {
+		obj := map[string]any{
+			"userName": "ann@example.com",
+			"active":   true,
+			"name":     map[string]any{"givenName": "Ann", "familyName": "Lee"},
+			"emails":   []any{map[string]any{"value": "ann@example.com", "type": "work"}},
+		}
+
+		require.NoError(t, patch(t, obj, `[
+			{"op": "Replace", "path": "active", "value": false},
+			{"op": "replace", "path": "name.familyName", "value": "Park"},
+			{"op": "replace", "value": {"displayName": "Ann Park", "name.givenName": "Anna"}},
+			{"op": "add", "path": "emails[type eq \"home\"].value", "value": "ann@home.example"},
+			{"op": "remove", "path": "emails[type eq \"work\"]"}
+		]`))
+
+		assert.Equal(t, false, obj["active"])
+		assert.Equal(t, map[string]any{"givenName": "Anna", "familyName": "Park"}, obj["name"])
+		assert.Equal(t, "Ann Park", obj["displayName"])
+		assert.Equal(t, []any{map[string]any{"type": "home", "value": "ann@home.example"}}, obj["emails"])
+	})
+
+	t.Run("group members", func(t *testing.T) {
+		obj := map[string]any{
+			"displayName": "Eng",
+			"members":     []any{map[string]any{"value": "1"}, map[string]any{"value": "2"}},
+		}
+
+		require.NoError(t, patch(t, obj, `[
+			{"op": "add", "path": "members", "value": [{"value": "2"}, {"value": "3"}]},
+			{"op": "remove", "path": "members[value eq \"1\"]"}
+		]`))
+		assert.Equal(t, []any{map[string]any{"value": "2"}, map[string]any{"value": "3"}}, obj["members"])
+
+		// Some clients name the members to remove in the value.
+		require.NoError(t, patch(t, obj, `[{"op": "remove", "path": "members", "value": [{"value": "3"}]}]`))
+		assert.Equal(t, []any{map[string]any{"value": "2"}}, obj["members"])
+
+		require.NoError(t, patch(t, obj, `[{"op": "replace", "path": "members", "value": []}]`))
+		assert.Empty(t, obj["members"])
+
+		require.NoError(t, patch(t, obj, `[{"op": "remove", "path": "displayName"}]`))
+		assert.NotContains(t, obj, "displayName")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, ops := range []string{
+			`[{"op": "move", "path": "active"}]`,
+			`[{"op": "remove"}]`,
+			`[{"op": "add", "value": "not an object"}]`,
+			`[{"op": "replace", "path": "emails[type eq \"work\" or type eq \"home\"].value", "value": "x"}]`,
+		} {
+			var serr *scim.Error
+			assert.ErrorAs(t, patch(t, map[string]any{}, ops), &serr, ops)
+		}
+	})
+}
+
+func TestMatchETag(t *testing.T) {
+	tag := scim.ETag(3)
+	assert.Equal(t, `W/"3"`, tag)
+
+	assert.True(t, scim.MatchETag(`W/"3"`, tag))
+	assert.True(t, scim.MatchETag(`"3"`, tag))
+	assert.True(t, scim.MatchETag(`W/"1", W/"3"`, tag))
+	assert.True(t, scim.MatchETag(`*`, tag))
+	assert.False(t, scim.MatchETag(`W/"4"`, tag))
+}
+
+func TestErrorJSON(t *testing.T) {
+	out, err := json.Marshal(scim.BadRequest(scim.ErrorInvalidFilter, "bad %s", "filter"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+		"status": "400",
+		"scimType": "invalidFilter",
+		"detail": "bad filter"
+	}`, string(out))
+}
@@ -0,0 +1,68 @@
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const KeySize = 32
+
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// Box encrypts small secrets with AES-256-GCM. Ciphertexts carry their random nonce as a prefix.
+type Box struct {
+	aead cipher.AEAD
+}
+
+func New(key []byte) (*Box, error) {
+	const op = "secretbox.New"
+
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%s: key must be %d bytes, got %d", op, KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Box{aead: aead}, nil
+}
+
+// NewFromBase64 accepts the key in standard base64, as it is kept in the environment.
+func NewFromBase64(key string) (*Box, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("secretbox.NewFromBase64: %w", err)
+	}
+	return New(raw)
+}
+
+func (b *Box) Seal(plaintext []byte) []byte {
+	nonce := make([]byte, b.aead.NonceSize(), b.aead.NonceSize()+len(plaintext)+b.aead.Overhead())
+	_, _ = rand.Read(nonce)
+	return b.aead.Seal(nonce, nonce, plaintext, nil)
+}
+
+func (b *Box) Open(ciphertext []byte) ([]byte, error) {
+	n := b.aead.NonceSize()
+	if len(ciphertext) < n {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plaintext, err := b.aead.Open(nil, ciphertext[:n], ciphertext[n:], nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return plaintext, nil
+}
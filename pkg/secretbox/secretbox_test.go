@@ -0,0 +1,38 @@
+package secretbox_test
+
+import (
+	"bytes"
+	"testing"
+
+	"auth/pkg/secretbox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBox(t *testing.T) {
+	box, err := secretbox.New(bytes.Repeat([]byte{1}, secretbox.KeySize))
+	require.NoError(t, err)
+
+	t.Run("round trip", func(t *testing.T) {
+		sealed := box.Seal([]byte("app secret"))
+		assert.NotContains(t, string(sealed), "app secret")
+
+		opened, err := box.Open(sealed)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("app secret"), opened)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		sealed := box.Seal([]byte("app secret"))
+		sealed[len(sealed)-1] ^= 0xff
+
+		_, err := box.Open(sealed)
+		assert.ErrorIs(t, err, secretbox.ErrInvalidCiphertext)
+	})
+
+	t.Run("wrong key size", func(t *testing.T) {
+		_, err := secretbox.New([]byte("short"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,92 @@
+// Package sms sends text messages. Providers are plugged in by implementing the senders'
+// Send method; the package ships senders that only log or keep messages.
+package sms
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var ErrInvalidNumber = errors.New("phone number must be in E.164 format")
+
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// Normalize returns the E.164 form of a phone number written with an international prefix,
+// dropping the spaces, dashes, dots and parentheses people format numbers with.
+func Normalize(phone string) (string, error) {
+	phone = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+
+	if !e164.MatchString(phone) {
+		return "", ErrInvalidNumber
+	}
+
+	return phone, nil
+}
+
+// Prefix returns the leading digits of an E.164 number, the country code included, with the
+// plus sign. Numbers with no more digits are returned whole.
+func Prefix(phone string, digits int) string {
+	if len(phone) <= digits+1 {
+		return phone
+	}
+	return phone[:digits+1]
+}
+
+type Message struct {
+	// To is in E.164 format.
+	To   string
+	Text string
+}
+
+// LogSender writes messages to the log instead of sending them, for development.
+type LogSender struct {
+	log *slog.Logger
+}
+
+func NewLogSender(log *slog.Logger) *LogSender {
+	return &LogSender{log: log}
+}
+
+func (s *LogSender) Send(_ context.Context, msg Message) error {
+	s.log.Info("text message not sent, no SMS provider is configured",
+		slog.String("to", msg.To),
+		slog.String("text", msg.Text),
+	)
+	return nil
+}
+
+// MemorySender keeps the messages it is given, for tests.
+type MemorySender struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(_ context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+// Sent returns the messages sent so far, oldest first.
+func (s *MemorySender) Sent() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.sent...)
+}
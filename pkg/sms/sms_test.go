@@ -0,0 +1,50 @@
+package sms_test
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"auth/pkg/sms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"+447911123456":     "+447911123456",
+		" +44 7911 123456 ": "+447911123456",
+		"+1 (415) 555-0132": "+14155550132",
+		"+49.30.1234567":    "+49301234567",
+		"+123456789012345":  "+123456789012345",
+	} {
+		got, err := sms.Normalize(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got)
+	}
+
+	for _, in := range []string{"", "07911123456", "+0447911123456", "+44791112345a", "+12345", "+1234567890123456", "++447911123456"} {
+		_, err := sms.Normalize(in)
+		assert.ErrorIs(t, err, sms.ErrInvalidNumber, in)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	assert.Equal(t, "+44791", sms.Prefix("+447911123456", 5))
+	assert.Equal(t, "+1234567", sms.Prefix("+1234567", 8))
+}
+
+func TestSenders(t *testing.T) {
+	ctx := context.Background()
+	msg := sms.Message{To: "+447911123456", Text: "Your code is 123456"}
+
+	mem := sms.NewMemorySender()
+	require.NoError(t, mem.Send(ctx, msg))
+	assert.Equal(t, []sms.Message{msg}, mem.Sent())
+
+	var out strings.Builder
+	require.NoError(t, sms.NewLogSender(slog.New(slog.NewTextHandler(&out, nil))).Send(ctx, msg))
+	assert.Contains(t, out.String(), "+447911123456")
+}